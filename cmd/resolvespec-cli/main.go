@@ -0,0 +1,286 @@
+// Command resolvespec-cli queries and edits the entities of a running RestHeadSpec server.
+//
+//	resolvespec-cli list public.users -filter status=active -filter "age:gt:30" -sort -created_at -limit 10
+//	resolvespec-cli get public.users 42 -columns id,name -output json
+//	resolvespec-cli create public.users -data '{"name":"Jane"}'
+//	resolvespec-cli update public.users 42 -data @user.json
+//	resolvespec-cli delete public.users 42
+//
+// The server is given by -url or RESOLVESPEC_URL, a bearer token by -token or RESOLVESPEC_TOKEN.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/client"
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type record = map[string]interface{}
+
+const usage = `usage: resolvespec-cli <command> [schema.]entity [id] [flags]
+
+commands:
+  list    list the records matching -filter, -sort, -limit and -offset
+  get     show the record with the given id
+  create  insert the record given by -data
+  update  set the columns given by -data on the record with the given id
+  delete  delete the record with the given id
+
+run "resolvespec-cli <command> -h" for the flags of a command`
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "resolvespec-cli:", err)
+		os.Exit(1)
+	}
+}
+
+// multiFlag collects the values of a flag given several times
+type multiFlag []string
+
+func (m *multiFlag) String() string { return strings.Join(*m, ", ") }
+
+func (m *multiFlag) Set(value string) error {
+	*m = append(*m, value)
+	return nil
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errors.New(usage)
+	}
+	command := args[0]
+
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	baseURL := flags.String("url", os.Getenv("RESOLVESPEC_URL"), "URL the RestHeadSpec routes are mounted on")
+	token := flags.String("token", os.Getenv("RESOLVESPEC_TOKEN"), "bearer token sent as Authorization")
+	var headers multiFlag
+	flags.Var(&headers, "header", `header sent with the request, "Name: value" (repeatable)`)
+	output := flags.String("output", "table", "output format: table, json or csv")
+	var filters multiFlag
+	flags.Var(&filters, "filter", `filter "column=value" or "column:operator:value" (repeatable)`)
+	sort := flags.String("sort", "", "sort columns, e.g. name,-created_at")
+	columns := flags.String("columns", "", "columns to return, e.g. id,name")
+	var preloads multiFlag
+	flags.Var(&preloads, "preload", `relation to load, "Relation" or "Relation:col1,col2" (repeatable)`)
+	limit := flags.Int("limit", 0, "maximum number of records")
+	offset := flags.Int("offset", 0, "number of records to skip")
+	data := flags.String("data", "", "JSON record for create and update, @file to read a file or - for stdin")
+
+	// Positional arguments come first, flags follow
+	var positional []string
+	rest := args[1:]
+	for len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		positional = append(positional, rest[0])
+		rest = rest[1:]
+	}
+	if err := flags.Parse(rest); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	positional = append(positional, flags.Args()...)
+
+	wantArgs := map[string]int{"list": 1, "create": 1, "get": 2, "update": 2, "delete": 2}
+	want, ok := wantArgs[command]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n%s", command, usage)
+	}
+	if len(positional) != want {
+		if want == 1 {
+			return fmt.Errorf("%s needs an entity", command)
+		}
+		return fmt.Errorf("%s needs an entity and an id", command)
+	}
+	if *baseURL == "" {
+		return errors.New("no server given, set -url or RESOLVESPEC_URL")
+	}
+	if *output != "table" && *output != "json" && *output != "csv" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	config := client.Config{BaseURL: *baseURL, Headers: make(map[string]string)}
+	if *token != "" {
+		config.Headers["Authorization"] = "Bearer " + *token
+	}
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			return fmt.Errorf("invalid header %q, want \"Name: value\"", header)
+		}
+		config.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	config.Schema, config.Entity = splitEntity(positional[0])
+	records := client.NewClient[record](config)
+
+	options := client.Query().Page(*limit, *offset)
+	if *columns != "" {
+		options.Select(splitList(*columns)...)
+	}
+	for _, filter := range filters {
+		option, err := parseFilter(filter)
+		if err != nil {
+			return err
+		}
+		options.Where(option)
+	}
+	for _, column := range splitList(*sort) {
+		if strings.HasPrefix(column, "-") {
+			options.OrderBy(client.Desc(column[1:]))
+		} else {
+			options.OrderBy(client.Asc(strings.TrimPrefix(column, "+")))
+		}
+	}
+	for _, preload := range preloads {
+		relation, cols, _ := strings.Cut(preload, ":")
+		options.With(client.Relation(relation, splitList(cols)...))
+	}
+
+	printer := &printer{out: stdout, format: *output, columns: options.Columns}
+	switch command {
+	case "list":
+		result, err := records.List(ctx, options)
+		if err != nil {
+			return err
+		}
+		return printer.records(result.Items, result.Metadata)
+	case "get":
+		found, err := records.Get(ctx, positional[1], options)
+		if err != nil {
+			return err
+		}
+		return printer.record(*found)
+	case "create":
+		body, err := readData(*data, stdin)
+		if err != nil {
+			return err
+		}
+		created, err := records.Create(ctx, body)
+		if err != nil {
+			return err
+		}
+		return printer.record(*created)
+	case "update":
+		body, err := readData(*data, stdin)
+		if err != nil {
+			return err
+		}
+		updated, err := records.Patch(ctx, positional[1], body)
+		if err != nil {
+			return err
+		}
+		return printer.record(*updated)
+	default:
+		deleted, err := records.Delete(ctx, positional[1])
+		if err != nil {
+			return err
+		}
+		return printer.record(record{"deleted": deleted})
+	}
+}
+
+// splitEntity splits "schema.entity" into its parts; a name without a dot has no schema
+func splitEntity(name string) (string, string) {
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		return name[:idx], name[idx+1:]
+	}
+	return "", name
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseFilter parses "column=value" as eq and "column:operator:value" as any operator. The values
+// of in, between and between_inclusive are separated by commas; is_null and is_not_null take none.
+func parseFilter(filter string) (common.FilterOption, error) {
+	if column, value, ok := strings.Cut(filter, "="); ok && !strings.Contains(column, ":") {
+		return client.Eq(strings.TrimSpace(column), parseValue(value)), nil
+	}
+	parts := strings.SplitN(filter, ":", 3)
+	if len(parts) < 2 || parts[0] == "" {
+		return common.FilterOption{}, fmt.Errorf("invalid filter %q, want column=value or column:operator:value", filter)
+	}
+	column, operator := strings.TrimSpace(parts[0]), strings.ToLower(strings.TrimSpace(parts[1]))
+	value := ""
+	if len(parts) == 3 {
+		value = parts[2]
+	}
+
+	switch operator {
+	case "is_null", "is_not_null":
+		return client.Filter(column, operator, nil), nil
+	case "in":
+		var values []interface{}
+		for _, item := range strings.Split(value, ",") {
+			values = append(values, parseValue(item))
+		}
+		return client.In(column, values...), nil
+	case "between", "between_inclusive":
+		from, to, ok := strings.Cut(value, ",")
+		if !ok {
+			return common.FilterOption{}, fmt.Errorf("filter %q needs two values, from,to", filter)
+		}
+		return client.Filter(column, operator, []interface{}{parseValue(from), parseValue(to)}), nil
+	}
+	if len(parts) < 3 {
+		return common.FilterOption{}, fmt.Errorf("filter %q needs a value", filter)
+	}
+	return client.Filter(column, operator, parseValue(value)), nil
+}
+
+// parseValue sends numbers and booleans as JSON numbers and booleans, anything else as a string.
+// Quotes keep a value a string, e.g. '007'.
+func parseValue(value string) interface{} {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '\'' || value[0] == '"') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return number
+	}
+	if flag, err := strconv.ParseBool(value); err == nil && (value == "true" || value == "false") {
+		return flag
+	}
+	return value
+}
+
+// readData reads the JSON object of -data: inline, @file or - for stdin
+func readData(data string, stdin io.Reader) (record, error) {
+	var raw []byte
+	var err error
+	switch {
+	case data == "":
+		return nil, errors.New("no record given, set -data")
+	case data == "-":
+		raw, err = io.ReadAll(stdin)
+	case strings.HasPrefix(data, "@"):
+		raw, err = os.ReadFile(data[1:])
+	default:
+		raw = []byte(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var body record
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, fmt.Errorf("invalid record: %w", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   common.FilterOption
+	}{
+		{"status=active", common.FilterOption{Column: "status", Operator: "eq", Value: "active"}},
+		{"age:gt:30", common.FilterOption{Column: "age", Operator: "gt", Value: 30.0}},
+		{"code='007'", common.FilterOption{Column: "code", Operator: "eq", Value: "007"}},
+		{"url:eq:http://x", common.FilterOption{Column: "url", Operator: "eq", Value: "http://x"}},
+		{"role:in:admin,owner", common.FilterOption{Column: "role", Operator: "in", Value: []interface{}{"admin", "owner"}}},
+		{"score:between:1,5", common.FilterOption{Column: "score", Operator: "between", Value: []interface{}{1.0, 5.0}}},
+		{"deleted_at:is_null", common.FilterOption{Column: "deleted_at", Operator: "is_null"}},
+		{"active:EQ:true", common.FilterOption{Column: "active", Operator: "eq", Value: true}},
+	}
+	for _, tt := range tests {
+		got, err := parseFilter(tt.filter)
+		require.NoError(t, err, tt.filter)
+		assert.Equal(t, tt.want, got, tt.filter)
+	}
+
+	for _, invalid := range []string{"status", "age:gt", "score:between:1", ":eq:1"} {
+		_, err := parseFilter(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestRun(t *testing.T) {
+	var lastRequest *http.Request
+	var lastBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastRequest = r
+		lastBody, _ = io.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/public/users":
+			_, _ = w.Write([]byte(`{"success":true,"data":[{"id":1,"name":"Jane","tags":["a"]},{"id":2,"name":"John, Jr.","tags":null}],"metadata":{"total":12}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/public/users/1":
+			_, _ = w.Write([]byte(`{"success":true,"data":[{"id":1,"name":"Jane"}]}`))
+		case r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(`{"id":3,"name":"Jim"}`))
+		case r.Method == http.MethodPatch:
+			_, _ = w.Write([]byte(`{"id":1,"name":"Janet"}`))
+		case r.Method == http.MethodDelete:
+			_, _ = w.Write([]byte(`{"deleted":1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"success":false,"error":{"code":"not_found","message":"Entity not found"}}`))
+		}
+	}))
+	defer server.Close()
+
+	cli := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		args = append(args, "-url", server.URL+"/api", "-token", "secret")
+		err := run(context.Background(), args, strings.NewReader(`{"name":"Jim"}`), &out)
+		return out.String(), err
+	}
+
+	out, err := cli("list", "public.users", "-filter", "status=active", "-sort", "-created_at", "-limit", "2", "-header", "X-Tenant: 7")
+	require.NoError(t, err)
+	assert.Equal(t, "id  name       tags\n1   Jane       [\"a\"]\n2   John, Jr.  \n(2 of 12 records)\n", out)
+	assert.Equal(t, "Bearer secret", lastRequest.Header.Get("Authorization"))
+	assert.Equal(t, "7", lastRequest.Header.Get("X-Tenant"))
+	assert.Equal(t, "-created_at", lastRequest.Header.Get("X-Sort"))
+	assert.Equal(t, "2", lastRequest.Header.Get("X-Limit"))
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(lastRequest.Header.Get("X-Filter-Json"), "__"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"column":"status"`)
+
+	out, err = cli("list", "public.users", "-columns", "name,id", "-output", "csv")
+	require.NoError(t, err)
+	assert.Equal(t, "name,id\nJane,1\n\"John, Jr.\",2\n", out)
+
+	out, err = cli("get", "public.users", "1", "-output", "json")
+	require.NoError(t, err)
+	var user map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(out), &user))
+	assert.Equal(t, "Jane", user["name"])
+
+	out, err = cli("create", "public.users", "-data", "-")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"Jim"}`, string(lastBody))
+	assert.Equal(t, "id  name\n3   Jim\n", out)
+
+	_, err = cli("update", "public.users", "1", "-data", `{"name":"Janet"}`)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPatch, lastRequest.Method)
+	assert.Equal(t, "/api/public/users/1", lastRequest.URL.Path)
+
+	out, err = cli("delete", "public.users", "1", "-output", "json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"deleted":1}`, out)
+
+	_, err = cli("get", "public.missing", "1")
+	assert.EqualError(t, err, "resolvespec: 404 Entity not found")
+
+	_, err = cli("get", "public.users")
+	assert.Error(t, err, "get needs an id")
+	_, err = cli("drop", "public.users")
+	assert.Error(t, err)
+	_, err = cli("create", "public.users")
+	assert.Error(t, err, "create needs -data")
+	_, err = cli("list", "public.users", "-output", "xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// printer writes records as an aligned table, JSON or CSV
+type printer struct {
+	out    io.Writer
+	format string
+	// columns orders the output; the columns of the records sorted by name when empty
+	columns []string
+}
+
+func (p *printer) record(item record) error {
+	if p.format == "json" {
+		return p.json(item)
+	}
+	return p.rows([]record{item})
+}
+
+func (p *printer) records(items []record, metadata *common.Metadata) error {
+	if p.format == "json" {
+		if items == nil {
+			items = []record{}
+		}
+		return p.json(items)
+	}
+	if err := p.rows(items); err != nil {
+		return err
+	}
+	if p.format == "table" && metadata != nil {
+		_, err := fmt.Fprintf(p.out, "(%d of %d records)\n", len(items), metadata.Total)
+		return err
+	}
+	return nil
+}
+
+func (p *printer) json(value interface{}) error {
+	encoder := json.NewEncoder(p.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func (p *printer) rows(items []record) error {
+	columns := p.columns
+	if len(columns) == 0 {
+		seen := make(map[string]bool)
+		for _, item := range items {
+			for column := range item {
+				if !seen[column] {
+					seen[column] = true
+					columns = append(columns, column)
+				}
+			}
+		}
+		sort.Strings(columns)
+	}
+
+	if p.format == "csv" {
+		writer := csv.NewWriter(p.out)
+		if err := writer.Write(columns); err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := writer.Write(cells(item, columns)); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	writer := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	writeRow(writer, columns)
+	for _, item := range items {
+		writeRow(writer, cells(item, columns))
+	}
+	return writer.Flush()
+}
+
+func writeRow(out io.Writer, row []string) {
+	for i, cell := range row {
+		if i > 0 {
+			fmt.Fprint(out, "\t")
+		}
+		fmt.Fprint(out, cell)
+	}
+	fmt.Fprintln(out)
+}
+
+// cells renders the columns of a record; relations and other nested values as compact JSON
+func cells(item record, columns []string) []string {
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		switch value := item[column].(type) {
+		case nil:
+			row[i] = ""
+		case string:
+			row[i] = value
+		case float64:
+			row[i] = strconv.FormatFloat(value, 'f', -1, 64)
+		case int64:
+			row[i] = strconv.FormatInt(value, 10)
+		case bool:
+			row[i] = strconv.FormatBool(value)
+		default:
+			data, err := json.Marshal(value)
+			if err != nil {
+				row[i] = fmt.Sprintf("%v", value)
+			} else {
+				row[i] = string(data)
+			}
+		}
+	}
+	return row
+}
@@ -0,0 +1,19 @@
+// Command resolvespec-gen emits TypeScript interfaces and a typed RestHeadSpec client, or Go
+// client accessors, for the registered models. It generates the test models; applications build
+// their own generator with their registry, see codegen.Main.
+//
+//	go run ./cmd/resolvespec-gen -out web/src/api.ts
+//	go run ./cmd/resolvespec-gen -lang go -package apiclient -out apiclient/apiclient.go
+package main
+
+import (
+	"github.com/bitechdev/ResolveSpec/pkg/codegen"
+	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
+	"github.com/bitechdev/ResolveSpec/pkg/testmodels"
+)
+
+func main() {
+	registry := modelregistry.NewModelRegistry()
+	testmodels.RegisterTestModels(registry)
+	codegen.Main(registry)
+}
@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/fixtures"
 	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/migrations"
 	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
 	"github.com/bitechdev/ResolveSpec/pkg/testmodels"
 
@@ -19,12 +25,25 @@ import (
 )
 
 func main() {
+	fixtureFiles := flag.String("fixtures", "", "comma-separated fixture files to load on start, e.g. cmd/testserver/fixtures.yaml")
+	migrationsDir := flag.String("migrations", "", "directory of SQL migrations, e.g. cmd/testserver/migrations; they replace AutoMigrate and pending ones are applied on start")
+	migrateCommand := flag.String("migrate", "", "run a migration command and exit: up, down, status or generate (needs -migrations)")
+	steps := flag.Int("steps", 1, "number of migrations -migrate down reverts")
+	migrationName := flag.String("name", "schema", "name of the migration -migrate generate writes")
+	drop := flag.Bool("drop", false, "let -migrate generate drop columns and indexes the models don't declare")
+	flag.Parse()
+
 	// Initialize logger
 	logger.Init(true)
 	logger.Info("ResolveSpec test server starting")
 
-	// Initialize database
-	db, err := initDB()
+	if *migrateCommand != "" && *migrationsDir == "" {
+		logger.Error("-migrate needs a -migrations directory")
+		os.Exit(2)
+	}
+
+	// Initialize database; migrations own the schema when they are given
+	db, err := initDB(*migrationsDir == "")
 	if err != nil {
 		logger.Error("Failed to initialize database: %+v", err)
 		os.Exit(1)
@@ -47,6 +66,34 @@ func main() {
 		handler.RegisterModel("public", modelNames[i], model)
 	}
 
+	if *migrationsDir != "" {
+		command := *migrateCommand
+		if command == "" {
+			command = "up"
+		}
+		options := migrateOptions{dir: *migrationsDir, steps: *steps, name: *migrationName, drop: *drop}
+		if err := migrate(context.Background(), database.NewGormAdapter(db), registry, command, options); err != nil {
+			logger.Error("Migration failed: %v", err)
+			os.Exit(1)
+		}
+		if *migrateCommand != "" {
+			return
+		}
+	} else if _, err := migrations.EnsureIndexes(context.Background(), database.NewGormAdapter(db), registry); err != nil {
+		// AutoMigrate doesn't create the indexes of resolvespec tags and Indexes methods
+		logger.Error("Failed to create indexes: %v", err)
+		os.Exit(1)
+	}
+
+	// Load sample data
+	if *fixtureFiles != "" {
+		loader := fixtures.NewLoader(database.NewGormAdapter(db), registry, handler)
+		if _, err := loader.LoadFiles(context.Background(), strings.Split(*fixtureFiles, ",")...); err != nil {
+			logger.Error("Failed to load fixtures: %v", err)
+			os.Exit(1)
+		}
+	}
+
 	// Setup routes using new SetupMuxRoutes function
 	resolvespec.SetupMuxRoutes(r, handler)
 
@@ -58,7 +105,7 @@ func main() {
 	}
 }
 
-func initDB() (*gorm.DB, error) {
+func initDB(autoMigrate bool) (*gorm.DB, error) {
 
 	newLogger := gormlog.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags), // io writer
@@ -77,6 +124,10 @@ func initDB() (*gorm.DB, error) {
 		return nil, err
 	}
 
+	if !autoMigrate {
+		return db, nil
+	}
+
 	modelList := testmodels.GetTestModels()
 
 	// Auto migrate schemas
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/migrations"
+)
+
+type migrateOptions struct {
+	dir   string
+	steps int
+	name  string
+	drop  bool
+}
+
+// migrate runs a migration command against the migrations directory
+func migrate(ctx context.Context, db common.Database, registry common.ModelRegistry, command string, options migrateOptions) error {
+	migrator := migrations.NewMigrator(db, os.DirFS(options.dir))
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		logger.Info("Applied %d migrations", len(applied))
+		return err
+	case "down":
+		reverted, err := migrator.Down(ctx, options.steps)
+		logger.Info("Reverted %d migrations", len(reverted))
+		return err
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+		for _, entry := range status {
+			state := "pending"
+			switch {
+			case entry.Missing:
+				state = "applied, file missing"
+			case entry.Applied:
+				state = "applied " + entry.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Version, entry.Name, state)
+		}
+		return w.Flush()
+	case "generate":
+		// Pending migrations first, so only the changes since the last migration are generated
+		if _, err := migrator.Up(ctx); err != nil {
+			return err
+		}
+		migration, err := migrations.Generate(ctx, db, registry, options.dir, options.name, migrations.DiffOptions{Drop: options.drop})
+		if err != nil {
+			return err
+		}
+		if migration == nil {
+			logger.Info("The database matches the models, no migration generated")
+			return nil
+		}
+		logger.Info("Generated migration %s_%s in %s", migration.Version, migration.Name, options.dir)
+		return nil
+	default:
+		return fmt.Errorf("unknown migration command %q, use up, down, status or generate", command)
+	}
+}
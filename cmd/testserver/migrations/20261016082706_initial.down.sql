@@ -0,0 +1,19 @@
+-- Generated from the registered models; review before applying
+
+-- revert create_table projects
+DROP TABLE "projects";
+
+-- revert create_table project_tasks
+DROP TABLE "project_tasks";
+
+-- revert create_table employees
+DROP TABLE "employees";
+
+-- revert create_table documents
+DROP TABLE "documents";
+
+-- revert create_table departments
+DROP TABLE "departments";
+
+-- revert create_table comments
+DROP TABLE "comments";
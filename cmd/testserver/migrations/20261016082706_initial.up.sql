@@ -0,0 +1,88 @@
+-- Generated from the registered models; review before applying
+
+-- create_table comments
+CREATE TABLE "comments" (
+  "id" text NOT NULL,
+  "task_id" text,
+  "author_id" text,
+  "content" text,
+  "created_at" datetime,
+  "updated_at" datetime,
+  PRIMARY KEY ("id")
+);
+
+-- create_table departments
+CREATE TABLE "departments" (
+  "id" text NOT NULL,
+  "name" text,
+  "code" text,
+  "description" text,
+  "created_at" datetime,
+  "updated_at" datetime,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_departments_code" ON "departments" ("code");
+
+-- create_table documents
+CREATE TABLE "documents" (
+  "id" text NOT NULL,
+  "name" text,
+  "type" text,
+  "content_type" text,
+  "size" integer,
+  "path" text,
+  "owner_id" text,
+  "project_id" text,
+  "status" text,
+  "created_at" datetime,
+  "updated_at" datetime,
+  PRIMARY KEY ("id")
+);
+
+-- create_table employees
+CREATE TABLE "employees" (
+  "id" text NOT NULL,
+  "first_name" text,
+  "last_name" text,
+  "email" text,
+  "title" text,
+  "department_id" text,
+  "manager_id" text,
+  "hire_date" datetime,
+  "status" text,
+  "created_at" datetime,
+  "updated_at" datetime,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_employees_email" ON "employees" ("email");
+
+-- create_table project_tasks
+CREATE TABLE "project_tasks" (
+  "id" text NOT NULL,
+  "project_id" text,
+  "assignee_id" text,
+  "title" text,
+  "description" text,
+  "status" text,
+  "priority" integer,
+  "due_date" datetime,
+  "created_at" datetime,
+  "updated_at" datetime,
+  PRIMARY KEY ("id")
+);
+
+-- create_table projects
+CREATE TABLE "projects" (
+  "id" text NOT NULL,
+  "name" text,
+  "code" text,
+  "description" text,
+  "status" text,
+  "start_date" datetime,
+  "end_date" datetime,
+  "budget" real,
+  "created_at" datetime,
+  "updated_at" datetime,
+  PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_projects_code" ON "projects" ("code");
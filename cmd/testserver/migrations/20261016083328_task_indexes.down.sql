@@ -0,0 +1,7 @@
+-- Generated from the registered models; review before applying
+
+-- revert create_index project_tasks.idx_project_tasks_open
+DROP INDEX "idx_project_tasks_open";
+
+-- revert create_index employees.idx_employees_department_id_status
+DROP INDEX "idx_employees_department_id_status";
@@ -0,0 +1,7 @@
+-- Generated from the registered models; review before applying
+
+-- create_index employees.idx_employees_department_id_status
+CREATE INDEX "idx_employees_department_id_status" ON "employees" ("department_id", "status");
+
+-- create_index project_tasks.idx_project_tasks_open
+CREATE INDEX "idx_project_tasks_open" ON "project_tasks" ("project_id", "due_date") WHERE status <> 'done';
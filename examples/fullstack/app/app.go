@@ -0,0 +1,186 @@
+// Package app wires the ResolveSpec features into a single HTTP server: both APIs,
+// the security provider, hooks and the debug endpoints. It is used by the example
+// server in ../server and is the target of the end-to-end tests in tests/e2e.
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/membudget"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+	"github.com/bitechdev/ResolveSpec/pkg/security"
+)
+
+// Schema is the schema the example models are registered under
+const Schema = "public"
+
+// Customer is a customer; the credit card is masked by column security
+type Customer struct {
+	ID         int64     `json:"id" gorm:"column:id;primaryKey"`
+	Name       string    `json:"name" gorm:"column:name"`
+	Email      string    `json:"email" gorm:"column:email;uniqueIndex"`
+	CreditCard string    `json:"credit_card" gorm:"column:credit_card"`
+	CreatedAt  time.Time `json:"created_at" gorm:"column:created_at"`
+
+	Orders []Order `json:"orders,omitempty" gorm:"foreignKey:CustomerID;references:ID"`
+}
+
+func (Customer) TableName() string {
+	return "customers"
+}
+
+// Order belongs to a customer and to the user that created it; row security
+// limits every user to their own orders
+type Order struct {
+	ID         int64     `json:"id" gorm:"column:id;primaryKey"`
+	CustomerID int64     `json:"customer_id" gorm:"column:customer_id"`
+	UserID     int       `json:"user_id" gorm:"column:user_id"`
+	Status     string    `json:"status" gorm:"column:status"`
+	Total      float64   `json:"total" gorm:"column:total"`
+	CreatedAt  time.Time `json:"created_at" gorm:"column:created_at"`
+
+	Customer *Customer `json:"customer,omitempty" gorm:"foreignKey:CustomerID;references:ID"`
+}
+
+func (Order) TableName() string {
+	return "orders"
+}
+
+// Models returns the example models in migration order
+func Models() []interface{} {
+	return []interface{}{&Customer{}, &Order{}}
+}
+
+// Migrate creates the example tables
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(Models()...)
+}
+
+// Seed inserts the example data unless it exists already
+func Seed(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&Customer{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	customers := []Customer{
+		{Name: "Acme Corp", Email: "billing@acme.example", CreditCard: "4111111111111111"},
+		{Name: "Globex", Email: "accounts@globex.example", CreditCard: "5500000000000004"},
+	}
+	if err := db.Create(&customers).Error; err != nil {
+		return err
+	}
+
+	orders := []Order{
+		{CustomerID: customers[0].ID, UserID: 1, Status: "paid", Total: 120.50},
+		{CustomerID: customers[0].ID, UserID: 1, Status: "new", Total: 80},
+		{CustomerID: customers[1].ID, UserID: 2, Status: "paid", Total: 42},
+	}
+	return db.Create(&orders).Error
+}
+
+// NewRouter builds the example server: RestHeadSpec on /api, ResolveSpec on /rs,
+// both behind the security middleware, plus /health and /debug/membudget.
+func NewRouter(db *gorm.DB) (http.Handler, error) {
+	headSpec := restheadspec.NewHandlerWithGORM(db)
+	bodySpec := resolvespec.NewHandlerWithGORM(db)
+	for name, model := range map[string]interface{}{"customers": Customer{}, "orders": Order{}} {
+		if err := headSpec.GetRegistry().RegisterModel(Schema+"."+name, model); err != nil {
+			return nil, err
+		}
+		if err := bodySpec.RegisterModel(Schema, name, model); err != nil {
+			return nil, err
+		}
+	}
+
+	security.GlobalSecurity.AuthenticateCallback = security.ExampleAuthenticateFromHeader
+	security.GlobalSecurity.LoadColumnSecurityCallback = loadColumnSecurity
+	security.GlobalSecurity.LoadRowSecurityCallback = loadRowSecurity
+	if err := security.SetupSecurityProvider(headSpec, &security.GlobalSecurity); err != nil {
+		return nil, fmt.Errorf("failed to setup security: %w", err)
+	}
+	registerHooks(headSpec)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	}).Methods("GET")
+	router.Handle("/debug/membudget", membudget.Handler()).Methods("GET")
+
+	secured := security.Chain(security.AuthMiddleware, security.SetSecurityMiddleware)
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.Use(mux.MiddlewareFunc(secured))
+	restheadspec.SetupMuxRoutes(api, headSpec)
+
+	rs := router.PathPrefix("/rs").Subrouter()
+	rs.Use(mux.MiddlewareFunc(secured))
+	resolvespec.SetupMuxRoutes(rs, bodySpec)
+
+	return router, nil
+}
+
+// registerHooks stamps new orders with the authenticated user and a default status
+func registerHooks(handler *restheadspec.Handler) {
+	handler.Hooks().Register(restheadspec.BeforeCreate, func(hookCtx *restheadspec.HookContext) error {
+		if hookCtx.Entity != "orders" {
+			return nil
+		}
+		data, ok := hookCtx.Data.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		userID, ok := security.GetUserID(hookCtx.Context)
+		if !ok {
+			return fmt.Errorf("authentication required")
+		}
+		data["user_id"] = userID
+		if status, _ := data["status"].(string); status == "" {
+			data["status"] = "new"
+		}
+		return nil
+	})
+
+	handler.Hooks().Register(restheadspec.AfterCreate, func(hookCtx *restheadspec.HookContext) error {
+		userID, _ := security.GetUserID(hookCtx.Context)
+		logger.Info("User %d created a record in %s.%s", userID, hookCtx.Schema, hookCtx.Entity)
+		return nil
+	})
+}
+
+// loadColumnSecurity masks all but the last four digits of credit card numbers
+func loadColumnSecurity(userID int, schema, table string) ([]security.ColumnSecurity, error) {
+	if table != "customers" {
+		return nil, nil
+	}
+	return []security.ColumnSecurity{{
+		Schema:     schema,
+		Tablename:  table,
+		UserID:     userID,
+		Path:       []string{"credit_card"},
+		Accesstype: "mask",
+		MaskStart:  12,
+		MaskChar:   "*",
+	}}, nil
+}
+
+// loadRowSecurity limits orders to the ones created by the user
+func loadRowSecurity(userID int, schema, table string) (security.RowSecurity, error) {
+	rowSecurity := security.RowSecurity{Schema: schema, Tablename: table, UserID: userID}
+	if table == "orders" {
+		rowSecurity.Template = "user_id = {UserID}"
+	}
+	return rowSecurity, nil
+}
@@ -0,0 +1,301 @@
+// Package attachments stores files of records. Models declare FileRef columns; the plugin adds
+// a multipart upload endpoint per record, keeps the content in a pluggable Storage (local disk
+// or S3), answers with signed download URLs and removes the files of deleted records once the
+// delete committed.
+//
+// # Usage Example
+//
+//	type Document struct {
+//		ID     int64                `json:"id" gorm:"column:id;primaryKey"`
+//		Title  string               `json:"title" gorm:"column:title"`
+//		Scan   attachments.FileRef  `json:"scan" gorm:"column:scan"`
+//		Signed *attachments.FileRef `json:"signed" gorm:"column:signed"`
+//	}
+//
+//	files := attachments.New(attachments.Config{
+//		Storage: attachments.NewLocalStorage("/var/lib/app/files"),
+//		Secret:  []byte(os.Getenv("FILES_SECRET")),
+//		BaseURL: "https://api.example.com/api",
+//		Authorize: func(ctx context.Context, schema, entity, id string) error {
+//			return nil // Check the user of ctx may change the record
+//		},
+//	})
+//	restheadspecHandler.Use(files)
+//
+//	// POST /api/public/documents/42/files with a multipart form of the fields "scan" and
+//	// "signed" stores both files and answers with their references and download URLs
+package attachments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// Defaults of Config
+const (
+	DefaultURLTTL  = 15 * time.Minute
+	DefaultMaxSize = 32 << 20
+)
+
+// ErrNotFound is returned by Storage.Open for keys without content
+var ErrNotFound = errors.New("attachment not found")
+
+// FileRef is a column referencing a stored file. It is kept as JSON text in the database; an
+// empty reference is stored as NULL.
+type FileRef struct {
+	Key         string    `json:"key"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+	// URL is a signed download URL, set in the responses of the plugin and never stored
+	URL string `json:"url,omitempty"`
+}
+
+// ServerManaged implements common.ServerManaged: FileRef columns are only set by the uploads of
+// the plugin, so creates and updates setting them are rejected
+func (FileRef) ServerManaged() {}
+
+// Value implements driver.Valuer
+func (f FileRef) Value() (driver.Value, error) {
+	if f.Key == "" {
+		return nil, nil
+	}
+	f.URL = ""
+	encoded, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// Scan implements sql.Scanner
+func (f *FileRef) Scan(value interface{}) error {
+	*f = FileRef{}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		if v == "" {
+			return nil
+		}
+		return json.Unmarshal([]byte(v), f)
+	case []byte:
+		if len(v) == 0 {
+			return nil
+		}
+		return json.Unmarshal(v, f)
+	default:
+		return fmt.Errorf("cannot scan %T into a FileRef", value)
+	}
+}
+
+// Storage keeps the content of files by key. Keys are slash separated paths.
+type Storage interface {
+	// Put stores content of size bytes under key, replacing earlier content
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	// Open returns the content of key, or ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the content of key; deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix removes the content of every key starting with prefix
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// URLSigner is implemented by storages serving downloads themselves, e.g. S3 with presigned
+// URLs. The files of other storages are served by the download route of the plugin.
+type URLSigner interface {
+	SignedURL(ctx context.Context, key, name string, ttl time.Duration) (string, error)
+}
+
+// Config configures a Manager
+type Config struct {
+	// Storage keeps the content of the files
+	Storage Storage
+	// Secret signs the download URLs served by the plugin; a random secret is generated when
+	// empty, so the URLs expire with the process
+	Secret []byte
+	// URLTTL is the lifetime of download URLs; default 15 minutes
+	URLTTL time.Duration
+	// MaxSize is the largest upload of a request in bytes; default 32 MiB
+	MaxSize int64
+	// BaseURL is where the routes of the plugin are mounted, prefixing the download URLs
+	// served by the plugin, e.g. "https://api.example.com/api"
+	BaseURL string
+	// ProxyDownloads serves the files of URLSigner storages through the plugin as well, so
+	// their downloads can be transformed into thumbnails
+	ProxyDownloads bool
+	// ImageEncoders adds or replaces the formats of thumbnails, next to jpeg, png and gif
+	ImageEncoders map[string]ImageEncoder
+	// MaxThumbnailSize is the largest width or height of a thumbnail; default 2048
+	MaxThumbnailSize int
+	// MaxImagePixels is the largest image transformed into thumbnails; default 40 megapixels
+	MaxImagePixels int64
+	// Authorize checks the upload and listing of the files of a record, with the context of the
+	// authenticated request; an error answers 403. It is required.
+	Authorize func(ctx context.Context, schema, entity, id string) error
+}
+
+// Manager is the attachments plugin
+type Manager struct {
+	config   Config
+	records  common.RecordHost
+	registry common.ModelRegistry
+}
+
+// New returns an attachments plugin for config
+func New(config Config) *Manager {
+	if config.URLTTL <= 0 {
+		config.URLTTL = DefaultURLTTL
+	}
+	if config.MaxSize <= 0 {
+		config.MaxSize = DefaultMaxSize
+	}
+	if config.MaxThumbnailSize <= 0 {
+		config.MaxThumbnailSize = DefaultMaxThumbnailSize
+	}
+	if config.MaxImagePixels <= 0 {
+		config.MaxImagePixels = DefaultMaxImagePixels
+	}
+	if len(config.Secret) == 0 {
+		config.Secret = make([]byte, 32)
+		_, _ = rand.Read(config.Secret)
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	return &Manager{config: config}
+}
+
+// DownloadURL returns a signed URL of the content of ref, valid for the URLTTL. The URLs served
+// by the plugin take the w, h, fit and fmt parameters of thumbnails.
+func (m *Manager) DownloadURL(ctx context.Context, ref FileRef) (string, error) {
+	if signer, ok := m.config.Storage.(URLSigner); ok && !m.config.ProxyDownloads {
+		return signer.SignedURL(ctx, ref.Key, ref.Name, m.config.URLTTL)
+	}
+	expires := strconv.FormatInt(time.Now().Add(m.config.URLTTL).Unix(), 10)
+	query := url.Values{}
+	query.Set("key", ref.Key)
+	query.Set("name", ref.Name)
+	query.Set("type", ref.ContentType)
+	query.Set("expires", expires)
+	query.Set("signature", m.sign(ref.Key, ref.Name, ref.ContentType, expires))
+	return m.config.BaseURL + "/files?" + query.Encode(), nil
+}
+
+// sign returns the signature of a download served by the plugin
+func (m *Manager) sign(key, name, contentType, expires string) string {
+	mac := hmac.New(sha256.New, m.config.Secret)
+	mac.Write([]byte(key + "\n" + name + "\n" + contentType + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks the signature and expiry of a download served by the plugin
+func (m *Manager) verify(key, name, contentType, expires, signature string) error {
+	if !hmac.Equal([]byte(signature), []byte(m.sign(key, name, contentType, expires))) {
+		return errors.New("invalid signature")
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return errors.New("invalid expiry")
+	}
+	if time.Now().Unix() > unix {
+		return errors.New("download URL expired")
+	}
+	return nil
+}
+
+// recordPrefix returns the prefix of the keys of the files of a record
+func recordPrefix(schema, entity, id string) string {
+	return keySegment(schema) + "/" + keySegment(entity) + "/" + keySegment(id) + "/"
+}
+
+// keySegment escapes a value as a single segment of a key, never empty, "." or ".."
+func keySegment(value string) string {
+	if value == "" {
+		return "_"
+	}
+	escaped := url.PathEscape(value)
+	if strings.Trim(escaped, ".") == "" {
+		escaped = strings.ReplaceAll(escaped, ".", "%2E")
+	}
+	return escaped
+}
+
+// newKey returns a fresh key of a file of a column of a record
+func newKey(schema, entity, id, column string) string {
+	random := make([]byte, 16)
+	_, _ = rand.Read(random)
+	return recordPrefix(schema, entity, id) + keySegment(column) + "/" + hex.EncodeToString(random)
+}
+
+// fileColumn is a FileRef or *FileRef field of a model
+type fileColumn struct {
+	name  string
+	index []int
+}
+
+var fileRefType = reflect.TypeOf(FileRef{})
+
+// fileColumns returns the FileRef columns of a model
+func fileColumns(model interface{}) []fileColumn {
+	modelType := reflect.TypeOf(model)
+	for modelType != nil && modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	if modelType == nil || modelType.Kind() != reflect.Struct {
+		return nil
+	}
+	var columns []fileColumn
+	for _, field := range reflect.VisibleFields(modelType) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		if field.Type != fileRefType && field.Type != reflect.PointerTo(fileRefType) {
+			continue
+		}
+		name := reflection.GetColumnNameForField(reflect.New(modelType).Interface(), field.Name)
+		if name == "" || name == "-" {
+			continue
+		}
+		columns = append(columns, fileColumn{name: name, index: field.Index})
+	}
+	return columns
+}
+
+// refOf returns the reference of a column of a loaded record
+func (c fileColumn) refOf(record interface{}) FileRef {
+	value := reflect.ValueOf(record)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return FileRef{}
+		}
+		value = value.Elem()
+	}
+	field, err := value.FieldByIndexErr(c.index)
+	if err != nil {
+		return FileRef{}
+	}
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return FileRef{}
+		}
+		field = field.Elem()
+	}
+	ref, _ := field.Interface().(FileRef)
+	return ref
+}
@@ -0,0 +1,88 @@
+package attachments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage keeps files in a directory on disk. Its downloads are served by the plugin.
+type LocalStorage struct {
+	dir string
+}
+
+// NewLocalStorage returns a storage of the files below dir, created on the first upload
+func NewLocalStorage(dir string) *LocalStorage {
+	return &LocalStorage{dir: dir}
+}
+
+// path returns the file of a key, rejecting keys escaping the directory
+func (s *LocalStorage) path(key string) (string, error) {
+	if key == "" || strings.Contains(key, "\\") || !fs.ValidPath(strings.TrimSuffix(key, "/")) {
+		return "", fmt.Errorf("invalid attachment key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put implements Storage, writing to a temporary file renamed into place once complete
+func (s *LocalStorage) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), target)
+}
+
+// Open implements Storage
+func (s *LocalStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete implements Storage
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// DeletePrefix implements Storage for prefixes ending at a directory, e.g. "public/orders/42/"
+func (s *LocalStorage) DeletePrefix(ctx context.Context, prefix string) error {
+	target, err := s.path(prefix)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(target)
+}
@@ -0,0 +1,419 @@
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// Name returns the plugin name
+func (m *Manager) Name() string {
+	return "attachments"
+}
+
+// Init keeps the first handler the plugin is loaded into, which reads and updates the records of
+// its routes
+func (m *Manager) Init(host common.PluginHost) error {
+	if m.config.Storage == nil {
+		return errors.New("attachments need a storage")
+	}
+	if m.config.Authorize == nil {
+		return errors.New("attachments need an Authorize function")
+	}
+	records, ok := host.(common.RecordHost)
+	if !ok {
+		return fmt.Errorf("attachments can't be loaded into %T", host)
+	}
+	if m.records == nil {
+		m.records, m.registry = records, host.GetRegistry()
+	}
+	return nil
+}
+
+// RegisterTypes implements common.Plugin; the plugin has no models of its own
+func (m *Manager) RegisterTypes(registry common.ModelRegistry) error {
+	return nil
+}
+
+// RegisterHooks removes the files of records once their delete committed
+func (m *Manager) RegisterHooks(host common.PluginHost) error {
+	switch handler := host.(type) {
+	case *restheadspec.Handler:
+		handler.Hooks().Register(restheadspec.AfterCommit, func(ctx *restheadspec.HookContext) error {
+			m.cleanup(ctx.Context, ctx.Operation, ctx.Schema, ctx.Entity, ctx.ID, ctx.Model)
+			return nil
+		})
+	case *resolvespec.Handler:
+		handler.Hooks().Register(resolvespec.AfterCommit, func(ctx *resolvespec.HookContext) error {
+			m.cleanup(ctx.Context, ctx.Operation, ctx.Schema, ctx.Entity, ctx.ID, ctx.Model)
+			return nil
+		})
+	default:
+		return fmt.Errorf("attachments can't be loaded into %T", host)
+	}
+	return nil
+}
+
+// cleanup removes the files of a deleted record. Deletes without the id of their record, e.g.
+// by filter, leave the files in place.
+func (m *Manager) cleanup(ctx context.Context, operation, schema, entity, id string, model interface{}) {
+	if operation != common.BatchActionDelete || id == "" || len(fileColumns(model)) == 0 {
+		return
+	}
+	prefix := recordPrefix(schema, entity, id)
+	if err := m.config.Storage.DeletePrefix(ctx, prefix); err != nil {
+		logger.WarnContext(ctx, "Failed to remove the attachments of %s: %v", prefix, err)
+	}
+}
+
+// RegisterRoutes adds the upload and listing of the files of a record, and the download of
+// signed URLs:
+//
+//	POST /{schema}/{entity}/{id}/files  multipart upload, one file per FileRef column
+//	GET  /{schema}/{entity}/{id}/files  references of the files with download URLs
+//	GET  /files?key=...&signature=...   download of a signed URL, or a thumbnail of it with
+//	                                    w, h, fit (contain or cover) and fmt, e.g. &w=200&fmt=png
+func (m *Manager) RegisterRoutes(router common.Router) error {
+	router.HandleFunc("/files", m.handleDownload).Methods(http.MethodGet)
+	router.HandleFunc("/{schema}/{entity}/{id}/files", m.handleUpload).Methods(http.MethodPost)
+	router.HandleFunc("/{schema}/{entity}/{id}/files", m.handleList).Methods(http.MethodGet)
+	return nil
+}
+
+// record is the record addressed by a request of the files routes
+type record struct {
+	schema, entity, id string
+	columns            []fileColumn
+	value              interface{}
+}
+
+// loadRecord loads the record of a request through the handler, like a read of the record, and
+// authorizes the request. It answers the request on failure.
+func (m *Manager) loadRecord(w common.ResponseWriter, r common.Request) (*record, context.Context, bool) {
+	rec := &record{schema: r.PathParam("schema"), entity: r.PathParam("entity"), id: r.PathParam("id")}
+	if m.records == nil {
+		writeError(w, http.StatusInternalServerError, "not_loaded", "Attachments are not loaded into a handler", nil)
+		return nil, nil, false
+	}
+	model, err := m.registry.GetModelByEntity(rec.schema, rec.entity)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("Unknown entity %s.%s", rec.schema, rec.entity), nil)
+		return nil, nil, false
+	}
+	rec.columns = fileColumns(model)
+	if len(rec.columns) == 0 {
+		writeError(w, http.StatusNotFound, "no_file_columns", fmt.Sprintf("Entity %s.%s has no file columns", rec.schema, rec.entity), nil)
+		return nil, nil, false
+	}
+
+	ctx, value, err := m.records.ReadRecord(r, rec.schema, rec.entity, rec.id)
+	if err != nil {
+		failure := recordError(err)
+		writeError(w, failure.Status, failure.Code, failure.Message, failure.Err)
+		return nil, nil, false
+	}
+	if err := m.config.Authorize(ctx, rec.schema, rec.entity, rec.id); err != nil {
+		writeError(w, http.StatusForbidden, "forbidden", err.Error(), nil)
+		return nil, nil, false
+	}
+	rec.value = value
+	return rec, ctx, true
+}
+
+// recordError returns the answer of a failed read or update of a record by the handler
+func recordError(err error) *common.RecordError {
+	var recordErr *common.RecordError
+	if errors.As(err, &recordErr) {
+		return recordErr
+	}
+	return &common.RecordError{Status: http.StatusInternalServerError, Code: "record_error", Message: "Error accessing the record", Err: err}
+}
+
+// handleUpload stores the files of a multipart form, one per FileRef column named by its field,
+// and sets the columns of the record. Replaced files are removed once the update succeeded.
+func (m *Manager) handleUpload(w common.ResponseWriter, r common.Request) {
+	rec, ctx, ok := m.loadRecord(w, r)
+	if !ok {
+		return
+	}
+	unwrapper, ok := r.(common.HTTPRequestUnwrapper)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "unsupported_router", "Uploads need a net/http request", nil)
+		return
+	}
+	req := unwrapper.UnderlyingRequest()
+	req.Body = http.MaxBytesReader(nil, req.Body, m.config.MaxSize)
+	reader, err := req.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_upload", "Uploads are multipart/form-data requests", err)
+		return
+	}
+
+	byName := make(map[string]fileColumn, len(rec.columns))
+	for _, column := range rec.columns {
+		byName[column.name] = column
+	}
+	uploaded := make(map[string]FileRef)
+	fail := func(status int, code, message string, err error) {
+		for _, ref := range uploaded {
+			if deleteErr := m.config.Storage.Delete(ctx, ref.Key); deleteErr != nil {
+				logger.WarnContext(ctx, "Failed to remove the upload %s: %v", ref.Key, deleteErr)
+			}
+		}
+		writeError(w, status, code, message, err)
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fail(uploadStatus(err), "invalid_upload", "Error reading the upload", err)
+			return
+		}
+		column, isFile := byName[part.FormName()]
+		if !isFile || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+		ref, err := m.store(ctx, rec, column.name, part)
+		part.Close()
+		if err != nil {
+			fail(uploadStatus(err), "upload_error", "Error storing "+column.name, err)
+			return
+		}
+		if previous, replaced := uploaded[column.name]; replaced {
+			_ = m.config.Storage.Delete(ctx, previous.Key)
+		}
+		uploaded[column.name] = ref
+	}
+	if len(uploaded) == 0 {
+		fail(http.StatusBadRequest, "no_files", "The upload has no files of the file columns", nil)
+		return
+	}
+
+	values := make(map[string]interface{}, len(uploaded))
+	for column, ref := range uploaded {
+		values[column] = ref
+	}
+	if err := m.records.UpdateRecord(r, rec.schema, rec.entity, rec.id, values); err != nil {
+		failure := recordError(err)
+		fail(failure.Status, failure.Code, failure.Message, failure.Err)
+		return
+	}
+	for column := range uploaded {
+		previous := byName[column].refOf(rec.value)
+		if previous.Key == "" {
+			continue
+		}
+		if err := m.config.Storage.Delete(ctx, previous.Key); err != nil {
+			logger.WarnContext(ctx, "Failed to remove the replaced attachment %s: %v", previous.Key, err)
+		}
+		if err := m.config.Storage.DeletePrefix(ctx, previous.Key+thumbnailSuffix); err != nil {
+			logger.WarnContext(ctx, "Failed to remove the thumbnails of %s: %v", previous.Key, err)
+		}
+	}
+	m.writeRefs(w, r, uploaded)
+}
+
+// store spools a part to a temporary file, so storages learn its size, and puts it
+func (m *Manager) store(ctx context.Context, rec *record, column string, part *multipart.Part) (FileRef, error) {
+	spool, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return FileRef{}, err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	size, err := io.Copy(spool, part)
+	if err != nil {
+		return FileRef{}, err
+	}
+	ref := FileRef{
+		Key:         newKey(rec.schema, rec.entity, rec.id, column),
+		Name:        part.FileName(),
+		ContentType: part.Header.Get("Content-Type"),
+		Size:        size,
+		UploadedAt:  time.Now().UTC(),
+	}
+	if ref.ContentType == "" || ref.ContentType == "application/octet-stream" {
+		sniff := make([]byte, 512)
+		n, _ := spool.ReadAt(sniff, 0)
+		ref.ContentType = http.DetectContentType(sniff[:n])
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return FileRef{}, err
+	}
+	if err := m.config.Storage.Put(ctx, ref.Key, spool, size, ref.ContentType); err != nil {
+		return FileRef{}, err
+	}
+	return ref, nil
+}
+
+// uploadStatus returns the status of a failed upload: 413 above the MaxSize, else 400
+func uploadStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// handleList answers the references of the files of a record with download URLs
+func (m *Manager) handleList(w common.ResponseWriter, r common.Request) {
+	rec, _, ok := m.loadRecord(w, r)
+	if !ok {
+		return
+	}
+	refs := make(map[string]FileRef, len(rec.columns))
+	for _, column := range rec.columns {
+		if ref := column.refOf(rec.value); ref.Key != "" {
+			refs[column.name] = ref
+		}
+	}
+	m.writeRefs(w, r, refs)
+}
+
+// writeRefs answers references by column with their download URLs
+func (m *Manager) writeRefs(w common.ResponseWriter, r common.Request, refs map[string]FileRef) {
+	for column, ref := range refs {
+		url, err := m.DownloadURL(r.Context(), ref)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "sign_error", "Error signing the download URL", err)
+			return
+		}
+		ref.URL = url
+		refs[column] = ref
+	}
+	w.SetHeader("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := w.WriteJSON(common.Response{Success: true, Data: refs}); err != nil {
+		logger.Error("Error sending response: %v", err)
+	}
+}
+
+// handleDownload serves the content of a signed download URL, or a thumbnail of it when the
+// URL has thumbnail parameters
+func (m *Manager) handleDownload(w common.ResponseWriter, r common.Request) {
+	key, name, contentType := r.QueryParam("key"), r.QueryParam("name"), r.QueryParam("type")
+	if err := m.verify(key, name, contentType, r.QueryParam("expires"), r.QueryParam("signature")); err != nil {
+		writeError(w, http.StatusForbidden, "invalid_signature", err.Error(), nil)
+		return
+	}
+	thumb, transform, err := m.parseThumbnail(r.QueryParam("w"), r.QueryParam("h"), r.QueryParam("fit"), r.QueryParam("fmt"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_thumbnail", err.Error(), nil)
+		return
+	}
+	if transform {
+		if thumb.Format == "" {
+			thumb.Format = imageFormat(contentType)
+		}
+		m.serveThumbnail(w, r, key, name, thumb)
+		return
+	}
+
+	content, err := m.config.Storage.Open(r.Context(), key)
+	if errors.Is(err, ErrNotFound) {
+		writeError(w, http.StatusNotFound, "not_found", "File not found", nil)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", "Error opening the file", err)
+		return
+	}
+	defer content.Close()
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	writeDownloadHeaders(w, name, contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		logger.Warn("Error sending %s: %v", key, err)
+	}
+}
+
+// serveThumbnail serves a thumbnail of a file, rendered on the first request and kept in the
+// storage next to the file for later ones
+func (m *Manager) serveThumbnail(w common.ResponseWriter, r common.Request, key, name string, thumb Thumbnail) {
+	ctx := r.Context()
+	var data []byte
+	if thumb.Format != "" {
+		if cached, err := m.config.Storage.Open(ctx, thumb.cacheKey(key)); err == nil {
+			data, err = io.ReadAll(cached)
+			cached.Close()
+			if err != nil {
+				data = nil
+			}
+		}
+	}
+
+	if data == nil {
+		content, err := m.config.Storage.Open(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "File not found", nil)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "storage_error", "Error opening the file", err)
+			return
+		}
+		data, err = m.renderThumbnail(content, &thumb)
+		content.Close()
+		if errors.Is(err, errThumbnail) {
+			writeError(w, http.StatusBadRequest, "invalid_thumbnail", err.Error(), nil)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "thumbnail_error", "Error rendering the thumbnail", err)
+			return
+		}
+		if err := m.config.Storage.Put(ctx, thumb.cacheKey(key), bytes.NewReader(data), int64(len(data)), "image/"+thumb.Format); err != nil {
+			logger.Warn("Failed to cache the thumbnail of %s: %v", key, err)
+		}
+	}
+
+	writeDownloadHeaders(w, thumbnailName(name, thumb.Format), "image/"+thumb.Format)
+	w.SetHeader("Content-Length", strconv.Itoa(len(data)))
+	w.SetHeader("Cache-Control", fmt.Sprintf("private, max-age=%d", int(m.config.URLTTL.Seconds())))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		logger.Warn("Error sending the thumbnail of %s: %v", key, err)
+	}
+}
+
+// writeDownloadHeaders sets the content headers of a download
+func writeDownloadHeaders(w common.ResponseWriter, name, contentType string) {
+	w.SetHeader("Content-Type", contentType)
+	w.SetHeader("X-Content-Type-Options", "nosniff")
+	if name != "" {
+		w.SetHeader("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	}
+}
+
+// writeError answers an error; the details of err are logged, not sent
+func writeError(w common.ResponseWriter, status int, code, message string, err error) {
+	if err != nil {
+		logger.Error("Attachments request failed (%s): %v", code, err)
+	}
+	w.SetHeader("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if writeErr := w.WriteJSON(common.Response{Success: false, Error: &common.APIError{Code: code, Message: message}}); writeErr != nil {
+		logger.Error("Error sending response: %v", writeErr)
+	}
+}
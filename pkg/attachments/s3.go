@@ -0,0 +1,308 @@
+package attachments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config configures an S3Storage
+type S3Config struct {
+	// Endpoint is the URL of the bucket, e.g. "https://my-bucket.s3.eu-west-1.amazonaws.com",
+	// or of the service when Bucket is set, e.g. "http://minio:9000" for path style requests
+	Endpoint string
+	// Bucket is appended to the path of the Endpoint; leave empty for virtual host endpoints
+	Bucket          string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// Client sends the requests; nil uses a client with a 30 second timeout
+	Client *http.Client
+}
+
+// S3Storage keeps files in an S3 compatible bucket, signing its requests with AWS Signature
+// Version 4. Downloads use presigned URLs of the bucket.
+type S3Storage struct {
+	config S3Config
+	base   *url.URL
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3Storage returns a storage of the files of a bucket
+func NewS3Storage(config S3Config) (*S3Storage, error) {
+	base, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", config.Endpoint)
+	}
+	if config.Bucket != "" {
+		base.Path += "/" + config.Bucket
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &S3Storage{config: config, base: base, client: client, now: time.Now}, nil
+}
+
+// objectURL returns the URL of a key
+func (s *S3Storage) objectURL(key string) *url.URL {
+	u := *s.base
+	u.Path += "/" + key
+	return &u
+}
+
+// Put implements Storage
+func (s *S3Storage) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), content)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	_, err = s.do(req, http.StatusOK)
+	return err
+}
+
+// Open implements Storage
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete implements Storage
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+	_, err = s.do(req, http.StatusNoContent, http.StatusOK)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// listResult is the part of a ListObjectsV2 response used by DeletePrefix
+type listResult struct {
+	Keys                  []string `xml:"Contents>Key"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken"`
+}
+
+// DeletePrefix implements Storage, listing the keys of prefix and deleting them one by one
+func (s *S3Storage) DeletePrefix(ctx context.Context, prefix string) error {
+	token := ""
+	for {
+		u := *s.base
+		u.Path += "/"
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		u.RawQuery = query.Encode()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := s.do(req, http.StatusOK)
+		if err != nil {
+			return err
+		}
+		var result listResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", prefix, err)
+		}
+		for _, key := range result.Keys {
+			if err := s.Delete(ctx, key); err != nil {
+				return err
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// SignedURL implements URLSigner with a presigned GET URL, naming the download name
+func (s *S3Storage) SignedURL(ctx context.Context, key, name string, ttl time.Duration) (string, error) {
+	u := s.objectURL(key)
+	query := url.Values{}
+	if name != "" {
+		query.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	}
+	s.presign(u, query, ttl)
+	return u.String(), nil
+}
+
+// do signs and sends a request, returning the response of an expected status
+func (s *S3Storage) do(req *http.Request, expected ...int) (*http.Response, error) {
+	s.sign(req)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return resp, nil
+		}
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	return nil, fmt.Errorf("S3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// s3UnsignedPayload leaves the body out of the signature, so uploads stream
+const s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+// sign adds the Authorization header of Signature Version 4 to a request
+func (s *S3Storage) sign(req *http.Request) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	signedHeaders, signature := s.signature(req.Method, req.URL, headers, s3UnsignedPayload, now)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKeyID, s.scope(now), signedHeaders, signature))
+}
+
+// presign adds the query parameters of a presigned GET URL valid for ttl to u
+func (s *S3Storage) presign(u *url.URL, query url.Values, ttl time.Duration) {
+	now := s.now().UTC()
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.config.AccessKeyID+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	u.RawQuery = s3Query(query)
+	_, signature := s.signature(http.MethodGet, u, map[string]string{"host": u.Host}, s3UnsignedPayload, now)
+	u.RawQuery += "&X-Amz-Signature=" + signature
+}
+
+// scope returns the credential scope of a date
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.config.Region + "/s3/aws4_request"
+}
+
+// signature returns the signed header names and the signature of a canonical request
+func (s *S3Storage) signature(method string, u *url.URL, headers map[string]string, payloadHash string, now time.Time) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		s3EscapePath(u.Path),
+		s3Query(u.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format("20060102T150405Z"),
+		s.scope(now),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Query returns the canonical form of query parameters: sorted and escaped per RFC 3986
+func s3Query(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, s3Escape(key, true)+"="+s3Escape(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3EscapePath returns the canonical form of a path, escaping every segment
+func s3EscapePath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return s3Escape(p, false)
+}
+
+// s3Escape escapes everything but the unreserved characters of RFC 3986, and slashes unless
+// escapeSlash is set
+func s3Escape(value string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,213 @@
+package attachments
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Defaults of the thumbnail limits of Config
+const (
+	DefaultMaxThumbnailSize = 2048
+	DefaultMaxImagePixels   = 40_000_000
+)
+
+// Fit modes of a thumbnail
+const (
+	FitContain = "contain" // Scales the image into the box, keeping its aspect ratio
+	FitCover   = "cover"   // Fills the box, cropping the center of the image
+)
+
+// thumbnailSuffix is appended to the key of a file for the keys of its cached thumbnails
+const thumbnailSuffix = ".thumbs/"
+
+// ImageEncoder writes an image in a format other than the built-in jpeg, png and gif, e.g. webp
+type ImageEncoder func(w io.Writer, img image.Image) error
+
+// builtinImageEncoders are the formats thumbnails are encoded in without configuration
+var builtinImageEncoders = map[string]ImageEncoder{
+	"jpeg": func(w io.Writer, img image.Image) error {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	},
+	"png": png.Encode,
+	"gif": func(w io.Writer, img image.Image) error {
+		return gif.Encode(w, img, nil)
+	},
+}
+
+// Thumbnail is a transformation of an image: at most Width x Height pixels, either may be 0 to
+// follow the aspect ratio, encoded in Format
+type Thumbnail struct {
+	Width  int
+	Height int
+	Fit    string
+	Format string
+}
+
+// errThumbnail is the cause of transformations rejected with 400
+var errThumbnail = errors.New("invalid thumbnail")
+
+// parseThumbnail returns the transformation of the w, h, fit and fmt parameters of a download,
+// or false when the download has none
+func (m *Manager) parseThumbnail(width, height, fit, format string) (Thumbnail, bool, error) {
+	if width == "" && height == "" && fit == "" && format == "" {
+		return Thumbnail{}, false, nil
+	}
+	thumb := Thumbnail{Fit: strings.ToLower(fit), Format: strings.ToLower(format)}
+	for _, size := range []struct {
+		value  string
+		target *int
+	}{{width, &thumb.Width}, {height, &thumb.Height}} {
+		if size.value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(size.value)
+		if err != nil || n <= 0 || n > m.config.MaxThumbnailSize {
+			return Thumbnail{}, true, fmt.Errorf("%w: sizes are 1 to %d pixels", errThumbnail, m.config.MaxThumbnailSize)
+		}
+		*size.target = n
+	}
+	switch thumb.Fit {
+	case "":
+		thumb.Fit = FitContain
+	case FitContain, FitCover:
+	default:
+		return Thumbnail{}, true, fmt.Errorf("%w: unknown fit %q", errThumbnail, fit)
+	}
+	if thumb.Format == "jpg" {
+		thumb.Format = "jpeg"
+	}
+	if thumb.Format != "" && m.imageEncoder(thumb.Format) == nil {
+		return Thumbnail{}, true, fmt.Errorf("%w: unsupported format %q", errThumbnail, format)
+	}
+	return thumb, true, nil
+}
+
+// imageEncoder returns the encoder of a format, preferring the ImageEncoders of the config
+func (m *Manager) imageEncoder(format string) ImageEncoder {
+	if encoder := m.config.ImageEncoders[format]; encoder != nil {
+		return encoder
+	}
+	return builtinImageEncoders[format]
+}
+
+// imageFormat returns the built-in format of an image content type, or ""
+func imageFormat(contentType string) string {
+	format := strings.TrimPrefix(strings.ToLower(contentType), "image/")
+	if _, ok := builtinImageEncoders[format]; ok && format != contentType {
+		return format
+	}
+	return ""
+}
+
+// cacheKey returns the key of the cached thumbnail of a file
+func (t Thumbnail) cacheKey(key string) string {
+	return fmt.Sprintf("%s%s%dx%d-%s.%s", key, thumbnailSuffix, t.Width, t.Height, t.Fit, t.Format)
+}
+
+// thumbnailName returns the download name of a thumbnail of a file
+func thumbnailName(name, format string) string {
+	if name == "" {
+		return ""
+	}
+	return strings.TrimSuffix(name, path.Ext(name)) + "." + format
+}
+
+// renderThumbnail decodes an image, scales it down to the box of thumb and encodes it. The
+// format of the thumbnail defaults to the format of the image.
+func (m *Manager) renderThumbnail(content io.Reader, thumb *Thumbnail) ([]byte, error) {
+	var source bytes.Buffer
+	config, format, err := image.DecodeConfig(io.TeeReader(content, &source))
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a jpeg, png or gif image", errThumbnail)
+	}
+	if int64(config.Width)*int64(config.Height) > m.config.MaxImagePixels {
+		return nil, fmt.Errorf("%w: the image exceeds %d pixels", errThumbnail, m.config.MaxImagePixels)
+	}
+	img, _, err := image.Decode(io.MultiReader(&source, content))
+	if err != nil {
+		return nil, err
+	}
+	if thumb.Format == "" {
+		thumb.Format = format
+	}
+
+	var encoded bytes.Buffer
+	if err := m.imageEncoder(thumb.Format)(&encoded, scaleImage(img, thumb.Width, thumb.Height, thumb.Fit)); err != nil {
+		return nil, err
+	}
+	return encoded.Bytes(), nil
+}
+
+// scaleImage returns img scaled down into a box of width x height, averaging the source pixels
+// covered by every pixel. Images are never scaled up; cover crops the center of img to the
+// aspect ratio of the box first.
+func scaleImage(img image.Image, width, height int, fit string) image.Image {
+	bounds := img.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	if sw == 0 || sh == 0 {
+		return img
+	}
+	switch {
+	case width == 0:
+		width = max(1, sw*height/sh)
+	case height == 0:
+		height = max(1, sh*width/sw)
+	case fit == FitCover:
+		// Crop the largest centered rectangle of the aspect ratio of the box
+		cw, ch := sw, sw*height/width
+		if ch > sh {
+			cw, ch = sh*width/height, sh
+		}
+		x, y := bounds.Min.X+(sw-cw)/2, bounds.Min.Y+(sh-ch)/2
+		bounds = image.Rect(x, y, x+cw, y+ch)
+		sw, sh = cw, ch
+	default:
+		if sw*height > sh*width {
+			height = max(1, sh*width/sw)
+		} else {
+			width = max(1, sw*height/sh)
+		}
+	}
+	if width >= sw || height >= sh {
+		width, height = sw, sh
+	}
+
+	source := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(source, source.Bounds(), img, bounds.Min, draw.Src)
+	if width == sw && height == sh {
+		return source
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, max((y+1)*sh/height, y*sh/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, max((x+1)*sw/width, x*sw/width+1)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				offset := source.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(source.Pix[offset])
+					g += uint64(source.Pix[offset+1])
+					b += uint64(source.Pix[offset+2])
+					a += uint64(source.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+			scaled.SetRGBA(x, y, color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: uint8(a / n)})
+		}
+	}
+	return scaled
+}
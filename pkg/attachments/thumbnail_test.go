@@ -0,0 +1,90 @@
+package attachments
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseThumbnail(t *testing.T) {
+	manager := New(Config{ImageEncoders: map[string]ImageEncoder{"webp": func(w io.Writer, img image.Image) error { return nil }}})
+
+	_, transform, err := manager.parseThumbnail("", "", "", "")
+	require.NoError(t, err)
+	assert.False(t, transform)
+
+	thumb, transform, err := manager.parseThumbnail("200", "", "", "JPG")
+	require.NoError(t, err)
+	assert.True(t, transform)
+	assert.Equal(t, Thumbnail{Width: 200, Fit: FitContain, Format: "jpeg"}, thumb)
+
+	thumb, _, err = manager.parseThumbnail("64", "64", "cover", "webp")
+	require.NoError(t, err)
+	assert.Equal(t, Thumbnail{Width: 64, Height: 64, Fit: FitCover, Format: "webp"}, thumb)
+	assert.Equal(t, "a/b.thumbs/64x64-cover.webp", thumb.cacheKey("a/b"))
+
+	for _, params := range [][4]string{{"0", "", "", ""}, {"5000", "", "", ""}, {"x", "", "", ""}, {"10", "", "stretch", ""}, {"10", "", "", "bmp"}} {
+		_, _, err := manager.parseThumbnail(params[0], params[1], params[2], params[3])
+		assert.ErrorIs(t, err, errThumbnail, params)
+	}
+}
+
+func TestScaleImage(t *testing.T) {
+	// Left half red, right half blue
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			if x < 200 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+
+	contained := scaleImage(img, 100, 100, FitContain)
+	assert.Equal(t, image.Rect(0, 0, 100, 50), contained.Bounds())
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, contained.At(10, 10))
+	assert.Equal(t, color.RGBA{B: 255, A: 255}, contained.At(90, 10))
+
+	covered := scaleImage(img, 100, 100, FitCover)
+	assert.Equal(t, image.Rect(0, 0, 100, 100), covered.Bounds(), "cover fills the box from the center square")
+
+	assert.Equal(t, image.Rect(0, 0, 50, 25), scaleImage(img, 50, 0, FitContain).Bounds())
+	assert.Equal(t, image.Rect(0, 0, 400, 200), scaleImage(img, 1000, 1000, FitContain).Bounds(), "images are not scaled up")
+
+	// Every pixel averages the pixels it covers
+	averaged := scaleImage(img, 1, 1, FitContain)
+	assert.Equal(t, image.Rect(0, 0, 1, 1), averaged.Bounds())
+	assert.Equal(t, color.RGBA{R: 127, B: 127, A: 255}, averaged.At(0, 0))
+}
+
+func TestRenderThumbnail(t *testing.T) {
+	manager := New(Config{MaxImagePixels: 10_000})
+	var source bytes.Buffer
+	require.NoError(t, png.Encode(&source, image.NewRGBA(image.Rect(0, 0, 90, 60))))
+
+	thumb := Thumbnail{Width: 30, Fit: FitContain}
+	data, err := manager.renderThumbnail(bytes.NewReader(source.Bytes()), &thumb)
+	require.NoError(t, err)
+	assert.Equal(t, "png", thumb.Format, "the format of the image by default")
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "png", format)
+	assert.Equal(t, 30, config.Width)
+	assert.Equal(t, 20, config.Height)
+
+	_, err = manager.renderThumbnail(bytes.NewReader([]byte("not an image")), &Thumbnail{Width: 10, Fit: FitContain})
+	assert.ErrorIs(t, err, errThumbnail)
+
+	source.Reset()
+	require.NoError(t, png.Encode(&source, image.NewRGBA(image.Rect(0, 0, 200, 200))))
+	_, err = manager.renderThumbnail(bytes.NewReader(source.Bytes()), &Thumbnail{Width: 10, Fit: FitContain})
+	assert.ErrorIs(t, err, errThumbnail, "images above MaxImagePixels are rejected")
+}
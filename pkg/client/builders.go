@@ -0,0 +1,184 @@
+package client
+
+import "github.com/bitechdev/ResolveSpec/pkg/common"
+
+// Query starts a QueryOptions built with the chainable methods below:
+//
+//	options := client.Query().
+//	    Select("id", "name").
+//	    Where(client.Eq("status", "active"), client.Gt("age", 30)).
+//	    Match(client.Or(client.Like("name", "J%"), client.IsNull("name"))).
+//	    OrderBy(client.Desc("created_at")).
+//	    With(client.Relation("Orders", "id", "total")).
+//	    Page(20, 40)
+func Query() *QueryOptions {
+	return &QueryOptions{}
+}
+
+// Select limits the returned columns
+func (o *QueryOptions) Select(columns ...string) *QueryOptions {
+	o.Columns = append(o.Columns, columns...)
+	return o
+}
+
+// Omit excludes columns from the result
+func (o *QueryOptions) Omit(columns ...string) *QueryOptions {
+	o.OmitColumns = append(o.OmitColumns, columns...)
+	return o
+}
+
+// Where adds filters, combined with AND
+func (o *QueryOptions) Where(filters ...common.FilterOption) *QueryOptions {
+	o.Filters = append(o.Filters, filters...)
+	return o
+}
+
+// Match adds a filter group; several groups are combined with AND
+func (o *QueryOptions) Match(group *common.FilterGroup) *QueryOptions {
+	if o.FilterGroup.IsEmpty() {
+		o.FilterGroup = group
+	} else {
+		o.FilterGroup = AllOf(o.FilterGroup, group)
+	}
+	return o
+}
+
+// OrderBy adds sort columns, see Asc and Desc
+func (o *QueryOptions) OrderBy(sorts ...common.SortOption) *QueryOptions {
+	o.Sort = append(o.Sort, sorts...)
+	return o
+}
+
+// With preloads relations, see Relation
+func (o *QueryOptions) With(relations ...common.PreloadOption) *QueryOptions {
+	o.Preload = append(o.Preload, relations...)
+	return o
+}
+
+// Join expands belongsTo/hasOne relations into the main query, see Relation
+func (o *QueryOptions) Join(relations ...common.PreloadOption) *QueryOptions {
+	o.Expand = append(o.Expand, relations...)
+	return o
+}
+
+// Page sets the limit and offset
+func (o *QueryOptions) Page(limit, offset int) *QueryOptions {
+	o.Limit = limit
+	o.Offset = offset
+	return o
+}
+
+// Filter returns a filter of any operator supported by the server
+func Filter(column, operator string, value interface{}) common.FilterOption {
+	return common.FilterOption{Column: column, Operator: operator, Value: value}
+}
+
+// Eq matches column = value
+func Eq(column string, value interface{}) common.FilterOption {
+	return Filter(column, "eq", value)
+}
+
+// Neq matches column <> value
+func Neq(column string, value interface{}) common.FilterOption {
+	return Filter(column, "neq", value)
+}
+
+// Gt matches column > value
+func Gt(column string, value interface{}) common.FilterOption {
+	return Filter(column, "gt", value)
+}
+
+// Gte matches column >= value
+func Gte(column string, value interface{}) common.FilterOption {
+	return Filter(column, "gte", value)
+}
+
+// Lt matches column < value
+func Lt(column string, value interface{}) common.FilterOption {
+	return Filter(column, "lt", value)
+}
+
+// Lte matches column <= value
+func Lte(column string, value interface{}) common.FilterOption {
+	return Filter(column, "lte", value)
+}
+
+// Like matches column LIKE pattern
+func Like(column, pattern string) common.FilterOption {
+	return Filter(column, "like", pattern)
+}
+
+// ILike matches column ILIKE pattern, case-insensitive
+func ILike(column, pattern string) common.FilterOption {
+	return Filter(column, "ilike", pattern)
+}
+
+// In matches column IN (values...)
+func In(column string, values ...interface{}) common.FilterOption {
+	return Filter(column, "in", values)
+}
+
+// Between matches from < column < to
+func Between(column string, from, to interface{}) common.FilterOption {
+	return Filter(column, "between", []interface{}{from, to})
+}
+
+// BetweenInclusive matches from <= column <= to
+func BetweenInclusive(column string, from, to interface{}) common.FilterOption {
+	return Filter(column, "between_inclusive", []interface{}{from, to})
+}
+
+// IsNull matches column IS NULL
+func IsNull(column string) common.FilterOption {
+	return Filter(column, "is_null", nil)
+}
+
+// IsNotNull matches column IS NOT NULL
+func IsNotNull(column string) common.FilterOption {
+	return Filter(column, "is_not_null", nil)
+}
+
+// And returns a group whose filters must all match
+func And(filters ...common.FilterOption) *common.FilterGroup {
+	return &common.FilterGroup{Logic: "AND", Filters: filters}
+}
+
+// Or returns a group of which any filter must match
+func Or(filters ...common.FilterOption) *common.FilterGroup {
+	return &common.FilterGroup{Logic: "OR", Filters: filters}
+}
+
+// AllOf nests groups that must all match, e.g. AllOf(Or(Eq("a", 1), Eq("b", 2)), Or(...))
+func AllOf(groups ...*common.FilterGroup) *common.FilterGroup {
+	return nest("AND", groups)
+}
+
+// AnyOf nests groups of which any must match
+func AnyOf(groups ...*common.FilterGroup) *common.FilterGroup {
+	return nest("OR", groups)
+}
+
+func nest(logic string, groups []*common.FilterGroup) *common.FilterGroup {
+	result := &common.FilterGroup{Logic: logic}
+	for _, group := range groups {
+		if !group.IsEmpty() {
+			result.Groups = append(result.Groups, *group)
+		}
+	}
+	return result
+}
+
+// Asc sorts by column ascending
+func Asc(column string) common.SortOption {
+	return common.SortOption{Column: column, Direction: "ASC"}
+}
+
+// Desc sorts by column descending
+func Desc(column string) common.SortOption {
+	return common.SortOption{Column: column, Direction: "DESC"}
+}
+
+// Relation selects a relation to preload or expand, optionally with its columns
+func Relation(relation string, columns ...string) common.PreloadOption {
+	return common.PreloadOption{Relation: relation, Columns: columns}
+}
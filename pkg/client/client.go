@@ -0,0 +1,290 @@
+// Package client provides a typed Go client for entities served by a RestHeadSpec handler.
+//
+// Read options are given as typed structs, or built with Query and the filter, sort and
+// relation helpers, and translated into the RestHeadSpec headers; values that aren't plain
+// ASCII are sent in the ZIP_ base64 convention. Responses are decoded into the entity type.
+// ForEntity creates the clients of several entities from one server configuration, and
+// resolvespec-gen generates an accessor for every registered model.
+//
+// # Usage Example
+//
+//	users := client.NewClient[User](client.Config{
+//	    BaseURL: "http://localhost:8080/api",
+//	    Schema:  "public",
+//	    Entity:  "users",
+//	    Headers: map[string]string{"Authorization": "Bearer " + token},
+//	})
+//
+//	result, err := users.List(ctx, &client.QueryOptions{
+//	    Filters: []common.FilterOption{{Column: "status", Operator: "eq", Value: "active"}},
+//	    Sort:    []common.SortOption{{Column: "created_at", Direction: "DESC"}},
+//	    Limit:   20,
+//	})
+//
+//	result, err = users.List(ctx, client.Query().
+//	    Where(client.Eq("status", "active")).
+//	    OrderBy(client.Desc("created_at")).
+//	    With(client.Relation("Orders", "id", "total")).
+//	    Page(20, 0))
+//
+//	user, err := users.Get(ctx, 42, nil)
+//	created, err := users.Create(ctx, User{Name: "Jane"})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// ErrNotFound is returned by Get when no record matches the ID
+var ErrNotFound = errors.New("record not found")
+
+// Config configures a Client
+type Config struct {
+	// BaseURL is the URL the RestHeadSpec routes are mounted on, e.g. "http://localhost:8080/api"
+	BaseURL string
+	// Schema and Entity select the model. An empty schema is omitted from the URL.
+	Schema string
+	Entity string
+	// HTTPClient is used for all requests; http.DefaultClient when nil
+	HTTPClient *http.Client
+	// Headers are sent with every request, e.g. Authorization
+	Headers map[string]string
+}
+
+// Client reads and writes the records of a single entity, decoded into T
+type Client[T any] struct {
+	config     Config
+	httpClient *http.Client
+	endpoint   string
+}
+
+// ListResult holds a page of records and the paging metadata reported by the server
+type ListResult[T any] struct {
+	Items    []T
+	Metadata *common.Metadata
+}
+
+// Error is returned when the server answers with a non-2xx status
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("resolvespec: %d %s", e.StatusCode, e.Message)
+}
+
+// NewClient creates a client for the entity described by config
+func NewClient[T any](config Config) *Client[T] {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	endpoint := strings.TrimRight(config.BaseURL, "/")
+	if config.Schema != "" {
+		endpoint += "/" + url.PathEscape(config.Schema)
+	}
+	endpoint += "/" + url.PathEscape(config.Entity)
+
+	return &Client[T]{
+		config:     config,
+		httpClient: httpClient,
+		endpoint:   endpoint,
+	}
+}
+
+// ForEntity returns a client for an entity of the server config points at, sharing its HTTP
+// client and headers. The Schema and Entity of config are replaced.
+//
+//	server := client.Config{BaseURL: "http://localhost:8080/api"}
+//	users := client.ForEntity[User](server, "public", "users")
+//	orders := client.ForEntity[Order](server, "public", "orders")
+func ForEntity[T any](config Config, schema, entity string) *Client[T] {
+	config.Schema = schema
+	config.Entity = entity
+	return NewClient[T](config)
+}
+
+// List returns the records matching the options
+func (c *Client[T]) List(ctx context.Context, options *QueryOptions) (*ListResult[T], error) {
+	var response struct {
+		Data     json.RawMessage  `json:"data"`
+		Metadata *common.Metadata `json:"metadata"`
+	}
+	if err := c.read(ctx, c.endpoint, options, &response); err != nil {
+		return nil, err
+	}
+	items, err := decodeRecords[T](response.Data)
+	if err != nil {
+		return nil, err
+	}
+	return &ListResult[T]{Items: items, Metadata: response.Metadata}, nil
+}
+
+// Get returns the record with the given primary key, or ErrNotFound.
+// Only the column, preload and expand options apply to a single record.
+func (c *Client[T]) Get(ctx context.Context, id interface{}, options *QueryOptions) (*T, error) {
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := c.read(ctx, c.recordURL(id), options, &response); err != nil {
+		return nil, err
+	}
+	items, err := decodeRecords[T](response.Data)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrNotFound
+	}
+	return &items[0], nil
+}
+
+// Create inserts a record and returns it as stored, including generated keys
+func (c *Client[T]) Create(ctx context.Context, record T) (*T, error) {
+	var created T
+	if err := c.do(ctx, http.MethodPost, c.endpoint, nil, record, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Update writes every field T serializes to the record with the given primary key.
+// Use omitempty tags or Patch to send partial updates.
+func (c *Client[T]) Update(ctx context.Context, id interface{}, record T) (*T, error) {
+	return c.update(ctx, http.MethodPut, id, record)
+}
+
+// Patch updates only the given fields, keyed by JSON column name
+func (c *Client[T]) Patch(ctx context.Context, id interface{}, fields map[string]interface{}) (*T, error) {
+	return c.update(ctx, http.MethodPatch, id, fields)
+}
+
+// Delete removes the record with the given primary key and returns the number of deleted rows
+func (c *Client[T]) Delete(ctx context.Context, id interface{}) (int64, error) {
+	var response struct {
+		Deleted int64 `json:"deleted"`
+	}
+	if err := c.do(ctx, http.MethodDelete, c.recordURL(id), nil, nil, &response); err != nil {
+		return 0, err
+	}
+	return response.Deleted, nil
+}
+
+func (c *Client[T]) update(ctx context.Context, method string, id interface{}, body interface{}) (*T, error) {
+	var updated T
+	if err := c.do(ctx, method, c.recordURL(id), nil, body, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// read performs a GET in the detail response format, so data is always an array
+func (c *Client[T]) read(ctx context.Context, target string, options *QueryOptions, dest interface{}) error {
+	headers, err := options.headers()
+	if err != nil {
+		return err
+	}
+	headers["X-DetailApi"] = "true"
+	headers["X-Single-Record-As-Object"] = "false"
+	return c.do(ctx, http.MethodGet, target, headers, nil, dest)
+}
+
+// decodeRecords decodes the data of a read response into records. Data is an array in the
+// detail format; a single object, as sent when headers override the format, is one record.
+func decodeRecords[T any](data json.RawMessage) ([]T, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	if data[0] == '{' {
+		var record T
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		return []T{record}, nil
+	}
+	var records []T
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return records, nil
+}
+
+func (c *Client[T]) recordURL(id interface{}) string {
+	return c.endpoint + "/" + url.PathEscape(fmt.Sprintf("%v", id))
+}
+
+func (c *Client[T]) do(ctx context.Context, method, target string, headers map[string]string, body interface{}, dest interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request body: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range c.config.Headers {
+		req.Header.Set(key, value)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &Error{StatusCode: resp.StatusCode, Message: errorMessage(data)}
+	}
+
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// errorMessage extracts the error text from a RestHeadSpec or ResolveSpec error body
+func errorMessage(data []byte) string {
+	var body struct {
+		Message string           `json:"_error"`
+		Error   *common.APIError `json:"error"`
+	}
+	if err := json.Unmarshal(data, &body); err == nil {
+		if body.Message != "" {
+			return body.Message
+		}
+		if body.Error != nil && body.Error.Message != "" {
+			return body.Error.Message
+		}
+	}
+	return strings.TrimSpace(string(data))
+}
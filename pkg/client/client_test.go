@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+type testUser struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestQueryOptionsHeaders(t *testing.T) {
+	options := &QueryOptions{
+		Columns:     []string{"id", "name"},
+		OmitColumns: []string{"password"},
+		Filters:     []common.FilterOption{{Column: "status", Operator: "eq", Value: "active"}},
+		FilterGroup: &common.FilterGroup{
+			Logic: "OR",
+			Filters: []common.FilterOption{
+				{Column: "age", Operator: "gt", Value: 30},
+				{Column: "name", Operator: "ilike", Value: "%jo\"hn%"},
+			},
+		},
+		Sort:      []common.SortOption{{Column: "name"}, {Column: "created_at", Direction: "desc"}},
+		Preload:   []common.PreloadOption{{Relation: "Orders", Columns: []string{"id", "total"}, Where: "total > 10"}},
+		Expand:    []common.PreloadOption{{Relation: "Department"}},
+		Limit:     10,
+		Offset:    20,
+		SkipCount: true,
+		Headers:   map[string]string{"X-Limit": "5"},
+	}
+
+	headers, err := options.headers()
+	require.NoError(t, err)
+
+	assert.Equal(t, "id,name", headers["X-Select-Fields"])
+	assert.Equal(t, "password", headers["X-Not-Select-Fields"])
+	assert.Equal(t, "+name,-created_at", headers["X-Sort"])
+	assert.Equal(t, "Orders:id,total", headers["X-Preload-1"])
+	assert.Equal(t, "__"+base64.StdEncoding.EncodeToString([]byte("total > 10")), headers["X-Preload-1-Where"])
+	assert.Equal(t, "Department", headers["X-Expand-1"])
+	assert.Equal(t, "5", headers["X-Limit"], "explicit headers override generated ones")
+	assert.Equal(t, "20", headers["X-Offset"])
+	assert.Equal(t, "true", headers["X-Skipcount"])
+	assert.NotContains(t, headers, "X-Distinct")
+
+	raw, err := base64.StdEncoding.DecodeString(headers["X-Filter-Json"][2:])
+	require.NoError(t, err)
+	var group common.FilterGroup
+	require.NoError(t, json.Unmarshal(raw, &group))
+	assert.Equal(t, "AND", group.Logic)
+	require.Len(t, group.Filters, 1)
+	assert.Equal(t, "status", group.Filters[0].Column)
+	require.Len(t, group.Groups, 1)
+	assert.Equal(t, "OR", group.Groups[0].Logic)
+	assert.Len(t, group.Groups[0].Filters, 2)
+
+	empty, err := (*QueryOptions)(nil).headers()
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestClient(t *testing.T) {
+	var lastRequest *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastRequest = r
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/public/users":
+			_, _ = w.Write([]byte(`{"success":true,"data":[{"id":1,"name":"Jane"},{"id":2,"name":"John"}],"metadata":{"total":2,"count":2,"filtered":2}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/public/users/1":
+			_, _ = w.Write([]byte(`{"success":true,"data":[{"id":1,"name":"Jane"}]}`))
+		case r.Method == http.MethodGet:
+			_, _ = w.Write([]byte(`{"success":true,"data":[]}`))
+		case r.Method == http.MethodPost:
+			var user testUser
+			_ = json.NewDecoder(r.Body).Decode(&user)
+			user.ID = 3
+			_ = json.NewEncoder(w).Encode(user)
+		case r.Method == http.MethodPatch:
+			_, _ = w.Write([]byte(`{"id":1,"name":"Janet"}`))
+		case r.Method == http.MethodDelete:
+			_, _ = w.Write([]byte(`{"deleted":1}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"_error":"Invalid entity","_retval":1}`))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	users := NewClient[testUser](Config{
+		BaseURL: server.URL + "/api/",
+		Schema:  "public",
+		Entity:  "users",
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	})
+
+	result, err := users.List(ctx, &QueryOptions{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []testUser{{1, "Jane"}, {2, "John"}}, result.Items)
+	assert.Equal(t, int64(2), result.Metadata.Total)
+	assert.Equal(t, "Bearer token", lastRequest.Header.Get("Authorization"))
+	assert.Equal(t, "2", lastRequest.Header.Get("X-Limit"))
+	assert.Equal(t, "true", lastRequest.Header.Get("X-DetailApi"))
+	assert.Equal(t, "false", lastRequest.Header.Get("X-Single-Record-As-Object"))
+
+	user, err := users.Get(ctx, 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", user.Name)
+
+	_, err = users.Get(ctx, 99, nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	created, err := users.Create(ctx, testUser{Name: "Jim"})
+	require.NoError(t, err)
+	assert.Equal(t, testUser{3, "Jim"}, *created)
+	assert.Equal(t, "application/json", lastRequest.Header.Get("Content-Type"))
+
+	patched, err := users.Patch(ctx, 1, map[string]interface{}{"name": "Janet"})
+	require.NoError(t, err)
+	assert.Equal(t, "Janet", patched.Name)
+
+	deleted, err := users.Delete(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = users.Update(ctx, 1, testUser{Name: "x"})
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, "Invalid entity", apiErr.Message)
+}
+
+func TestQueryBuilder(t *testing.T) {
+	options := Query().
+		Select("id", "name").
+		Omit("password").
+		Where(Eq("status", "active"), In("role", "admin", "owner")).
+		Match(Or(Like("name", "J%"), IsNull("name"))).
+		Match(Or(Gt("age", 30), Between("score", 1, 5))).
+		OrderBy(Asc("name"), Desc("created_at")).
+		With(Relation("Orders", "id", "total")).
+		Join(Relation("Department")).
+		Page(20, 40)
+
+	assert.Equal(t, []string{"id", "name"}, options.Columns)
+	assert.Equal(t, []string{"password"}, options.OmitColumns)
+	assert.Equal(t, []common.FilterOption{
+		{Column: "status", Operator: "eq", Value: "active"},
+		{Column: "role", Operator: "in", Value: []interface{}{"admin", "owner"}},
+	}, options.Filters)
+	require.NotNil(t, options.FilterGroup)
+	assert.Equal(t, "AND", options.FilterGroup.Logic, "several groups must all match")
+	require.Len(t, options.FilterGroup.Groups, 2)
+	assert.Equal(t, "OR", options.FilterGroup.Groups[0].Logic)
+	assert.Equal(t, []interface{}{1, 5}, options.FilterGroup.Groups[1].Filters[1].Value)
+	assert.Equal(t, []common.SortOption{{Column: "name", Direction: "ASC"}, {Column: "created_at", Direction: "DESC"}}, options.Sort)
+	assert.Equal(t, "Orders", options.Preload[0].Relation)
+	assert.Equal(t, "Department", options.Expand[0].Relation)
+	assert.Equal(t, 20, options.Limit)
+	assert.Equal(t, 40, options.Offset)
+
+	headers, err := options.headers()
+	require.NoError(t, err)
+	assert.Equal(t, "+name,-created_at", headers["X-Sort"])
+	assert.Equal(t, "Orders:id,total", headers["X-Preload-1"])
+
+	assert.Empty(t, AnyOf(nil, &common.FilterGroup{}).Groups, "empty groups are dropped")
+}
+
+func TestHeaderValueEncoding(t *testing.T) {
+	assert.Equal(t, "id,name", headerValue("id,name"), "plain values stay readable")
+	for _, value := range []string{"naïve,größe", "line\nbreak"} {
+		encoded := headerValue(value)
+		require.True(t, len(encoded) > 4 && encoded[:4] == "ZIP_", value)
+		decoded, err := restheadspec.DecodeParam(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, value, decoded, "the server decodes the value")
+	}
+
+	headers, err := Query().Select("größe").OrderBy(Desc("straße")).headers()
+	require.NoError(t, err)
+	assert.Equal(t, EncodeHeaderValue("größe"), headers["X-Select-Fields"])
+	assert.Equal(t, EncodeHeaderValue("-straße"), headers["X-Sort"])
+}
+
+func TestForEntity(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		// A single record sent as an object still decodes
+		_, _ = w.Write([]byte(`{"success":true,"data":{"id":7,"name":"Jane"}}`))
+	}))
+	defer server.Close()
+
+	config := Config{BaseURL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}}
+	users := ForEntity[testUser](config, "public", "users")
+	teams := ForEntity[testUser](config, "", "teams")
+
+	user, err := users.Get(context.Background(), 7, nil)
+	require.NoError(t, err)
+	assert.Equal(t, testUser{7, "Jane"}, *user)
+	result, err := teams.List(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 1)
+	assert.Equal(t, []string{"/public/users/7", "/teams"}, paths)
+}
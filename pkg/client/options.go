@@ -0,0 +1,151 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// QueryOptions describes a read request. The client translates it into the
+// RestHeadSpec headers, so callers never build header strings by hand.
+type QueryOptions struct {
+	// Columns limits the returned columns (x-select-fields)
+	Columns []string
+	// OmitColumns excludes columns from the result (x-not-select-fields)
+	OmitColumns []string
+	// Filters are combined with AND. Use FilterGroup for OR logic; the
+	// LogicOperator of the individual filters is ignored.
+	Filters []common.FilterOption
+	// FilterGroup is a nested AND/OR expression, combined with Filters using AND
+	FilterGroup *common.FilterGroup
+	// Sort orders the result; Direction is "ASC" (default) or "DESC"
+	Sort []common.SortOption
+	// Preload loads relations in separate queries. Relation, Columns and Where are sent.
+	Preload []common.PreloadOption
+	// Expand joins belongsTo/hasOne relations into the main query. Relation and Columns are sent.
+	Expand []common.PreloadOption
+	// Limit and Offset page the result; zero values are not sent
+	Limit  int
+	Offset int
+	// Distinct returns only distinct rows
+	Distinct bool
+	// SkipCount skips the total count query; Metadata.Total is then zero
+	SkipCount bool
+	// Headers are sent as-is and override the generated headers
+	Headers map[string]string
+}
+
+// RequestHeaders returns the RestHeadSpec request headers of the options, for requests
+// made without a Client, e.g. in tests
+func (o *QueryOptions) RequestHeaders() (map[string]string, error) {
+	return o.headers()
+}
+
+// headers converts the options into RestHeadSpec request headers
+func (o *QueryOptions) headers() (map[string]string, error) {
+	headers := make(map[string]string)
+	if o == nil {
+		return headers, nil
+	}
+
+	if len(o.Columns) > 0 {
+		headers["X-Select-Fields"] = headerValue(strings.Join(o.Columns, ","))
+	}
+	if len(o.OmitColumns) > 0 {
+		headers["X-Not-Select-Fields"] = headerValue(strings.Join(o.OmitColumns, ","))
+	}
+
+	if group := o.filterGroup(); !group.IsEmpty() {
+		data, err := json.Marshal(group)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode filters: %w", err)
+		}
+		// Base64 keeps quotes and non-ASCII values intact in the header
+		headers["X-Filter-Json"] = "__" + base64.StdEncoding.EncodeToString(data)
+	}
+
+	if len(o.Sort) > 0 {
+		sorts := make([]string, 0, len(o.Sort))
+		for _, sort := range o.Sort {
+			if strings.EqualFold(sort.Direction, "DESC") {
+				sorts = append(sorts, "-"+sort.Column)
+			} else {
+				sorts = append(sorts, "+"+sort.Column)
+			}
+		}
+		headers["X-Sort"] = headerValue(strings.Join(sorts, ","))
+	}
+
+	for idx, preload := range o.Preload {
+		key := fmt.Sprintf("X-Preload-%d", idx+1)
+		headers[key] = headerValue(relationSpec(preload))
+		if preload.Where != "" {
+			headers[key+"-Where"] = "__" + base64.StdEncoding.EncodeToString([]byte(preload.Where))
+		}
+	}
+	for idx, expand := range o.Expand {
+		headers[fmt.Sprintf("X-Expand-%d", idx+1)] = headerValue(relationSpec(expand))
+	}
+
+	if o.Limit > 0 {
+		headers["X-Limit"] = strconv.Itoa(o.Limit)
+	}
+	if o.Offset > 0 {
+		headers["X-Offset"] = strconv.Itoa(o.Offset)
+	}
+	if o.Distinct {
+		headers["X-Distinct"] = "true"
+	}
+	if o.SkipCount {
+		headers["X-Skipcount"] = "true"
+	}
+
+	for key, value := range o.Headers {
+		headers[key] = value
+	}
+	return headers, nil
+}
+
+// filterGroup combines Filters and FilterGroup into a single expression
+func (o *QueryOptions) filterGroup() *common.FilterGroup {
+	switch {
+	case len(o.Filters) == 0:
+		return o.FilterGroup
+	case o.FilterGroup.IsEmpty():
+		return &common.FilterGroup{Logic: "AND", Filters: o.Filters}
+	default:
+		return &common.FilterGroup{
+			Logic:   "AND",
+			Filters: o.Filters,
+			Groups:  []common.FilterGroup{*o.FilterGroup},
+		}
+	}
+}
+
+// relationSpec renders a relation in the "Relation:col1,col2" header format
+func relationSpec(option common.PreloadOption) string {
+	if len(option.Columns) == 0 {
+		return option.Relation
+	}
+	return option.Relation + ":" + strings.Join(option.Columns, ",")
+}
+
+// EncodeHeaderValue encodes a header value in the ZIP_ base64 convention of RestHeadSpec, which
+// the server decodes for every header. Values that aren't plain ASCII need it to survive HTTP.
+func EncodeHeaderValue(value string) string {
+	return "ZIP_" + base64.StdEncoding.EncodeToString([]byte(value))
+}
+
+// headerValue sends plain values as they are, readable in logs, and encodes the others
+func headerValue(value string) string {
+	for i := 0; i < len(value); i++ {
+		if value[i] < 0x20 || value[i] > 0x7e {
+			return EncodeHeaderValue(value)
+		}
+	}
+	return value
+}
@@ -0,0 +1,219 @@
+/** Column names of a model */
+export type Column<T> = keyof T & string;
+
+/** Filter operators of RestHeadSpec */
+export type Operator =
+  | "eq" | "neq" | "gt" | "gte" | "lt" | "lte"
+  | "like" | "ilike" | "in"
+  | "between" | "between_inclusive"
+  | "is_null" | "is_not_null"
+  | "contains" | "contained_by" | "overlaps" | "any_eq";
+
+export interface Filter<T> {
+  column: Column<T>;
+  operator: Operator;
+  value?: unknown;
+}
+
+/** FilterGroup combines filters and nested groups with AND (default) or OR */
+export interface FilterGroup<T> {
+  logic?: "AND" | "OR";
+  filters?: Filter<T>[];
+  groups?: FilterGroup<T>[];
+}
+
+export interface Sort<T> {
+  column: Column<T>;
+  direction?: "ASC" | "DESC";
+}
+
+/** QueryOptions describes a read; the client sends it as RestHeadSpec headers */
+export interface QueryOptions<T> {
+  columns?: Column<T>[];
+  omitColumns?: Column<T>[];
+  /** Filters are combined with AND, use filterGroup for OR logic */
+  filters?: Filter<T>[];
+  filterGroup?: FilterGroup<T>;
+  sort?: Sort<T>[];
+  /** Relations loaded in separate queries, e.g. "Employees" */
+  preload?: string[];
+  /** belongsTo and hasOne relations joined into the main query */
+  expand?: string[];
+  limit?: number;
+  offset?: number;
+  /** Cursor paging: the primary key of the last (forward) or first (backward) record of a page */
+  cursorForward?: string;
+  cursorBackward?: string;
+  distinct?: boolean;
+  skipCount?: boolean;
+  /** Headers sent as-is, overriding the generated ones */
+  headers?: Record<string, string>;
+}
+
+export interface Metadata {
+  total: number;
+  count: number;
+  filtered: number;
+  limit: number;
+  offset: number;
+  row_number?: number;
+}
+
+export interface ListResult<T> {
+  items: T[];
+  metadata?: Metadata;
+}
+
+export interface ClientConfig {
+  /** URL the RestHeadSpec routes are mounted on, e.g. "/api" */
+  baseUrl: string;
+  /** Headers sent with every request, e.g. Authorization */
+  headers?: Record<string, string> | (() => Record<string, string> | Promise<Record<string, string>>);
+  fetch?: typeof fetch;
+}
+
+/** ApiError is thrown when the server answers with a non-2xx status */
+export class ApiError extends Error {
+  constructor(
+    public readonly status: number,
+    message: string,
+    public readonly code?: string,
+  ) {
+    super(message);
+    this.name = "ApiError";
+  }
+}
+
+/** EntityClient reads and writes the records of a single entity */
+export class EntityClient<T> {
+  constructor(
+    private readonly config: ClientConfig,
+    private readonly path: string,
+  ) {}
+
+  /** list returns the records matching the options */
+  async list(options?: QueryOptions<T>): Promise<ListResult<T>> {
+    const body = await this.request("GET", this.url(), readHeaders(options));
+    return { items: body?.data ?? [], metadata: body?.metadata };
+  }
+
+  /** get returns the record with the given primary key, or undefined */
+  async get(id: string | number, options?: QueryOptions<T>): Promise<T | undefined> {
+    const body = await this.request("GET", this.url(id), readHeaders(options));
+    const data = body?.data;
+    return Array.isArray(data) ? data[0] : data ?? undefined;
+  }
+
+  /** create inserts a record and returns it as stored */
+  async create(record: Partial<T>): Promise<T> {
+    return this.request("POST", this.url(), {}, record);
+  }
+
+  /** update sets the given columns of the record with the given primary key */
+  async update(id: string | number, record: Partial<T>): Promise<T> {
+    return this.request("PUT", this.url(id), {}, record);
+  }
+
+  /** patch is update with PATCH */
+  async patch(id: string | number, record: Partial<T>): Promise<T> {
+    return this.request("PATCH", this.url(id), {}, record);
+  }
+
+  /** delete removes the record with the given primary key and returns the number of deleted rows */
+  async delete(id: string | number): Promise<number> {
+    const body = await this.request("DELETE", this.url(id), {});
+    return body?.deleted ?? 0;
+  }
+
+  private url(id?: string | number): string {
+    const base = this.config.baseUrl.replace(/\/+$/, "");
+    const path = this.path.split("/").map(encodeURIComponent).join("/");
+    return id === undefined ? `${base}/${path}` : `${base}/${path}/${encodeURIComponent(String(id))}`;
+  }
+
+  // eslint-disable-next-line @typescript-eslint/no-explicit-any
+  private async request(method: string, url: string, headers: Record<string, string>, body?: unknown): Promise<any> {
+    const configHeaders = typeof this.config.headers === "function" ? await this.config.headers() : this.config.headers;
+    const doFetch = this.config.fetch ?? fetch;
+    const response = await doFetch(url, {
+      method,
+      headers: {
+        Accept: "application/json",
+        ...(body !== undefined ? { "Content-Type": "application/json" } : {}),
+        ...configHeaders,
+        ...headers,
+      },
+      body: body !== undefined ? JSON.stringify(body) : undefined,
+    });
+    const text = await response.text();
+    const data = text ? JSON.parse(text) : undefined;
+    if (!response.ok) {
+      const error = data?.error;
+      throw new ApiError(response.status, error?.message ?? data?._error ?? response.statusText, error?.code);
+    }
+    return data;
+  }
+}
+
+/** readHeaders translates read options into RestHeadSpec headers, in the detail response format */
+function readHeaders<T>(options?: QueryOptions<T>): Record<string, string> {
+  const headers: Record<string, string> = { "X-DetailApi": "true", "X-Single-Record-As-Object": "false" };
+  if (!options) {
+    return headers;
+  }
+  if (options.columns?.length) {
+    headers["X-Select-Fields"] = options.columns.join(",");
+  }
+  if (options.omitColumns?.length) {
+    headers["X-Not-Select-Fields"] = options.omitColumns.join(",");
+  }
+  const group = filterGroup(options);
+  if (group) {
+    // Base64 keeps quotes and non-ASCII values intact in the header
+    headers["X-Filter-Json"] = "__" + base64(JSON.stringify(group));
+  }
+  if (options.sort?.length) {
+    headers["X-Sort"] = options.sort.map((s) => (s.direction === "DESC" ? "-" : "+") + s.column).join(",");
+  }
+  options.preload?.forEach((relation, i) => {
+    headers[`X-Preload-${i + 1}`] = relation;
+  });
+  options.expand?.forEach((relation, i) => {
+    headers[`X-Expand-${i + 1}`] = relation;
+  });
+  if (options.limit) {
+    headers["X-Limit"] = String(options.limit);
+  }
+  if (options.offset) {
+    headers["X-Offset"] = String(options.offset);
+  }
+  if (options.cursorForward) {
+    headers["X-Cursor-Forward"] = options.cursorForward;
+  }
+  if (options.cursorBackward) {
+    headers["X-Cursor-Backward"] = options.cursorBackward;
+  }
+  if (options.distinct) {
+    headers["X-Distinct"] = "true";
+  }
+  if (options.skipCount) {
+    headers["X-Skipcount"] = "true";
+  }
+  return { ...headers, ...options.headers };
+}
+
+function filterGroup<T>(options: QueryOptions<T>): FilterGroup<T> | undefined {
+  const filters = options.filters ?? [];
+  if (filters.length === 0) {
+    return options.filterGroup;
+  }
+  return { logic: "AND", filters, groups: options.filterGroup ? [options.filterGroup] : [] };
+}
+
+function base64(text: string): string {
+  let binary = "";
+  new TextEncoder().encode(text).forEach((byte) => {
+    binary += String.fromCharCode(byte);
+  });
+  return btoa(binary);
+}
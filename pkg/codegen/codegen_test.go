@@ -0,0 +1,170 @@
+package codegen
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
+	"github.com/bitechdev/ResolveSpec/pkg/testmodels"
+)
+
+type auditFields struct {
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+type codegenCustomer struct {
+	ID      int64  `json:"id" gorm:"primaryKey"`
+	Name    string `json:"name"`
+	Country string `json:"country" resolvespec:"enum:de,fr,nl"`
+}
+
+type codegenOrder struct {
+	auditFields
+	ID         int64              `json:"id" gorm:"primaryKey"`
+	Status     string             `json:"status" validate:"oneof=open paid"`
+	Total      common.SqlFloat64  `json:"total"`
+	ShippedOn  common.SqlDate     `json:"shipped_on"`
+	Quantity   common.SqlInt32    `json:"quantity"`
+	Reference  int64              `json:"reference,string"`
+	Tags       []string           `json:"tags"`
+	Attributes map[string]float64 `json:"attributes"`
+	Document   []byte             `json:"document"`
+	Notes      *string            `json:"notes,omitempty"`
+	Secret     string             `json:"-"`
+	internal   string             //nolint:unused
+	CustomerID int64              `json:"customer_id"`
+	Customer   *codegenCustomer   `json:"customer,omitempty"`
+	Lines      []codegenLine      `json:"lines,omitempty"`
+}
+
+type codegenLine struct {
+	ID       int64  `json:"id"`
+	Product  string `json:"product"`
+	UnitCost *int   `json:"unit-cost"`
+}
+
+func testRegistry(t *testing.T) *modelregistry.DefaultModelRegistry {
+	registry := modelregistry.NewModelRegistry()
+	require.NoError(t, registry.RegisterModel("sales.orders", codegenOrder{}))
+	require.NoError(t, registry.RegisterModel("sales.customers", &codegenCustomer{}))
+	require.NoError(t, registry.RegisterModel("order_lines", codegenLine{}))
+	return registry
+}
+
+func TestTypeScriptInterfaces(t *testing.T) {
+	source, err := TypeScript(testRegistry(t), Options{TypesOnly: true})
+	require.NoError(t, err)
+	ts := string(source)
+
+	assert.Contains(t, ts, "// Code generated by resolvespec-gen. DO NOT EDIT.")
+	assert.NotContains(t, ts, "class EntityClient", "types only leaves out the client")
+	assert.NotContains(t, ts, "createClient")
+
+	for _, field := range []string{
+		"  created_at: string;\n",
+		"  deleted_at: string | null;\n",
+		"  id: number;\n",
+		"  status: \"open\" | \"paid\";\n",
+		"  total: number | null;\n",
+		"  shipped_on: string | null;\n",
+		"  quantity: number;\n",
+		"  reference: string;\n",
+		"  tags: string[];\n",
+		"  attributes: Record<string, number>;\n",
+		"  document: string;\n",
+		"  notes?: string | null;\n",
+		"  customer?: CodegenCustomer | null;\n",
+		"  lines?: CodegenLine[];\n",
+		"  country: \"de\" | \"fr\" | \"nl\";\n",
+		"  \"unit-cost\": number | null;\n",
+	} {
+		assert.Contains(t, ts, field)
+	}
+	assert.NotContains(t, ts, "Secret")
+	assert.NotContains(t, ts, "internal")
+	assert.NotContains(t, ts, "auditFields", "embedded structs are flattened")
+
+	assert.Equal(t, 1, bytes.Count(source, []byte("export interface CodegenLine {")), "models referenced twice are rendered once")
+	assert.Contains(t, ts, "export interface Entities {\n  \"order_lines\": CodegenLine;\n  \"sales/customers\": CodegenCustomer;\n  \"sales/orders\": CodegenOrder;\n}\n")
+}
+
+func TestTypeScriptClient(t *testing.T) {
+	source, err := TypeScript(testRegistry(t), Options{})
+	require.NoError(t, err)
+	ts := string(source)
+
+	assert.Contains(t, ts, "export class EntityClient<T>")
+	assert.Contains(t, ts, "\"X-Filter-Json\"")
+	assert.Contains(t, ts, "    orderLines: new EntityClient<CodegenLine>(config, \"order_lines\"),\n")
+	assert.Contains(t, ts, "    salesCustomers: new EntityClient<CodegenCustomer>(config, \"sales/customers\"),\n")
+	assert.Contains(t, ts, "    salesOrders: new EntityClient<CodegenOrder>(config, \"sales/orders\"),\n")
+}
+
+func TestTypeScriptSchema(t *testing.T) {
+	source, err := TypeScript(testRegistry(t), Options{Schema: "sales", TypesOnly: true})
+	require.NoError(t, err)
+	assert.Contains(t, string(source), "\"sales/orders\": CodegenOrder;")
+	assert.NotContains(t, string(source), "\"order_lines\"")
+	// Referenced by orders, the lines still get an interface
+	assert.Contains(t, string(source), "export interface CodegenLine {")
+
+	_, err = TypeScript(testRegistry(t), Options{Schema: "missing"})
+	assert.Error(t, err)
+}
+
+func TestTypeNames(t *testing.T) {
+	type Page[T any] struct {
+		Items []T `json:"items"`
+	}
+	gen := newGenerator()
+	assert.Equal(t, "CodegenLine", gen.typeName(modelType(codegenLine{})))
+	assert.Equal(t, "CodegenLine", gen.typeName(modelType([]*codegenLine{})))
+	assert.Equal(t, "PageCodegenLine", gen.typeName(modelType(Page[codegenLine]{})))
+
+	other := gen.typeName(modelType(struct{}{}))
+	assert.Equal(t, "Anonymous", other)
+}
+
+func TestCommand(t *testing.T) {
+	var stdout bytes.Buffer
+	require.NoError(t, Command(testRegistry(t), []string{"-types-only"}, &stdout))
+	assert.Contains(t, stdout.String(), "export interface CodegenOrder {")
+
+	out := filepath.Join(t.TempDir(), "api.ts")
+	require.NoError(t, Command(testRegistry(t), []string{"-out", out, "-schema", "sales"}, &stdout))
+	written, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(written), "export function createClient(config: ClientConfig)")
+
+	assert.Error(t, Command(testRegistry(t), []string{"-unknown"}, &stdout))
+	assert.Error(t, Command(testRegistry(t), []string{"-lang", "rust"}, &stdout))
+}
+
+func TestGo(t *testing.T) {
+	registry := modelregistry.NewModelRegistry()
+	testmodels.RegisterTestModels(registry)
+	require.NoError(t, registry.RegisterModel("hr.departments", testmodels.Department{}))
+
+	source, err := Go(registry, Options{Package: "api"})
+	require.NoError(t, err)
+	code := string(source)
+
+	assert.Contains(t, code, "package api\n")
+	assert.Contains(t, code, "testmodels \"github.com/bitechdev/ResolveSpec/pkg/testmodels\"")
+	assert.Contains(t, code, "func (a *API) Departments() *client.Client[testmodels.Department] {\n\treturn client.ForEntity[testmodels.Department](a.config, \"\", \"departments\")\n}")
+	assert.Contains(t, code, "func (a *API) HrDepartments() *client.Client[testmodels.Department] {\n\treturn client.ForEntity[testmodels.Department](a.config, \"hr\", \"departments\")\n}")
+	assert.Contains(t, code, "func (a *API) ProjectTasks() *client.Client[testmodels.ProjectTask]")
+
+	_, err = Go(testRegistry(t), Options{})
+	assert.Error(t, err, "unexported models can't be referenced")
+	_, err = Go(registry, Options{Package: "my-api"})
+	assert.Error(t, err)
+}
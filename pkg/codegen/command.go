@@ -0,0 +1,59 @@
+package codegen
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
+)
+
+// Main runs the resolvespec-gen command line for the models of a registry and exits on errors.
+// A generator of an application is a main package registering its models:
+//
+//	func main() {
+//		registry := modelregistry.NewModelRegistry()
+//		models.Register(registry)
+//		codegen.Main(registry)
+//	}
+func Main(registry *modelregistry.DefaultModelRegistry) {
+	if err := Command(registry, os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "resolvespec-gen:", err)
+		os.Exit(1)
+	}
+}
+
+// Command parses the arguments of resolvespec-gen and generates the TypeScript module, or with
+// -lang go the Go client package, to the file given by -out or to stdout
+func Command(registry *modelregistry.DefaultModelRegistry, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("resolvespec-gen", flag.ContinueOnError)
+	out := flags.String("out", "", "file to write the generated code to (default stdout)")
+	schema := flags.String("schema", "", "generate only the models of this schema")
+	typesOnly := flags.Bool("types-only", false, "generate the model interfaces without the client")
+	lang := flags.String("lang", "ts", "language to generate: ts or go")
+	pkg := flags.String("package", "", "name of the generated Go package (default apiclient)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	options := Options{Schema: *schema, TypesOnly: *typesOnly, Package: *pkg}
+	var source []byte
+	var err error
+	switch *lang {
+	case "ts":
+		source, err = TypeScript(registry, options)
+	case "go":
+		source, err = Go(registry, options)
+	default:
+		return fmt.Errorf("unknown language %q", *lang)
+	}
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = stdout.Write(source)
+		return err
+	}
+	return os.WriteFile(*out, source, 0o644)
+}
@@ -0,0 +1,261 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	rawJSONType   = reflect.TypeOf(json.RawMessage{})
+)
+
+// generator collects the interfaces of the struct types reachable from the models
+type generator struct {
+	names    map[reflect.Type]string
+	taken    map[string]reflect.Type
+	order    []reflect.Type
+	rendered map[reflect.Type]bool
+}
+
+func newGenerator() *generator {
+	return &generator{
+		names:    make(map[reflect.Type]string),
+		taken:    make(map[string]reflect.Type),
+		rendered: make(map[reflect.Type]bool),
+	}
+}
+
+// modelType unwraps pointers, slices and arrays to the struct type of a model
+func modelType(model interface{}) reflect.Type {
+	typ := reflect.TypeOf(model)
+	for typ != nil && (typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// typeName returns the interface name of a struct type and queues it for rendering. Types of
+// the same name from different packages are told apart by their package name.
+func (g *generator) typeName(typ reflect.Type) string {
+	if name, ok := g.names[typ]; ok {
+		return name
+	}
+	name := identifier(typ.Name())
+	if base, args, ok := strings.Cut(typ.Name(), "["); ok {
+		// Instantiated generic types are named like Page[example.com/pkg.User], named PageUser
+		name = identifier(base)
+		for _, arg := range strings.Split(strings.TrimSuffix(args, "]"), ",") {
+			name += identifier(arg[strings.LastIndex(arg, ".")+1:])
+		}
+	}
+	if name == "" {
+		name = "Anonymous"
+	}
+	if other, ok := g.taken[name]; ok && other != typ {
+		pkg := typ.PkgPath()
+		name = exportedName(pkg[strings.LastIndex(pkg, "/")+1:]) + name
+		for suffix := 2; g.taken[name] != nil; suffix++ {
+			name = fmt.Sprintf("%s%d", strings.TrimRight(name, "0123456789"), suffix)
+		}
+	}
+	g.names[typ] = name
+	g.taken[name] = typ
+	g.order = append(g.order, typ)
+	return name
+}
+
+// writeInterfaces renders the queued struct types, including the ones their fields reference
+func (g *generator) writeInterfaces(out *bytes.Buffer) {
+	for i := 0; i < len(g.order); i++ {
+		typ := g.order[i]
+		if g.rendered[typ] {
+			continue
+		}
+		g.rendered[typ] = true
+
+		fmt.Fprintf(out, "export interface %s {\n", g.names[typ])
+		g.writeFields(out, typ)
+		out.WriteString("}\n\n")
+	}
+}
+
+// writeFields renders the JSON fields of a struct, flattening embedded structs like encoding/json
+func (g *generator) writeFields(out *bytes.Buffer, typ reflect.Type) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.writeFields(out, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		tsType := g.tsType(field.Type)
+		if values := common.EnumValues(field); len(values) > 0 {
+			literals := make([]string, len(values))
+			for j, value := range values {
+				literals[j] = fmt.Sprintf("%q", value)
+			}
+			tsType = strings.Join(literals, " | ")
+			if field.Type.Kind() == reflect.Ptr {
+				tsType += " | null"
+			}
+		} else if strings.Contains(","+options+",", ",string,") {
+			tsType = "string"
+		}
+
+		optional := ""
+		if strings.Contains(","+options+",", ",omitempty,") || strings.Contains(","+options+",", ",omitzero,") {
+			optional = "?"
+		}
+		fmt.Fprintf(out, "  %s%s: %s;\n", propertyName(name), optional, tsType)
+	}
+}
+
+// tsType maps a Go type to the TypeScript type of its JSON encoding
+func (g *generator) tsType(typ reflect.Type) string {
+	if typ.Kind() == reflect.Ptr {
+		inner := g.tsType(typ.Elem())
+		if strings.HasSuffix(inner, " | null") {
+			return inner
+		}
+		return inner + " | null"
+	}
+	if typ == timeType {
+		return "string"
+	}
+	if typ == rawJSONType {
+		return "unknown"
+	}
+	if typ.Implements(marshalerType) || reflect.PointerTo(typ).Implements(marshalerType) {
+		return g.marshalerType(typ)
+	}
+
+	if basic, ok := basicType(typ.Kind()); ok {
+		return basic
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			// Byte slices are encoded as base64 strings
+			return "string"
+		}
+		return arrayOf(g.tsType(typ.Elem()))
+	case reflect.Map:
+		return fmt.Sprintf("Record<string, %s>", g.tsType(typ.Elem()))
+	case reflect.Struct:
+		return g.typeName(typ)
+	default:
+		return "unknown"
+	}
+}
+
+// marshalerType maps a type with its own JSON encoding, like the SQL types of common: basic
+// kinds keep their type, times and nullable wrappers may be null, anything else is unknown
+func (g *generator) marshalerType(typ reflect.Type) string {
+	if basic, ok := basicType(typ.Kind()); ok {
+		return basic
+	}
+	if typ.Kind() == reflect.Struct {
+		if typ.ConvertibleTo(timeType) {
+			return "string | null"
+		}
+		if nullable, ok := nullableValue(typ); ok {
+			return g.tsType(nullable) + " | null"
+		}
+	}
+	return "unknown"
+}
+
+// basicType maps the kinds JSON encodes as strings, numbers and booleans
+func basicType(kind reflect.Kind) (string, bool) {
+	switch kind {
+	case reflect.String:
+		return "string", true
+	case reflect.Bool:
+		return "boolean", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number", true
+	}
+	return "", false
+}
+
+// nullableValue returns the value type of nullable wrappers like common.SqlFloat64: a value field
+// and Valid
+func nullableValue(typ reflect.Type) (reflect.Type, bool) {
+	if typ.NumField() != 2 {
+		return nil, false
+	}
+	if valid, ok := typ.FieldByName("Valid"); !ok || valid.Type.Kind() != reflect.Bool {
+		return nil, false
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		if field := typ.Field(i); field.Name != "Valid" {
+			return field.Type, true
+		}
+	}
+	return nil, false
+}
+
+func arrayOf(element string) string {
+	if strings.Contains(element, " ") {
+		return "(" + element + ")[]"
+	}
+	return element + "[]"
+}
+
+// propertyName quotes property names that aren't identifiers
+func propertyName(name string) string {
+	for i, r := range name {
+		if !(r == '_' || r == '$' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return fmt.Sprintf("%q", name)
+		}
+	}
+	return name
+}
+
+// identifier drops the characters of a Go type name that aren't allowed in a TypeScript name
+func identifier(name string) string {
+	return exportedName(strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, name))
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return ""
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
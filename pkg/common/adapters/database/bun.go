@@ -8,15 +8,20 @@ import (
 	"strings"
 
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/feature"
+	"github.com/uptrace/bun/schema"
 
 	"github.com/bitechdev/ResolveSpec/pkg/common"
 	"github.com/bitechdev/ResolveSpec/pkg/logger"
 	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
 	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+	"github.com/bitechdev/ResolveSpec/pkg/tracing"
 )
 
 // BunAdapter adapts Bun to work with our Database interface
 // This demonstrates how the abstraction works with different ORMs
+// Bun renders the values of queries into their SQL instead of binding them, so no two runs of a
+// query share a statement and the adapter has no statement cache, unlike SQLAdapter.
 type BunAdapter struct {
 	db *bun.DB
 }
@@ -26,6 +31,21 @@ func NewBunAdapter(db *bun.DB) *BunAdapter {
 	return &BunAdapter{db: db}
 }
 
+// Dialect implements common.Database
+func (b *BunAdapter) Dialect() common.Dialect {
+	return common.LookupDialect(normalizeDialectName(b.db.Dialect().Name().String()))
+}
+
+// RelationReference implements common.RelationReferenceProvider
+func (b *BunAdapter) RelationReference(model interface{}, relation string) string {
+	return bunRelationReference(b.db.Dialect(), model, relation)
+}
+
+// SQLDB implements common.SQLDBProvider
+func (b *BunAdapter) SQLDB() (*sql.DB, error) {
+	return b.db.DB, nil
+}
+
 func (b *BunAdapter) NewSelect() common.SelectQuery {
 	return &BunSelectQuery{
 		query: b.db.NewSelect(),
@@ -46,16 +66,22 @@ func (b *BunAdapter) NewDelete() common.DeleteQuery {
 }
 
 func (b *BunAdapter) Exec(ctx context.Context, query string, args ...interface{}) (res common.Result, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "bun", "exec", tracing.AttrDBStatement.String(query))
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("BunAdapter.Exec", r)
 		}
 	}()
 	result, err := b.db.ExecContext(ctx, query, args...)
-	return &BunResult{result: result}, err
+	res = &BunResult{result: result}
+	span.SetAttributes(tracing.AttrRowsAffected.Int64(res.RowsAffected()))
+	return res, err
 }
 
 func (b *BunAdapter) Query(ctx context.Context, dest interface{}, query string, args ...interface{}) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "bun", "query", tracing.AttrDBStatement.String(query))
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("BunAdapter.Query", r)
@@ -106,7 +132,8 @@ type BunSelectQuery struct {
 	schema           string  // Separated schema name
 	tableName        string  // Just the table name, without schema
 	tableAlias       string
-	deferredPreloads []deferredPreload // Preloads to execute as separate queries
+	deferredPreloads []deferredPreload   // Preloads to execute as separate queries
+	tenant           *common.TenantScope // Tenant of the relations, see ScopeTenantRelations
 }
 
 // deferredPreload represents a preload that will be executed as a separate query
@@ -134,6 +161,14 @@ func (b *BunSelectQuery) Model(model interface{}) common.SelectQuery {
 	return b
 }
 
+// TableReference returns the alias bun selects the table of the model as, or "" without a model
+func (b *BunSelectQuery) TableReference() string {
+	if model, ok := b.query.GetModel().(bun.TableModel); ok && model.Table() != nil {
+		return model.Table().Alias
+	}
+	return ""
+}
+
 func (b *BunSelectQuery) Table(table string) common.SelectQuery {
 	b.query = b.query.Table(table)
 	// Check if the table name contains schema (e.g., "schema.table")
@@ -147,21 +182,41 @@ func (b *BunSelectQuery) Column(columns ...string) common.SelectQuery {
 }
 
 func (b *BunSelectQuery) ColumnExpr(query string, args ...interface{}) common.SelectQuery {
-	b.query = b.query.ColumnExpr(query, args)
+	b.query = b.query.ColumnExpr(query, args...)
 
 	return b
 }
 
 func (b *BunSelectQuery) Where(query string, args ...interface{}) common.SelectQuery {
-	b.query = b.query.Where(query, args...)
+	b.query = b.query.Where(query, bunInArgs(query, args)...)
 	return b
 }
 
 func (b *BunSelectQuery) WhereOr(query string, args ...interface{}) common.SelectQuery {
-	b.query = b.query.WhereOr(query, args...)
+	b.query = b.query.WhereOr(query, bunInArgs(query, args)...)
 	return b
 }
 
+// bunInArgs wraps slice arguments with bun.In when the condition uses "IN (?)",
+// since Bun would otherwise format the slice as a single JSON/array value
+func bunInArgs(query string, args []interface{}) []interface{} {
+	if !strings.Contains(strings.ToUpper(query), "IN (?)") {
+		return args
+	}
+
+	wrapped := make([]interface{}, len(args))
+	for i, arg := range args {
+		wrapped[i] = arg
+		if arg == nil {
+			continue
+		}
+		if v := reflect.ValueOf(arg); v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+			wrapped[i] = bun.In(arg)
+		}
+	}
+	return wrapped
+}
+
 func (b *BunSelectQuery) Join(query string, args ...interface{}) common.SelectQuery {
 	// Extract optional prefix from args
 	// If the last arg is a string that looks like a table prefix, use it
@@ -238,10 +293,56 @@ func (b *BunSelectQuery) Preload(relation string, conditions ...interface{}) com
 	// Bun uses Relation() method for preloading
 	// For now, we'll just pass the relation name without conditions
 	// TODO: Implement proper condition handling for Bun
-	b.query = b.query.Relation(relation)
+	b.query = scopeBunRelation(b.query, b.tenant, relation).Relation(relation)
 	return b
 }
 
+// ScopeTenantRelations implements common.TenantRelationScoper. The tenant condition is added to
+// the ON clause of joined relations and to the queries of has-many and many-to-many relations.
+func (b *BunSelectQuery) ScopeTenantRelations(scope *common.TenantScope) bool {
+	b.tenant = scope
+	return true
+}
+
+// scopeBunRelation adds the tenant condition to each relation along a relation path whose table
+// has the tenant column, e.g. to Project and Project.Owner for Project.Owner. Joined relations
+// are aliased by the path of relations since the last has-many or many-to-many relation,
+// like bun does.
+func scopeBunRelation(query *bun.SelectQuery, scope *common.TenantScope, relation string) *bun.SelectQuery {
+	model, ok := query.GetModel().(bun.TableModel)
+	if scope == nil || !ok || model.Table() == nil {
+		return query
+	}
+	table := model.Table()
+	path := strings.Split(relation, ".")
+	alias := ""
+	for i, name := range path {
+		rel, ok := table.Relations[name]
+		if !ok {
+			return query // Bun reports the unknown relation
+		}
+		table = rel.JoinTable
+		reference := string(table.SQLAlias)
+		if rel.Type == schema.HasOneRelation || rel.Type == schema.BelongsToRelation {
+			if alias != "" {
+				alias += "__"
+			}
+			alias += rel.Field.Name
+			reference = alias
+		} else {
+			alias = ""
+		}
+		if table.HasField(scope.Column) {
+			query = query.RelationWithOpts(strings.Join(path[:i+1], "."), bun.RelationOpts{
+				AdditionalJoinOnConditions: []schema.QueryWithArgs{
+					schema.SafeQuery(scope.Condition(reference), []interface{}{scope.Tenant}),
+				},
+			})
+		}
+	}
+	return query
+}
+
 // // shortenAliasForPostgres shortens a table/relation alias if it would exceed PostgreSQL's 63-char limit
 // // when combined with typical column names
 // func shortenAliasForPostgres(relationPath string) (string, bool) {
@@ -310,7 +411,7 @@ func (b *BunSelectQuery) PreloadRelation(relation string, apply ...func(common.S
 				firstLevel, remainingPath)
 
 			// Apply the first level preload normally
-			b.query = b.query.Relation(firstLevel)
+			b.query = scopeBunRelation(b.query, b.tenant, firstLevel).Relation(firstLevel)
 
 			// Store the remaining nested preload to be executed after the main query
 			b.deferredPreloads = append(b.deferredPreloads, deferredPreload{
@@ -328,7 +429,7 @@ func (b *BunSelectQuery) PreloadRelation(relation string, apply ...func(common.S
 	}
 
 	// Normal preload handling
-	b.query = b.query.Relation(relation, func(sq *bun.SelectQuery) *bun.SelectQuery {
+	b.query = scopeBunRelation(b.query, b.tenant, relation).Relation(relation, func(sq *bun.SelectQuery) *bun.SelectQuery {
 		defer func() {
 			if r := recover(); r != nil {
 				err := logger.HandlePanic("BunSelectQuery.PreloadRelation", r)
@@ -369,11 +470,45 @@ func (b *BunSelectQuery) PreloadRelation(relation string, apply ...func(common.S
 	return b
 }
 
+func (b *BunSelectQuery) JoinRelation(relation string, apply ...func(common.SelectQuery) common.SelectQuery) common.SelectQuery {
+	// Bun joins belongs-to and has-one relations natively, aliasing the
+	// related columns as <relation>__<column>
+	b.query = scopeBunRelation(b.query, b.tenant, relation).Relation(relation, func(sq *bun.SelectQuery) *bun.SelectQuery {
+		if len(apply) == 0 {
+			return sq
+		}
+
+		current := common.SelectQuery(&BunSelectQuery{query: sq, db: b.db})
+		for _, fn := range apply {
+			if fn != nil {
+				current = fn(current)
+			}
+		}
+
+		if finalBun, ok := current.(*BunSelectQuery); ok {
+			return finalBun.query
+		}
+
+		return sq // fallback
+	})
+	return b
+}
+
 func (b *BunSelectQuery) Order(order string) common.SelectQuery {
+	// Bun quotes the column of an order, so orders with quoted identifiers are used as they are
+	if strings.ContainsAny(order, "\"`") {
+		b.query = b.query.OrderExpr(order)
+		return b
+	}
 	b.query = b.query.Order(order)
 	return b
 }
 
+func (b *BunSelectQuery) OrderExpr(order string, args ...interface{}) common.SelectQuery {
+	b.query = b.query.OrderExpr(order, args...)
+	return b
+}
+
 func (b *BunSelectQuery) Limit(n int) common.SelectQuery {
 	b.query = b.query.Limit(n)
 	return b
@@ -384,7 +519,13 @@ func (b *BunSelectQuery) Offset(n int) common.SelectQuery {
 	return b
 }
 
+// Group groups by a column, or by an expression or list of columns, which Bun's Group would
+// quote as a single column name
 func (b *BunSelectQuery) Group(group string) common.SelectQuery {
+	if strings.ContainsAny(group, "(), '") {
+		b.query = b.query.GroupExpr(group)
+		return b
+	}
 	b.query = b.query.Group(group)
 	return b
 }
@@ -394,7 +535,25 @@ func (b *BunSelectQuery) Having(having string, args ...interface{}) common.Selec
 	return b
 }
 
+func (b *BunSelectQuery) Distinct() common.SelectQuery {
+	b.query = b.query.Distinct()
+	return b
+}
+
+func (b *BunSelectQuery) DistinctOn(columns ...string) common.SelectQuery {
+	if dialect := common.LookupDialect(normalizeDialectName(b.query.Dialect().Name().String())); !dialect.DistinctOn {
+		b.query = b.query.Err(fmt.Errorf("DISTINCT ON is not supported by the %s database", dialect.Name))
+		return b
+	}
+	for _, col := range columns {
+		b.query = b.query.DistinctOn(col)
+	}
+	return b
+}
+
 func (b *BunSelectQuery) Scan(ctx context.Context, dest interface{}) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "bun", "select", tracing.AttrTable.String(b.tableName))
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("BunSelectQuery.Scan", r)
@@ -409,6 +568,7 @@ func (b *BunSelectQuery) Scan(ctx context.Context, dest interface{}) (err error)
 	if err != nil {
 		return err
 	}
+	span.SetAttributes(tracing.AttrRowCount.Int(scannedRowCount(dest)))
 
 	// Execute any deferred preloads
 	if len(b.deferredPreloads) > 0 {
@@ -423,6 +583,8 @@ func (b *BunSelectQuery) Scan(ctx context.Context, dest interface{}) (err error)
 }
 
 func (b *BunSelectQuery) ScanModel(ctx context.Context) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "bun", "select", tracing.AttrTable.String(b.tableName))
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("BunSelectQuery.ScanModel", r)
@@ -437,6 +599,7 @@ func (b *BunSelectQuery) ScanModel(ctx context.Context) (err error) {
 	if err != nil {
 		return err
 	}
+	span.SetAttributes(tracing.AttrRowCount.Int(scannedRowCount(b.query.GetModel().Value())))
 
 	// Execute any deferred preloads
 	if len(b.deferredPreloads) > 0 {
@@ -451,6 +614,11 @@ func (b *BunSelectQuery) ScanModel(ctx context.Context) (err error) {
 	return nil
 }
 
+// ToSQL renders the main SELECT statement; deferred preloads run as separate queries
+func (b *BunSelectQuery) ToSQL() (string, []interface{}, error) {
+	return bunSQL(b.query, b.query.DB())
+}
+
 // executeDeferredPreloads executes preloads that were deferred to avoid PostgreSQL identifier length limits
 func (b *BunSelectQuery) executeDeferredPreloads(ctx context.Context, dest interface{}) error {
 	if len(b.deferredPreloads) == 0 {
@@ -539,8 +707,8 @@ func (b *BunSelectQuery) loadChildRelationForRecord(ctx context.Context, record
 
 	// Load the child relation on the parent record
 	// This uses a shorter alias since we're only loading "Child", not "Parent.Child"
-	return b.db.NewSelect().
-		Model(parentValue).
+	query := scopeBunRelation(b.db.NewSelect().Model(parentValue), b.tenant, childRelation)
+	return query.
 		Relation(childRelation, func(sq *bun.SelectQuery) *bun.SelectQuery {
 			// Apply any custom query modifications
 			if len(apply) > 0 {
@@ -561,6 +729,8 @@ func (b *BunSelectQuery) loadChildRelationForRecord(ctx context.Context, record
 }
 
 func (b *BunSelectQuery) Count(ctx context.Context) (count int, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "bun", "count", tracing.AttrTable.String(b.tableName))
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("BunSelectQuery.Count", r)
@@ -568,6 +738,7 @@ func (b *BunSelectQuery) Count(ctx context.Context) (count int, err error) {
 		}
 	}()
 	// If Model() was set, use bun's native Count() which works properly
+	// Bun wraps DISTINCT and DISTINCT ON queries in a CTE so only distinct rows are counted
 	if b.hasModel {
 		count, err := b.query.Count(ctx)
 		return count, err
@@ -583,6 +754,8 @@ func (b *BunSelectQuery) Count(ctx context.Context) (count int, err error) {
 }
 
 func (b *BunSelectQuery) Exists(ctx context.Context) (exists bool, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "bun", "exists", tracing.AttrTable.String(b.tableName))
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("BunSelectQuery.Exists", r)
@@ -594,9 +767,14 @@ func (b *BunSelectQuery) Exists(ctx context.Context) (exists bool, err error) {
 
 // BunInsertQuery implements InsertQuery for Bun
 type BunInsertQuery struct {
-	query    *bun.InsertQuery
-	values   map[string]interface{}
-	hasModel bool
+	query         *bun.InsertQuery
+	values        map[string]interface{}
+	valuesApplied bool
+	hasModel      bool
+	returning     bool
+	bulk          reflect.Value // Slice of models inserted in chunks of chunkSize rows
+	bulkModels    interface{}
+	chunkSize     int
 }
 
 func (b *BunInsertQuery) Model(model interface{}) common.InsertQuery {
@@ -628,37 +806,103 @@ func (b *BunInsertQuery) OnConflict(action string) common.InsertQuery {
 
 func (b *BunInsertQuery) Returning(columns ...string) common.InsertQuery {
 	if len(columns) > 0 {
-		b.query = b.query.Returning(columns[0])
+		b.query = b.query.Returning(strings.Join(columns, ", "))
+		b.returning = true
+	}
+	return b
+}
+
+// Bulk inserts the models of a slice with a multi-row INSERT per chunk of chunkSize rows
+func (b *BunInsertQuery) Bulk(models interface{}, chunkSize int) common.InsertQuery {
+	if chunkSize <= 0 {
+		chunkSize = common.DefaultInsertChunkSize
 	}
+	b.bulk = reflect.Indirect(reflect.ValueOf(models))
+	b.bulkModels = models
+	b.chunkSize = chunkSize
+	b.query = b.query.Model(models)
+	b.hasModel = true
 	return b
 }
 
 func (b *BunInsertQuery) Exec(ctx context.Context) (res common.Result, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "bun", "insert")
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("BunInsertQuery.Exec", r)
 		}
 	}()
-	if len(b.values) > 0 {
-		if !b.hasModel {
-			// If no model was set, use the values map as the model
-			// Bun can insert map[string]interface{} directly
-			b.query = b.query.Model(&b.values)
-		} else {
-			// If model was set, use Value() to add individual values
-			for k, v := range b.values {
-				b.query = b.query.Value(k, "?", v)
-			}
-		}
+	b.applyValues()
+	if b.bulk.IsValid() {
+		res, err = b.execChunks(ctx)
+		span.SetAttributes(tracing.AttrRowsAffected.Int64(res.RowsAffected()))
+		return res, err
 	}
 	result, err := b.query.Exec(ctx)
-	return &BunResult{result: result}, err
+	bunResult := &BunResult{result: result}
+	if err == nil && b.returning && !b.hasModel && b.query.DB().HasFeature(feature.InsertReturning) {
+		// Bun scans the returned row into the values map
+		bunResult.returned = b.values
+	}
+	res = bunResult
+	span.SetAttributes(tracing.AttrRowsAffected.Int64(res.RowsAffected()))
+	return res, err
+}
+
+// execChunks inserts the bulk models chunk by chunk. The chunks share the backing array of
+// the models, so returned columns are scanned into them.
+func (b *BunInsertQuery) execChunks(ctx context.Context) (common.Result, error) {
+	var rowsAffected int64
+	for start := 0; start < b.bulk.Len(); start += b.chunkSize {
+		end := min(start+b.chunkSize, b.bulk.Len())
+		chunk := reflect.New(b.bulk.Type())
+		chunk.Elem().Set(b.bulk.Slice(start, end))
+		result, err := b.query.Model(chunk.Interface()).Exec(ctx)
+		if err != nil {
+			return &BunResult{rowsAffected: rowsAffected}, err
+		}
+		n, _ := result.RowsAffected()
+		rowsAffected += n
+	}
+	return &BunResult{rowsAffected: rowsAffected}, nil
+}
+
+// ToSQL renders the INSERT statement, of the first chunk for bulk inserts
+func (b *BunInsertQuery) ToSQL() (string, []interface{}, error) {
+	b.applyValues()
+	if b.bulk.IsValid() && b.bulk.Len() > b.chunkSize {
+		chunk := reflect.New(b.bulk.Type())
+		chunk.Elem().Set(b.bulk.Slice(0, b.chunkSize))
+		b.query = b.query.Model(chunk.Interface())
+		defer func() { b.query = b.query.Model(b.bulkModels) }()
+	}
+	return bunSQL(b.query, b.query.DB())
+}
+
+// applyValues adds the Value columns to the query once
+func (b *BunInsertQuery) applyValues() {
+	if b.valuesApplied || len(b.values) == 0 {
+		return
+	}
+	b.valuesApplied = true
+	if !b.hasModel {
+		// If no model was set, use the values map as the model
+		// Bun can insert map[string]interface{} directly
+		b.query = b.query.Model(&b.values)
+		return
+	}
+	// If model was set, use Value() to add individual values
+	for k, v := range b.values {
+		b.query = b.query.Value(k, "?", v)
+	}
 }
 
 // BunUpdateQuery implements UpdateQuery for Bun
 type BunUpdateQuery struct {
 	query *bun.UpdateQuery
 	model interface{}
+	err   error
 }
 
 func (b *BunUpdateQuery) Model(model interface{}) common.UpdateQuery {
@@ -686,11 +930,34 @@ func (b *BunUpdateQuery) Set(column string, value interface{}) common.UpdateQuer
 		// Skip scan-only columns
 		return b
 	}
+	if patch, ok := value.(common.JSONPatch); ok {
+		b.setJSONPatch(column, patch)
+		return b
+	}
 	b.query = b.query.Set(column+" = ?", value)
 	return b
 }
 
+// setJSONPatch sets the column to the patched value of its current document
+func (b *BunUpdateQuery) setJSONPatch(column string, patch common.JSONPatch) {
+	expr, args, err := common.JSONPatchSQL(b.query.Dialect().Name().String(), column, patch)
+	if err != nil {
+		b.err = err
+		return
+	}
+	b.query = b.query.Set(common.QuoteIdent(column)+" = "+expr, args...)
+}
+
 func (b *BunUpdateQuery) SetMap(values map[string]interface{}) common.UpdateQuery {
+	values, patches, err := common.ExtractJSONPatches(values)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	for column, patch := range patches {
+		values[column] = patch
+	}
+
 	pkName := reflection.GetPrimaryKeyName(b.model)
 	for column, value := range values {
 		// Validate column is writable if model is set
@@ -702,6 +969,10 @@ func (b *BunUpdateQuery) SetMap(values map[string]interface{}) common.UpdateQuer
 			// Skip primary key updates
 			continue
 		}
+		if patch, ok := value.(common.JSONPatch); ok {
+			b.setJSONPatch(column, patch)
+			continue
+		}
 		b.query = b.query.Set(column+" = ?", value)
 	}
 	return b
@@ -720,13 +991,28 @@ func (b *BunUpdateQuery) Returning(columns ...string) common.UpdateQuery {
 }
 
 func (b *BunUpdateQuery) Exec(ctx context.Context) (res common.Result, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "bun", "update")
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("BunUpdateQuery.Exec", r)
 		}
 	}()
+	if b.err != nil {
+		return &BunResult{}, b.err
+	}
 	result, err := b.query.Exec(ctx)
-	return &BunResult{result: result}, err
+	res = &BunResult{result: result}
+	span.SetAttributes(tracing.AttrRowsAffected.Int64(res.RowsAffected()))
+	return res, err
+}
+
+// ToSQL renders the UPDATE statement
+func (b *BunUpdateQuery) ToSQL() (string, []interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	return bunSQL(b.query, b.query.DB())
 }
 
 // BunDeleteQuery implements DeleteQuery for Bun
@@ -745,28 +1031,53 @@ func (b *BunDeleteQuery) Table(table string) common.DeleteQuery {
 }
 
 func (b *BunDeleteQuery) Where(query string, args ...interface{}) common.DeleteQuery {
-	b.query = b.query.Where(query, args...)
+	b.query = b.query.Where(query, bunInArgs(query, args)...)
 	return b
 }
 
 func (b *BunDeleteQuery) Exec(ctx context.Context) (res common.Result, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "bun", "delete")
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("BunDeleteQuery.Exec", r)
 		}
 	}()
 	result, err := b.query.Exec(ctx)
-	return &BunResult{result: result}, err
+	res = &BunResult{result: result}
+	span.SetAttributes(tracing.AttrRowsAffected.Int64(res.RowsAffected()))
+	return res, err
+}
+
+// ToSQL renders the DELETE statement
+func (b *BunDeleteQuery) ToSQL() (string, []interface{}, error) {
+	return bunSQL(b.query, b.query.DB())
+}
+
+// bunSQL renders a query of db. Bun inlines the bind parameters, so none are returned.
+func bunSQL(query schema.QueryAppender, db *bun.DB) (string, []interface{}, error) {
+	b, err := query.AppendQuery(db.Formatter(), nil)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(b), nil, nil
 }
 
 // BunResult implements Result for Bun
 type BunResult struct {
-	result sql.Result
+	result       sql.Result
+	returned     map[string]interface{}
+	rowsAffected int64 // Total of a bulk insert, which has no single result
+}
+
+// Returned implements common.ReturningResult
+func (b *BunResult) Returned() map[string]interface{} {
+	return b.returned
 }
 
 func (b *BunResult) RowsAffected() int64 {
 	if b.result == nil {
-		return 0
+		return b.rowsAffected
 	}
 	rows, _ := b.result.RowsAffected()
 	return rows
@@ -784,6 +1095,16 @@ type BunTxAdapter struct {
 	tx bun.Tx
 }
 
+// Dialect implements common.Database
+func (b *BunTxAdapter) Dialect() common.Dialect {
+	return common.LookupDialect(normalizeDialectName(b.tx.Dialect().Name().String()))
+}
+
+// RelationReference implements common.RelationReferenceProvider
+func (b *BunTxAdapter) RelationReference(model interface{}, relation string) string {
+	return bunRelationReference(b.tx.Dialect(), model, relation)
+}
+
 func (b *BunTxAdapter) NewSelect() common.SelectQuery {
 	return &BunSelectQuery{
 		query: b.tx.NewSelect(),
@@ -803,12 +1124,18 @@ func (b *BunTxAdapter) NewDelete() common.DeleteQuery {
 	return &BunDeleteQuery{query: b.tx.NewDelete()}
 }
 
-func (b *BunTxAdapter) Exec(ctx context.Context, query string, args ...interface{}) (common.Result, error) {
+func (b *BunTxAdapter) Exec(ctx context.Context, query string, args ...interface{}) (res common.Result, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "bun", "exec", tracing.AttrDBStatement.String(query))
+	defer tracing.EndSpan(span, &err)
 	result, err := b.tx.ExecContext(ctx, query, args...)
-	return &BunResult{result: result}, err
+	res = &BunResult{result: result}
+	span.SetAttributes(tracing.AttrRowsAffected.Int64(res.RowsAffected()))
+	return res, err
 }
 
-func (b *BunTxAdapter) Query(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+func (b *BunTxAdapter) Query(ctx context.Context, dest interface{}, query string, args ...interface{}) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "bun", "query", tracing.AttrDBStatement.String(query))
+	defer tracing.EndSpan(span, &err)
 	return b.tx.NewRaw(query, args...).Scan(ctx, dest)
 }
 
@@ -827,3 +1154,21 @@ func (b *BunTxAdapter) RollbackTx(ctx context.Context) error {
 func (b *BunTxAdapter) RunInTransaction(ctx context.Context, fn func(common.Database) error) error {
 	return fn(b) // Already in transaction
 }
+
+// bunRelationReference returns the quoted alias bun joins a relation of model under, the SQL
+// name of the relation field, or "" for an unknown relation
+func bunRelationReference(dialect schema.Dialect, model interface{}, relation string) string {
+	modelType := reflect.TypeOf(model)
+	for modelType != nil && (modelType.Kind() == reflect.Ptr || modelType.Kind() == reflect.Slice) {
+		modelType = modelType.Elem()
+	}
+	if modelType == nil || modelType.Kind() != reflect.Struct {
+		return ""
+	}
+	rel, exists := dialect.Tables().Get(modelType).Relations[relation]
+	if !exists {
+		return ""
+	}
+	quote := string(dialect.IdentQuote())
+	return quote + rel.Field.Name + quote
+}
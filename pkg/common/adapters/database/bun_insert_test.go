@@ -10,6 +10,8 @@ import (
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/sqlitedialect"
 	"github.com/uptrace/bun/driver/sqliteshim"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
 )
 
 // TestInsertModel is a test model for insert operations
@@ -72,6 +74,32 @@ func TestBunInsertQuery_Model(t *testing.T) {
 	assert.Equal(t, 30, retrieved.Age)
 }
 
+func TestBunInsertQuery_Bulk(t *testing.T) {
+	db := setupBunTestDB(t)
+	defer db.Close()
+	_, err := db.NewTruncateTable().Model((*TestInsertModel)(nil)).Exec(context.Background())
+	require.NoError(t, err)
+
+	adapter := NewBunAdapter(db)
+	ctx := context.Background()
+
+	models := []*TestInsertModel{{Name: "a", Age: 1}, {Name: "b", Age: 2}, {Name: "c", Age: 3}}
+	result, err := adapter.NewInsert().
+		Bulk(&models, 2).
+		Returning("*").
+		Exec(ctx)
+
+	require.NoError(t, err, "Bulk insert should succeed")
+	assert.Equal(t, int64(3), result.RowsAffected(), "Should insert 3 rows in 2 chunks")
+	for _, model := range models {
+		assert.NotZero(t, model.ID, "Generated keys are returned into the models")
+	}
+
+	count, err := db.NewSelect().Model((*TestInsertModel)(nil)).Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
 func TestBunInsertQuery_Value(t *testing.T) {
 	db := setupBunTestDB(t)
 	defer db.Close()
@@ -180,7 +208,6 @@ func TestBunInsertQuery_Returning(t *testing.T) {
 	ctx := context.Background()
 
 	// Test insert with RETURNING clause
-	// Note: SQLite has limited RETURNING support, but this tests the API
 	result, err := adapter.NewInsert().
 		Table("test_inserts").
 		Value("name", "Return Test").
@@ -191,6 +218,14 @@ func TestBunInsertQuery_Returning(t *testing.T) {
 
 	require.NoError(t, err, "Insert with RETURNING should succeed")
 	assert.Equal(t, int64(1), result.RowsAffected())
+
+	// The returned row carries the generated primary key
+	returning, ok := result.(common.ReturningResult)
+	require.True(t, ok, "Bun results should implement ReturningResult")
+	returned := returning.Returned()
+	require.NotNil(t, returned)
+	assert.NotNil(t, returned["id"], "Generated ID should be returned")
+	assert.Equal(t, "Return Test", returned["name"])
 }
 
 func TestBunInsertQuery_EmptyValues(t *testing.T) {
@@ -0,0 +1,539 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// Chaos operation names, used to restrict fault injection with ChaosConfig.Operations
+const (
+	ChaosOpSelect = "select"
+	ChaosOpInsert = "insert"
+	ChaosOpUpdate = "update"
+	ChaosOpDelete = "delete"
+	ChaosOpExec   = "exec"
+	ChaosOpQuery  = "query"
+	ChaosOpBegin  = "begin"
+	ChaosOpCommit = "commit"
+)
+
+// ErrChaosTransient is the default error injected by ChaosAdapter for transient failures
+var ErrChaosTransient = errors.New("chaos: injected transient error")
+
+// ChaosConfig configures the faults injected by ChaosAdapter.
+// Rates are fractions of calls in the range 0..1.
+type ChaosConfig struct {
+	// LatencyRate is the fraction of calls delayed by a random duration between MinLatency and MaxLatency
+	LatencyRate float64
+	MinLatency  time.Duration
+	MaxLatency  time.Duration
+
+	// ErrorRate is the fraction of calls failing with TransientError (ErrChaosTransient if nil)
+	ErrorRate      float64
+	TransientError error
+
+	// DropRate is the fraction of calls failing with driver.ErrBadConn, as if the connection was dropped
+	DropRate float64
+
+	// Operations restricts injection to the listed operations (ChaosOp*). Empty means all operations.
+	Operations []string
+
+	// Seed makes the injected faults reproducible. Zero seeds from the current time.
+	Seed int64
+}
+
+// ChaosStats counts the faults injected by a ChaosAdapter
+type ChaosStats struct {
+	Calls   int64
+	Delayed int64
+	Errors  int64
+	Drops   int64
+}
+
+// chaosState is shared between a ChaosAdapter and the transactions it starts
+type chaosState struct {
+	mu      sync.Mutex
+	config  ChaosConfig
+	rng     *rand.Rand
+	enabled atomic.Bool
+
+	calls   atomic.Int64
+	delayed atomic.Int64
+	errors  atomic.Int64
+	drops   atomic.Int64
+}
+
+// ChaosAdapter wraps a Database and injects latency, transient errors and dropped
+// connections on a percentage of calls. Faults are injected when a query executes
+// (Scan, Count, Exec, ...), not while it is being built, so it can be used to test
+// hook retry logic and transaction handling under failure.
+type ChaosAdapter struct {
+	db    common.Database
+	state *chaosState
+}
+
+// NewChaosAdapter wraps db with fault injection. Injection starts enabled.
+func NewChaosAdapter(db common.Database, config ChaosConfig) *ChaosAdapter {
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	state := &chaosState{
+		config: config,
+		rng:    rand.New(rand.NewSource(seed)),
+	}
+	state.enabled.Store(true)
+	return &ChaosAdapter{db: db, state: state}
+}
+
+// Enable turns fault injection on
+func (c *ChaosAdapter) Enable() {
+	c.state.enabled.Store(true)
+}
+
+// Disable turns fault injection off; calls pass straight through to the wrapped database
+func (c *ChaosAdapter) Disable() {
+	c.state.enabled.Store(false)
+}
+
+// SetConfig replaces the fault configuration. The random source is kept.
+func (c *ChaosAdapter) SetConfig(config ChaosConfig) {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	c.state.config = config
+}
+
+// Stats returns the number of calls seen and faults injected so far
+func (c *ChaosAdapter) Stats() ChaosStats {
+	return ChaosStats{
+		Calls:   c.state.calls.Load(),
+		Delayed: c.state.delayed.Load(),
+		Errors:  c.state.errors.Load(),
+		Drops:   c.state.drops.Load(),
+	}
+}
+
+// Unwrap returns the wrapped database
+func (c *ChaosAdapter) Unwrap() common.Database {
+	return c.db
+}
+
+// Dialect implements common.Database for the wrapped database
+func (c *ChaosAdapter) Dialect() common.Dialect {
+	return c.db.Dialect()
+}
+
+// RelationReference implements common.RelationReferenceProvider for the wrapped database
+func (c *ChaosAdapter) RelationReference(model interface{}, relation string) string {
+	if provider, ok := c.db.(common.RelationReferenceProvider); ok {
+		return provider.RelationReference(model, relation)
+	}
+	return ""
+}
+
+// SQLDB implements common.SQLDBProvider for the wrapped database
+func (c *ChaosAdapter) SQLDB() (*sql.DB, error) {
+	if provider, ok := c.db.(common.SQLDBProvider); ok {
+		return provider.SQLDB()
+	}
+	return nil, fmt.Errorf("database %T has no database/sql pool", c.db)
+}
+
+// IsChaosError reports whether err was injected by a ChaosAdapter with the default errors
+func IsChaosError(err error) bool {
+	return errors.Is(err, ErrChaosTransient) || errors.Is(err, driver.ErrBadConn)
+}
+
+// inject applies the configured faults for one call of the given operation
+func (s *chaosState) inject(ctx context.Context, operation string) error {
+	if !s.enabled.Load() {
+		return nil
+	}
+
+	s.mu.Lock()
+	config := s.config
+	if !config.applies(operation) {
+		s.mu.Unlock()
+		return nil
+	}
+	s.calls.Add(1)
+
+	var delay time.Duration
+	if config.LatencyRate > 0 && s.rng.Float64() < config.LatencyRate {
+		delay = config.MinLatency
+		if spread := config.MaxLatency - config.MinLatency; spread > 0 {
+			delay += time.Duration(s.rng.Int63n(int64(spread)))
+		}
+	}
+	drop := config.DropRate > 0 && s.rng.Float64() < config.DropRate
+	fail := !drop && config.ErrorRate > 0 && s.rng.Float64() < config.ErrorRate
+	s.mu.Unlock()
+
+	if delay > 0 {
+		s.delayed.Add(1)
+		logger.Debug("Chaos: delaying %s by %s", operation, delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if drop {
+		s.drops.Add(1)
+		logger.Debug("Chaos: dropping connection on %s", operation)
+		return driver.ErrBadConn
+	}
+
+	if fail {
+		s.errors.Add(1)
+		logger.Debug("Chaos: injecting transient error on %s", operation)
+		if config.TransientError != nil {
+			return config.TransientError
+		}
+		return ErrChaosTransient
+	}
+
+	return nil
+}
+
+func (c ChaosConfig) applies(operation string) bool {
+	if len(c.Operations) == 0 {
+		return true
+	}
+	for _, op := range c.Operations {
+		if op == operation {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *ChaosAdapter) NewSelect() common.SelectQuery {
+	return &chaosSelectQuery{query: c.db.NewSelect(), state: c.state}
+}
+
+func (c *ChaosAdapter) NewInsert() common.InsertQuery {
+	return &chaosInsertQuery{query: c.db.NewInsert(), state: c.state}
+}
+
+func (c *ChaosAdapter) NewUpdate() common.UpdateQuery {
+	return &chaosUpdateQuery{query: c.db.NewUpdate(), state: c.state}
+}
+
+func (c *ChaosAdapter) NewDelete() common.DeleteQuery {
+	return &chaosDeleteQuery{query: c.db.NewDelete(), state: c.state}
+}
+
+func (c *ChaosAdapter) Exec(ctx context.Context, query string, args ...interface{}) (common.Result, error) {
+	if err := c.state.inject(ctx, ChaosOpExec); err != nil {
+		return nil, err
+	}
+	return c.db.Exec(ctx, query, args...)
+}
+
+func (c *ChaosAdapter) Query(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	if err := c.state.inject(ctx, ChaosOpQuery); err != nil {
+		return err
+	}
+	return c.db.Query(ctx, dest, query, args...)
+}
+
+func (c *ChaosAdapter) BeginTx(ctx context.Context) (common.Database, error) {
+	if err := c.state.inject(ctx, ChaosOpBegin); err != nil {
+		return nil, err
+	}
+	tx, err := c.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &ChaosAdapter{db: tx, state: c.state}, nil
+}
+
+func (c *ChaosAdapter) CommitTx(ctx context.Context) error {
+	if err := c.state.inject(ctx, ChaosOpCommit); err != nil {
+		return err
+	}
+	return c.db.CommitTx(ctx)
+}
+
+// RollbackTx is never faulted so callers can always clean up after an injected error
+func (c *ChaosAdapter) RollbackTx(ctx context.Context) error {
+	return c.db.RollbackTx(ctx)
+}
+
+func (c *ChaosAdapter) RunInTransaction(ctx context.Context, fn func(common.Database) error) error {
+	if err := c.state.inject(ctx, ChaosOpBegin); err != nil {
+		return err
+	}
+	return c.db.RunInTransaction(ctx, func(tx common.Database) error {
+		if err := fn(&ChaosAdapter{db: tx, state: c.state}); err != nil {
+			return err
+		}
+		// Failing here rolls the whole transaction back, like a failed commit
+		return c.state.inject(ctx, ChaosOpCommit)
+	})
+}
+
+// chaosSelectQuery wraps a SelectQuery and injects faults when it executes
+type chaosSelectQuery struct {
+	query common.SelectQuery
+	state *chaosState
+}
+
+func (q *chaosSelectQuery) wrap(query common.SelectQuery) common.SelectQuery {
+	q.query = query
+	return q
+}
+
+func (q *chaosSelectQuery) Model(model interface{}) common.SelectQuery {
+	return q.wrap(q.query.Model(model))
+}
+
+func (q *chaosSelectQuery) Table(table string) common.SelectQuery {
+	return q.wrap(q.query.Table(table))
+}
+
+func (q *chaosSelectQuery) Column(columns ...string) common.SelectQuery {
+	return q.wrap(q.query.Column(columns...))
+}
+
+func (q *chaosSelectQuery) ColumnExpr(query string, args ...interface{}) common.SelectQuery {
+	return q.wrap(q.query.ColumnExpr(query, args...))
+}
+
+func (q *chaosSelectQuery) Where(query string, args ...interface{}) common.SelectQuery {
+	return q.wrap(q.query.Where(query, args...))
+}
+
+func (q *chaosSelectQuery) WhereOr(query string, args ...interface{}) common.SelectQuery {
+	return q.wrap(q.query.WhereOr(query, args...))
+}
+
+func (q *chaosSelectQuery) Join(query string, args ...interface{}) common.SelectQuery {
+	return q.wrap(q.query.Join(query, args...))
+}
+
+func (q *chaosSelectQuery) LeftJoin(query string, args ...interface{}) common.SelectQuery {
+	return q.wrap(q.query.LeftJoin(query, args...))
+}
+
+func (q *chaosSelectQuery) Preload(relation string, conditions ...interface{}) common.SelectQuery {
+	return q.wrap(q.query.Preload(relation, conditions...))
+}
+
+func (q *chaosSelectQuery) PreloadRelation(relation string, apply ...func(common.SelectQuery) common.SelectQuery) common.SelectQuery {
+	return q.wrap(q.query.PreloadRelation(relation, apply...))
+}
+
+func (q *chaosSelectQuery) JoinRelation(relation string, apply ...func(common.SelectQuery) common.SelectQuery) common.SelectQuery {
+	return q.wrap(q.query.JoinRelation(relation, apply...))
+}
+
+// ScopeTenantRelations implements common.TenantRelationScoper for the wrapped query
+func (q *chaosSelectQuery) ScopeTenantRelations(scope *common.TenantScope) bool {
+	scoper, ok := q.query.(common.TenantRelationScoper)
+	return ok && scoper.ScopeTenantRelations(scope)
+}
+
+func (q *chaosSelectQuery) Order(order string) common.SelectQuery {
+	return q.wrap(q.query.Order(order))
+}
+
+func (q *chaosSelectQuery) OrderExpr(order string, args ...interface{}) common.SelectQuery {
+	return q.wrap(q.query.OrderExpr(order, args...))
+}
+
+func (q *chaosSelectQuery) Limit(n int) common.SelectQuery {
+	return q.wrap(q.query.Limit(n))
+}
+
+func (q *chaosSelectQuery) Offset(n int) common.SelectQuery {
+	return q.wrap(q.query.Offset(n))
+}
+
+func (q *chaosSelectQuery) Group(group string) common.SelectQuery {
+	return q.wrap(q.query.Group(group))
+}
+
+func (q *chaosSelectQuery) Having(having string, args ...interface{}) common.SelectQuery {
+	return q.wrap(q.query.Having(having, args...))
+}
+
+func (q *chaosSelectQuery) Distinct() common.SelectQuery {
+	return q.wrap(q.query.Distinct())
+}
+
+func (q *chaosSelectQuery) DistinctOn(columns ...string) common.SelectQuery {
+	return q.wrap(q.query.DistinctOn(columns...))
+}
+
+func (q *chaosSelectQuery) Scan(ctx context.Context, dest interface{}) error {
+	if err := q.state.inject(ctx, ChaosOpSelect); err != nil {
+		return err
+	}
+	return q.query.Scan(ctx, dest)
+}
+
+func (q *chaosSelectQuery) ScanModel(ctx context.Context) error {
+	if err := q.state.inject(ctx, ChaosOpSelect); err != nil {
+		return err
+	}
+	return q.query.ScanModel(ctx)
+}
+
+func (q *chaosSelectQuery) Count(ctx context.Context) (int, error) {
+	if err := q.state.inject(ctx, ChaosOpSelect); err != nil {
+		return 0, err
+	}
+	return q.query.Count(ctx)
+}
+
+func (q *chaosSelectQuery) Exists(ctx context.Context) (bool, error) {
+	if err := q.state.inject(ctx, ChaosOpSelect); err != nil {
+		return false, err
+	}
+	return q.query.Exists(ctx)
+}
+
+func (q *chaosSelectQuery) ToSQL() (string, []interface{}, error) {
+	return q.query.ToSQL()
+}
+
+// chaosInsertQuery wraps an InsertQuery and injects faults when it executes
+type chaosInsertQuery struct {
+	query common.InsertQuery
+	state *chaosState
+}
+
+func (q *chaosInsertQuery) wrap(query common.InsertQuery) common.InsertQuery {
+	q.query = query
+	return q
+}
+
+func (q *chaosInsertQuery) Model(model interface{}) common.InsertQuery {
+	return q.wrap(q.query.Model(model))
+}
+
+func (q *chaosInsertQuery) Table(table string) common.InsertQuery {
+	return q.wrap(q.query.Table(table))
+}
+
+func (q *chaosInsertQuery) Value(column string, value interface{}) common.InsertQuery {
+	return q.wrap(q.query.Value(column, value))
+}
+
+func (q *chaosInsertQuery) OnConflict(action string) common.InsertQuery {
+	return q.wrap(q.query.OnConflict(action))
+}
+
+func (q *chaosInsertQuery) Returning(columns ...string) common.InsertQuery {
+	return q.wrap(q.query.Returning(columns...))
+}
+
+func (q *chaosInsertQuery) Bulk(models interface{}, chunkSize int) common.InsertQuery {
+	return q.wrap(q.query.Bulk(models, chunkSize))
+}
+
+func (q *chaosInsertQuery) Exec(ctx context.Context) (common.Result, error) {
+	if err := q.state.inject(ctx, ChaosOpInsert); err != nil {
+		return nil, err
+	}
+	return q.query.Exec(ctx)
+}
+
+func (q *chaosInsertQuery) ToSQL() (string, []interface{}, error) {
+	return q.query.ToSQL()
+}
+
+// chaosUpdateQuery wraps an UpdateQuery and injects faults when it executes
+type chaosUpdateQuery struct {
+	query common.UpdateQuery
+	state *chaosState
+}
+
+func (q *chaosUpdateQuery) wrap(query common.UpdateQuery) common.UpdateQuery {
+	q.query = query
+	return q
+}
+
+func (q *chaosUpdateQuery) Model(model interface{}) common.UpdateQuery {
+	return q.wrap(q.query.Model(model))
+}
+
+func (q *chaosUpdateQuery) Table(table string) common.UpdateQuery {
+	return q.wrap(q.query.Table(table))
+}
+
+func (q *chaosUpdateQuery) Set(column string, value interface{}) common.UpdateQuery {
+	return q.wrap(q.query.Set(column, value))
+}
+
+func (q *chaosUpdateQuery) SetMap(values map[string]interface{}) common.UpdateQuery {
+	return q.wrap(q.query.SetMap(values))
+}
+
+func (q *chaosUpdateQuery) Where(query string, args ...interface{}) common.UpdateQuery {
+	return q.wrap(q.query.Where(query, args...))
+}
+
+func (q *chaosUpdateQuery) Returning(columns ...string) common.UpdateQuery {
+	return q.wrap(q.query.Returning(columns...))
+}
+
+func (q *chaosUpdateQuery) Exec(ctx context.Context) (common.Result, error) {
+	if err := q.state.inject(ctx, ChaosOpUpdate); err != nil {
+		return nil, err
+	}
+	return q.query.Exec(ctx)
+}
+
+func (q *chaosUpdateQuery) ToSQL() (string, []interface{}, error) {
+	return q.query.ToSQL()
+}
+
+// chaosDeleteQuery wraps a DeleteQuery and injects faults when it executes
+type chaosDeleteQuery struct {
+	query common.DeleteQuery
+	state *chaosState
+}
+
+func (q *chaosDeleteQuery) wrap(query common.DeleteQuery) common.DeleteQuery {
+	q.query = query
+	return q
+}
+
+func (q *chaosDeleteQuery) Model(model interface{}) common.DeleteQuery {
+	return q.wrap(q.query.Model(model))
+}
+
+func (q *chaosDeleteQuery) Table(table string) common.DeleteQuery {
+	return q.wrap(q.query.Table(table))
+}
+
+func (q *chaosDeleteQuery) Where(query string, args ...interface{}) common.DeleteQuery {
+	return q.wrap(q.query.Where(query, args...))
+}
+
+func (q *chaosDeleteQuery) Exec(ctx context.Context) (common.Result, error) {
+	if err := q.state.inject(ctx, ChaosOpDelete); err != nil {
+		return nil, err
+	}
+	return q.query.Exec(ctx)
+}
+
+func (q *chaosDeleteQuery) ToSQL() (string, []interface{}, error) {
+	return q.query.ToSQL()
+}
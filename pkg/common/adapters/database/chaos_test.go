@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+func TestChaosAdapter_PassThrough(t *testing.T) {
+	chaos := NewChaosAdapter(NewBunAdapter(setupBunDistinctDB(t)), ChaosConfig{})
+
+	count, err := chaos.NewSelect().Model(&[]TestDistinctModel{}).Where("department = ?", "sales").Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, ChaosStats{Calls: 1}, chaos.Stats())
+	assert.Equal(t, common.LookupDialect("sqlite"), chaos.Dialect(), "the dialect of the wrapped database")
+}
+
+func TestChaosAdapter_InjectsFaults(t *testing.T) {
+	ctx := context.Background()
+	chaos := NewChaosAdapter(NewBunAdapter(setupBunDistinctDB(t)), ChaosConfig{ErrorRate: 1, Seed: 1})
+
+	var rows []TestDistinctModel
+	err := chaos.NewSelect().Model(&rows).Scan(ctx, &rows)
+	assert.ErrorIs(t, err, ErrChaosTransient)
+	assert.True(t, IsChaosError(err))
+
+	custom := errors.New("deadlock detected")
+	chaos.SetConfig(ChaosConfig{ErrorRate: 1, TransientError: custom})
+	_, err = chaos.NewDelete().Table("test_distinct").Where("id = ?", 1).Exec(ctx)
+	assert.ErrorIs(t, err, custom)
+
+	chaos.SetConfig(ChaosConfig{DropRate: 1})
+	_, err = chaos.Exec(ctx, "SELECT 1")
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+
+	chaos.Disable()
+	_, err = chaos.Exec(ctx, "SELECT 1")
+	assert.NoError(t, err)
+
+	stats := chaos.Stats()
+	assert.Equal(t, int64(3), stats.Calls)
+	assert.Equal(t, int64(2), stats.Errors)
+	assert.Equal(t, int64(1), stats.Drops)
+}
+
+func TestChaosAdapter_Operations(t *testing.T) {
+	ctx := context.Background()
+	chaos := NewChaosAdapter(NewBunAdapter(setupBunDistinctDB(t)), ChaosConfig{
+		ErrorRate:  1,
+		Operations: []string{ChaosOpInsert},
+	})
+
+	_, err := chaos.NewSelect().Model(&[]TestDistinctModel{}).Count(ctx)
+	assert.NoError(t, err, "selects are not faulted")
+
+	_, err = chaos.NewInsert().Model(&TestDistinctModel{Department: "hr"}).Exec(ctx)
+	assert.ErrorIs(t, err, ErrChaosTransient)
+}
+
+func TestChaosAdapter_TransactionRollsBack(t *testing.T) {
+	ctx := context.Background()
+	db := NewBunAdapter(setupBunDistinctDB(t))
+	chaos := NewChaosAdapter(db, ChaosConfig{ErrorRate: 1, Operations: []string{ChaosOpCommit}})
+
+	err := chaos.RunInTransaction(ctx, func(tx common.Database) error {
+		_, err := tx.NewInsert().Model(&TestDistinctModel{Department: "hr"}).Exec(ctx)
+		return err
+	})
+	assert.ErrorIs(t, err, ErrChaosTransient)
+
+	count, err := db.NewSelect().Model(&[]TestDistinctModel{}).Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(distinctTestRows), count, "insert must be rolled back")
+}
+
+func TestChaosAdapter_Latency(t *testing.T) {
+	chaos := NewChaosAdapter(NewBunAdapter(setupBunDistinctDB(t)), ChaosConfig{
+		LatencyRate: 1,
+		MinLatency:  time.Second,
+		MaxLatency:  2 * time.Second,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := chaos.NewSelect().Model(&[]TestDistinctModel{}).Exists(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second, "latency must honour context cancellation")
+	assert.Equal(t, int64(1), chaos.Stats().Delayed)
+}
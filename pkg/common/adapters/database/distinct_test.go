@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"github.com/uptrace/bun/driver/sqliteshim"
+)
+
+// TestDistinctModel is a test model for DISTINCT queries
+type TestDistinctModel struct {
+	bun.BaseModel `bun:"table:test_distinct"`
+	ID            int64  `bun:"id,pk,autoincrement"`
+	Department    string `bun:"department"`
+	Status        string `bun:"status"`
+}
+
+var distinctTestRows = []TestDistinctModel{
+	{Department: "sales", Status: "active"},
+	{Department: "sales", Status: "active"},
+	{Department: "sales", Status: "inactive"},
+	{Department: "support", Status: "active"},
+}
+
+func setupBunDistinctDB(t *testing.T) *bun.DB {
+	sqldb, err := sql.Open(sqliteshim.ShimName, "file::memory:")
+	require.NoError(t, err)
+	db := bun.NewDB(sqldb, sqlitedialect.New())
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	_, err = db.NewCreateTable().Model((*TestDistinctModel)(nil)).Exec(ctx)
+	require.NoError(t, err)
+	rows := append([]TestDistinctModel(nil), distinctTestRows...)
+	_, err = db.NewInsert().Model(&rows).Exec(ctx)
+	require.NoError(t, err)
+	return db
+}
+
+func TestBunSelectQuery_DistinctCount(t *testing.T) {
+	adapter := NewBunAdapter(setupBunDistinctDB(t))
+	ctx := context.Background()
+
+	count, err := adapter.NewSelect().Model(&[]TestDistinctModel{}).
+		Column("department", "status").
+		Distinct().
+		Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	var departments []string
+	err = adapter.NewSelect().Table("test_distinct").Column("department").Distinct().Order("department").Scan(ctx, &departments)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sales", "support"}, departments)
+}
+
+func TestBunSelectQuery_DistinctOnSQL(t *testing.T) {
+	sqldb, err := sql.Open(sqliteshim.ShimName, "file::memory:")
+	require.NoError(t, err)
+	defer sqldb.Close()
+	adapter := NewBunAdapter(bun.NewDB(sqldb, pgdialect.New()))
+
+	query := adapter.NewSelect().Model(&[]TestDistinctModel{}).DistinctOn("department").(*BunSelectQuery)
+	assert.Contains(t, query.query.String(), "DISTINCT ON (department)")
+}
+
+func TestSelectQuery_DistinctOnUnsupported(t *testing.T) {
+	ctx := context.Background()
+	var rows []TestDistinctModel
+
+	err := NewBunAdapter(setupBunDistinctDB(t)).NewSelect().Model(&rows).DistinctOn("department").Scan(ctx, &rows)
+	assert.ErrorContains(t, err, "DISTINCT ON is not supported by the sqlite database")
+
+	sqldb, err := sql.Open("sqlite", "file::memory:")
+	require.NoError(t, err)
+	defer sqldb.Close()
+	err = NewSQLAdapter(sqldb, "sqlite").NewSelect().Table("test_distinct").DistinctOn("department").Scan(ctx, &rows)
+	assert.ErrorContains(t, err, "DISTINCT ON is not supported by the sqlite database")
+}
@@ -2,15 +2,18 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/bitechdev/ResolveSpec/pkg/common"
 	"github.com/bitechdev/ResolveSpec/pkg/logger"
 	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
 	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+	"github.com/bitechdev/ResolveSpec/pkg/tracing"
 )
 
 // GormAdapter adapts GORM to work with our Database interface
@@ -23,6 +26,24 @@ func NewGormAdapter(db *gorm.DB) *GormAdapter {
 	return &GormAdapter{db: db}
 }
 
+// Dialect implements common.Database
+func (g *GormAdapter) Dialect() common.Dialect {
+	return common.LookupDialect(normalizeDialectName(g.db.Dialector.Name()))
+}
+
+// RelationReference implements common.RelationReferenceProvider. GORM joins a relation under
+// the name of its field.
+func (g *GormAdapter) RelationReference(model interface{}, relation string) string {
+	var reference strings.Builder
+	g.db.Dialector.QuoteTo(&reference, relation)
+	return reference.String()
+}
+
+// SQLDB implements common.SQLDBProvider
+func (g *GormAdapter) SQLDB() (*sql.DB, error) {
+	return g.db.DB()
+}
+
 func (g *GormAdapter) NewSelect() common.SelectQuery {
 	return &GormSelectQuery{db: g.db}
 }
@@ -40,16 +61,21 @@ func (g *GormAdapter) NewDelete() common.DeleteQuery {
 }
 
 func (g *GormAdapter) Exec(ctx context.Context, query string, args ...interface{}) (res common.Result, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "gorm", "exec", tracing.AttrDBStatement.String(query))
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("GormAdapter.Exec", r)
 		}
 	}()
 	result := g.db.WithContext(ctx).Exec(query, args...)
+	span.SetAttributes(tracing.AttrRowsAffected.Int64(result.RowsAffected))
 	return &GormResult{result: result}, result.Error
 }
 
 func (g *GormAdapter) Query(ctx context.Context, dest interface{}, query string, args ...interface{}) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "gorm", "query", tracing.AttrDBStatement.String(query))
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("GormAdapter.Query", r)
@@ -92,6 +118,9 @@ type GormSelectQuery struct {
 	schema     string // Separated schema name
 	tableName  string // Just the table name, without schema
 	tableAlias string
+	distinct   bool
+	distinctOn []string            // DISTINCT ON columns, applied just before execution
+	tenant     *common.TenantScope // Tenant of the relations, see ScopeTenantRelations
 }
 
 func (g *GormSelectQuery) Model(model interface{}) common.SelectQuery {
@@ -120,11 +149,17 @@ func (g *GormSelectQuery) Table(table string) common.SelectQuery {
 }
 
 func (g *GormSelectQuery) Column(columns ...string) common.SelectQuery {
-	g.db = g.db.Select(columns)
+	// Select replaces earlier selections, so add to the columns selected so far (like Bun)
+	selects := append(append([]string{}, g.db.Statement.Selects...), columns...)
+	g.db = g.db.Select(selects)
 	return g
 }
 
 func (g *GormSelectQuery) ColumnExpr(query string, args ...interface{}) common.SelectQuery {
+	if len(args) == 0 {
+		// Selects without bound args are rendered raw, so the expression can be added to the selection
+		return g.Column(query)
+	}
 	g.db = g.db.Select(query, args...)
 	return g
 }
@@ -212,12 +247,81 @@ func (g *GormSelectQuery) LeftJoin(query string, args ...interface{}) common.Sel
 }
 
 func (g *GormSelectQuery) Preload(relation string, conditions ...interface{}) common.SelectQuery {
+	if g.scopeTenantPath(relation, g.preloadTenant) {
+		conditions = append([]interface{}{g.tenantCondition}, conditions...)
+	}
 	g.db = g.db.Preload(relation, conditions...)
 	return g
 }
 
+// ScopeTenantRelations implements common.TenantRelationScoper. The tenant condition is added to
+// the queries of preloaded relations and to the ON clause of joined relations.
+func (g *GormSelectQuery) ScopeTenantRelations(scope *common.TenantScope) bool {
+	g.tenant = scope
+	return true
+}
+
+// tenantCondition adds the tenant condition on the table of a preload query or, in the session
+// of a join, on the alias of the joined relation
+func (g *GormSelectQuery) tenantCondition(db *gorm.DB) *gorm.DB {
+	return db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: g.tenant.Column}, Value: g.tenant.Tenant})
+}
+
+// scopeTenantPath calls scope for the relations before the last one of a relation path whose
+// models have the tenant column, as GORM loads them without conditions, and returns whether
+// the model of the last relation has it
+func (g *GormSelectQuery) scopeTenantPath(relation string, scope func(path string)) bool {
+	if g.tenant == nil || g.db.Statement.Model == nil {
+		return false
+	}
+	stmt := &gorm.Statement{DB: g.db}
+	if err := stmt.Parse(g.db.Statement.Model); err != nil {
+		return false
+	}
+	relations := stmt.Schema.Relationships.Relations
+	path := strings.Split(relation, ".")
+	for i, name := range path {
+		rel, ok := relations[name]
+		if !ok {
+			return false // GORM reports the unknown relation
+		}
+		scoped := rel.FieldSchema.LookUpField(g.tenant.Column) != nil
+		if i == len(path)-1 {
+			return scoped
+		}
+		if scoped {
+			scope(strings.Join(path[:i+1], "."))
+		}
+		relations = rel.FieldSchema.Relationships.Relations
+	}
+	return false
+}
+
+// preloadTenant preloads a relation of a relation path with the tenant condition, unless it is
+// preloaded already
+func (g *GormSelectQuery) preloadTenant(path string) {
+	if _, ok := g.db.Statement.Preloads[path]; !ok {
+		g.db = g.db.Preload(path, g.tenantCondition)
+	}
+}
+
+// joinTenant joins a relation of a relation path with the tenant condition, unless it is joined
+// already
+func (g *GormSelectQuery) joinTenant(path string) {
+	for _, join := range g.db.Statement.Joins {
+		if join.Name == path {
+			return
+		}
+	}
+	g.db = g.db.Joins(path, g.tenantCondition(g.db.Session(&gorm.Session{NewDB: true})))
+}
+
 func (g *GormSelectQuery) PreloadRelation(relation string, apply ...func(common.SelectQuery) common.SelectQuery) common.SelectQuery {
+	scoped := g.scopeTenantPath(relation, g.preloadTenant)
 	g.db = g.db.Preload(relation, func(db *gorm.DB) *gorm.DB {
+		if scoped {
+			db = g.tenantCondition(db)
+		}
 		if len(apply) == 0 {
 			return db
 		}
@@ -246,8 +350,63 @@ func (g *GormSelectQuery) PreloadRelation(relation string, apply ...func(common.
 	return g
 }
 
+func (g *GormSelectQuery) JoinRelation(relation string, apply ...func(common.SelectQuery) common.SelectQuery) common.SelectQuery {
+	scoped := g.scopeTenantPath(relation, g.joinTenant)
+	if len(apply) == 0 && !scoped {
+		g.db = g.db.Joins(relation)
+		return g
+	}
+
+	// GORM takes the join's column selection and ON conditions from a separate session
+	session := g.db.Session(&gorm.Session{NewDB: true})
+	if scoped {
+		session = g.tenantCondition(session)
+	}
+	current := common.SelectQuery(&GormSelectQuery{db: session})
+	for _, fn := range apply {
+		if fn != nil {
+			current = fn(current)
+		}
+	}
+
+	if finalGorm, ok := current.(*GormSelectQuery); ok {
+		g.db = g.db.Joins(relation, finalGorm.db)
+	} else {
+		g.db = g.db.Joins(relation)
+	}
+
+	return g
+}
+
 func (g *GormSelectQuery) Order(order string) common.SelectQuery {
-	g.db = g.db.Order(order)
+	return g.OrderExpr(order)
+}
+
+// OrderExpr adds an order with bind parameters. GORM drops an order expression once a column
+// order is added, so after the first order with args the whole ORDER BY is one expression.
+func (g *GormSelectQuery) OrderExpr(order string, args ...interface{}) common.SelectQuery {
+	current, _ := g.db.Statement.Clauses["ORDER BY"].Expression.(clause.OrderBy)
+	if len(args) == 0 && current.Expression == nil {
+		g.db = g.db.Order(order)
+		return g
+	}
+
+	expr := clause.Expr{SQL: order, Vars: args, WithoutParentheses: true}
+	if existing, ok := current.Expression.(clause.Expr); ok {
+		expr.SQL = existing.SQL + ", " + order
+		expr.Vars = append(append([]interface{}{}, existing.Vars...), args...)
+	} else if len(current.Columns) > 0 {
+		orders := make([]string, 0, len(current.Columns)+1)
+		for _, column := range current.Columns {
+			name := column.Column.Name
+			if column.Desc {
+				name += " DESC"
+			}
+			orders = append(orders, name)
+		}
+		expr.SQL = strings.Join(append(orders, order), ", ")
+	}
+	g.db = g.db.Order(clause.OrderBy{Expression: expr})
 	return g
 }
 
@@ -271,16 +430,64 @@ func (g *GormSelectQuery) Having(having string, args ...interface{}) common.Sele
 	return g
 }
 
+func (g *GormSelectQuery) Distinct() common.SelectQuery {
+	g.db = g.db.Distinct()
+	g.distinct = true
+	return g
+}
+
+func (g *GormSelectQuery) DistinctOn(columns ...string) common.SelectQuery {
+	g.distinctOn = append(g.distinctOn, columns...)
+	return g
+}
+
+// prepare returns the query to execute with DISTINCT ON applied
+// GORM has no native DISTINCT ON support, so the clause is prepended to the selected columns.
+// Databases without DISTINCT ON fail the query.
+func (g *GormSelectQuery) prepare(ctx context.Context) *gorm.DB {
+	db := g.db.WithContext(ctx)
+	if len(g.distinctOn) == 0 {
+		return db
+	}
+	if dialect := common.LookupDialect(normalizeDialectName(db.Dialector.Name())); !dialect.DistinctOn {
+		_ = db.AddError(fmt.Errorf("DISTINCT ON is not supported by the %s database", dialect.Name))
+		return db
+	}
+
+	columns := "*"
+	if len(db.Statement.Selects) > 0 {
+		columns = strings.Join(db.Statement.Selects, ", ")
+	}
+	db = db.Session(&gorm.Session{})
+	db.Statement.Distinct = false
+	return db.Select(fmt.Sprintf("DISTINCT ON (%s) %s", strings.Join(g.distinctOn, ", "), columns))
+}
+
+// ToSQL renders the SELECT statement with a DryRun session
+func (g *GormSelectQuery) ToSQL() (string, []interface{}, error) {
+	dest := g.db.Statement.Model
+	if dest == nil {
+		dest = &[]map[string]interface{}{}
+	}
+	return gormSQL(gormDryRun(g.prepare(context.Background())).Find(dest))
+}
+
 func (g *GormSelectQuery) Scan(ctx context.Context, dest interface{}) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "gorm", "select", tracing.AttrTable.String(g.tableName))
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("GormSelectQuery.Scan", r)
 		}
 	}()
-	return g.db.WithContext(ctx).Find(dest).Error
+	err = g.prepare(ctx).Find(dest).Error
+	span.SetAttributes(tracing.AttrRowCount.Int(scannedRowCount(dest)))
+	return err
 }
 
 func (g *GormSelectQuery) ScanModel(ctx context.Context) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "gorm", "select", tracing.AttrTable.String(g.tableName))
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("GormSelectQuery.ScanModel", r)
@@ -289,10 +496,14 @@ func (g *GormSelectQuery) ScanModel(ctx context.Context) (err error) {
 	if g.db.Statement.Model == nil {
 		return fmt.Errorf("ScanModel requires Model() to be set before scanning")
 	}
-	return g.db.WithContext(ctx).Find(g.db.Statement.Model).Error
+	err = g.prepare(ctx).Find(g.db.Statement.Model).Error
+	span.SetAttributes(tracing.AttrRowCount.Int(scannedRowCount(g.db.Statement.Model)))
+	return err
 }
 
 func (g *GormSelectQuery) Count(ctx context.Context) (count int, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "gorm", "count", tracing.AttrTable.String(g.tableName))
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("GormSelectQuery.Count", r)
@@ -300,11 +511,23 @@ func (g *GormSelectQuery) Count(ctx context.Context) (count int, err error) {
 		}
 	}()
 	var count64 int64
+
+	// GORM only emits COUNT(DISTINCT col) for a single selected column
+	// Any other DISTINCT query is counted by wrapping it as a subquery
+	if len(g.distinctOn) > 0 || (g.distinct && len(g.db.Statement.Selects) != 1) {
+		err = g.db.Session(&gorm.Session{NewDB: true}).WithContext(ctx).
+			Table("(?) AS subquery", g.prepare(ctx)).
+			Count(&count64).Error
+		return int(count64), err
+	}
+
 	err = g.db.WithContext(ctx).Count(&count64).Error
 	return int(count64), err
 }
 
 func (g *GormSelectQuery) Exists(ctx context.Context) (exists bool, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "gorm", "exists", tracing.AttrTable.String(g.tableName))
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("GormSelectQuery.Exists", r)
@@ -318,9 +541,11 @@ func (g *GormSelectQuery) Exists(ctx context.Context) (exists bool, err error) {
 
 // GormInsertQuery implements InsertQuery for GORM
 type GormInsertQuery struct {
-	db     *gorm.DB
-	model  interface{}
-	values map[string]interface{}
+	db        *gorm.DB
+	model     interface{}
+	values    map[string]interface{}
+	returning []clause.Column
+	chunkSize int // Rows per INSERT of a bulk insert
 }
 
 func (g *GormInsertQuery) Model(model interface{}) common.InsertQuery {
@@ -348,26 +573,78 @@ func (g *GormInsertQuery) OnConflict(action string) common.InsertQuery {
 }
 
 func (g *GormInsertQuery) Returning(columns ...string) common.InsertQuery {
-	// GORM doesn't have explicit RETURNING, but updates the model
+	// GORM fills the primary key of models by itself; the RETURNING clause also
+	// loads generated values into models and table inserts. No columns or "*" returns all.
+	g.returning = make([]clause.Column, 0, len(columns))
+	for _, column := range columns {
+		if column != "*" {
+			g.returning = append(g.returning, clause.Column{Name: column})
+		}
+	}
 	return g
 }
 
+// Bulk inserts the models of a slice in batches of chunkSize rows
+func (g *GormInsertQuery) Bulk(models interface{}, chunkSize int) common.InsertQuery {
+	if chunkSize <= 0 {
+		chunkSize = common.DefaultInsertChunkSize
+	}
+	g.chunkSize = chunkSize
+	return g.Model(models)
+}
+
 func (g *GormInsertQuery) Exec(ctx context.Context) (res common.Result, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "gorm", "insert")
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("GormInsertQuery.Exec", r)
 		}
 	}()
-	var result *gorm.DB
+	result, supportsReturning := g.create(g.db.WithContext(ctx))
+	gormResult := &GormResult{result: result}
+	if result.Error == nil && supportsReturning && g.model == nil {
+		// GORM scans the returned row into the values map
+		gormResult.returned = g.values
+	}
+	span.SetAttributes(tracing.AttrRowsAffected.Int64(result.RowsAffected))
+	return gormResult, result.Error
+}
+
+// ToSQL renders the INSERT statement with a DryRun session
+func (g *GormInsertQuery) ToSQL() (string, []interface{}, error) {
+	result, _ := g.create(gormDryRun(g.db))
+	return gormSQL(result)
+}
+
+// create inserts the model or values with db and reports whether RETURNING was used
+func (g *GormInsertQuery) create(db *gorm.DB) (*gorm.DB, bool) {
+	supportsReturning := g.returning != nil && g.supportsReturning()
+	if supportsReturning {
+		db = db.Clauses(clause.Returning{Columns: g.returning})
+	}
+
 	switch {
+	case g.model != nil && g.chunkSize > 0:
+		return db.CreateInBatches(g.model, g.chunkSize), supportsReturning
 	case g.model != nil:
-		result = g.db.WithContext(ctx).Create(g.model)
+		return db.Create(g.model), supportsReturning
 	case g.values != nil:
-		result = g.db.WithContext(ctx).Create(g.values)
+		return db.Create(g.values), supportsReturning
 	default:
-		result = g.db.WithContext(ctx).Create(map[string]interface{}{})
+		g.values = map[string]interface{}{}
+		return db.Create(g.values), supportsReturning
 	}
-	return &GormResult{result: result}, result.Error
+}
+
+// supportsReturning reports whether the dialect of the connection supports RETURNING on insert
+func (g *GormInsertQuery) supportsReturning() bool {
+	for _, name := range g.db.Callback().Create().Clauses {
+		if name == "RETURNING" {
+			return true
+		}
+	}
+	return false
 }
 
 // GormUpdateQuery implements UpdateQuery for GORM
@@ -375,6 +652,7 @@ type GormUpdateQuery struct {
 	db      *gorm.DB
 	model   interface{}
 	updates interface{}
+	err     error
 }
 
 func (g *GormUpdateQuery) Model(model interface{}) common.UpdateQuery {
@@ -412,6 +690,15 @@ func (g *GormUpdateQuery) Set(column string, value interface{}) common.UpdateQue
 }
 
 func (g *GormUpdateQuery) SetMap(values map[string]interface{}) common.UpdateQuery {
+	// Copies the values, so later Set calls don't change the caller's map
+	values, patches, err := common.ExtractJSONPatches(values)
+	if err != nil {
+		g.err = err
+		return g
+	}
+	for column, patch := range patches {
+		values[column] = patch
+	}
 
 	// Filter out read-only columns if model is set
 	if g.model != nil {
@@ -445,15 +732,48 @@ func (g *GormUpdateQuery) Returning(columns ...string) common.UpdateQuery {
 }
 
 func (g *GormUpdateQuery) Exec(ctx context.Context) (res common.Result, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "gorm", "update")
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("GormUpdateQuery.Exec", r)
 		}
 	}()
+	if err := g.prepare(); err != nil {
+		return &GormResult{result: g.db}, err
+	}
 	result := g.db.WithContext(ctx).Updates(g.updates)
+	span.SetAttributes(tracing.AttrRowsAffected.Int64(result.RowsAffected))
 	return &GormResult{result: result}, result.Error
 }
 
+// ToSQL renders the UPDATE statement with a DryRun session
+func (g *GormUpdateQuery) ToSQL() (string, []interface{}, error) {
+	if err := g.prepare(); err != nil {
+		return "", nil, err
+	}
+	return gormSQL(gormDryRun(g.db).Updates(g.updates))
+}
+
+// prepare turns the JSON patches of the updates into SQL expressions
+func (g *GormUpdateQuery) prepare() error {
+	if g.err != nil {
+		return g.err
+	}
+	if updates, ok := g.updates.(map[string]interface{}); ok {
+		for column, value := range updates {
+			if patch, ok := value.(common.JSONPatch); ok {
+				expr, args, err := common.JSONPatchSQL(g.db.Dialector.Name(), column, patch)
+				if err != nil {
+					return err
+				}
+				updates[column] = gorm.Expr(expr, args...)
+			}
+		}
+	}
+	return nil
+}
+
 // GormDeleteQuery implements DeleteQuery for GORM
 type GormDeleteQuery struct {
 	db    *gorm.DB
@@ -477,18 +797,45 @@ func (g *GormDeleteQuery) Where(query string, args ...interface{}) common.Delete
 }
 
 func (g *GormDeleteQuery) Exec(ctx context.Context) (res common.Result, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "gorm", "delete")
+	defer tracing.EndSpan(span, &err)
 	defer func() {
 		if r := recover(); r != nil {
 			err = logger.HandlePanic("GormDeleteQuery.Exec", r)
 		}
 	}()
 	result := g.db.WithContext(ctx).Delete(g.model)
+	span.SetAttributes(tracing.AttrRowsAffected.Int64(result.RowsAffected))
 	return &GormResult{result: result}, result.Error
 }
 
+// ToSQL renders the DELETE statement with a DryRun session
+func (g *GormDeleteQuery) ToSQL() (string, []interface{}, error) {
+	return gormSQL(gormDryRun(g.db).Delete(g.model))
+}
+
+// gormDryRun returns a session of db that builds statements without executing them
+func gormDryRun(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{DryRun: true})
+}
+
+// gormSQL returns the statement and bind parameters built by a DryRun session
+func gormSQL(result *gorm.DB) (string, []interface{}, error) {
+	if result.Error != nil {
+		return "", nil, result.Error
+	}
+	return result.Statement.SQL.String(), result.Statement.Vars, nil
+}
+
 // GormResult implements Result for GORM
 type GormResult struct {
-	result *gorm.DB
+	result   *gorm.DB
+	returned map[string]interface{}
+}
+
+// Returned implements common.ReturningResult
+func (g *GormResult) Returned() map[string]interface{} {
+	return g.returned
 }
 
 func (g *GormResult) RowsAffected() int64 {
@@ -496,6 +843,16 @@ func (g *GormResult) RowsAffected() int64 {
 }
 
 func (g *GormResult) LastInsertId() (int64, error) {
-	// GORM doesn't directly provide last insert ID, would need specific implementation
+	// GORM doesn't expose the driver result; a returned integer "id" column is used when available
+	if id, ok := g.returned["id"]; ok {
+		switch v := id.(type) {
+		case int64:
+			return v, nil
+		case int:
+			return int64(v), nil
+		case int32:
+			return int64(v), nil
+		}
+	}
 	return 0, nil
 }
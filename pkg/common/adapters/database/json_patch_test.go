@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"github.com/uptrace/bun/driver/sqliteshim"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type jsonPatchProfile struct {
+	bun.BaseModel `bun:"table:json_patch_profiles"`
+	ID            int64           `json:"id" bun:"id,pk,autoincrement"`
+	Name          string          `json:"name" bun:"name"`
+	Settings      common.SqlJSONB `json:"settings" bun:"settings"`
+}
+
+func TestBunUpdateQuery_JSONPatch(t *testing.T) {
+	sqldb, err := sql.Open(sqliteshim.ShimName, "file::memory:")
+	require.NoError(t, err)
+	db := bun.NewDB(sqldb, sqlitedialect.New())
+	defer db.Close()
+
+	ctx := context.Background()
+	_, err = db.NewCreateTable().Model((*jsonPatchProfile)(nil)).IfNotExists().Exec(ctx)
+	require.NoError(t, err)
+	_, err = db.NewInsert().Model(&jsonPatchProfile{
+		Name:     "a",
+		Settings: common.SqlJSONB(`{"theme":"light","font":{"size":10},"legacy":true}`),
+	}).Exec(ctx)
+	require.NoError(t, err)
+
+	settings := func() map[string]interface{} {
+		var profile jsonPatchProfile
+		require.NoError(t, db.NewSelect().Model(&profile).Where("id = 1").Scan(ctx))
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(profile.Settings, &doc))
+		return doc
+	}
+
+	adapter := NewBunAdapter(db)
+	_, err = adapter.NewUpdate().Table("json_patch_profiles").SetMap(map[string]interface{}{
+		"name":            "b",
+		"settings->theme": "dark",
+		common.JSONPatchKey: map[string]interface{}{
+			"settings": map[string]interface{}{"font": map[string]interface{}{"size": 12}, "legacy": nil},
+		},
+	}).Where("id = ?", 1).Exec(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"theme": "dark",
+		"font":  map[string]interface{}{"size": float64(12)},
+	}, settings())
+
+	_, err = adapter.NewUpdate().Table("json_patch_profiles").
+		Set("settings", common.JSONPatch{{Path: []string{"tags"}, Value: []string{"x"}}}).
+		Where("id = ?", 1).Exec(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"x"}, settings()["tags"])
+
+	_, err = adapter.NewUpdate().Table("json_patch_profiles").SetMap(map[string]interface{}{
+		"settings":        "{}",
+		"settings->theme": "dark",
+	}).Where("id = ?", 1).Exec(ctx)
+	assert.Error(t, err, "replacing and patching the same column is rejected")
+}
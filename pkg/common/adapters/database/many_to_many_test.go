@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"github.com/uptrace/bun/driver/sqliteshim"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type m2mOrder struct {
+	bun.BaseModel `bun:"table:m2m_orders"`
+	ID            int64      `json:"id" bun:"id,pk,autoincrement"`
+	Ref           string     `json:"ref" bun:"ref"`
+	Items         []*m2mItem `json:"items" bun:"m2m:m2m_order_items,join:Order=Item"`
+}
+
+func (m2mOrder) TableName() string { return "m2m_orders" }
+
+type m2mItem struct {
+	bun.BaseModel `bun:"table:m2m_items"`
+	ID            int64  `json:"id" bun:"id,pk,autoincrement"`
+	Name          string `json:"name" bun:"name"`
+}
+
+func (m2mItem) TableName() string { return "m2m_items" }
+
+type m2mOrderItem struct {
+	bun.BaseModel `bun:"table:m2m_order_items"`
+	OrderID       int64     `bun:"order_id,pk"`
+	Order         *m2mOrder `bun:"rel:belongs-to,join:order_id=id"`
+	ItemID        int64     `bun:"item_id,pk"`
+	Item          *m2mItem  `bun:"rel:belongs-to,join:item_id=id"`
+}
+
+// m2mRelations resolves relations from Bun m2m tags
+type m2mRelations struct{}
+
+func (m2mRelations) GetRelationshipInfo(modelType reflect.Type, relationName string) *common.RelationshipInfo {
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+		if strings.Split(field.Tag.Get("json"), ",")[0] != relationName {
+			continue
+		}
+		if joinTable, joinForeignKey, joinReferences, ok := common.ParseBunM2MTag(field.Tag.Get("bun")); ok {
+			return &common.RelationshipInfo{
+				FieldName: field.Name, JSONName: relationName, RelationType: "many2many",
+				JoinTable: joinTable, JoinForeignKey: joinForeignKey, JoinReferences: joinReferences,
+			}
+		}
+	}
+	return nil
+}
+
+func TestNestedCUDManyToManyWithBun(t *testing.T) {
+	sqldb, err := sql.Open(sqliteshim.ShimName, "file::memory:")
+	require.NoError(t, err)
+	db := bun.NewDB(sqldb, sqlitedialect.New())
+	defer db.Close()
+
+	ctx := context.Background()
+	db.RegisterModel((*m2mOrderItem)(nil))
+	for _, model := range []interface{}{(*m2mOrder)(nil), (*m2mItem)(nil), (*m2mOrderItem)(nil)} {
+		_, err := db.NewCreateTable().Model(model).IfNotExists().Exec(ctx)
+		require.NoError(t, err)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		_, err := db.NewInsert().Model(&m2mItem{Name: name}).Exec(ctx)
+		require.NoError(t, err)
+	}
+
+	processor := common.NewNestedCUDProcessor(NewBunAdapter(db), nil, m2mRelations{})
+	itemIDs := func(orderID interface{}) []int64 {
+		var ids []int64
+		require.NoError(t, db.NewSelect().Table("m2m_order_items").Column("item_id").
+			Where("order_id = ?", orderID).Order("item_id").Scan(ctx, &ids))
+		return ids
+	}
+
+	// JSON numbers arrive as float64
+	result, err := processor.ProcessNestedCUD(ctx, "insert", map[string]interface{}{
+		"ref":   "o1",
+		"items": []interface{}{float64(1), map[string]interface{}{"id": float64(2)}},
+	}, m2mOrder{}, nil, "m2m_orders")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, itemIDs(result.ID))
+
+	_, err = processor.ProcessNestedCUD(ctx, "update", map[string]interface{}{
+		"id":    result.ID,
+		"items": []interface{}{float64(2), float64(3)},
+	}, m2mOrder{}, nil, "m2m_orders")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 3}, itemIDs(result.ID))
+
+	_, err = processor.ProcessNestedCUD(ctx, "delete", map[string]interface{}{
+		"id":    result.ID,
+		"items": []interface{}{},
+	}, m2mOrder{}, nil, "m2m_orders")
+	require.NoError(t, err)
+	assert.Empty(t, itemIDs(result.ID))
+
+	count, err := db.NewSelect().Table("m2m_items").Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count, "detaching keeps the related records")
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/tracing"
+)
+
+// PgxAdapter is the adapter for PostgreSQL connection pools of pgx (github.com/jackc/pgx/v5).
+// Queries are built and run like those of SQLAdapter, on a database/sql handle over the pool;
+// bulk inserts use the native COPY FROM of pgx. Errors of PostgreSQL are *pgconn.PgError, which
+// common.AsConstraintError recognizes by their SQLSTATE code.
+type PgxAdapter struct {
+	*SQLAdapter
+	pool *pgxpool.Pool
+}
+
+// NewPgxAdapter creates a pgx adapter for a connection pool. The pool isn't closed by the
+// adapter.
+func NewPgxAdapter(pool *pgxpool.Pool) *PgxAdapter {
+	return &PgxAdapter{SQLAdapter: NewSQLAdapter(stdlib.OpenDBFromPool(pool), "pgx"), pool: pool}
+}
+
+// Pool returns the pgx connection pool of the adapter
+func (p *PgxAdapter) Pool() *pgxpool.Pool {
+	return p.pool
+}
+
+// WithStatementCache runs the queries of the adapter with cached prepared statements, see
+// SQLAdapter.WithStatementCache
+func (p *PgxAdapter) WithStatementCache(size int) *PgxAdapter {
+	p.SQLAdapter.WithStatementCache(size)
+	return p
+}
+
+func (p *PgxAdapter) BeginTx(ctx context.Context) (common.Database, error) {
+	tx, err := p.beginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &PgxAdapter{SQLAdapter: tx, pool: p.pool}, nil
+}
+
+func (p *PgxAdapter) RunInTransaction(ctx context.Context, fn func(common.Database) error) error {
+	if p.tx != nil {
+		return fn(p) // Already in transaction
+	}
+	tx, err := p.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	return runInTransaction(ctx, tx, fn)
+}
+
+// BulkInsert implements common.BulkInserter with COPY FROM, on a connection of the pool or,
+// in a transaction, on the pgx connection of the transaction. table may be schema qualified.
+func (p *PgxAdapter) BulkInsert(ctx context.Context, table string, columns []string, rows [][]interface{}) (n int64, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "pgx", "copy", tracing.AttrTable.String(table))
+	defer tracing.EndSpan(span, &err)
+	defer func() {
+		if r := recover(); r != nil {
+			err = logger.HandlePanic("PgxAdapter.BulkInsert", r)
+		}
+	}()
+
+	values := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		values[i] = make([]interface{}, len(row))
+		for j, value := range row {
+			values[i][j] = sqlArgValue(value)
+		}
+	}
+	identifier := pgx.Identifier(strings.Split(table, "."))
+
+	if p.txConn == nil {
+		n, err = p.pool.CopyFrom(ctx, identifier, columns, pgx.CopyFromRows(values))
+	} else {
+		err = p.txConn.Raw(func(driverConn interface{}) error {
+			conn, ok := driverConn.(*stdlib.Conn)
+			if !ok {
+				return fmt.Errorf("connection %T of the transaction isn't a pgx connection", driverConn)
+			}
+			var copyErr error
+			n, copyErr = conn.Conn().CopyFrom(ctx, identifier, columns, pgx.CopyFromRows(values))
+			return copyErr
+		})
+	}
+	span.SetAttributes(tracing.AttrRowsAffected.Int64(n))
+	return n, err
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// pgxTestURL is the PostgreSQL database of the pgx tests, which are skipped without it
+const pgxTestURL = "RESOLVESPEC_TEST_POSTGRES_URL"
+
+func TestPgxAdapter(t *testing.T) {
+	pool, err := pgxpool.New(context.Background(), "postgres://resolvespec@127.0.0.1:1/resolvespec?connect_timeout=1")
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	db := NewPgxAdapter(pool)
+	var _ common.BulkInserter = db
+	assert.Equal(t, "postgres", db.Dialect().Name)
+	assert.Same(t, pool, db.Pool())
+
+	query, args, err := db.NewSelect().Table("tasks").Where("title = ?", "docs").ToSQL()
+	require.NoError(t, err)
+	assert.Contains(t, query, "title = $1")
+	assert.Equal(t, []interface{}{"docs"}, args)
+
+	_, err = db.BulkInsert(context.Background(), "tasks", []string{"title"}, [][]interface{}{{"x"}})
+	assert.Error(t, err, "the pool can't connect")
+}
+
+func TestPgxConstraintError(t *testing.T) {
+	err := fmt.Errorf("create: %w", &pgconn.PgError{
+		Code:           "23505",
+		Message:        `duplicate key value violates unique constraint "users_email_key"`,
+		Detail:         "Key (email)=(a@b.c) already exists.",
+		ConstraintName: "users_email_key",
+	})
+	constraintErr, ok := common.AsConstraintError(err)
+	require.True(t, ok)
+	assert.Equal(t, common.UniqueViolation, constraintErr.Code)
+	assert.Equal(t, "email", constraintErr.Column)
+	assert.Equal(t, "users_email_key", constraintErr.Constraint)
+}
+
+// TestPgxAdapter_BulkInsert copies rows into PostgreSQL, set RESOLVESPEC_TEST_POSTGRES_URL to run it
+func TestPgxAdapter_BulkInsert(t *testing.T) {
+	url := os.Getenv(pgxTestURL)
+	if url == "" {
+		t.Skip(pgxTestURL + " is not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	_, err = pool.Exec(ctx, "DROP TABLE IF EXISTS pgx_tasks; CREATE TABLE pgx_tasks (id SERIAL PRIMARY KEY, title TEXT NOT NULL, priority INTEGER, labels JSONB)")
+	require.NoError(t, err)
+	t.Cleanup(func() { _, _ = pool.Exec(ctx, "DROP TABLE pgx_tasks") })
+	db := NewPgxAdapter(pool)
+
+	n, err := db.BulkInsert(ctx, "pgx_tasks", []string{"labels", "priority", "title"}, [][]interface{}{
+		{map[string]string{"team": "core"}, 1, "write docs"},
+		{nil, 2, "fix bug"},
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+
+	rollback := errors.New("rollback")
+	err = db.RunInTransaction(ctx, func(tx common.Database) error {
+		n, err := tx.(common.BulkInserter).BulkInsert(ctx, "pgx_tasks", []string{"priority", "title"}, [][]interface{}{{3, "review"}})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+		return rollback
+	})
+	assert.ErrorIs(t, err, rollback)
+
+	var titles []string
+	var labels string
+	require.NoError(t, pool.QueryRow(ctx, "SELECT array_agg(title ORDER BY id), (array_agg(labels::text ORDER BY id))[1] FROM pgx_tasks").Scan(&titles, &labels))
+	assert.Equal(t, []string{"write docs", "fix bug"}, titles, "the copy in the transaction is rolled back")
+	assert.JSONEq(t, `{"team": "core"}`, labels)
+
+	_, err = db.BulkInsert(ctx, "pgx_tasks", []string{"title"}, [][]interface{}{{nil}})
+	constraintErr, ok := common.AsConstraintError(err)
+	require.True(t, ok, "%v", err)
+	assert.Equal(t, common.NotNullViolation, constraintErr.Code)
+}
@@ -0,0 +1,1250 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+	"github.com/bitechdev/ResolveSpec/pkg/tracing"
+)
+
+// sqlConn is implemented by *sql.DB and *sql.Tx
+type sqlConn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// SQLAdapter adapts a plain database/sql connection to work with our Database interface.
+// Queries are rendered by a minimal query builder with ? placeholders, which are rewritten
+// for the dialect. Models are mapped by their bun and gorm column tags, or the snake_case
+// field names; relations (Preload, JoinRelation) need GORM or Bun.
+type SQLAdapter struct {
+	db      *sql.DB
+	txConn  *sql.Conn // connection of the transaction
+	tx      *sql.Tx
+	dialect common.Dialect
+	stmts   *StatementCache // Prepared statements of queries outside transactions
+}
+
+// NewSQLAdapter creates a database/sql adapter. dialect is the SQL dialect of the connection:
+// "postgres", "sqlite", "mysql" or "mssql"; driver names like "pgx" and "sqlite3" work too.
+func NewSQLAdapter(db *sql.DB, dialect string) *SQLAdapter {
+	return &SQLAdapter{db: db, dialect: common.LookupDialect(normalizeDialectName(dialect))}
+}
+
+// Dialect implements common.Database
+func (a *SQLAdapter) Dialect() common.Dialect {
+	return a.dialect
+}
+
+// SQLDB implements common.SQLDBProvider
+func (a *SQLAdapter) SQLDB() (*sql.DB, error) {
+	return a.db, nil
+}
+
+// WithStatementCache runs the queries the adapter builds outside transactions with prepared
+// statements, keeping those of the last size distinct queries, DefaultStatementCacheSize when
+// size is 0. Hot list reads then skip parsing and planning. Raw SQL of Exec and Query isn't
+// prepared. The Bun adapter renders the values of queries into their SQL, so it has no
+// statement cache.
+func (a *SQLAdapter) WithStatementCache(size int) *SQLAdapter {
+	a.stmts = NewStatementCache(a.db, size)
+	return a
+}
+
+// StatementCache returns the statement cache of the adapter, nil without one
+func (a *SQLAdapter) StatementCache() *StatementCache {
+	return a.stmts
+}
+
+func (a *SQLAdapter) conn() sqlConn {
+	if a.tx != nil {
+		return a.tx
+	}
+	if a.stmts != nil {
+		return cachedConn{a.stmts}
+	}
+	return a.db
+}
+
+// cachedConn runs queries with the prepared statements of a statement cache
+type cachedConn struct {
+	stmts *StatementCache
+}
+
+func (c cachedConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, release, err := c.stmts.Prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return stmt.ExecContext(ctx, args...)
+}
+
+func (c cachedConn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, release, err := c.stmts.Prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return stmt.QueryContext(ctx, args...)
+}
+
+// uncached returns the adapter without its statement cache, for raw SQL like DDL and scripts of
+// several statements, which are run once and may not prepare
+func (a *SQLAdapter) uncached() *SQLAdapter {
+	if a.stmts == nil {
+		return a
+	}
+	raw := *a
+	raw.stmts = nil
+	return &raw
+}
+
+func (a *SQLAdapter) NewSelect() common.SelectQuery {
+	return &SQLSelectQuery{adapter: a}
+}
+
+func (a *SQLAdapter) NewInsert() common.InsertQuery {
+	return &SQLInsertQuery{adapter: a}
+}
+
+func (a *SQLAdapter) NewUpdate() common.UpdateQuery {
+	return &SQLUpdateQuery{adapter: a}
+}
+
+func (a *SQLAdapter) NewDelete() common.DeleteQuery {
+	return &SQLDeleteQuery{adapter: a}
+}
+
+func (a *SQLAdapter) Exec(ctx context.Context, query string, args ...interface{}) (res common.Result, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "sql", "exec", tracing.AttrDBStatement.String(query))
+	defer tracing.EndSpan(span, &err)
+	defer func() {
+		if r := recover(); r != nil {
+			err = logger.HandlePanic("SQLAdapter.Exec", r)
+		}
+	}()
+	result, err := a.uncached().exec(ctx, query, args)
+	if err != nil {
+		return result, err
+	}
+	span.SetAttributes(tracing.AttrRowsAffected.Int64(result.RowsAffected()))
+	return result, nil
+}
+
+func (a *SQLAdapter) Query(ctx context.Context, dest interface{}, query string, args ...interface{}) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "sql", "query", tracing.AttrDBStatement.String(query))
+	defer tracing.EndSpan(span, &err)
+	defer func() {
+		if r := recover(); r != nil {
+			err = logger.HandlePanic("SQLAdapter.Query", r)
+		}
+	}()
+	return a.uncached().query(ctx, dest, query, args)
+}
+
+// exec binds the arguments of query for the dialect and executes it
+func (a *SQLAdapter) exec(ctx context.Context, query string, args []interface{}) (*SQLResult, error) {
+	query, args = bindSQL(a.dialect, query, args)
+	result, err := a.conn().ExecContext(ctx, query, args...)
+	if err != nil {
+		return &SQLResult{}, err
+	}
+	return &SQLResult{result: result}, nil
+}
+
+// query binds the arguments of query for the dialect and scans the rows into dest
+func (a *SQLAdapter) query(ctx context.Context, dest interface{}, query string, args []interface{}) error {
+	_, err := a.queryRows(ctx, dest, query, args)
+	return err
+}
+
+// queryRows is query returning the number of scanned rows
+func (a *SQLAdapter) queryRows(ctx context.Context, dest interface{}, query string, args []interface{}) (int, error) {
+	query, args = bindSQL(a.dialect, query, args)
+	rows, err := a.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	n, err := scanRows(rows, dest)
+	if err != nil {
+		return n, err
+	}
+	return n, rows.Err()
+}
+
+// queryEach scans the i-th row of a query into the model pointer dest returns, skipping rows
+// without a destination, and returns the number of rows
+func (a *SQLAdapter) queryEach(ctx context.Context, query string, args []interface{}, dest func(i int) reflect.Value) (int, error) {
+	query, args = bindSQL(a.dialect, query, args)
+	rows, err := a.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for ; rows.Next(); n++ {
+		if ptr := dest(n); ptr.IsValid() {
+			if err := scanRow(rows, columns, ptr); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, rows.Err()
+}
+
+// quote quotes an identifier for the dialect
+func (a *SQLAdapter) quote(ident string) string {
+	if a.dialect.Name == "mysql" {
+		return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+	}
+	return common.QuoteIdent(ident)
+}
+
+func (a *SQLAdapter) BeginTx(ctx context.Context) (common.Database, error) {
+	return a.beginTx(ctx)
+}
+
+// beginTx starts a transaction on a dedicated connection, which bulk loads use through
+// the driver connection
+func (a *SQLAdapter) beginTx(ctx context.Context) (*SQLAdapter, error) {
+	if a.tx != nil {
+		return nil, fmt.Errorf("nested transactions not supported")
+	}
+	conn, err := a.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &SQLAdapter{db: a.db, txConn: conn, tx: tx, dialect: a.dialect, stmts: a.stmts}, nil
+}
+
+func (a *SQLAdapter) CommitTx(ctx context.Context) error {
+	if a.tx == nil {
+		return fmt.Errorf("no transaction to commit")
+	}
+	defer a.txConn.Close()
+	return a.tx.Commit()
+}
+
+func (a *SQLAdapter) RollbackTx(ctx context.Context) error {
+	if a.tx == nil {
+		return fmt.Errorf("no transaction to roll back")
+	}
+	defer a.txConn.Close()
+	return a.tx.Rollback()
+}
+
+func (a *SQLAdapter) RunInTransaction(ctx context.Context, fn func(common.Database) error) error {
+	if a.tx != nil {
+		return fn(a) // Already in transaction
+	}
+	tx, err := a.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	return runInTransaction(ctx, tx, fn)
+}
+
+// runInTransaction runs fn in the transaction tx, commits on success and rolls back on
+// errors and panics
+func runInTransaction(ctx context.Context, tx common.Database, fn func(common.Database) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.RollbackTx(ctx)
+			err = logger.HandlePanic("SQLAdapter.RunInTransaction", r)
+		}
+	}()
+	if err := fn(tx); err != nil {
+		_ = tx.RollbackTx(ctx)
+		return err
+	}
+	return tx.CommitTx(ctx)
+}
+
+// sqlExpr is a SQL fragment with its arguments
+type sqlExpr struct {
+	query string
+	args  []interface{}
+}
+
+// sqlCondition is a WHERE condition joined to the previous ones with AND or OR
+type sqlCondition struct {
+	sqlExpr
+	or bool
+}
+
+// writeConditions renders conditions as "(a) AND (b) OR (c)"
+func writeConditions(sb *strings.Builder, args *[]interface{}, conditions []sqlCondition) {
+	for i, condition := range conditions {
+		if i > 0 {
+			if condition.or {
+				sb.WriteString(" OR ")
+			} else {
+				sb.WriteString(" AND ")
+			}
+		}
+		sb.WriteString("(" + condition.query + ")")
+		*args = append(*args, condition.args...)
+	}
+}
+
+// SQLSelectQuery implements SelectQuery for database/sql
+type SQLSelectQuery struct {
+	adapter    *SQLAdapter
+	model      interface{}
+	table      string
+	alias      string
+	columns    []sqlExpr
+	joins      []sqlExpr
+	where      []sqlCondition
+	group      []string
+	having     []sqlCondition
+	order      []sqlExpr
+	limit      int
+	offset     int
+	distinct   bool
+	distinctOn []string
+	err        error
+}
+
+func (q *SQLSelectQuery) Model(model interface{}) common.SelectQuery {
+	q.model = model
+	if q.table == "" {
+		q.table, q.alias = sqlTableName(model)
+	}
+	return q
+}
+
+func (q *SQLSelectQuery) Table(table string) common.SelectQuery {
+	q.table = table
+	q.alias = ""
+	return q
+}
+
+func (q *SQLSelectQuery) Column(columns ...string) common.SelectQuery {
+	for _, column := range columns {
+		q.columns = append(q.columns, sqlExpr{query: column})
+	}
+	return q
+}
+
+func (q *SQLSelectQuery) ColumnExpr(query string, args ...interface{}) common.SelectQuery {
+	q.columns = append(q.columns, sqlExpr{query: query, args: args})
+	return q
+}
+
+func (q *SQLSelectQuery) Where(query string, args ...interface{}) common.SelectQuery {
+	q.where = append(q.where, sqlCondition{sqlExpr: sqlExpr{query: query, args: args}})
+	return q
+}
+
+func (q *SQLSelectQuery) WhereOr(query string, args ...interface{}) common.SelectQuery {
+	q.where = append(q.where, sqlCondition{sqlExpr: sqlExpr{query: query, args: args}, or: true})
+	return q
+}
+
+func (q *SQLSelectQuery) Join(query string, args ...interface{}) common.SelectQuery {
+	if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "JOIN") {
+		query = "JOIN " + query
+	}
+	q.joins = append(q.joins, sqlExpr{query: query, args: args})
+	return q
+}
+
+func (q *SQLSelectQuery) LeftJoin(query string, args ...interface{}) common.SelectQuery {
+	q.joins = append(q.joins, sqlExpr{query: "LEFT JOIN " + query, args: args})
+	return q
+}
+
+func (q *SQLSelectQuery) Preload(relation string, conditions ...interface{}) common.SelectQuery {
+	q.err = fmt.Errorf("relation %s cannot be preloaded with the database/sql adapter", relation)
+	return q
+}
+
+func (q *SQLSelectQuery) PreloadRelation(relation string, apply ...func(common.SelectQuery) common.SelectQuery) common.SelectQuery {
+	return q.Preload(relation)
+}
+
+func (q *SQLSelectQuery) JoinRelation(relation string, apply ...func(common.SelectQuery) common.SelectQuery) common.SelectQuery {
+	q.err = fmt.Errorf("relation %s cannot be joined with the database/sql adapter", relation)
+	return q
+}
+
+// ScopeTenantRelations implements common.TenantRelationScoper. The adapter has no relations to
+// scope.
+func (q *SQLSelectQuery) ScopeTenantRelations(scope *common.TenantScope) bool {
+	return true
+}
+
+func (q *SQLSelectQuery) Order(order string) common.SelectQuery {
+	q.order = append(q.order, sqlExpr{query: order})
+	return q
+}
+
+func (q *SQLSelectQuery) OrderExpr(order string, args ...interface{}) common.SelectQuery {
+	q.order = append(q.order, sqlExpr{query: order, args: args})
+	return q
+}
+
+func (q *SQLSelectQuery) Limit(n int) common.SelectQuery {
+	q.limit = n
+	return q
+}
+
+func (q *SQLSelectQuery) Offset(n int) common.SelectQuery {
+	q.offset = n
+	return q
+}
+
+func (q *SQLSelectQuery) Group(group string) common.SelectQuery {
+	q.group = append(q.group, group)
+	return q
+}
+
+func (q *SQLSelectQuery) Having(having string, args ...interface{}) common.SelectQuery {
+	q.having = append(q.having, sqlCondition{sqlExpr: sqlExpr{query: having, args: args}})
+	return q
+}
+
+func (q *SQLSelectQuery) Distinct() common.SelectQuery {
+	q.distinct = true
+	return q
+}
+
+func (q *SQLSelectQuery) DistinctOn(columns ...string) common.SelectQuery {
+	if !q.adapter.dialect.DistinctOn {
+		q.err = fmt.Errorf("DISTINCT ON is not supported by the %s database", q.adapter.dialect.Name)
+		return q
+	}
+	q.distinctOn = append(q.distinctOn, columns...)
+	return q
+}
+
+// render renders the SELECT statement; ORDER BY, LIMIT and OFFSET only when paginate is set
+func (q *SQLSelectQuery) render(paginate bool) (string, []interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+	if q.table == "" {
+		return "", nil, fmt.Errorf("select query needs a model or a table")
+	}
+
+	var sb strings.Builder
+	var args []interface{}
+	sb.WriteString("SELECT ")
+	if len(q.distinctOn) > 0 {
+		sb.WriteString("DISTINCT ON (" + strings.Join(q.distinctOn, ", ") + ") ")
+	} else if q.distinct {
+		sb.WriteString("DISTINCT ")
+	}
+	if len(q.columns) == 0 {
+		sb.WriteString("*")
+	}
+	for i, column := range q.columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(column.query)
+		args = append(args, column.args...)
+	}
+
+	sb.WriteString(" FROM " + q.table)
+	if q.alias != "" {
+		sb.WriteString(" AS " + q.alias)
+	}
+	for _, join := range q.joins {
+		sb.WriteString(" " + join.query)
+		args = append(args, join.args...)
+	}
+	if len(q.where) > 0 {
+		sb.WriteString(" WHERE ")
+		writeConditions(&sb, &args, q.where)
+	}
+	if len(q.group) > 0 {
+		sb.WriteString(" GROUP BY " + strings.Join(q.group, ", "))
+	}
+	if len(q.having) > 0 {
+		sb.WriteString(" HAVING ")
+		writeConditions(&sb, &args, q.having)
+	}
+	if paginate {
+		for i, order := range q.order {
+			if i == 0 {
+				sb.WriteString(" ORDER BY ")
+			} else {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(order.query)
+			args = append(args, order.args...)
+		}
+		sb.WriteString(q.adapter.limitClause(q.limit, q.offset, len(q.order) > 0))
+	}
+	return sb.String(), args, nil
+}
+
+// limitClause renders LIMIT and OFFSET for the dialect
+func (a *SQLAdapter) limitClause(limit, offset int, ordered bool) string {
+	if limit <= 0 && offset <= 0 {
+		return ""
+	}
+	if a.dialect.Name == "mssql" {
+		clause := ""
+		if !ordered {
+			clause = " ORDER BY (SELECT NULL)"
+		}
+		clause += fmt.Sprintf(" OFFSET %d ROWS", offset)
+		if limit > 0 {
+			clause += fmt.Sprintf(" FETCH NEXT %d ROWS ONLY", limit)
+		}
+		return clause
+	}
+
+	clause := ""
+	switch {
+	case limit > 0:
+		clause = fmt.Sprintf(" LIMIT %d", limit)
+	case a.dialect.Name == "sqlite":
+		// SQLite and MySQL only accept OFFSET after a LIMIT
+		clause = " LIMIT -1"
+	case a.dialect.Name == "mysql":
+		clause = " LIMIT 18446744073709551615"
+	}
+	if offset > 0 {
+		clause += fmt.Sprintf(" OFFSET %d", offset)
+	}
+	return clause
+}
+
+func (q *SQLSelectQuery) Scan(ctx context.Context, dest interface{}) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "sql", "select", tracing.AttrTable.String(q.table))
+	defer tracing.EndSpan(span, &err)
+	defer func() {
+		if r := recover(); r != nil {
+			err = logger.HandlePanic("SQLSelectQuery.Scan", r)
+		}
+	}()
+	query, args, err := q.render(true)
+	if err != nil {
+		return err
+	}
+	err = q.adapter.query(ctx, dest, query, args)
+	span.SetAttributes(tracing.AttrRowCount.Int(scannedRowCount(dest)))
+	return err
+}
+
+// ToSQL renders the SELECT statement with the placeholders of the dialect
+func (q *SQLSelectQuery) ToSQL() (string, []interface{}, error) {
+	query, args, err := q.render(true)
+	if err != nil {
+		return "", nil, err
+	}
+	query, args = bindSQL(q.adapter.dialect, query, args)
+	return query, args, nil
+}
+
+func (q *SQLSelectQuery) ScanModel(ctx context.Context) error {
+	if q.model == nil {
+		return fmt.Errorf("ScanModel requires Model() to be set before scanning")
+	}
+	return q.Scan(ctx, q.model)
+}
+
+func (q *SQLSelectQuery) Count(ctx context.Context) (count int, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "sql", "count", tracing.AttrTable.String(q.table))
+	defer tracing.EndSpan(span, &err)
+	defer func() {
+		if r := recover(); r != nil {
+			err = logger.HandlePanic("SQLSelectQuery.Count", r)
+			count = 0
+		}
+	}()
+
+	var query string
+	var args []interface{}
+	if q.distinct || len(q.distinctOn) > 0 || len(q.group) > 0 {
+		// DISTINCT and grouped queries are counted by wrapping them as a subquery
+		inner, innerArgs, err := q.render(false)
+		if err != nil {
+			return 0, err
+		}
+		query, args = "SELECT count(*) FROM ("+inner+") AS subquery", innerArgs
+	} else {
+		counter := *q
+		counter.columns = []sqlExpr{{query: "count(*)"}}
+		if query, args, err = counter.render(false); err != nil {
+			return 0, err
+		}
+	}
+
+	err = q.adapter.query(ctx, &count, query, args)
+	return count, err
+}
+
+func (q *SQLSelectQuery) Exists(ctx context.Context) (exists bool, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "sql", "exists", tracing.AttrTable.String(q.table))
+	defer tracing.EndSpan(span, &err)
+	defer func() {
+		if r := recover(); r != nil {
+			err = logger.HandlePanic("SQLSelectQuery.Exists", r)
+			exists = false
+		}
+	}()
+	probe := *q
+	probe.columns = []sqlExpr{{query: "1"}}
+	probe.limit, probe.offset = 1, 0
+	query, args, err := probe.render(true)
+	if err != nil {
+		return false, err
+	}
+	var rows []int
+	err = q.adapter.query(ctx, &rows, query, args)
+	return len(rows) > 0, err
+}
+
+// SQLInsertQuery implements InsertQuery for database/sql
+type SQLInsertQuery struct {
+	adapter    *SQLAdapter
+	model      interface{}
+	table      string
+	values     map[string]interface{}
+	onConflict string
+	returning  []string
+	chunkSize  int // Rows per INSERT of a bulk insert; 0 inserts row by row
+}
+
+func (q *SQLInsertQuery) Model(model interface{}) common.InsertQuery {
+	q.model = model
+	if q.table == "" {
+		q.table, _ = sqlTableName(model)
+	}
+	return q
+}
+
+func (q *SQLInsertQuery) Table(table string) common.InsertQuery {
+	q.table = table
+	return q
+}
+
+func (q *SQLInsertQuery) Value(column string, value interface{}) common.InsertQuery {
+	if q.values == nil {
+		q.values = make(map[string]interface{})
+	}
+	q.values[column] = value
+	return q
+}
+
+// OnConflict adds an ON CONFLICT clause, e.g. "(id) DO NOTHING", on dialects with that upsert syntax
+func (q *SQLInsertQuery) OnConflict(action string) common.InsertQuery {
+	q.onConflict = action
+	return q
+}
+
+// Returning loads the returned columns into the model, or into the Returned map of table
+// inserts, on dialects with RETURNING. No columns or "*" returns all.
+func (q *SQLInsertQuery) Returning(columns ...string) common.InsertQuery {
+	q.returning = append([]string{}, columns...)
+	return q
+}
+
+// Bulk inserts the models of a slice with multi-row INSERT statements of at most chunkSize
+// rows. Consecutive rows with the same columns share a statement.
+func (q *SQLInsertQuery) Bulk(models interface{}, chunkSize int) common.InsertQuery {
+	if chunkSize <= 0 {
+		chunkSize = common.DefaultInsertChunkSize
+	}
+	q.chunkSize = chunkSize
+	return q.Model(models)
+}
+
+func (q *SQLInsertQuery) Exec(ctx context.Context) (res common.Result, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "sql", "insert", tracing.AttrTable.String(q.table))
+	defer tracing.EndSpan(span, &err)
+	defer func() {
+		if r := recover(); r != nil {
+			err = logger.HandlePanic("SQLInsertQuery.Exec", r)
+		}
+	}()
+	if err := q.check(); err != nil {
+		return &SQLResult{}, err
+	}
+	rows := q.rows()
+
+	result := &SQLResult{}
+	switch {
+	case len(rows) == 0:
+		err = q.insertRow(ctx, reflect.Value{}, result)
+	case q.chunkSize > 0:
+		err = q.insertChunks(ctx, rows, result)
+	default:
+		for _, row := range rows {
+			if err = q.insertRow(ctx, row, result); err != nil {
+				break
+			}
+		}
+	}
+	span.SetAttributes(tracing.AttrRowsAffected.Int64(result.RowsAffected()))
+	return result, err
+}
+
+// ToSQL renders the INSERT statement with the placeholders of the dialect. Slices of models
+// are inserted row by row, or chunk by chunk by Bulk; ToSQL renders the first statement.
+func (q *SQLInsertQuery) ToSQL() (string, []interface{}, error) {
+	if err := q.check(); err != nil {
+		return "", nil, err
+	}
+	var query string
+	var args []interface{}
+	rows := q.rows()
+	switch {
+	case len(rows) == 0:
+		query, args, _ = q.statement(reflect.Value{})
+	case q.chunkSize > 0:
+		chunk := q.nextChunk(rows)
+		query, args = chunk.query, chunk.args
+	default:
+		query, args, _ = q.statement(rows[0])
+	}
+	query, args = bindSQL(q.adapter.dialect, query, args)
+	return query, args, nil
+}
+
+// check reports inserts the dialect can't run
+func (q *SQLInsertQuery) check() error {
+	if q.table == "" {
+		return fmt.Errorf("insert query needs a model or a table")
+	}
+	if q.onConflict != "" && q.adapter.dialect.Upsert != common.UpsertOnConflict {
+		return fmt.Errorf("ON CONFLICT is not supported by dialect %q", q.adapter.dialect.Name)
+	}
+	return nil
+}
+
+// rows returns the model rows to insert, none for table inserts
+func (q *SQLInsertQuery) rows() []reflect.Value {
+	// Slices of models are inserted row by row, so each row gets its generated values
+	rows := []reflect.Value{}
+	if q.model != nil {
+		val := reflect.ValueOf(q.model)
+		for val.Kind() == reflect.Pointer && val.Elem().Kind() == reflect.Pointer {
+			val = val.Elem()
+		}
+		if val.Kind() == reflect.Pointer && val.Elem().Kind() == reflect.Slice {
+			val = val.Elem()
+			for i := 0; i < val.Len(); i++ {
+				row := val.Index(i)
+				if row.Kind() != reflect.Pointer {
+					row = row.Addr()
+				}
+				rows = append(rows, row)
+			}
+		} else {
+			rows = append(rows, val)
+		}
+	}
+	return rows
+}
+
+// statement renders the INSERT of a model row (invalid for table inserts) with its primary key field
+func (q *SQLInsertQuery) statement(row reflect.Value) (string, []interface{}, reflect.Value) {
+	columns, args, pkField := q.rowValues(row)
+	return q.render(columns, 1), args, pkField
+}
+
+// rowValues returns the columns and arguments of a model row (invalid for table inserts) with
+// its primary key field
+func (q *SQLInsertQuery) rowValues(row reflect.Value) ([]string, []interface{}, reflect.Value) {
+	var columns []string
+	var args []interface{}
+	var pkField reflect.Value
+	if row.IsValid() {
+		model := row.Interface()
+		pkName := reflection.GetPrimaryKeyName(model)
+		for _, field := range sqlFieldsOf(row.Type()).columns {
+			value := row.Elem().FieldByIndex(field.index)
+			if _, set := q.values[field.name]; set {
+				continue
+			}
+			if field.name == pkName {
+				pkField = value
+				if value.IsZero() {
+					// Left out, so the database generates the key
+					continue
+				}
+			}
+			if !reflection.IsColumnWritable(model, field.name) {
+				continue
+			}
+			columns = append(columns, field.name)
+			args = append(args, sqlArgValue(value.Interface()))
+		}
+	}
+	for _, column := range sortedKeys(q.values) {
+		columns = append(columns, column)
+		args = append(args, sqlArgValue(q.values[column]))
+	}
+	return columns, args, pkField
+}
+
+// render renders the INSERT of rows rows of columns
+func (q *SQLInsertQuery) render(columns []string, rows int) string {
+	a := q.adapter
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO " + q.table)
+	switch {
+	case len(columns) > 0:
+		quoted := make([]string, len(columns))
+		for i, column := range columns {
+			quoted[i] = a.quote(column)
+		}
+		row := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+		sb.WriteString(" (" + strings.Join(quoted, ", ") + ") VALUES " + strings.TrimSuffix(strings.Repeat(row+", ", rows), ", "))
+	case a.dialect.Name == "mysql":
+		sb.WriteString(" () VALUES ()")
+	default:
+		sb.WriteString(" DEFAULT VALUES")
+	}
+	if q.onConflict != "" {
+		sb.WriteString(" ON CONFLICT " + q.onConflict)
+	}
+	if q.returning != nil && a.dialect.Returning {
+		sb.WriteString(" RETURNING " + returningColumns(q.returning))
+	}
+	return sb.String()
+}
+
+// maxInsertArgs is the most bind parameters of a multi-row INSERT, the limit of PostgreSQL
+const maxInsertArgs = 65535
+
+// insertChunk is a multi-row INSERT of consecutive model rows
+type insertChunk struct {
+	query    string
+	args     []interface{}
+	rows     []reflect.Value
+	pkFields []reflect.Value
+}
+
+// nextChunk renders the INSERT of the first rows with the columns of the first row, at most
+// chunkSize rows and maxInsertArgs parameters. Rows inserting default values only are
+// inserted one by one.
+func (q *SQLInsertQuery) nextChunk(rows []reflect.Value) insertChunk {
+	columns, args, pkField := q.rowValues(rows[0])
+	chunk := insertChunk{args: args, rows: rows[:1], pkFields: []reflect.Value{pkField}}
+	for len(columns) > 0 && len(chunk.rows) < min(q.chunkSize, len(rows)) {
+		next := len(chunk.rows)
+		rowColumns, rowArgs, rowPK := q.rowValues(rows[next])
+		if !slices.Equal(rowColumns, columns) || len(chunk.args)+len(rowArgs) > maxInsertArgs {
+			break
+		}
+		chunk.args = append(chunk.args, rowArgs...)
+		chunk.rows = rows[:next+1]
+		chunk.pkFields = append(chunk.pkFields, rowPK)
+	}
+	chunk.query = q.render(columns, len(chunk.rows))
+	return chunk
+}
+
+// insertChunks inserts model rows with multi-row INSERT statements and adds to the result
+func (q *SQLInsertQuery) insertChunks(ctx context.Context, rows []reflect.Value, result *SQLResult) error {
+	a := q.adapter
+	for len(rows) > 0 {
+		chunk := q.nextChunk(rows)
+		rows = rows[len(chunk.rows):]
+
+		if q.returning != nil && a.dialect.Returning {
+			// The returned rows fill generated keys and defaults into the models in order
+			n, err := a.queryEach(ctx, chunk.query, chunk.args, func(i int) reflect.Value {
+				if i < len(chunk.rows) {
+					return chunk.rows[i]
+				}
+				return reflect.Value{}
+			})
+			result.rowsAffected += int64(n)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		execResult, err := a.exec(ctx, chunk.query, chunk.args)
+		if err != nil {
+			return err
+		}
+		result.result = execResult.result
+		result.rowsAffected += execResult.RowsAffected()
+		// MySQL reports the key generated for the first row of a multi-row INSERT; the keys
+		// of the following rows are consecutive
+		if a.dialect.Name != "mysql" {
+			continue
+		}
+		id, err := execResult.result.LastInsertId()
+		if err != nil || id <= 0 {
+			continue
+		}
+		for _, pkField := range chunk.pkFields {
+			if pkField.IsValid() && pkField.IsZero() && pkField.CanInt() {
+				pkField.SetInt(id)
+				id++
+			}
+		}
+	}
+	return nil
+}
+
+// insertRow inserts a model row (invalid for table inserts) and adds to the result
+func (q *SQLInsertQuery) insertRow(ctx context.Context, row reflect.Value, result *SQLResult) error {
+	a := q.adapter
+	query, args, pkField := q.statement(row)
+	if q.returning != nil && a.dialect.Returning {
+		// The returned row fills generated keys and defaults into the model
+		if row.IsValid() {
+			n, err := a.queryRows(ctx, row.Interface(), query, args)
+			result.rowsAffected += int64(n)
+			return err
+		}
+		var returned []map[string]interface{}
+		n, err := a.queryRows(ctx, &returned, query, args)
+		result.rowsAffected += int64(n)
+		if n > 0 {
+			result.returned = returned[0]
+		}
+		return err
+	}
+
+	execResult, err := a.exec(ctx, query, args)
+	if err != nil {
+		return err
+	}
+	result.result = execResult.result
+	result.rowsAffected += execResult.RowsAffected()
+	// Without RETURNING a generated integer key is taken from the driver
+	if pkField.IsValid() && pkField.IsZero() && pkField.CanInt() {
+		if id, err := execResult.result.LastInsertId(); err == nil && id > 0 {
+			pkField.SetInt(id)
+		}
+	}
+	return nil
+}
+
+// returningColumns renders the columns of a RETURNING clause; none or "*" returns all
+func returningColumns(columns []string) string {
+	if len(columns) == 0 || (len(columns) == 1 && columns[0] == "*") {
+		return "*"
+	}
+	return strings.Join(columns, ", ")
+}
+
+// SQLUpdateQuery implements UpdateQuery for database/sql
+type SQLUpdateQuery struct {
+	adapter   *SQLAdapter
+	model     interface{}
+	table     string
+	sets      []sqlSet
+	where     []sqlCondition
+	returning []string
+	err       error
+}
+
+// sqlSet is a column of an UPDATE with its new value or JSON patch
+type sqlSet struct {
+	column string
+	value  interface{}
+}
+
+func (q *SQLUpdateQuery) Model(model interface{}) common.UpdateQuery {
+	q.model = model
+	if q.table == "" {
+		q.table, _ = sqlTableName(model)
+	}
+	return q
+}
+
+func (q *SQLUpdateQuery) Table(table string) common.UpdateQuery {
+	q.table = table
+	if q.model == nil {
+		// Try to get the model from the table name, so read-only columns are skipped
+		model, err := modelregistry.GetModelByName(table)
+		if err == nil {
+			q.model = model
+		}
+	}
+	return q
+}
+
+func (q *SQLUpdateQuery) Set(column string, value interface{}) common.UpdateQuery {
+	// Skip read-only columns if model is set
+	if q.model != nil && !reflection.IsColumnWritable(q.model, column) {
+		return q
+	}
+	q.sets = append(q.sets, sqlSet{column: column, value: value})
+	return q
+}
+
+func (q *SQLUpdateQuery) SetMap(values map[string]interface{}) common.UpdateQuery {
+	values, patches, err := common.ExtractJSONPatches(values)
+	if err != nil {
+		q.err = err
+		return q
+	}
+	for column, patch := range patches {
+		values[column] = patch
+	}
+
+	pkName := ""
+	if q.model != nil {
+		pkName = reflection.GetPrimaryKeyName(q.model)
+	}
+	for _, column := range sortedKeys(values) {
+		if pkName != "" && column == pkName {
+			// Skip primary key updates
+			continue
+		}
+		q.Set(column, values[column])
+	}
+	return q
+}
+
+func (q *SQLUpdateQuery) Where(query string, args ...interface{}) common.UpdateQuery {
+	q.where = append(q.where, sqlCondition{sqlExpr: sqlExpr{query: query, args: args}})
+	return q
+}
+
+// Returning loads the updated row into the model on dialects with RETURNING
+func (q *SQLUpdateQuery) Returning(columns ...string) common.UpdateQuery {
+	q.returning = append([]string{}, columns...)
+	return q
+}
+
+func (q *SQLUpdateQuery) Exec(ctx context.Context) (res common.Result, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "sql", "update", tracing.AttrTable.String(q.table))
+	defer tracing.EndSpan(span, &err)
+	defer func() {
+		if r := recover(); r != nil {
+			err = logger.HandlePanic("SQLUpdateQuery.Exec", r)
+		}
+	}()
+	query, args, row, err := q.statement()
+	if err != nil {
+		return &SQLResult{}, err
+	}
+
+	if q.returning != nil && q.adapter.dialect.Returning && row.IsValid() {
+		n, err := q.adapter.queryRows(ctx, row.Interface(), query, args)
+		return &SQLResult{rowsAffected: int64(n)}, err
+	}
+
+	result, err := q.adapter.exec(ctx, query, args)
+	if err != nil {
+		return result, err
+	}
+	span.SetAttributes(tracing.AttrRowsAffected.Int64(result.RowsAffected()))
+	return result, nil
+}
+
+// ToSQL renders the UPDATE statement with the placeholders of the dialect
+func (q *SQLUpdateQuery) ToSQL() (string, []interface{}, error) {
+	query, args, _, err := q.statement()
+	if err != nil {
+		return "", nil, err
+	}
+	query, args = bindSQL(q.adapter.dialect, query, args)
+	return query, args, nil
+}
+
+// statement renders the UPDATE with the model row, which receives returned columns
+func (q *SQLUpdateQuery) statement() (string, []interface{}, reflect.Value, error) {
+	row := sqlModelRow(q.model)
+	if q.err != nil {
+		return "", nil, row, q.err
+	}
+	if q.table == "" {
+		return "", nil, row, fmt.Errorf("update query needs a model or a table")
+	}
+
+	sets, where := q.sets, q.where
+	if row.IsValid() {
+		// A model without Set values updates all of its writable columns by primary key
+		pkName := reflection.GetPrimaryKeyName(q.model)
+		if len(sets) == 0 {
+			for _, field := range sqlFieldsOf(row.Type()).columns {
+				if field.name != pkName && reflection.IsColumnWritable(q.model, field.name) {
+					sets = append(sets, sqlSet{column: field.name, value: row.Elem().FieldByIndex(field.index).Interface()})
+				}
+			}
+		}
+		if len(where) == 0 && pkName != "" {
+			where = []sqlCondition{{sqlExpr: sqlExpr{query: q.adapter.quote(pkName) + " = ?", args: []interface{}{reflection.GetPrimaryKeyValue(q.model)}}}}
+		}
+	}
+	if len(sets) == 0 {
+		return "", nil, row, fmt.Errorf("update query has no columns to set")
+	}
+	if len(where) == 0 {
+		return "", nil, row, fmt.Errorf("update query needs a WHERE condition")
+	}
+
+	var sb strings.Builder
+	var args []interface{}
+	sb.WriteString("UPDATE " + q.table + " SET ")
+	for i, set := range sets {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		if patch, ok := set.value.(common.JSONPatch); ok {
+			expr, patchArgs, err := common.JSONPatchSQL(q.adapter.dialect.Name, set.column, patch)
+			if err != nil {
+				return "", nil, row, err
+			}
+			sb.WriteString(q.adapter.quote(set.column) + " = " + expr)
+			args = append(args, patchArgs...)
+			continue
+		}
+		sb.WriteString(q.adapter.quote(set.column) + " = ?")
+		args = append(args, sqlArgValue(set.value))
+	}
+	sb.WriteString(" WHERE ")
+	writeConditions(&sb, &args, where)
+	if q.returning != nil && q.adapter.dialect.Returning && row.IsValid() {
+		sb.WriteString(" RETURNING " + returningColumns(q.returning))
+	}
+	return sb.String(), args, row, nil
+}
+
+// SQLDeleteQuery implements DeleteQuery for database/sql
+type SQLDeleteQuery struct {
+	adapter *SQLAdapter
+	model   interface{}
+	table   string
+	where   []sqlCondition
+}
+
+func (q *SQLDeleteQuery) Model(model interface{}) common.DeleteQuery {
+	q.model = model
+	if q.table == "" {
+		q.table, _ = sqlTableName(model)
+	}
+	return q
+}
+
+func (q *SQLDeleteQuery) Table(table string) common.DeleteQuery {
+	q.table = table
+	return q
+}
+
+func (q *SQLDeleteQuery) Where(query string, args ...interface{}) common.DeleteQuery {
+	q.where = append(q.where, sqlCondition{sqlExpr: sqlExpr{query: query, args: args}})
+	return q
+}
+
+func (q *SQLDeleteQuery) Exec(ctx context.Context) (res common.Result, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "sql", "delete", tracing.AttrTable.String(q.table))
+	defer tracing.EndSpan(span, &err)
+	defer func() {
+		if r := recover(); r != nil {
+			err = logger.HandlePanic("SQLDeleteQuery.Exec", r)
+		}
+	}()
+	query, args, err := q.statement()
+	if err != nil {
+		return &SQLResult{}, err
+	}
+	result, err := q.adapter.exec(ctx, query, args)
+	if err != nil {
+		return result, err
+	}
+	span.SetAttributes(tracing.AttrRowsAffected.Int64(result.RowsAffected()))
+	return result, nil
+}
+
+// ToSQL renders the DELETE statement with the placeholders of the dialect
+func (q *SQLDeleteQuery) ToSQL() (string, []interface{}, error) {
+	query, args, err := q.statement()
+	if err != nil {
+		return "", nil, err
+	}
+	query, args = bindSQL(q.adapter.dialect, query, args)
+	return query, args, nil
+}
+
+// statement renders the DELETE
+func (q *SQLDeleteQuery) statement() (string, []interface{}, error) {
+	if q.table == "" {
+		return "", nil, fmt.Errorf("delete query needs a model or a table")
+	}
+
+	where := q.where
+	if len(where) == 0 && sqlModelRow(q.model).IsValid() {
+		// A model without conditions is deleted by primary key
+		if pkName := reflection.GetPrimaryKeyName(q.model); pkName != "" {
+			where = []sqlCondition{{sqlExpr: sqlExpr{query: q.adapter.quote(pkName) + " = ?", args: []interface{}{reflection.GetPrimaryKeyValue(q.model)}}}}
+		}
+	}
+	if len(where) == 0 {
+		return "", nil, fmt.Errorf("delete query needs a WHERE condition")
+	}
+
+	var sb strings.Builder
+	var args []interface{}
+	sb.WriteString("DELETE FROM " + q.table + " WHERE ")
+	writeConditions(&sb, &args, where)
+	return sb.String(), args, nil
+}
+
+// SQLResult implements Result for database/sql
+type SQLResult struct {
+	result       sql.Result
+	rowsAffected int64
+	returned     map[string]interface{}
+}
+
+// Returned implements common.ReturningResult
+func (r *SQLResult) Returned() map[string]interface{} {
+	return r.returned
+}
+
+func (r *SQLResult) RowsAffected() int64 {
+	if r.rowsAffected == 0 && r.result != nil {
+		if n, err := r.result.RowsAffected(); err == nil {
+			return n
+		}
+	}
+	return r.rowsAffected
+}
+
+func (r *SQLResult) LastInsertId() (int64, error) {
+	if id, ok := r.returned["id"].(int64); ok {
+		return id, nil
+	}
+	if r.result == nil {
+		return 0, nil
+	}
+	return r.result.LastInsertId()
+}
+
+// sortedKeys returns the keys of values in order, so statements are rendered deterministically
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,421 @@
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// bindSQL rewrites the ? placeholders of query to the placeholders of the dialect ($1 on
+// PostgreSQL, @p1 on SQL Server) and expands slice arguments into lists, so "id IN (?)" with
+// []int{1, 2} becomes "id IN ($1, $2)". Question marks in quotes and beyond the last
+// argument (e.g. the PostgreSQL jsonb ? operator) are kept.
+func bindSQL(dialect common.Dialect, query string, args []interface{}) (string, []interface{}) {
+	var sb strings.Builder
+	bound := make([]interface{}, 0, len(args))
+	next := 0
+	var quote rune
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '?' && next < len(args):
+			items := expandArg(args[next])
+			next++
+			if len(items) == 0 {
+				// An empty list matches nothing
+				sb.WriteString("NULL")
+				continue
+			}
+			for i, item := range items {
+				if i > 0 {
+					sb.WriteString(", ")
+				}
+				bound = append(bound, item)
+				sb.WriteString(placeholder(dialect, len(bound)))
+			}
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String(), append(bound, args[next:]...)
+}
+
+// expandArg returns the elements of a slice argument, or the argument itself
+func expandArg(arg interface{}) []interface{} {
+	if _, ok := arg.(driver.Valuer); ok {
+		return []interface{}{arg}
+	}
+	val := reflect.ValueOf(arg)
+	if (val.Kind() != reflect.Slice && val.Kind() != reflect.Array) || val.Type().Elem().Kind() == reflect.Uint8 {
+		return []interface{}{arg}
+	}
+	items := make([]interface{}, val.Len())
+	for i := range items {
+		items[i] = val.Index(i).Interface()
+	}
+	return items
+}
+
+func placeholder(dialect common.Dialect, n int) string {
+	switch dialect.Name {
+	case "postgres":
+		return "$" + strconv.Itoa(n)
+	case "mssql":
+		return "@p" + strconv.Itoa(n)
+	default:
+		return "?"
+	}
+}
+
+// sqlArgValue converts a value written to a column: maps, slices and structs other than
+// time.Time and driver.Valuer types are stored as JSON
+func sqlArgValue(value interface{}) interface{} {
+	switch value.(type) {
+	case nil, driver.Valuer, []byte, time.Time:
+		return value
+	}
+	val := reflect.ValueOf(value)
+	for val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return nil
+		}
+		if val.Type().Implements(valuerType) {
+			return value
+		}
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		if val.Type() == timeType {
+			return val.Interface()
+		}
+		data, err := json.Marshal(val.Interface())
+		if err != nil {
+			return value
+		}
+		return string(data)
+	default:
+		return val.Interface()
+	}
+}
+
+// sqlTableName returns the table and alias of a model, a slice of models or a pointer to them:
+// from TableName() and TableAlias(), or a bun "table:name,alias:a" tag; otherwise the
+// snake_case plural of the type name, like GORM
+func sqlTableName(model interface{}) (table, alias string) {
+	typ := reflect.TypeOf(model)
+	for typ != nil && (typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return "", ""
+	}
+
+	instance := reflect.New(typ).Interface()
+	if provider, ok := instance.(common.TableNameProvider); ok {
+		table = provider.TableName()
+	}
+	if provider, ok := instance.(common.TableAliasProvider); ok {
+		alias = provider.TableAlias()
+	}
+	for i := 0; i < typ.NumField() && table == ""; i++ {
+		field := typ.Field(i)
+		if !field.Anonymous {
+			continue
+		}
+		for _, option := range strings.Split(field.Tag.Get("bun"), ",") {
+			if name, ok := strings.CutPrefix(option, "table:"); ok {
+				table = name
+			} else if name, ok := strings.CutPrefix(option, "alias:"); ok && alias == "" {
+				alias = name
+			}
+		}
+	}
+	if table == "" {
+		table = snakeCase(typ.Name()) + "s"
+	}
+	return table, alias
+}
+
+// sqlModelRow returns the pointer to the struct of a single model, or an invalid value
+func sqlModelRow(model interface{}) reflect.Value {
+	val := reflect.ValueOf(model)
+	for val.Kind() == reflect.Pointer && val.Elem().Kind() == reflect.Pointer {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Pointer || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	return val
+}
+
+// sqlField is a struct field mapped to a column
+type sqlField struct {
+	name  string
+	index []int
+}
+
+// sqlFields are the columns of a model type, in field order and by lowercase name
+type sqlFields struct {
+	columns []sqlField
+	byName  map[string]sqlField
+}
+
+var sqlFieldCache sync.Map // reflect.Type -> *sqlFields
+
+// sqlFieldsOf maps the fields of a struct type (or pointer to it) to columns. The column is
+// taken from the bun or gorm tag, or the snake_case field name. Relations, fields tagged "-"
+// and untagged struct fields (relations by GORM convention) are not columns.
+func sqlFieldsOf(typ reflect.Type) *sqlFields {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if cached, ok := sqlFieldCache.Load(typ); ok {
+		return cached.(*sqlFields)
+	}
+	fields := &sqlFields{byName: make(map[string]sqlField)}
+	collectSQLFields(typ, nil, fields)
+	sqlFieldCache.Store(typ, fields)
+	return fields
+}
+
+func collectSQLFields(typ reflect.Type, index []int, fields *sqlFields) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		bunTag, gormTag := field.Tag.Get("bun"), field.Tag.Get("gorm")
+		if bunTag == "-" || gormTag == "-" {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			collectSQLFields(field.Type, fieldIndex, fields)
+			continue
+		}
+		if !field.IsExported() || isSQLRelation(field, bunTag, gormTag) {
+			continue
+		}
+
+		name := reflection.ExtractColumnFromBunTag(bunTag)
+		if name == "" {
+			name = reflection.ExtractColumnFromGormTag(gormTag)
+		}
+		if name == "" {
+			name = snakeCase(field.Name)
+		}
+		key := strings.ToLower(name)
+		if _, exists := fields.byName[key]; exists {
+			continue
+		}
+		mapped := sqlField{name: name, index: fieldIndex}
+		fields.columns = append(fields.columns, mapped)
+		fields.byName[key] = mapped
+	}
+}
+
+// snakeCase converts a Go name to snake_case keeping acronyms together, like GORM:
+// "UserID" -> "user_id", "HTTPStatus" -> "http_status"
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func isSQLRelation(field reflect.StructField, bunTag, gormTag string) bool {
+	if strings.Contains(bunTag, "rel:") || strings.Contains(bunTag, "join:") || strings.Contains(bunTag, "m2m:") {
+		return true
+	}
+	if strings.Contains(gormTag, "foreignKey:") || strings.Contains(gormTag, "references:") || strings.Contains(gormTag, "many2many:") {
+		return true
+	}
+	if bunTag != "" || gormTag != "" {
+		return false
+	}
+	// Untagged structs and slices of structs are relations; tagged ones are JSON columns
+	typ := field.Type
+	for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct && typ != timeType && !reflect.PointerTo(typ).Implements(scannerType)
+}
+
+// scanRows scans rows into dest, a pointer to a struct, a map[string]interface{}, a scalar or
+// a slice of them, and returns the number of scanned rows. A single struct, map or scalar
+// takes the first row and is left unchanged without rows.
+func scanRows(rows *sql.Rows, dest interface{}) (int, error) {
+	val := reflect.ValueOf(dest)
+	if val.Kind() != reflect.Pointer || val.IsNil() {
+		return 0, fmt.Errorf("scan destination must be a non-nil pointer, got %T", dest)
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	target := val.Elem()
+	if target.Kind() != reflect.Slice || target.Type().Elem().Kind() == reflect.Uint8 {
+		if !rows.Next() {
+			return 0, nil
+		}
+		return 1, scanRow(rows, columns, val)
+	}
+
+	elemType := target.Type().Elem()
+	isPointer := elemType.Kind() == reflect.Pointer
+	if isPointer {
+		elemType = elemType.Elem()
+	}
+	result := reflect.MakeSlice(target.Type(), 0, 0)
+	for rows.Next() {
+		item := reflect.New(elemType)
+		if err := scanRow(rows, columns, item); err != nil {
+			return result.Len(), err
+		}
+		if isPointer {
+			result = reflect.Append(result, item)
+		} else {
+			result = reflect.Append(result, item.Elem())
+		}
+	}
+	target.Set(result)
+	return result.Len(), nil
+}
+
+// scanRow scans the current row into the value ptr points to
+func scanRow(rows *sql.Rows, columns []string, ptr reflect.Value) error {
+	target := ptr.Elem()
+	dests := make([]interface{}, len(columns))
+
+	switch {
+	case target.Kind() == reflect.Map && target.Type().Key().Kind() == reflect.String:
+		values := make([]interface{}, len(columns))
+		for i := range values {
+			dests[i] = &values[i]
+		}
+		if err := rows.Scan(dests...); err != nil {
+			return err
+		}
+		if target.IsNil() {
+			target.Set(reflect.MakeMap(target.Type()))
+		}
+		for i, column := range columns {
+			value := values[i]
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			target.SetMapIndex(reflect.ValueOf(column), reflect.ValueOf(&value).Elem())
+		}
+		return nil
+
+	case target.Kind() == reflect.Struct && target.Type() != timeType && !ptr.Type().Implements(scannerType):
+		fields := sqlFieldsOf(target.Type())
+		var assigns []func()
+		for i, column := range columns {
+			field, ok := fields.byName[strings.ToLower(column)]
+			if !ok {
+				dests[i] = new(interface{})
+				continue
+			}
+			var assign func()
+			dests[i], assign = fieldScanDest(target.FieldByIndex(field.index))
+			if assign != nil {
+				assigns = append(assigns, assign)
+			}
+		}
+		if err := rows.Scan(dests...); err != nil {
+			return err
+		}
+		for _, assign := range assigns {
+			assign()
+		}
+		return nil
+
+	default:
+		// Scalars take the first column
+		dests[0] = ptr.Interface()
+		for i := 1; i < len(dests); i++ {
+			dests[i] = new(interface{})
+		}
+		return rows.Scan(dests...)
+	}
+}
+
+// fieldScanDest returns the scan destination of a struct field. NULL is scanned into plain
+// fields as their zero value by scanning into a pointer that is assigned afterwards; JSON
+// columns (maps, slices and structs) are decoded.
+func fieldScanDest(field reflect.Value) (interface{}, func()) {
+	typ := field.Type()
+	if typ.Kind() == reflect.Pointer || reflect.PointerTo(typ).Implements(scannerType) {
+		return field.Addr().Interface(), nil
+	}
+	switch typ.Kind() {
+	case reflect.Map, reflect.Array, reflect.Struct:
+		if typ != timeType {
+			return &jsonColumn{field: field}, nil
+		}
+	case reflect.Slice:
+		if typ.Elem().Kind() != reflect.Uint8 {
+			return &jsonColumn{field: field}, nil
+		}
+	}
+	holder := reflect.New(reflect.PointerTo(typ))
+	return holder.Interface(), func() {
+		if holder.Elem().IsNil() {
+			field.Set(reflect.Zero(typ))
+		} else {
+			field.Set(holder.Elem().Elem())
+		}
+	}
+}
+
+// jsonColumn decodes a JSON column into a struct field
+type jsonColumn struct {
+	field reflect.Value
+}
+
+func (j *jsonColumn) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		j.field.Set(reflect.Zero(j.field.Type()))
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot decode %T as JSON", src)
+	}
+	return json.Unmarshal(data, j.field.Addr().Interface())
+}
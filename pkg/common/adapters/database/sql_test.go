@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun/driver/sqliteshim"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type sqlTestTask struct {
+	ID       int64             `json:"id" gorm:"column:id;primaryKey"`
+	Title    string            `json:"title" gorm:"column:title"`
+	Priority int               `json:"priority" gorm:"column:priority"`
+	Note     *string           `json:"note" gorm:"column:note"`
+	Labels   map[string]string `json:"labels" gorm:"column:labels;serializer:json"`
+	Owner    *sqlTestOwner     `json:"owner"`
+}
+
+type sqlTestOwner struct {
+	Name string
+}
+
+func (sqlTestTask) TableName() string {
+	return "tasks"
+}
+
+func setupSQLAdapter(t *testing.T) *SQLAdapter {
+	sqldb, err := sql.Open(sqliteshim.ShimName, "file::memory:")
+	require.NoError(t, err)
+	sqldb.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqldb.Close() })
+
+	_, err = sqldb.Exec(`CREATE TABLE tasks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		priority INTEGER NOT NULL DEFAULT 0,
+		note TEXT,
+		labels TEXT
+	)`)
+	require.NoError(t, err)
+	return NewSQLAdapter(sqldb, "sqlite3")
+}
+
+func TestSQLAdapter_CRUD(t *testing.T) {
+	ctx := context.Background()
+	db := setupSQLAdapter(t)
+	assert.Equal(t, "sqlite", db.Dialect().Name)
+
+	for i, title := range []string{"write docs", "fix bug", "review"} {
+		task := &sqlTestTask{Title: title, Priority: i + 1, Labels: map[string]string{"team": "core"}}
+		_, err := db.NewInsert().Model(task).Returning("*").Exec(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(i+1), task.ID, "the generated key is returned into the model")
+	}
+
+	var tasks []sqlTestTask
+	err := db.NewSelect().Model(&tasks).
+		Where("priority IN (?)", []int{2, 3}).
+		Order("priority DESC").
+		Limit(1).Offset(1).
+		ScanModel(ctx)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "fix bug", tasks[0].Title)
+	assert.Nil(t, tasks[0].Note, "NULL is scanned into a nil pointer")
+	assert.Equal(t, map[string]string{"team": "core"}, tasks[0].Labels)
+
+	count, err := db.NewSelect().Model(&[]sqlTestTask{}).Where("priority > ?", 1).WhereOr("title = ?", "write docs").Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	count, err = db.NewSelect().Table("tasks").Column("labels").Distinct().Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	result, err := db.NewUpdate().Table("tasks").
+		SetMap(map[string]interface{}{"note": "urgent", "labels->team": "platform"}).
+		Where(`"id" = ?`, 2).
+		Exec(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.RowsAffected())
+
+	var task sqlTestTask
+	require.NoError(t, db.NewSelect().Model(&task).Where("id = ?", 2).ScanModel(ctx))
+	require.NotNil(t, task.Note)
+	assert.Equal(t, "urgent", *task.Note)
+	assert.Equal(t, "platform", task.Labels["team"], "the JSON column is patched")
+
+	task.Priority = 9
+	_, err = db.NewUpdate().Model(&task).Exec(ctx)
+	require.NoError(t, err)
+
+	var rows []map[string]interface{}
+	require.NoError(t, db.Query(ctx, &rows, "SELECT id, priority FROM tasks WHERE id = ?", 2))
+	require.Len(t, rows, 1)
+	assert.EqualValues(t, 9, rows[0]["priority"])
+
+	result, err = db.NewDelete().Table("tasks").Where("id = ?", 1).Exec(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.RowsAffected())
+
+	exists, err := db.NewSelect().Table("tasks").Where("id = ?", 1).Exists(ctx)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = db.NewDelete().Table("tasks").Exec(ctx)
+	assert.Error(t, err, "deletes need a WHERE condition")
+}
+
+func TestSQLAdapter_TableInsertReturning(t *testing.T) {
+	db := setupSQLAdapter(t)
+
+	result, err := db.NewInsert().Table("tasks").Value("title", "plan").Returning("*").Exec(context.Background())
+	require.NoError(t, err)
+	returning, ok := result.(common.ReturningResult)
+	require.True(t, ok)
+	assert.EqualValues(t, 0, returning.Returned()["priority"], "server-side defaults are returned")
+	id, err := result.LastInsertId()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+}
+
+func TestSQLAdapter_BulkInsert(t *testing.T) {
+	ctx := context.Background()
+	db := setupSQLAdapter(t)
+
+	tasks := []*sqlTestTask{{Title: "a"}, {Title: "b", Priority: 2}, {Title: "c"}, {ID: 10, Title: "d"}, {Title: "e"}}
+	query := db.NewInsert().Bulk(&tasks, 2).Returning("*")
+	statement, args, err := query.ToSQL()
+	require.NoError(t, err)
+	assert.Contains(t, statement, "VALUES (?, ?, ?, ?), (?, ?, ?, ?) RETURNING *", "the first chunk has two rows")
+	assert.Len(t, args, 8)
+
+	result, err := query.Exec(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), result.RowsAffected())
+	ids := make([]int64, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	assert.Equal(t, []int64{1, 2, 3, 10, 11}, ids, "generated keys are returned into the models in order")
+
+	var priority int
+	require.NoError(t, db.Query(ctx, &priority, "SELECT priority FROM tasks WHERE id = ?", 2))
+	assert.Equal(t, 2, priority)
+}
+
+func TestSQLAdapter_RunInTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := setupSQLAdapter(t)
+
+	errRollback := errors.New("rollback")
+	err := db.RunInTransaction(ctx, func(tx common.Database) error {
+		if _, err := tx.NewInsert().Model(&sqlTestTask{Title: "discarded"}).Exec(ctx); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	assert.ErrorIs(t, err, errRollback)
+
+	count, err := db.NewSelect().Table("tasks").Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestSQLAdapter_RelationsNeedAnORM(t *testing.T) {
+	db := setupSQLAdapter(t)
+
+	var tasks []sqlTestTask
+	err := db.NewSelect().Model(&tasks).Preload("Owner").ScanModel(context.Background())
+	assert.ErrorContains(t, err, "Owner")
+}
+
+func TestBindSQL(t *testing.T) {
+	query, args := bindSQL(common.LookupDialect("postgres"),
+		`SELECT * FROM t WHERE name = '?' AND id IN (?) AND status = ? AND data ? 'key'`,
+		[]interface{}{[]int{1, 2}, "active"})
+	assert.Equal(t, `SELECT * FROM t WHERE name = '?' AND id IN ($1, $2) AND status = $3 AND data ? 'key'`, query)
+	assert.Equal(t, []interface{}{1, 2, "active"}, args)
+
+	query, args = bindSQL(common.LookupDialect("sqlite"), "id IN (?) AND data = ?", []interface{}{[]string{}, []byte("x")})
+	assert.Equal(t, "id IN (NULL) AND data = ?", query)
+	assert.Equal(t, []interface{}{[]byte("x")}, args)
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{"ID": "id", "UserID": "user_id", "HTTPStatus": "http_status", "Address2Line": "address2_line"} {
+		assert.Equal(t, want, snakeCase(name))
+	}
+}
@@ -0,0 +1,155 @@
+package database
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// DefaultStatementCacheSize is the number of statements a cache of size 0 keeps
+const DefaultStatementCacheSize = 256
+
+// StatementCacheStats are the counters of a statement cache
+type StatementCacheStats struct {
+	Size      int     `json:"size"`      // Statements in the cache
+	Capacity  int     `json:"capacity"`  // Most statements the cache keeps
+	Hits      int64   `json:"hits"`      // Queries run with a cached statement
+	Misses    int64   `json:"misses"`    // Queries that prepared their statement
+	Evictions int64   `json:"evictions"` // Statements closed to make room for others
+	HitRate   float64 `json:"hit_rate"`  // Hits of all queries, 0 to 1
+}
+
+// StatementCache keeps the prepared statements of the most recently run SQL, so repeated
+// queries skip parsing and planning. The least recently used statements are evicted first and
+// closed once the queries running them release them. It is safe for concurrent use.
+type StatementCache struct {
+	db       *sql.DB
+	capacity int
+
+	mu        sync.Mutex
+	order     *list.List // Front is the most recently used
+	entries   map[string]*list.Element
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+type cachedStatement struct {
+	query   string
+	stmt    *sql.Stmt
+	users   int  // Queries that hold the statement
+	evicted bool // Removed from the cache, closed when the last user releases it
+}
+
+// NewStatementCache returns a cache of at most capacity statements of db,
+// DefaultStatementCacheSize when capacity is not positive
+func NewStatementCache(db *sql.DB, capacity int) *StatementCache {
+	if capacity <= 0 {
+		capacity = DefaultStatementCacheSize
+	}
+	return &StatementCache{db: db, capacity: capacity, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Prepare returns the prepared statement of query, preparing it on a miss. The statement stays
+// open until release is called, also when it is evicted in the meantime; call release once the
+// statement has run. Rows of a query keep their statement open until they are closed.
+func (c *StatementCache) Prepare(ctx context.Context, query string) (stmt *sql.Stmt, release func(), err error) {
+	c.mu.Lock()
+	if element, ok := c.entries[query]; ok {
+		c.hits++
+		c.order.MoveToFront(element)
+		entry := c.acquire(element)
+		c.mu.Unlock()
+		return entry.stmt, func() { c.release(entry) }, nil
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	stmt, err = c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[query]; ok {
+		// Prepared concurrently by another query
+		stmt.Close()
+		c.order.MoveToFront(element)
+		entry := c.acquire(element)
+		return entry.stmt, func() { c.release(entry) }, nil
+	}
+	entry := &cachedStatement{query: query, stmt: stmt, users: 1}
+	c.entries[query] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		c.evict(c.order.Back())
+	}
+	return stmt, func() { c.release(entry) }, nil
+}
+
+// acquire adds a user to a cached statement; c.mu must be held
+func (c *StatementCache) acquire(element *list.Element) *cachedStatement {
+	entry := element.Value.(*cachedStatement)
+	entry.users++
+	return entry
+}
+
+// release removes a user from a statement, closing it when it was evicted and was the last one
+func (c *StatementCache) release(entry *cachedStatement) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.users--
+	if entry.evicted && entry.users == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// evict removes a statement from the cache and closes it unless it is in use; c.mu must be held
+func (c *StatementCache) evict(element *list.Element) {
+	entry := c.order.Remove(element).(*cachedStatement)
+	delete(c.entries, entry.query)
+	entry.evicted = true
+	if entry.users == 0 {
+		entry.stmt.Close()
+	}
+	c.evictions++
+}
+
+// Stats returns the counters of the cache
+func (c *StatementCache) Stats() StatementCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := StatementCacheStats{
+		Size:      c.order.Len(),
+		Capacity:  c.capacity,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+	if total := c.hits + c.misses; total > 0 {
+		stats.HitRate = float64(c.hits) / float64(total)
+	}
+	return stats
+}
+
+// Close closes the cached statements and empties the cache. Statements in use are closed when
+// they are released.
+func (c *StatementCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var firstErr error
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*cachedStatement)
+		entry.evicted = true
+		if entry.users > 0 {
+			continue
+		}
+		if err := entry.stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+	return firstErr
+}
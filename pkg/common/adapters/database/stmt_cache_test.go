@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+func TestSQLAdapter_StatementCache(t *testing.T) {
+	ctx := context.Background()
+	db := setupSQLAdapter(t).WithStatementCache(2)
+
+	for i, title := range []string{"write docs", "fix bug", "review"} {
+		_, err := db.NewInsert().Model(&sqlTestTask{Title: title, Priority: i + 1}).Exec(ctx)
+		require.NoError(t, err)
+	}
+	stats := db.StatementCache().Stats()
+	assert.Equal(t, int64(1), stats.Misses, "inserts of the same columns share a statement")
+	assert.Equal(t, int64(2), stats.Hits)
+
+	for priority := 1; priority <= 3; priority++ {
+		var tasks []sqlTestTask
+		require.NoError(t, db.NewSelect().Model(&tasks).Where("priority >= ?", priority).Order("id").Scan(ctx, &tasks))
+		assert.Len(t, tasks, 4-priority, "each run binds its own arguments")
+	}
+	stats = db.StatementCache().Stats()
+	assert.Equal(t, int64(2), stats.Misses)
+	assert.Equal(t, int64(4), stats.Hits)
+	assert.InDelta(t, 4.0/6.0, stats.HitRate, 0.001)
+
+	count, err := db.NewSelect().Table("tasks").Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	stats = db.StatementCache().Stats()
+	assert.Equal(t, 2, stats.Size)
+	assert.Equal(t, 2, stats.Capacity)
+	assert.Equal(t, int64(1), stats.Evictions, "the least recently used statement is closed")
+
+	require.NoError(t, db.RunInTransaction(ctx, func(tx common.Database) error {
+		_, err := tx.Exec(ctx, "UPDATE tasks SET priority = priority + 1")
+		return err
+	}))
+	assert.Equal(t, stats, db.StatementCache().Stats(), "transactions don't use the cache")
+
+	var rows []map[string]interface{}
+	require.NoError(t, db.Query(ctx, &rows, "SELECT MAX(priority) AS top FROM tasks"))
+	assert.EqualValues(t, 4, rows[0]["top"])
+
+	require.NoError(t, db.StatementCache().Close())
+	assert.Equal(t, 0, db.StatementCache().Stats().Size)
+	_, err = db.NewSelect().Table("tasks").Count(ctx)
+	assert.NoError(t, err, "a closed cache prepares again")
+
+	_, err = db.Exec(ctx, "SELECT * FROM missing_table")
+	assert.Error(t, err, "statements that don't prepare fail the query")
+}
+
+func TestStatementCache_ConcurrentEviction(t *testing.T) {
+	ctx := context.Background()
+	db := setupSQLAdapter(t).WithStatementCache(1)
+	cache := db.StatementCache()
+
+	// Every query evicts the statement of the other while it may still be running
+	queries := []string{"SELECT COUNT(*) FROM tasks", "SELECT COUNT(*) FROM tasks WHERE priority >= 0"}
+	var wg sync.WaitGroup
+	errs := make(chan error, 400)
+	for i := 0; i < 400; i++ {
+		wg.Add(1)
+		go func(query string) {
+			defer wg.Done()
+			stmt, release, err := cache.Prepare(ctx, query)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer release()
+			var count int
+			errs <- stmt.QueryRowContext(ctx).Scan(&count)
+		}(queries[i%2])
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err, "a statement in use is never closed")
+	}
+	assert.Positive(t, cache.Stats().Evictions)
+	assert.LessOrEqual(t, cache.Stats().Size, 1)
+
+	// A statement evicted while held runs and is closed when it is released
+	held, release, err := cache.Prepare(ctx, "SELECT 1")
+	require.NoError(t, err)
+	_, releaseOther, err := cache.Prepare(ctx, "SELECT 2")
+	require.NoError(t, err)
+	releaseOther()
+	var one int
+	require.NoError(t, held.QueryRowContext(ctx).Scan(&one))
+	release()
+	assert.Error(t, held.QueryRowContext(ctx).Scan(&one), "closed after its last release")
+	require.NoError(t, cache.Close())
+}
+
+func TestSQLAdapter_StatementCacheSkipsRawSQL(t *testing.T) {
+	ctx := context.Background()
+	db := setupSQLAdapter(t).WithStatementCache(0)
+
+	_, err := db.Exec(ctx, "CREATE TABLE raw_notes (id INTEGER PRIMARY KEY); CREATE INDEX raw_notes_id ON raw_notes (id)")
+	require.NoError(t, err, "scripts of several statements run unprepared")
+	var rows []map[string]interface{}
+	require.NoError(t, db.Query(ctx, &rows, "SELECT COUNT(*) AS n FROM raw_notes"))
+	assert.Zero(t, db.StatementCache().Stats().Misses, "raw SQL isn't cached")
+
+	_, err = db.NewSelect().Table("raw_notes").Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), db.StatementCache().Stats().Misses, "built queries are")
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"reflect"
 	"strings"
 )
 
@@ -14,3 +15,39 @@ func parseTableName(fullTableName string) (schema, table string) {
 	}
 	return "", fullTableName
 }
+
+// scannedRowCount returns the number of records held by a scan destination
+// Slices report their length, while a single struct counts as one row
+func scannedRowCount(dest interface{}) int {
+	val := reflect.ValueOf(dest)
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return 0
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array:
+		return val.Len()
+	case reflect.Struct, reflect.Map:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// normalizeDialectName maps the dialect names of GORM and Bun to the names
+// known to common.LookupDialect, e.g. "pg" -> "postgres", "sqlite3" -> "sqlite"
+func normalizeDialectName(name string) string {
+	switch name = strings.ToLower(name); name {
+	case "pg", "postgresql", "pgx":
+		return "postgres"
+	case "sqlite3":
+		return "sqlite"
+	case "sqlserver":
+		return "mssql"
+	default:
+		return name
+	}
+}
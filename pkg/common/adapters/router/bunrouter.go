@@ -1,6 +1,7 @@
 package router
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/uptrace/bunrouter"
@@ -86,6 +87,16 @@ func (b *BunRouterRequest) Method() string {
 	return b.req.Method
 }
 
+// Context returns the context of the underlying bunrouter.Request
+func (b *BunRouterRequest) Context() context.Context {
+	return b.req.Context()
+}
+
+// UnderlyingRequest returns the http.Request wrapped by the bunrouter.Request
+func (b *BunRouterRequest) UnderlyingRequest() *http.Request {
+	return b.req.Request
+}
+
 func (b *BunRouterRequest) URL() string {
 	return b.req.URL.String()
 }
@@ -0,0 +1,220 @@
+package router
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// builtinEncoders are the encodings compressed without configuration, in order of preference
+var builtinEncoders = []struct {
+	name    string
+	encoder common.CompressionEncoder
+}{
+	{"gzip", func(w io.Writer, level int) (io.WriteCloser, error) {
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	}},
+	{"deflate", func(w io.Writer, level int) (io.WriteCloser, error) {
+		if level == 0 {
+			level = flate.DefaultCompression
+		}
+		return flate.NewWriter(w, level)
+	}},
+}
+
+// NegotiateEncoding returns the encoding of the response to a request with the Accept-Encoding
+// header, among the added encoders of config and the built-in gzip and deflate, or "" for an
+// uncompressed response. The highest quality wins; ties go to the added encoders first.
+func NegotiateEncoding(acceptEncoding string, config common.CompressionConfig) (string, common.CompressionEncoder) {
+	accepted := parseAcceptEncoding(acceptEncoding)
+	if len(accepted) == 0 {
+		return "", nil
+	}
+
+	added := make(map[string]common.CompressionEncoder, len(config.Encoders))
+	names := make([]string, 0, len(config.Encoders))
+	for name, encoder := range config.Encoders {
+		name = strings.ToLower(name)
+		added[name] = encoder
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		best    string
+		encoder common.CompressionEncoder
+		quality float64
+	)
+	consider := func(name string, candidate common.CompressionEncoder) {
+		q, ok := accepted[name]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > quality {
+			best, encoder, quality = name, candidate, q
+		}
+	}
+	for _, name := range names {
+		consider(name, added[name])
+	}
+	for _, builtin := range builtinEncoders {
+		if _, replaced := added[builtin.name]; !replaced {
+			consider(builtin.name, builtin.encoder)
+		}
+	}
+	return best, encoder
+}
+
+// parseAcceptEncoding returns the quality of the encodings of an Accept-Encoding header by their
+// lowercase name, e.g. "gzip;q=0.8, br" as gzip 0.8 and br 1
+func parseAcceptEncoding(header string) map[string]float64 {
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(param, "=")
+			if ok && strings.EqualFold(strings.TrimSpace(key), "q") {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		accepted[name] = quality
+	}
+	return accepted
+}
+
+// compressor holds back the start of a response until MinSize bytes were written, then sends it
+// compressed, or uncompressed when it ends smaller
+type compressor struct {
+	encoding string
+	encoder  common.CompressionEncoder
+	level    int
+	minSize  int
+
+	status  int
+	pending []byte
+	started bool
+	writer  io.WriteCloser // Set once the response is compressed
+}
+
+// Compress negotiates the encoding of the response with the Accept-Encoding header of the request
+func (h *HTTPResponseWriter) Compress(acceptEncoding string, config common.CompressionConfig) bool {
+	if h.compression != nil || h.status != 0 {
+		return false
+	}
+	// Caches keep the responses per encoding either way
+	h.resp.Header().Add("Vary", "Accept-Encoding")
+	encoding, encoder := NegotiateEncoding(acceptEncoding, config)
+	if encoder == nil {
+		return false
+	}
+	minSize := config.MinSize
+	if minSize <= 0 {
+		minSize = common.DefaultCompressionMinSize
+	}
+	h.compression = &compressor{encoding: encoding, encoder: encoder, level: config.Level, minSize: minSize}
+	return true
+}
+
+// Close ends a compressed response, sending the held back start uncompressed when it stayed
+// below the minimum size
+func (h *HTTPResponseWriter) Close() error {
+	c := h.compression
+	if c == nil {
+		return nil
+	}
+	if !c.started {
+		return h.startResponse(false)
+	}
+	if c.writer != nil {
+		writer := c.writer
+		c.writer = nil
+		return writer.Close()
+	}
+	return nil
+}
+
+// writeCompressed writes data of a compressed response
+func (h *HTTPResponseWriter) writeCompressed(data []byte) (int, error) {
+	c := h.compression
+	if c.started {
+		if c.writer != nil {
+			return c.writer.Write(data)
+		}
+		return h.resp.Write(data)
+	}
+	c.pending = append(c.pending, data...)
+	if len(c.pending) >= c.minSize {
+		if err := h.startResponse(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// startResponse writes the held back status and start of the response, compressed when compress
+// is set and the response has a compressible body
+func (h *HTTPResponseWriter) startResponse(compress bool) error {
+	c := h.compression
+	c.started = true
+	header := h.resp.Header()
+	if compress && compressible(c.status, header) {
+		writer, err := c.encoder(h.resp, c.level)
+		if err != nil {
+			return err
+		}
+		header.Set("Content-Encoding", c.encoding)
+		header.Del("Content-Length")
+		c.writer = writer
+	}
+	if c.status != 0 {
+		h.resp.WriteHeader(c.status)
+	}
+	if len(c.pending) == 0 {
+		return nil
+	}
+	pending := c.pending
+	c.pending = nil
+	var err error
+	if c.writer != nil {
+		_, err = c.writer.Write(pending)
+	} else {
+		_, err = h.resp.Write(pending)
+	}
+	return err
+}
+
+// compressible reports whether a response has a body worth compressing: not encoded yet and not
+// of an already compressed media type
+func compressible(status int, header http.Header) bool {
+	if status == http.StatusNoContent || status == http.StatusNotModified || header.Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType := strings.ToLower(header.Get("Content-Type"))
+	switch {
+	case strings.HasPrefix(mediaType, "image/") && !strings.HasPrefix(mediaType, "image/svg"),
+		strings.HasPrefix(mediaType, "video/"),
+		strings.HasPrefix(mediaType, "audio/"),
+		strings.HasPrefix(mediaType, "application/zip"),
+		strings.HasPrefix(mediaType, "application/gzip"),
+		strings.HasPrefix(mediaType, "application/pdf"),
+		strings.HasPrefix(mediaType, "application/vnd.openxmlformats"):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package router
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestNegotiateEncoding(t *testing.T) {
+	identity := func(w io.Writer, level int) (io.WriteCloser, error) { return nopWriteCloser{w}, nil }
+	withBrotli := common.CompressionConfig{Encoders: map[string]common.CompressionEncoder{"br": identity}}
+
+	tests := []struct {
+		name   string
+		accept string
+		config common.CompressionConfig
+		want   string
+	}{
+		{"no header", "", common.CompressionConfig{}, ""},
+		{"gzip", "gzip", common.CompressionConfig{}, "gzip"},
+		{"gzip preferred on ties", "deflate, gzip", common.CompressionConfig{}, "gzip"},
+		{"quality", "gzip;q=0.5, deflate", common.CompressionConfig{}, "deflate"},
+		{"refused", "gzip;q=0", common.CompressionConfig{}, ""},
+		{"wildcard", "*", common.CompressionConfig{}, "gzip"},
+		{"unknown only", "br", common.CompressionConfig{}, ""},
+		{"added encoder preferred", "gzip, br", withBrotli, "br"},
+		{"added encoder by quality", "gzip, br;q=0.1", withBrotli, "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoding, _ := NegotiateEncoding(tt.accept, tt.config)
+			assert.Equal(t, tt.want, encoding)
+		})
+	}
+}
+
+func TestHTTPResponseWriterCompress(t *testing.T) {
+	large := strings.Repeat(`{"name":"record"},`, 200)
+
+	t.Run("large response is compressed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		w := NewHTTPResponseWriter(rec)
+		require.True(t, w.Compress("gzip", common.CompressionConfig{}))
+		w.SetHeader("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, err := w.Write([]byte(large[:100]))
+		require.NoError(t, err)
+		_, err = w.Write([]byte(large[100:]))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+		reader, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, large, string(body))
+	})
+
+	t.Run("small response is sent uncompressed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		w := NewHTTPResponseWriter(rec)
+		require.True(t, w.Compress("gzip", common.CompressionConfig{MinSize: 4096}))
+		w.WriteHeader(http.StatusAccepted)
+		_, err := w.Write([]byte(large))
+		require.NoError(t, err)
+		assert.Equal(t, 0, rec.Body.Len(), "the response is held back until it is ended")
+		require.NoError(t, w.Close())
+
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, large, rec.Body.String())
+	})
+
+	t.Run("compressed media types are sent as they are", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		w := NewHTTPResponseWriter(rec)
+		require.True(t, w.Compress("gzip", common.CompressionConfig{MinSize: 1}))
+		w.SetHeader("Content-Type", "image/png")
+		_, err := w.Write([]byte(large))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, large, rec.Body.String())
+	})
+
+	t.Run("unaccepted encodings", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		w := NewHTTPResponseWriter(rec)
+		assert.False(t, w.Compress("identity", common.CompressionConfig{}))
+		require.NoError(t, w.WriteJSON(map[string]string{"name": "record"}))
+		require.NoError(t, w.Close())
+
+		assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+		assert.Equal(t, "{\"name\":\"record\"}\n", rec.Body.String())
+	})
+
+	t.Run("added encoder", func(t *testing.T) {
+		var used bool
+		config := common.CompressionConfig{MinSize: 1, Encoders: map[string]common.CompressionEncoder{
+			"br": func(w io.Writer, level int) (io.WriteCloser, error) {
+				used = true
+				return nopWriteCloser{w}, nil
+			},
+		}}
+		rec := httptest.NewRecorder()
+		w := NewHTTPResponseWriter(rec)
+		require.True(t, w.Compress("gzip, br", config))
+		_, err := w.Write([]byte("payload"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		assert.True(t, used)
+		assert.Equal(t, "br", rec.Header().Get("Content-Encoding"))
+		assert.True(t, bytes.Equal([]byte("payload"), rec.Body.Bytes()))
+	})
+}
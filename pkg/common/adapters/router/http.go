@@ -0,0 +1,140 @@
+package router
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// HandleFunc registers a handler for a method and a path pattern on a router framework
+type HandleFunc func(method, pattern string, handler http.HandlerFunc)
+
+// PathParamFunc returns a path parameter of a request
+type PathParamFunc func(r *http.Request, name string) string
+
+// ChiRouter is the part of chi.Router the chi adapter registers routes with
+type ChiRouter interface {
+	MethodFunc(method, pattern string, handler http.HandlerFunc)
+}
+
+// braceParam matches the {name} path parameters of route patterns
+var braceParam = regexp.MustCompile(`\{([^/{}]+)\}`)
+
+// HTTPRouterAdapter mounts handlers on routers built on net/http handlers: ServeMux, chi,
+// echo, gin and others. ResolveSpec doesn't depend on these frameworks, so the adapter
+// registers routes with a HandleFunc. Route patterns are written with {name} parameters and
+// converted to :name for echo and gin.
+type HTTPRouterAdapter struct {
+	handle     HandleFunc
+	pathParam  PathParamFunc
+	colonStyle bool
+}
+
+// NewChiAdapter creates an adapter for a chi router, reading path parameters with chi.URLParam:
+//
+//	restheadspec.SetupHTTPRoutes(router.NewChiAdapter(r, chi.URLParam), handler)
+func NewChiAdapter(r ChiRouter, urlParam PathParamFunc) *HTTPRouterAdapter {
+	return &HTTPRouterAdapter{handle: r.MethodFunc, pathParam: urlParam}
+}
+
+// NewServeMuxAdapter creates an adapter for a net/http ServeMux using Go 1.22 method and
+// wildcard patterns. Routes are registered below prefix.
+func NewServeMuxAdapter(mux *http.ServeMux, prefix string) *HTTPRouterAdapter {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return &HTTPRouterAdapter{handle: func(method, pattern string, handler http.HandlerFunc) {
+		mux.HandleFunc(method+" "+prefix+pattern, handler)
+	}}
+}
+
+// NewEchoAdapter creates an adapter for echo. handle passes the echo path parameters to
+// the request with WithPathParams:
+//
+//	router.NewEchoAdapter(func(method, pattern string, h http.HandlerFunc) {
+//		e.Add(method, pattern, func(c echo.Context) error {
+//			h(c.Response(), router.WithPathParams(c.Request(), c.ParamNames(), c.ParamValues()))
+//			return nil
+//		})
+//	})
+func NewEchoAdapter(handle HandleFunc) *HTTPRouterAdapter {
+	return &HTTPRouterAdapter{handle: handle, colonStyle: true}
+}
+
+// NewGinAdapter creates an adapter for gin. handle passes the gin path parameters to the
+// request with SetPathValue:
+//
+//	router.NewGinAdapter(func(method, pattern string, h http.HandlerFunc) {
+//		engine.Handle(method, pattern, func(c *gin.Context) {
+//			for _, p := range c.Params {
+//				c.Request.SetPathValue(p.Key, p.Value)
+//			}
+//			h(c.Writer, c.Request)
+//		})
+//	})
+func NewGinAdapter(handle HandleFunc) *HTTPRouterAdapter {
+	return &HTTPRouterAdapter{handle: handle, colonStyle: true}
+}
+
+// WithPathParams sets path parameters of a request by name, to be read by the adapters
+func WithPathParams(r *http.Request, names, values []string) *http.Request {
+	for i, name := range names {
+		if i < len(values) {
+			r.SetPathValue(name, values[i])
+		}
+	}
+	return r
+}
+
+// Handle registers an http.HandlerFunc for a method and a {name} pattern. The handler gets
+// the path parameters of the pattern.
+func (a *HTTPRouterAdapter) Handle(method, pattern string, handler func(http.ResponseWriter, *http.Request, map[string]string)) {
+	names := braceParam.FindAllStringSubmatch(pattern, -1)
+	if a.colonStyle {
+		pattern = braceParam.ReplaceAllString(pattern, ":$1")
+	}
+	a.handle(method, pattern, func(w http.ResponseWriter, r *http.Request) {
+		params := make(map[string]string, len(names))
+		for _, name := range names {
+			params[name[1]] = a.param(r, name[1])
+		}
+		handler(w, r, params)
+	})
+}
+
+func (a *HTTPRouterAdapter) param(r *http.Request, name string) string {
+	if a.pathParam != nil {
+		return a.pathParam(r, name)
+	}
+	return r.PathValue(name)
+}
+
+func (a *HTTPRouterAdapter) HandleFunc(pattern string, handler common.HTTPHandlerFunc) common.RouteRegistration {
+	return &HTTPRouteRegistration{adapter: a, pattern: pattern, handler: handler}
+}
+
+func (a *HTTPRouterAdapter) ServeHTTP(w common.ResponseWriter, r common.Request) {
+	// Requests are served by the underlying router
+	panic("ServeHTTP not implemented - serve requests with the underlying router")
+}
+
+// HTTPRouteRegistration implements RouteRegistration for HTTPRouterAdapter
+type HTTPRouteRegistration struct {
+	adapter *HTTPRouterAdapter
+	pattern string
+	handler common.HTTPHandlerFunc
+}
+
+func (h *HTTPRouteRegistration) Methods(methods ...string) common.RouteRegistration {
+	for _, method := range methods {
+		h.adapter.Handle(method, h.pattern, func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+			h.handler(NewHTTPResponseWriter(w), &HTTPRequest{req: r, vars: params})
+		})
+	}
+	return h
+}
+
+func (h *HTTPRouteRegistration) PathPrefix(prefix string) common.RouteRegistration {
+	h.pattern = prefix + h.pattern
+	return h
+}
@@ -1,7 +1,7 @@
 package router
 
 import (
-	"encoding/json"
+	"context"
 	"io"
 	"net/http"
 
@@ -85,6 +85,16 @@ func (h *HTTPRequest) Method() string {
 	return h.req.Method
 }
 
+// Context returns the context of the underlying http.Request
+func (h *HTTPRequest) Context() context.Context {
+	return h.req.Context()
+}
+
+// UnderlyingRequest returns the wrapped http.Request
+func (h *HTTPRequest) UnderlyingRequest() *http.Request {
+	return h.req
+}
+
 func (h *HTTPRequest) URL() string {
 	return h.req.URL.String()
 }
@@ -139,9 +149,10 @@ func (h *HTTPRequest) AllHeaders() map[string]string {
 
 // HTTPResponseWriter adapts our ResponseWriter interface to standard http.ResponseWriter
 type HTTPResponseWriter struct {
-	resp   http.ResponseWriter
-	w      common.ResponseWriter //nolint:unused
-	status int
+	resp        http.ResponseWriter
+	w           common.ResponseWriter //nolint:unused
+	status      int
+	compression *compressor // Set when the response is compressed, see Compress
 }
 
 func NewHTTPResponseWriter(w http.ResponseWriter) *HTTPResponseWriter {
@@ -154,16 +165,24 @@ func (h *HTTPResponseWriter) SetHeader(key, value string) {
 
 func (h *HTTPResponseWriter) WriteHeader(statusCode int) {
 	h.status = statusCode
+	if h.compression != nil && !h.compression.started {
+		h.compression.status = statusCode
+		return
+	}
 	h.resp.WriteHeader(statusCode)
 }
 
 func (h *HTTPResponseWriter) Write(data []byte) (int, error) {
+	if h.compression != nil {
+		return h.writeCompressed(data)
+	}
 	return h.resp.Write(data)
 }
 
+// WriteJSON writes data as JSON, streaming large result sets row by row, see common.EncodeJSON
 func (h *HTTPResponseWriter) WriteJSON(data interface{}) error {
 	h.SetHeader("Content-Type", "application/json")
-	return json.NewEncoder(h.resp).Encode(data)
+	return common.EncodeJSON(h, data)
 }
 
 // StandardMuxAdapter creates routes compatible with standard http.HandlerFunc
@@ -0,0 +1,15 @@
+package router
+
+import (
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// RegisterPluginRoutes registers the routes of the plugins of a handler on r. The route setup of
+// the handlers calls it before registering their generic /{schema}/{entity} patterns, so those
+// don't shadow the plugin routes. A failure is logged and the generic routes still work.
+func RegisterPluginRoutes(r common.Router, plugins *common.PluginManager) {
+	if err := plugins.RegisterRoutes(r); err != nil {
+		logger.Error("Failed to register plugin routes: %v", err)
+	}
+}
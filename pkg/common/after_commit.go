@@ -0,0 +1,88 @@
+package common
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+type commitQueueKey struct{}
+
+// commitQueue holds the work queued during a transaction until it commits
+type commitQueue struct {
+	tx   Database
+	mu   sync.Mutex
+	work []func(context.Context)
+}
+
+func (q *commitQueue) add(work ...func(context.Context)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.work = append(q.work, work...)
+}
+
+// AfterCommit runs work once the transaction of ctx committed. Inside RunInTransaction the
+// work is queued and dropped when the transaction rolls back; outside a transaction the write
+// is committed already and the work starts right away. Work runs in the background, in the
+// order it was queued, with a context that is not canceled with the request.
+func AfterCommit(ctx context.Context, work func(context.Context)) {
+	if queue, ok := ctx.Value(commitQueueKey{}).(*commitQueue); ok {
+		queue.add(work)
+		return
+	}
+	runAfterCommit(ctx, []func(context.Context){work})
+}
+
+// RunInTransaction runs fn in a transaction of db, passing it the context of the transaction.
+// Work queued with AfterCommit in that context runs after the transaction committed. Nested
+// calls hand their work to the outer transaction.
+func RunInTransaction(ctx context.Context, db Database, fn func(ctx context.Context, tx Database) error) error {
+	queue := &commitQueue{}
+	txCtx := context.WithValue(ctx, commitQueueKey{}, queue)
+	if err := db.RunInTransaction(txCtx, func(tx Database) error {
+		queue.tx = tx
+		return fn(txCtx, tx)
+	}); err != nil {
+		if len(queue.work) > 0 {
+			logger.Debug("Dropped %d after commit task(s) of a rolled back transaction", len(queue.work))
+		}
+		return err
+	}
+	if len(queue.work) == 0 {
+		return nil
+	}
+	if outer, ok := ctx.Value(commitQueueKey{}).(*commitQueue); ok {
+		outer.add(queue.work...)
+		return nil
+	}
+	runAfterCommit(ctx, queue.work)
+	return nil
+}
+
+// Transaction returns the transaction of ctx started with RunInTransaction, e.g. for hooks
+// that write in the transaction of a request with HandlerConfig.WriteTransactions
+func Transaction(ctx context.Context) (Database, bool) {
+	queue, ok := ctx.Value(commitQueueKey{}).(*commitQueue)
+	if !ok || queue.tx == nil {
+		return nil, false
+	}
+	return queue.tx, true
+}
+
+// runAfterCommit runs the work of a committed transaction in the background
+func runAfterCommit(ctx context.Context, work []func(context.Context)) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		for _, run := range work {
+			func() {
+				defer func() {
+					if err := recover(); err != nil {
+						logger.ErrorContext(ctx, "Panic in after commit task: %v", err)
+					}
+				}()
+				run(ctx)
+			}()
+		}
+	}()
+}
@@ -0,0 +1,162 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AggregateHeader asks for aggregates of columns over every row a list read matches, before
+// pagination, e.g. "sum(amount),avg(amount),max(created_at)"
+const AggregateHeader = "X-Aggregate"
+
+// MaxAggregates limits the aggregates of a read
+const MaxAggregates = 32
+
+// aggregateFunctions maps the aggregate functions of reads to their SQL
+var aggregateFunctions = map[string]string{"sum": "SUM", "avg": "AVG", "min": "MIN", "max": "MAX"}
+
+// AggregateOption is an aggregate function of a column: sum, avg, min or max
+type AggregateOption struct {
+	Function string `json:"function"`
+	Column   string `json:"column"`
+}
+
+// callPattern matches a function of a column, e.g. sum(amount)
+var callPattern = regexp.MustCompile(`^([A-Za-z]+)\s*\(\s*([^()]*?)\s*\)$`)
+
+// ParseAggregates parses a comma separated list of aggregates like "sum(amount)". Entries
+// without a function are kept with an empty function, so ValidateAggregates rejects them.
+func ParseAggregates(value string) []AggregateOption {
+	aggregates := make([]AggregateOption, 0)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if match := callPattern.FindStringSubmatch(entry); match != nil {
+			aggregates = append(aggregates, AggregateOption{Function: match[1], Column: match[2]})
+			continue
+		}
+		aggregates = append(aggregates, AggregateOption{Column: entry})
+	}
+	return aggregates
+}
+
+// ValidateAggregates checks the functions of aggregates and that their columns are among the
+// columns a read selects. It returns the aggregates with lowercase functions and the column
+// names as given in columns, without duplicates.
+func ValidateAggregates(aggregates []AggregateOption, columns []string) ([]AggregateOption, error) {
+	if len(aggregates) > MaxAggregates {
+		return nil, fmt.Errorf("a read is limited to %d aggregates", MaxAggregates)
+	}
+	validated := make([]AggregateOption, 0, len(aggregates))
+	seen := make(map[AggregateOption]bool, len(aggregates))
+	for _, aggregate := range aggregates {
+		function := strings.ToLower(strings.TrimSpace(aggregate.Function))
+		if _, ok := aggregateFunctions[function]; !ok {
+			if function == "" {
+				return nil, fmt.Errorf("invalid aggregate %q: use sum, avg, min or max of a column, e.g. sum(amount)", aggregate.Column)
+			}
+			return nil, fmt.Errorf("unsupported aggregate function %q: use sum, avg, min or max", aggregate.Function)
+		}
+		column := matchColumn(columns, aggregate.Column)
+		if column == "" {
+			return nil, fmt.Errorf("invalid aggregate column %q: the column is not selected by the read", aggregate.Column)
+		}
+		option := AggregateOption{Function: function, Column: column}
+		if !seen[option] {
+			seen[option] = true
+			validated = append(validated, option)
+		}
+	}
+	return validated, nil
+}
+
+// Aggregate computes aggregates over the rows of query, which must select their columns and
+// should not be paginated. The result maps each column to its aggregates by function; sums and
+// averages are numbers, minimums and maximums keep the type of the column.
+func Aggregate(ctx context.Context, db Database, query SelectQuery, aggregates []AggregateOption) (map[string]map[string]interface{}, error) {
+	if len(aggregates) == 0 {
+		return nil, nil
+	}
+	statement, params, err := query.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	expressions := make([]string, 0, len(aggregates))
+	for i, aggregate := range aggregates {
+		expression, err := aggregateExpression(aggregate, "aggregated."+aggregate.Column, i)
+		if err != nil {
+			return nil, err
+		}
+		expressions = append(expressions, expression)
+	}
+	rows := []map[string]interface{}{}
+	sql := fmt.Sprintf("SELECT %s FROM (%s) AS aggregated", strings.Join(expressions, ", "), statement)
+	if err := db.Query(ctx, &rows, sql, params...); err != nil {
+		return nil, fmt.Errorf("aggregate failed: %w", err)
+	}
+	row := map[string]interface{}{}
+	if len(rows) > 0 {
+		row = rows[0]
+	}
+	return aggregateResults(aggregates, row), nil
+}
+
+// matchColumn returns the column of columns named name, ignoring case, or ""
+func matchColumn(columns []string, name string) string {
+	name = strings.TrimSpace(name)
+	for _, candidate := range columns {
+		if strings.EqualFold(candidate, name) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// aggregateExpression renders the i-th aggregate of a query over column
+func aggregateExpression(aggregate AggregateOption, column string, i int) (string, error) {
+	function, ok := aggregateFunctions[aggregate.Function]
+	if !ok {
+		return "", fmt.Errorf("unsupported aggregate function %q", aggregate.Function)
+	}
+	return fmt.Sprintf("%s(%s) AS aggregate_%d", function, column, i), nil
+}
+
+// aggregateResults returns the aggregates of a row by column and function
+func aggregateResults(aggregates []AggregateOption, row map[string]interface{}) map[string]map[string]interface{} {
+	result := make(map[string]map[string]interface{}, len(aggregates))
+	for i, aggregate := range aggregates {
+		value := row[fmt.Sprintf("aggregate_%d", i)]
+		if aggregate.Function == "sum" || aggregate.Function == "avg" {
+			value = aggregateNumber(value)
+		} else if raw, ok := value.([]byte); ok {
+			value = string(raw)
+		}
+		if result[aggregate.Column] == nil {
+			result[aggregate.Column] = make(map[string]interface{})
+		}
+		result[aggregate.Column][aggregate.Function] = value
+	}
+	return result
+}
+
+// aggregateNumber returns a numeric value drivers scan as text, e.g. a PostgreSQL numeric,
+// as a JSON number without losing precision
+func aggregateNumber(value interface{}) interface{} {
+	text, ok := value.(string)
+	if raw, isBytes := value.([]byte); isBytes {
+		text, ok = string(raw), true
+	}
+	if !ok {
+		return value
+	}
+	if _, err := strconv.ParseFloat(text, 64); err != nil {
+		return text
+	}
+	return json.Number(text)
+}
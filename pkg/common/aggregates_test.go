@@ -0,0 +1,45 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAggregates(t *testing.T) {
+	assert.Equal(t, []AggregateOption{
+		{Function: "sum", Column: "amount"},
+		{Function: "MAX", Column: "created_at"},
+		{Column: "amount"},
+	}, ParseAggregates(" sum(amount), MAX( created_at ),,amount"))
+	assert.Empty(t, ParseAggregates(""))
+}
+
+func TestValidateAggregates(t *testing.T) {
+	columns := []string{"id", "Amount", "created_at"}
+	aggregates, err := ValidateAggregates(ParseAggregates("SUM(amount),avg(amount),sum(Amount),min(created_at)"), columns)
+	require.NoError(t, err)
+	assert.Equal(t, []AggregateOption{
+		{Function: "sum", Column: "Amount"},
+		{Function: "avg", Column: "Amount"},
+		{Function: "min", Column: "created_at"},
+	}, aggregates, "functions are lowercased and duplicates dropped")
+
+	for _, invalid := range []string{"median(amount)", "amount", "sum(missing)", "sum(amount) OR 1=1", "sum(amount; DROP TABLE x)"} {
+		_, err := ValidateAggregates(ParseAggregates(invalid), columns)
+		assert.Error(t, err, invalid)
+	}
+
+	many := make([]AggregateOption, MaxAggregates+1)
+	_, err = ValidateAggregates(many, columns)
+	assert.Error(t, err)
+}
+
+func TestAggregateNumber(t *testing.T) {
+	assert.Equal(t, json.Number("12.50"), aggregateNumber([]byte("12.50")))
+	assert.Equal(t, json.Number("3"), aggregateNumber("3"))
+	assert.Equal(t, int64(3), aggregateNumber(int64(3)))
+	assert.Nil(t, aggregateNumber(nil))
+}
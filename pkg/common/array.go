@@ -0,0 +1,153 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// IsArrayOperator reports whether a filter operator is one of the PostgreSQL array operators
+// contains (@>), contained_by (<@), overlaps (&&) and any_eq (= ANY)
+func IsArrayOperator(operator string) bool {
+	switch strings.ToLower(operator) {
+	case "contains", "contained_by", "overlaps", "any_eq":
+		return true
+	}
+	return false
+}
+
+// CoerceArrayFilterValue converts the value of an array filter to a list of elements of the
+// given kind. The value can be a list, a comma-separated string or a single value; strings are
+// parsed as numbers or booleans for numeric and boolean elements. reflect.Invalid keeps the
+// elements as they are.
+func CoerceArrayFilterValue(value interface{}, elemKind reflect.Kind) ([]interface{}, error) {
+	var items []interface{}
+	switch v := value.(type) {
+	case nil:
+		return nil, fmt.Errorf("array filter needs a value")
+	case string:
+		for _, item := range strings.Split(v, ",") {
+			items = append(items, strings.TrimSpace(item))
+		}
+	default:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			for i := 0; i < rv.Len(); i++ {
+				items = append(items, rv.Index(i).Interface())
+			}
+		} else {
+			items = []interface{}{value}
+		}
+	}
+
+	elements := make([]interface{}, len(items))
+	for i, item := range items {
+		element, err := coerceArrayElement(item, elemKind)
+		if err != nil {
+			return nil, err
+		}
+		elements[i] = element
+	}
+	return elements, nil
+}
+
+func coerceArrayElement(item interface{}, elemKind reflect.Kind) (interface{}, error) {
+	switch {
+	case item == nil || elemKind == reflect.Invalid:
+		return item, nil
+	case elemKind == reflect.String:
+		if s, ok := item.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprint(item), nil
+	case elemKind == reflect.Bool:
+		switch v := item.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a boolean", v)
+			}
+			return b, nil
+		}
+	case elemKind >= reflect.Int && elemKind <= reflect.Uint64:
+		switch v := item.(type) {
+		case float64:
+			if v == float64(int64(v)) {
+				return int64(v), nil
+			}
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			return v, nil
+		case string:
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an integer", v)
+			}
+			return n, nil
+		}
+	case elemKind == reflect.Float32 || elemKind == reflect.Float64:
+		switch v := item.(type) {
+		case float32, float64, int, int64:
+			return v, nil
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a number", v)
+			}
+			return f, nil
+		}
+	default:
+		return item, nil
+	}
+	return nil, fmt.Errorf("%v is not a valid %s element", item, elemKind)
+}
+
+// ArrayFilterCondition renders a PostgreSQL array filter on column:
+//   - contains: the column holds all values (column @> values)
+//   - contained_by: all elements of the column are values (column <@ values)
+//   - overlaps: the column holds any of the values (column && values)
+//   - any_eq: the column holds the single value (value = ANY(column))
+//
+// The values are passed as an array literal, whose type PostgreSQL takes from the column,
+// so text[], int[], uuid[] and other array columns work alike.
+func ArrayFilterCondition(operator, column string, value interface{}) (string, []interface{}, error) {
+	elements, err := CoerceArrayFilterValue(value, reflect.Invalid)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s on %s: %w", operator, column, err)
+	}
+
+	switch strings.ToLower(operator) {
+	case "contains":
+		return fmt.Sprintf("%s @> ?", column), []interface{}{PostgresArrayLiteral(elements)}, nil
+	case "contained_by":
+		return fmt.Sprintf("%s <@ ?", column), []interface{}{PostgresArrayLiteral(elements)}, nil
+	case "overlaps":
+		return fmt.Sprintf("%s && ?", column), []interface{}{PostgresArrayLiteral(elements)}, nil
+	case "any_eq":
+		if len(elements) != 1 {
+			return "", nil, fmt.Errorf("any_eq on %s needs a single value, got %d", column, len(elements))
+		}
+		return fmt.Sprintf("? = ANY(%s)", column), elements, nil
+	default:
+		return "", nil, fmt.Errorf("unknown array operator %q", operator)
+	}
+}
+
+// PostgresArrayLiteral formats elements as a PostgreSQL array literal, e.g. {"a","b"} or {1,2}
+func PostgresArrayLiteral(elements []interface{}) string {
+	parts := make([]string, len(elements))
+	for i, element := range elements {
+		switch v := element.(type) {
+		case nil:
+			parts[i] = "NULL"
+		case string:
+			v = strings.ReplaceAll(v, `\`, `\\`)
+			parts[i] = `"` + strings.ReplaceAll(v, `"`, `\"`) + `"`
+		default:
+			parts[i] = fmt.Sprint(v)
+		}
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCoerceArrayFilterValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		kind     reflect.Kind
+		expected []interface{}
+	}{
+		{"comma-separated integers", "1, 2", reflect.Int64, []interface{}{int64(1), int64(2)}},
+		{"JSON numbers as integers", []interface{}{float64(3)}, reflect.Int, []interface{}{int64(3)}},
+		{"numbers as text", []interface{}{float64(3), "a"}, reflect.String, []interface{}{"3", "a"}},
+		{"single value", "true", reflect.Bool, []interface{}{true}},
+		{"floats", []string{"1.5"}, reflect.Float64, []interface{}{1.5}},
+		{"unknown element type", []interface{}{"a", float64(1)}, reflect.Invalid, []interface{}{"a", float64(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CoerceArrayFilterValue(tt.value, tt.kind)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %#v, want %#v", got, tt.expected)
+			}
+		})
+	}
+
+	for name, tc := range map[string]struct {
+		value interface{}
+		kind  reflect.Kind
+	}{
+		"nil":              {nil, reflect.String},
+		"text for integer": {"1,x", reflect.Int64},
+		"fraction":         {[]interface{}{1.5}, reflect.Int},
+		"text for boolean": {"maybe", reflect.Bool},
+	} {
+		if _, err := CoerceArrayFilterValue(tc.value, tc.kind); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestArrayFilterCondition(t *testing.T) {
+	tests := []struct {
+		operator string
+		value    interface{}
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{"contains", []interface{}{"a", `b"c`}, "tags @> ?", []interface{}{`{"a","b\"c"}`}},
+		{"contained_by", "a,b", "tags <@ ?", []interface{}{`{"a","b"}`}},
+		{"overlaps", []interface{}{int64(1), nil}, "tags && ?", []interface{}{"{1,NULL}"}},
+		{"any_eq", "a", "? = ANY(tags)", []interface{}{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.operator, func(t *testing.T) {
+			sql, args, err := ArrayFilterCondition(tt.operator, "tags", tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sql != tt.wantSQL || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("got %s %v, want %s %v", sql, args, tt.wantSQL, tt.wantArgs)
+			}
+		})
+	}
+
+	if _, _, err := ArrayFilterCondition("any_eq", "tags", "a,b"); err == nil {
+		t.Error("expected an error for any_eq with several values")
+	}
+	if _, _, err := ArrayFilterCondition("contains", "tags", nil); err == nil {
+		t.Error("expected an error without a value")
+	}
+}
@@ -0,0 +1,116 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// Audit column kinds, also the values of the audit option of a resolvespec tag, e.g.
+// `resolvespec:"audit:created_by"` on a field named Author
+const (
+	AuditCreatedAt = "created_at"
+	AuditUpdatedAt = "updated_at"
+	AuditCreatedBy = "created_by"
+	AuditUpdatedBy = "updated_by"
+)
+
+// auditFieldNames detects audit columns by the name of their field
+var auditFieldNames = map[string]string{
+	"CreatedAt": AuditCreatedAt,
+	"UpdatedAt": AuditUpdatedAt,
+	"CreatedBy": AuditCreatedBy,
+	"UpdatedBy": AuditUpdatedBy,
+}
+
+// AuditColumns returns the audit columns of a model by kind. Columns are detected by the audit
+// option of a resolvespec tag, by the field names CreatedAt, UpdatedAt, CreatedBy and UpdatedBy,
+// or by the column names created_at, updated_at, created_by and updated_by.
+func AuditColumns(model interface{}) map[string]string {
+	columns := make(map[string]string)
+	info := reflection.Describe(model)
+	if info == nil {
+		return columns
+	}
+
+	for _, field := range info.Fields {
+		if !field.Exported {
+			continue
+		}
+		column := field.Column
+		kind := auditFieldNames[field.Name]
+		switch column {
+		case AuditCreatedAt, AuditUpdatedAt, AuditCreatedBy, AuditUpdatedBy:
+			kind = column
+		}
+		for _, option := range strings.Split(info.Type.FieldByIndex(field.Index).Tag.Get("resolvespec"), ";") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(option), "audit:"); ok {
+				kind = value
+			}
+		}
+		if kind != "" {
+			if _, exists := columns[kind]; !exists {
+				columns[kind] = column
+			}
+		}
+	}
+	return columns
+}
+
+// StampAudit sets the audit columns of model in the records of a create or update payload, a
+// record or a list of records, when AuditColumns is enabled. Creates get all four columns,
+// updates the updated columns; values sent by the client are replaced, and the created columns
+// are removed from updates. The user columns are set with the user of AuditUser.
+func (c HandlerConfig) StampAudit(ctx context.Context, model, data interface{}, create bool) {
+	if !c.AuditColumns {
+		return
+	}
+	columns := AuditColumns(model)
+	if len(columns) == 0 {
+		return
+	}
+
+	now := time.Now()
+	user, hasUser := "", false
+	if c.AuditUser != nil {
+		user, hasUser = c.AuditUser(ctx)
+	}
+
+	stamp := func(record map[string]interface{}) {
+		set := func(kind string, value interface{}) {
+			if column, ok := columns[kind]; ok {
+				record[column] = value
+			}
+		}
+		if create {
+			set(AuditCreatedAt, now)
+			if hasUser {
+				set(AuditCreatedBy, user)
+			}
+		} else {
+			delete(record, columns[AuditCreatedAt])
+			delete(record, columns[AuditCreatedBy])
+		}
+		set(AuditUpdatedAt, now)
+		if hasUser {
+			set(AuditUpdatedBy, user)
+		}
+	}
+
+	switch v := data.(type) {
+	case map[string]interface{}:
+		stamp(v)
+	case []map[string]interface{}:
+		for _, record := range v {
+			stamp(record)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if record, ok := item.(map[string]interface{}); ok {
+				stamp(record)
+			}
+		}
+	}
+}
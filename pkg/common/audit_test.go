@@ -0,0 +1,55 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type auditedOrder struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Modified  time.Time `json:"modified" resolvespec:"audit:updated_at"`
+	Author    string    `json:"author" resolvespec:"audit:created_by"`
+	UpdatedBy string    `json:"editor" bun:"editor"`
+}
+
+func TestAuditColumns(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		AuditCreatedAt: "created_at",
+		AuditUpdatedAt: "modified",
+		AuditCreatedBy: "author",
+		AuditUpdatedBy: "editor",
+	}, AuditColumns(auditedOrder{}))
+	assert.Empty(t, AuditColumns(struct{ ID int64 }{}))
+}
+
+func TestStampAudit(t *testing.T) {
+	config := HandlerConfig{AuditColumns: true, AuditUser: func(ctx context.Context) (string, bool) { return "42", true }}
+	ctx := context.Background()
+
+	created := map[string]interface{}{"id": 1, "author": "mallory"}
+	config.StampAudit(ctx, auditedOrder{}, []interface{}{created}, true)
+	assert.Equal(t, "42", created["author"], "client values are replaced")
+	assert.Equal(t, "42", created["editor"])
+	assert.IsType(t, time.Time{}, created["created_at"])
+	assert.Equal(t, created["created_at"], created["modified"])
+
+	updated := map[string]interface{}{"id": 1, "created_at": "2020-01-01", "author": "mallory"}
+	config.StampAudit(ctx, auditedOrder{}, updated, false)
+	assert.NotContains(t, updated, "created_at")
+	assert.NotContains(t, updated, "author")
+	assert.Equal(t, "42", updated["editor"])
+	assert.IsType(t, time.Time{}, updated["modified"])
+
+	anonymous := map[string]interface{}{"id": 1}
+	HandlerConfig{AuditColumns: true}.StampAudit(ctx, auditedOrder{}, anonymous, true)
+	assert.NotContains(t, anonymous, "author", "no user without AuditUser")
+	assert.Contains(t, anonymous, "created_at")
+
+	disabled := map[string]interface{}{"id": 1}
+	HandlerConfig{}.StampAudit(ctx, auditedOrder{}, disabled, true)
+	assert.Equal(t, map[string]interface{}{"id": 1}, disabled)
+}
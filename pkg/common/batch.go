@@ -0,0 +1,245 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// MaxBatchSize limits the number of items in a single batch request
+const MaxBatchSize = 1000
+
+// Batch actions
+const (
+	BatchActionCreate = "create"
+	BatchActionUpdate = "update"
+	BatchActionDelete = "delete"
+)
+
+// BatchItem is a single create, update or delete in a batch request
+type BatchItem struct {
+	Action string                 `json:"action"`
+	ID     interface{}            `json:"id,omitempty"` // Primary key for update/delete, may also be given in Data
+	Data   map[string]interface{} `json:"data"`
+}
+
+// RecordData returns a copy of the item data with the primary key set from ID, if given
+func (b BatchItem) RecordData(pkName string) map[string]interface{} {
+	data := make(map[string]interface{}, len(b.Data)+1)
+	for key, value := range b.Data {
+		data[key] = value
+	}
+	if b.ID != nil {
+		data[pkName] = b.ID
+	}
+	return data
+}
+
+// BatchRequest is a list of items executed in order. Atomic batches (the default)
+// run in one transaction and roll back entirely when an item fails; otherwise each
+// item runs in its own transaction and failures are reported per item.
+type BatchRequest struct {
+	Atomic *bool       `json:"atomic"`
+	Items  []BatchItem `json:"items"`
+}
+
+// IsAtomic reports whether the batch must succeed or fail as a whole
+func (b *BatchRequest) IsAtomic() bool {
+	return b.Atomic == nil || *b.Atomic
+}
+
+// BatchItemResult is the outcome of a single batch item
+type BatchItemResult struct {
+	Index        int         `json:"index"`
+	Action       string      `json:"action"`
+	Success      bool        `json:"success"`
+	ID           interface{} `json:"id,omitempty"`
+	AffectedRows int64       `json:"affected_rows"`
+	Data         interface{} `json:"data,omitempty"`
+	Error        string      `json:"error,omitempty"`
+	Code         string      `json:"code,omitempty"` // Error code, see HandlerConfig.ItemError
+}
+
+// BatchResult is the outcome of a batch request
+type BatchResult struct {
+	Atomic     bool              `json:"atomic"`
+	RolledBack bool              `json:"rolled_back"`
+	Succeeded  int               `json:"succeeded"`
+	Failed     int               `json:"failed"`
+	Results    []BatchItemResult `json:"results"`
+}
+
+// StatusCode returns the HTTP status for the result: 200 when every item succeeded,
+// 207 when a non-atomic batch partially failed and 422 when an atomic batch was rolled back
+func (r *BatchResult) StatusCode() int {
+	switch {
+	case r.RolledBack:
+		return http.StatusUnprocessableEntity
+	case r.Failed > 0:
+		return http.StatusMultiStatus
+	default:
+		return http.StatusOK
+	}
+}
+
+// BatchItemFunc executes one batch item against db, which is the transaction of the item
+type BatchItemFunc func(ctx context.Context, db Database, item BatchItem) (*ProcessResult, error)
+
+// ParseBatchRequest parses a batch from request data. It accepts either an array of
+// items or an object with "atomic" and "items" keys, and validates the items.
+func ParseBatchRequest(data interface{}) (*BatchRequest, error) {
+	var raw interface{}
+	batch := &BatchRequest{}
+
+	switch v := data.(type) {
+	case []interface{}:
+		raw = v
+	case map[string]interface{}:
+		if atomic, ok := v["atomic"]; ok {
+			value, isBool := atomic.(bool)
+			if !isBool {
+				return nil, fmt.Errorf("atomic must be a boolean")
+			}
+			batch.Atomic = &value
+		}
+		raw = v["items"]
+	default:
+		return nil, fmt.Errorf("batch must be an array of items or an object with items")
+	}
+
+	items, ok := raw.([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("batch contains no items")
+	}
+	if len(items) > MaxBatchSize {
+		return nil, fmt.Errorf("batch contains %d items, maximum is %d", len(items), MaxBatchSize)
+	}
+
+	batch.Items = make([]BatchItem, 0, len(items))
+	for idx, rawItem := range items {
+		itemMap, ok := rawItem.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("item %d: must be an object", idx)
+		}
+
+		item := BatchItem{ID: itemMap["id"]}
+		action, _ := itemMap["action"].(string)
+		switch strings.ToLower(strings.TrimSpace(action)) {
+		case "create", "insert":
+			item.Action = BatchActionCreate
+		case "update":
+			item.Action = BatchActionUpdate
+		case "delete":
+			item.Action = BatchActionDelete
+		default:
+			return nil, fmt.Errorf("item %d: unsupported action %q", idx, action)
+		}
+
+		if rawData, exists := itemMap["data"]; exists && rawData != nil {
+			if item.Data, ok = rawData.(map[string]interface{}); !ok {
+				return nil, fmt.Errorf("item %d: data must be an object", idx)
+			}
+		}
+		if item.Data == nil {
+			if item.Action != BatchActionDelete {
+				return nil, fmt.Errorf("item %d: data is required for %s", idx, item.Action)
+			}
+			item.Data = make(map[string]interface{})
+		}
+
+		batch.Items = append(batch.Items, item)
+	}
+
+	return batch, nil
+}
+
+// errBatchItemFailed aborts the transaction of an atomic batch
+var errBatchItemFailed = errors.New("batch item failed")
+
+// ExecuteBatch runs the batch items in order using exec. Atomic batches share one
+// transaction; otherwise each item gets its own transaction. Item errors are reported under the
+// error policy of config.
+func ExecuteBatch(ctx context.Context, db Database, config HandlerConfig, batch *BatchRequest, exec BatchItemFunc) *BatchResult {
+	result := &BatchResult{
+		Atomic:  batch.IsAtomic(),
+		Results: make([]BatchItemResult, len(batch.Items)),
+	}
+	for idx, item := range batch.Items {
+		result.Results[idx] = BatchItemResult{Index: idx, Action: item.Action}
+	}
+
+	runItem := func(ctx context.Context, tx Database, idx int) error {
+		itemResult := &result.Results[idx]
+		processed, err := exec(ctx, tx, batch.Items[idx])
+		if err != nil {
+			logger.Warn("Batch item %d (%s) failed: %v", idx, itemResult.Action, err)
+			itemResult.Code, itemResult.Error = config.ItemError(err)
+			return err
+		}
+		itemResult.Success = true
+		if processed != nil {
+			itemResult.ID = processed.ID
+			itemResult.AffectedRows = processed.AffectedRows
+			itemResult.Data = processed.Data
+		}
+		return nil
+	}
+
+	if result.Atomic {
+		failedIdx := -1
+		err := RunInTransaction(ctx, db, func(ctx context.Context, tx Database) error {
+			for idx := range batch.Items {
+				if err := runItem(ctx, tx, idx); err != nil {
+					failedIdx = idx
+					return errBatchItemFailed
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			result.RolledBack = true
+			for idx := range result.Results {
+				itemResult := &result.Results[idx]
+				switch {
+				case idx == failedIdx:
+				case failedIdx < 0:
+					// The commit itself failed
+					itemResult.Code, itemResult.Error = config.ItemError(fmt.Errorf("transaction failed: %w", err))
+				case idx < failedIdx:
+					itemResult.Error = "rolled back"
+				default:
+					itemResult.Error = "not executed"
+				}
+				itemResult.Success = false
+				itemResult.ID = nil
+				itemResult.AffectedRows = 0
+				itemResult.Data = nil
+			}
+		}
+	} else {
+		for idx := range batch.Items {
+			// Item failures are recorded by runItem; the item's transaction is rolled back
+			err := RunInTransaction(ctx, db, func(ctx context.Context, tx Database) error {
+				return runItem(ctx, tx, idx)
+			})
+			if itemResult := &result.Results[idx]; err != nil && itemResult.Success {
+				// The commit itself failed
+				*itemResult = BatchItemResult{Index: idx, Action: itemResult.Action}
+				itemResult.Code, itemResult.Error = config.ItemError(fmt.Errorf("transaction failed: %w", err))
+			}
+		}
+	}
+
+	for _, itemResult := range result.Results {
+		if itemResult.Success {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+	return result
+}
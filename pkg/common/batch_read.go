@@ -0,0 +1,111 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// MaxBatchReadIDs limits the number of ids of a single batch read
+const MaxBatchReadIDs = MaxBatchSize
+
+// IDList is a list of primary key values, decoded from JSON strings or numbers
+type IDList []string
+
+// UnmarshalJSON accepts ids as JSON strings or numbers, keeping numbers as written
+func (l *IDList) UnmarshalJSON(data []byte) error {
+	var values []json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	ids := make(IDList, 0, len(values))
+	for _, value := range values {
+		value = bytes.TrimSpace(value)
+		if len(value) > 0 && value[0] == '"' {
+			var id string
+			if err := json.Unmarshal(value, &id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+			continue
+		}
+		var number json.Number
+		if err := json.Unmarshal(value, &number); err != nil {
+			return fmt.Errorf("id %s is no string or number", value)
+		}
+		ids = append(ids, number.String())
+	}
+	*l = ids
+	return nil
+}
+
+// ParseIDList parses a comma separated list of ids, e.g. the X-IDs header
+func ParseIDList(value string) IDList {
+	var ids IDList
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Unique returns the ids without duplicates, in the order of their first occurrence
+func (l IDList) Unique() []interface{} {
+	seen := make(map[string]bool, len(l))
+	unique := make([]interface{}, 0, len(l))
+	for _, id := range l {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// BatchReadResult is the record read for one id of a batch read
+type BatchReadResult struct {
+	ID    string      `json:"id"`
+	Found bool        `json:"found"`
+	Data  interface{} `json:"data,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// OrderByIDs returns the records of a slice in the order of ids, matched by primary key.
+// Ids without a record get a "not found" entry.
+func OrderByIDs(records interface{}, ids IDList) []BatchReadResult {
+	byID := make(map[string]interface{})
+	list := reflect.ValueOf(records)
+	for list.Kind() == reflect.Pointer {
+		list = list.Elem()
+	}
+	if list.Kind() == reflect.Slice {
+		for i := 0; i < list.Len(); i++ {
+			record := list.Index(i).Interface()
+			pk := reflect.ValueOf(reflection.GetPrimaryKeyValue(record))
+			if pk.Kind() == reflect.Pointer {
+				if pk.IsNil() {
+					continue
+				}
+				pk = pk.Elem()
+			}
+			if pk.IsValid() {
+				byID[fmt.Sprint(pk.Interface())] = record
+			}
+		}
+	}
+
+	results := make([]BatchReadResult, len(ids))
+	for i, id := range ids {
+		if record, ok := byID[id]; ok {
+			results[i] = BatchReadResult{ID: id, Found: true, Data: record}
+		} else {
+			results[i] = BatchReadResult{ID: id, Error: "not found"}
+		}
+	}
+	return results
+}
@@ -0,0 +1,35 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type batchReadRecord struct {
+	ID   int64  `json:"id" gorm:"column:id;primaryKey"`
+	Name string `json:"name"`
+}
+
+func TestIDList(t *testing.T) {
+	var body RequestBody
+	require.NoError(t, json.Unmarshal([]byte(`{"operation":"read","ids":[3,"a-1",12345678901234567]}`), &body))
+	assert.Equal(t, IDList{"3", "a-1", "12345678901234567"}, body.IDs)
+	assert.Error(t, json.Unmarshal([]byte(`{"ids":[{"id":1}]}`), &body))
+
+	ids := ParseIDList(" 3, 1,,3 ")
+	assert.Equal(t, IDList{"3", "1", "3"}, ids)
+	assert.Equal(t, []interface{}{"3", "1"}, ids.Unique())
+}
+
+func TestOrderByIDs(t *testing.T) {
+	records := []*batchReadRecord{{ID: 1, Name: "one"}, {ID: 3, Name: "three"}}
+	results := OrderByIDs(&records, IDList{"3", "2", "1", "3"})
+	require.Len(t, results, 4)
+	assert.Equal(t, BatchReadResult{ID: "3", Found: true, Data: records[1]}, results[0])
+	assert.Equal(t, BatchReadResult{ID: "2", Error: "not found"}, results[1])
+	assert.Equal(t, "one", results[2].Data.(*batchReadRecord).Name)
+	assert.True(t, results[3].Found)
+}
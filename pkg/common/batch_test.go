@@ -0,0 +1,95 @@
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestParseBatchRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantErr   bool
+		atomic    bool
+		actions   []string
+		firstData map[string]interface{}
+	}{
+		{
+			name:    "array of items defaults to atomic",
+			body:    `[{"action":"insert","data":{"name":"a"}},{"action":"Update","id":2,"data":{"name":"b"}},{"action":"delete","id":3}]`,
+			atomic:  true,
+			actions: []string{BatchActionCreate, BatchActionUpdate, BatchActionDelete},
+		},
+		{
+			name:    "object with atomic false",
+			body:    `{"atomic":false,"items":[{"action":"create","data":{"name":"a"}}]}`,
+			atomic:  false,
+			actions: []string{BatchActionCreate},
+		},
+		{name: "unknown action", body: `[{"action":"upsert","data":{}}]`, wantErr: true},
+		{name: "missing data for create", body: `[{"action":"create"}]`, wantErr: true},
+		{name: "data must be an object", body: `[{"action":"update","data":[1]}]`, wantErr: true},
+		{name: "empty batch", body: `{"items":[]}`, wantErr: true},
+		{name: "atomic must be boolean", body: `{"atomic":"yes","items":[{"action":"delete","id":1}]}`, wantErr: true},
+		{name: "scalar body", body: `"batch"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(tt.body), &data); err != nil {
+				t.Fatalf("invalid test body: %v", err)
+			}
+
+			batch, err := ParseBatchRequest(data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", batch)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if batch.IsAtomic() != tt.atomic {
+				t.Errorf("IsAtomic() = %v, want %v", batch.IsAtomic(), tt.atomic)
+			}
+			if len(batch.Items) != len(tt.actions) {
+				t.Fatalf("expected %d items, got %d", len(tt.actions), len(batch.Items))
+			}
+			for idx, action := range tt.actions {
+				if batch.Items[idx].Action != action {
+					t.Errorf("item %d action = %q, want %q", idx, batch.Items[idx].Action, action)
+				}
+				if batch.Items[idx].Data == nil {
+					t.Errorf("item %d data must not be nil", idx)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchItemRecordData(t *testing.T) {
+	item := BatchItem{ID: float64(5), Data: map[string]interface{}{"name": "x"}}
+	data := item.RecordData("user_id")
+
+	if data["user_id"] != float64(5) || data["name"] != "x" {
+		t.Errorf("unexpected record data: %v", data)
+	}
+	if _, modified := item.Data["user_id"]; modified {
+		t.Error("RecordData must not modify the item data")
+	}
+}
+
+func TestBatchResultStatusCode(t *testing.T) {
+	if code := (&BatchResult{Succeeded: 2}).StatusCode(); code != http.StatusOK {
+		t.Errorf("expected 200, got %d", code)
+	}
+	if code := (&BatchResult{Succeeded: 1, Failed: 1}).StatusCode(); code != http.StatusMultiStatus {
+		t.Errorf("expected 207, got %d", code)
+	}
+	if code := (&BatchResult{Atomic: true, RolledBack: true, Failed: 2}).StatusCode(); code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422, got %d", code)
+	}
+}
@@ -0,0 +1,70 @@
+package common
+
+import (
+	"context"
+	"sort"
+)
+
+// DefaultBulkInsertThreshold is the number of records above which handlers create an array
+// of records with a single bulk insert, when the database implements BulkInserter
+const DefaultBulkInsertThreshold = 100
+
+// DefaultInsertChunkSize is the number of rows of a multi-row INSERT of InsertQuery.Bulk
+// without a chunk size
+const DefaultInsertChunkSize = 500
+
+// BulkInserter is implemented by databases that load many rows at once faster than
+// row-by-row inserts, e.g. with PostgreSQL COPY FROM. Each row holds the values of columns.
+type BulkInserter interface {
+	BulkInsert(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error)
+}
+
+// BulkInsertRows converts records to the columns and rows of a bulk insert. column maps a
+// record key to its column name. ok is false if a key has no column or the records don't
+// all have the same keys, since a bulk insert writes the same columns for every row.
+func BulkInsertRows(records []map[string]interface{}, column func(key string) (string, bool)) (columns []string, rows [][]interface{}, ok bool) {
+	if len(records) == 0 {
+		return nil, nil, false
+	}
+
+	keys := make([]string, 0, len(records[0]))
+	for key := range records[0] {
+		name, found := column(key)
+		if !found {
+			return nil, nil, false
+		}
+		keys = append(keys, key)
+		columns = append(columns, name)
+	}
+	sort.Sort(keysByColumn{keys, columns})
+
+	rows = make([][]interface{}, len(records))
+	for i, record := range records {
+		if len(record) != len(keys) {
+			return nil, nil, false
+		}
+		row := make([]interface{}, len(keys))
+		for j, key := range keys {
+			value, found := record[key]
+			if !found {
+				return nil, nil, false
+			}
+			row[j] = value
+		}
+		rows[i] = row
+	}
+	return columns, rows, true
+}
+
+// keysByColumn sorts record keys and their columns by column name
+type keysByColumn struct {
+	keys    []string
+	columns []string
+}
+
+func (k keysByColumn) Len() int           { return len(k.keys) }
+func (k keysByColumn) Less(i, j int) bool { return k.columns[i] < k.columns[j] }
+func (k keysByColumn) Swap(i, j int) {
+	k.keys[i], k.keys[j] = k.keys[j], k.keys[i]
+	k.columns[i], k.columns[j] = k.columns[j], k.columns[i]
+}
@@ -0,0 +1,30 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkInsertRows(t *testing.T) {
+	columnsByKey := map[string]string{"name": "name", "createdAt": "created_at", "age": "age"}
+	column := func(key string) (string, bool) {
+		name, ok := columnsByKey[key]
+		return name, ok
+	}
+
+	columns, rows, ok := BulkInsertRows([]map[string]interface{}{
+		{"name": "a", "createdAt": "2024-01-01", "age": 1},
+		{"name": "b", "createdAt": "2024-01-02", "age": 2},
+	}, column)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"age", "created_at", "name"}, columns)
+	assert.Equal(t, [][]interface{}{{1, "2024-01-01", "a"}, {2, "2024-01-02", "b"}}, rows)
+
+	_, _, ok = BulkInsertRows([]map[string]interface{}{{"name": "a"}, {"age": 2}}, column)
+	assert.False(t, ok, "records with different keys")
+	_, _, ok = BulkInsertRows([]map[string]interface{}{{"name": "a", "unknown": 1}}, column)
+	assert.False(t, ok, "keys without a column")
+	_, _, ok = BulkInsertRows(nil, column)
+	assert.False(t, ok)
+}
@@ -0,0 +1,105 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSONPrune selects the classes of empty values PruneJSON removes
+type JSONPrune uint8
+
+const (
+	PruneNull        JSONPrune = 1 << iota // null fields
+	PruneEmptyString                       // "" fields
+	PruneEmptyArray                        // [] fields
+	PruneEmptyObject                       // {} fields, also objects left empty by pruning
+
+	// PruneDefault is the pruning of a clean JSON request without classes
+	PruneDefault = PruneNull | PruneEmptyString | PruneEmptyArray
+)
+
+// ParseJSONPrune parses the value of a clean JSON option: "true" for PruneDefault, "false", or
+// a comma-separated list of the classes null, empty, array and object
+func ParseJSONPrune(value string) (JSONPrune, error) {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(value) {
+	case "true", "1", "yes":
+		return PruneDefault, nil
+	case "false", "0", "no", "":
+		return 0, nil
+	}
+	var prune JSONPrune
+	for _, class := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(class)) {
+		case "null":
+			prune |= PruneNull
+		case "empty", "string":
+			prune |= PruneEmptyString
+		case "array":
+			prune |= PruneEmptyArray
+		case "object":
+			prune |= PruneEmptyObject
+		case "all":
+			prune |= PruneDefault | PruneEmptyObject
+		default:
+			return 0, fmt.Errorf("unknown clean JSON class %q, expected null, empty, array, object or all", class)
+		}
+	}
+	return prune, nil
+}
+
+// PruneJSON removes the fields holding the selected classes of empty values from data, at any
+// depth. Data is converted to its JSON representation first, so models are pruned by their JSON
+// names and numbers keep their precision. Elements of arrays are pruned but never removed.
+func PruneJSON(data interface{}, prune JSONPrune) (interface{}, error) {
+	if prune == 0 || data == nil {
+		return data, nil
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	return pruneValue(decoded, prune), nil
+}
+
+func pruneValue(value interface{}, prune JSONPrune) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			field = pruneValue(field, prune)
+			if prunable(field, prune) {
+				delete(v, key)
+			} else {
+				v[key] = field
+			}
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = pruneValue(element, prune)
+		}
+	}
+	return value
+}
+
+// prunable reports whether a field holds a pruned class of empty value
+func prunable(value interface{}, prune JSONPrune) bool {
+	switch v := value.(type) {
+	case nil:
+		return prune&PruneNull != 0
+	case string:
+		return v == "" && prune&PruneEmptyString != 0
+	case []interface{}:
+		return len(v) == 0 && prune&PruneEmptyArray != 0
+	case map[string]interface{}:
+		return len(v) == 0 && prune&PruneEmptyObject != 0
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cleanedProject struct {
+	ID    int64           `json:"id"`
+	Name  string          `json:"name"`
+	Note  *string         `json:"note"`
+	Tags  []string        `json:"tags"`
+	Owner *cleanedProject `json:"owner"`
+	Meta  map[string]any  `json:"meta"`
+	Tasks []cleanedTask   `json:"tasks"`
+}
+
+type cleanedTask struct {
+	Title string  `json:"title"`
+	Done  bool    `json:"done"`
+	Due   *string `json:"due"`
+}
+
+func TestPruneJSON(t *testing.T) {
+	project := cleanedProject{
+		ID:    9007199254740993,
+		Tags:  []string{},
+		Meta:  map[string]any{"empty": nil},
+		Tasks: []cleanedTask{{Title: "a"}, {}},
+	}
+	encode := func(data interface{}) string {
+		encoded, err := json.Marshal(data)
+		require.NoError(t, err)
+		return string(encoded)
+	}
+
+	cleaned, err := PruneJSON([]cleanedProject{project}, PruneDefault)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"id":9007199254740993,"meta":{},"tasks":[{"title":"a","done":false},{"done":false}]}]`, encode(cleaned))
+
+	cleaned, err = PruneJSON(project, PruneDefault|PruneEmptyObject)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":9007199254740993,"tasks":[{"title":"a","done":false},{"done":false}]}`, encode(cleaned), "objects left empty are pruned")
+
+	cleaned, err = PruneJSON(project, PruneNull)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":9007199254740993,"name":"","tags":[],"meta":{},"tasks":[{"title":"a","done":false},{"title":"","done":false}]}`, encode(cleaned))
+
+	cleaned, err = PruneJSON(project, 0)
+	require.NoError(t, err)
+	assert.Equal(t, project, cleaned, "nothing to prune")
+}
+
+func TestParseJSONPrune(t *testing.T) {
+	for value, expected := range map[string]JSONPrune{
+		"true":        PruneDefault,
+		"FALSE":       0,
+		"null":        PruneNull,
+		"empty,array": PruneEmptyString | PruneEmptyArray,
+		"all":         PruneDefault | PruneEmptyObject,
+	} {
+		prune, err := ParseJSONPrune(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, prune, value)
+	}
+	_, err := ParseJSONPrune("zeros")
+	assert.Error(t, err)
+}
@@ -0,0 +1,136 @@
+package common
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ColumnDescriber returns the columns of a table as the database reports them. Set it as
+// HandlerConfig.DescribeColumns to complete the metadata of HandleGet, e.g. with defaults and
+// comments that only exist in migrations.
+type ColumnDescriber func(ctx context.Context, schema, table string) ([]Column, error)
+
+// typeSizePattern matches the size of a column type, e.g. varchar(100) or numeric(10,2)
+var typeSizePattern = regexp.MustCompile(`\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\)`)
+
+// ApplyColumnTags sets the default value, comment, maximum length, precision and scale of
+// column from the GORM (default, comment, size, precision, scale, type) and Bun (default,
+// type) tags of field
+func ApplyColumnTags(column *Column, field reflect.StructField) {
+	for _, option := range strings.Split(field.Tag.Get("gorm"), ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), ":")
+		switch strings.ToLower(key) {
+		case "default":
+			column.DefaultValue = value
+		case "comment":
+			column.Comment = value
+		case "size":
+			column.MaxLength, _ = strconv.Atoi(value)
+		case "precision":
+			column.Precision, _ = strconv.Atoi(value)
+		case "scale":
+			column.Scale, _ = strconv.Atoi(value)
+		case "type":
+			applyTypeSize(column, value)
+		}
+	}
+	for _, option := range splitBunTag(field.Tag.Get("bun")) {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), ":")
+		switch key {
+		case "default":
+			column.DefaultValue = value
+		case "type":
+			applyTypeSize(column, value)
+		}
+	}
+}
+
+// applyTypeSize reads the length of character types and the precision and scale of numeric
+// types from a column type
+func applyTypeSize(column *Column, columnType string) {
+	match := typeSizePattern.FindStringSubmatch(columnType)
+	if match == nil {
+		return
+	}
+	size, _ := strconv.Atoi(match[1])
+	name := strings.ToLower(columnType)
+	if strings.Contains(name, "char") || strings.Contains(name, "binary") || strings.Contains(name, "bit") {
+		column.MaxLength = size
+		return
+	}
+	column.Precision = size
+	if match[2] != "" {
+		column.Scale, _ = strconv.Atoi(match[2])
+	}
+}
+
+// splitBunTag splits the options of a Bun tag on the commas outside of parentheses, so
+// type:numeric(10,2) stays one option
+func splitBunTag(tag string) []string {
+	options := make([]string, 0)
+	depth, start := 0, 0
+	for i, r := range tag {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				options = append(options, tag[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(tag) {
+		options = append(options, tag[start:])
+	}
+	return options
+}
+
+// MergeColumnDetails completes columns with the default values, comments, maximum lengths,
+// precisions and scales of the matching described columns. Values reported by the database
+// take precedence over the struct tags.
+func MergeColumnDetails(columns []Column, described []Column) {
+	byName := make(map[string]Column, len(described))
+	for _, column := range described {
+		byName[strings.ToLower(column.Name)] = column
+	}
+	for i := range columns {
+		column, ok := byName[strings.ToLower(columns[i].Name)]
+		if !ok {
+			continue
+		}
+		if column.DefaultValue != "" {
+			columns[i].DefaultValue = column.DefaultValue
+		}
+		if column.Comment != "" {
+			columns[i].Comment = column.Comment
+		}
+		if column.MaxLength > 0 {
+			columns[i].MaxLength = column.MaxLength
+		}
+		if column.Precision > 0 {
+			columns[i].Precision = column.Precision
+		}
+		if column.Scale > 0 {
+			columns[i].Scale = column.Scale
+		}
+	}
+}
+
+// DescribeMetadata completes metadata with the columns reported by DescribeColumns, if set
+func (c HandlerConfig) DescribeMetadata(ctx context.Context, metadata *TableMetadata) error {
+	if c.DescribeColumns == nil || metadata == nil {
+		return nil
+	}
+	described, err := c.DescribeColumns(ctx, metadata.Schema, metadata.Table)
+	if err != nil {
+		return err
+	}
+	MergeColumnDetails(metadata.Columns, described)
+	return nil
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type taggedProduct struct {
+	Name   string  `json:"name" gorm:"size:120;default:'unnamed';comment:Display name"`
+	Code   string  `json:"code" gorm:"type:varchar(20);not null"`
+	Price  float64 `json:"price" bun:"price,type:numeric(10,2),default:0"`
+	Weight float64 `json:"weight" gorm:"precision:8;scale:3"`
+	Notes  string  `json:"notes"`
+}
+
+func TestApplyColumnTags(t *testing.T) {
+	productType := reflect.TypeOf(taggedProduct{})
+	tests := []struct {
+		field string
+		want  Column
+	}{
+		{"Name", Column{DefaultValue: "'unnamed'", Comment: "Display name", MaxLength: 120}},
+		{"Code", Column{MaxLength: 20}},
+		{"Price", Column{DefaultValue: "0", Precision: 10, Scale: 2}},
+		{"Weight", Column{Precision: 8, Scale: 3}},
+		{"Notes", Column{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			field, _ := productType.FieldByName(tt.field)
+			var column Column
+			ApplyColumnTags(&column, field)
+			assert.Equal(t, tt.want, column)
+		})
+	}
+}
+
+func TestDescribeMetadata(t *testing.T) {
+	metadata := &TableMetadata{Schema: "shop", Table: "products", Columns: []Column{
+		{Name: "name", MaxLength: 120, Comment: "Display name"},
+		{Name: "stock"},
+	}}
+	config := HandlerConfig{DescribeColumns: func(ctx context.Context, schema, table string) ([]Column, error) {
+		assert.Equal(t, "shop", schema)
+		assert.Equal(t, "products", table)
+		return []Column{
+			{Name: "NAME", MaxLength: 200},
+			{Name: "stock", DefaultValue: "0", Comment: "Units on hand"},
+			{Name: "dropped", Comment: "not in the model"},
+		}, nil
+	}}
+	require.NoError(t, config.DescribeMetadata(context.Background(), metadata))
+	assert.Equal(t, []Column{
+		{Name: "name", MaxLength: 200, Comment: "Display name"},
+		{Name: "stock", DefaultValue: "0", Comment: "Units on hand"},
+	}, metadata.Columns)
+
+	config.DescribeColumns = func(context.Context, string, string) ([]Column, error) {
+		return nil, errors.New("no connection")
+	}
+	assert.Error(t, config.DescribeMetadata(context.Background(), metadata))
+	assert.NoError(t, HandlerConfig{}.DescribeMetadata(context.Background(), metadata))
+}
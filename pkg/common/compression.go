@@ -0,0 +1,51 @@
+package common
+
+import (
+	"io"
+
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// DefaultCompressionMinSize is the smallest response compressed when CompressionConfig.MinSize is 0
+const DefaultCompressionMinSize = 1024
+
+// CompressionEncoder creates the writer compressing a response at a level, 0 for the default
+// level of the encoding
+type CompressionEncoder func(w io.Writer, level int) (io.WriteCloser, error)
+
+// CompressionConfig configures the compression of responses, see HandlerConfig.Compression
+type CompressionConfig struct {
+	// MinSize is the smallest response compressed, DefaultCompressionMinSize when 0; smaller
+	// responses gain little and are sent as they are
+	MinSize int
+	// Level is the compression level of gzip and deflate, from 1 (fastest) to 9 (smallest), or
+	// their default when 0
+	Level int
+	// Encoders adds encodings by their Accept-Encoding name, e.g. "br" with a brotli writer.
+	// They are preferred over the built-in gzip and deflate when the client accepts both.
+	Encoders map[string]CompressionEncoder
+}
+
+// CompressingWriter is a ResponseWriter of a router adapter that compresses its response
+type CompressingWriter interface {
+	// Compress negotiates the encoding of the response with the Accept-Encoding header of the
+	// request; it returns false when the response is sent uncompressed
+	Compress(acceptEncoding string, config CompressionConfig) bool
+	// Close ends a compressed response
+	Close() error
+}
+
+// CompressResponse negotiates the compression of the response of a request when Compression is
+// set and the writer of the router adapter supports it. The returned function ends the response
+// and must be deferred.
+func (c HandlerConfig) CompressResponse(w ResponseWriter, r Request) func() {
+	compressing, ok := w.(CompressingWriter)
+	if !ok || c.Compression == nil || !compressing.Compress(r.Header("Accept-Encoding"), *c.Compression) {
+		return func() {}
+	}
+	return func() {
+		if err := compressing.Close(); err != nil {
+			logger.Warn("Error ending compressed response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Error codes of constraint violations
+const (
+	UniqueViolation     = "unique_violation"
+	ForeignKeyViolation = "foreign_key_violation"
+	NotNullViolation    = "not_null_violation"
+)
+
+// ConstraintError is a unique, foreign key or not-null violation reported by the database
+type ConstraintError struct {
+	Code       string // UniqueViolation, ForeignKeyViolation or NotNullViolation
+	Column     string // Offending column, when the driver reports it
+	Constraint string // Name of the violated constraint, when the driver reports it
+	Err        error
+}
+
+func (e *ConstraintError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// Status returns 409 for unique violations and 422 for foreign key and not-null violations
+func (e *ConstraintError) Status() int {
+	if e.Code == UniqueViolation {
+		return http.StatusConflict
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// Message returns a message for the client that doesn't reveal the database error
+func (e *ConstraintError) Message() string {
+	subject := "the record"
+	if e.Column != "" {
+		subject = e.Column
+	}
+	switch e.Code {
+	case UniqueViolation:
+		return fmt.Sprintf("A record with this value of %s already exists", subject)
+	case ForeignKeyViolation:
+		return fmt.Sprintf("The value of %s references a record that does not exist or is still referenced", subject)
+	default:
+		return fmt.Sprintf("A value for %s is required", subject)
+	}
+}
+
+// sqlStates maps PostgreSQL (and standard) SQLSTATE codes to constraint codes
+var sqlStates = map[string]string{
+	"23505": UniqueViolation,
+	"23503": ForeignKeyViolation,
+	"23502": NotNullViolation,
+}
+
+// constraintPatterns detect constraint violations in the messages of PostgreSQL, SQLite, MySQL
+// and SQL Server drivers. The group, when present, is the column.
+var constraintPatterns = []struct {
+	code    string
+	pattern *regexp.Regexp
+}{
+	{UniqueViolation, regexp.MustCompile(`UNIQUE constraint failed: (?:\w+\.)?(\w+)`)},
+	{NotNullViolation, regexp.MustCompile(`NOT NULL constraint failed: (?:\w+\.)?(\w+)`)},
+	{ForeignKeyViolation, regexp.MustCompile(`FOREIGN KEY constraint failed`)},
+	{UniqueViolation, regexp.MustCompile(`violates unique constraint`)},
+	{NotNullViolation, regexp.MustCompile(`null value in column "(\w+)"`)},
+	{ForeignKeyViolation, regexp.MustCompile(`violates foreign key constraint`)},
+	{UniqueViolation, regexp.MustCompile(`Duplicate entry '.*' for key '(?:\w+\.)?(\w+)'`)},
+	{NotNullViolation, regexp.MustCompile("Column '(\\w+)' cannot be null")},
+	{ForeignKeyViolation, regexp.MustCompile("a foreign key constraint fails \\(.*FOREIGN KEY \\(`(\\w+)`\\)")},
+	{UniqueViolation, regexp.MustCompile(`Cannot insert duplicate key`)},
+	{NotNullViolation, regexp.MustCompile(`Cannot insert the value NULL into column '(\w+)'`)},
+	{ForeignKeyViolation, regexp.MustCompile(`conflicted with the (?:FOREIGN KEY|REFERENCE) constraint`)},
+}
+
+// keyDetailPattern extracts the column of a PostgreSQL "Key (column)=(value)" detail
+var keyDetailPattern = regexp.MustCompile(`Key \((\w+)\)=`)
+
+// constraintNamePattern extracts the constraint name of PostgreSQL and SQL Server messages
+var constraintNamePattern = regexp.MustCompile(`constraint "([^"]+)"`)
+
+// AsConstraintError detects a constraint violation in an error of the GORM, Bun or database/sql
+// adapters. Drivers exposing SQLSTATE codes (pgx, lib/pq) are recognized by code, the others by
+// their messages.
+func AsConstraintError(err error) (*ConstraintError, bool) {
+	if err == nil {
+		return nil, false
+	}
+	var constraintErr *ConstraintError
+	if errors.As(err, &constraintErr) {
+		return constraintErr, true
+	}
+
+	result := &ConstraintError{Err: err}
+	if fields, ok := driverErrorFields(err); ok {
+		if code, known := sqlStates[fields["Code"]]; known {
+			result.Code = code
+			result.Column = fields["ColumnName"] + fields["Column"]
+			result.Constraint = fields["ConstraintName"] + fields["Constraint"]
+			if result.Column == "" {
+				result.Column = submatch(keyDetailPattern, fields["Detail"])
+			}
+			return result, true
+		}
+	}
+
+	message := err.Error()
+	for _, p := range constraintPatterns {
+		if match := p.pattern.FindStringSubmatch(message); match != nil {
+			result.Code = p.code
+			if len(match) > 1 {
+				result.Column = match[1]
+			} else {
+				result.Column = submatch(keyDetailPattern, message)
+			}
+			if name := constraintNamePattern.FindStringSubmatch(message); name != nil {
+				result.Constraint = name[1]
+			}
+			return result, true
+		}
+	}
+	return nil, false
+}
+
+// submatch returns the first group of pattern in s, or ""
+func submatch(pattern *regexp.Regexp, s string) string {
+	if match := pattern.FindStringSubmatch(s); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// driverErrorFields returns the string fields of the first error in the chain with a
+// SQLSTATE code, e.g. *pgconn.PgError (Code, ColumnName, ConstraintName, Detail) or
+// *pq.Error (Code, Column, Constraint, Detail)
+func driverErrorFields(err error) (map[string]string, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		stateErr, ok := err.(interface{ SQLState() string })
+		if !ok {
+			continue
+		}
+		fields := map[string]string{"Code": stateErr.SQLState()}
+		value := reflect.Indirect(reflect.ValueOf(err))
+		if value.Kind() == reflect.Struct {
+			for _, name := range []string{"ColumnName", "Column", "ConstraintName", "Constraint", "Detail"} {
+				if field := value.FieldByName(name); field.IsValid() && field.Kind() == reflect.String {
+					fields[name] = strings.TrimSpace(field.String())
+				}
+			}
+		}
+		return fields, true
+	}
+	return nil, false
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// pgError mimics *pgconn.PgError
+type pgError struct {
+	Code           string
+	Message        string
+	Detail         string
+	ColumnName     string
+	ConstraintName string
+}
+
+func (e *pgError) Error() string    { return e.Message }
+func (e *pgError) SQLState() string { return e.Code }
+
+func TestAsConstraintError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   string
+		column string
+	}{
+		{"sqlite unique", errors.New("constraint failed: UNIQUE constraint failed: users.email (2067)"), UniqueViolation, "email"},
+		{"sqlite not null", errors.New("NOT NULL constraint failed: users.name"), NotNullViolation, "name"},
+		{"sqlite foreign key", errors.New("FOREIGN KEY constraint failed"), ForeignKeyViolation, ""},
+		{"postgres unique", errors.New(`ERROR: duplicate key value violates unique constraint "users_email_key" (SQLSTATE 23505)`), UniqueViolation, ""},
+		{"postgres not null", errors.New(`ERROR: null value in column "name" of relation "users" violates not-null constraint (SQLSTATE 23502)`), NotNullViolation, "name"},
+		{"postgres foreign key", errors.New(`pq: insert or update on table "orders" violates foreign key constraint "orders_user_id_fkey"`), ForeignKeyViolation, ""},
+		{"pgx error", fmt.Errorf("create: %w", &pgError{Code: "23505", Message: "duplicate key", Detail: "Key (email)=(a@b.c) already exists.", ConstraintName: "users_email_key"}), UniqueViolation, "email"},
+		{"pgx not null", &pgError{Code: "23502", Message: "null value", ColumnName: "name"}, NotNullViolation, "name"},
+		{"mysql unique", errors.New("Error 1062 (23000): Duplicate entry 'a@b.c' for key 'users.email'"), UniqueViolation, "email"},
+		{"mysql not null", errors.New("Error 1048 (23000): Column 'name' cannot be null"), NotNullViolation, "name"},
+		{"mysql foreign key", errors.New("Error 1452 (23000): Cannot add or update a child row: a foreign key constraint fails (`shop`.`orders`, CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`))"), ForeignKeyViolation, "user_id"},
+		{"sqlserver unique", errors.New("mssql: Cannot insert duplicate key row in object 'dbo.users' with unique index 'ix_email'."), UniqueViolation, ""},
+		{"sqlserver not null", errors.New("mssql: Cannot insert the value NULL into column 'name', table 'shop.dbo.users'"), NotNullViolation, "name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			constraintErr, ok := AsConstraintError(tt.err)
+			if !ok {
+				t.Fatalf("expected a constraint error for %v", tt.err)
+			}
+			if constraintErr.Code != tt.code || constraintErr.Column != tt.column {
+				t.Errorf("got %s %q, want %s %q", constraintErr.Code, constraintErr.Column, tt.code, tt.column)
+			}
+			if !errors.Is(constraintErr, tt.err) {
+				t.Error("expected the constraint error to wrap the driver error")
+			}
+		})
+	}
+
+	constraintErr, _ := AsConstraintError(errors.New(`ERROR: duplicate key value violates unique constraint "users_email_key"`))
+	if constraintErr.Constraint != "users_email_key" || constraintErr.Status() != http.StatusConflict {
+		t.Errorf("got constraint %q and status %d", constraintErr.Constraint, constraintErr.Status())
+	}
+	if _, ok := AsConstraintError(errors.New("syntax error")); ok {
+		t.Error("expected no constraint error")
+	}
+	if _, ok := AsConstraintError(nil); ok {
+		t.Error("expected no constraint error for nil")
+	}
+}
@@ -0,0 +1,24 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// StatusClientClosedRequest is the non-standard status used when the client
+// disconnected before the response was written (as popularized by nginx)
+const StatusClientClosedRequest = 499
+
+// ContextErrorStatus maps errors caused by the request context to an HTTP status
+// and error code. It returns false when err is not a context error.
+func ContextErrorStatus(err error) (status int, code string, ok bool) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest, "request_canceled", true
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "request_timeout", true
+	default:
+		return 0, "", false
+	}
+}
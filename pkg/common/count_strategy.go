@@ -0,0 +1,217 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// CountStrategy is how the total of the list reads of an entity is counted
+type CountStrategy string
+
+const (
+	// CountExact runs COUNT(*) on every read
+	CountExact CountStrategy = ""
+	// CountEstimate takes the row estimate of the PostgreSQL planner, which follows
+	// pg_class.reltuples and the statistics of the filtered columns, and counts exactly when the
+	// estimate is below MinEstimatedCount or the database is not PostgreSQL
+	CountEstimate CountStrategy = "estimate"
+	// CountCached runs COUNT(*) once per filter and keeps the total for the TTL of the entity.
+	// Writes of the entity through the handler drop its totals. The totals are kept in the
+	// process: every instance of a service counts on its own, and writes through one instance
+	// or outside the handler don't drop the totals of the others until their TTL ends.
+	CountCached CountStrategy = "cached"
+)
+
+// MinEstimatedCount is the smallest estimate CountEstimate returns; smaller tables are cheap
+// to count and are counted exactly
+const MinEstimatedCount = 10000
+
+// DefaultCountCacheTTL keeps cached totals of entities without a CountCacheTTL
+const DefaultCountCacheTTL = time.Minute
+
+// MaxCountCacheEntries is the most totals a CountCache keeps per entity; the oldest total is
+// dropped for a new one
+const MaxCountCacheEntries = 1000
+
+// CountCache keeps the totals of the reads of entities with CountCached, by entity and count
+// statement, at most MaxCountCacheEntries per entity. It is safe for concurrent use.
+type CountCache struct {
+	mu      sync.Mutex
+	entries map[string]map[string]cachedCount
+	now     func() time.Time
+}
+
+type cachedCount struct {
+	count   int
+	expires time.Time
+}
+
+// NewCountCache returns an empty count cache
+func NewCountCache() *CountCache {
+	return &CountCache{entries: make(map[string]map[string]cachedCount), now: time.Now}
+}
+
+// Get returns the total of a count statement of an entity, unless it expired
+func (c *CountCache) Get(schema, entity, statement string) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[countCacheEntity(schema, entity)][statement]
+	if !ok || !c.now().Before(entry.expires) {
+		return 0, false
+	}
+	return entry.count, true
+}
+
+// Set keeps the total of a count statement of an entity for ttl. The expired totals of the
+// entity are dropped, and the oldest one when the entity has MaxCountCacheEntries.
+func (c *CountCache) Set(schema, entity, statement string, count int, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := countCacheEntity(schema, entity)
+	entries := c.entries[key]
+	if entries == nil {
+		entries = make(map[string]cachedCount)
+		c.entries[key] = entries
+	}
+
+	now := c.now()
+	oldest := ""
+	for cached, entry := range entries {
+		if !now.Before(entry.expires) {
+			delete(entries, cached)
+		} else if oldest == "" || entry.expires.Before(entries[oldest].expires) {
+			oldest = cached
+		}
+	}
+	if _, replaced := entries[statement]; !replaced && len(entries) >= MaxCountCacheEntries {
+		delete(entries, oldest)
+	}
+	entries[statement] = cachedCount{count: count, expires: now.Add(ttl)}
+}
+
+// Invalidate drops the totals of an entity, e.g. after a write
+func (c *CountCache) Invalidate(schema, entity string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, countCacheEntity(schema, entity))
+}
+
+// InvalidateOnWrite drops the totals of an entity written in ctx, right away and again once
+// the transaction of ctx committed, so reads in between don't keep the totals from before the
+// write
+func (c *CountCache) InvalidateOnWrite(ctx context.Context, schema, entity string) {
+	c.Invalidate(schema, entity)
+	AfterCommit(ctx, func(context.Context) {
+		c.Invalidate(schema, entity)
+	})
+}
+
+func countCacheEntity(schema, entity string) string {
+	if schema == "" {
+		return entity
+	}
+	return schema + "." + entity
+}
+
+// CountRows counts the rows of query, which should not be paginated, with the count strategy
+// of the entity. estimated is true for totals taken from the planner estimate.
+func CountRows(ctx context.Context, db Database, query SelectQuery, schema, entity string, config EntityConfig, cache *CountCache) (count int, estimated bool, err error) {
+	switch config.CountStrategy {
+	case CountExact:
+		count, err = query.Count(ctx)
+		return count, false, err
+	case CountEstimate:
+		estimate, ok, err := EstimateCount(ctx, db, query)
+		if err != nil {
+			return 0, false, err
+		}
+		if ok && estimate >= MinEstimatedCount {
+			return estimate, true, nil
+		}
+		count, err = query.Count(ctx)
+		return count, false, err
+	case CountCached:
+		if cache == nil {
+			count, err = query.Count(ctx)
+			return count, false, err
+		}
+		statement, params, err := query.ToSQL()
+		if err != nil {
+			return 0, false, err
+		}
+		key := fmt.Sprintf("%s %v", statement, params)
+		if count, ok := cache.Get(schema, entity, key); ok {
+			return count, false, nil
+		}
+		if count, err = query.Count(ctx); err != nil {
+			return 0, false, err
+		}
+		ttl := config.CountCacheTTL
+		if ttl <= 0 {
+			ttl = DefaultCountCacheTTL
+		}
+		cache.Set(schema, entity, key, count, ttl)
+		return count, false, nil
+	default:
+		return 0, false, fmt.Errorf("unsupported count strategy %q", config.CountStrategy)
+	}
+}
+
+// EstimateCount returns the rows the PostgreSQL planner expects query to return, from
+// EXPLAIN (FORMAT JSON). ok is false on other databases.
+func EstimateCount(ctx context.Context, db Database, query SelectQuery) (count int, ok bool, err error) {
+	if DialectOf(db).Name != "postgres" {
+		return 0, false, nil
+	}
+	statement, params, err := query.ToSQL()
+	if err != nil {
+		return 0, false, err
+	}
+	rows := []map[string]interface{}{}
+	if err := db.Query(ctx, &rows, "EXPLAIN (FORMAT JSON) "+statement, params...); err != nil {
+		return 0, false, fmt.Errorf("count estimate failed: %w", err)
+	}
+	if len(rows) == 0 {
+		return 0, false, nil
+	}
+	estimate, err := planRows(rows[0]["QUERY PLAN"])
+	if err != nil {
+		return 0, false, fmt.Errorf("count estimate failed: %w", err)
+	}
+	return estimate, true, nil
+}
+
+// planRows returns the "Plan Rows" of the top node of an EXPLAIN (FORMAT JSON) plan, which
+// drivers scan as text or decoded JSON
+func planRows(plan interface{}) (int, error) {
+	switch v := plan.(type) {
+	case []byte:
+		if err := json.Unmarshal(v, &plan); err != nil {
+			return 0, err
+		}
+	case string:
+		if err := json.Unmarshal([]byte(v), &plan); err != nil {
+			return 0, err
+		}
+	}
+	var explained []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	raw, err := json.Marshal(plan)
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(raw, &explained); err != nil {
+		return 0, err
+	}
+	if len(explained) == 0 {
+		return 0, fmt.Errorf("the plan has no rows estimate")
+	}
+	return int(math.Round(explained[0].Plan.Rows)), nil
+}
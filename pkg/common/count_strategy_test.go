@@ -0,0 +1,74 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountCache(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewCountCache()
+	cache.now = func() time.Time { return now }
+
+	cache.Set("", "orders", "SELECT 1", 10, time.Minute)
+	cache.Set("sales", "orders", "SELECT 1", 20, time.Minute)
+	count, ok := cache.Get("", "orders", "SELECT 1")
+	assert.True(t, ok)
+	assert.Equal(t, 10, count)
+	count, ok = cache.Get("sales", "orders", "SELECT 1")
+	assert.True(t, ok)
+	assert.Equal(t, 20, count, "entities are cached per schema")
+	_, ok = cache.Get("", "orders", "SELECT 2")
+	assert.False(t, ok)
+
+	cache.Invalidate("", "orders")
+	_, ok = cache.Get("", "orders", "SELECT 1")
+	assert.False(t, ok)
+	_, ok = cache.Get("sales", "orders", "SELECT 1")
+	assert.True(t, ok, "invalidation keeps the totals of other entities")
+
+	now = now.Add(time.Minute)
+	_, ok = cache.Get("sales", "orders", "SELECT 1")
+	assert.False(t, ok, "totals expire after their TTL")
+
+	cache.Set("sales", "orders", "SELECT 2", 30, time.Minute)
+	assert.Len(t, cache.entries["sales.orders"], 1, "setting a total drops the expired ones")
+}
+
+func TestCountCacheLimit(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewCountCache()
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i <= MaxCountCacheEntries; i++ {
+		cache.Set("", "orders", fmt.Sprintf("SELECT %d", i), i, time.Minute)
+		now = now.Add(time.Millisecond)
+	}
+	assert.Len(t, cache.entries["orders"], MaxCountCacheEntries)
+	_, ok := cache.Get("", "orders", "SELECT 0")
+	assert.False(t, ok, "the oldest total is dropped")
+	count, ok := cache.Get("", "orders", fmt.Sprintf("SELECT %d", MaxCountCacheEntries))
+	assert.True(t, ok)
+	assert.Equal(t, MaxCountCacheEntries, count)
+
+	cache.Set("", "orders", "SELECT 1", 100, time.Minute)
+	assert.Len(t, cache.entries["orders"], MaxCountCacheEntries, "replacing a total drops none")
+}
+
+func TestPlanRows(t *testing.T) {
+	plan := `[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "orders", "Plan Rows": 104857600.0, "Plan Width": 8}}]`
+	for _, value := range []interface{}{plan, []byte(plan), []interface{}{map[string]interface{}{"Plan": map[string]interface{}{"Plan Rows": float64(104857600)}}}} {
+		rows, err := planRows(value)
+		require.NoError(t, err)
+		assert.Equal(t, 104857600, rows)
+	}
+
+	_, err := planRows(`[]`)
+	assert.Error(t, err)
+	_, err = planRows(`not json`)
+	assert.Error(t, err)
+}
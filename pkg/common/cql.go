@@ -0,0 +1,166 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// cqlAliasPattern matches the column alias a computed expression is selected as
+var cqlAliasPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// cqlOperators are the operators allowed in a computed expression, longest first
+var cqlOperators = []string{
+	"#>>", "->>", "||", "<=", ">=", "<>", "!=", "::", "->", "#>",
+	"+", "-", "*", "/", "%", "=", "<", ">", ".", ",", "(", ")",
+}
+
+// ValidateComputedQL checks that a computed column (CQL) expression is a single scalar
+// SQL expression over the columns of table: columns, optionally qualified by the table,
+// calls of DefaultSQLFunctions, string and number literals, operators, the keywords of
+// conditions and balanced parentheses. Other identifiers and functions, statement
+// separators, comments, placeholders, subqueries and top level commas are rejected, so
+// the expression cannot escape "(expr) AS alias" or call server functions.
+func ValidateComputedQL(expr, table string, columns []string) error {
+	if strings.TrimSpace(expr) == "" {
+		return fmt.Errorf("empty expression")
+	}
+
+	depth := 0
+	tokens := make([]sqlToken, 0, 16)
+	for pos := 0; pos < len(expr); {
+		ch := rune(expr[pos])
+		switch {
+		case unicode.IsSpace(ch):
+			pos++
+		case ch == '\'':
+			end := skipQuoted(expr, pos, '\'')
+			if end < 0 {
+				return fmt.Errorf("unterminated string literal at position %d", pos)
+			}
+			tokens = append(tokens, sqlToken{kind: sqlString, text: expr[pos:end]})
+			pos = end
+		case ch == '"' || ch == '`':
+			end := skipQuoted(expr, pos, byte(ch))
+			if end < 0 {
+				return fmt.Errorf("unterminated quoted identifier at position %d", pos)
+			}
+			tokens = append(tokens, sqlToken{kind: sqlQuoted, text: expr[pos+1 : end-1]})
+			pos = end
+		case ch == '_' || unicode.IsLetter(ch):
+			start := pos
+			for pos < len(expr) && (expr[pos] == '_' || isAlphaNumeric(rune(expr[pos]))) {
+				pos++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlWord, text: expr[start:pos]})
+		case unicode.IsDigit(ch):
+			start := pos
+			for pos < len(expr) && (isAlphaNumeric(rune(expr[pos])) || expr[pos] == '.') {
+				pos++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlNumber, text: expr[start:pos]})
+		case strings.HasPrefix(expr[pos:], "--") || strings.HasPrefix(expr[pos:], "/*"):
+			return fmt.Errorf("comments are not allowed")
+		default:
+			operator := ""
+			for _, op := range cqlOperators {
+				if strings.HasPrefix(expr[pos:], op) {
+					operator = op
+					break
+				}
+			}
+			switch operator {
+			case "":
+				return fmt.Errorf("unexpected character %q at position %d", ch, pos)
+			case "(":
+				depth++
+			case ")":
+				depth--
+				if depth < 0 {
+					return fmt.Errorf("unbalanced parenthesis at position %d", pos)
+				}
+			case ",":
+				if depth == 0 {
+					return fmt.Errorf("top level comma at position %d", pos)
+				}
+			}
+			tokens = append(tokens, sqlToken{kind: sqlPunct, text: operator})
+			pos += len(operator)
+		}
+	}
+
+	if depth != 0 {
+		return fmt.Errorf("unbalanced parentheses")
+	}
+	return checkSQLNames(tokens, table, columns, func(name string) bool {
+		return containsFold(DefaultSQLFunctions, name)
+	}, "computed expression")
+}
+
+// skipQuoted returns the position after the quoted token starting at start, where a
+// doubled quote escapes the quote character, or -1 if the token is not terminated
+func skipQuoted(expr string, start int, quote byte) int {
+	for pos := start + 1; pos < len(expr); pos++ {
+		if expr[pos] != quote {
+			continue
+		}
+		if pos+1 < len(expr) && expr[pos+1] == quote {
+			pos++
+			continue
+		}
+		return pos + 1
+	}
+	return -1
+}
+
+func isAlphaNumeric(ch rune) bool {
+	return ch == '_' || unicode.IsLetter(ch) || unicode.IsDigit(ch)
+}
+
+// ValidateComputedQL checks a computed column (CQL) expression over the columns of the
+// model, see ValidateComputedQL
+func (v *ColumnValidator) ValidateComputedQL(expr string) error {
+	return ValidateComputedQL(expr, v.tableName(), v.GetValidColumns())
+}
+
+// tableName returns the table of the model from its TableName method, "" without one
+func (v *ColumnValidator) tableName() string {
+	modelType := reflect.TypeOf(v.model)
+	for modelType != nil && (modelType.Kind() == reflect.Ptr || modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Array) {
+		modelType = modelType.Elem()
+	}
+	if modelType == nil {
+		return ""
+	}
+	if provider, ok := reflect.New(modelType).Interface().(TableNameProvider); ok {
+		return provider.TableName()
+	}
+	return ""
+}
+
+// FilterComputedQL returns the computed columns whose alias is a column of the model
+// (typically a scan-only cql1, cql2, ... field) and whose expression passes
+// ValidateComputedQL over the model, logging warnings for the ones removed
+func (v *ColumnValidator) FilterComputedQL(computed map[string]string) map[string]string {
+	if computed == nil {
+		return nil
+	}
+
+	filtered := make(map[string]string, len(computed))
+	for alias, expr := range computed {
+		if !cqlAliasPattern.MatchString(alias) || !v.validColumns[strings.ToLower(alias)] {
+			logger.Warn("Computed column '%s' removed: the model has no field to receive it", alias)
+			continue
+		}
+		if err := v.ValidateComputedQL(expr); err != nil {
+			logger.Warn("Computed column '%s' removed: invalid expression: %v", alias, err)
+			continue
+		}
+		filtered[alias] = expr
+	}
+	return filtered
+}
@@ -0,0 +1,74 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateComputedQL(t *testing.T) {
+	columns := []string{"name", "code", "salary", "status", "first_name", "last_name", "data", "created_at", "avg_score"}
+	valid := []string{
+		"upper(name) || '-' || code",
+		"coalesce(salary, 0) * 1.1",
+		"CASE WHEN status = 'active' THEN 1 ELSE 0 END",
+		`"employees"."first_name" || ' ' || last_name`,
+		"data->>'city'",
+		"created_at::date",
+		"'it''s'",
+		"round(avg_score, 2)",
+		"employees.salary * 12",
+	}
+	for _, expr := range valid {
+		if err := ValidateComputedQL(expr, "hr.employees", columns); err != nil {
+			t.Errorf("ValidateComputedQL(%q) unexpected error: %v", expr, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"name; drop table users",
+		"name -- comment",
+		"name /* comment */",
+		"(select password from users limit 1)",
+		"1 union all 2",
+		"name) AS cql1, (code",
+		"name, code",
+		"(name",
+		"'unterminated",
+		"name = ?",
+		"pg_sleep(10)",
+		"pg_sleep_for('1 hour')",
+		"pg_read_file('/etc/passwd')",
+		"pg_terminate_backend(1)",
+		"set_config('role','x',false)",
+		"pg_catalog.upper(name)",
+		"password",
+		"users.name",
+		`"name"(code)`,
+	}
+	for _, expr := range invalid {
+		if err := ValidateComputedQL(expr, "hr.employees", columns); err == nil {
+			t.Errorf("ValidateComputedQL(%q) expected an error", expr)
+		}
+	}
+}
+
+func TestFilterComputedQL(t *testing.T) {
+	type model struct {
+		ID   int    `json:"id"`
+		CQL1 string `json:"cql1" bun:",scanonly"`
+		CQL2 string `json:"cql2" bun:",scanonly"`
+	}
+	validator := NewColumnValidator(model{})
+
+	got := validator.FilterComputedQL(map[string]string{
+		"cql1":  "id * 2",
+		"cql2":  "id; delete from model",
+		"cql3":  "id + 1",
+		"id as": "1",
+	})
+	want := map[string]string{"cql1": "id * 2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterComputedQL() = %v, want %v", got, want)
+	}
+}
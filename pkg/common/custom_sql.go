@@ -0,0 +1,235 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultSQLFunctions are the functions custom SQL conditions may call under a CustomSQLPolicy
+var DefaultSQLFunctions = []string{
+	"abs", "cast", "ceil", "ceiling", "char_length", "coalesce", "concat", "date", "date_trunc",
+	"datetime", "floor", "greatest", "least", "length", "lower", "ltrim", "now", "nullif",
+	"replace", "round", "rtrim", "strftime", "substr", "substring", "to_char", "trim", "upper",
+}
+
+// customSQLKeywords are the words of SQL conditions that are neither columns nor functions
+var customSQLKeywords = map[string]bool{
+	"and": true, "or": true, "not": true, "is": true, "null": true, "in": true, "like": true,
+	"ilike": true, "between": true, "true": true, "false": true, "case": true, "when": true,
+	"then": true, "else": true, "end": true, "as": true, "escape": true, "distinct": true,
+	"from": true, "interval": true, "current_date": true, "current_time": true,
+	"current_timestamp": true,
+}
+
+// CustomSQLPolicy checks the custom WHERE conditions of reads, e.g. x-custom-sql-w, before they
+// reach the database. A condition may only name the columns of the model, optionally qualified
+// by its table, call allowed functions and use literals, operators and the keywords of
+// conditions; other identifiers, subqueries, comments and further statements are rejected.
+type CustomSQLPolicy struct {
+	// Functions conditions may call besides DefaultSQLFunctions
+	Functions []string
+}
+
+// CheckCustomSQL checks custom SQL conditions on table against the CustomSQL policy. It returns
+// nil without a policy.
+func (c HandlerConfig) CheckCustomSQL(table string, columns []string, conditions ...string) error {
+	if c.CustomSQL == nil {
+		return nil
+	}
+	for _, condition := range conditions {
+		if err := c.CustomSQL.Validate(condition, table, columns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks a custom WHERE condition on table with columns
+func (p *CustomSQLPolicy) Validate(condition, table string, columns []string) error {
+	if strings.TrimSpace(condition) == "" {
+		return nil
+	}
+	tokens, err := tokenizeSQL(condition)
+	if err != nil {
+		return err
+	}
+	return checkSQLNames(tokens, table, columns, p.allowsFunction, "custom SQL")
+}
+
+func (p *CustomSQLPolicy) allowsFunction(name string) bool {
+	return containsFold(DefaultSQLFunctions, name) || containsFold(p.Functions, name)
+}
+
+// checkSQLNames checks the identifiers of tokenized SQL on table with columns: a name may be a
+// column, optionally qualified by the table, a function allowsFunction accepts or a keyword.
+// context names the SQL in errors, e.g. "custom SQL".
+func checkSQLNames(tokens []sqlToken, table string, columns []string, allowsFunction func(string) bool, context string) error {
+	table = strings.ToLower(table[strings.LastIndex(table, ".")+1:])
+	depth := 0
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token.kind {
+		case sqlPunct:
+			switch token.text {
+			case "(":
+				depth++
+			case ")":
+				if depth--; depth < 0 {
+					return fmt.Errorf("unbalanced parentheses in %s", context)
+				}
+			}
+			continue
+		case sqlWord, sqlQuoted:
+		default:
+			continue
+		}
+
+		// An identifier, possibly qualified: schema.table.column or table.column
+		parts := []sqlToken{token}
+		for i+2 < len(tokens) && tokens[i+1].text == "." && (tokens[i+2].kind == sqlWord || tokens[i+2].kind == sqlQuoted) {
+			parts = append(parts, tokens[i+2])
+			i += 2
+		}
+		name := strings.ToLower(parts[len(parts)-1].text)
+		previous := ""
+		if start := i - 2*(len(parts)-1); start > 0 {
+			previous = strings.ToLower(tokens[start-1].text)
+		}
+		calls := i+1 < len(tokens) && tokens[i+1].text == "("
+
+		if len(parts) == 1 && token.kind == sqlWord {
+			switch {
+			case previous == "::" || previous == "as":
+				continue // The type of a cast, e.g. CAST(code AS varchar(10))
+			case customSQLKeywords[name]:
+				continue
+			case calls:
+				if !allowsFunction(name) {
+					return fmt.Errorf("function %q is not allowed in %s", token.text, context)
+				}
+				continue
+			}
+		}
+		if calls {
+			return fmt.Errorf("function %q is not allowed in %s", joinSQLParts(parts), context)
+		}
+		if len(parts) > 1 && strings.ToLower(parts[len(parts)-2].text) != table || len(parts) > 3 {
+			return fmt.Errorf("%s may only reference the columns of %s, not %q", context, table, joinSQLParts(parts))
+		}
+		if matchColumn(columns, name) == "" {
+			return fmt.Errorf("unknown column %q in %s", joinSQLParts(parts), context)
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("unbalanced parentheses in %s", context)
+	}
+	return nil
+}
+
+func joinSQLParts(parts []sqlToken) string {
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		names = append(names, part.text)
+	}
+	return strings.Join(names, ".")
+}
+
+// Kinds of the tokens of custom SQL
+const (
+	sqlWord   = iota // Unquoted identifier or keyword
+	sqlQuoted        // Quoted identifier, "name", `name` or [name]
+	sqlString        // String literal
+	sqlNumber        // Numeric literal
+	sqlPunct         // Operator, parenthesis, comma or dot
+)
+
+type sqlToken struct {
+	kind int
+	text string
+}
+
+// sqlOperators are the operators of conditions, longest first
+var sqlOperators = []string{"<>", "!=", "<=", ">=", "||", "::", "=", "<", ">", "+", "-", "*", "/", "%", "(", ")", ",", "."}
+
+// tokenizeSQL splits a condition into tokens. Comments, statement separators, placeholders and
+// backslashes in string literals, which dialects read differently, are rejected.
+func tokenizeSQL(sql string) ([]sqlToken, error) {
+	tokens := make([]sqlToken, 0, 16)
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == ';':
+			return nil, fmt.Errorf("custom SQL may not contain multiple statements")
+		case strings.HasPrefix(sql[i:], "--") || strings.HasPrefix(sql[i:], "/*") || c == '#':
+			return nil, fmt.Errorf("custom SQL may not contain comments")
+		case c == '\'':
+			end := i + 1
+			for ; end < len(sql); end++ {
+				if sql[end] == '\\' {
+					return nil, fmt.Errorf("custom SQL string literals may not contain backslashes")
+				}
+				if sql[end] == '\'' {
+					if end+1 < len(sql) && sql[end+1] == '\'' {
+						end++
+						continue
+					}
+					break
+				}
+			}
+			if end >= len(sql) {
+				return nil, fmt.Errorf("unterminated string literal in custom SQL")
+			}
+			tokens = append(tokens, sqlToken{kind: sqlString, text: sql[i : end+1]})
+			i = end + 1
+		case c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(sql[i+1:], closing)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated identifier in custom SQL")
+			}
+			tokens = append(tokens, sqlToken{kind: sqlQuoted, text: sql[i+1 : i+1+end]})
+			i += end + 2
+		case isSQLDigit(c):
+			end := i
+			for end < len(sql) && (isSQLDigit(sql[end]) || sql[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlNumber, text: sql[i:end]})
+			i = end
+		case isSQLLetter(c):
+			end := i
+			for end < len(sql) && (isSQLLetter(sql[end]) || isSQLDigit(sql[end])) {
+				end++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlWord, text: sql[i:end]})
+			i = end
+		default:
+			operator := ""
+			for _, candidate := range sqlOperators {
+				if strings.HasPrefix(sql[i:], candidate) {
+					operator = candidate
+					break
+				}
+			}
+			if operator == "" {
+				return nil, fmt.Errorf("unexpected character %q in custom SQL", c)
+			}
+			tokens = append(tokens, sqlToken{kind: sqlPunct, text: operator})
+			i += len(operator)
+		}
+	}
+	return tokens, nil
+}
+
+func isSQLDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isSQLLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
@@ -0,0 +1,65 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomSQLPolicy(t *testing.T) {
+	policy := &CustomSQLPolicy{Functions: []string{"similarity"}}
+	columns := []string{"id", "status", "created_at", "name"}
+
+	tests := []struct {
+		name      string
+		condition string
+		valid     bool
+	}{
+		{"comparison", "status = 'active' AND created_at > '2024-01-01'", true},
+		{"qualified columns", `("employees".status = 'active' OR employees.status IS NULL)`, true},
+		{"schema qualified", "hr.employees.id IN (1, 2, 3)", true},
+		{"quoted column", "`Status` <> 'x' and [name] LIKE 'a%'", true},
+		{"functions", "LOWER(name) LIKE lower('%ann%') AND COALESCE(status, '') != ''", true},
+		{"added function", "similarity(name, 'ann') > 0.3", true},
+		{"casts", "CAST(id AS varchar(10)) = '1' OR id::text = '2'", true},
+		{"escaped quote", "name = 'O''Brien'", true},
+		{"date keywords", "created_at < CURRENT_TIMESTAMP AND NOT (status IS DISTINCT FROM 'open')", true},
+		{"empty", "  ", true},
+
+		{"unknown column", "password = 'x'", false},
+		{"other table", "users.id = 1", false},
+		{"subquery", "id IN (SELECT id FROM users)", false},
+		{"unknown function", "pg_sleep(10) IS NULL", false},
+		{"qualified function", "pg_catalog.lower(name) = 'a'", false},
+		{"multiple statements", "id = 1; DROP TABLE employees", false},
+		{"semicolon at end", "id = 1;", false},
+		{"line comment", "id = 1 -- and owner_id = 2", false},
+		{"block comment", "id = 1 /* */", false},
+		{"hash comment", "id = 1 # x", false},
+		{"backslash", `name = 'a\' OR 1 = 1 OR ''`, false},
+		{"unterminated string", "name = 'a", false},
+		{"unterminated identifier", `"name = 'a'`, false},
+		{"placeholder", "id = $1", false},
+		{"unbalanced", "(id = 1", false},
+		{"closing first", "id = 1) OR (1 = 1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.condition, "hr.employees", columns)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckCustomSQL(t *testing.T) {
+	columns := []string{"id", "status"}
+	assert.NoError(t, HandlerConfig{}.CheckCustomSQL("orders", columns, "anything goes"), "without a policy")
+
+	config := HandlerConfig{CustomSQL: &CustomSQLPolicy{}}
+	assert.NoError(t, config.CheckCustomSQL("orders", columns, "status = 'open'", ""))
+	assert.Error(t, config.CheckCustomSQL("orders", columns, "status = 'open'", "secret = 1"))
+}
@@ -0,0 +1,99 @@
+package common
+
+import (
+	"reflect"
+	"strings"
+)
+
+// DefaultSortProvider is implemented by models with an ordering of their lists, used when a read
+// has no sort of its own. Lists without an order are returned in the order the database happens to
+// read them, which changes between pages and makes cursor pagination unreliable.
+//
+//	func (Order) DefaultSort() []common.SortOption {
+//		return []common.SortOption{{Column: "created_at", Direction: "desc"}, {Column: "id", Direction: "desc"}}
+//	}
+type DefaultSortProvider interface {
+	DefaultSort() []SortOption
+}
+
+// DefaultFiltersProvider is implemented by models with filters of their lists, e.g. hiding
+// archived records, used when a read has no filters of its own
+type DefaultFiltersProvider interface {
+	DefaultFilters() []FilterOption
+}
+
+// modelCandidates returns the zero value and a pointer of the struct type of a model, to check
+// for interfaces implemented on either receiver
+func modelCandidates(model interface{}) []interface{} {
+	modelType := reflect.TypeOf(model)
+	for modelType != nil && (modelType.Kind() == reflect.Ptr || modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Array) {
+		modelType = modelType.Elem()
+	}
+	if modelType == nil {
+		return nil
+	}
+	return []interface{}{reflect.Zero(modelType).Interface(), reflect.New(modelType).Interface()}
+}
+
+// ModelDefaultSort returns the default sort of a model, or nil when it has none
+func ModelDefaultSort(model interface{}) []SortOption {
+	for _, candidate := range modelCandidates(model) {
+		if provider, ok := candidate.(DefaultSortProvider); ok {
+			return append([]SortOption{}, provider.DefaultSort()...)
+		}
+	}
+	return nil
+}
+
+// ModelDefaultFilters returns the default filters of a model, or nil when it has none
+func ModelDefaultFilters(model interface{}) []FilterOption {
+	for _, candidate := range modelCandidates(model) {
+		if provider, ok := candidate.(DefaultFiltersProvider); ok {
+			return append([]FilterOption{}, provider.DefaultFilters()...)
+		}
+	}
+	return nil
+}
+
+// HasFilters reports whether the options filter the read by Filters, FilterGroup or Scopes
+func (o RequestOptions) HasFilters() bool {
+	return len(o.Filters) > 0 || o.FilterGroup != nil || len(o.Scopes) > 0
+}
+
+// ApplyModelDefaults sets the default sort of the model on a list read without a sort, and its
+// default filters on a list read without filters. filtered reports filters of the request the
+// options don't hold, e.g. a search. Defaults are set by the server and skip column validation.
+func ApplyModelDefaults(model interface{}, options *RequestOptions, filtered bool) {
+	if len(options.Sort) == 0 {
+		options.Sort = ModelDefaultSort(model)
+	}
+	if !filtered && !options.HasFilters() {
+		options.Filters = ModelDefaultFilters(model)
+	}
+}
+
+// WithPrimaryKeySort appends the primary key columns missing from a sort, so rows with equal
+// sort values keep their order between pages and limit/offset or cursor pagination never skips
+// or repeats a row. An empty sort is left empty. Only unqualified sort columns match a key
+// column; qualified columns may belong to a joined table. Reads of distinct rows keep their
+// sort, as SELECT DISTINCT can only be sorted by selected columns.
+func WithPrimaryKeySort(sort []SortOption, pkNames []string) []SortOption {
+	if len(sort) == 0 || len(pkNames) == 0 {
+		return sort
+	}
+	sorted := make(map[string]bool, len(sort))
+	for _, option := range sort {
+		column := strings.ToLower(strings.TrimSpace(option.Column))
+		if fields := strings.Fields(column); len(fields) > 0 {
+			column = fields[0]
+		}
+		sorted[strings.Trim(column, "\"`")] = true
+	}
+	result := append(make([]SortOption, 0, len(sort)+len(pkNames)), sort...)
+	for _, pkName := range pkNames {
+		if pkName != "" && !sorted[strings.ToLower(pkName)] {
+			result = append(result, SortOption{Column: pkName, Direction: "asc"})
+		}
+	}
+	return result
+}
@@ -0,0 +1,55 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type defaultsModel struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+}
+
+func (defaultsModel) DefaultSort() []SortOption {
+	return []SortOption{{Column: "id", Direction: "desc"}}
+}
+
+func (*defaultsModel) DefaultFilters() []FilterOption {
+	return []FilterOption{{Column: "status", Operator: "neq", Value: "archived"}}
+}
+
+func TestModelDefaults(t *testing.T) {
+	assert.Equal(t, []SortOption{{Column: "id", Direction: "desc"}}, ModelDefaultSort(defaultsModel{}))
+	assert.Equal(t, "status", ModelDefaultFilters([]*defaultsModel{})[0].Column, "pointer receivers and slices are found")
+	assert.Nil(t, ModelDefaultSort(struct{}{}))
+	assert.Nil(t, ModelDefaultFilters(nil))
+
+	var options RequestOptions
+	ApplyModelDefaults(defaultsModel{}, &options, false)
+	assert.Equal(t, "id", options.Sort[0].Column)
+	assert.Len(t, options.Filters, 1)
+
+	options = RequestOptions{Sort: []SortOption{{Column: "status"}}, Scopes: []string{"active"}}
+	ApplyModelDefaults(defaultsModel{}, &options, false)
+	assert.Equal(t, []SortOption{{Column: "status"}}, options.Sort, "the sort of the request wins")
+	assert.Empty(t, options.Filters, "scopes are filters of the request")
+
+	options = RequestOptions{}
+	ApplyModelDefaults(defaultsModel{}, &options, true)
+	assert.Empty(t, options.Filters)
+	assert.Len(t, options.Sort, 1)
+}
+
+func TestWithPrimaryKeySort(t *testing.T) {
+	sort := []SortOption{{Column: "status", Direction: "desc"}}
+	assert.Equal(t, []SortOption{{Column: "status", Direction: "desc"}, {Column: "id", Direction: "asc"}}, WithPrimaryKeySort(sort, []string{"id"}))
+	assert.Len(t, sort, 1, "the sort of the request is not changed")
+
+	assert.Len(t, WithPrimaryKeySort([]SortOption{{Column: "status"}, {Column: `"ID"`, Direction: "desc"}}, []string{"id"}), 2, "sorts on the key are unique")
+	assert.Equal(t, []SortOption{{Column: "status"}, {Column: "tenant_id", Direction: "asc"}, {Column: "code", Direction: "asc"}},
+		WithPrimaryKeySort([]SortOption{{Column: "status"}}, []string{"tenant_id", "code"}))
+	assert.Len(t, WithPrimaryKeySort([]SortOption{{Column: "owner.id"}}, []string{"id"}), 2, "qualified columns may be joined")
+	assert.Empty(t, WithPrimaryKeySort(nil, []string{"id"}), "unsorted reads stay unsorted")
+	assert.Len(t, WithPrimaryKeySort(sort, nil), 1)
+}
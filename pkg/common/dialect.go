@@ -0,0 +1,86 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UpsertSyntax is the statement form a dialect uses to insert or update on a key conflict
+type UpsertSyntax string
+
+const (
+	// UpsertNone means the dialect has no single-statement upsert
+	UpsertNone UpsertSyntax = ""
+	// UpsertOnConflict is INSERT ... ON CONFLICT (...) DO UPDATE (PostgreSQL, SQLite)
+	UpsertOnConflict UpsertSyntax = "on_conflict"
+	// UpsertOnDuplicateKey is INSERT ... ON DUPLICATE KEY UPDATE (MySQL)
+	UpsertOnDuplicateKey UpsertSyntax = "on_duplicate_key"
+	// UpsertMerge is MERGE INTO ... (SQL Server)
+	UpsertMerge UpsertSyntax = "merge"
+)
+
+// Dialect describes the SQL dialect of a database and the features handlers can rely on
+// when generating SQL. Name is "postgres", "sqlite", "mysql", "mssql" or the driver name
+// of other databases.
+type Dialect struct {
+	Name string
+	// Returning is true if INSERT, UPDATE and DELETE accept a RETURNING clause
+	Returning bool
+	// ILike is true if the ILIKE operator is available
+	ILike bool
+	// JSONB is true if the jsonb type and its operators (->, ->>, @>, jsonb_set) are available
+	JSONB bool
+	// CTE is true if WITH queries (common table expressions) are available
+	CTE bool
+	// DistinctOn is true if SELECT DISTINCT ON (...) is available
+	DistinctOn bool
+	// Upsert is the syntax of insert-or-update statements
+	Upsert UpsertSyntax
+}
+
+// LookupDialect returns the capabilities of a dialect by name. Unknown dialects
+// get no capabilities, so handlers fall back to portable SQL.
+func LookupDialect(name string) Dialect {
+	switch name = strings.ToLower(name); name {
+	case "postgres":
+		return Dialect{Name: name, Returning: true, ILike: true, JSONB: true, CTE: true, DistinctOn: true, Upsert: UpsertOnConflict}
+	case "sqlite":
+		return Dialect{Name: name, Returning: true, CTE: true, Upsert: UpsertOnConflict}
+	case "mysql":
+		return Dialect{Name: name, CTE: true, Upsert: UpsertOnDuplicateKey}
+	case "mssql":
+		return Dialect{Name: name, CTE: true, Upsert: UpsertMerge}
+	default:
+		return Dialect{Name: name}
+	}
+}
+
+// DialectOf returns the dialect of db. Without a database, e.g. when handlers only build
+// SQL, it returns the PostgreSQL dialect.
+func DialectOf(db Database) Dialect {
+	if db == nil {
+		return LookupDialect("postgres")
+	}
+	return db.Dialect()
+}
+
+// ILikeExpression renders a case-insensitive LIKE of column against pattern (a placeholder or
+// a quoted literal): ILIKE where the dialect has it, LOWER(column) LIKE LOWER(pattern) elsewhere
+func (d Dialect) ILikeExpression(column, pattern string) string {
+	if d.ILike {
+		return fmt.Sprintf("%s ILIKE %s", column, pattern)
+	}
+	return fmt.Sprintf("LOWER(%s) LIKE LOWER(%s)", column, pattern)
+}
+
+// CastToText renders a cast of column to the text type of the dialect
+func (d Dialect) CastToText(column string) string {
+	switch d.Name {
+	case "mysql":
+		return fmt.Sprintf("CAST(%s AS CHAR)", column)
+	case "mssql":
+		return fmt.Sprintf("CAST(%s AS NVARCHAR(MAX))", column)
+	default:
+		return fmt.Sprintf("CAST(%s AS TEXT)", column)
+	}
+}
@@ -0,0 +1,41 @@
+package common
+
+import "testing"
+
+func TestLookupDialect(t *testing.T) {
+	postgres := LookupDialect("Postgres")
+	if postgres.Name != "postgres" || !postgres.Returning || !postgres.ILike || !postgres.JSONB || !postgres.DistinctOn || postgres.Upsert != UpsertOnConflict {
+		t.Errorf("unexpected postgres capabilities: %+v", postgres)
+	}
+	if mysql := LookupDialect("mysql"); mysql.Returning || mysql.ILike || mysql.DistinctOn || mysql.Upsert != UpsertOnDuplicateKey {
+		t.Errorf("unexpected mysql capabilities: %+v", mysql)
+	}
+	if unknown := LookupDialect("clickhouse"); unknown != (Dialect{Name: "clickhouse"}) {
+		t.Errorf("unknown dialects have no capabilities, got %+v", unknown)
+	}
+	if got := DialectOf(nil); got.Name != "postgres" {
+		t.Errorf("expected the postgres dialect without a database, got %+v", got)
+	}
+}
+
+func TestDialectExpressions(t *testing.T) {
+	tests := []struct {
+		dialect string
+		ilike   string
+		cast    string
+	}{
+		{"postgres", "users.name ILIKE ?", "CAST(users.name AS TEXT)"},
+		{"sqlite", "LOWER(users.name) LIKE LOWER(?)", "CAST(users.name AS TEXT)"},
+		{"mysql", "LOWER(users.name) LIKE LOWER(?)", "CAST(users.name AS CHAR)"},
+		{"mssql", "LOWER(users.name) LIKE LOWER(?)", "CAST(users.name AS NVARCHAR(MAX))"},
+	}
+	for _, tt := range tests {
+		d := LookupDialect(tt.dialect)
+		if got := d.ILikeExpression("users.name", "?"); got != tt.ilike {
+			t.Errorf("%s: expected %q, got %q", tt.dialect, tt.ilike, got)
+		}
+		if got := d.CastToText("users.name"); got != tt.cast {
+			t.Errorf("%s: expected %q, got %q", tt.dialect, tt.cast, got)
+		}
+	}
+}
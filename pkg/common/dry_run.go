@@ -0,0 +1,405 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DryRunHeader makes a request build, validate and hook its statements without executing them
+const DryRunHeader = "X-Dry-Run"
+
+// IsDryRun reports whether an X-Dry-Run header value asks for a dry run
+func IsDryRun(header string) bool {
+	return strings.EqualFold(header, "true") || header == "1"
+}
+
+// DryRunStatement is a statement of a dry run with its bind parameters
+type DryRunStatement struct {
+	SQL    string        `json:"sql"`
+	Params []interface{} `json:"params"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// DryRunResult is the response of a dry run
+type DryRunResult struct {
+	DryRun     bool              `json:"dry_run"`
+	Statements []DryRunStatement `json:"statements"`
+}
+
+// DryRunRecorder collects the statements of a dry run
+type DryRunRecorder struct {
+	mu         sync.Mutex
+	statements []DryRunStatement
+}
+
+// Statements returns the recorded statements in execution order
+func (r *DryRunRecorder) Statements() []DryRunStatement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]DryRunStatement{}, r.statements...)
+}
+
+func (r *DryRunRecorder) record(query string, params []interface{}, err error) {
+	statement := DryRunStatement{SQL: query, Params: params}
+	if statement.Params == nil {
+		statement.Params = []interface{}{}
+	}
+	if err != nil {
+		statement.Error = err.Error()
+	}
+	r.mu.Lock()
+	r.statements = append(r.statements, statement)
+	r.mu.Unlock()
+}
+
+func (r *DryRunRecorder) recordQuery(query interface {
+	ToSQL() (string, []interface{}, error)
+}) {
+	r.record(query.ToSQL())
+}
+
+// NewDryRunDatabase wraps db so that queries are rendered with ToSQL and recorded instead of
+// executed. Reads return no rows, writes affect no rows and transactions are not started.
+func NewDryRunDatabase(db Database) (Database, *DryRunRecorder) {
+	recorder := &DryRunRecorder{}
+	return &dryRunDatabase{db: db, recorder: recorder}, recorder
+}
+
+type dryRunDatabase struct {
+	db       Database
+	recorder *DryRunRecorder
+}
+
+func (d *dryRunDatabase) NewSelect() SelectQuery {
+	return &dryRunSelectQuery{query: d.db.NewSelect(), recorder: d.recorder}
+}
+
+func (d *dryRunDatabase) NewInsert() InsertQuery {
+	return &dryRunInsertQuery{query: d.db.NewInsert(), recorder: d.recorder}
+}
+
+func (d *dryRunDatabase) NewUpdate() UpdateQuery {
+	return &dryRunUpdateQuery{query: d.db.NewUpdate(), recorder: d.recorder}
+}
+
+func (d *dryRunDatabase) NewDelete() DeleteQuery {
+	return &dryRunDeleteQuery{query: d.db.NewDelete(), recorder: d.recorder}
+}
+
+func (d *dryRunDatabase) Exec(ctx context.Context, query string, args ...interface{}) (Result, error) {
+	d.recorder.record(query, args, nil)
+	return dryRunResult{}, nil
+}
+
+func (d *dryRunDatabase) Query(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	d.recorder.record(query, args, nil)
+	return nil
+}
+
+func (d *dryRunDatabase) BeginTx(ctx context.Context) (Database, error) {
+	return d, nil
+}
+
+func (d *dryRunDatabase) CommitTx(ctx context.Context) error {
+	return nil
+}
+
+func (d *dryRunDatabase) RollbackTx(ctx context.Context) error {
+	return nil
+}
+
+func (d *dryRunDatabase) RunInTransaction(ctx context.Context, fn func(Database) error) error {
+	return fn(d)
+}
+
+func (d *dryRunDatabase) Dialect() Dialect {
+	return d.db.Dialect()
+}
+
+// dryRunResult is the result of a write that was not executed
+type dryRunResult struct{}
+
+func (dryRunResult) RowsAffected() int64 {
+	return 0
+}
+
+func (dryRunResult) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+// dryRunSelectQuery records the statement of a SelectQuery when it would execute
+type dryRunSelectQuery struct {
+	query    SelectQuery
+	recorder *DryRunRecorder
+}
+
+func (q *dryRunSelectQuery) wrap(query SelectQuery) SelectQuery {
+	q.query = query
+	return q
+}
+
+func (q *dryRunSelectQuery) Model(model interface{}) SelectQuery {
+	return q.wrap(q.query.Model(model))
+}
+
+func (q *dryRunSelectQuery) Table(table string) SelectQuery {
+	return q.wrap(q.query.Table(table))
+}
+
+func (q *dryRunSelectQuery) Column(columns ...string) SelectQuery {
+	return q.wrap(q.query.Column(columns...))
+}
+
+func (q *dryRunSelectQuery) ColumnExpr(query string, args ...interface{}) SelectQuery {
+	return q.wrap(q.query.ColumnExpr(query, args...))
+}
+
+func (q *dryRunSelectQuery) Where(query string, args ...interface{}) SelectQuery {
+	return q.wrap(q.query.Where(query, args...))
+}
+
+func (q *dryRunSelectQuery) WhereOr(query string, args ...interface{}) SelectQuery {
+	return q.wrap(q.query.WhereOr(query, args...))
+}
+
+func (q *dryRunSelectQuery) Join(query string, args ...interface{}) SelectQuery {
+	return q.wrap(q.query.Join(query, args...))
+}
+
+func (q *dryRunSelectQuery) LeftJoin(query string, args ...interface{}) SelectQuery {
+	return q.wrap(q.query.LeftJoin(query, args...))
+}
+
+func (q *dryRunSelectQuery) Preload(relation string, conditions ...interface{}) SelectQuery {
+	return q.wrap(q.query.Preload(relation, conditions...))
+}
+
+func (q *dryRunSelectQuery) PreloadRelation(relation string, apply ...func(SelectQuery) SelectQuery) SelectQuery {
+	return q.wrap(q.query.PreloadRelation(relation, apply...))
+}
+
+func (q *dryRunSelectQuery) JoinRelation(relation string, apply ...func(SelectQuery) SelectQuery) SelectQuery {
+	return q.wrap(q.query.JoinRelation(relation, apply...))
+}
+
+// ScopeTenantRelations implements TenantRelationScoper for the wrapped query
+func (q *dryRunSelectQuery) ScopeTenantRelations(scope *TenantScope) bool {
+	scoper, ok := q.query.(TenantRelationScoper)
+	return ok && scoper.ScopeTenantRelations(scope)
+}
+
+func (q *dryRunSelectQuery) Order(order string) SelectQuery {
+	return q.wrap(q.query.Order(order))
+}
+
+func (q *dryRunSelectQuery) OrderExpr(order string, args ...interface{}) SelectQuery {
+	return q.wrap(q.query.OrderExpr(order, args...))
+}
+
+func (q *dryRunSelectQuery) Limit(n int) SelectQuery {
+	return q.wrap(q.query.Limit(n))
+}
+
+func (q *dryRunSelectQuery) Offset(n int) SelectQuery {
+	return q.wrap(q.query.Offset(n))
+}
+
+func (q *dryRunSelectQuery) Group(group string) SelectQuery {
+	return q.wrap(q.query.Group(group))
+}
+
+func (q *dryRunSelectQuery) Having(having string, args ...interface{}) SelectQuery {
+	return q.wrap(q.query.Having(having, args...))
+}
+
+func (q *dryRunSelectQuery) Distinct() SelectQuery {
+	return q.wrap(q.query.Distinct())
+}
+
+func (q *dryRunSelectQuery) DistinctOn(columns ...string) SelectQuery {
+	return q.wrap(q.query.DistinctOn(columns...))
+}
+
+func (q *dryRunSelectQuery) Scan(ctx context.Context, dest interface{}) error {
+	q.recorder.recordQuery(q.query)
+	return nil
+}
+
+func (q *dryRunSelectQuery) ScanModel(ctx context.Context) error {
+	q.recorder.recordQuery(q.query)
+	return nil
+}
+
+// Count is not recorded; the SELECT of the following Scan shows the filters
+func (q *dryRunSelectQuery) Count(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (q *dryRunSelectQuery) Exists(ctx context.Context) (bool, error) {
+	return false, nil
+}
+
+func (q *dryRunSelectQuery) ToSQL() (string, []interface{}, error) {
+	return q.query.ToSQL()
+}
+
+// dryRunInsertQuery records the statement of an InsertQuery instead of executing it
+type dryRunInsertQuery struct {
+	query    InsertQuery
+	recorder *DryRunRecorder
+}
+
+func (q *dryRunInsertQuery) wrap(query InsertQuery) InsertQuery {
+	q.query = query
+	return q
+}
+
+func (q *dryRunInsertQuery) Model(model interface{}) InsertQuery {
+	return q.wrap(q.query.Model(model))
+}
+
+func (q *dryRunInsertQuery) Table(table string) InsertQuery {
+	return q.wrap(q.query.Table(table))
+}
+
+func (q *dryRunInsertQuery) Value(column string, value interface{}) InsertQuery {
+	return q.wrap(q.query.Value(column, value))
+}
+
+func (q *dryRunInsertQuery) OnConflict(action string) InsertQuery {
+	return q.wrap(q.query.OnConflict(action))
+}
+
+func (q *dryRunInsertQuery) Returning(columns ...string) InsertQuery {
+	return q.wrap(q.query.Returning(columns...))
+}
+
+func (q *dryRunInsertQuery) Bulk(models interface{}, chunkSize int) InsertQuery {
+	return q.wrap(q.query.Bulk(models, chunkSize))
+}
+
+func (q *dryRunInsertQuery) Exec(ctx context.Context) (Result, error) {
+	q.recorder.recordQuery(q.query)
+	return dryRunResult{}, nil
+}
+
+func (q *dryRunInsertQuery) ToSQL() (string, []interface{}, error) {
+	return q.query.ToSQL()
+}
+
+// dryRunUpdateQuery records the statement of an UpdateQuery instead of executing it
+type dryRunUpdateQuery struct {
+	query    UpdateQuery
+	recorder *DryRunRecorder
+}
+
+func (q *dryRunUpdateQuery) wrap(query UpdateQuery) UpdateQuery {
+	q.query = query
+	return q
+}
+
+func (q *dryRunUpdateQuery) Model(model interface{}) UpdateQuery {
+	return q.wrap(q.query.Model(model))
+}
+
+func (q *dryRunUpdateQuery) Table(table string) UpdateQuery {
+	return q.wrap(q.query.Table(table))
+}
+
+func (q *dryRunUpdateQuery) Set(column string, value interface{}) UpdateQuery {
+	return q.wrap(q.query.Set(column, value))
+}
+
+func (q *dryRunUpdateQuery) SetMap(values map[string]interface{}) UpdateQuery {
+	return q.wrap(q.query.SetMap(values))
+}
+
+func (q *dryRunUpdateQuery) Where(query string, args ...interface{}) UpdateQuery {
+	return q.wrap(q.query.Where(query, args...))
+}
+
+func (q *dryRunUpdateQuery) Returning(columns ...string) UpdateQuery {
+	return q.wrap(q.query.Returning(columns...))
+}
+
+func (q *dryRunUpdateQuery) Exec(ctx context.Context) (Result, error) {
+	q.recorder.recordQuery(q.query)
+	return dryRunResult{}, nil
+}
+
+func (q *dryRunUpdateQuery) ToSQL() (string, []interface{}, error) {
+	return q.query.ToSQL()
+}
+
+// dryRunDeleteQuery records the statement of a DeleteQuery instead of executing it
+type dryRunDeleteQuery struct {
+	query    DeleteQuery
+	recorder *DryRunRecorder
+}
+
+func (q *dryRunDeleteQuery) wrap(query DeleteQuery) DeleteQuery {
+	q.query = query
+	return q
+}
+
+func (q *dryRunDeleteQuery) Model(model interface{}) DeleteQuery {
+	return q.wrap(q.query.Model(model))
+}
+
+func (q *dryRunDeleteQuery) Table(table string) DeleteQuery {
+	return q.wrap(q.query.Table(table))
+}
+
+func (q *dryRunDeleteQuery) Where(query string, args ...interface{}) DeleteQuery {
+	return q.wrap(q.query.Where(query, args...))
+}
+
+func (q *dryRunDeleteQuery) Exec(ctx context.Context) (Result, error) {
+	q.recorder.recordQuery(q.query)
+	return dryRunResult{}, nil
+}
+
+func (q *dryRunDeleteQuery) ToSQL() (string, []interface{}, error) {
+	return q.query.ToSQL()
+}
+
+// DryRunWriter holds back the response of a dry run request. Error responses are passed on by
+// Finish, other responses are replaced by the recorded statements.
+type DryRunWriter struct {
+	ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// NewDryRunWriter wraps the response writer of a dry run request
+func NewDryRunWriter(w ResponseWriter) *DryRunWriter {
+	return &DryRunWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (w *DryRunWriter) WriteHeader(statusCode int) {
+	w.status = statusCode
+}
+
+func (w *DryRunWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *DryRunWriter) WriteJSON(data interface{}) error {
+	return json.NewEncoder(&w.body).Encode(data)
+}
+
+// Finish writes the held back error response, or the statements of the recorder
+func (w *DryRunWriter) Finish(recorder *DryRunRecorder) error {
+	if w.status >= http.StatusBadRequest {
+		w.ResponseWriter.WriteHeader(w.status)
+		_, err := w.ResponseWriter.Write(w.body.Bytes())
+		return err
+	}
+	w.ResponseWriter.SetHeader("Content-Type", "application/json")
+	w.ResponseWriter.WriteHeader(http.StatusOK)
+	return w.ResponseWriter.WriteJSON(DryRunResult{DryRun: true, Statements: recorder.Statements()})
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type recordedWriter struct {
+	status  int
+	body    []byte
+	headers map[string]string
+}
+
+func (w *recordedWriter) SetHeader(key, value string) { w.headers[key] = value }
+func (w *recordedWriter) WriteHeader(statusCode int)  { w.status = statusCode }
+func (w *recordedWriter) Write(data []byte) (int, error) {
+	w.body = append(w.body, data...)
+	return len(data), nil
+}
+func (w *recordedWriter) WriteJSON(data interface{}) error {
+	body, err := json.Marshal(data)
+	w.body = append(w.body, body...)
+	return err
+}
+
+func TestIsDryRun(t *testing.T) {
+	for header, want := range map[string]bool{"true": true, "TRUE": true, "1": true, "": false, "false": false, "yes": false} {
+		if got := IsDryRun(header); got != want {
+			t.Errorf("IsDryRun(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
+
+func TestDryRunWriter(t *testing.T) {
+	recorder := &DryRunRecorder{}
+	recorder.record("SELECT * FROM users WHERE id = ?", []interface{}{1}, nil)
+	recorder.record("", nil, errors.New("no table"))
+
+	out := &recordedWriter{headers: map[string]string{}}
+	w := NewDryRunWriter(out)
+	_ = w.WriteJSON(map[string]interface{}{"data": []int{}})
+	if err := w.Finish(recorder); err != nil {
+		t.Fatal(err)
+	}
+	if out.status != http.StatusOK {
+		t.Errorf("expected 200, got %d", out.status)
+	}
+	var result DryRunResult
+	if err := json.Unmarshal(out.body, &result); err != nil {
+		t.Fatal(err)
+	}
+	if !result.DryRun || len(result.Statements) != 2 || result.Statements[1].Error != "no table" || len(result.Statements[1].Params) != 0 {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	out = &recordedWriter{headers: map[string]string{}}
+	w = NewDryRunWriter(out)
+	w.WriteHeader(http.StatusBadRequest)
+	_, _ = w.Write([]byte(`{"error":"invalid"}`))
+	if err := w.Finish(recorder); err != nil {
+		t.Fatal(err)
+	}
+	if out.status != http.StatusBadRequest || string(out.body) != `{"error":"invalid"}` {
+		t.Errorf("expected the error response, got %d %s", out.status, out.body)
+	}
+}
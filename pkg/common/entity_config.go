@@ -0,0 +1,162 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// EntityConfig overrides the defaults of a handler for one entity. Handle applies it before
+// dispatching a request.
+type EntityConfig struct {
+	// Operations lists the allowed operations: read, create, update, delete and batch.
+	// Empty allows every operation.
+	Operations []string
+	// Roles lists the roles of which the user needs at least one, see HandlerConfig.UserRoles.
+	// Empty allows every user.
+	Roles []string
+
+	SkipCount        bool // Never count the total of reads
+	MaxLimit         int  // Largest limit of a read, replacing the MaxLimit of the handler
+	DisableNestedCUD bool // Reject creates and updates with nested relations
+
+	// CountStrategy counts the total of list reads exactly, from the planner estimate or from
+	// a cache kept for CountCacheTTL, DefaultCountCacheTTL when 0, for tables too large to
+	// COUNT(*) on every read
+	CountStrategy CountStrategy
+	CountCacheTTL time.Duration
+
+	// OwnerColumn holds the user owning a record, e.g. owner_id. X-Only-Mine restricts reads to
+	// the records of the user of the request, see HandlerConfig.OwnerFilter.
+	OwnerColumn string
+
+	// IDGenerator sets the primary key of created records that don't carry one, e.g. UUIDv7(),
+	// ULID(), a Snowflake or Sequence("order_ids"), see HandlerConfig.GenerateIDs
+	IDGenerator IDGenerator
+
+	// Serializer transforms the records of responses and payloads, e.g. a FieldMapping renaming
+	// fields and dropping internal columns, see HandlerConfig.Serialize
+	Serializer Serializer
+}
+
+// EntityAccessError is the rejection of a request by the EntityConfig of its entity
+type EntityAccessError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *EntityAccessError) Error() string {
+	return e.Message
+}
+
+// Entity returns the overrides of an entity, looked up by "schema.entity" and then by entity
+func (c HandlerConfig) Entity(schema, entity string) EntityConfig {
+	if schema != "" {
+		if config, ok := c.Entities[schema+"."+entity]; ok {
+			return config
+		}
+	}
+	return c.Entities[entity]
+}
+
+// ForEntity returns the config with the overrides of an entity applied to its guardrails
+func (c HandlerConfig) ForEntity(schema, entity string) HandlerConfig {
+	if override := c.Entity(schema, entity); override.MaxLimit > 0 {
+		c.MaxLimit = override.MaxLimit
+		if c.DefaultLimit > c.MaxLimit {
+			c.DefaultLimit = c.MaxLimit
+		}
+	}
+	return c
+}
+
+// CheckEntityAccess checks an operation on an entity against its allowed operations and
+// required roles. It returns nil when the request may proceed.
+func (c HandlerConfig) CheckEntityAccess(ctx context.Context, schema, entity, operation string) *EntityAccessError {
+	config := c.Entity(schema, entity)
+	if len(config.Operations) > 0 && !containsFold(config.Operations, operation) {
+		return &EntityAccessError{
+			Status:  http.StatusMethodNotAllowed,
+			Code:    "operation_not_allowed",
+			Message: fmt.Sprintf("Operation %s is not allowed on %s", operation, entity),
+		}
+	}
+	if len(config.Roles) > 0 {
+		var roles []string
+		if c.UserRoles != nil {
+			roles = c.UserRoles(ctx)
+		}
+		for _, role := range roles {
+			if containsFold(config.Roles, role) {
+				return nil
+			}
+		}
+		return &EntityAccessError{
+			Status:  http.StatusForbidden,
+			Code:    "forbidden",
+			Message: fmt.Sprintf("Access to %s requires one of the roles %s", entity, strings.Join(config.Roles, ", ")),
+		}
+	}
+	return nil
+}
+
+// CheckNestedCUD rejects data with nested relations when the entity disables nested CUD.
+// data is a record or a list of records.
+func (c HandlerConfig) CheckNestedCUD(schema, entity string, data, model interface{}, provider RelationshipInfoProvider) *EntityAccessError {
+	if !c.Entity(schema, entity).DisableNestedCUD {
+		return nil
+	}
+	records := []interface{}{data}
+	switch v := data.(type) {
+	case []interface{}:
+		records = v
+	case []map[string]interface{}:
+		records = make([]interface{}, 0, len(v))
+		for _, record := range v {
+			records = append(records, record)
+		}
+	}
+	modelType := reflect.TypeOf(model)
+	for modelType != nil && (modelType.Kind() == reflect.Ptr || modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Array) {
+		modelType = modelType.Elem()
+	}
+	for _, record := range records {
+		if record, ok := record.(map[string]interface{}); ok && hasNestedRelations(record, modelType, provider) {
+			return &EntityAccessError{
+				Status:  http.StatusBadRequest,
+				Code:    "nested_cud_disabled",
+				Message: fmt.Sprintf("Nested relations can't be written through %s", entity),
+			}
+		}
+	}
+	return nil
+}
+
+// hasNestedRelations reports whether a record carries a _request or the data of a relation
+func hasNestedRelations(record map[string]interface{}, modelType reflect.Type, provider RelationshipInfoProvider) bool {
+	if _, ok := record["_request"]; ok {
+		return true
+	}
+	for key, value := range record {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}, []map[string]interface{}:
+			if provider.GetRelationshipInfo(modelType, key) != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(strings.TrimSpace(candidate), value) {
+			return true
+		}
+	}
+	return false
+}
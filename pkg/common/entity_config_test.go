@@ -0,0 +1,124 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type entityRoleKey struct{}
+
+func TestCheckEntityAccess(t *testing.T) {
+	config := HandlerConfig{
+		Entities: map[string]EntityConfig{
+			"audit_logs":    {Operations: []string{"read"}},
+			"hr.salaries":   {Roles: []string{"hr", "admin"}},
+			"salaries":      {Operations: []string{"read", "create"}},
+			"public_values": {},
+		},
+		UserRoles: func(ctx context.Context) []string {
+			roles, _ := ctx.Value(entityRoleKey{}).([]string)
+			return roles
+		},
+	}
+	admin := context.WithValue(context.Background(), entityRoleKey{}, []string{"viewer", "Admin"})
+	viewer := context.WithValue(context.Background(), entityRoleKey{}, []string{"viewer"})
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		schema    string
+		entity    string
+		operation string
+		status    int
+	}{
+		{"allowed operation", viewer, "", "audit_logs", "read", 0},
+		{"operation not allowed", viewer, "", "audit_logs", "delete", http.StatusMethodNotAllowed},
+		{"required role", admin, "hr", "salaries", "delete", 0},
+		{"missing role", viewer, "hr", "salaries", "read", http.StatusForbidden},
+		{"entity fallback", viewer, "other", "salaries", "update", http.StatusMethodNotAllowed},
+		{"no overrides", viewer, "", "orders", "delete", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := config.CheckEntityAccess(tt.ctx, tt.schema, tt.entity, tt.operation)
+			if tt.status == 0 {
+				assert.Nil(t, err)
+				return
+			}
+			require.NotNil(t, err)
+			assert.Equal(t, tt.status, err.Status)
+		})
+	}
+
+	config.UserRoles = nil
+	assert.NotNil(t, config.CheckEntityAccess(admin, "hr", "salaries", "read"), "roles can't be checked without UserRoles")
+}
+
+func TestForEntity(t *testing.T) {
+	config := HandlerConfig{DefaultLimit: 50, MaxLimit: 500, Entities: map[string]EntityConfig{"events": {MaxLimit: 20}}}
+	assert.Equal(t, 20, config.ForEntity("", "events").MaxLimit)
+	assert.Equal(t, 20, config.ForEntity("", "events").DefaultLimit)
+	assert.Equal(t, 500, config.ForEntity("", "users").MaxLimit)
+	assert.Equal(t, 500, config.MaxLimit, "the handler config is unchanged")
+}
+
+type nestedOwner struct {
+	ID    int64        `json:"id"`
+	Items []nestedItem `json:"items"`
+}
+
+type nestedItem struct {
+	ID int64 `json:"id"`
+}
+
+type nestedRelations struct{}
+
+func (nestedRelations) GetRelationshipInfo(modelType reflect.Type, name string) *RelationshipInfo {
+	if name == "items" {
+		return &RelationshipInfo{FieldName: "Items", JSONName: "items", RelationType: "hasMany"}
+	}
+	return nil
+}
+
+func TestCheckNestedCUD(t *testing.T) {
+	config := HandlerConfig{Entities: map[string]EntityConfig{"owners": {DisableNestedCUD: true}}}
+	nested := map[string]interface{}{"id": 1, "items": []interface{}{map[string]interface{}{"id": 2}}}
+	flat := map[string]interface{}{"id": 1}
+
+	assert.NotNil(t, config.CheckNestedCUD("", "owners", nested, nestedOwner{}, nestedRelations{}))
+	assert.NotNil(t, config.CheckNestedCUD("", "owners", []interface{}{flat, nested}, nestedOwner{}, nestedRelations{}))
+	assert.Nil(t, config.CheckNestedCUD("", "owners", flat, nestedOwner{}, nestedRelations{}))
+	assert.Nil(t, config.CheckNestedCUD("", "others", nested, nestedOwner{}, nestedRelations{}))
+}
+
+func TestOwnerFilter(t *testing.T) {
+	config := HandlerConfig{
+		Entities: map[string]EntityConfig{"tasks": {OwnerColumn: "owner_id"}},
+		AuditUser: func(ctx context.Context) (string, bool) {
+			user, ok := ctx.Value(ownerKey{}).(string)
+			return user, ok
+		},
+	}
+	ctx := context.WithValue(context.Background(), ownerKey{}, "42")
+
+	if group, err := config.OwnerFilter(ctx, "", "tasks", ""); group != nil || err != nil {
+		t.Errorf("expected no filter without the header, got %+v, %v", group, err)
+	}
+	group, err := config.OwnerFilter(ctx, "", "tasks", "true")
+	if err != nil || len(group.Filters) != 1 || group.Filters[0].Column != "owner_id" || group.Filters[0].Value != "42" {
+		t.Errorf("unexpected filter %+v, %v", group, err)
+	}
+	if _, err := config.OwnerFilter(ctx, "", "notes", "true"); err == nil || err.Status != http.StatusBadRequest {
+		t.Errorf("expected 400 for an entity without owner column, got %v", err)
+	}
+	if _, err := config.OwnerFilter(context.Background(), "", "tasks", "1"); err == nil || err.Status != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a user, got %v", err)
+	}
+}
+
+type ownerKey struct{}
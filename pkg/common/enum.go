@@ -0,0 +1,132 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// InvalidEnumValue is the error code of a value outside of the allowed values of a column
+const InvalidEnumValue = "invalid_enum_value"
+
+// EnumError reports a value outside of the allowed values of a column
+type EnumError struct {
+	Column  string
+	Value   interface{}
+	Allowed []string
+}
+
+func (e *EnumError) Error() string {
+	return fmt.Sprintf("invalid value %v for %s, allowed values are %s", e.Value, e.Column, strings.Join(e.Allowed, ", "))
+}
+
+// EnumValues returns the allowed values of a field, declared with `resolvespec:"enum:a,b,c"` or
+// with the oneof rule of a validator tag, `validate:"oneof=a b c"`. It returns nil for fields
+// without allowed values.
+func EnumValues(field reflect.StructField) []string {
+	for _, option := range strings.Split(field.Tag.Get("resolvespec"), ";") {
+		option = strings.TrimSpace(option)
+		if strings.HasPrefix(option, "enum:") {
+			return splitEnum(strings.TrimPrefix(option, "enum:"), ",")
+		}
+	}
+	for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+		rule = strings.TrimSpace(rule)
+		if strings.HasPrefix(rule, "oneof=") {
+			return splitEnum(strings.TrimPrefix(rule, "oneof="), " ")
+		}
+	}
+	return nil
+}
+
+func splitEnum(list, separator string) []string {
+	values := make([]string, 0)
+	for _, value := range strings.Split(list, separator) {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// ModelEnums returns the allowed values of the enum columns of model by column name. Columns are
+// also listed under their JSON name when it differs.
+func ModelEnums(model interface{}) map[string][]string {
+	enums := make(map[string][]string)
+	info := reflection.Describe(model)
+	if info == nil {
+		return enums
+	}
+
+	for _, field := range info.Fields {
+		values := EnumValues(info.Type.FieldByIndex(field.Index))
+		if !field.Exported || values == nil {
+			continue
+		}
+		if field.Column != "" {
+			enums[field.Column] = values
+		}
+		if field.JSONName != "" {
+			enums[field.JSONName] = values
+		}
+	}
+	return enums
+}
+
+// ValidateEnums checks the values of the enum columns of model in the records of data, a map or
+// a list of maps, and returns an *EnumError for the first value that isn't allowed. Null values
+// are left to the nullability of the column.
+func ValidateEnums(model interface{}, data interface{}) error {
+	enums := ModelEnums(model)
+	if len(enums) == 0 {
+		return nil
+	}
+
+	validate := func(record map[string]interface{}) error {
+		for key, value := range record {
+			allowed, ok := enums[key]
+			if !ok || value == nil {
+				continue
+			}
+			if !enumAllows(allowed, value) {
+				return &EnumError{Column: key, Value: value, Allowed: allowed}
+			}
+		}
+		return nil
+	}
+
+	switch records := data.(type) {
+	case map[string]interface{}:
+		return validate(records)
+	case []map[string]interface{}:
+		for _, record := range records {
+			if err := validate(record); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range records {
+			if record, ok := item.(map[string]interface{}); ok {
+				if err := validate(record); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func enumAllows(allowed []string, value interface{}) bool {
+	text := fmt.Sprint(value)
+	for _, candidate := range allowed {
+		if candidate == text {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type enumTask struct {
+	ID       int64  `json:"id"`
+	Status   string `json:"status" resolvespec:"enum:active,inactive,archived"`
+	Priority string `json:"priority" gorm:"column:prio" validate:"required,oneof=low high"`
+	Title    string `json:"title"`
+}
+
+func TestEnumValues(t *testing.T) {
+	taskType := reflect.TypeOf(enumTask{})
+	tests := []struct {
+		field string
+		want  []string
+	}{
+		{"Status", []string{"active", "inactive", "archived"}},
+		{"Priority", []string{"low", "high"}},
+		{"Title", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			field, _ := taskType.FieldByName(tt.field)
+			assert.Equal(t, tt.want, EnumValues(field))
+		})
+	}
+}
+
+func TestValidateEnums(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   interface{}
+		column string
+	}{
+		{"allowed values", map[string]interface{}{"status": "active", "priority": "low"}, ""},
+		{"null value", map[string]interface{}{"status": nil}, ""},
+		{"column name", map[string]interface{}{"prio": "urgent"}, "prio"},
+		{"invalid value", map[string]interface{}{"status": "deleted", "title": "x"}, "status"},
+		{"list of records", []interface{}{map[string]interface{}{"status": "active"}, map[string]interface{}{"status": "gone"}}, "status"},
+		{"list of maps", []map[string]interface{}{{"priority": "medium"}}, "priority"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEnums(enumTask{}, tt.data)
+			if tt.column == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var enumErr *EnumError
+			require.ErrorAs(t, err, &enumErr)
+			assert.Equal(t, tt.column, enumErr.Column)
+		})
+	}
+}
+
+func TestResolveErrorEnum(t *testing.T) {
+	err := &EnumError{Column: "status", Value: "deleted", Allowed: []string{"active", "archived"}}
+	resolved, expose := HandlerConfig{ErrorDetail: ErrorDetailProduction}.ResolveError(http.StatusBadRequest, "create_error", "Error creating record", err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resolved.Status)
+	assert.Equal(t, InvalidEnumValue, resolved.Code)
+	assert.Equal(t, "status", resolved.Column)
+	assert.Equal(t, "invalid value deleted for status, allowed values are active, archived", resolved.Message)
+	assert.False(t, expose)
+}
@@ -0,0 +1,84 @@
+package common
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrorDetailLevel controls how much of an internal error reaches the client
+type ErrorDetailLevel int
+
+const (
+	// ErrorDetailDevelopment returns the error text of every error response
+	ErrorDetailDevelopment ErrorDetailLevel = iota
+	// ErrorDetailProduction returns only the code and message of server errors and mapped
+	// errors; the error text is logged with the request id instead
+	ErrorDetailProduction
+)
+
+// MappedError is the client facing form of an error. Zero fields keep the values of the handler.
+type MappedError struct {
+	Status  int
+	Code    string
+	Message string
+	Column  string // Offending column, e.g. of a constraint violation
+}
+
+// ErrorMapper maps an error, e.g. a database unique or foreign key violation, to a client
+// facing error. It returns false for errors it doesn't know.
+type ErrorMapper func(err error) (MappedError, bool)
+
+// ResolveError applies the error policy to an error response. Errors are mapped with MapError
+// and otherwise, for constraint violations, to 409 or 422 with the codes of AsConstraintError,
+// and for values outside of an enum or writes of protected columns, to 422 with InvalidEnumValue
+// or ProtectedColumn.
+// It returns the response error and whether the error text may be included in it.
+func (c HandlerConfig) ResolveError(status int, code, message string, err error) (MappedError, bool) {
+	resolved := MappedError{Status: status, Code: code, Message: message}
+	var m MappedError
+	mapped := false
+	if err != nil && c.MapError != nil {
+		m, mapped = c.MapError(err)
+	}
+	if !mapped {
+		var enumErr *EnumError
+		var columnErr *WriteColumnError
+		if constraintErr, ok := AsConstraintError(err); ok {
+			m = MappedError{Status: constraintErr.Status(), Code: constraintErr.Code, Message: constraintErr.Message(), Column: constraintErr.Column}
+			mapped = true
+		} else if errors.As(err, &enumErr) {
+			m = MappedError{Status: http.StatusUnprocessableEntity, Code: InvalidEnumValue, Message: enumErr.Error(), Column: enumErr.Column}
+			mapped = true
+		} else if errors.As(err, &columnErr) {
+			m = MappedError{Status: http.StatusUnprocessableEntity, Code: ProtectedColumn, Message: columnErr.Error(), Column: columnErr.Column}
+			mapped = true
+		}
+	}
+	if mapped {
+		if m.Status != 0 {
+			resolved.Status = m.Status
+		}
+		if m.Code != "" {
+			resolved.Code = m.Code
+		}
+		if m.Message != "" {
+			resolved.Message = m.Message
+		}
+		resolved.Column = m.Column
+	}
+	if c.ErrorDetail == ErrorDetailDevelopment {
+		return resolved, true
+	}
+	return resolved, !mapped && resolved.Status < http.StatusInternalServerError
+}
+
+// ItemError returns the client facing code and message of a failed item of a batch or
+// transaction under the error policy, like ResolveError for a server error. The message is the
+// error text unless the policy hides it.
+func (c HandlerConfig) ItemError(err error) (code, message string) {
+	resolved, expose := c.ResolveError(http.StatusInternalServerError, "item_failed", "The item failed", err)
+	if expose {
+		return resolved.Code, err.Error()
+	}
+	return resolved.Code, resolved.Message
+}
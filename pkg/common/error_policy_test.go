@@ -0,0 +1,44 @@
+package common
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestResolveError(t *testing.T) {
+	errDuplicate := errors.New("duplicate key value violates unique constraint")
+	mapper := func(err error) (MappedError, bool) {
+		if err == errDuplicate {
+			return MappedError{Status: http.StatusConflict, Code: "duplicate"}, true
+		}
+		return MappedError{}, false
+	}
+
+	tests := []struct {
+		name    string
+		config  HandlerConfig
+		status  int
+		err     error
+		want    int
+		code    string
+		message string
+		expose  bool
+	}{
+		{"development exposes server errors", HandlerConfig{}, 500, errors.New("boom"), 500, "create_error", "Error creating record", true},
+		{"production hides server errors", HandlerConfig{ErrorDetail: ErrorDetailProduction}, 500, errors.New("boom"), 500, "create_error", "Error creating record", false},
+		{"production exposes client errors", HandlerConfig{ErrorDetail: ErrorDetailProduction}, 400, errors.New("bad limit"), 400, "create_error", "Error creating record", true},
+		{"mapped errors keep the handler message", HandlerConfig{MapError: mapper}, 500, errDuplicate, 409, "duplicate", "Error creating record", true},
+		{"production hides mapped errors", HandlerConfig{ErrorDetail: ErrorDetailProduction, MapError: mapper}, 500, errDuplicate, 409, "duplicate", "Error creating record", false},
+		{"unmapped errors", HandlerConfig{MapError: mapper}, 500, errors.New("boom"), 500, "create_error", "Error creating record", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, expose := tt.config.ResolveError(tt.status, "create_error", "Error creating record", tt.err)
+			status, code, message := resolved.Status, resolved.Code, resolved.Message
+			if status != tt.want || code != tt.code || message != tt.message || expose != tt.expose {
+				t.Errorf("got %d %q %q %v, want %d %q %q %v", status, code, message, expose, tt.want, tt.code, tt.message, tt.expose)
+			}
+		})
+	}
+}
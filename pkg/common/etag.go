@@ -0,0 +1,47 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// ComputeETag returns a strong entity tag for a value, derived from a hash of its
+// JSON encoding. Equal results always produce the same tag. The encoding is hashed as it
+// is written, so large result sets are streamed as by EncodeJSON.
+func ComputeETag(value interface{}) (string, error) {
+	hash := sha256.New()
+	if err := EncodeJSON(hash, value); err != nil {
+		return "", err
+	}
+	sum := hash.Sum(nil)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// MatchETag reports whether an If-None-Match or If-Match header value matches etag.
+// The header may list several tags or be "*". With weak comparison (If-None-Match)
+// a W/ prefix is ignored; with strong comparison (If-Match) weak tags never match.
+func MatchETag(header, etag string, weak bool) bool {
+	header = strings.TrimSpace(header)
+	if header == "" || etag == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package common
+
+import "testing"
+
+func TestComputeETag(t *testing.T) {
+	a, err := ComputeETag(map[string]interface{}{"id": 1, "name": "a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := ComputeETag(map[string]interface{}{"name": "a", "id": 1})
+	c, _ := ComputeETag(map[string]interface{}{"id": 1, "name": "b"})
+
+	if a != b {
+		t.Errorf("equal values produced different tags: %s, %s", a, b)
+	}
+	if a == c {
+		t.Error("different values produced the same tag")
+	}
+	if len(a) != 34 || a[0] != '"' || a[len(a)-1] != '"' {
+		t.Errorf("unexpected tag format: %s", a)
+	}
+
+	if _, err := ComputeETag(make(chan int)); err == nil {
+		t.Error("expected an error for a value that cannot be encoded")
+	}
+}
+
+func TestMatchETag(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		etag   string
+		weak   bool
+		want   bool
+	}{
+		{name: "exact", header: `"abc"`, etag: `"abc"`, want: true},
+		{name: "mismatch", header: `"abc"`, etag: `"def"`, want: false},
+		{name: "list", header: `"x", "abc"`, etag: `"abc"`, want: true},
+		{name: "wildcard", header: "*", etag: `"abc"`, want: true},
+		{name: "empty header", header: "", etag: `"abc"`, want: false},
+		{name: "weak tag with weak comparison", header: `W/"abc"`, etag: `"abc"`, weak: true, want: true},
+		{name: "weak tag with strong comparison", header: `W/"abc"`, etag: `"abc"`, want: false},
+		{name: "unquoted", header: "abc", etag: `"abc"`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchETag(tt.header, tt.etag, tt.weak); got != tt.want {
+				t.Errorf("MatchETag(%q, %q, %v) = %v, want %v", tt.header, tt.etag, tt.weak, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ExplainHeader asks for the query plan of a read: "true" runs EXPLAIN, "analyze" runs
+// EXPLAIN ANALYZE, which also executes the query
+const ExplainHeader = "X-Explain"
+
+// ParseExplain parses an explain option: "true" or "1" explains the query, "analyze" also
+// executes it to report actual timings
+func ParseExplain(value string) (explain, analyze bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "1":
+		return true, false
+	case "analyze":
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// ExplainMetadata is the query plan of a read, returned in the response metadata
+type ExplainMetadata struct {
+	SQL     string                   `json:"sql"`
+	Params  []interface{}            `json:"params"`
+	Analyze bool                     `json:"analyze"`
+	Plan    []map[string]interface{} `json:"plan"`
+}
+
+// ExplainStatement prefixes a statement with the EXPLAIN of the dialect. SQLite has no
+// EXPLAIN ANALYZE and answers with its query plan; SQL Server is not supported.
+func ExplainStatement(dialect, statement string, analyze bool) (string, error) {
+	switch dialect {
+	case "postgres", "mysql":
+		if analyze {
+			return "EXPLAIN ANALYZE " + statement, nil
+		}
+		return "EXPLAIN " + statement, nil
+	case "sqlite":
+		return "EXPLAIN QUERY PLAN " + statement, nil
+	default:
+		return "", fmt.Errorf("EXPLAIN is not supported by dialect %q", dialect)
+	}
+}
+
+// Explain runs EXPLAIN on the statement of query and returns the plan rows
+func Explain(ctx context.Context, db Database, query SelectQuery, analyze bool) (*ExplainMetadata, error) {
+	statement, params, err := query.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	explain, err := ExplainStatement(DialectOf(db).Name, statement, analyze)
+	if err != nil {
+		return nil, err
+	}
+	plan := []map[string]interface{}{}
+	if err := db.Query(ctx, &plan, explain, params...); err != nil {
+		return nil, fmt.Errorf("explain failed: %w", err)
+	}
+	if params == nil {
+		params = []interface{}{}
+	}
+	return &ExplainMetadata{SQL: statement, Params: params, Analyze: analyze, Plan: plan}, nil
+}
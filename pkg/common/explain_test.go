@@ -0,0 +1,44 @@
+package common
+
+import "testing"
+
+func TestParseExplain(t *testing.T) {
+	tests := []struct {
+		value            string
+		explain, analyze bool
+	}{
+		{"", false, false},
+		{"true", true, false},
+		{"1", true, false},
+		{"ANALYZE", true, true},
+		{"false", false, false},
+	}
+	for _, tt := range tests {
+		explain, analyze := ParseExplain(tt.value)
+		if explain != tt.explain || analyze != tt.analyze {
+			t.Errorf("ParseExplain(%q) = %v, %v", tt.value, explain, analyze)
+		}
+	}
+}
+
+func TestExplainStatement(t *testing.T) {
+	tests := []struct {
+		dialect string
+		analyze bool
+		want    string
+	}{
+		{"postgres", false, "EXPLAIN SELECT 1"},
+		{"postgres", true, "EXPLAIN ANALYZE SELECT 1"},
+		{"mysql", true, "EXPLAIN ANALYZE SELECT 1"},
+		{"sqlite", true, "EXPLAIN QUERY PLAN SELECT 1"},
+	}
+	for _, tt := range tests {
+		got, err := ExplainStatement(tt.dialect, "SELECT 1", tt.analyze)
+		if err != nil || got != tt.want {
+			t.Errorf("ExplainStatement(%s, %v) = %q, %v", tt.dialect, tt.analyze, got, err)
+		}
+	}
+	if _, err := ExplainStatement("mssql", "SELECT 1", false); err == nil {
+		t.Error("expected an error for mssql")
+	}
+}
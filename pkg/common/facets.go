@@ -0,0 +1,101 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// FacetsHeader lists the columns of a list read whose values are counted over the filters of
+// the read, e.g. "status,category_id"
+const FacetsHeader = "X-Facets"
+
+// MaxFacets limits the facets of a read
+const MaxFacets = 10
+
+// MaxFacetValues limits the values counted per facet to the most frequent ones
+const MaxFacetValues = 100
+
+// ValidateFacets returns the columns of facets as named by the model columns, without
+// duplicates. Facets of columns the model doesn't have are rejected.
+func ValidateFacets(facets []string, modelColumns []string) ([]string, error) {
+	if len(facets) > MaxFacets {
+		return nil, fmt.Errorf("a read is limited to %d facets", MaxFacets)
+	}
+	validated := make([]string, 0, len(facets))
+	seen := make(map[string]bool, len(facets))
+	for _, facet := range facets {
+		column := matchColumn(modelColumns, facet)
+		if column == "" {
+			return nil, fmt.Errorf("invalid facet column %q", facet)
+		}
+		if !seen[column] {
+			seen[column] = true
+			validated = append(validated, column)
+		}
+	}
+	return validated, nil
+}
+
+// FacetCounts counts the rows of query by the values of column, for at most MaxFacetValues of
+// the most frequent values. query must select from the table of column without joins. Values
+// are keyed by their text; NULL is counted under "".
+func FacetCounts(ctx context.Context, query SelectQuery, column string) (map[string]int64, error) {
+	var rows []map[string]interface{}
+	query = query.
+		ColumnExpr(fmt.Sprintf("%s AS facet_value", column)).
+		ColumnExpr("COUNT(*) AS facet_count").
+		Group(column).
+		Order("facet_count DESC").
+		Order("facet_value ASC").
+		Limit(MaxFacetValues)
+	if err := query.Scan(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to count facet %s: %w", column, err)
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		count, err := facetCount(row["facet_count"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to count facet %s: %w", column, err)
+		}
+		counts[facetKey(row["facet_value"])] += count
+	}
+	return counts, nil
+}
+
+// facetKey returns the text of a facet value
+func facetKey(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// facetCount returns a count as drivers scan it into a map
+func facetCount(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("unexpected count %v of type %T", value, value)
+	}
+}
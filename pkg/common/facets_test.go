@@ -0,0 +1,36 @@
+package common
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFacets(t *testing.T) {
+	facets, err := ValidateFacets([]string{"Status", " category_id ", "status"}, []string{"id", "status", "category_id"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"status", "category_id"}, facets)
+
+	_, err = ValidateFacets([]string{"status; DROP TABLE x"}, []string{"status"})
+	assert.Error(t, err)
+	_, err = ValidateFacets(strings.Split(strings.Repeat("status,", MaxFacets+1), ",")[:MaxFacets+1], []string{"status"})
+	assert.Error(t, err)
+}
+
+func TestFacetKeyAndCount(t *testing.T) {
+	assert.Equal(t, "", facetKey(nil))
+	assert.Equal(t, "open", facetKey([]byte("open")))
+	assert.Equal(t, "3", facetKey(int64(3)))
+	assert.Equal(t, "2024-05-01T10:00:00Z", facetKey(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)))
+
+	for _, value := range []interface{}{int64(7), 7, float64(7), []byte("7"), "7"} {
+		count, err := facetCount(value)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), count)
+	}
+	_, err := facetCount(nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,95 @@
+package common
+
+import (
+	"strings"
+)
+
+// MaxFilterGroupDepth limits how deeply the filter groups of a request may be nested, see
+// HandlerConfig.CheckQueryLimits
+const MaxFilterGroupDepth = 10
+
+// FilterConditionFunc renders a single filter as a SQL condition with ? placeholders.
+// Returning an empty condition skips the filter.
+type FilterConditionFunc func(filter FilterOption) (condition string, args []interface{})
+
+// IsEmpty reports whether the group contains no filters at any depth
+func (g *FilterGroup) IsEmpty() bool {
+	if g == nil {
+		return true
+	}
+	if len(g.Filters) > 0 {
+		return false
+	}
+	for idx := range g.Groups {
+		if !g.Groups[idx].IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+// Depth returns the number of levels of the group and its nested groups, 0 for a nil group
+func (g *FilterGroup) Depth() int {
+	if g == nil {
+		return 0
+	}
+	depth := 0
+	for idx := range g.Groups {
+		depth = max(depth, g.Groups[idx].Depth())
+	}
+	return depth + 1
+}
+
+// AndFilterGroups combines group with an existing group, which may be nil, using AND
+func AndFilterGroups(existing *FilterGroup, group FilterGroup) *FilterGroup {
+	if existing == nil {
+		return &group
+	}
+	return &FilterGroup{
+		Logic:  "AND",
+		Groups: []FilterGroup{*existing, group},
+	}
+}
+
+// ToSQL renders the group as a parenthesized WHERE clause, e.g.
+// "(status = ? OR (priority > ? AND owner = ?))". Empty groups render as "".
+func (g *FilterGroup) ToSQL(condition FilterConditionFunc) (string, []interface{}) {
+	if g == nil {
+		return "", nil
+	}
+
+	parts := make([]string, 0, len(g.Filters)+len(g.Groups))
+	var args []interface{}
+
+	for _, filter := range g.Filters {
+		cond, condArgs := condition(filter)
+		if cond == "" {
+			continue
+		}
+		parts = append(parts, cond)
+		args = append(args, condArgs...)
+	}
+
+	for idx := range g.Groups {
+		cond, condArgs := g.Groups[idx].ToSQL(condition)
+		if cond == "" {
+			continue
+		}
+		parts = append(parts, cond)
+		args = append(args, condArgs...)
+	}
+
+	if len(parts) == 0 {
+		return "", nil
+	}
+
+	return "(" + strings.Join(parts, " "+g.LogicOperator()+" ") + ")", args
+}
+
+// LogicOperator returns the normalized logic operator of the group ("AND" or "OR")
+func (g *FilterGroup) LogicOperator() string {
+	if strings.EqualFold(strings.TrimSpace(g.Logic), "OR") {
+		return "OR"
+	}
+	return "AND"
+}
@@ -0,0 +1,125 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func simpleCondition(filter FilterOption) (string, []interface{}) {
+	if filter.Operator == "skip" {
+		return "", nil
+	}
+	return fmt.Sprintf("%s = ?", filter.Column), []interface{}{filter.Value}
+}
+
+func TestFilterGroupToSQL(t *testing.T) {
+	tests := []struct {
+		name         string
+		group        string
+		expectedSQL  string
+		expectedArgs []interface{}
+	}{
+		{
+			name:         "flat AND by default",
+			group:        `{"filters":[{"column":"a","value":1},{"column":"b","value":2}]}`,
+			expectedSQL:  "(a = ? AND b = ?)",
+			expectedArgs: []interface{}{float64(1), float64(2)},
+		},
+		{
+			name: "nested OR with AND group",
+			group: `{"logic":"or","filters":[{"column":"status","value":"active"}],
+				"groups":[{"logic":"and","filters":[{"column":"priority","value":3},{"column":"owner","value":"me"}]}]}`,
+			expectedSQL:  "(status = ? OR (priority = ? AND owner = ?))",
+			expectedArgs: []interface{}{"active", float64(3), "me"},
+		},
+		{
+			name:         "skipped filters and empty groups are dropped",
+			group:        `{"logic":"OR","filters":[{"column":"a","operator":"skip"},{"column":"b","value":"x"}],"groups":[{"filters":[]}]}`,
+			expectedSQL:  "(b = ?)",
+			expectedArgs: []interface{}{"x"},
+		},
+		{
+			name:        "empty group",
+			group:       `{"logic":"or"}`,
+			expectedSQL: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var group FilterGroup
+			if err := json.Unmarshal([]byte(tt.group), &group); err != nil {
+				t.Fatalf("invalid test group: %v", err)
+			}
+
+			sql, args := group.ToSQL(simpleCondition)
+			if sql != tt.expectedSQL {
+				t.Errorf("ToSQL() sql = %q, want %q", sql, tt.expectedSQL)
+			}
+			if !reflect.DeepEqual(args, tt.expectedArgs) {
+				t.Errorf("ToSQL() args = %v, want %v", args, tt.expectedArgs)
+			}
+		})
+	}
+}
+
+func TestFilterGroupMaxDepth(t *testing.T) {
+	root := &FilterGroup{Filters: []FilterOption{{Column: "level", Value: 0}}}
+	current := root
+	for i := 1; i < MaxFilterGroupDepth; i++ {
+		current.Groups = []FilterGroup{{Filters: []FilterOption{{Column: "level", Value: i}}}}
+		current = &current.Groups[0]
+	}
+	if depth := root.Depth(); depth != MaxFilterGroupDepth {
+		t.Fatalf("expected depth %d, got %d", MaxFilterGroupDepth, depth)
+	}
+	if limitErr := (HandlerConfig{}).CheckQueryLimits(RequestOptions{FilterGroup: root}); limitErr != nil {
+		t.Errorf("expected %d levels to be allowed, got %v", MaxFilterGroupDepth, limitErr)
+	}
+
+	// A group nested deeper is rejected, not rendered without its deepest levels
+	current.Groups = []FilterGroup{{Filters: []FilterOption{{Column: "level", Value: MaxFilterGroupDepth}}}}
+	limitErr := (HandlerConfig{}).CheckQueryLimits(RequestOptions{FilterGroup: root})
+	if limitErr == nil || limitErr.Status != http.StatusBadRequest || limitErr.Code != "filter_group_too_deep" {
+		t.Errorf("expected a filter_group_too_deep 400, got %v", limitErr)
+	}
+	type model struct {
+		Level int `json:"level"`
+	}
+	if err := NewColumnValidator(model{}).ValidateFilters(nil, root); err == nil {
+		t.Error("expected ValidateFilters to reject the group")
+	}
+	if _, args := root.ToSQL(simpleCondition); len(args) != MaxFilterGroupDepth+1 {
+		t.Errorf("expected %d conditions, got %d", MaxFilterGroupDepth+1, len(args))
+	}
+}
+
+func TestColumnValidatorFiltersGroups(t *testing.T) {
+	type model struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	validator := NewColumnValidator(model{})
+
+	options := validator.FilterRequestOptions(RequestOptions{
+		FilterGroup: &FilterGroup{
+			Logic:   "or",
+			Filters: []FilterOption{{Column: "name"}, {Column: "password"}},
+			Groups: []FilterGroup{
+				{Filters: []FilterOption{{Column: "secret"}}},
+				{Filters: []FilterOption{{Column: "id"}}},
+			},
+		},
+	})
+
+	group := options.FilterGroup
+	if group == nil || len(group.Filters) != 1 || group.Filters[0].Column != "name" {
+		t.Fatalf("expected only the valid root filter, got %+v", group)
+	}
+	if len(group.Groups) != 1 || group.Groups[0].Filters[0].Column != "id" {
+		t.Errorf("expected the invalid sub-group to be removed, got %+v", group.Groups)
+	}
+}
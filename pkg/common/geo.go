@@ -0,0 +1,190 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GeoSRID is the spatial reference of coordinates in geo filter values (WGS 84 longitude/latitude)
+const GeoSRID = 4326
+
+// IsGeoOperator reports whether a filter operator is one of the PostGIS operators
+// st_within, st_intersects and st_dwithin
+func IsGeoOperator(operator string) bool {
+	switch strings.ToLower(operator) {
+	case "st_within", "st_intersects", "st_dwithin":
+		return true
+	}
+	return false
+}
+
+// GeoFilterCondition renders a PostGIS filter on a geometry or geography column.
+//
+// st_within and st_intersects take a geometry: a bounding box [minLon, minLat, maxLon, maxLat]
+// (or "minLon,minLat,maxLon,maxLat"), a GeoJSON geometry, or WKT/EWKT text.
+// st_dwithin takes a radius search [lon, lat, meters] (or "lon,lat,meters",
+// {"lon": .., "lat": .., "distance": ..}) or {"geometry": <geometry>, "distance": meters};
+// distances are in meters.
+func GeoFilterCondition(operator, column string, value interface{}) (string, []interface{}, error) {
+	switch strings.ToLower(operator) {
+	case "st_within", "st_intersects":
+		geometry, args, err := geoGeometry(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s on %s: %w", operator, column, err)
+		}
+		function := "ST_Within"
+		if strings.EqualFold(operator, "st_intersects") {
+			function = "ST_Intersects"
+		}
+		return fmt.Sprintf("%s(%s, %s)", function, column, geometry), args, nil
+	case "st_dwithin":
+		geometry, args, distance, err := geoRadius(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s on %s: %w", operator, column, err)
+		}
+		return fmt.Sprintf("ST_DWithin(CAST(%s AS geography), CAST(%s AS geography), ?)", column, geometry),
+			append(args, distance), nil
+	default:
+		return "", nil, fmt.Errorf("unknown geo operator %q", operator)
+	}
+}
+
+// geoGeometry renders a geometry filter value as a PostGIS geometry expression
+func geoGeometry(value interface{}) (string, []interface{}, error) {
+	if numbers, isList, err := geoNumbers(value); isList {
+		if err != nil {
+			return "", nil, err
+		}
+		if len(numbers) != 4 {
+			return "", nil, fmt.Errorf("a bounding box needs 4 numbers, got %d", len(numbers))
+		}
+		return fmt.Sprintf("ST_MakeEnvelope(?, ?, ?, ?, %d)", GeoSRID),
+			[]interface{}{numbers[0], numbers[1], numbers[2], numbers[3]}, nil
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		geoJSON, err := json.Marshal(v)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("ST_SetSRID(ST_GeomFromGeoJSON(?), %d)", GeoSRID), []interface{}{string(geoJSON)}, nil
+	case string:
+		text := strings.TrimSpace(v)
+		switch {
+		case text == "":
+			return "", nil, fmt.Errorf("empty geometry")
+		case strings.HasPrefix(text, "{"):
+			return fmt.Sprintf("ST_SetSRID(ST_GeomFromGeoJSON(?), %d)", GeoSRID), []interface{}{text}, nil
+		case strings.HasPrefix(strings.ToUpper(text), "SRID="):
+			return "ST_GeomFromEWKT(?)", []interface{}{text}, nil
+		default:
+			return fmt.Sprintf("ST_GeomFromText(?, %d)", GeoSRID), []interface{}{text}, nil
+		}
+	default:
+		return "", nil, fmt.Errorf("unsupported geometry value %T", value)
+	}
+}
+
+// geoRadius renders a radius filter value as a geometry expression and a distance in meters
+func geoRadius(value interface{}) (string, []interface{}, float64, error) {
+	if numbers, isList, err := geoNumbers(value); isList {
+		if err != nil {
+			return "", nil, 0, err
+		}
+		if len(numbers) != 3 {
+			return "", nil, 0, fmt.Errorf("a radius search needs lon, lat and distance, got %d numbers", len(numbers))
+		}
+		return fmt.Sprintf("ST_SetSRID(ST_MakePoint(?, ?), %d)", GeoSRID),
+			[]interface{}{numbers[0], numbers[1]}, numbers[2], nil
+	}
+
+	if s, ok := value.(string); ok && strings.HasPrefix(strings.TrimSpace(s), "{") {
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+			return "", nil, 0, fmt.Errorf("invalid radius search: %w", err)
+		}
+		value = decoded
+	}
+	v, ok := value.(map[string]interface{})
+	if !ok {
+		return "", nil, 0, fmt.Errorf("unsupported radius search value %T", value)
+	}
+
+	distance, ok := geoNumber(geoFirst(v, "distance", "radius", "meters"))
+	if !ok {
+		return "", nil, 0, fmt.Errorf("a radius search needs a distance")
+	}
+	if geometry, found := v["geometry"]; found {
+		expr, args, err := geoGeometry(geometry)
+		return expr, args, distance, err
+	}
+	lon, lonOK := geoNumber(geoFirst(v, "lon", "lng", "longitude"))
+	lat, latOK := geoNumber(geoFirst(v, "lat", "latitude"))
+	if !lonOK || !latOK {
+		return "", nil, 0, fmt.Errorf("a radius search needs lon and lat, or a geometry")
+	}
+	return fmt.Sprintf("ST_SetSRID(ST_MakePoint(?, ?), %d)", GeoSRID), []interface{}{lon, lat}, distance, nil
+}
+
+// geoNumbers returns the numbers of a list value or of a comma-separated string of numbers.
+// isList is false for values that are not lists, e.g. WKT or GeoJSON.
+func geoNumbers(value interface{}) (numbers []float64, isList bool, err error) {
+	var items []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		items = v
+	case []float64:
+		return v, true, nil
+	case []string:
+		for _, item := range v {
+			items = append(items, item)
+		}
+	case string:
+		if !strings.Contains(v, ",") || strings.ContainsAny(v, "{(") {
+			return nil, false, nil
+		}
+		for _, item := range strings.Split(v, ",") {
+			items = append(items, item)
+		}
+	default:
+		return nil, false, nil
+	}
+
+	numbers = make([]float64, 0, len(items))
+	for _, item := range items {
+		number, ok := geoNumber(item)
+		if !ok {
+			return nil, true, fmt.Errorf("%v is not a number", item)
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, true, nil
+}
+
+func geoNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func geoFirst(values map[string]interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		if value, ok := values[key]; ok {
+			return value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGeoFilterCondition(t *testing.T) {
+	tests := []struct {
+		name     string
+		operator string
+		value    interface{}
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "bounding box list",
+			operator: "st_within",
+			value:    []interface{}{13.0, 52.0, 14.0, 53.0},
+			wantSQL:  "ST_Within(location, ST_MakeEnvelope(?, ?, ?, ?, 4326))",
+			wantArgs: []interface{}{13.0, 52.0, 14.0, 53.0},
+		},
+		{
+			name:     "bounding box header value",
+			operator: "ST_INTERSECTS",
+			value:    "13, 52, 14, 53",
+			wantSQL:  "ST_Intersects(location, ST_MakeEnvelope(?, ?, ?, ?, 4326))",
+			wantArgs: []interface{}{13.0, 52.0, 14.0, 53.0},
+		},
+		{
+			name:     "GeoJSON",
+			operator: "st_intersects",
+			value:    map[string]interface{}{"type": "Point", "coordinates": []interface{}{13.4, 52.5}},
+			wantSQL:  "ST_Intersects(location, ST_SetSRID(ST_GeomFromGeoJSON(?), 4326))",
+			wantArgs: []interface{}{`{"coordinates":[13.4,52.5],"type":"Point"}`},
+		},
+		{
+			name:     "WKT",
+			operator: "st_within",
+			value:    "POLYGON((0 0, 1 0, 1 1, 0 0))",
+			wantSQL:  "ST_Within(location, ST_GeomFromText(?, 4326))",
+			wantArgs: []interface{}{"POLYGON((0 0, 1 0, 1 1, 0 0))"},
+		},
+		{
+			name:     "EWKT",
+			operator: "st_within",
+			value:    "SRID=3857;POINT(1 2)",
+			wantSQL:  "ST_Within(location, ST_GeomFromEWKT(?))",
+			wantArgs: []interface{}{"SRID=3857;POINT(1 2)"},
+		},
+		{
+			name:     "radius list",
+			operator: "st_dwithin",
+			value:    []interface{}{13.4, 52.5, 500.0},
+			wantSQL:  "ST_DWithin(CAST(location AS geography), CAST(ST_SetSRID(ST_MakePoint(?, ?), 4326) AS geography), ?)",
+			wantArgs: []interface{}{13.4, 52.5, 500.0},
+		},
+		{
+			name:     "radius object",
+			operator: "st_dwithin",
+			value:    map[string]interface{}{"lng": 13.4, "lat": "52.5", "radius": 500.0},
+			wantSQL:  "ST_DWithin(CAST(location AS geography), CAST(ST_SetSRID(ST_MakePoint(?, ?), 4326) AS geography), ?)",
+			wantArgs: []interface{}{13.4, 52.5, 500.0},
+		},
+		{
+			name:     "distance to a geometry",
+			operator: "st_dwithin",
+			value:    `{"geometry": "LINESTRING(0 0, 1 1)", "distance": 25}`,
+			wantSQL:  "ST_DWithin(CAST(location AS geography), CAST(ST_GeomFromText(?, 4326) AS geography), ?)",
+			wantArgs: []interface{}{"LINESTRING(0 0, 1 1)", 25.0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := GeoFilterCondition(tt.operator, "location", tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("sql: got %s, want %s", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args: got %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+
+	for name, value := range map[string]interface{}{
+		"short bounding box": []interface{}{1.0, 2.0},
+		"non-numeric box":    "a,b,c,d",
+		"unsupported type":   42,
+	} {
+		if _, _, err := GeoFilterCondition("st_within", "location", value); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	for name, value := range map[string]interface{}{
+		"missing distance": map[string]interface{}{"lon": 1.0, "lat": 2.0},
+		"missing point":    map[string]interface{}{"distance": 1.0},
+		"four numbers":     "1,2,3,4",
+	} {
+		if _, _, err := GeoFilterCondition("st_dwithin", "location", value); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
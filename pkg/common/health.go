@@ -0,0 +1,102 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// HealthPingTimeout bounds the database ping of a health check
+const HealthPingTimeout = 5 * time.Second
+
+// SQLDBProvider is implemented by databases backed by a database/sql connection pool
+type SQLDBProvider interface {
+	SQLDB() (*sql.DB, error)
+}
+
+// Health is the state reported by a health check
+type Health struct {
+	Status   string         `json:"status"` // "ok" or "unavailable"
+	Database DatabaseHealth `json:"database"`
+	Models   int            `json:"models"`
+}
+
+// DatabaseHealth is the ping result and pool usage of the database
+type DatabaseHealth struct {
+	Dialect   string     `json:"dialect"`
+	Ping      string     `json:"ping"` // "ok" or the ping error
+	LatencyMS float64    `json:"latency_ms"`
+	Pool      *PoolStats `json:"pool,omitempty"`
+}
+
+// PoolStats are the connection pool statistics of a database/sql pool
+type PoolStats struct {
+	MaxOpen        int     `json:"max_open"`
+	Open           int     `json:"open"`
+	InUse          int     `json:"in_use"`
+	Idle           int     `json:"idle"`
+	WaitCount      int64   `json:"wait_count"`
+	WaitDurationMS float64 `json:"wait_duration_ms"`
+}
+
+// CheckHealth pings the database and collects its pool statistics and the number of models.
+// Databases without a database/sql pool are pinged with SELECT 1.
+func CheckHealth(ctx context.Context, db Database, registry ModelRegistry) Health {
+	ctx, cancel := context.WithTimeout(ctx, HealthPingTimeout)
+	defer cancel()
+
+	health := Health{Status: "ok", Database: DatabaseHealth{Dialect: DialectOf(db).Name, Ping: "ok"}}
+	if registry != nil {
+		health.Models = len(registry.GetAllModels())
+	}
+
+	var sqlDB *sql.DB
+	if provider, ok := db.(SQLDBProvider); ok {
+		sqlDB, _ = provider.SQLDB()
+	}
+
+	start := time.Now()
+	var err error
+	switch {
+	case db == nil:
+		err = sql.ErrConnDone
+	case sqlDB != nil:
+		err = sqlDB.PingContext(ctx)
+	default:
+		_, err = db.Exec(ctx, "SELECT 1")
+	}
+	health.Database.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		health.Status = "unavailable"
+		health.Database.Ping = err.Error()
+	}
+
+	if sqlDB != nil {
+		stats := sqlDB.Stats()
+		health.Database.Pool = &PoolStats{
+			MaxOpen:        stats.MaxOpenConnections,
+			Open:           stats.OpenConnections,
+			InUse:          stats.InUse,
+			Idle:           stats.Idle,
+			WaitCount:      stats.WaitCount,
+			WaitDurationMS: float64(stats.WaitDuration.Microseconds()) / 1000,
+		}
+	}
+	return health
+}
+
+// HealthHandler serves the health of a database as JSON for liveness and readiness probes.
+// It answers 200 when the database responds and 503 otherwise.
+func HealthHandler(db Database, registry ModelRegistry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health := CheckHealth(r.Context(), db, registry)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if health.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(health)
+	})
+}
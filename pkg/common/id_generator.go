@@ -0,0 +1,202 @@
+package common
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// IDGenerator generates the primary keys of created records, see EntityConfig.IDGenerator
+type IDGenerator interface {
+	// NextID returns a new key; db is the database of the handler, for sequences
+	NextID(ctx context.Context, db Database) (interface{}, error)
+}
+
+// IDGeneratorFunc adapts a function to IDGenerator
+type IDGeneratorFunc func(ctx context.Context, db Database) (interface{}, error)
+
+// NextID calls f
+func (f IDGeneratorFunc) NextID(ctx context.Context, db Database) (interface{}, error) {
+	return f(ctx, db)
+}
+
+// UUIDv7 generates time-ordered UUIDs, e.g. "01920f1e-7c3a-7d4e-9b1f-2a6c8e0d4f31"
+func UUIDv7() IDGenerator {
+	return IDGeneratorFunc(func(ctx context.Context, db Database) (interface{}, error) {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return nil, err
+		}
+		return id.String(), nil
+	})
+}
+
+// crockford is the base32 alphabet of ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID generates lexicographically sortable identifiers of 26 characters: a millisecond
+// timestamp and 80 random bits, e.g. "01J9ZQ4X5K8M2N3P4Q5R6S7T8V"
+func ULID() IDGenerator {
+	return IDGeneratorFunc(func(ctx context.Context, db Database) (interface{}, error) {
+		var id [16]byte
+		ms := uint64(time.Now().UnixMilli())
+		for i := 0; i < 6; i++ {
+			id[i] = byte(ms >> (40 - 8*i))
+		}
+		if _, err := rand.Read(id[6:]); err != nil {
+			return nil, err
+		}
+		return encodeULID(id), nil
+	})
+}
+
+// encodeULID writes the 128 bits of a ULID as 26 base32 characters of 5 bits; the first
+// character holds 3 bits
+func encodeULID(id [16]byte) string {
+	var text [26]byte
+	bit := -2
+	for i := range text {
+		var value byte
+		for j := 0; j < 5; j++ {
+			value <<= 1
+			if position := bit + j; position >= 0 {
+				value |= id[position/8] >> (7 - position%8) & 1
+			}
+		}
+		text[i] = crockford[value]
+		bit += 5
+	}
+	return string(text[:])
+}
+
+// SnowflakeEpoch is the start of the timestamps of Snowflake ids, 2020-01-01 UTC
+var SnowflakeEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Snowflake generates 63-bit integer ids ordered by time: 41 bits of milliseconds since
+// SnowflakeEpoch, 10 bits of node and a 12-bit sequence within the millisecond. Ids above 2^53
+// lose precision in JavaScript clients, which should read them as strings.
+type Snowflake struct {
+	node int64
+
+	mu       sync.Mutex
+	last     int64
+	sequence int64
+}
+
+// NewSnowflake creates a Snowflake generator for a node from 0 to 1023, unique per process
+// generating ids of the same table
+func NewSnowflake(node int64) (*Snowflake, error) {
+	if node < 0 || node > 1023 {
+		return nil, fmt.Errorf("snowflake node %d is not between 0 and 1023", node)
+	}
+	return &Snowflake{node: node}, nil
+}
+
+// NextID returns the next id as an int64
+func (s *Snowflake) NextID(ctx context.Context, db Database) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Since(SnowflakeEpoch).Milliseconds()
+	if now < s.last {
+		// The clock went back, keep counting in the last millisecond
+		now = s.last
+	}
+	if now == s.last {
+		s.sequence = (s.sequence + 1) & 4095
+		if s.sequence == 0 {
+			for now <= s.last {
+				time.Sleep(100 * time.Microsecond)
+				now = time.Since(SnowflakeEpoch).Milliseconds()
+			}
+		}
+	} else {
+		s.sequence = 0
+	}
+	s.last = now
+	return now<<22 | s.node<<12 | s.sequence, nil
+}
+
+// Sequence generates ids with nextval of a PostgreSQL sequence, e.g. "public.order_ids"
+func Sequence(name string) IDGenerator {
+	return IDGeneratorFunc(func(ctx context.Context, db Database) (interface{}, error) {
+		if dialect := DialectOf(db).Name; dialect != "postgres" {
+			return nil, fmt.Errorf("sequence %s needs PostgreSQL, the database is %s", name, dialect)
+		}
+		rows := []map[string]interface{}{}
+		if err := db.Query(ctx, &rows, "SELECT nextval(?) AS id", name); err != nil {
+			return nil, fmt.Errorf("failed to read sequence %s: %w", name, err)
+		}
+		if len(rows) == 0 {
+			return nil, fmt.Errorf("sequence %s returned no value", name)
+		}
+		return rows[0]["id"], nil
+	})
+}
+
+// GenerateIDs sets the primary key of the records of a create payload, a record or a list of
+// records, that don't carry one, with the IDGenerator of the entity. Records created through
+// nested relations keep their own keys. Entities without a generator are left as they are.
+func (c HandlerConfig) GenerateIDs(ctx context.Context, db Database, schema, entity string, model, data interface{}) error {
+	generator := c.Entity(schema, entity).IDGenerator
+	if generator == nil {
+		return nil
+	}
+	keys := reflection.GetPrimaryKeyNames(model)
+	if len(keys) != 1 {
+		return fmt.Errorf("generated ids need a single primary key column, %s has %d", entity, len(keys))
+	}
+
+	generate := func(record map[string]interface{}) error {
+		if !emptyID(record[keys[0]]) {
+			return nil
+		}
+		id, err := generator.NextID(ctx, db)
+		if err != nil {
+			return fmt.Errorf("failed to generate the id of %s: %w", entity, err)
+		}
+		record[keys[0]] = id
+		return nil
+	}
+
+	switch v := data.(type) {
+	case map[string]interface{}:
+		return generate(v)
+	case []map[string]interface{}:
+		for _, record := range v {
+			if err := generate(record); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if record, ok := item.(map[string]interface{}); ok {
+				if err := generate(record); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// emptyID reports whether a key sent by the client is missing: nil, blank or zero
+func emptyID(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case float64:
+		return v == 0 || math.IsNaN(v)
+	}
+	return reflect.ValueOf(value).IsZero()
+}
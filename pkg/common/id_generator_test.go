@@ -0,0 +1,79 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type generatedOrder struct {
+	ID    string `json:"id" gorm:"column:id;primaryKey"`
+	Title string `json:"title" gorm:"column:title"`
+}
+
+func TestIDGenerators(t *testing.T) {
+	ctx := context.Background()
+
+	id, err := UUIDv7().NextID(ctx, nil)
+	require.NoError(t, err)
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, id)
+
+	id, err = ULID().NextID(ctx, nil)
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`), id)
+	assert.Equal(t, "00000000000000000000000000", encodeULID([16]byte{}))
+	var max [16]byte
+	for i := range max {
+		max[i] = 0xff
+	}
+	assert.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", encodeULID(max))
+	assert.Equal(t, "00000000000000000000000001", encodeULID([16]byte{15: 1}))
+
+	_, err = NewSnowflake(1024)
+	assert.Error(t, err)
+	snowflake, err := NewSnowflake(5)
+	require.NoError(t, err)
+	last := int64(0)
+	for i := 0; i < 10000; i++ {
+		id, err := snowflake.NextID(ctx, nil)
+		require.NoError(t, err)
+		next := id.(int64)
+		require.Greater(t, next, last, "ids increase")
+		assert.EqualValues(t, 5, next>>12&1023, "node bits")
+		last = next
+	}
+}
+
+func TestGenerateIDs(t *testing.T) {
+	ctx := context.Background()
+	next := 0
+	config := HandlerConfig{Entities: map[string]EntityConfig{
+		"orders": {IDGenerator: IDGeneratorFunc(func(ctx context.Context, db Database) (interface{}, error) {
+			next++
+			return next, nil
+		})},
+		"failing": {IDGenerator: IDGeneratorFunc(func(ctx context.Context, db Database) (interface{}, error) {
+			return nil, errors.New("unavailable")
+		})},
+	}}
+
+	records := []interface{}{
+		map[string]interface{}{"title": "a"},
+		map[string]interface{}{"id": "", "title": "b"},
+		map[string]interface{}{"id": "keep", "title": "c"},
+	}
+	require.NoError(t, config.GenerateIDs(ctx, nil, "", "orders", generatedOrder{}, records))
+	assert.Equal(t, 1, records[0].(map[string]interface{})["id"])
+	assert.Equal(t, 2, records[1].(map[string]interface{})["id"])
+	assert.Equal(t, "keep", records[2].(map[string]interface{})["id"])
+
+	record := map[string]interface{}{"title": "d"}
+	require.NoError(t, config.GenerateIDs(ctx, nil, "", "notes", generatedOrder{}, record))
+	assert.NotContains(t, record, "id", "entities without a generator")
+	assert.Error(t, config.GenerateIDs(ctx, nil, "", "failing", generatedOrder{}, record))
+	assert.Error(t, config.GenerateIDs(ctx, nil, "", "orders", struct{ Title string }{}, record), "no primary key")
+}
@@ -0,0 +1,69 @@
+package common
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// IndexSpec declares an index of a model
+type IndexSpec struct {
+	// Name of the index; IndexName derives one from the table and columns when it is empty
+	Name string
+	// Columns are column names or expressions, e.g. "lower(email)", in the order of the index
+	Columns []string
+	Unique  bool
+	// Where is the predicate of a partial index, e.g. "deleted_at IS NULL"; PostgreSQL and
+	// SQLite only
+	Where string
+}
+
+// IndexProvider is implemented by models declaring indexes that struct tags can't express,
+// e.g. partial or expression indexes, so filter-heavy entities don't rely on sequential scans
+//
+//	func (Order) Indexes() []common.IndexSpec {
+//		return []common.IndexSpec{
+//			{Columns: []string{"customer_id", "created_at"}},
+//			{Name: "idx_orders_open", Columns: []string{"due_date"}, Where: "status <> 'closed'"},
+//		}
+//	}
+//
+// Indexes can also be declared on fields with `resolvespec:"index"`, `resolvespec:"index:name"`
+// or `resolvespec:"unique_index:name"`, where fields sharing a name form a composite index, and
+// `resolvespec:"index:name;index_where:predicate"` for a partial one.
+type IndexProvider interface {
+	Indexes() []IndexSpec
+}
+
+// ModelIndexes returns the indexes a model declares with IndexProvider, or nil
+func ModelIndexes(model interface{}) []IndexSpec {
+	modelType := reflect.TypeOf(model)
+	for modelType != nil && (modelType.Kind() == reflect.Ptr || modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Array) {
+		modelType = modelType.Elem()
+	}
+	if modelType == nil {
+		return nil
+	}
+	for _, candidate := range []interface{}{reflect.Zero(modelType).Interface(), reflect.New(modelType).Interface()} {
+		if provider, ok := candidate.(IndexProvider); ok {
+			return provider.Indexes()
+		}
+	}
+	return nil
+}
+
+// indexNameUnsafe matches the characters of expressions that can't be part of an index name
+var indexNameUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// IndexName returns the name of the index on table: Name, or idx_<table>_<columns>
+func (s IndexSpec) IndexName(table string) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	parts := make([]string, 0, len(s.Columns)+2)
+	parts = append(parts, "idx", table)
+	for _, column := range s.Columns {
+		parts = append(parts, strings.Trim(indexNameUnsafe.ReplaceAllString(strings.ToLower(column), "_"), "_"))
+	}
+	return strings.Join(parts, "_")
+}
@@ -0,0 +1,27 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type indexedOrder struct {
+	ID int64 `bun:"id,pk"`
+}
+
+func (*indexedOrder) Indexes() []IndexSpec {
+	return []IndexSpec{{Columns: []string{"customer_id", "created_at"}}}
+}
+
+func TestModelIndexes(t *testing.T) {
+	assert.Equal(t, []IndexSpec{{Columns: []string{"customer_id", "created_at"}}}, ModelIndexes([]indexedOrder{}), "pointer receivers and slices")
+	assert.Nil(t, ModelIndexes(scopedTask{}))
+	assert.Nil(t, ModelIndexes(nil))
+}
+
+func TestIndexName(t *testing.T) {
+	assert.Equal(t, "idx_orders_customer_id_created_at", IndexSpec{Columns: []string{"customer_id", "created_at"}}.IndexName("orders"))
+	assert.Equal(t, "idx_orders_lower_email", IndexSpec{Columns: []string{"LOWER(email)"}}.IndexName("orders"))
+	assert.Equal(t, "orders_open", IndexSpec{Name: "orders_open", Columns: []string{"id"}}.IndexName("orders"))
+}
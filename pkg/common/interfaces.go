@@ -1,6 +1,9 @@
 package common
 
-import "context"
+import (
+	"context"
+	"net/http"
+)
 
 // Database interface designed to work with both GORM and Bun
 type Database interface {
@@ -19,6 +22,9 @@ type Database interface {
 	CommitTx(ctx context.Context) error
 	RollbackTx(ctx context.Context) error
 	RunInTransaction(ctx context.Context, fn func(Database) error) error
+
+	// Dialect reports the SQL dialect and its capabilities
+	Dialect() Dialect
 }
 
 // SelectQuery interface for building SELECT queries (compatible with both GORM and Bun)
@@ -33,17 +39,29 @@ type SelectQuery interface {
 	LeftJoin(query string, args ...interface{}) SelectQuery
 	Preload(relation string, conditions ...interface{}) SelectQuery
 	PreloadRelation(relation string, apply ...func(SelectQuery) SelectQuery) SelectQuery
+	// JoinRelation loads a belongs-to/has-one relation in the same query using a LEFT JOIN.
+	// Related columns are aliased as <relation>__<column> and scanned into the relation field.
+	JoinRelation(relation string, apply ...func(SelectQuery) SelectQuery) SelectQuery
 	Order(order string) SelectQuery
+	// OrderExpr orders by an expression with bind parameters
+	OrderExpr(order string, args ...interface{}) SelectQuery
 	Limit(n int) SelectQuery
 	Offset(n int) SelectQuery
 	Group(group string) SelectQuery
 	Having(having string, args ...interface{}) SelectQuery
+	Distinct() SelectQuery
+	DistinctOn(columns ...string) SelectQuery // PostgreSQL only
 
 	// Execution methods
 	Scan(ctx context.Context, dest interface{}) error
 	ScanModel(ctx context.Context) error
 	Count(ctx context.Context) (int, error)
 	Exists(ctx context.Context) (bool, error)
+
+	// ToSQL renders the statement and its bind parameters, in the order of their placeholders,
+	// without executing it, e.g. for dry runs. Adapters that render the parameters into the
+	// statement, like Bun, return no parameters.
+	ToSQL() (string, []interface{}, error)
 }
 
 // InsertQuery interface for building INSERT queries
@@ -53,9 +71,15 @@ type InsertQuery interface {
 	Value(column string, value interface{}) InsertQuery
 	OnConflict(action string) InsertQuery
 	Returning(columns ...string) InsertQuery
+	// Bulk inserts a slice of models with multi-row INSERT statements of at most chunkSize
+	// rows each, DefaultInsertChunkSize when chunkSize <= 0. Returned columns are loaded into
+	// the models in order.
+	Bulk(models interface{}, chunkSize int) InsertQuery
 
 	// Execution
 	Exec(ctx context.Context) (Result, error)
+	// ToSQL renders the statement without executing it, see SelectQuery.ToSQL
+	ToSQL() (string, []interface{}, error)
 }
 
 // UpdateQuery interface for building UPDATE queries
@@ -69,6 +93,8 @@ type UpdateQuery interface {
 
 	// Execution
 	Exec(ctx context.Context) (Result, error)
+	// ToSQL renders the statement without executing it, see SelectQuery.ToSQL
+	ToSQL() (string, []interface{}, error)
 }
 
 // DeleteQuery interface for building DELETE queries
@@ -79,6 +105,8 @@ type DeleteQuery interface {
 
 	// Execution
 	Exec(ctx context.Context) (Result, error)
+	// ToSQL renders the statement without executing it, see SelectQuery.ToSQL
+	ToSQL() (string, []interface{}, error)
 }
 
 // Result interface for query execution results
@@ -87,6 +115,13 @@ type Result interface {
 	LastInsertId() (int64, error)
 }
 
+// ReturningResult is implemented by results of table inserts that used Returning.
+// Returned holds the column values of the inserted row, including generated keys and
+// server-side defaults; it is nil when the database does not support RETURNING.
+type ReturningResult interface {
+	Returned() map[string]interface{}
+}
+
 // ModelRegistry manages model registration and retrieval
 type ModelRegistry interface {
 	RegisterModel(name string, model interface{}) error
@@ -117,6 +152,15 @@ type Request interface {
 	PathParam(key string) string
 	QueryParam(key string) string
 	AllQueryParams() map[string]string // Get all query parameters as a map
+	// Context returns the request context. It carries deadlines and values of the
+	// incoming request and is canceled when the client disconnects.
+	Context() context.Context
+}
+
+// HTTPRequestUnwrapper is implemented by requests that wrap a *http.Request, so
+// net/http based code (e.g. authentication callbacks) can inspect the original request
+type HTTPRequestUnwrapper interface {
+	UnderlyingRequest() *http.Request
 }
 
 // ResponseWriter interface abstracts HTTP response
@@ -139,6 +183,19 @@ type TableAliasProvider interface {
 	TableAlias() string
 }
 
+// TableReferenceProvider is implemented by select queries qualifying the columns of their main
+// table with an alias instead of the table name, e.g. bun, which selects FROM "users" AS "user"
+type TableReferenceProvider interface {
+	TableReference() string
+}
+
+// RelationReferenceProvider is implemented by databases joining the relations of a model under
+// an alias of their own, e.g. gorm, which joins the relation Department AS "Department". The
+// reference is the quoted alias, or "" for an unknown relation.
+type RelationReferenceProvider interface {
+	RelationReference(model interface{}, relation string) string
+}
+
 // PrimaryKeyNameProvider interface for models that provide primary key column names
 type PrimaryKeyNameProvider interface {
 	GetIDName() string
@@ -0,0 +1,260 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IndexMetadata describes an index of a table as reported by the database
+type IndexMetadata struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+	Primary bool     `json:"primary"`
+}
+
+// IntrospectTable reads the columns and indexes of a table from the catalog of the database:
+// information_schema on PostgreSQL and MySQL, PRAGMA table_info and index_list on SQLite
+func IntrospectTable(ctx context.Context, db Database, schema, table string) ([]Column, []IndexMetadata, error) {
+	if db == nil {
+		return nil, nil, fmt.Errorf("no database to introspect %s", table)
+	}
+	switch dialect := DialectOf(db).Name; dialect {
+	case "postgres":
+		return introspectPostgres(ctx, db, schema, table)
+	case "sqlite":
+		return introspectSQLite(ctx, db, table)
+	case "mysql":
+		return introspectMySQL(ctx, db, schema, table)
+	default:
+		return nil, nil, fmt.Errorf("schema introspection is not supported by dialect %q", dialect)
+	}
+}
+
+// IntrospectMetadata merges the live columns and indexes of table into metadata, so the
+// database wins where struct tags drifted from the actual schema. Columns of the database
+// that the model doesn't map are left out.
+func IntrospectMetadata(ctx context.Context, db Database, schema, table string, metadata *TableMetadata) error {
+	columns, indexes, err := IntrospectTable(ctx, db, schema, table)
+	if err != nil {
+		return err
+	}
+	MergeIntrospectedColumns(metadata, columns, indexes)
+	return nil
+}
+
+// MergeIntrospectedColumns overrides the types, nullability, keys, indexes and details of the
+// columns of metadata with the introspected columns and indexes
+func MergeIntrospectedColumns(metadata *TableMetadata, columns []Column, indexes []IndexMetadata) {
+	unique := make(map[string]bool)
+	indexed := make(map[string]bool)
+	for _, index := range indexes {
+		for _, column := range index.Columns {
+			indexed[strings.ToLower(column)] = true
+		}
+		if (index.Unique || index.Primary) && len(index.Columns) == 1 {
+			unique[strings.ToLower(index.Columns[0])] = true
+		}
+	}
+
+	byName := make(map[string]Column, len(columns))
+	for _, column := range columns {
+		byName[strings.ToLower(column.Name)] = column
+	}
+	for i := range metadata.Columns {
+		name := strings.ToLower(metadata.Columns[i].Name)
+		column, ok := byName[name]
+		if !ok {
+			continue
+		}
+		metadata.Columns[i].Type = column.Type
+		metadata.Columns[i].IsNullable = column.IsNullable
+		metadata.Columns[i].IsPrimary = column.IsPrimary
+		metadata.Columns[i].IsUnique = unique[name]
+		metadata.Columns[i].HasIndex = indexed[name]
+	}
+	MergeColumnDetails(metadata.Columns, columns)
+	metadata.Indexes = indexes
+}
+
+func introspectPostgres(ctx context.Context, db Database, schema, table string) ([]Column, []IndexMetadata, error) {
+	if schema == "" {
+		schema = "public"
+	}
+	rows := []map[string]interface{}{}
+	err := db.Query(ctx, &rows, `SELECT c.column_name, c.data_type, c.is_nullable, c.column_default,
+		c.character_maximum_length, c.numeric_precision, c.numeric_scale,
+		col_description(format('%I.%I', c.table_schema, c.table_name)::regclass, c.ordinal_position) AS column_comment
+		FROM information_schema.columns c
+		WHERE c.table_schema = ? AND c.table_name = ?
+		ORDER BY c.ordinal_position`, schema, table)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	indexRows := []map[string]interface{}{}
+	err = db.Query(ctx, &indexRows, `SELECT i.relname AS index_name, a.attname AS column_name,
+		ix.indisunique AS is_unique, ix.indisprimary AS is_primary
+		FROM pg_index ix
+		JOIN pg_class t ON t.oid = ix.indrelid
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey)
+		WHERE n.nspname = ? AND t.relname = ?
+		ORDER BY i.relname, array_position(ix.indkey::int2[], a.attnum)`, schema, table)
+	if err != nil {
+		return nil, nil, err
+	}
+	indexes := collectIndexes(indexRows)
+	return catalogColumns(rows, primaryColumns(indexes)), indexes, nil
+}
+
+func introspectMySQL(ctx context.Context, db Database, schema, table string) ([]Column, []IndexMetadata, error) {
+	rows := []map[string]interface{}{}
+	err := db.Query(ctx, &rows, `SELECT COLUMN_NAME AS column_name, COLUMN_TYPE AS data_type, IS_NULLABLE AS is_nullable,
+		COLUMN_DEFAULT AS column_default, CHARACTER_MAXIMUM_LENGTH AS character_maximum_length,
+		NUMERIC_PRECISION AS numeric_precision, NUMERIC_SCALE AS numeric_scale, COLUMN_COMMENT AS column_comment
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ?
+		ORDER BY ORDINAL_POSITION`, schema, table)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	indexRows := []map[string]interface{}{}
+	err = db.Query(ctx, &indexRows, `SELECT INDEX_NAME AS index_name, COLUMN_NAME AS column_name,
+		NON_UNIQUE = 0 AS is_unique, INDEX_NAME = 'PRIMARY' AS is_primary
+		FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ?
+		ORDER BY INDEX_NAME, SEQ_IN_INDEX`, schema, table)
+	if err != nil {
+		return nil, nil, err
+	}
+	indexes := collectIndexes(indexRows)
+	return catalogColumns(rows, primaryColumns(indexes)), indexes, nil
+}
+
+func introspectSQLite(ctx context.Context, db Database, table string) ([]Column, []IndexMetadata, error) {
+	rows := []map[string]interface{}{}
+	if err := db.Query(ctx, &rows, fmt.Sprintf("PRAGMA table_info(%s)", QuoteIdent(table))); err != nil {
+		return nil, nil, err
+	}
+	columns := make([]Column, 0, len(rows))
+	for _, row := range rows {
+		column := Column{
+			Name:         catalogString(row["name"]),
+			Type:         strings.ToLower(catalogString(row["type"])),
+			IsNullable:   catalogInt(row["notnull"]) == 0,
+			IsPrimary:    catalogInt(row["pk"]) > 0,
+			DefaultValue: catalogString(row["dflt_value"]),
+		}
+		column.IsNullable = column.IsNullable && !column.IsPrimary
+		applyTypeSize(&column, column.Type)
+		columns = append(columns, column)
+	}
+
+	indexList := []map[string]interface{}{}
+	if err := db.Query(ctx, &indexList, fmt.Sprintf("PRAGMA index_list(%s)", QuoteIdent(table))); err != nil {
+		return nil, nil, err
+	}
+	indexes := make([]IndexMetadata, 0, len(indexList))
+	for _, row := range indexList {
+		index := IndexMetadata{
+			Name:    catalogString(row["name"]),
+			Unique:  catalogInt(row["unique"]) == 1,
+			Primary: catalogString(row["origin"]) == "pk",
+		}
+		info := []map[string]interface{}{}
+		if err := db.Query(ctx, &info, fmt.Sprintf("PRAGMA index_info(%s)", QuoteIdent(index.Name))); err != nil {
+			return nil, nil, err
+		}
+		for _, column := range info {
+			index.Columns = append(index.Columns, catalogString(column["name"]))
+		}
+		indexes = append(indexes, index)
+	}
+	return columns, indexes, nil
+}
+
+// catalogColumns converts information_schema rows to columns
+func catalogColumns(rows []map[string]interface{}, primary map[string]bool) []Column {
+	columns := make([]Column, 0, len(rows))
+	for _, row := range rows {
+		name := catalogString(row["column_name"])
+		columns = append(columns, Column{
+			Name:         name,
+			Type:         catalogString(row["data_type"]),
+			IsNullable:   strings.EqualFold(catalogString(row["is_nullable"]), "YES"),
+			IsPrimary:    primary[name],
+			DefaultValue: catalogString(row["column_default"]),
+			Comment:      catalogString(row["column_comment"]),
+			MaxLength:    catalogInt(row["character_maximum_length"]),
+			Precision:    catalogInt(row["numeric_precision"]),
+			Scale:        catalogInt(row["numeric_scale"]),
+		})
+	}
+	return columns
+}
+
+// collectIndexes groups index rows with one column each into indexes, keeping their order
+func collectIndexes(rows []map[string]interface{}) []IndexMetadata {
+	indexes := make([]IndexMetadata, 0)
+	positions := make(map[string]int)
+	for _, row := range rows {
+		name := catalogString(row["index_name"])
+		position, ok := positions[name]
+		if !ok {
+			position = len(indexes)
+			positions[name] = position
+			indexes = append(indexes, IndexMetadata{
+				Name:    name,
+				Unique:  catalogBool(row["is_unique"]),
+				Primary: catalogBool(row["is_primary"]),
+			})
+		}
+		indexes[position].Columns = append(indexes[position].Columns, catalogString(row["column_name"]))
+	}
+	return indexes
+}
+
+func primaryColumns(indexes []IndexMetadata) map[string]bool {
+	primary := make(map[string]bool)
+	for _, index := range indexes {
+		if index.Primary {
+			for _, column := range index.Columns {
+				primary[column] = true
+			}
+		}
+	}
+	return primary
+}
+
+// catalogString converts a catalog value scanned by any driver to a string; NULL is ""
+func catalogString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func catalogInt(value interface{}) int {
+	n, _ := strconv.Atoi(catalogString(value))
+	return n
+}
+
+func catalogBool(value interface{}) bool {
+	switch catalogString(value) {
+	case "true", "t", "1":
+		return true
+	default:
+		return false
+	}
+}
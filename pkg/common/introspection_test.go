@@ -0,0 +1,50 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectIndexes(t *testing.T) {
+	rows := []map[string]interface{}{
+		{"index_name": "users_pkey", "column_name": "id", "is_unique": true, "is_primary": true},
+		{"index_name": "idx_name", "column_name": "last_name", "is_unique": int64(0), "is_primary": int64(0)},
+		{"index_name": "idx_name", "column_name": "first_name", "is_unique": int64(0), "is_primary": int64(0)},
+		{"index_name": "users_email_key", "column_name": []byte("email"), "is_unique": "t", "is_primary": "f"},
+	}
+	assert.Equal(t, []IndexMetadata{
+		{Name: "users_pkey", Columns: []string{"id"}, Unique: true, Primary: true},
+		{Name: "idx_name", Columns: []string{"last_name", "first_name"}},
+		{Name: "users_email_key", Columns: []string{"email"}, Unique: true},
+	}, collectIndexes(rows))
+}
+
+func TestMergeIntrospectedColumns(t *testing.T) {
+	metadata := &TableMetadata{Columns: []Column{
+		{Name: "id", Type: "integer", IsPrimary: true},
+		{Name: "email", Type: "string", IsNullable: true},
+		{Name: "last_name", Type: "string", IsUnique: true, MaxLength: 50},
+		{Name: "nickname", Type: "string", IsNullable: true},
+	}}
+	columns := []Column{
+		{Name: "id", Type: "bigint", IsPrimary: true},
+		{Name: "email", Type: "character varying", MaxLength: 120},
+		{Name: "last_name", Type: "text", IsNullable: true, DefaultValue: "''"},
+		{Name: "legacy", Type: "text"},
+	}
+	indexes := []IndexMetadata{
+		{Name: "users_pkey", Columns: []string{"id"}, Unique: true, Primary: true},
+		{Name: "users_email_key", Columns: []string{"email"}, Unique: true},
+		{Name: "idx_name", Columns: []string{"last_name", "first_name"}, Unique: true},
+	}
+	MergeIntrospectedColumns(metadata, columns, indexes)
+
+	assert.Equal(t, []Column{
+		{Name: "id", Type: "bigint", IsPrimary: true, IsUnique: true, HasIndex: true},
+		{Name: "email", Type: "character varying", IsUnique: true, HasIndex: true, MaxLength: 120},
+		{Name: "last_name", Type: "text", IsNullable: true, HasIndex: true, MaxLength: 50, DefaultValue: "''"},
+		{Name: "nickname", Type: "string", IsNullable: true},
+	}, metadata.Columns, "the database wins; unmapped columns stay out")
+	assert.Equal(t, indexes, metadata.Indexes)
+}
@@ -0,0 +1,226 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// JSONPatchKey is the update data key of an explicit JSON patch section:
+//
+//	{"json_patch": {"settings": {"theme": "dark", "legacy": null}}}
+//
+// Each document is merged into its column like a JSON merge patch (RFC 7396):
+// objects are merged recursively, null removes a key and other values replace it.
+const JSONPatchKey = "json_patch"
+
+// JSONPatchOp sets or removes the value at a path of a JSON document
+type JSONPatchOp struct {
+	Path   []string
+	Value  interface{}
+	Remove bool
+}
+
+// JSONPatch is an update value that changes parts of a JSON column instead of replacing
+// the whole document. Adapters render it as jsonb_set (PostgreSQL), json_set (SQLite)
+// or JSON_SET (MySQL) on the current value of the column.
+type JSONPatch []JSONPatchOp
+
+// IsJSONPatchKey reports whether an update data key is a partial JSON update,
+// either a column path ("settings->theme") or the json_patch section
+func IsJSONPatchKey(key string) bool {
+	return key == JSONPatchKey || strings.Contains(key, "->")
+}
+
+// ExtractJSONPatches splits update data into plain column values and JSON patches per column.
+// Keys with a JSON path ("settings->theme", "settings->font->size") set the value at that path;
+// the documents of the json_patch section are flattened into set and remove operations.
+// data is not modified.
+func ExtractJSONPatches(data map[string]interface{}) (map[string]interface{}, map[string]JSONPatch, error) {
+	values := make(map[string]interface{}, len(data))
+	var patches map[string]JSONPatch
+	addOp := func(column string, op JSONPatchOp) {
+		if patches == nil {
+			patches = make(map[string]JSONPatch)
+		}
+		patches[column] = append(patches[column], op)
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := data[key]
+		switch {
+		case key == JSONPatchKey:
+			documents, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, nil, fmt.Errorf("%s must be an object of column documents, got %T", JSONPatchKey, value)
+			}
+			columns := make([]string, 0, len(documents))
+			for column := range documents {
+				columns = append(columns, column)
+			}
+			sort.Strings(columns)
+			for _, column := range columns {
+				document, ok := documents[column].(map[string]interface{})
+				if !ok {
+					return nil, nil, fmt.Errorf("%s of column %s must be an object, got %T", JSONPatchKey, column, documents[column])
+				}
+				flattenMergePatch(document, nil, func(op JSONPatchOp) { addOp(column, op) })
+			}
+		case strings.Contains(key, "->"):
+			parts := strings.Split(key, "->")
+			column := strings.TrimSpace(parts[0])
+			path := make([]string, 0, len(parts)-1)
+			for _, part := range parts[1:] {
+				part = strings.Trim(strings.TrimSpace(strings.TrimPrefix(part, ">")), `'"`)
+				if part == "" {
+					return nil, nil, fmt.Errorf("invalid JSON path in %q", key)
+				}
+				path = append(path, part)
+			}
+			if column == "" {
+				return nil, nil, fmt.Errorf("invalid JSON path in %q", key)
+			}
+			addOp(column, JSONPatchOp{Path: path, Value: value})
+		default:
+			values[key] = value
+		}
+	}
+
+	for column := range patches {
+		if _, exists := values[column]; exists {
+			return nil, nil, fmt.Errorf("column %s is both replaced and patched", column)
+		}
+	}
+	return values, patches, nil
+}
+
+// flattenMergePatch turns a merge patch document into operations on leaf paths
+func flattenMergePatch(document map[string]interface{}, prefix []string, add func(JSONPatchOp)) {
+	keys := make([]string, 0, len(document))
+	for key := range document {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path := append(append([]string{}, prefix...), key)
+		switch value := document[key].(type) {
+		case nil:
+			add(JSONPatchOp{Path: path, Remove: true})
+		case map[string]interface{}:
+			if len(value) == 0 {
+				add(JSONPatchOp{Path: path, Value: value})
+				continue
+			}
+			flattenMergePatch(value, path, add)
+		default:
+			add(JSONPatchOp{Path: path, Value: value})
+		}
+	}
+}
+
+// JSONPatchSQL renders the expression that applies the patch to a column for the dialect
+// ("postgres"/"pg", "sqlite"/"sqlite3" or "mysql"). A NULL column is patched as an empty object.
+// On PostgreSQL the parent objects of a nested path must exist.
+func JSONPatchSQL(dialect, column string, patch JSONPatch) (string, []interface{}, error) {
+	var expr string
+	switch strings.ToLower(dialect) {
+	case "postgres", "pg", "postgresql":
+		expr = fmt.Sprintf("COALESCE(%s, '{}'::jsonb)", QuoteIdent(column))
+	case "sqlite", "sqlite3":
+		expr = fmt.Sprintf("COALESCE(%s, '{}')", QuoteIdent(column))
+	case "mysql":
+		expr = fmt.Sprintf("COALESCE(%s, JSON_OBJECT())", QuoteIdent(column))
+	default:
+		return "", nil, fmt.Errorf("partial JSON updates are not supported for dialect %q", dialect)
+	}
+
+	var args []interface{}
+	for _, op := range patch {
+		if len(op.Path) == 0 {
+			return "", nil, fmt.Errorf("empty JSON path for column %s", column)
+		}
+
+		var value []byte
+		if !op.Remove {
+			var err error
+			if value, err = json.Marshal(op.Value); err != nil {
+				return "", nil, fmt.Errorf("failed to encode JSON value for %s: %w", column, err)
+			}
+		}
+
+		switch strings.ToLower(dialect) {
+		case "postgres", "pg", "postgresql":
+			path := postgresTextArray(op.Path)
+			if op.Remove {
+				expr = fmt.Sprintf("(%s #- ?::text[])", expr)
+				args = append(args, path)
+			} else {
+				expr = fmt.Sprintf("jsonb_set(%s, ?::text[], ?::jsonb, true)", expr)
+				args = append(args, path, string(value))
+			}
+		case "sqlite", "sqlite3":
+			if op.Remove {
+				expr = fmt.Sprintf("json_remove(%s, ?)", expr)
+				args = append(args, jsonPathExpression(op.Path))
+			} else {
+				expr = fmt.Sprintf("json_set(%s, ?, json(?))", expr)
+				args = append(args, jsonPathExpression(op.Path), string(value))
+			}
+		case "mysql":
+			if op.Remove {
+				expr = fmt.Sprintf("JSON_REMOVE(%s, ?)", expr)
+				args = append(args, jsonPathExpression(op.Path))
+			} else {
+				expr = fmt.Sprintf("JSON_SET(%s, ?, CAST(? AS JSON))", expr)
+				args = append(args, jsonPathExpression(op.Path), string(value))
+			}
+		}
+	}
+
+	return expr, args, nil
+}
+
+// postgresTextArray formats a path as a PostgreSQL text[] literal, e.g. {"font","size"}
+func postgresTextArray(path []string) string {
+	quoted := make([]string, len(path))
+	for i, key := range path {
+		key = strings.ReplaceAll(key, `\`, `\\`)
+		quoted[i] = `"` + strings.ReplaceAll(key, `"`, `\"`) + `"`
+	}
+	return "{" + strings.Join(quoted, ",") + "}"
+}
+
+// jsonPathExpression formats a path as a SQLite/MySQL JSON path, e.g. $."font"."size";
+// numeric keys address array elements
+func jsonPathExpression(path []string) string {
+	var builder strings.Builder
+	builder.WriteString("$")
+	for _, key := range path {
+		if isArrayIndex(key) {
+			builder.WriteString("[" + key + "]")
+			continue
+		}
+		builder.WriteString(`."` + strings.ReplaceAll(key, `"`, `\"`) + `"`)
+	}
+	return builder.String()
+}
+
+func isArrayIndex(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, ch := range key {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
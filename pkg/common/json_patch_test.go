@@ -0,0 +1,106 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractJSONPatches(t *testing.T) {
+	data := map[string]interface{}{
+		"name":                   "n",
+		"settings->theme":        "dark",
+		`settings->'font'->size`: float64(12),
+		JSONPatchKey: map[string]interface{}{
+			"meta": map[string]interface{}{"legacy": nil, "tags": []interface{}{"a"}, "owner": map[string]interface{}{"id": float64(1)}},
+		},
+	}
+
+	values, patches, err := ExtractJSONPatches(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(values, map[string]interface{}{"name": "n"}) {
+		t.Errorf("values: got %v", values)
+	}
+	if len(data) != 4 {
+		t.Errorf("input data was modified: %v", data)
+	}
+
+	wantSettings := JSONPatch{
+		{Path: []string{"font", "size"}, Value: float64(12)},
+		{Path: []string{"theme"}, Value: "dark"},
+	}
+	if !reflect.DeepEqual(patches["settings"], wantSettings) {
+		t.Errorf("settings: got %+v", patches["settings"])
+	}
+	wantMeta := JSONPatch{
+		{Path: []string{"legacy"}, Remove: true},
+		{Path: []string{"owner", "id"}, Value: float64(1)},
+		{Path: []string{"tags"}, Value: []interface{}{"a"}},
+	}
+	if !reflect.DeepEqual(patches["meta"], wantMeta) {
+		t.Errorf("meta: got %+v", patches["meta"])
+	}
+
+	for name, invalid := range map[string]map[string]interface{}{
+		"replaced and patched": {"settings": "{}", "settings->theme": "dark"},
+		"empty path":           {"settings->": "dark"},
+		"non-object section":   {JSONPatchKey: "x"},
+		"non-object document":  {JSONPatchKey: map[string]interface{}{"settings": "x"}},
+	} {
+		if _, _, err := ExtractJSONPatches(invalid); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestJSONPatchSQL(t *testing.T) {
+	patch := JSONPatch{
+		{Path: []string{"font", "size"}, Value: 12},
+		{Path: []string{"legacy"}, Remove: true},
+	}
+
+	tests := []struct {
+		dialect  string
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			dialect:  "postgres",
+			wantSQL:  `(jsonb_set(COALESCE("settings", '{}'::jsonb), ?::text[], ?::jsonb, true) #- ?::text[])`,
+			wantArgs: []interface{}{`{"font","size"}`, "12", `{"legacy"}`},
+		},
+		{
+			dialect:  "sqlite",
+			wantSQL:  `json_remove(json_set(COALESCE("settings", '{}'), ?, json(?)), ?)`,
+			wantArgs: []interface{}{`$."font"."size"`, "12", `$."legacy"`},
+		},
+		{
+			dialect:  "mysql",
+			wantSQL:  `JSON_REMOVE(JSON_SET(COALESCE("settings", JSON_OBJECT()), ?, CAST(? AS JSON)), ?)`,
+			wantArgs: []interface{}{`$."font"."size"`, "12", `$."legacy"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			sql, args, err := JSONPatchSQL(tt.dialect, "settings", patch)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("sql: got %s, want %s", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args: got %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+
+	if _, _, err := JSONPatchSQL("mssql", "settings", patch); err == nil {
+		t.Error("expected an error for an unsupported dialect")
+	}
+	if got := jsonPathExpression([]string{"items", "0", "name"}); got != `$."items"[0]."name"` {
+		t.Errorf("array index path: got %s", got)
+	}
+}
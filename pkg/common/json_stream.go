@@ -0,0 +1,174 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"io"
+	"reflect"
+)
+
+// StreamJSONMinRows is the number of rows from which EncodeJSON streams a result set
+const StreamJSONMinRows = 256
+
+// streamBufferSize is the size of the buffer between a streamed response and its writer
+const streamBufferSize = 32 << 10
+
+// rowsPlaceholder stands in for the streamed rows while their envelope is encoded
+var rowsPlaceholder = json.RawMessage(`"\u0000rows\u0000"`)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// EncodeJSON writes data to w as JSON like json.Encoder.Encode, but streams large result sets:
+// a slice of at least StreamJSONMinRows rows, on its own, as the data of a Response or as a value
+// of a map, is encoded one row at a time instead of all at once in memory. The output is the
+// same. Other data is encoded into a buffer first, so nothing is written when it fails to
+// encode; a row that fails to encode leaves a streamed response truncated.
+func EncodeJSON(w io.Writer, data interface{}) error {
+	envelope, rows := streamedResponse(data)
+	if !rows.IsValid() {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(encoded, '\n'))
+		return err
+	}
+
+	stream := newJSONStream(w)
+	head, tail := []byte(nil), []byte("\n")
+	if envelope != nil {
+		if err := stream.enc.Encode(envelope); err != nil {
+			return err
+		}
+		encoded := stream.buf.Bytes()
+		at := bytes.Index(encoded, rowsPlaceholder)
+		if at < 0 {
+			return json.NewEncoder(w).Encode(data)
+		}
+		head = append(head, encoded[:at]...)
+		tail = append([]byte(nil), encoded[at+len(rowsPlaceholder):]...)
+	}
+
+	if _, err := stream.w.Write(head); err != nil {
+		return err
+	}
+	err := stream.rows(rows)
+	if err == nil {
+		_, err = stream.w.Write(tail)
+	}
+	// Flush what was written, also the rows before an error
+	if flushErr := stream.w.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// streamedResponse returns the rows of data to stream and the envelope they are the data of,
+// nil for rows on their own. The rows are invalid when data isn't streamed.
+func streamedResponse(data interface{}) (envelope interface{}, rows reflect.Value) {
+	switch value := data.(type) {
+	case Response:
+		if rows = streamedRows(value.Data); rows.IsValid() {
+			value.Data = rowsPlaceholder
+			envelope = value
+		}
+	case *Response:
+		if value != nil {
+			if rows = streamedRows(value.Data); rows.IsValid() {
+				response := *value
+				response.Data = rowsPlaceholder
+				envelope = response
+			}
+		}
+	case map[string]interface{}:
+		for key, item := range value {
+			if rows = streamedRows(item); rows.IsValid() {
+				fields := make(map[string]interface{}, len(value))
+				for k, v := range value {
+					fields[k] = v
+				}
+				fields[key] = rowsPlaceholder
+				envelope = fields
+				break
+			}
+		}
+	default:
+		rows = streamedRows(data)
+	}
+	return envelope, rows
+}
+
+// streamedRows returns the rows of data when it is a result set large enough to stream
+func streamedRows(data interface{}) reflect.Value {
+	value := reflect.ValueOf(data)
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return reflect.Value{}
+	}
+	// Byte slices are encoded as base64 and types with their own encoding as they choose
+	if value.Type().Elem().Kind() == reflect.Uint8 || hasJSONEncoding(value.Type()) || value.Len() < StreamJSONMinRows {
+		return reflect.Value{}
+	}
+	return value
+}
+
+// hasJSONEncoding reports whether typ or its pointer encodes itself
+func hasJSONEncoding(typ reflect.Type) bool {
+	for _, t := range []reflect.Type{typ, reflect.PointerTo(typ)} {
+		if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonStream encodes rows one by one into a reused buffer
+type jsonStream struct {
+	w   *bufio.Writer
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+func newJSONStream(w io.Writer) *jsonStream {
+	stream := &jsonStream{w: bufio.NewWriterSize(w, streamBufferSize)}
+	stream.enc = json.NewEncoder(&stream.buf)
+	return stream
+}
+
+// rows writes rows as a JSON array
+func (s *jsonStream) rows(rows reflect.Value) error {
+	if err := s.w.WriteByte('['); err != nil {
+		return err
+	}
+	for i := 0; i < rows.Len(); i++ {
+		if i > 0 {
+			if err := s.w.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		// Encode slice elements by address, as json.Marshal does, which also saves copying them
+		row := rows.Index(i)
+		if row.CanAddr() {
+			row = row.Addr()
+		}
+		s.buf.Reset()
+		if err := s.enc.Encode(row.Interface()); err != nil {
+			return err
+		}
+		// Leave out the newline of Encode
+		if _, err := s.w.Write(s.buf.Bytes()[:s.buf.Len()-1]); err != nil {
+			return err
+		}
+	}
+	return s.w.WriteByte(']')
+}
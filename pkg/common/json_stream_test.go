@@ -0,0 +1,84 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type streamRow struct {
+	ID    int     `json:"id"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score,omitempty"`
+	Note  *string `json:"note"`
+}
+
+// streamLabel encodes itself through a pointer receiver
+type streamLabel struct{ text string }
+
+func (l *streamLabel) MarshalJSON() ([]byte, error) { return json.Marshal("label:" + l.text) }
+
+// streamFailing fails to encode its rows from the second on
+type streamFailing int
+
+func (f streamFailing) MarshalJSON() ([]byte, error) {
+	if f > 0 {
+		return nil, fmt.Errorf("row %d", int(f))
+	}
+	return []byte("0"), nil
+}
+
+func TestEncodeJSON(t *testing.T) {
+	note := "<b>&"
+	rows := make([]streamRow, StreamJSONMinRows+1)
+	for i := range rows {
+		rows[i] = streamRow{ID: i, Name: fmt.Sprintf("row %d", i), Score: float64(i % 3)}
+	}
+	rows[7].Note = &note
+	labels := make([]streamLabel, StreamJSONMinRows)
+	for i := range labels {
+		labels[i].text = fmt.Sprint(i)
+	}
+
+	tests := []struct {
+		name     string
+		data     interface{}
+		streamed bool
+	}{
+		{"rows", rows, true},
+		{"pointer to rows", &rows, true},
+		{"response", Response{Success: true, Data: &rows, Metadata: &Metadata{Total: 300, Count: 257}}, true},
+		{"response pointer", &Response{Success: true, Data: rows}, true},
+		{"map", map[string]interface{}{"value": rows, "@odata.count": 300}, true},
+		{"maps as rows", []map[string]interface{}{{"id": 1}}, false},
+		{"pointer receiver marshaler", labels, true},
+		{"small", rows[:10], false},
+		{"small response", Response{Success: true, Data: rows[:3]}, false},
+		{"bytes", bytes.Repeat([]byte("x"), 2*StreamJSONMinRows), false},
+		{"nil", nil, false},
+		{"nil response", (*Response)(nil), false},
+		{"struct", rows[0], false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var expected, actual bytes.Buffer
+			require.NoError(t, json.NewEncoder(&expected).Encode(tt.data))
+			require.NoError(t, EncodeJSON(&actual, tt.data))
+			assert.Equal(t, expected.String(), actual.String())
+			_, rows := streamedResponse(tt.data)
+			assert.Equal(t, tt.streamed, rows.IsValid())
+		})
+	}
+
+	failing := make([]streamFailing, StreamJSONMinRows)
+	for i := range failing {
+		failing[i] = streamFailing(i)
+	}
+	var out bytes.Buffer
+	assert.Error(t, EncodeJSON(&out, Response{Success: true, Data: failing}))
+	assert.Equal(t, `{"success":true,"data":[0,`, out.String(), "the rows before the error are written")
+}
@@ -0,0 +1,187 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryTimeoutHeader sets the query timeout of a single request, e.g. "500ms", "5s" or "5" seconds
+const QueryTimeoutHeader = "X-Query-Timeout"
+
+// HandlerConfig holds the pagination and timeout guardrails and the error policy of a handler.
+// Zero values disable a guardrail.
+type HandlerConfig struct {
+	DefaultLimit int // Limit of reads without a limit
+	MaxLimit     int // Largest limit of a read, also applied to reads without a limit
+	MaxOffset    int // Largest offset of a read
+
+	QueryTimeout    time.Duration // Deadline of the queries of a request
+	MaxQueryTimeout time.Duration // Largest timeout a request may ask for with X-Query-Timeout
+
+	// RejectOutOfRange rejects reads above MaxLimit or MaxOffset instead of clamping them
+	RejectOutOfRange bool
+
+	ErrorDetail ErrorDetailLevel // How much of an internal error reaches the client
+	MapError    ErrorMapper      // Maps errors, e.g. database constraint violations, to client errors
+
+	// AllowExplain reports whether a request may ask for query plans with X-Explain, e.g. for
+	// privileged roles. nil rejects every X-Explain request with 403.
+	AllowExplain func(ctx context.Context) bool
+
+	// AllowDryRun reports whether a request may run with X-Dry-Run, e.g. for privileged roles.
+	// ctx is the context of the request as it reaches the handler, e.g. with the user of an
+	// authentication middleware. nil rejects every X-Dry-Run request with 403.
+	AllowDryRun func(ctx context.Context) bool
+
+	// SlowQueryThreshold logs queries running at least this long with their entity, options
+	// and duration, and passes them to OnSlowQuery
+	SlowQueryThreshold time.Duration
+	OnSlowQuery        SlowQueryFunc
+
+	// DescribeColumns completes the metadata of HandleGet with the columns the database reports
+	DescribeColumns ColumnDescriber
+
+	// IntrospectSchema merges the columns and indexes of the live database into the metadata of
+	// HandleGet, see IntrospectMetadata
+	IntrospectSchema bool
+
+	// ProtectedColumns are managed by the server; creates and updates setting them are rejected
+	ProtectedColumns []string
+
+	// AuditColumns sets the created and updated timestamp and user columns of models on creates
+	// and updates, see StampAudit
+	AuditColumns bool
+	// AuditUser returns the user of a request for the created_by and updated_by columns, the
+	// X-Only-Mine filter and the owner of saved views
+	AuditUser func(ctx context.Context) (string, bool)
+
+	// TenantColumn restricts every query on a table with this column, e.g. tenant_id, to the
+	// tenant returned by TenantResolver, see NewTenantDatabase
+	TenantColumn   string
+	TenantResolver TenantResolver
+
+	// Request limits, checked before a request is executed. Requests exceeding them are
+	// rejected with 413 (body and batch size) or 400, see RequestLimitError.
+	MaxBodyBytes    int64 // Largest request body
+	MaxBatchSize    int   // Most records of a create, batch or transaction
+	MaxFilters      int   // Most filters of a read, including filter groups and preload filters
+	MaxPreloadDepth int   // Deepest preload or expand, e.g. 2 allows "Orders.Items"
+
+	// WriteTransactions runs every write request in a single transaction that commits once the
+	// request succeeded, so hooks writing with the transaction of the request, see Transaction,
+	// commit or roll back with it. The response is held back until the commit.
+	WriteTransactions bool
+
+	// InsertChunkSize is the number of rows of the multi-row INSERT statements creating an
+	// array of records, DefaultInsertChunkSize when 0. A negative size inserts row by row.
+	InsertChunkSize int
+
+	// MinimalUpdates loads the record before an update by id and sets only the columns whose
+	// values change, listing them in the X-Changed-Columns header. An update changing nothing
+	// skips the UPDATE.
+	MinimalUpdates bool
+
+	// Compression compresses responses of the size of Compression.MinSize with gzip, deflate or
+	// an added encoding accepted by the client. nil sends responses uncompressed.
+	Compression *CompressionConfig
+
+	// ResponseEncoders adds or replaces the encoders of response media types requested with
+	// Accept, next to the built-in MessagePack and CBOR, see DefaultResponseEncoders
+	ResponseEncoders map[string]ResponseEncoder
+
+	// CustomSQL checks the custom WHERE conditions of reads, x-custom-sql-w and
+	// x-custom-sql-or, against the columns of the model and allowed functions, rejecting them
+	// with 400. nil applies them as sent, for trusted clients only.
+	CustomSQL *CustomSQLPolicy
+
+	// Views stores the saved views of reads, run with X-View or the view query parameter. nil
+	// disables saved views.
+	Views ViewStore
+
+	// CountCache keeps the totals of entities with CountCached for every handler of this config,
+	// so writes through one handler drop the totals of the others. nil gives every handler a
+	// cache of its own.
+	CountCache *CountCache
+
+	// Entities overrides these defaults per entity, keyed by "schema.entity" or entity
+	Entities map[string]EntityConfig
+	// UserRoles returns the roles of the user of a request, checked against EntityConfig.Roles
+	UserRoles func(ctx context.Context) []string
+}
+
+// ExplainAllowed reports whether the request of ctx may ask for query plans
+func (c HandlerConfig) ExplainAllowed(ctx context.Context) bool {
+	return c.AllowExplain != nil && c.AllowExplain(ctx)
+}
+
+// DryRunAllowed reports whether the request of ctx may run as a dry run
+func (c HandlerConfig) DryRunAllowed(ctx context.Context) bool {
+	return c.AllowDryRun != nil && c.AllowDryRun(ctx)
+}
+
+// ApplyLimits returns the limit and offset of a read within the guardrails. Without a limit
+// the DefaultLimit is used, or else the MaxLimit. Values out of range are clamped, or rejected
+// with an error when RejectOutOfRange is set.
+func (c HandlerConfig) ApplyLimits(limit, offset *int) (*int, *int, error) {
+	if limit == nil || *limit <= 0 {
+		switch {
+		case c.DefaultLimit > 0 && (c.MaxLimit <= 0 || c.DefaultLimit <= c.MaxLimit):
+			limit = &c.DefaultLimit
+		case c.MaxLimit > 0:
+			limit = &c.MaxLimit
+		}
+	} else if c.MaxLimit > 0 && *limit > c.MaxLimit {
+		if c.RejectOutOfRange {
+			return nil, nil, fmt.Errorf("limit %d exceeds the maximum of %d", *limit, c.MaxLimit)
+		}
+		limit = &c.MaxLimit
+	}
+
+	if offset != nil && c.MaxOffset > 0 && *offset > c.MaxOffset {
+		if c.RejectOutOfRange {
+			return nil, nil, fmt.Errorf("offset %d exceeds the maximum of %d", *offset, c.MaxOffset)
+		}
+		offset = &c.MaxOffset
+	}
+	return limit, offset, nil
+}
+
+// WithQueryTimeout returns the request context with the deadline of the query timeout. header is
+// the X-Query-Timeout value of the request, which may shorten the handler timeout or, up to
+// MaxQueryTimeout, extend it. The cancel function must be called when the request is done.
+func (c HandlerConfig) WithQueryTimeout(ctx context.Context, header string) (context.Context, context.CancelFunc, error) {
+	timeout := c.QueryTimeout
+	if header != "" {
+		requested, err := ParseQueryTimeout(header)
+		if err != nil {
+			return ctx, func() {}, err
+		}
+		timeout = requested
+		if c.MaxQueryTimeout > 0 && timeout > c.MaxQueryTimeout {
+			timeout = c.MaxQueryTimeout
+		} else if c.MaxQueryTimeout <= 0 && c.QueryTimeout > 0 && timeout > c.QueryTimeout {
+			timeout = c.QueryTimeout
+		}
+	}
+	if timeout <= 0 {
+		return ctx, func() {}, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, nil
+}
+
+// ParseQueryTimeout parses a query timeout given as a Go duration or in seconds
+func ParseQueryTimeout(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		value = fmt.Sprintf("%gs", seconds)
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid query timeout %q", value)
+	}
+	return timeout, nil
+}
@@ -0,0 +1,71 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerConfig_ApplyLimits(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	tests := []struct {
+		name       string
+		config     HandlerConfig
+		limit      *int
+		offset     *int
+		wantLimit  *int
+		wantOffset *int
+		wantErr    bool
+	}{
+		{name: "no guardrails", limit: nil, offset: intPtr(5), wantOffset: intPtr(5)},
+		{name: "default limit", config: HandlerConfig{DefaultLimit: 50, MaxLimit: 100}, wantLimit: intPtr(50)},
+		{name: "max limit without default", config: HandlerConfig{MaxLimit: 100}, limit: intPtr(0), wantLimit: intPtr(100)},
+		{name: "default above max", config: HandlerConfig{DefaultLimit: 500, MaxLimit: 100}, wantLimit: intPtr(100)},
+		{name: "limit in range", config: HandlerConfig{MaxLimit: 100}, limit: intPtr(20), wantLimit: intPtr(20)},
+		{name: "limit clamped", config: HandlerConfig{MaxLimit: 100}, limit: intPtr(1000), wantLimit: intPtr(100)},
+		{name: "limit rejected", config: HandlerConfig{MaxLimit: 100, RejectOutOfRange: true}, limit: intPtr(1000), wantErr: true},
+		{name: "offset clamped", config: HandlerConfig{MaxOffset: 10}, offset: intPtr(20), wantOffset: intPtr(10)},
+		{name: "offset rejected", config: HandlerConfig{MaxOffset: 10, RejectOutOfRange: true}, offset: intPtr(20), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, offset, err := tt.config.ApplyLimits(tt.limit, tt.offset)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLimit, limit)
+			assert.Equal(t, tt.wantOffset, offset)
+		})
+	}
+}
+
+func TestHandlerConfig_WithQueryTimeout(t *testing.T) {
+	remaining := func(config HandlerConfig, header string) time.Duration {
+		t.Helper()
+		ctx, cancel, err := config.WithQueryTimeout(context.Background(), header)
+		defer cancel()
+		require.NoError(t, err)
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return 0
+		}
+		return time.Until(deadline).Round(time.Second)
+	}
+
+	assert.Zero(t, remaining(HandlerConfig{}, ""), "no timeout")
+	assert.Equal(t, 30*time.Second, remaining(HandlerConfig{QueryTimeout: 30 * time.Second}, ""))
+	assert.Equal(t, 5*time.Second, remaining(HandlerConfig{QueryTimeout: 30 * time.Second}, "5"), "shortened by the request")
+	assert.Equal(t, 30*time.Second, remaining(HandlerConfig{QueryTimeout: 30 * time.Second}, "2m"), "capped at the handler timeout")
+	assert.Equal(t, 60*time.Second, remaining(HandlerConfig{QueryTimeout: 30 * time.Second, MaxQueryTimeout: time.Minute}, "2m"))
+	assert.Equal(t, 10*time.Second, remaining(HandlerConfig{}, "10s"))
+
+	_, cancel, err := HandlerConfig{}.WithQueryTimeout(context.Background(), "-1s")
+	cancel()
+	assert.Error(t, err)
+}
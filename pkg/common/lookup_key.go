@@ -0,0 +1,23 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// LookupKeyHeader names a unique column, e.g. email or code, to read a record by instead of its
+// primary key: GET /users/jane@example.com with X-Lookup-Key: email
+const LookupKeyHeader = "X-Lookup-Key"
+
+// LookupCondition renders the condition selecting the record with the given id: by the column
+// lookupKey, which the model must declare unique, or by the primary key when lookupKey is empty
+func LookupCondition(model interface{}, lookupKey string, id interface{}) (string, []interface{}, error) {
+	if lookupKey == "" {
+		return KeyCondition(model, id)
+	}
+	if !reflection.IsColumnUnique(model, lookupKey) {
+		return "", nil, fmt.Errorf("%s is not a unique column", lookupKey)
+	}
+	return fmt.Sprintf("%s = ?", QuoteIdent(lookupKey)), []interface{}{id}, nil
+}
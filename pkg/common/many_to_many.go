@@ -0,0 +1,228 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// ProcessManyToMany writes a many2many relation of the parent record through its join table.
+//
+// The relation value is a list of related IDs and/or related objects:
+//   - an ID, or an object with its primary key, attaches an existing record
+//   - an object without primary key, or with "_request": "insert", is inserted and attached
+//   - an object with "_request": "update" is updated and attached
+//   - an object with "_request": "delete" is detached; the related record itself is kept
+//
+// A list replaces the associations of the parent: join rows of records that are not in the
+// list are removed. A single object only attaches (or detaches) that record. When the parent
+// is deleted, all its join rows are removed.
+func (p *NestedCUDProcessor) ProcessManyToMany(
+	ctx context.Context,
+	operation string,
+	parentID interface{},
+	parentModelType reflect.Type,
+	relInfo *RelationshipInfo,
+	relationValue interface{},
+) error {
+	if relInfo.JoinTable == "" {
+		return fmt.Errorf("many2many relation %s has no join table", relInfo.FieldName)
+	}
+	if parentID == nil {
+		return fmt.Errorf("many2many relation %s requires the parent ID", relInfo.FieldName)
+	}
+
+	field, found := parentModelType.FieldByName(relInfo.FieldName)
+	if !found {
+		return fmt.Errorf("field %s not found in model", relInfo.FieldName)
+	}
+	relatedModelType := field.Type
+	for relatedModelType.Kind() == reflect.Slice || relatedModelType.Kind() == reflect.Ptr {
+		relatedModelType = relatedModelType.Elem()
+	}
+	relatedModel := reflect.New(relatedModelType).Elem().Interface()
+	relatedTableName := p.getTableNameForModel(relatedModel, relInfo.JSONName)
+	relatedPK := reflection.GetPrimaryKeyName(relatedModel)
+	if relatedPK == "" {
+		relatedPK = "id"
+	}
+
+	joinForeignKey, joinReferences := relInfo.JoinForeignKey, relInfo.JoinReferences
+	if joinForeignKey == "" {
+		joinForeignKey = joinColumnName(parentModelType, reflection.GetPrimaryKeyName(reflect.New(parentModelType).Elem().Interface()))
+	}
+	if joinReferences == "" {
+		joinReferences = joinColumnName(relatedModelType, relatedPK)
+	}
+	parentID = normalizeRelationID(parentID)
+
+	if strings.EqualFold(operation, "delete") {
+		return p.deleteJoinRows(ctx, relInfo.JoinTable, joinForeignKey, parentID, "")
+	}
+
+	var items []interface{}
+	replace := false
+	switch v := relationValue.(type) {
+	case []interface{}:
+		items, replace = v, true
+	case []map[string]interface{}:
+		for _, item := range v {
+			items = append(items, item)
+		}
+		replace = true
+	default:
+		items = []interface{}{v}
+	}
+
+	keep := make([]interface{}, 0, len(items))
+	for i, item := range items {
+		id := item
+		if itemMap, ok := item.(map[string]interface{}); ok {
+			request := p.extractCRUDRequest(itemMap)
+			id = itemMap[relatedPK]
+
+			switch {
+			case request == "delete":
+				// Within a list the record is detached by leaving it out
+				if id != nil && !replace {
+					condition := fmt.Sprintf("%s = ?", QuoteIdent(joinReferences))
+					if err := p.deleteJoinRows(ctx, relInfo.JoinTable, joinForeignKey, parentID, condition, normalizeRelationID(id)); err != nil {
+						return err
+					}
+				}
+				continue
+			case id == nil || request == "insert" || request == "create":
+				result, err := p.ProcessNestedCUD(ctx, "insert", itemMap, relatedModel, nil, relatedTableName)
+				if err != nil {
+					return fmt.Errorf("failed to insert %s[%d]: %w", relInfo.JSONName, i, err)
+				}
+				id = result.ID
+			case request == "update":
+				if _, err := p.ProcessNestedCUD(ctx, "update", itemMap, relatedModel, nil, relatedTableName); err != nil {
+					return fmt.Errorf("failed to update %s[%d]: %w", relInfo.JSONName, i, err)
+				}
+			}
+		}
+		if id == nil {
+			return fmt.Errorf("%s[%d] has no %s", relInfo.JSONName, i, relatedPK)
+		}
+		keep = append(keep, normalizeRelationID(id))
+	}
+
+	if replace {
+		condition := ""
+		var args []interface{}
+		if len(keep) > 0 {
+			condition = fmt.Sprintf("%s NOT IN (?)", QuoteIdent(joinReferences))
+			args = append(args, keep)
+		}
+		if err := p.deleteJoinRows(ctx, relInfo.JoinTable, joinForeignKey, parentID, condition, args...); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range keep {
+		exists, err := p.db.NewSelect().Table(relInfo.JoinTable).
+			Where(fmt.Sprintf("%s = ?", QuoteIdent(joinForeignKey)), parentID).
+			Where(fmt.Sprintf("%s = ?", QuoteIdent(joinReferences)), id).
+			Exists(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to check %s association: %w", relInfo.JoinTable, err)
+		}
+		if exists {
+			continue
+		}
+
+		logger.Debug("Attaching %s %v to %v", relInfo.JoinTable, id, parentID)
+		_, err = p.db.NewInsert().Table(relInfo.JoinTable).
+			Value(joinForeignKey, parentID).
+			Value(joinReferences, id).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to attach %s association: %w", relInfo.JoinTable, err)
+		}
+	}
+
+	return nil
+}
+
+// deleteJoinRows removes the join rows of the parent, restricted by an optional condition
+func (p *NestedCUDProcessor) deleteJoinRows(ctx context.Context, joinTable, joinForeignKey string, parentID interface{}, condition string, args ...interface{}) error {
+	query := p.db.NewDelete().Table(joinTable).Where(fmt.Sprintf("%s = ?", QuoteIdent(joinForeignKey)), parentID)
+	if condition != "" {
+		query = query.Where(condition, args...)
+	}
+
+	result, err := query.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to detach %s associations: %w", joinTable, err)
+	}
+	logger.Debug("Detached %d %s associations of %v", result.RowsAffected(), joinTable, parentID)
+	return nil
+}
+
+// joinColumnName returns the default join table column referencing a model, as GORM names
+// it: the snake_case model name followed by its primary key column, e.g. "employee_id"
+func joinColumnName(modelType reflect.Type, pkName string) string {
+	if pkName == "" {
+		pkName = "id"
+	}
+	return snakeCase(modelType.Name()) + "_" + pkName
+}
+
+// JoinColumnFromField converts the struct field name used in joinForeignKey/joinReferences
+// GORM tags or in the join of a Bun m2m tag (e.g. "EmployeeID", "Employee") to the join
+// table column ("employee_id")
+func JoinColumnFromField(fieldName string) string {
+	column := snakeCase(fieldName)
+	if !strings.HasSuffix(column, "_id") {
+		column += "_id"
+	}
+	return column
+}
+
+// ParseBunM2MTag extracts the join table and join columns from a Bun m2m tag,
+// e.g. "m2m:order_to_items,join:Order=Item" -> "order_to_items", "order_id", "item_id".
+// ok is false if the tag does not declare a m2m relation.
+func ParseBunM2MTag(tag string) (joinTable, joinForeignKey, joinReferences string, ok bool) {
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if value, found := strings.CutPrefix(part, "m2m:"); found {
+			joinTable, ok = value, true
+		} else if value, found := strings.CutPrefix(part, "join:"); found {
+			if left, right, found := strings.Cut(value, "="); found {
+				joinForeignKey, joinReferences = JoinColumnFromField(left), JoinColumnFromField(right)
+			}
+		}
+	}
+	return joinTable, joinForeignKey, joinReferences, ok
+}
+
+// normalizeRelationID converts integral JSON numbers to int64 so they compare with integer keys
+func normalizeRelationID(id interface{}) interface{} {
+	if f, ok := id.(float64); ok && f == float64(int64(f)) {
+		return int64(f)
+	}
+	return id
+}
+
+// snakeCase converts a PascalCase or camelCase name to snake_case, keeping acronyms together
+func snakeCase(s string) string {
+	var result strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			prevIsLower := runes[i-1] >= 'a' && runes[i-1] <= 'z'
+			nextIsLower := i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z'
+			if prevIsLower || nextIsLower {
+				result.WriteByte('_')
+			}
+		}
+		result.WriteRune(r)
+	}
+	return strings.ToLower(result.String())
+}
@@ -0,0 +1,78 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ChangedColumnsHeader lists the columns a minimal update changed, see HandlerConfig.MinimalUpdates
+const ChangedColumnsHeader = "X-Changed-Columns"
+
+// LoadRecord loads the record with the given primary key as a pointer to the model, see
+// KeyCondition for the ids of composite keys. found is false when no such record exists.
+func LoadRecord(ctx context.Context, db Database, model interface{}, id interface{}) (record interface{}, found bool, err error) {
+	where, args, err := KeyCondition(model, id)
+	if err != nil {
+		return nil, false, err
+	}
+	modelType := reflect.TypeOf(model)
+	for modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+
+	// Scan into a slice so a missing record is reported the same way by every adapter
+	records := reflect.New(reflect.SliceOf(reflect.PointerTo(modelType)))
+	query := db.NewSelect().Model(records.Interface()).Where(where, args...)
+	if err := query.ScanModel(ctx); err != nil {
+		return nil, false, err
+	}
+	if records.Elem().Len() == 0 {
+		return nil, false, nil
+	}
+	return records.Elem().Index(0).Interface(), true, nil
+}
+
+// ChangedColumns returns the sorted columns of update data whose values differ from the stored
+// record, and the data with only those columns, both without the primary key. Values are compared
+// by their JSON encoding; keys the encoding of the record lacks, like JSON paths, count as changed.
+func ChangedColumns(record interface{}, data map[string]interface{}, pkName string) ([]string, map[string]interface{}, error) {
+	var stored map[string]interface{}
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(encoded, &stored); err != nil {
+		return nil, nil, fmt.Errorf("record is not a JSON object: %w", err)
+	}
+
+	changed := make([]string, 0, len(data))
+	changes := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		if key == pkName {
+			continue
+		}
+		if current, ok := stored[key]; ok && sameJSON(current, value) {
+			continue
+		}
+		changed = append(changed, key)
+		changes[key] = value
+	}
+	sort.Strings(changed)
+	return changed, changes, nil
+}
+
+// sameJSON reports whether two values have the same JSON encoding
+func sameJSON(stored, value interface{}) bool {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(stored, decoded)
+}
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedColumns(t *testing.T) {
+	type note struct {
+		ID      int64     `json:"id"`
+		Title   string    `json:"title"`
+		Stars   int       `json:"stars"`
+		Tags    []string  `json:"tags"`
+		Created time.Time `json:"created"`
+	}
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	record := &note{ID: 1, Title: "a", Stars: 2, Tags: []string{"x"}, Created: created}
+
+	changed, changes, err := ChangedColumns(record, map[string]interface{}{
+		"id":              "1",
+		"title":           "a",
+		"stars":           float64(3),
+		"tags":            []interface{}{"x"},
+		"created":         created,
+		"settings->theme": "dark",
+	}, "id")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"settings->theme", "stars"}, changed)
+	assert.Equal(t, map[string]interface{}{"stars": float64(3), "settings->theme": "dark"}, changes)
+
+	changed, changes, err = ChangedColumns(record, map[string]interface{}{"title": "a", "stars": 2}, "id")
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+	assert.NotNil(t, changed, "an unchanged record has an empty list")
+	assert.Empty(t, changes)
+}
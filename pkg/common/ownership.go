@@ -0,0 +1,41 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// OnlyMineHeader restricts a read to the records owned by the user of the request
+const OnlyMineHeader = "X-Only-Mine"
+
+// OwnerFilter returns the filter group restricting a read of an entity to the records whose
+// EntityConfig.OwnerColumn holds the user of AuditUser, for an X-Only-Mine header value. It
+// returns nil when the header doesn't ask for it. Entities without an owner column and requests
+// without a user are rejected.
+func (c HandlerConfig) OwnerFilter(ctx context.Context, schema, entity, header string) (*FilterGroup, *EntityAccessError) {
+	if !strings.EqualFold(header, "true") && header != "1" {
+		return nil, nil
+	}
+	column := c.Entity(schema, entity).OwnerColumn
+	if column == "" {
+		return nil, &EntityAccessError{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_request",
+			Message: fmt.Sprintf("%s has no owner column for %s", entity, OnlyMineHeader),
+		}
+	}
+	user, ok := "", false
+	if c.AuditUser != nil {
+		user, ok = c.AuditUser(ctx)
+	}
+	if !ok || user == "" {
+		return nil, &EntityAccessError{
+			Status:  http.StatusUnauthorized,
+			Code:    "unauthorized",
+			Message: fmt.Sprintf("%s requires an authenticated user", OnlyMineHeader),
+		}
+	}
+	return &FilterGroup{Filters: []FilterOption{{Column: column, Operator: "eq", Value: user}}}, nil
+}
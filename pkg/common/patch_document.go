@@ -0,0 +1,298 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Content types of PATCH bodies that change a record instead of listing the columns to set
+const (
+	MergePatchContentType = "application/merge-patch+json" // RFC 7386 JSON Merge Patch
+	JSONPatchContentType  = "application/json-patch+json"  // RFC 6902 JSON Patch
+)
+
+// ErrPatchTestFailed is returned when a test operation of a JSON Patch does not match the record
+var ErrPatchTestFailed = errors.New("patch test failed")
+
+// PatchOperation is an operation of a JSON Patch document (RFC 6902)
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7386) to a decoded JSON document: objects are
+// merged recursively, null removes a member and other values replace it. target is not modified.
+func ApplyMergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, _ := target.(map[string]interface{})
+	result := make(map[string]interface{}, len(targetObject)+len(patchObject))
+	for key, value := range targetObject {
+		result[key] = value
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		result[key] = ApplyMergePatch(result[key], value)
+	}
+	return result
+}
+
+// ParsePatchOperations decodes the body of a JSON Patch, an array of operations
+func ParsePatchOperations(data interface{}) ([]PatchOperation, error) {
+	if _, ok := data.([]interface{}); !ok {
+		return nil, fmt.Errorf("a JSON Patch must be an array of operations, got %T", data)
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var operations []PatchOperation
+	if err := json.Unmarshal(body, &operations); err != nil {
+		return nil, fmt.Errorf("invalid JSON Patch: %w", err)
+	}
+	for _, operation := range operations {
+		switch operation.Op {
+		case "add", "remove", "replace", "test":
+		case "move", "copy":
+			if _, err := parseJSONPointer(operation.From); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unknown JSON Patch operation %q", operation.Op)
+		}
+		if _, err := parseJSONPointer(operation.Path); err != nil {
+			return nil, err
+		}
+	}
+	return operations, nil
+}
+
+// ApplyPatchOperations applies the operations of a JSON Patch (RFC 6902) to a decoded JSON
+// document in order. A failing operation fails the whole patch; doc is not modified.
+func ApplyPatchOperations(doc interface{}, operations []PatchOperation) (interface{}, error) {
+	doc = cloneJSONValue(doc)
+	for _, operation := range operations {
+		path, err := parseJSONPointer(operation.Path)
+		if err != nil {
+			return nil, err
+		}
+		switch operation.Op {
+		case "add":
+			doc, err = addJSONValue(doc, path, cloneJSONValue(operation.Value))
+		case "remove":
+			doc, _, err = removeJSONValue(doc, path)
+		case "replace":
+			if len(path) == 0 {
+				doc = cloneJSONValue(operation.Value)
+			} else if doc, _, err = removeJSONValue(doc, path); err == nil {
+				doc, err = addJSONValue(doc, path, cloneJSONValue(operation.Value))
+			}
+		case "move":
+			var value interface{}
+			from, _ := parseJSONPointer(operation.From)
+			if strings.HasPrefix(operation.Path, operation.From+"/") {
+				return nil, fmt.Errorf("can't move %s into its own child %s", operation.From, operation.Path)
+			}
+			if doc, value, err = removeJSONValue(doc, from); err == nil {
+				doc, err = addJSONValue(doc, path, value)
+			}
+		case "copy":
+			var value interface{}
+			from, _ := parseJSONPointer(operation.From)
+			if value, err = getJSONValue(doc, from); err == nil {
+				doc, err = addJSONValue(doc, path, cloneJSONValue(value))
+			}
+		case "test":
+			var value interface{}
+			if value, err = getJSONValue(doc, path); err == nil && !reflect.DeepEqual(value, operation.Value) {
+				err = fmt.Errorf("%w: value at %s differs", ErrPatchTestFailed, operation.Path)
+			}
+		default:
+			err = fmt.Errorf("unknown JSON Patch operation %q", operation.Op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", operation.Op, operation.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+// PatchChanges returns the members of a patched record that differ from the record before,
+// with null for removed members: the columns a patch sets
+func PatchChanges(before, after map[string]interface{}) map[string]interface{} {
+	changes := make(map[string]interface{})
+	for key, value := range after {
+		if previous, ok := before[key]; !ok || !reflect.DeepEqual(previous, value) {
+			changes[key] = value
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changes[key] = nil
+		}
+	}
+	return changes
+}
+
+// parseJSONPointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// jsonArrayIndex parses the index of an array member; "-" is the end of the array when allowed
+func jsonArrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	limit := length - 1
+	if allowEnd {
+		limit = length
+	}
+	if index > limit {
+		return 0, fmt.Errorf("array index %d out of range", index)
+	}
+	return index, nil
+}
+
+func getJSONValue(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q does not exist", token)
+			}
+			doc = value
+		case []interface{}:
+			index, err := jsonArrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[index]
+		default:
+			return nil, fmt.Errorf("member %q does not exist", token)
+		}
+	}
+	return doc, nil
+}
+
+// updateJSONValue replaces the parent container of the last token of path with the result of
+// update and returns the changed document
+func updateJSONValue(doc interface{}, path []string, update func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return update(doc, path[0])
+	}
+	child, err := getJSONValue(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	if child, err = updateJSONValue(child, path[1:], update); err != nil {
+		return nil, err
+	}
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		node[path[0]] = child
+	case []interface{}:
+		index, _ := jsonArrayIndex(path[0], len(node), false)
+		node[index] = child
+	}
+	return doc, nil
+}
+
+func addJSONValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateJSONValue(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			index, err := jsonArrayIndex(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		default:
+			return nil, fmt.Errorf("can't add member %q to %T", token, parent)
+		}
+	})
+}
+
+func removeJSONValue(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("can't remove the whole document")
+	}
+	var removed interface{}
+	doc, err := updateJSONValue(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q does not exist", token)
+			}
+			removed = value
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			index, err := jsonArrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[index]
+			return append(node[:index:index], node[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("member %q does not exist", token)
+		}
+	})
+	return doc, removed, err
+}
+
+// cloneJSONValue deep copies the objects and arrays of a decoded JSON value
+func cloneJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		clone := make(map[string]interface{}, len(v))
+		for key, member := range v {
+			clone[key] = cloneJSONValue(member)
+		}
+		return clone
+	case []interface{}:
+		clone := make([]interface{}, len(v))
+		for i, member := range v {
+			clone[i] = cloneJSONValue(member)
+		}
+		return clone
+	default:
+		return value
+	}
+}
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeJSON(t *testing.T, text string) interface{} {
+	t.Helper()
+	var value interface{}
+	require.NoError(t, json.Unmarshal([]byte(text), &value))
+	return value
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	// Examples of RFC 7386 appendix A
+	cases := []struct{ target, patch, result string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, c := range cases {
+		target := decodeJSON(t, c.target)
+		assert.Equal(t, decodeJSON(t, c.result), ApplyMergePatch(target, decodeJSON(t, c.patch)), c.patch)
+		assert.Equal(t, decodeJSON(t, c.target), target, "the target is not modified")
+	}
+}
+
+func TestApplyPatchOperations(t *testing.T) {
+	apply := func(doc, patch string) (interface{}, error) {
+		operations, err := ParsePatchOperations(decodeJSON(t, patch))
+		require.NoError(t, err)
+		return ApplyPatchOperations(decodeJSON(t, doc), operations)
+	}
+
+	// Examples of RFC 6902 appendix A
+	cases := []struct{ doc, patch, result string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{`{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`},
+	}
+	for _, c := range cases {
+		result, err := apply(c.doc, c.patch)
+		require.NoError(t, err, c.patch)
+		assert.Equal(t, decodeJSON(t, c.result), result, c.patch)
+	}
+
+	_, err := apply(`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":"2"}]`)
+	assert.True(t, errors.Is(err, ErrPatchTestFailed))
+	_, err = apply(`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`)
+	assert.Error(t, err, "the parent of an added member must exist")
+	_, err = apply(`{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`)
+	assert.Error(t, err)
+
+	_, err = ParsePatchOperations(decodeJSON(t, `[{"op":"merge","path":"/a"}]`))
+	assert.Error(t, err)
+	_, err = ParsePatchOperations(decodeJSON(t, `{"op":"add","path":"/a"}`))
+	assert.Error(t, err)
+}
+
+func TestPatchChanges(t *testing.T) {
+	before := map[string]interface{}{"id": 1.0, "title": "a", "stars": 2.0}
+	after := map[string]interface{}{"id": 1.0, "title": "b", "note": "new"}
+	assert.Equal(t, map[string]interface{}{"title": "b", "note": "new", "stars": nil}, PatchChanges(before, after))
+}
@@ -0,0 +1,186 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// PluginHost is the handler surface exposed to plugins
+// Both resolvespec.Handler and restheadspec.Handler implement it.
+// Plugins that need handler-specific features (e.g. restheadspec hooks)
+// can type-assert the host to the concrete handler type.
+type PluginHost interface {
+	GetDatabase() Database
+	GetRegistry() ModelRegistry
+}
+
+// RecordHost is implemented by plugin hosts that read and update single records for the routes
+// of plugins the way their own requests do: authenticated, scoped to the tenant of the request,
+// checked against the entity access and passed through the hooks of the host, e.g. row security.
+// Both resolvespec.Handler and restheadspec.Handler implement it. Failures are *RecordError.
+type RecordHost interface {
+	// ReadRecord returns the record of entity with the primary key id as a pointer to its model,
+	// and the context of the authenticated request
+	ReadRecord(r Request, schema, entity, id string) (context.Context, interface{}, error)
+	// UpdateRecord sets columns of the record of entity with the primary key id. The protected
+	// columns of the host are not checked, so plugins can set the columns they manage.
+	UpdateRecord(r Request, schema, entity, id string, values map[string]interface{}) error
+}
+
+// RecordError is a failed call of a RecordHost, answered with Status and Code
+type RecordError struct {
+	Status  int
+	Code    string
+	Message string
+	Err     error // Cause, logged and not sent
+}
+
+func (e *RecordError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Plugin is a third-party extension that snaps into a handler
+// Features such as audit, webhooks or caching can be shipped as separate
+// modules implementing this interface.
+type Plugin interface {
+	// Name returns the unique name of the plugin
+	Name() string
+
+	// Init is called once when the plugin is loaded into a handler
+	Init(host PluginHost) error
+
+	// RegisterHooks attaches the plugin's hooks to the handler
+	RegisterHooks(host PluginHost) error
+
+	// RegisterRoutes adds extra endpoints to the router
+	RegisterRoutes(router Router) error
+
+	// RegisterTypes registers the plugin's models with the model registry
+	RegisterTypes(registry ModelRegistry) error
+}
+
+// BasePlugin provides no-op implementations of the Plugin methods
+// Embed it in a plugin struct and override only the methods you need.
+type BasePlugin struct{}
+
+func (BasePlugin) Init(host PluginHost) error                 { return nil }
+func (BasePlugin) RegisterHooks(host PluginHost) error        { return nil }
+func (BasePlugin) RegisterRoutes(router Router) error         { return nil }
+func (BasePlugin) RegisterTypes(registry ModelRegistry) error { return nil }
+
+// PluginManager keeps track of the plugins loaded into a handler
+type PluginManager struct {
+	mutex   sync.RWMutex
+	plugins []Plugin
+}
+
+// NewPluginManager creates an empty plugin manager
+func NewPluginManager() *PluginManager {
+	return &PluginManager{}
+}
+
+// Load loads plugins into the host in order
+// For each plugin RegisterTypes, Init and RegisterHooks are called, in that order.
+// Loading stops at the first error; plugins loaded before it stay loaded.
+func (m *PluginManager) Load(host PluginHost, plugins ...Plugin) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, plugin := range plugins {
+		if plugin == nil {
+			continue
+		}
+		name := plugin.Name()
+		if m.indexOf(name) >= 0 {
+			return fmt.Errorf("plugin %s is already loaded", name)
+		}
+
+		if err := plugin.RegisterTypes(host.GetRegistry()); err != nil {
+			return fmt.Errorf("plugin %s: failed to register types: %w", name, err)
+		}
+		if err := plugin.Init(host); err != nil {
+			return fmt.Errorf("plugin %s: init failed: %w", name, err)
+		}
+		if err := plugin.RegisterHooks(host); err != nil {
+			return fmt.Errorf("plugin %s: failed to register hooks: %w", name, err)
+		}
+
+		m.plugins = append(m.plugins, plugin)
+		logger.Info("Loaded plugin: %s", name)
+	}
+	return nil
+}
+
+// RegisterRoutes lets every loaded plugin add its endpoints to the router
+func (m *PluginManager) RegisterRoutes(router Router) error {
+	for _, plugin := range m.Plugins() {
+		if err := plugin.RegisterRoutes(router); err != nil {
+			return fmt.Errorf("plugin %s: failed to register routes: %w", plugin.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Plugins returns the loaded plugins in load order
+func (m *PluginManager) Plugins() []Plugin {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := make([]Plugin, len(m.plugins))
+	copy(result, m.plugins)
+	return result
+}
+
+// Get returns the loaded plugin with the given name
+func (m *PluginManager) Get(name string) (Plugin, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if i := m.indexOf(name); i >= 0 {
+		return m.plugins[i], true
+	}
+	return nil, false
+}
+
+func (m *PluginManager) indexOf(name string) int {
+	for i, plugin := range m.plugins {
+		if plugin.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+var (
+	registeredPlugins      []Plugin
+	registeredPluginsMutex sync.RWMutex
+)
+
+// RegisterPlugin adds a plugin to the global plugin list
+// Plugin modules typically call this from an init() function so that
+// applications can load them with handler.Use(common.RegisteredPlugins()...).
+func RegisterPlugin(plugin Plugin) {
+	registeredPluginsMutex.Lock()
+	defer registeredPluginsMutex.Unlock()
+	registeredPlugins = append(registeredPlugins, plugin)
+}
+
+// RegisteredPlugins returns all globally registered plugins
+func RegisteredPlugins() []Plugin {
+	registeredPluginsMutex.RLock()
+	defer registeredPluginsMutex.RUnlock()
+
+	result := make([]Plugin, len(registeredPlugins))
+	copy(result, registeredPlugins)
+	return result
+}
@@ -0,0 +1,89 @@
+package common
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testPluginHost struct {
+	registry ModelRegistry
+}
+
+func (h *testPluginHost) GetDatabase() Database      { return nil }
+func (h *testPluginHost) GetRegistry() ModelRegistry { return h.registry }
+
+type testPlugin struct {
+	BasePlugin
+	name    string
+	calls   []string
+	initErr error
+}
+
+func (p *testPlugin) Name() string { return p.name }
+
+func (p *testPlugin) RegisterTypes(registry ModelRegistry) error {
+	p.calls = append(p.calls, "types")
+	return nil
+}
+
+func (p *testPlugin) Init(host PluginHost) error {
+	p.calls = append(p.calls, "init")
+	return p.initErr
+}
+
+func (p *testPlugin) RegisterHooks(host PluginHost) error {
+	p.calls = append(p.calls, "hooks")
+	return nil
+}
+
+func TestPluginManagerLoadOrder(t *testing.T) {
+	manager := NewPluginManager()
+	plugin := &testPlugin{name: "audit"}
+
+	if err := manager.Load(&testPluginHost{}, plugin); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := strings.Join(plugin.calls, ","); got != "types,init,hooks" {
+		t.Errorf("Expected calls types,init,hooks, got %s", got)
+	}
+
+	if _, ok := manager.Get("audit"); !ok {
+		t.Error("Expected plugin to be loaded")
+	}
+}
+
+func TestPluginManagerDuplicate(t *testing.T) {
+	manager := NewPluginManager()
+	host := &testPluginHost{}
+
+	if err := manager.Load(host, &testPlugin{name: "cache"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := manager.Load(host, &testPlugin{name: "cache"}); err == nil {
+		t.Error("Expected error when loading a plugin twice")
+	}
+	if len(manager.Plugins()) != 1 {
+		t.Errorf("Expected 1 plugin, got %d", len(manager.Plugins()))
+	}
+}
+
+func TestPluginManagerInitError(t *testing.T) {
+	manager := NewPluginManager()
+	plugin := &testPlugin{name: "webhooks", initErr: errors.New("boom")}
+
+	err := manager.Load(&testPluginHost{}, plugin)
+	if err == nil {
+		t.Fatal("Expected init error")
+	}
+	if !errors.Is(err, plugin.initErr) {
+		t.Errorf("Expected wrapped init error, got %v", err)
+	}
+	if strings.Contains(strings.Join(plugin.calls, ","), "hooks") {
+		t.Error("Expected hooks not to be registered after init failure")
+	}
+	if len(manager.Plugins()) != 0 {
+		t.Error("Expected failed plugin not to be loaded")
+	}
+}
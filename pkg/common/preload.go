@@ -0,0 +1,76 @@
+package common
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ApplyPreloadPagination applies a preload's offset and limit to the related records of
+// every loaded parent. Preloads are fetched with a single query for all parents, where
+// LIMIT and OFFSET would page the combined rows, so they are applied per parent after
+// loading. The relation is a dot separated path of field names or JSON names; records
+// may be a struct, a slice of structs, or pointers to either.
+func ApplyPreloadPagination(records interface{}, relation string, offset, limit *int) {
+	start := 0
+	if offset != nil && *offset > 0 {
+		start = *offset
+	}
+	end := -1
+	if limit != nil && *limit > 0 {
+		end = start + *limit
+	}
+	if start == 0 && end < 0 {
+		return
+	}
+
+	paginateRelation(reflect.ValueOf(records), strings.Split(relation, "."), start, end)
+}
+
+func paginateRelation(val reflect.Value, path []string, start, end int) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			paginateRelation(val.Index(i), path, start, end)
+		}
+	case reflect.Struct:
+		field := relationField(val, path[0])
+		if !field.IsValid() {
+			return
+		}
+		if len(path) > 1 {
+			paginateRelation(field, path[1:], start, end)
+			return
+		}
+		if field.Kind() != reflect.Slice || !field.CanSet() {
+			return
+		}
+
+		length := field.Len()
+		from := min(start, length)
+		to := length
+		if end >= 0 {
+			to = min(end, length)
+		}
+		field.Set(field.Slice(from, to))
+	}
+}
+
+// relationField returns the struct field matching name by field name or JSON name
+func relationField(val reflect.Value, name string) reflect.Value {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if strings.EqualFold(field.Name, name) || (jsonName != "" && strings.EqualFold(jsonName, name)) {
+			return val.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
@@ -0,0 +1,73 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+type preloadTask struct {
+	Name string `json:"name"`
+}
+
+type preloadProject struct {
+	Name  string        `json:"name"`
+	Tasks []preloadTask `json:"tasks"`
+	Owner *preloadTask  `json:"owner"`
+}
+
+func taskNames(tasks []preloadTask) []string {
+	names := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		names = append(names, task.Name)
+	}
+	return names
+}
+
+func TestApplyPreloadPagination(t *testing.T) {
+	newProjects := func() []preloadProject {
+		return []preloadProject{
+			{Name: "p1", Tasks: []preloadTask{{"a"}, {"b"}, {"c"}}},
+			{Name: "p2", Tasks: []preloadTask{{"d"}}},
+			{Name: "p3"},
+		}
+	}
+	limit, offset, bigOffset := 2, 1, 5
+
+	projects := newProjects()
+	ApplyPreloadPagination(&projects, "Tasks", nil, &limit)
+	if got := taskNames(projects[0].Tasks); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("limit: got %v", got)
+	}
+	if got := taskNames(projects[1].Tasks); !reflect.DeepEqual(got, []string{"d"}) {
+		t.Errorf("limit beyond length: got %v", got)
+	}
+
+	projects = newProjects()
+	ApplyPreloadPagination(projects, "tasks", &offset, &limit)
+	if got := taskNames(projects[0].Tasks); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("offset and limit by json name: got %v", got)
+	}
+	if got := taskNames(projects[1].Tasks); len(got) != 0 {
+		t.Errorf("offset beyond length: got %v", got)
+	}
+
+	projects = newProjects()
+	ApplyPreloadPagination(&projects, "Tasks", &bigOffset, nil)
+	if got := taskNames(projects[0].Tasks); len(got) != 0 {
+		t.Errorf("offset only: got %v", got)
+	}
+
+	// Nested paths are followed through pointers and slices; non-slice relations are left alone
+	type portfolio struct {
+		Projects []*preloadProject `json:"projects"`
+	}
+	single := &portfolio{Projects: []*preloadProject{{Name: "p1", Tasks: []preloadTask{{"a"}, {"b"}, {"c"}}, Owner: &preloadTask{"o"}}}}
+	ApplyPreloadPagination(single, "Projects.Tasks", nil, &limit)
+	ApplyPreloadPagination(single, "Projects.Owner", nil, &limit)
+	if got := taskNames(single.Projects[0].Tasks); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("nested path: got %v", got)
+	}
+	if single.Projects[0].Owner == nil || single.Projects[0].Owner.Name != "o" {
+		t.Errorf("non-slice relation changed: %+v", single.Projects[0].Owner)
+	}
+}
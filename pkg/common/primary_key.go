@@ -0,0 +1,99 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// PKHeader addresses a record by its primary key instead of the URL, needed by keys containing a
+// slash: the key values separated by KeySeparator, or a JSON object of the key columns
+const PKHeader = "X-PK"
+
+// KeySeparator separates the values of a composite primary key in a URL, e.g. /order_lines/7,2
+const KeySeparator = ","
+
+// KeyValues splits the id of a record into the values of the primary key columns of the model,
+// in the order of reflection.GetPrimaryKeyNames. A single key column takes the id as it is; a
+// composite key takes "v1,v2" or a JSON object of all key columns.
+func KeyValues(model interface{}, id interface{}) ([]string, []interface{}, error) {
+	names := reflection.GetPrimaryKeyNames(model)
+	if len(names) == 0 {
+		return nil, nil, fmt.Errorf("model %T has no primary key", model)
+	}
+	if len(names) == 1 {
+		return names, []interface{}{id}, nil
+	}
+
+	text, ok := id.(string)
+	if !ok {
+		return nil, nil, fmt.Errorf("the key of %T has %d columns, got %T", model, len(names), id)
+	}
+	values := make([]interface{}, len(names))
+	if strings.HasPrefix(strings.TrimSpace(text), "{") {
+		var object map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &object); err != nil {
+			return nil, nil, fmt.Errorf("invalid key object: %w", err)
+		}
+		if len(object) != len(names) {
+			return nil, nil, fmt.Errorf("the key needs the columns %s", strings.Join(names, ", "))
+		}
+		for i, name := range names {
+			value, ok := object[name]
+			if !ok {
+				return nil, nil, fmt.Errorf("the key needs the columns %s", strings.Join(names, ", "))
+			}
+			values[i] = value
+		}
+		return names, values, nil
+	}
+
+	parts := strings.Split(text, KeySeparator)
+	if len(parts) != len(names) {
+		return nil, nil, fmt.Errorf("the key needs %d values (%s), got %d", len(names), strings.Join(names, ", "), len(parts))
+	}
+	for i, part := range parts {
+		values[i] = part
+	}
+	return names, values, nil
+}
+
+// KeyCondition renders the condition selecting the record with the given id, one comparison per
+// primary key column, see KeyValues
+func KeyCondition(model interface{}, id interface{}) (string, []interface{}, error) {
+	names, values, err := KeyValues(model, id)
+	if err != nil {
+		return "", nil, err
+	}
+	conditions := make([]string, len(names))
+	for i, name := range names {
+		conditions[i] = fmt.Sprintf("%s = ?", QuoteIdent(name))
+	}
+	return strings.Join(conditions, " AND "), values, nil
+}
+
+// RecordKey returns the id of a record given as a map of its columns: the values of the primary
+// key columns joined by KeySeparator. ok is false when a key column is missing.
+func RecordKey(model interface{}, record map[string]interface{}) (string, bool) {
+	names := reflection.GetPrimaryKeyNames(model)
+	if len(names) == 0 {
+		return "", false
+	}
+	values := make([]string, len(names))
+	for i, name := range names {
+		value, ok := record[name]
+		if !ok || value == nil {
+			return "", false
+		}
+		if number, ok := value.(float64); ok {
+			// JSON numbers decode as float64, keep large ids out of exponent notation
+			values[i] = strconv.FormatFloat(number, 'f', -1, 64)
+		} else {
+			values[i] = fmt.Sprintf("%v", value)
+		}
+	}
+	return strings.Join(values, KeySeparator), true
+}
@@ -0,0 +1,55 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type compositeKeyModel struct {
+	OrderID int    `json:"order_id" gorm:"column:order_id;primaryKey"`
+	LineNo  int    `json:"line_no" gorm:"column:line_no;primaryKey"`
+	Product string `json:"product" gorm:"column:product"`
+}
+
+type singleKeyModel struct {
+	ID   int    `json:"id" gorm:"column:id;primaryKey"`
+	Name string `json:"name" gorm:"column:name"`
+}
+
+func TestKeyCondition(t *testing.T) {
+	where, args, err := KeyCondition(singleKeyModel{}, "5")
+	require.NoError(t, err)
+	assert.Equal(t, `"id" = ?`, where)
+	assert.Equal(t, []interface{}{"5"}, args)
+
+	where, args, err = KeyCondition(compositeKeyModel{}, "7,2")
+	require.NoError(t, err)
+	assert.Equal(t, `"order_id" = ? AND "line_no" = ?`, where)
+	assert.Equal(t, []interface{}{"7", "2"}, args)
+
+	_, args, err = KeyCondition(compositeKeyModel{}, `{"line_no":2,"order_id":7}`)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{7.0, 2.0}, args)
+
+	_, _, err = KeyCondition(compositeKeyModel{}, "7")
+	assert.Error(t, err, "a composite key needs all its values")
+	_, _, err = KeyCondition(compositeKeyModel{}, `{"order_id":7,"product":"x"}`)
+	assert.Error(t, err)
+	_, _, err = KeyCondition(compositeKeyModel{}, 7)
+	assert.Error(t, err)
+}
+
+func TestRecordKey(t *testing.T) {
+	key, ok := RecordKey(compositeKeyModel{}, map[string]interface{}{"order_id": 7.0, "line_no": 12000000.0})
+	assert.True(t, ok)
+	assert.Equal(t, "7,12000000", key)
+
+	_, ok = RecordKey(compositeKeyModel{}, map[string]interface{}{"order_id": 7.0})
+	assert.False(t, ok)
+
+	key, ok = RecordKey(singleKeyModel{}, map[string]interface{}{"id": "a"})
+	assert.True(t, ok)
+	assert.Equal(t, "a", key)
+}
@@ -7,7 +7,9 @@ import (
 	"strings"
 
 	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
 	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+	"github.com/bitechdev/ResolveSpec/pkg/tracing"
 )
 
 // CRUDRequestProvider interface for models that provide CRUD request strings
@@ -28,7 +30,11 @@ type RelationshipInfo struct {
 	ForeignKey   string
 	References   string
 	JoinTable    string
-	RelatedModel interface{}
+	// Join table columns of many2many relations referencing the parent and the related
+	// record; defaults are derived from the model names (e.g. "employee_id", "project_id")
+	JoinForeignKey string
+	JoinReferences string
+	RelatedModel   interface{}
 }
 
 // NestedCUDProcessor handles recursive processing of nested object graphs
@@ -64,9 +70,15 @@ func (p *NestedCUDProcessor) ProcessNestedCUD(
 	model interface{},
 	parentIDs map[string]interface{}, // Parent IDs for foreign key resolution
 	tableName string,
-) (*ProcessResult, error) {
+) (_ *ProcessResult, err error) {
 	logger.Info("Processing nested CUD: operation=%s, table=%s", operation, tableName)
 
+	ctx, span := tracing.StartSpan(ctx, "resolvespec.nested_cud",
+		tracing.AttrOperation.String(operation),
+		tracing.AttrTable.String(tableName),
+	)
+	defer tracing.EndSpan(span, &err)
+
 	result := &ProcessResult{
 		Data:         make(map[string]interface{}),
 		RelationData: make(map[string]interface{}),
@@ -76,6 +88,7 @@ func (p *NestedCUDProcessor) ProcessNestedCUD(
 	if requestOp := p.extractCRUDRequest(data); requestOp != "" {
 		logger.Debug("Found _request override: %s", requestOp)
 		operation = requestOp
+		span.SetAttributes(tracing.AttrOperation.String(operation))
 	}
 
 	// Get model type for reflection
@@ -117,7 +130,7 @@ func (p *NestedCUDProcessor) ProcessNestedCUD(
 	// Process based on operation
 	switch strings.ToLower(operation) {
 	case "insert", "create":
-		id, err := p.processInsert(ctx, regularData, tableName)
+		id, err := p.processInsert(ctx, regularData, tableName, pkName)
 		if err != nil {
 			return nil, fmt.Errorf("insert failed: %w", err)
 		}
@@ -131,7 +144,7 @@ func (p *NestedCUDProcessor) ProcessNestedCUD(
 		}
 
 	case "update":
-		rows, err := p.processUpdate(ctx, regularData, tableName, data[pkName])
+		rows, err := p.processUpdate(ctx, regularData, tableName, pkName, data[pkName])
 		if err != nil {
 			return nil, fmt.Errorf("update failed: %w", err)
 		}
@@ -150,7 +163,7 @@ func (p *NestedCUDProcessor) ProcessNestedCUD(
 			return nil, fmt.Errorf("failed to process child relations before delete: %w", err)
 		}
 
-		rows, err := p.processDelete(ctx, tableName, data[pkName])
+		rows, err := p.processDelete(ctx, tableName, pkName, data[pkName])
 		if err != nil {
 			return nil, fmt.Errorf("delete failed: %w", err)
 		}
@@ -163,6 +176,7 @@ func (p *NestedCUDProcessor) ProcessNestedCUD(
 	}
 
 	logger.Info("Nested CUD completed: operation=%s, id=%v, rows=%d", operation, result.ID, result.AffectedRows)
+	span.SetAttributes(tracing.AttrRowsAffected.Int64(result.AffectedRows))
 	return result, nil
 }
 
@@ -176,25 +190,37 @@ func (p *NestedCUDProcessor) extractCRUDRequest(data map[string]interface{}) str
 	return ""
 }
 
+// descriptorProvider is implemented by registries that describe their models when they are
+// registered, see modelregistry.ModelDescriptor
+type descriptorProvider interface {
+	GetDescriptorByType(modelType reflect.Type) (*modelregistry.ModelDescriptor, error)
+}
+
+// descriptor returns the descriptor of a model type from the registry, or nil when the
+// registry doesn't describe it
+func (p *NestedCUDProcessor) descriptor(modelType reflect.Type) *modelregistry.ModelDescriptor {
+	if provider, ok := p.registry.(descriptorProvider); ok {
+		if descriptor, err := provider.GetDescriptorByType(modelType); err == nil {
+			return descriptor
+		}
+	}
+	return nil
+}
+
 // injectForeignKeys injects parent IDs into data for foreign key fields
 func (p *NestedCUDProcessor) injectForeignKeys(data map[string]interface{}, modelType reflect.Type, parentIDs map[string]interface{}) {
 	if len(parentIDs) == 0 {
 		return
 	}
 
-	// Iterate through model fields to find foreign key fields
-	for i := 0; i < modelType.NumField(); i++ {
-		field := modelType.Field(i)
-		jsonTag := field.Tag.Get("json")
-		jsonName := strings.Split(jsonTag, ",")[0]
-
+	inject := func(fieldName, jsonName string) {
 		// Check if this field is a foreign key and we have a parent ID for it
 		// Common patterns: DepartmentID, ManagerID, ProjectID, etc.
 		for parentKey, parentID := range parentIDs {
 			// Match field name patterns like "department_id" with parent key "department"
 			if strings.EqualFold(jsonName, parentKey+"_id") ||
 				strings.EqualFold(jsonName, parentKey+"id") ||
-				strings.EqualFold(field.Name, parentKey+"ID") {
+				strings.EqualFold(fieldName, parentKey+"ID") {
 				// Only inject if not already present
 				if _, exists := data[jsonName]; !exists {
 					logger.Debug("Injecting foreign key: %s = %v", jsonName, parentID)
@@ -203,6 +229,21 @@ func (p *NestedCUDProcessor) injectForeignKeys(data map[string]interface{}, mode
 			}
 		}
 	}
+
+	// Registered models list their columns in the descriptor
+	if descriptor := p.descriptor(modelType); descriptor != nil {
+		for _, column := range descriptor.Columns {
+			inject(column.Field, column.JSONName)
+		}
+		return
+	}
+
+	// Iterate through model fields to find foreign key fields
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+		jsonTag := field.Tag.Get("json")
+		inject(field.Name, strings.Split(jsonTag, ",")[0])
+	}
 }
 
 // processInsert handles insert operation
@@ -210,6 +251,7 @@ func (p *NestedCUDProcessor) processInsert(
 	ctx context.Context,
 	data map[string]interface{},
 	tableName string,
+	pkName string,
 ) (interface{}, error) {
 	logger.Debug("Inserting into %s with data: %+v", tableName, data)
 
@@ -219,31 +261,56 @@ func (p *NestedCUDProcessor) processInsert(
 		query = query.Value(key, value)
 	}
 
-	// Add RETURNING clause to get the inserted ID
-	query = query.Returning("id")
+	// Return the inserted row to get the generated ID and server-side defaults
+	query = query.Returning("*")
 
 	result, err := query.Exec(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("insert exec failed: %w", err)
 	}
 
+	// Merge the returned row into data so callers see the stored record
+	for key, value := range ReturnedRecord(nil, result) {
+		data[key] = value
+	}
+
 	// Try to get the ID
+	if pkName == "" {
+		pkName = "id"
+	}
 	var id interface{}
-	if lastID, err := result.LastInsertId(); err == nil && lastID > 0 {
+	if data[pkName] != nil {
+		id = data[pkName]
+	} else if lastID, err := result.LastInsertId(); err == nil && lastID > 0 {
 		id = lastID
-	} else if data["id"] != nil {
-		id = data["id"]
+		data[pkName] = lastID
 	}
 
 	logger.Debug("Insert successful, ID: %v, rows affected: %d", id, result.RowsAffected())
 	return id, nil
 }
 
+// ReturnedRecord returns data merged with the row returned by an insert, so the
+// response carries generated keys and server-side defaults. data is not modified.
+func ReturnedRecord(data map[string]interface{}, result Result) map[string]interface{} {
+	record := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		record[key] = value
+	}
+	if returning, ok := result.(ReturningResult); ok {
+		for key, value := range returning.Returned() {
+			record[key] = value
+		}
+	}
+	return record
+}
+
 // processUpdate handles update operation
 func (p *NestedCUDProcessor) processUpdate(
 	ctx context.Context,
 	data map[string]interface{},
 	tableName string,
+	pkName string,
 	id interface{},
 ) (int64, error) {
 	if id == nil {
@@ -252,7 +319,7 @@ func (p *NestedCUDProcessor) processUpdate(
 
 	logger.Debug("Updating %s with ID %v, data: %+v", tableName, id, data)
 
-	query := p.db.NewUpdate().Table(tableName).SetMap(data).Where(fmt.Sprintf("%s = ?", QuoteIdent(reflection.GetPrimaryKeyName(tableName))), id)
+	query := p.db.NewUpdate().Table(tableName).SetMap(data).Where(fmt.Sprintf("%s = ?", QuoteIdent(pkName)), id)
 
 	result, err := query.Exec(ctx)
 	if err != nil {
@@ -265,14 +332,14 @@ func (p *NestedCUDProcessor) processUpdate(
 }
 
 // processDelete handles delete operation
-func (p *NestedCUDProcessor) processDelete(ctx context.Context, tableName string, id interface{}) (int64, error) {
+func (p *NestedCUDProcessor) processDelete(ctx context.Context, tableName, pkName string, id interface{}) (int64, error) {
 	if id == nil {
 		return 0, fmt.Errorf("delete requires an ID")
 	}
 
 	logger.Debug("Deleting from %s with ID %v", tableName, id)
 
-	query := p.db.NewDelete().Table(tableName).Where(fmt.Sprintf("%s = ?", QuoteIdent(reflection.GetPrimaryKeyName(tableName))), id)
+	query := p.db.NewDelete().Table(tableName).Where(fmt.Sprintf("%s = ?", QuoteIdent(pkName)), id)
 
 	result, err := query.Exec(ctx)
 	if err != nil {
@@ -293,6 +360,7 @@ func (p *NestedCUDProcessor) processChildRelations(
 	relationData map[string]interface{},
 	parentModelType reflect.Type,
 ) error {
+	parentDescriptor := p.descriptor(parentModelType)
 	for relationName, relInfo := range relationFields {
 		relationValue, exists := relationData[relationName]
 		if !exists || relationValue == nil {
@@ -301,24 +369,39 @@ func (p *NestedCUDProcessor) processChildRelations(
 
 		logger.Debug("Processing relation: %s, type: %s", relationName, relInfo.RelationType)
 
-		// Get the related model
-		field, found := parentModelType.FieldByName(relInfo.FieldName)
-		if !found {
-			logger.Warn("Field %s not found in model", relInfo.FieldName)
+		if relInfo.RelationType == "many2many" {
+			if err := p.ProcessManyToMany(ctx, operation, parentID, parentModelType, relInfo, relationValue); err != nil {
+				return fmt.Errorf("failed to process relation %s: %w", relationName, err)
+			}
 			continue
 		}
 
-		// Get the model type for the relation
-		relatedModelType := field.Type
-		if relatedModelType.Kind() == reflect.Slice {
-			relatedModelType = relatedModelType.Elem()
-		}
-		if relatedModelType.Kind() == reflect.Ptr {
-			relatedModelType = relatedModelType.Elem()
+		// Get the related model, from the descriptor of registered models
+		var relatedModel interface{}
+		if parentDescriptor != nil {
+			if relation := parentDescriptor.Relation(relInfo.FieldName); relation != nil {
+				relatedModel = relation.Model
+			}
 		}
+		if relatedModel == nil {
+			field, found := parentModelType.FieldByName(relInfo.FieldName)
+			if !found {
+				logger.Warn("Field %s not found in model", relInfo.FieldName)
+				continue
+			}
+
+			// Get the model type for the relation
+			relatedModelType := field.Type
+			if relatedModelType.Kind() == reflect.Slice {
+				relatedModelType = relatedModelType.Elem()
+			}
+			if relatedModelType.Kind() == reflect.Ptr {
+				relatedModelType = relatedModelType.Elem()
+			}
 
-		// Create an instance of the related model
-		relatedModel := reflect.New(relatedModelType).Elem().Interface()
+			// Create an instance of the related model
+			relatedModel = reflect.New(relatedModelType).Elem().Interface()
+		}
 
 		// Get table name for related model
 		relatedTableName := p.getTableNameForModel(relatedModel, relInfo.JSONName)
@@ -419,6 +502,10 @@ func shouldUseNestedProcessorDepth(data map[string]interface{}, model interface{
 			// Check if the value is actually nested data (object or array)
 			switch v := value.(type) {
 			case map[string]interface{}, []interface{}, []map[string]interface{}:
+				// Many2many relations are written through their join table by the processor
+				if relInfo.RelationType == "many2many" {
+					return true
+				}
 				// If we're already at a nested level (depth > 0) and found a relation,
 				// that means we have multi-level nesting, so return true
 				if depth > 0 {
@@ -0,0 +1,127 @@
+package common
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// RelationMetadata describes a relation of a model in the metadata of HandleGet, so admin UIs
+// can render relationship pickers
+type RelationMetadata struct {
+	Name         string `json:"name"` // JSON name of the relation field
+	Type         string `json:"type"` // belongsTo, hasOne, hasMany or many2many
+	ForeignKey   string `json:"foreign_key,omitempty"`
+	References   string `json:"references,omitempty"`
+	JoinTable    string `json:"join_table,omitempty"`
+	TargetEntity string `json:"target_entity,omitempty"` // Registered name of the related model
+	Preloadable  bool   `json:"preloadable"`             // Can be loaded with preload
+	Expandable   bool   `json:"expandable"`              // Can be joined into the main query with expand
+}
+
+// ModelRelations returns the relations of model as resolved by provider. expandable reports
+// whether a relation can be joined with expand; nil marks every relation as not expandable.
+func ModelRelations(model interface{}, registry ModelRegistry, provider RelationshipInfoProvider, expandable func(info *RelationshipInfo) bool) []RelationMetadata {
+	relations := make([]RelationMetadata, 0)
+	modelType := reflect.TypeOf(model)
+	for modelType != nil && (modelType.Kind() == reflect.Ptr || modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Array) {
+		modelType = modelType.Elem()
+	}
+	if modelType == nil || modelType.Kind() != reflect.Struct {
+		return relations
+	}
+
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if !field.IsExported() || jsonName == "-" || jsonName == "" {
+			continue
+		}
+		info := provider.GetRelationshipInfo(modelType, jsonName)
+		if info == nil {
+			info = bunRelationshipInfo(field, jsonName)
+		}
+		if info == nil || info.RelationType == "" {
+			continue
+		}
+
+		relation := RelationMetadata{
+			Name:        jsonName,
+			Type:        info.RelationType,
+			ForeignKey:  info.ForeignKey,
+			References:  info.References,
+			JoinTable:   info.JoinTable,
+			Preloadable: true,
+		}
+		if related := relatedStructType(field.Type); related != nil {
+			relation.TargetEntity = RegisteredName(registry, related)
+		}
+		if expandable != nil {
+			relation.Expandable = expandable(info)
+		}
+		relations = append(relations, relation)
+	}
+	return relations
+}
+
+// RegisteredName returns the name the model type was registered under, or "" when it
+// isn't registered. With several registrations the first name in sort order is returned.
+func RegisteredName(registry ModelRegistry, modelType reflect.Type) string {
+	if registry == nil {
+		return ""
+	}
+	names := make([]string, 0, 1)
+	for name, model := range registry.GetAllModels() {
+		registered := reflect.TypeOf(model)
+		for registered != nil && (registered.Kind() == reflect.Ptr || registered.Kind() == reflect.Slice) {
+			registered = registered.Elem()
+		}
+		if registered == modelType {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// bunRelationshipInfo reads a relation from the rel: or m2m: option of a bun tag
+func bunRelationshipInfo(field reflect.StructField, jsonName string) *RelationshipInfo {
+	info := &RelationshipInfo{FieldName: field.Name, JSONName: jsonName}
+	for _, part := range strings.Split(field.Tag.Get("bun"), ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == "rel:belongs-to":
+			info.RelationType = "belongsTo"
+		case part == "rel:has-one":
+			info.RelationType = "hasOne"
+		case part == "rel:has-many":
+			info.RelationType = "hasMany"
+		case strings.HasPrefix(part, "m2m:"):
+			info.RelationType = "many2many"
+			info.JoinTable = strings.TrimPrefix(part, "m2m:")
+		case strings.HasPrefix(part, "join:"):
+			pair := strings.SplitN(strings.TrimPrefix(part, "join:"), "=", 2)
+			if len(pair) == 2 {
+				info.ForeignKey, info.References = pair[0], pair[1]
+			}
+		}
+	}
+	if info.RelationType == "" {
+		return nil
+	}
+	return info
+}
+
+// relatedStructType returns the struct type of a relation field
+func relatedStructType(fieldType reflect.Type) reflect.Type {
+	for fieldType.Kind() == reflect.Ptr || fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType.Kind() != reflect.Struct {
+		return nil
+	}
+	return fieldType
+}
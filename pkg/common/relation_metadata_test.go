@@ -0,0 +1,55 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type relationAuthor struct {
+	ID    int64          `bun:"id,pk" json:"id"`
+	Books []relationBook `bun:"rel:has-many,join:id=author_id" json:"books"`
+}
+
+type relationBook struct {
+	ID       int64           `bun:"id,pk" json:"id"`
+	AuthorID int64           `bun:"author_id" json:"author_id"`
+	Author   *relationAuthor `bun:"rel:belongs-to,join:author_id=id" json:"author"`
+	Tags     []string        `bun:"tags,array" json:"tags"`
+}
+
+type noRelations struct{}
+
+func (noRelations) GetRelationshipInfo(reflect.Type, string) *RelationshipInfo { return nil }
+
+type relationRegistry map[string]interface{}
+
+func (r relationRegistry) RegisterModel(string, interface{}) error              { return nil }
+func (r relationRegistry) GetModel(string) (interface{}, error)                 { return nil, nil }
+func (r relationRegistry) GetAllModels() map[string]interface{}                 { return r }
+func (r relationRegistry) GetModelByEntity(string, string) (interface{}, error) { return nil, nil }
+
+func TestModelRelations(t *testing.T) {
+	registry := relationRegistry{"authors": relationAuthor{}, "books": relationBook{}}
+	relations := ModelRelations(relationBook{}, registry, noRelations{}, func(info *RelationshipInfo) bool {
+		return info.RelationType == "belongsTo"
+	})
+
+	assert.Equal(t, []RelationMetadata{{
+		Name:         "author",
+		Type:         "belongsTo",
+		ForeignKey:   "author_id",
+		References:   "id",
+		TargetEntity: "authors",
+		Preloadable:  true,
+		Expandable:   true,
+	}}, relations, "array columns are not relations")
+
+	relations = ModelRelations(relationAuthor{}, registry, noRelations{}, nil)
+	if assert.Len(t, relations, 1) {
+		assert.Equal(t, "hasMany", relations[0].Type)
+		assert.Equal(t, "books", relations[0].TargetEntity)
+		assert.False(t, relations[0].Expandable)
+	}
+}
@@ -0,0 +1,73 @@
+package common
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// RequestIDHeader carries the request id of a request and its response
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits the length of incoming request ids
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// NewRequestID generates a random request id
+func NewRequestID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// RequestIDOf returns the incoming request id of a request, or a new one when the header is
+// empty or not a short string of letters, digits and -_.:
+func RequestIDOf(header string) string {
+	if header == "" || len(header) > maxRequestIDLength {
+		return NewRequestID()
+	}
+	for _, c := range header {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == ':':
+		default:
+			return NewRequestID()
+		}
+	}
+	return header
+}
+
+// WithRequestID returns a context carrying the request id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request id of the context, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestIDWriter is the response writer of a request with a request id
+type requestIDWriter struct {
+	ResponseWriter
+	requestID string
+}
+
+// NewRequestIDWriter sets the X-Request-ID response header and returns a writer that
+// remembers the request id for error responses
+func NewRequestIDWriter(w ResponseWriter, requestID string) ResponseWriter {
+	w.SetHeader(RequestIDHeader, requestID)
+	return &requestIDWriter{ResponseWriter: w, requestID: requestID}
+}
+
+// RequestIDOfWriter returns the request id of a writer created by NewRequestIDWriter, or ""
+func RequestIDOfWriter(w ResponseWriter) string {
+	if rw, ok := w.(*requestIDWriter); ok {
+		return rw.requestID
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type headerWriter struct {
+	ResponseWriter
+	headers map[string]string
+}
+
+func (w *headerWriter) SetHeader(key, value string) { w.headers[key] = value }
+
+func TestRequestIDOf(t *testing.T) {
+	if id := RequestIDOf("req-1.a_b:c"); id != "req-1.a_b:c" {
+		t.Errorf("expected the incoming id, got %q", id)
+	}
+	for _, header := range []string{"", "bad id", "quote\"", strings.Repeat("a", maxRequestIDLength+1)} {
+		id := RequestIDOf(header)
+		if id == header || len(id) != 32 {
+			t.Errorf("expected a generated id for %q, got %q", header, id)
+		}
+	}
+	if NewRequestID() == NewRequestID() {
+		t.Error("expected different generated ids")
+	}
+}
+
+func TestRequestIDContextAndWriter(t *testing.T) {
+	if id := RequestIDFromContext(context.Background()); id != "" {
+		t.Errorf("expected no id, got %q", id)
+	}
+	if id := RequestIDFromContext(WithRequestID(context.Background(), "req-1")); id != "req-1" {
+		t.Errorf("expected req-1, got %q", id)
+	}
+
+	inner := &headerWriter{headers: map[string]string{}}
+	w := NewRequestIDWriter(inner, "req-2")
+	if inner.headers[RequestIDHeader] != "req-2" {
+		t.Errorf("expected the response header, got %v", inner.headers)
+	}
+	if id := RequestIDOfWriter(w); id != "req-2" {
+		t.Errorf("expected req-2, got %q", id)
+	}
+	if id := RequestIDOfWriter(inner); id != "" {
+		t.Errorf("expected no id, got %q", id)
+	}
+}
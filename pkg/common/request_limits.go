@@ -0,0 +1,151 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// recursivePreloadDepth is the number of levels a recursive preload nests
+const recursivePreloadDepth = 5
+
+// RequestLimitError is the rejection of a request exceeding a request limit of HandlerConfig
+type RequestLimitError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *RequestLimitError) Error() string {
+	return e.Message
+}
+
+// ReadBody reads the body of a request of at most MaxBodyBytes. Larger bodies are rejected with
+// a *RequestLimitError with 413, by their Content-Length before they are read.
+func (c HandlerConfig) ReadBody(r Request) ([]byte, error) {
+	if c.MaxBodyBytes <= 0 {
+		return r.Body()
+	}
+	tooLarge := &RequestLimitError{
+		Status:  http.StatusRequestEntityTooLarge,
+		Code:    "body_too_large",
+		Message: fmt.Sprintf("Request body exceeds %d bytes", c.MaxBodyBytes),
+	}
+	if length, err := strconv.ParseInt(r.Header("Content-Length"), 10, 64); err == nil && length > c.MaxBodyBytes {
+		return nil, tooLarge
+	}
+	// Bodies without a length stop being read at the limit
+	if unwrapper, ok := r.(HTTPRequestUnwrapper); ok {
+		if req := unwrapper.UnderlyingRequest(); req != nil && req.Body != nil {
+			req.Body = http.MaxBytesReader(nil, req.Body, c.MaxBodyBytes)
+		}
+	}
+	body, err := r.Body()
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) || int64(len(body)) > c.MaxBodyBytes {
+		return nil, tooLarge
+	}
+	return body, err
+}
+
+// CheckBatchSize rejects writes of more than MaxBatchSize records with 413. data is the
+// payload of a create, a batch ({"items": [...]}) or a transaction ({"operations": [...]}).
+func (c HandlerConfig) CheckBatchSize(data interface{}) *RequestLimitError {
+	if c.MaxBatchSize <= 0 {
+		return nil
+	}
+	count := 1
+	switch v := data.(type) {
+	case []interface{}:
+		count = len(v)
+	case map[string]interface{}:
+		for _, key := range []string{"items", "operations"} {
+			if list, ok := v[key].([]interface{}); ok {
+				count = len(list)
+			}
+		}
+	}
+	if count > c.MaxBatchSize {
+		return &RequestLimitError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Code:    "batch_too_large",
+			Message: fmt.Sprintf("Request has %d records, at most %d are allowed", count, c.MaxBatchSize),
+		}
+	}
+	return nil
+}
+
+// CheckQueryLimits rejects reads with filter groups nesting deeper than MaxFilterGroupDepth, with
+// more than MaxFilters filters, counting nested filter groups and the filters of preloads, or
+// with preloads nesting deeper than MaxPreloadDepth, with 400. relations are further relation
+// paths of the read, e.g. expands.
+func (c HandlerConfig) CheckQueryLimits(options RequestOptions, relations ...string) *RequestLimitError {
+	if options.FilterGroup.Depth() > MaxFilterGroupDepth {
+		return filterGroupTooDeep()
+	}
+	if c.MaxFilters > 0 {
+		count := len(options.Filters) + countGroupFilters(options.FilterGroup)
+		for _, preload := range options.Preload {
+			count += len(preload.Filters)
+		}
+		if count > c.MaxFilters {
+			return &RequestLimitError{
+				Status:  http.StatusBadRequest,
+				Code:    "too_many_filters",
+				Message: fmt.Sprintf("Request has %d filters, at most %d are allowed", count, c.MaxFilters),
+			}
+		}
+	}
+	if c.MaxPreloadDepth > 0 {
+		for _, preload := range options.Preload {
+			depth := relationDepth(preload.Relation)
+			if preload.Recursive {
+				depth += recursivePreloadDepth
+			}
+			if depth > c.MaxPreloadDepth {
+				return preloadTooDeep(preload.Relation, c.MaxPreloadDepth)
+			}
+		}
+		for _, relation := range relations {
+			if relationDepth(relation) > c.MaxPreloadDepth {
+				return preloadTooDeep(relation, c.MaxPreloadDepth)
+			}
+		}
+	}
+	return nil
+}
+
+func filterGroupTooDeep() *RequestLimitError {
+	return &RequestLimitError{
+		Status:  http.StatusBadRequest,
+		Code:    "filter_group_too_deep",
+		Message: fmt.Sprintf("Filter group nests deeper than %d levels", MaxFilterGroupDepth),
+	}
+}
+
+func preloadTooDeep(relation string, max int) *RequestLimitError {
+	return &RequestLimitError{
+		Status:  http.StatusBadRequest,
+		Code:    "preload_too_deep",
+		Message: fmt.Sprintf("Preload %s nests deeper than %d levels", relation, max),
+	}
+}
+
+// relationDepth returns the number of levels of a relation path, e.g. 2 for "Orders.Items"
+func relationDepth(relation string) int {
+	return strings.Count(relation, ".") + 1
+}
+
+// countGroupFilters returns the number of filters of a filter group and its nested groups
+func countGroupFilters(group *FilterGroup) int {
+	if group == nil {
+		return 0
+	}
+	count := len(group.Filters)
+	for i := range group.Groups {
+		count += countGroupFilters(&group.Groups[i])
+	}
+	return count
+}
@@ -0,0 +1,75 @@
+package common
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// limitTestRequest is a request wrapping a *http.Request
+type limitTestRequest struct {
+	Request
+	req *http.Request
+}
+
+func (r *limitTestRequest) Header(key string) string { return r.req.Header.Get(key) }
+func (r *limitTestRequest) Body() ([]byte, error)    { return io.ReadAll(r.req.Body) }
+func (r *limitTestRequest) UnderlyingRequest() *http.Request {
+	return r.req
+}
+
+func TestReadBody(t *testing.T) {
+	config := HandlerConfig{MaxBodyBytes: 10}
+
+	body, err := config.ReadBody(&limitTestRequest{req: httptest.NewRequest("POST", "/", strings.NewReader("0123456789"))})
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(body))
+
+	_, err = config.ReadBody(&limitTestRequest{req: httptest.NewRequest("POST", "/", strings.NewReader("0123456789a"))})
+	var limitErr *RequestLimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, limitErr.Status)
+
+	// Without a length the body is read up to the limit only
+	req := httptest.NewRequest("POST", "/", io.MultiReader(bytes.NewReader(make([]byte, 1<<20))))
+	req.ContentLength = -1
+	req.Header.Del("Content-Length")
+	_, err = config.ReadBody(&limitTestRequest{req: req})
+	require.ErrorAs(t, err, &limitErr)
+}
+
+func TestCheckBatchSize(t *testing.T) {
+	config := HandlerConfig{MaxBatchSize: 2}
+	assert.Nil(t, config.CheckBatchSize(map[string]interface{}{"title": "a"}))
+	assert.Nil(t, config.CheckBatchSize([]interface{}{1, 2}))
+	assert.NotNil(t, config.CheckBatchSize([]interface{}{1, 2, 3}))
+	assert.NotNil(t, config.CheckBatchSize(map[string]interface{}{"items": []interface{}{1, 2, 3}}))
+	assert.NotNil(t, config.CheckBatchSize(map[string]interface{}{"operations": []interface{}{1, 2, 3}}))
+	assert.Nil(t, HandlerConfig{}.CheckBatchSize([]interface{}{1, 2, 3}))
+}
+
+func TestCheckQueryLimits(t *testing.T) {
+	config := HandlerConfig{MaxFilters: 3, MaxPreloadDepth: 2}
+	options := RequestOptions{
+		Filters:     []FilterOption{{Column: "a"}},
+		FilterGroup: &FilterGroup{Filters: []FilterOption{{Column: "b"}}, Groups: []FilterGroup{{Filters: []FilterOption{{Column: "c"}}}}},
+		Preload:     []PreloadOption{{Relation: "Orders.Items"}},
+	}
+	assert.Nil(t, config.CheckQueryLimits(options))
+
+	options.Preload[0].Filters = []FilterOption{{Column: "d"}}
+	limitErr := config.CheckQueryLimits(options)
+	require.NotNil(t, limitErr)
+	assert.Equal(t, "too_many_filters", limitErr.Code)
+
+	options.Preload[0].Filters = nil
+	assert.Equal(t, "preload_too_deep", config.CheckQueryLimits(options, "Orders.Items.Product").Code)
+	options.Preload[0] = PreloadOption{Relation: "Parent", Recursive: true}
+	assert.Equal(t, "preload_too_deep", config.CheckQueryLimits(options).Code)
+}
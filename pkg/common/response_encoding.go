@@ -0,0 +1,296 @@
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ResponseEncoder writes the data of a response in a media type other than JSON. Data is given as
+// its JSON representation: maps, slices, strings, json.Number, bools and nil, so models keep
+// their JSON names.
+type ResponseEncoder func(w io.Writer, data interface{}) error
+
+// DefaultResponseEncoders returns the built-in encoders by media type: MessagePack and CBOR
+func DefaultResponseEncoders() map[string]ResponseEncoder {
+	return map[string]ResponseEncoder{
+		"application/msgpack":   EncodeMsgpack,
+		"application/x-msgpack": EncodeMsgpack,
+		"application/cbor":      EncodeCBOR,
+	}
+}
+
+// NegotiateResponseEncoder returns the media type and encoder of the response to a request with
+// the Accept header, among the built-in encoders and the ResponseEncoders of the config, or
+// "application/json" and nil for a JSON response. The highest quality wins; ties go to the
+// earlier media type of the header.
+func (c HandlerConfig) NegotiateResponseEncoder(accept string) (string, ResponseEncoder) {
+	type accepted struct {
+		mediaType string
+		quality   float64
+	}
+	var types []accepted
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if mediaType == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(param, "=")
+			if ok && strings.EqualFold(strings.TrimSpace(key), "q") {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			types = append(types, accepted{mediaType, quality})
+		}
+	}
+	sort.SliceStable(types, func(i, j int) bool { return types[i].quality > types[j].quality })
+
+	encoders := DefaultResponseEncoders()
+	for mediaType, encoder := range c.ResponseEncoders {
+		encoders[strings.ToLower(mediaType)] = encoder
+	}
+	for _, t := range types {
+		switch t.mediaType {
+		case "application/json", "application/*", "*/*":
+			return "application/json", nil
+		}
+		if encoder := encoders[t.mediaType]; encoder != nil {
+			return t.mediaType, encoder
+		}
+	}
+	return "application/json", nil
+}
+
+// jsonValue returns the JSON representation of data, with numbers as json.Number
+func jsonValue(data interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+// EncodeMsgpack writes data as MessagePack, with the keys of maps sorted
+func EncodeMsgpack(w io.Writer, data interface{}) error {
+	value, err := jsonValue(data)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := writeMsgpack(&buf, value); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func writeMsgpack(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			writeMsgpackInt(buf, i)
+		} else if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			buf.WriteByte(0xcf)
+			_ = binary.Write(buf, binary.BigEndian, u)
+		} else {
+			f, err := v.Float64()
+			if err != nil {
+				return err
+			}
+			buf.WriteByte(0xcb)
+			_ = binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+		}
+	case string:
+		writeMsgpackHeader(buf, len(v), 0xa0, 31, 0xd9, 0xda, 0xdb)
+		buf.WriteString(v)
+	case []interface{}:
+		writeMsgpackHeader(buf, len(v), 0x90, 15, 0, 0xdc, 0xdd)
+		for _, item := range v {
+			if err := writeMsgpack(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		writeMsgpackHeader(buf, len(v), 0x80, 15, 0, 0xde, 0xdf)
+		for _, key := range sortedKeys(v) {
+			if err := writeMsgpack(buf, key); err != nil {
+				return err
+			}
+			if err := writeMsgpack(buf, v[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cannot encode %T as MessagePack", value)
+	}
+	return nil
+}
+
+func writeMsgpackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i <= 127, i >= -32 && i < 0:
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		buf.WriteByte(0xd1)
+		_ = binary.Write(buf, binary.BigEndian, int16(i))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		buf.WriteByte(0xd2)
+		_ = binary.Write(buf, binary.BigEndian, int32(i))
+	default:
+		buf.WriteByte(0xd3)
+		_ = binary.Write(buf, binary.BigEndian, i)
+	}
+}
+
+// writeMsgpackHeader writes the type and length of a string, array or map: the fix type for
+// lengths up to fixMax, else the 8 (when the type has one), 16 or 32-bit type
+func writeMsgpackHeader(buf *bytes.Buffer, length int, fix byte, fixMax int, type8, type16, type32 byte) {
+	switch {
+	case length <= fixMax:
+		buf.WriteByte(fix | byte(length))
+	case type8 != 0 && length <= math.MaxUint8:
+		buf.WriteByte(type8)
+		buf.WriteByte(byte(length))
+	case length <= math.MaxUint16:
+		buf.WriteByte(type16)
+		_ = binary.Write(buf, binary.BigEndian, uint16(length))
+	default:
+		buf.WriteByte(type32)
+		_ = binary.Write(buf, binary.BigEndian, uint32(length))
+	}
+}
+
+// EncodeCBOR writes data as CBOR (RFC 8949), with the keys of maps sorted
+func EncodeCBOR(w io.Writer, data interface{}) error {
+	value, err := jsonValue(data)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := writeCBOR(&buf, value); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// CBOR major types
+const (
+	cborUnsigned byte = 0
+	cborNegative byte = 1
+	cborText     byte = 3
+	cborArray    byte = 4
+	cborMap      byte = 5
+)
+
+func writeCBOR(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteByte(0xf6)
+	case bool:
+		if v {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			if i >= 0 {
+				writeCBORHeader(buf, cborUnsigned, uint64(i))
+			} else {
+				writeCBORHeader(buf, cborNegative, uint64(-(i + 1)))
+			}
+		} else if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			writeCBORHeader(buf, cborUnsigned, u)
+		} else {
+			f, err := v.Float64()
+			if err != nil {
+				return err
+			}
+			buf.WriteByte(0xfb)
+			_ = binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+		}
+	case string:
+		writeCBORHeader(buf, cborText, uint64(len(v)))
+		buf.WriteString(v)
+	case []interface{}:
+		writeCBORHeader(buf, cborArray, uint64(len(v)))
+		for _, item := range v {
+			if err := writeCBOR(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		writeCBORHeader(buf, cborMap, uint64(len(v)))
+		for _, key := range sortedKeys(v) {
+			if err := writeCBOR(buf, key); err != nil {
+				return err
+			}
+			if err := writeCBOR(buf, v[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cannot encode %T as CBOR", value)
+	}
+	return nil
+}
+
+// writeCBORHeader writes a major type with its argument in the shortest form
+func writeCBORHeader(buf *bytes.Buffer, major byte, argument uint64) {
+	major <<= 5
+	switch {
+	case argument < 24:
+		buf.WriteByte(major | byte(argument))
+	case argument <= math.MaxUint8:
+		buf.WriteByte(major | 24)
+		buf.WriteByte(byte(argument))
+	case argument <= math.MaxUint16:
+		buf.WriteByte(major | 25)
+		_ = binary.Write(buf, binary.BigEndian, uint16(argument))
+	case argument <= math.MaxUint32:
+		buf.WriteByte(major | 26)
+		_ = binary.Write(buf, binary.BigEndian, uint32(argument))
+	default:
+		buf.WriteByte(major | 27)
+		_ = binary.Write(buf, binary.BigEndian, argument)
+	}
+}
+
+func sortedKeys(record map[string]interface{}) []string {
+	keys := make([]string, 0, len(record))
+	for key := range record {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,86 @@
+package common
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeMsgpack(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want []byte
+	}{
+		{"map", map[string]interface{}{"b": []interface{}{true, nil, "x"}, "a": 1}, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x93, 0xc3, 0xc0, 0xa1, 'x'}},
+		{"negative fixint", -1, []byte{0xff}},
+		{"int16", 200, []byte{0xd1, 0x00, 0xc8}},
+		{"int64", int64(1) << 40, []byte{0xd3, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{"float", 1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"str8", strings.Repeat("a", 40), append([]byte{0xd9, 40}, strings.Repeat("a", 40)...)},
+		{"struct by JSON name", struct {
+			Name string `json:"name"`
+		}{"x"}, []byte{0x81, 0xa4, 'n', 'a', 'm', 'e', 0xa1, 'x'}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, EncodeMsgpack(&buf, tt.data))
+			assert.Equal(t, tt.want, buf.Bytes())
+		})
+	}
+}
+
+func TestEncodeCBOR(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want []byte
+	}{
+		{"map", map[string]interface{}{"b": []interface{}{true, nil, "x"}, "a": 1}, []byte{0xa2, 0x61, 'a', 0x01, 0x61, 'b', 0x83, 0xf5, 0xf6, 0x61, 'x'}},
+		{"negative", -1, []byte{0x20}},
+		{"uint16", 500, []byte{0x19, 0x01, 0xf4}},
+		{"negative uint8", -100, []byte{0x38, 0x63}},
+		{"float", 1.5, []byte{0xfb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"text", strings.Repeat("a", 30), append([]byte{0x78, 30}, strings.Repeat("a", 30)...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, EncodeCBOR(&buf, tt.data))
+			assert.Equal(t, tt.want, buf.Bytes())
+		})
+	}
+}
+
+func TestNegotiateResponseEncoder(t *testing.T) {
+	custom := func(w io.Writer, data interface{}) error { return nil }
+	config := HandlerConfig{ResponseEncoders: map[string]ResponseEncoder{"application/x-custom": custom}}
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "application/json"},
+		{"application/msgpack", "application/msgpack"},
+		{"application/x-msgpack", "application/x-msgpack"},
+		{"application/cbor, application/json", "application/cbor"},
+		{"application/json, application/cbor", "application/json"},
+		{"application/json;q=0.5, application/cbor", "application/cbor"},
+		{"text/html, */*;q=0.8", "application/json"},
+		{"application/msgpack;q=0", "application/json"},
+		{"application/x-custom", "application/x-custom"},
+		{"text/csv", "application/json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			mediaType, encoder := config.NegotiateResponseEncoder(tt.accept)
+			assert.Equal(t, tt.want, mediaType)
+			assert.Equal(t, tt.want != "application/json", encoder != nil)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// ReturnRecordsHeader set to "true" answers updates and deletes by filter and deletes by id with
+// the affected records in "records" next to their count. Deleted records are selected before the
+// DELETE, updated records by their primary keys after the UPDATE.
+const ReturnRecordsHeader = "X-Return-Records"
+
+// LoadRecords loads the records of the model matching a condition as a slice of pointers to
+// the model. tableName is used for models without a TableName. An empty condition loads all
+// records.
+func LoadRecords(ctx context.Context, db Database, model interface{}, tableName, where string, args ...interface{}) ([]interface{}, error) {
+	modelType := reflect.TypeOf(model)
+	for modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+
+	records := reflect.New(reflect.SliceOf(reflect.PointerTo(modelType)))
+	query := db.NewSelect().Model(records.Interface())
+	if provider, ok := reflect.New(modelType).Interface().(TableNameProvider); !ok || provider.TableName() == "" {
+		query = query.Table(tableName)
+	}
+	if where != "" {
+		query = query.Where(where, args...)
+	}
+	if err := query.ScanModel(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load records: %w", err)
+	}
+
+	result := make([]interface{}, records.Elem().Len())
+	for i := range result {
+		result[i] = records.Elem().Index(i).Interface()
+	}
+	return result, nil
+}
+
+// FirstRecord returns a pointer to the first record of a list of records, or nil if it is empty
+func FirstRecord(records interface{}) interface{} {
+	value := reflect.ValueOf(records)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Slice || value.Len() == 0 {
+		return nil
+	}
+	first := value.Index(0)
+	if first.Kind() == reflect.Ptr {
+		return first.Interface()
+	}
+	record := reflect.New(first.Type())
+	record.Elem().Set(first)
+	return record.Interface()
+}
+
+// ReloadRecords loads the current state of records by their primary keys, e.g. after an update
+// changed the columns they were selected by
+func ReloadRecords(ctx context.Context, db Database, model interface{}, tableName, pkName string, records []interface{}) ([]interface{}, error) {
+	if len(records) == 0 {
+		return []interface{}{}, nil
+	}
+	keys := make([]interface{}, len(records))
+	placeholders := make([]string, len(records))
+	for i, record := range records {
+		keys[i] = reflection.GetPrimaryKeyValue(record)
+		placeholders[i] = "?"
+	}
+	where := fmt.Sprintf("%s IN (%s)", QuoteIdent(pkName), strings.Join(placeholders, ", "))
+	return LoadRecords(ctx, db, model, tableName, where, keys...)
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ScopeHeader selects named filters of the model of a read, separated by commas
+const ScopeHeader = "X-Scope"
+
+// ScopeProvider is implemented by models with named filters, e.g. "active" or "overdue", so
+// common filter combinations live next to the model instead of in every client
+type ScopeProvider interface {
+	Scopes() map[string]FilterGroup
+}
+
+// ModelScopes returns the named filters of a model, or nil when it has none
+func ModelScopes(model interface{}) map[string]FilterGroup {
+	modelType := reflect.TypeOf(model)
+	for modelType != nil && (modelType.Kind() == reflect.Ptr || modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Array) {
+		modelType = modelType.Elem()
+	}
+	if modelType == nil {
+		return nil
+	}
+	for _, candidate := range []interface{}{reflect.Zero(modelType).Interface(), reflect.New(modelType).Interface()} {
+		if provider, ok := candidate.(ScopeProvider); ok {
+			return provider.Scopes()
+		}
+	}
+	return nil
+}
+
+// ScopeNames returns the sorted names of the scopes of a model
+func ScopeNames(model interface{}) []string {
+	scopes := ModelScopes(model)
+	if len(scopes) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(scopes))
+	for name := range scopes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ScopeFilter returns the filter group of the named scopes of a model, combined with AND, or
+// nil without scopes. Unknown scopes are rejected with an error.
+func ScopeFilter(model interface{}, names []string) (*FilterGroup, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	scopes := ModelScopes(model)
+	var group *FilterGroup
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		scope, ok := scopes[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown scope %q", name)
+		}
+		group = AndFilterGroups(group, copyFilterGroup(scope))
+	}
+	return group, nil
+}
+
+// copyFilterGroup copies a group, so requests can't change the scopes of a model
+func copyFilterGroup(group FilterGroup) FilterGroup {
+	copied := FilterGroup{Logic: group.Logic, Filters: append([]FilterOption{}, group.Filters...)}
+	for _, sub := range group.Groups {
+		copied.Groups = append(copied.Groups, copyFilterGroup(sub))
+	}
+	return copied
+}
@@ -0,0 +1,54 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type scopedTask struct {
+	ID     int64  `bun:"id,pk"`
+	Status string `bun:"status"`
+	Due    string `bun:"due"`
+}
+
+func (scopedTask) Scopes() map[string]FilterGroup {
+	return map[string]FilterGroup{
+		"active":  {Filters: []FilterOption{{Column: "status", Operator: "eq", Value: "active"}}},
+		"overdue": {Filters: []FilterOption{{Column: "due", Operator: "lt", Value: "2024-01-01"}}},
+	}
+}
+
+type pointerScopedTask struct {
+	ID int64 `bun:"id,pk"`
+}
+
+func (*pointerScopedTask) Scopes() map[string]FilterGroup {
+	return map[string]FilterGroup{"all": {}}
+}
+
+func TestModelScopes(t *testing.T) {
+	assert.Equal(t, []string{"active", "overdue"}, ScopeNames(scopedTask{}))
+	assert.Equal(t, []string{"all"}, ScopeNames(pointerScopedTask{}), "pointer receivers")
+	assert.Nil(t, ScopeNames(tenantRecord{}))
+}
+
+func TestScopeFilter(t *testing.T) {
+	group, err := ScopeFilter(scopedTask{}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+
+	group, err = ScopeFilter(scopedTask{}, []string{"active"})
+	require.NoError(t, err)
+	require.Len(t, group.Filters, 1)
+	assert.Equal(t, "status", group.Filters[0].Column)
+
+	group, err = ScopeFilter(scopedTask{}, []string{"active", " overdue"})
+	require.NoError(t, err)
+	assert.Equal(t, "AND", group.Logic)
+	assert.Len(t, group.Groups, 2)
+
+	_, err = ScopeFilter(scopedTask{}, []string{"archived"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,153 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultSearchConfig is the PostgreSQL text search configuration used when none is given
+const DefaultSearchConfig = "simple"
+
+var searchConfigPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// FullTextSearch describes a full-text search over the columns of a table
+type FullTextSearch struct {
+	Query   string   // Search text as typed by the user
+	Columns []string // Columns to search; required on PostgreSQL and MySQL
+	Config  string   // PostgreSQL text search configuration, DefaultSearchConfig if empty
+}
+
+// FullTextSQL holds the SQL fragments of a full-text search. The search text is always
+// bound: Where takes Args, Rank takes RankArgs and Snippet takes SnippetArgs.
+type FullTextSQL struct {
+	Where       string
+	Args        []interface{}
+	Rank        string // Relevance of a row, higher is better
+	RankArgs    []interface{}
+	Snippet     string // Matching text with the search terms wrapped in <b></b>; empty if unsupported
+	SnippetArgs []interface{}
+}
+
+// BuildFullTextSearch builds a full-text search on table for the dialect:
+//   - postgres: to_tsvector over the columns matched with websearch_to_tsquery, ranked with
+//     ts_rank and highlighted with ts_headline
+//   - sqlite: MATCH on the FTS5 table <table>_fts, whose rowid must be the rowid of table,
+//     ranked with bm25 and highlighted with snippet
+//   - mysql: MATCH ... AGAINST in natural language mode on a FULLTEXT index of the columns
+func BuildFullTextSearch(dialect, table string, search FullTextSearch) (*FullTextSQL, error) {
+	text := strings.TrimSpace(search.Query)
+	if text == "" {
+		return nil, fmt.Errorf("empty search text")
+	}
+	for _, column := range search.Columns {
+		if !cqlAliasPattern.MatchString(column) {
+			return nil, fmt.Errorf("invalid search column %q", column)
+		}
+	}
+
+	switch strings.ToLower(dialect) {
+	case "postgres":
+		return postgresFullTextSearch(table, text, search)
+	case "sqlite":
+		return sqliteFullTextSearch(table, text, search.Columns)
+	case "mysql":
+		return mysqlFullTextSearch(table, text, search.Columns)
+	default:
+		return nil, fmt.Errorf("full-text search is not supported for dialect %q", dialect)
+	}
+}
+
+func postgresFullTextSearch(table, text string, search FullTextSearch) (*FullTextSQL, error) {
+	if len(search.Columns) == 0 {
+		return nil, fmt.Errorf("full-text search requires search columns")
+	}
+	config := search.Config
+	if config == "" {
+		config = DefaultSearchConfig
+	}
+	if !searchConfigPattern.MatchString(config) {
+		return nil, fmt.Errorf("invalid text search configuration %q", config)
+	}
+
+	qualified := make([]string, len(search.Columns))
+	for i, column := range search.Columns {
+		qualified[i] = QuoteIdent(table) + "." + QuoteIdent(column)
+	}
+	document := fmt.Sprintf("concat_ws(' ', %s)", strings.Join(qualified, ", "))
+	configLiteral := QuoteLiteral(config)
+	tsQuery := fmt.Sprintf("websearch_to_tsquery(%s, ?)", configLiteral)
+
+	return &FullTextSQL{
+		Where:       fmt.Sprintf("to_tsvector(%s, %s) @@ %s", configLiteral, document, tsQuery),
+		Args:        []interface{}{text},
+		Rank:        fmt.Sprintf("ts_rank(to_tsvector(%s, %s), %s)", configLiteral, document, tsQuery),
+		RankArgs:    []interface{}{text},
+		Snippet:     fmt.Sprintf("ts_headline(%s, %s, %s, 'StartSel=<b>, StopSel=</b>, MaxFragments=2')", configLiteral, document, tsQuery),
+		SnippetArgs: []interface{}{text},
+	}, nil
+}
+
+func sqliteFullTextSearch(table, text string, columns []string) (*FullTextSQL, error) {
+	match := sqliteMatchExpression(text, columns)
+	if match == "" {
+		return nil, fmt.Errorf("search text %q has no terms", text)
+	}
+
+	index := QuoteIdent(table + "_fts")
+	correlated := func(expr string) string {
+		return fmt.Sprintf("(SELECT %s FROM %s WHERE %s MATCH ? AND %s.rowid = %s.rowid)",
+			expr, index, index, index, QuoteIdent(table))
+	}
+
+	return &FullTextSQL{
+		Where: fmt.Sprintf("%s.rowid IN (SELECT rowid FROM %s WHERE %s MATCH ?)", QuoteIdent(table), index, index),
+		Args:  []interface{}{match},
+		// bm25 is lower for better matches
+		Rank:        correlated(fmt.Sprintf("-bm25(%s)", index)),
+		RankArgs:    []interface{}{match},
+		Snippet:     correlated(fmt.Sprintf("snippet(%s, -1, '<b>', '</b>', '...', 16)", index)),
+		SnippetArgs: []interface{}{match},
+	}, nil
+}
+
+// sqliteMatchExpression turns search text into an FTS5 query of quoted terms, so the
+// FTS5 query syntax can't be injected; a trailing * keeps its prefix meaning
+func sqliteMatchExpression(text string, columns []string) string {
+	terms := make([]string, 0)
+	for _, word := range strings.Fields(text) {
+		prefix := strings.HasSuffix(word, "*")
+		word = strings.ReplaceAll(strings.TrimRight(word, "*"), `"`, "")
+		if word == "" {
+			continue
+		}
+		term := `"` + word + `"`
+		if prefix {
+			term += "*"
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+
+	expr := strings.Join(terms, " ")
+	if len(columns) > 0 {
+		expr = fmt.Sprintf("{%s} : (%s)", strings.Join(columns, " "), expr)
+	}
+	return expr
+}
+
+func mysqlFullTextSearch(table, text string, columns []string) (*FullTextSQL, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("full-text search requires search columns")
+	}
+
+	qualified := make([]string, len(columns))
+	for i, column := range columns {
+		qualified[i] = QuoteDialectIdent("mysql", table) + "." + QuoteDialectIdent("mysql", column)
+	}
+	match := fmt.Sprintf("MATCH(%s) AGAINST(? IN NATURAL LANGUAGE MODE)", strings.Join(qualified, ", "))
+
+	return &FullTextSQL{Where: match, Args: []interface{}{text}, Rank: match, RankArgs: []interface{}{text}}, nil
+}
@@ -0,0 +1,89 @@
+package common
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildFullTextSearch(t *testing.T) {
+	t.Run("postgres", func(t *testing.T) {
+		sql, err := BuildFullTextSearch("postgres", "articles", FullTextSearch{
+			Query: "tomato soup", Columns: []string{"title", "body"}, Config: "english",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		document := `concat_ws(' ', "articles"."title", "articles"."body")`
+		if want := `to_tsvector('english', ` + document + `) @@ websearch_to_tsquery('english', ?)`; sql.Where != want {
+			t.Errorf("where: got %s", sql.Where)
+		}
+		if !reflect.DeepEqual(sql.Args, []interface{}{"tomato soup"}) {
+			t.Errorf("args: got %v", sql.Args)
+		}
+		if want := `ts_rank(to_tsvector('english', ` + document + `), websearch_to_tsquery('english', ?))`; sql.Rank != want {
+			t.Errorf("rank: got %s", sql.Rank)
+		}
+		if !reflect.DeepEqual(sql.RankArgs, []interface{}{"tomato soup"}) {
+			t.Errorf("rank args: got %v", sql.RankArgs)
+		}
+		if sql.Snippet == "" || !reflect.DeepEqual(sql.SnippetArgs, []interface{}{"tomato soup"}) {
+			t.Errorf("expected a snippet expression with bound text, got %s %v", sql.Snippet, sql.SnippetArgs)
+		}
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		sql, err := BuildFullTextSearch("sqlite", "articles", FullTextSearch{Query: `it's "wat* NEAR(`, Columns: []string{"title"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := `"articles".rowid IN (SELECT rowid FROM "articles_fts" WHERE "articles_fts" MATCH ?)`; sql.Where != want {
+			t.Errorf("where: got %s", sql.Where)
+		}
+		if !reflect.DeepEqual(sql.Args, []interface{}{`{title} : ("it's" "wat"* "NEAR(")`}) {
+			t.Errorf("args: got %v", sql.Args)
+		}
+		if strings.Contains(sql.Rank, "it's") || !reflect.DeepEqual(sql.RankArgs, sql.Args) {
+			t.Errorf("rank must bind the match expression, got %s %v", sql.Rank, sql.RankArgs)
+		}
+	})
+
+	t.Run("mysql", func(t *testing.T) {
+		// MySQL reads a backslash in a string literal as an escape, so the text is only bound
+		text := `soup\' OR 1=1 -- `
+		sql, err := BuildFullTextSearch("mysql", "articles", FullTextSearch{Query: text, Columns: []string{"title", "body"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := "MATCH(`articles`.`title`, `articles`.`body`) AGAINST(? IN NATURAL LANGUAGE MODE)"
+		if sql.Where != want {
+			t.Errorf("where: got %s", sql.Where)
+		}
+		if sql.Rank != want {
+			t.Errorf("rank: got %s", sql.Rank)
+		}
+		bound := []interface{}{strings.TrimSpace(text)}
+		if !reflect.DeepEqual(sql.Args, bound) || !reflect.DeepEqual(sql.RankArgs, bound) {
+			t.Errorf("args: got %v and %v", sql.Args, sql.RankArgs)
+		}
+		if sql.Snippet != "" {
+			t.Errorf("mysql has no snippets, got %s", sql.Snippet)
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		dialect string
+		search  FullTextSearch
+	}{
+		"empty text":          {"postgres", FullTextSearch{Query: "  ", Columns: []string{"title"}}},
+		"no columns":          {"postgres", FullTextSearch{Query: "x"}},
+		"invalid column":      {"postgres", FullTextSearch{Query: "x", Columns: []string{"title; drop"}}},
+		"invalid config":      {"postgres", FullTextSearch{Query: "x", Columns: []string{"title"}, Config: "english')"}},
+		"no terms":            {"sqlite", FullTextSearch{Query: `"" *`}},
+		"unsupported dialect": {"mssql", FullTextSearch{Query: "x", Columns: []string{"title"}}},
+	} {
+		if _, err := BuildFullTextSearch(tc.dialect, "articles", tc.search); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// SelectColumns returns the SELECT list of a read: the selected columns, or all SQL columns of
+// the model, without the omitted ones, so heavy columns such as blobs or large JSON documents are
+// not read at all. Columns are returned as they are when nothing is omitted. When every column
+// is omitted the primary key is kept, as an empty list would select all columns.
+func SelectColumns(model interface{}, columns, omit []string) []string {
+	if len(omit) == 0 {
+		return columns
+	}
+	if len(columns) == 0 {
+		columns = reflection.GetSQLModelColumns(model)
+	}
+	omitted := make(map[string]bool, len(omit))
+	for _, column := range omit {
+		omitted[strings.ToLower(strings.TrimSpace(column))] = true
+	}
+
+	selected := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if !omitted[strings.ToLower(column)] && !omitted[strings.ToLower(reflection.ExtractSourceColumn(column))] {
+			selected = append(selected, column)
+		}
+	}
+	if len(selected) == 0 {
+		if pk := reflection.GetPrimaryKeyName(model); pk != "" {
+			selected = append(selected, pk)
+		}
+	}
+	return selected
+}
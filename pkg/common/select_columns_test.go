@@ -0,0 +1,22 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type selectedDocument struct {
+	ID      int64  `bun:"id,pk"`
+	Name    string `bun:"name"`
+	Content []byte `bun:"content"`
+	Meta    string `bun:"meta,type:jsonb"`
+}
+
+func TestSelectColumns(t *testing.T) {
+	assert.Equal(t, []string{"id", "name"}, SelectColumns(selectedDocument{}, []string{"id", "name"}, nil), "nothing omitted")
+	assert.Nil(t, SelectColumns(selectedDocument{}, nil, nil), "all columns")
+	assert.Equal(t, []string{"id", "name"}, SelectColumns(selectedDocument{}, nil, []string{"Content", " meta"}))
+	assert.Equal(t, []string{"name"}, SelectColumns(selectedDocument{}, []string{"name", "meta->>'size'"}, []string{"meta"}), "JSON paths of omitted columns")
+	assert.Equal(t, []string{"id"}, SelectColumns(selectedDocument{}, []string{"content"}, []string{"content"}), "the primary key is kept")
+}
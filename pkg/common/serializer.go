@@ -0,0 +1,179 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Serializer transforms the records of an entity between the API and the model, see
+// EntityConfig.Serializer. Both methods change the record in place; records are keyed by the
+// JSON names of the model on the model side.
+type Serializer interface {
+	// Outbound transforms a record of a response, after the AfterRead, AfterCreate and
+	// AfterUpdate hooks
+	Outbound(ctx context.Context, record map[string]interface{}) error
+	// Inbound transforms a record of a create or update payload before it is checked and
+	// before the BeforeCreate and BeforeUpdate hooks
+	Inbound(ctx context.Context, record map[string]interface{}) error
+}
+
+// FieldMapping is a Serializer of field renames, dropped columns, time formats and inbound
+// coercions. Records of nested relations are not transformed.
+type FieldMapping struct {
+	// Rename maps columns to the fields of the API, e.g. "emp_no": "employeeNumber"
+	Rename map[string]string
+	// Drop lists internal columns, never sent to clients and ignored in payloads
+	Drop []string
+	// TimeFormat maps columns to the layout of their times, e.g. "birth_date": "2006-01-02";
+	// payloads are parsed with the same layout
+	TimeFormat map[string]string
+	// Coerce converts inbound values of columns, e.g. numbers sent as strings
+	Coerce map[string]func(value interface{}) (interface{}, error)
+}
+
+// Outbound drops, formats and renames the columns of a record
+func (m FieldMapping) Outbound(ctx context.Context, record map[string]interface{}) error {
+	for _, column := range m.Drop {
+		delete(record, column)
+	}
+	for column, layout := range m.TimeFormat {
+		switch value := record[column].(type) {
+		case time.Time:
+			record[column] = value.Format(layout)
+		case string:
+			if parsed, err := time.Parse(time.RFC3339Nano, value); err == nil {
+				record[column] = parsed.Format(layout)
+			}
+		}
+	}
+	renameFields(record, m.Rename, false)
+	return nil
+}
+
+// Inbound renames, drops, parses and coerces the fields of a payload record
+func (m FieldMapping) Inbound(ctx context.Context, record map[string]interface{}) error {
+	renameFields(record, m.Rename, true)
+	for _, column := range m.Drop {
+		delete(record, column)
+	}
+	for column, layout := range m.TimeFormat {
+		if text, ok := record[column].(string); ok && text != "" {
+			parsed, err := time.Parse(layout, text)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for %s, expected the format %s", text, column, layout)
+			}
+			record[column] = parsed
+		}
+	}
+	for column, coerce := range m.Coerce {
+		if value, ok := record[column]; ok {
+			coerced, err := coerce(value)
+			if err != nil {
+				return fmt.Errorf("invalid value for %s: %w", column, err)
+			}
+			record[column] = coerced
+		}
+	}
+	return nil
+}
+
+// renameFields renames the keys of a record from columns to fields, or back with inbound.
+// All keys are removed before any is set, so renames may swap names.
+func renameFields(record map[string]interface{}, rename map[string]string, inbound bool) {
+	renamed := make(map[string]interface{}, len(rename))
+	for column, field := range rename {
+		from, to := column, field
+		if inbound {
+			from, to = field, column
+		}
+		if value, ok := record[from]; ok {
+			delete(record, from)
+			renamed[to] = value
+		}
+	}
+	for key, value := range renamed {
+		record[key] = value
+	}
+}
+
+// Serialize returns the response data of an entity transformed by its Serializer: a record, a
+// list of records or the results of a batch read, given as models or maps. Data of entities
+// without a serializer is returned as it is.
+func (c HandlerConfig) Serialize(ctx context.Context, schema, entity string, data interface{}) (interface{}, error) {
+	serializer := c.Entity(schema, entity).Serializer
+	if serializer == nil || data == nil {
+		return data, nil
+	}
+	if results, ok := data.([]BatchReadResult); ok {
+		serialized := make([]BatchReadResult, len(results))
+		for i, result := range results {
+			serialized[i] = result
+			if result.Found {
+				var err error
+				if serialized[i].Data, err = c.Serialize(ctx, schema, entity, result.Data); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return serialized, nil
+	}
+
+	// Models become maps keyed by their JSON names; numbers keep their precision
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize %s: %w", entity, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to serialize %s: %w", entity, err)
+	}
+
+	switch v := decoded.(type) {
+	case map[string]interface{}:
+		return v, serializer.Outbound(ctx, v)
+	case []interface{}:
+		for _, item := range v {
+			if record, ok := item.(map[string]interface{}); ok {
+				if err := serializer.Outbound(ctx, record); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return v, nil
+	default:
+		return data, nil
+	}
+}
+
+// Deserialize transforms the records of a create or update payload, a record or a list of
+// records, with the Serializer of the entity
+func (c HandlerConfig) Deserialize(ctx context.Context, schema, entity string, data interface{}) error {
+	serializer := c.Entity(schema, entity).Serializer
+	if serializer == nil {
+		return nil
+	}
+	switch v := data.(type) {
+	case map[string]interface{}:
+		return serializer.Inbound(ctx, v)
+	case []map[string]interface{}:
+		for _, record := range v {
+			if err := serializer.Inbound(ctx, record); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if record, ok := item.(map[string]interface{}); ok {
+				if err := serializer.Inbound(ctx, record); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type serializedEmployee struct {
+	ID        int64     `json:"id"`
+	EmpNo     string    `json:"emp_no"`
+	Secret    string    `json:"secret"`
+	BirthDate time.Time `json:"birth_date"`
+	Level     int       `json:"level"`
+}
+
+var employeeMapping = FieldMapping{
+	Rename:     map[string]string{"emp_no": "employeeNumber", "birth_date": "birthDate"},
+	Drop:       []string{"secret"},
+	TimeFormat: map[string]string{"birth_date": "2006-01-02"},
+	Coerce: map[string]func(value interface{}) (interface{}, error){
+		"level": func(value interface{}) (interface{}, error) {
+			if text, ok := value.(string); ok {
+				return strconv.Atoi(text)
+			}
+			return value, nil
+		},
+	},
+}
+
+func TestSerialize(t *testing.T) {
+	ctx := context.Background()
+	config := HandlerConfig{Entities: map[string]EntityConfig{"employees": {Serializer: employeeMapping}}}
+	employee := serializedEmployee{ID: 9007199254740993, EmpNo: "E1", Secret: "x", BirthDate: time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC), Level: 3}
+
+	data, err := config.Serialize(ctx, "", "employees", []serializedEmployee{employee})
+	require.NoError(t, err)
+	records := data.([]interface{})
+	require.Len(t, records, 1)
+	record := records[0].(map[string]interface{})
+	assert.Equal(t, "E1", record["employeeNumber"])
+	assert.Equal(t, "1990-05-17", record["birthDate"])
+	assert.NotContains(t, record, "secret")
+	assert.NotContains(t, record, "emp_no")
+	assert.Equal(t, "9007199254740993", record["id"].(interface{ String() string }).String(), "large ids keep their precision")
+
+	data, err = config.Serialize(ctx, "", "employees", []BatchReadResult{{ID: "1", Found: true, Data: &employee}, {ID: "2"}})
+	require.NoError(t, err)
+	results := data.([]BatchReadResult)
+	assert.Equal(t, "E1", results[0].Data.(map[string]interface{})["employeeNumber"])
+	assert.Nil(t, results[1].Data)
+
+	data, err = config.Serialize(ctx, "", "departments", employee)
+	require.NoError(t, err)
+	assert.Equal(t, employee, data, "entities without a serializer")
+}
+
+func TestDeserialize(t *testing.T) {
+	ctx := context.Background()
+	config := HandlerConfig{Entities: map[string]EntityConfig{"employees": {Serializer: employeeMapping}}}
+
+	record := map[string]interface{}{"employeeNumber": "E2", "birthDate": "1985-01-02", "secret": "x", "level": "4"}
+	require.NoError(t, config.Deserialize(ctx, "", "employees", []interface{}{record}))
+	assert.Equal(t, map[string]interface{}{
+		"emp_no":     "E2",
+		"birth_date": time.Date(1985, 1, 2, 0, 0, 0, 0, time.UTC),
+		"level":      4,
+	}, record)
+
+	assert.Error(t, config.Deserialize(ctx, "", "employees", map[string]interface{}{"birthDate": "02.01.1985"}))
+	assert.Error(t, config.Deserialize(ctx, "", "employees", map[string]interface{}{"level": "high"}))
+}
+
+func TestRenameFieldsSwap(t *testing.T) {
+	record := map[string]interface{}{"a": 1, "b": 2}
+	renameFields(record, map[string]string{"a": "b", "b": "a"}, false)
+	assert.Equal(t, map[string]interface{}{"a": 2, "b": 1}, record)
+}
@@ -0,0 +1,161 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// BucketHeader groups the rows of a series read into periods of a timestamp column, e.g.
+// "day(created_at)"
+const BucketHeader = "X-Bucket"
+
+// MaxSeriesBuckets limits the buckets of a series
+const MaxSeriesBuckets = 1000
+
+// ErrTooManyBuckets is returned for series of more than MaxSeriesBuckets buckets
+var ErrTooManyBuckets = fmt.Errorf("the series has more than %d buckets: use a longer period or narrow the filters", MaxSeriesBuckets)
+
+// Bucket periods of series
+const (
+	BucketHour  = "hour"
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+)
+
+// SeriesPoint is the count and aggregates of the rows of one bucket of a series. Buckets are
+// labeled by their start: "2006-01-02T15:00" for hours, "2006-01-02" for days and weeks, which
+// start on Monday, and "2006-01" for months.
+type SeriesPoint struct {
+	Bucket     string                            `json:"bucket"`
+	Count      int64                             `json:"count"`
+	Aggregates map[string]map[string]interface{} `json:"aggregates,omitempty"`
+}
+
+// Series is the rows of a read counted per period of a timestamp column. Buckets without
+// rows are left out.
+type Series struct {
+	Column string        `json:"column"`
+	Period string        `json:"period"`
+	Points []SeriesPoint `json:"points"`
+}
+
+// ParseBucket parses a bucket like "day(created_at)" into its period and the column of
+// columns it names
+func ParseBucket(value string, columns []string) (period, column string, err error) {
+	match := callPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return "", "", fmt.Errorf("invalid bucket %q: use hour, day, week or month of a column, e.g. day(created_at)", value)
+	}
+	period = strings.ToLower(match[1])
+	switch period {
+	case BucketHour, BucketDay, BucketWeek, BucketMonth:
+	default:
+		return "", "", fmt.Errorf("unsupported bucket period %q: use hour, day, week or month", match[1])
+	}
+	if column = matchColumn(columns, match[2]); column == "" {
+		return "", "", fmt.Errorf("invalid bucket column %q", match[2])
+	}
+	return period, column, nil
+}
+
+// BucketExpression renders the label of the bucket of column in the SQL of a dialect:
+// date_trunc and to_char on PostgreSQL, strftime on SQLite, DATE_FORMAT on MySQL and CONVERT
+// on SQL Server
+func BucketExpression(dialect, period, column string) (string, error) {
+	switch dialect {
+	case "postgres":
+		switch period {
+		case BucketHour:
+			return fmt.Sprintf(`to_char(date_trunc('hour', %s), 'YYYY-MM-DD"T"HH24:00')`, column), nil
+		case BucketDay, BucketWeek:
+			return fmt.Sprintf("to_char(date_trunc('%s', %s), 'YYYY-MM-DD')", period, column), nil
+		case BucketMonth:
+			return fmt.Sprintf("to_char(date_trunc('month', %s), 'YYYY-MM')", column), nil
+		}
+	case "sqlite":
+		switch period {
+		case BucketHour:
+			return fmt.Sprintf("strftime('%%Y-%%m-%%dT%%H:00', %s)", column), nil
+		case BucketDay:
+			return fmt.Sprintf("strftime('%%Y-%%m-%%d', %s)", column), nil
+		case BucketWeek:
+			return fmt.Sprintf("date(%s, 'weekday 0', '-6 days')", column), nil
+		case BucketMonth:
+			return fmt.Sprintf("strftime('%%Y-%%m', %s)", column), nil
+		}
+	case "mysql":
+		switch period {
+		case BucketHour:
+			return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-%%dT%%H:00')", column), nil
+		case BucketDay:
+			return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-%%d')", column), nil
+		case BucketWeek:
+			return fmt.Sprintf("DATE_FORMAT(DATE_SUB(%s, INTERVAL WEEKDAY(%s) DAY), '%%Y-%%m-%%d')", column, column), nil
+		case BucketMonth:
+			return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m')", column), nil
+		}
+	case "mssql":
+		switch period {
+		case BucketHour:
+			return fmt.Sprintf("CONVERT(varchar(13), %s, 126) + ':00'", column), nil
+		case BucketDay:
+			return fmt.Sprintf("CONVERT(varchar(10), %s, 23)", column), nil
+		case BucketWeek:
+			return fmt.Sprintf("CONVERT(varchar(10), DATEADD(day, -((DATEPART(weekday, %s) + @@DATEFIRST + 5) %% 7), %s), 23)", column, column), nil
+		case BucketMonth:
+			return fmt.Sprintf("CONVERT(varchar(7), %s, 23)", column), nil
+		}
+	default:
+		return "", fmt.Errorf("series are not supported by dialect %q", dialect)
+	}
+	return "", fmt.Errorf("unsupported bucket period %q", period)
+}
+
+// ComputeSeries counts the rows of query per bucket of column, with the aggregates of each
+// bucket. query must select from the table of column without joins. Rows without a timestamp
+// are left out.
+func ComputeSeries(ctx context.Context, db Database, query SelectQuery, period, column string, aggregates []AggregateOption) (*Series, error) {
+	bucket, err := BucketExpression(DialectOf(db).Name, period, column)
+	if err != nil {
+		return nil, err
+	}
+	query = query.
+		ColumnExpr(bucket + " AS series_bucket").
+		ColumnExpr("COUNT(*) AS series_count")
+	for i, aggregate := range aggregates {
+		expression, err := aggregateExpression(aggregate, aggregate.Column, i)
+		if err != nil {
+			return nil, err
+		}
+		query = query.ColumnExpr(expression)
+	}
+	query = query.
+		Where(column + " IS NOT NULL").
+		Group(bucket).
+		Order("series_bucket ASC").
+		Limit(MaxSeriesBuckets + 1)
+
+	var rows []map[string]interface{}
+	if err := query.Scan(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to compute series of %s: %w", column, err)
+	}
+	if len(rows) > MaxSeriesBuckets {
+		return nil, ErrTooManyBuckets
+	}
+
+	series := &Series{Column: column, Period: period, Points: make([]SeriesPoint, 0, len(rows))}
+	for _, row := range rows {
+		count, err := facetCount(row["series_count"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute series of %s: %w", column, err)
+		}
+		point := SeriesPoint{Bucket: facetKey(row["series_bucket"]), Count: count}
+		if len(aggregates) > 0 {
+			point.Aggregates = aggregateResults(aggregates, row)
+		}
+		series.Points = append(series.Points, point)
+	}
+	return series, nil
+}
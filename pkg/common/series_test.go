@@ -0,0 +1,42 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBucket(t *testing.T) {
+	period, column, err := ParseBucket(" Week( Created_At ) ", []string{"id", "created_at"})
+	require.NoError(t, err)
+	assert.Equal(t, BucketWeek, period)
+	assert.Equal(t, "created_at", column)
+
+	for _, invalid := range []string{"", "created_at", "year(created_at)", "day(missing)", "day(created_at) OR 1=1"} {
+		_, _, err := ParseBucket(invalid, []string{"created_at"})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestBucketExpression(t *testing.T) {
+	for _, dialect := range []string{"postgres", "sqlite", "mysql", "mssql"} {
+		for _, period := range []string{BucketHour, BucketDay, BucketWeek, BucketMonth} {
+			expression, err := BucketExpression(dialect, period, "created_at")
+			require.NoError(t, err, dialect, period)
+			assert.Contains(t, expression, "created_at")
+		}
+	}
+
+	expression, err := BucketExpression("postgres", BucketWeek, "created_at")
+	require.NoError(t, err)
+	assert.Equal(t, "to_char(date_trunc('week', created_at), 'YYYY-MM-DD')", expression)
+	expression, err = BucketExpression("sqlite", BucketHour, "created_at")
+	require.NoError(t, err)
+	assert.Equal(t, "strftime('%Y-%m-%dT%H:00', created_at)", expression)
+
+	_, err = BucketExpression("oracle", BucketDay, "created_at")
+	assert.Error(t, err)
+	_, err = BucketExpression("sqlite", "year", "created_at")
+	assert.Error(t, err)
+}
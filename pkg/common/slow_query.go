@@ -0,0 +1,92 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// SlowQuery describes a query that ran longer than the slow query threshold
+type SlowQuery struct {
+	Schema    string
+	Entity    string
+	Operation string        // read, create, update or delete
+	Options   string        // Normalized options of the request, see NormalizeOptions
+	Duration  time.Duration // Time the query took
+	RequestID string
+}
+
+// SlowQueryFunc is called for every slow query, e.g. to alert or record metrics
+type SlowQueryFunc func(ctx context.Context, query SlowQuery)
+
+// ReportSlowQuery logs the query and calls OnSlowQuery when it took at least the
+// SlowQueryThreshold. It returns whether the query was slow.
+func (c HandlerConfig) ReportSlowQuery(ctx context.Context, query SlowQuery) bool {
+	if c.SlowQueryThreshold <= 0 || query.Duration < c.SlowQueryThreshold {
+		return false
+	}
+	if query.RequestID == "" {
+		query.RequestID = RequestIDFromContext(ctx)
+	}
+	logger.FromContext(ctx).Warn("Slow query",
+		logger.F("schema", query.Schema),
+		logger.F("entity", query.Entity),
+		logger.F("operation", query.Operation),
+		logger.F("options", query.Options),
+		logger.F("duration_ms", query.Duration.Milliseconds()),
+	)
+	if c.OnSlowQuery != nil {
+		c.OnSlowQuery(ctx, query)
+	}
+	return true
+}
+
+// NormalizeOptions renders the shape of the options of a read without their values, so slow
+// queries of the same shape log the same text, e.g. "filters=[status eq ?] sort=[name asc] limit=50"
+func NormalizeOptions(options RequestOptions) string {
+	parts := make([]string, 0, 6)
+	if len(options.Columns) > 0 {
+		parts = append(parts, fmt.Sprintf("columns=[%s]", strings.Join(options.Columns, ",")))
+	}
+	if len(options.Filters) > 0 {
+		filters := make([]string, 0, len(options.Filters))
+		for _, filter := range options.Filters {
+			filters = append(filters, normalizeFilter(filter))
+		}
+		parts = append(parts, fmt.Sprintf("filters=[%s]", strings.Join(filters, ", ")))
+	}
+	if !options.FilterGroup.IsEmpty() {
+		group, _ := options.FilterGroup.ToSQL(func(filter FilterOption) (string, []interface{}) {
+			return normalizeFilter(filter), nil
+		})
+		parts = append(parts, fmt.Sprintf("filter_group=%s", group))
+	}
+	if len(options.Sort) > 0 {
+		sorts := make([]string, 0, len(options.Sort))
+		for _, sort := range options.Sort {
+			sorts = append(sorts, strings.TrimSpace(sort.Column+" "+strings.ToLower(sort.Direction)))
+		}
+		parts = append(parts, fmt.Sprintf("sort=[%s]", strings.Join(sorts, ", ")))
+	}
+	if len(options.Preload) > 0 {
+		relations := make([]string, 0, len(options.Preload))
+		for _, preload := range options.Preload {
+			relations = append(relations, preload.Relation)
+		}
+		parts = append(parts, fmt.Sprintf("preload=[%s]", strings.Join(relations, ",")))
+	}
+	if options.Limit != nil {
+		parts = append(parts, fmt.Sprintf("limit=%d", *options.Limit))
+	}
+	if options.Offset != nil {
+		parts = append(parts, fmt.Sprintf("offset=%d", *options.Offset))
+	}
+	return strings.Join(parts, " ")
+}
+
+func normalizeFilter(filter FilterOption) string {
+	return fmt.Sprintf("%s %s ?", filter.Column, strings.ToLower(filter.Operator))
+}
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeOptions(t *testing.T) {
+	limit := 50
+	options := RequestOptions{
+		Columns: []string{"id", "name"},
+		Filters: []FilterOption{{Column: "status", Operator: "EQ", Value: "active"}},
+		Sort:    []SortOption{{Column: "name", Direction: "ASC"}},
+		Preload: []PreloadOption{{Relation: "employees"}},
+		Limit:   &limit,
+	}
+	assert.Equal(t, "columns=[id,name] filters=[status eq ?] sort=[name asc] preload=[employees] limit=50", NormalizeOptions(options))
+	assert.Equal(t, "", NormalizeOptions(RequestOptions{}))
+}
+
+func TestHandlerConfig_ReportSlowQuery(t *testing.T) {
+	var reported []SlowQuery
+	config := HandlerConfig{SlowQueryThreshold: 100 * time.Millisecond, OnSlowQuery: func(ctx context.Context, query SlowQuery) {
+		reported = append(reported, query)
+	}}
+	ctx := WithRequestID(context.Background(), "req-1")
+
+	assert.False(t, config.ReportSlowQuery(ctx, SlowQuery{Entity: "notes", Duration: 10 * time.Millisecond}))
+	assert.True(t, config.ReportSlowQuery(ctx, SlowQuery{Entity: "notes", Duration: 150 * time.Millisecond}))
+	if assert.Len(t, reported, 1) {
+		assert.Equal(t, "req-1", reported[0].RequestID)
+	}
+
+	assert.False(t, HandlerConfig{}.ReportSlowQuery(ctx, SlowQuery{Duration: time.Hour}), "no threshold disables reporting")
+}
@@ -0,0 +1,624 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// TenantResolver returns the tenant of a request, e.g. from a header set by a gateway or the
+// context of an authentication middleware. The tenant has the type of the tenant column.
+type TenantResolver func(r Request) (interface{}, error)
+
+// TenantScope restricts the queries of a request to the rows of one tenant
+type TenantScope struct {
+	Column string      // Tenant column, e.g. tenant_id
+	Tenant interface{} // Tenant of the request
+	// Tables reports whether a table, named as passed to Table(), has the tenant column.
+	// Queries built from a model are scoped when the model has the column.
+	Tables func(table string) bool
+}
+
+// ResolveTenant returns the tenant scope of a request, or nil when the handler has no tenant
+// column. Requests without a tenant are rejected, so tenancy fails closed.
+func (c HandlerConfig) ResolveTenant(r Request, tables func(table string) bool) (*TenantScope, error) {
+	if c.TenantColumn == "" {
+		return nil, nil
+	}
+	if c.TenantResolver == nil {
+		return nil, fmt.Errorf("no tenant resolver for tenant column %s", c.TenantColumn)
+	}
+	tenant, err := c.TenantResolver(r)
+	if err != nil {
+		return nil, err
+	}
+	if tenant == nil || tenant == "" {
+		return nil, fmt.Errorf("request has no tenant")
+	}
+	return &TenantScope{Column: c.TenantColumn, Tenant: tenant, Tables: tables}, nil
+}
+
+// VersionedRegistry is implemented by model registries that count their changes, e.g.
+// modelregistry.DefaultModelRegistry, so what is derived from their models can be cached
+type VersionedRegistry interface {
+	Version() uint64
+}
+
+// TenantTableCache holds the tables with the tenant column of the models of a registry until a
+// model is registered. With a registry without a version the tables are built on every request.
+type TenantTableCache struct {
+	mu      sync.Mutex
+	version uint64
+	tables  func(table string) bool
+}
+
+// NewTenantTableCache creates an empty tenant table cache
+func NewTenantTableCache() *TenantTableCache {
+	return &TenantTableCache{}
+}
+
+// Tables returns the tables of the models of registry, calling build once per version of the
+// registry. A nil cache calls build every time.
+func (c *TenantTableCache) Tables(registry ModelRegistry, build func() func(table string) bool) func(table string) bool {
+	versioned, ok := registry.(VersionedRegistry)
+	if c == nil || !ok {
+		return build()
+	}
+	version := versioned.Version()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tables == nil || c.version != version {
+		c.tables, c.version = build(), version
+	}
+	return c.tables
+}
+
+// HasColumn reports whether a model has the tenant column
+func (s *TenantScope) HasColumn(model interface{}) bool {
+	for _, column := range NewColumnValidator(model).GetValidColumns() {
+		if strings.EqualFold(column, s.Column) {
+			return true
+		}
+	}
+	return false
+}
+
+// Stamp sets the tenant column of the records of a create or update payload, a record or a
+// list of records, replacing values sent by the client. A nil scope stamps nothing.
+func (s *TenantScope) Stamp(model, data interface{}) {
+	if s == nil || !s.HasColumn(model) {
+		return
+	}
+	switch v := data.(type) {
+	case map[string]interface{}:
+		v[s.Column] = s.Tenant
+	case []map[string]interface{}:
+		for _, record := range v {
+			record[s.Column] = s.Tenant
+		}
+	case []interface{}:
+		for _, item := range v {
+			if record, ok := item.(map[string]interface{}); ok {
+				record[s.Column] = s.Tenant
+			}
+		}
+	}
+}
+
+func (s *TenantScope) scopesTable(table string) bool {
+	return s.Tables != nil && s.Tables(table)
+}
+
+func (s *TenantScope) scopesModel(model interface{}) bool {
+	modelType := reflect.TypeOf(model)
+	for modelType != nil && (modelType.Kind() == reflect.Ptr || modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Array) {
+		modelType = modelType.Elem()
+	}
+	if modelType == nil || modelType.Kind() != reflect.Struct {
+		return false
+	}
+	return s.HasColumn(model)
+}
+
+// Condition returns the tenant condition on the column of table, an alias, table name or
+// schema.table name, or on the unqualified column when table is ""
+func (s *TenantScope) Condition(table string) string {
+	column := QuoteIdent(s.Column)
+	if table != "" {
+		parts := strings.Split(table, ".")
+		for i, part := range parts {
+			parts[i] = QuoteIdent(strings.Trim(part, "\"`"))
+		}
+		column = strings.Join(parts, ".") + "." + column
+	}
+	return fmt.Sprintf("%s = ?", column)
+}
+
+// TenantRelationScoper is implemented by select queries that can limit the rows of their
+// relations to a tenant
+type TenantRelationScoper interface {
+	// ScopeTenantRelations adds the tenant condition to the relations preloaded or joined by
+	// the query afterwards whose models have the tenant column. It returns false when the
+	// query can't scope its relations.
+	ScopeTenantRelations(scope *TenantScope) bool
+}
+
+// NewTenantDatabase wraps db so that the selects, updates and deletes of tables with the tenant
+// column only see the rows of the tenant, and inserts and updates set the tenant column. The
+// relations preloaded or joined by selects are scoped too, which needs select queries that
+// implement TenantRelationScoper; selects with relations fail otherwise. Raw SQL passed to
+// Exec and Query is not scoped.
+func NewTenantDatabase(db Database, scope *TenantScope) Database {
+	return &tenantDatabase{db: db, scope: scope}
+}
+
+type tenantDatabase struct {
+	db    Database
+	scope *TenantScope
+}
+
+func (d *tenantDatabase) NewSelect() SelectQuery {
+	query := d.db.NewSelect()
+	scoper, ok := query.(TenantRelationScoper)
+	return &tenantSelectQuery{query: query, scope: d.scope, relations: ok && scoper.ScopeTenantRelations(d.scope)}
+}
+
+func (d *tenantDatabase) NewInsert() InsertQuery {
+	return &tenantInsertQuery{query: d.db.NewInsert(), scope: d.scope}
+}
+
+func (d *tenantDatabase) NewUpdate() UpdateQuery {
+	return &tenantUpdateQuery{query: d.db.NewUpdate(), scope: d.scope}
+}
+
+func (d *tenantDatabase) NewDelete() DeleteQuery {
+	return &tenantDeleteQuery{query: d.db.NewDelete(), scope: d.scope}
+}
+
+func (d *tenantDatabase) Exec(ctx context.Context, query string, args ...interface{}) (Result, error) {
+	return d.db.Exec(ctx, query, args...)
+}
+
+func (d *tenantDatabase) Query(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return d.db.Query(ctx, dest, query, args...)
+}
+
+func (d *tenantDatabase) BeginTx(ctx context.Context) (Database, error) {
+	tx, err := d.db.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tenantDatabase{db: tx, scope: d.scope}, nil
+}
+
+func (d *tenantDatabase) CommitTx(ctx context.Context) error {
+	return d.db.CommitTx(ctx)
+}
+
+func (d *tenantDatabase) RollbackTx(ctx context.Context) error {
+	return d.db.RollbackTx(ctx)
+}
+
+func (d *tenantDatabase) RunInTransaction(ctx context.Context, fn func(Database) error) error {
+	return d.db.RunInTransaction(ctx, func(tx Database) error {
+		return fn(&tenantDatabase{db: tx, scope: d.scope})
+	})
+}
+
+func (d *tenantDatabase) Dialect() Dialect {
+	return d.db.Dialect()
+}
+
+// tenantSelectQuery adds the tenant condition once the query targets a scoped table. The
+// condition is added before the first condition of the query, once the table is known, and
+// qualified by the table so it stays unambiguous next to joined tables. Relations are scoped by
+// the wrapped query; when it can't scope them the query fails.
+type tenantSelectQuery struct {
+	query     SelectQuery
+	scope     *TenantScope
+	model     interface{}
+	table     string
+	pending   bool
+	scoped    bool
+	relations bool // The wrapped query scopes its relations
+	err       error
+}
+
+func (q *tenantSelectQuery) wrap(query SelectQuery) SelectQuery {
+	q.query = query
+	return q
+}
+
+func (q *tenantSelectQuery) target(scoped bool) SelectQuery {
+	q.pending = q.pending || (scoped && !q.scoped)
+	return q
+}
+
+// relation fails the query when the wrapped query doesn't scope the relation
+func (q *tenantSelectQuery) relation(relation string) bool {
+	if !q.relations && q.err == nil {
+		q.err = fmt.Errorf("relation %s can't be scoped to the tenant by %T", relation, q.query)
+	}
+	return q.relations
+}
+
+// where adds the pending tenant condition
+func (q *tenantSelectQuery) where() SelectQuery {
+	if q.pending {
+		q.pending = false
+		q.scoped = true
+		q.query = q.query.Where(q.scope.Condition(q.reference()), q.scope.Tenant)
+	}
+	return q.query
+}
+
+// reference returns how the query refers to its table: the alias of the query, the table passed
+// to Table() or the TableName of the model
+func (q *tenantSelectQuery) reference() string {
+	if reference := q.TableReference(); reference != "" {
+		return reference
+	}
+	if q.table != "" {
+		return q.table
+	}
+	if q.model != nil {
+		return NewColumnValidator(q.model).tableName()
+	}
+	return ""
+}
+
+func (q *tenantSelectQuery) Model(model interface{}) SelectQuery {
+	q.query = q.query.Model(model)
+	q.model = model
+	return q.target(q.scope.scopesModel(model))
+}
+
+func (q *tenantSelectQuery) Table(table string) SelectQuery {
+	q.query = q.query.Table(table)
+	q.table = table
+	return q.target(q.scope.scopesTable(table))
+}
+
+// TableReference implements TableReferenceProvider for the wrapped query
+func (q *tenantSelectQuery) TableReference() string {
+	if provider, ok := q.query.(TableReferenceProvider); ok {
+		return provider.TableReference()
+	}
+	return ""
+}
+
+func (q *tenantSelectQuery) Column(columns ...string) SelectQuery {
+	return q.wrap(q.query.Column(columns...))
+}
+
+func (q *tenantSelectQuery) ColumnExpr(query string, args ...interface{}) SelectQuery {
+	return q.wrap(q.query.ColumnExpr(query, args...))
+}
+
+func (q *tenantSelectQuery) Where(query string, args ...interface{}) SelectQuery {
+	return q.wrap(q.where().Where(query, args...))
+}
+
+// WhereOr is ORed with all conditions before it, so the tenant condition is added to the
+// alternative to keep it within the tenant
+func (q *tenantSelectQuery) WhereOr(query string, args ...interface{}) SelectQuery {
+	if q.where(); q.scoped {
+		return q.wrap(q.query.WhereOr(fmt.Sprintf("(%s) AND %s", query, q.scope.Condition(q.reference())), append(args, q.scope.Tenant)...))
+	}
+	return q.wrap(q.query.WhereOr(query, args...))
+}
+
+func (q *tenantSelectQuery) Join(query string, args ...interface{}) SelectQuery {
+	return q.wrap(q.query.Join(query, args...))
+}
+
+func (q *tenantSelectQuery) LeftJoin(query string, args ...interface{}) SelectQuery {
+	return q.wrap(q.query.LeftJoin(query, args...))
+}
+
+func (q *tenantSelectQuery) Preload(relation string, conditions ...interface{}) SelectQuery {
+	if !q.relation(relation) {
+		return q
+	}
+	return q.wrap(q.query.Preload(relation, conditions...))
+}
+
+func (q *tenantSelectQuery) PreloadRelation(relation string, apply ...func(SelectQuery) SelectQuery) SelectQuery {
+	if !q.relation(relation) {
+		return q
+	}
+	return q.wrap(q.query.PreloadRelation(relation, apply...))
+}
+
+func (q *tenantSelectQuery) JoinRelation(relation string, apply ...func(SelectQuery) SelectQuery) SelectQuery {
+	if !q.relation(relation) {
+		return q
+	}
+	return q.wrap(q.query.JoinRelation(relation, apply...))
+}
+
+func (q *tenantSelectQuery) Order(order string) SelectQuery {
+	return q.wrap(q.query.Order(order))
+}
+
+func (q *tenantSelectQuery) OrderExpr(order string, args ...interface{}) SelectQuery {
+	return q.wrap(q.query.OrderExpr(order, args...))
+}
+
+func (q *tenantSelectQuery) Limit(n int) SelectQuery {
+	return q.wrap(q.query.Limit(n))
+}
+
+func (q *tenantSelectQuery) Offset(n int) SelectQuery {
+	return q.wrap(q.query.Offset(n))
+}
+
+func (q *tenantSelectQuery) Group(group string) SelectQuery {
+	return q.wrap(q.query.Group(group))
+}
+
+func (q *tenantSelectQuery) Having(having string, args ...interface{}) SelectQuery {
+	return q.wrap(q.query.Having(having, args...))
+}
+
+func (q *tenantSelectQuery) Distinct() SelectQuery {
+	return q.wrap(q.query.Distinct())
+}
+
+func (q *tenantSelectQuery) DistinctOn(columns ...string) SelectQuery {
+	return q.wrap(q.query.DistinctOn(columns...))
+}
+
+func (q *tenantSelectQuery) Scan(ctx context.Context, dest interface{}) error {
+	if q.err != nil {
+		return q.err
+	}
+	return q.where().Scan(ctx, dest)
+}
+
+func (q *tenantSelectQuery) ScanModel(ctx context.Context) error {
+	if q.err != nil {
+		return q.err
+	}
+	return q.where().ScanModel(ctx)
+}
+
+func (q *tenantSelectQuery) Count(ctx context.Context) (int, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
+	return q.where().Count(ctx)
+}
+
+func (q *tenantSelectQuery) Exists(ctx context.Context) (bool, error) {
+	if q.err != nil {
+		return false, q.err
+	}
+	return q.where().Exists(ctx)
+}
+
+func (q *tenantSelectQuery) ToSQL() (string, []interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+	return q.where().ToSQL()
+}
+
+// tenantInsertQuery sets the tenant column of inserts into scoped tables
+type tenantInsertQuery struct {
+	query  InsertQuery
+	scope  *TenantScope
+	scoped bool
+}
+
+func (q *tenantInsertQuery) wrap(query InsertQuery) InsertQuery {
+	q.query = query
+	return q
+}
+
+func (q *tenantInsertQuery) Model(model interface{}) InsertQuery {
+	q.scoped = q.scoped || q.scope.scopesModel(model)
+	if q.scoped {
+		q.scope.stampModel(model)
+	}
+	return q.wrap(q.query.Model(model))
+}
+
+func (q *tenantInsertQuery) Table(table string) InsertQuery {
+	q.scoped = q.scoped || q.scope.scopesTable(table)
+	return q.wrap(q.query.Table(table))
+}
+
+func (q *tenantInsertQuery) Value(column string, value interface{}) InsertQuery {
+	return q.wrap(q.query.Value(column, value))
+}
+
+func (q *tenantInsertQuery) OnConflict(action string) InsertQuery {
+	return q.wrap(q.query.OnConflict(action))
+}
+
+func (q *tenantInsertQuery) Returning(columns ...string) InsertQuery {
+	return q.wrap(q.query.Returning(columns...))
+}
+
+func (q *tenantInsertQuery) Bulk(models interface{}, chunkSize int) InsertQuery {
+	q.scoped = q.scoped || q.scope.scopesModel(models)
+	if q.scoped {
+		q.scope.stampModel(models)
+	}
+	return q.wrap(q.query.Bulk(models, chunkSize))
+}
+
+func (q *tenantInsertQuery) stamped() InsertQuery {
+	if q.scoped {
+		return q.query.Value(q.scope.Column, q.scope.Tenant)
+	}
+	return q.query
+}
+
+func (q *tenantInsertQuery) Exec(ctx context.Context) (Result, error) {
+	return q.stamped().Exec(ctx)
+}
+
+func (q *tenantInsertQuery) ToSQL() (string, []interface{}, error) {
+	return q.stamped().ToSQL()
+}
+
+// stampModel sets the tenant field of a model, or of the models of a slice, passed to an insert
+func (s *TenantScope) stampModel(model interface{}) {
+	value := reflect.ValueOf(model)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		s.stampStruct(value)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			item := value.Index(i)
+			for item.Kind() == reflect.Ptr && !item.IsNil() {
+				item = item.Elem()
+			}
+			if item.Kind() == reflect.Struct {
+				s.stampStruct(item)
+			}
+		}
+	}
+}
+
+func (s *TenantScope) stampStruct(value reflect.Value) {
+	if !value.CanSet() {
+		return
+	}
+	for _, field := range reflection.DescribeType(value.Type()).Fields {
+		if !field.Exported || !strings.EqualFold(field.Column, s.Column) {
+			continue
+		}
+		target, err := value.FieldByIndexErr(field.Index)
+		if err != nil {
+			return // The embedded struct of the column is a nil pointer
+		}
+		tenant := reflect.ValueOf(s.Tenant)
+		if target.Kind() == reflect.Ptr {
+			if tenant.Type().ConvertibleTo(target.Type().Elem()) {
+				converted := reflect.New(target.Type().Elem())
+				converted.Elem().Set(tenant.Convert(target.Type().Elem()))
+				target.Set(converted)
+			}
+		} else if tenant.Type().ConvertibleTo(target.Type()) {
+			target.Set(tenant.Convert(target.Type()))
+		}
+		return
+	}
+}
+
+// tenantUpdateQuery limits updates of scoped tables to the rows of the tenant and keeps their
+// tenant column
+type tenantUpdateQuery struct {
+	query  UpdateQuery
+	scope  *TenantScope
+	scoped bool
+}
+
+func (q *tenantUpdateQuery) wrap(query UpdateQuery) UpdateQuery {
+	q.query = query
+	return q
+}
+
+func (q *tenantUpdateQuery) target(scoped bool) UpdateQuery {
+	if scoped && !q.scoped {
+		q.scoped = true
+		q.query = q.query.Where(q.scope.Condition(""), q.scope.Tenant)
+	}
+	return q
+}
+
+func (q *tenantUpdateQuery) Model(model interface{}) UpdateQuery {
+	q.query = q.query.Model(model)
+	return q.target(q.scope.scopesModel(model))
+}
+
+func (q *tenantUpdateQuery) Table(table string) UpdateQuery {
+	q.query = q.query.Table(table)
+	return q.target(q.scope.scopesTable(table))
+}
+
+func (q *tenantUpdateQuery) Set(column string, value interface{}) UpdateQuery {
+	if q.scoped && strings.EqualFold(column, q.scope.Column) {
+		value = q.scope.Tenant
+	}
+	return q.wrap(q.query.Set(column, value))
+}
+
+func (q *tenantUpdateQuery) SetMap(values map[string]interface{}) UpdateQuery {
+	if q.scoped {
+		for column := range values {
+			if strings.EqualFold(column, q.scope.Column) {
+				values[column] = q.scope.Tenant
+			}
+		}
+	}
+	return q.wrap(q.query.SetMap(values))
+}
+
+func (q *tenantUpdateQuery) Where(query string, args ...interface{}) UpdateQuery {
+	return q.wrap(q.query.Where(query, args...))
+}
+
+func (q *tenantUpdateQuery) Returning(columns ...string) UpdateQuery {
+	return q.wrap(q.query.Returning(columns...))
+}
+
+func (q *tenantUpdateQuery) Exec(ctx context.Context) (Result, error) {
+	return q.query.Exec(ctx)
+}
+
+func (q *tenantUpdateQuery) ToSQL() (string, []interface{}, error) {
+	return q.query.ToSQL()
+}
+
+// tenantDeleteQuery limits deletes of scoped tables to the rows of the tenant
+type tenantDeleteQuery struct {
+	query  DeleteQuery
+	scope  *TenantScope
+	scoped bool
+}
+
+func (q *tenantDeleteQuery) wrap(query DeleteQuery) DeleteQuery {
+	q.query = query
+	return q
+}
+
+func (q *tenantDeleteQuery) target(scoped bool) DeleteQuery {
+	if scoped && !q.scoped {
+		q.scoped = true
+		q.query = q.query.Where(q.scope.Condition(""), q.scope.Tenant)
+	}
+	return q
+}
+
+func (q *tenantDeleteQuery) Model(model interface{}) DeleteQuery {
+	q.query = q.query.Model(model)
+	return q.target(q.scope.scopesModel(model))
+}
+
+func (q *tenantDeleteQuery) Table(table string) DeleteQuery {
+	q.query = q.query.Table(table)
+	return q.target(q.scope.scopesTable(table))
+}
+
+func (q *tenantDeleteQuery) Where(query string, args ...interface{}) DeleteQuery {
+	return q.wrap(q.query.Where(query, args...))
+}
+
+func (q *tenantDeleteQuery) Exec(ctx context.Context) (Result, error) {
+	return q.query.Exec(ctx)
+}
+
+func (q *tenantDeleteQuery) ToSQL() (string, []interface{}, error) {
+	return q.query.ToSQL()
+}
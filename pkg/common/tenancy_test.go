@@ -0,0 +1,132 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type tenantRecord struct {
+	ID       int64  `bun:"id,pk"`
+	TenantID int64  `bun:"tenant_id"`
+	Title    string `bun:"title"`
+}
+
+type untenantedRecord struct {
+	ID int64 `bun:"id,pk"`
+}
+
+func TestResolveTenant(t *testing.T) {
+	if scope, err := (HandlerConfig{}).ResolveTenant(nil, nil); scope != nil || err != nil {
+		t.Errorf("expected no scope without a tenant column, got %+v, %v", scope, err)
+	}
+	if _, err := (HandlerConfig{TenantColumn: "tenant_id"}).ResolveTenant(nil, nil); err == nil {
+		t.Error("expected an error without a tenant resolver")
+	}
+
+	config := HandlerConfig{TenantColumn: "tenant_id", TenantResolver: func(r Request) (interface{}, error) {
+		return "", nil
+	}}
+	if _, err := config.ResolveTenant(nil, nil); err == nil {
+		t.Error("expected an error for an empty tenant")
+	}
+	config.TenantResolver = func(r Request) (interface{}, error) { return nil, errors.New("no token") }
+	if _, err := config.ResolveTenant(nil, nil); err == nil {
+		t.Error("expected the error of the resolver")
+	}
+	config.TenantResolver = func(r Request) (interface{}, error) { return int64(7), nil }
+	scope, err := config.ResolveTenant(nil, nil)
+	if err != nil || scope.Tenant != int64(7) || scope.Column != "tenant_id" {
+		t.Errorf("unexpected scope %+v, %v", scope, err)
+	}
+}
+
+func TestTenantScopeStamp(t *testing.T) {
+	scope := &TenantScope{Column: "tenant_id", Tenant: int64(7)}
+
+	record := map[string]interface{}{"title": "a", "tenant_id": 9}
+	scope.Stamp(tenantRecord{}, record)
+	if record["tenant_id"] != int64(7) {
+		t.Errorf("expected the tenant to replace the client value, got %v", record["tenant_id"])
+	}
+
+	records := []interface{}{map[string]interface{}{"title": "b"}}
+	scope.Stamp(tenantRecord{}, records)
+	if records[0].(map[string]interface{})["tenant_id"] != int64(7) {
+		t.Errorf("expected list records to be stamped, got %v", records)
+	}
+
+	other := map[string]interface{}{"id": 1}
+	scope.Stamp(untenantedRecord{}, other)
+	if _, ok := other["tenant_id"]; ok {
+		t.Error("expected models without the tenant column to be left alone")
+	}
+
+	var none *TenantScope
+	none.Stamp(tenantRecord{}, record)
+
+	model := &tenantRecord{TenantID: 9}
+	scope.stampModel(model)
+	if model.TenantID != 7 {
+		t.Errorf("expected the model to be stamped, got %d", model.TenantID)
+	}
+}
+
+// versionedRegistry is a model registry whose version the test sets
+type versionedRegistry struct {
+	ModelRegistry
+	version uint64
+}
+
+func (r *versionedRegistry) Version() uint64 { return r.version }
+
+func TestTenantTableCache(t *testing.T) {
+	builds := 0
+	build := func() func(table string) bool {
+		builds++
+		return func(table string) bool { return table == "notes" }
+	}
+
+	cache := NewTenantTableCache()
+	registry := &versionedRegistry{version: 1}
+	cache.Tables(registry, build)
+	if tables := cache.Tables(registry, build); builds != 1 || !tables("notes") {
+		t.Errorf("expected the tables to be built once per version, built %d times", builds)
+	}
+	registry.version++
+	cache.Tables(registry, build)
+	if builds != 2 {
+		t.Errorf("expected a registration to rebuild the tables, built %d times", builds)
+	}
+
+	cache.Tables(nil, build)
+	cache.Tables(nil, build)
+	if builds != 4 {
+		t.Errorf("expected registries without a version to build the tables every time, built %d times", builds)
+	}
+}
+
+// unscopedDatabase returns select queries that can't scope their relations to a tenant
+type unscopedDatabase struct {
+	Database
+}
+
+type unscopedSelectQuery struct {
+	SelectQuery
+}
+
+func (unscopedDatabase) NewSelect() SelectQuery {
+	return unscopedSelectQuery{}
+}
+
+func TestTenantRelationsFailClosed(t *testing.T) {
+	db := NewTenantDatabase(unscopedDatabase{}, &TenantScope{Column: "tenant_id", Tenant: int64(1)})
+	err := db.NewSelect().PreloadRelation("Project").Scan(context.Background(), &[]tenantRecord{})
+	if err == nil || !strings.Contains(err.Error(), "relation Project can't be scoped to the tenant") {
+		t.Errorf("expected relations that can't be scoped to fail the query, got %v", err)
+	}
+	if _, _, err := db.NewSelect().JoinRelation("Project").ToSQL(); err == nil {
+		t.Error("expected joined relations that can't be scoped to fail the query")
+	}
+}
@@ -0,0 +1,280 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// TransactionOperation is a create, update or delete against one entity in a
+// cross-entity transaction. Ref names the operation so later operations can refer
+// to its result with "${ref.field}"; operations can always be referred to by index.
+type TransactionOperation struct {
+	Entity string                 `json:"entity"`
+	Action string                 `json:"action"`
+	Ref    string                 `json:"ref,omitempty"`
+	ID     interface{}            `json:"id,omitempty"`
+	Data   map[string]interface{} `json:"data"`
+}
+
+// BatchItem returns the operation as a batch item
+func (o TransactionOperation) BatchItem() BatchItem {
+	return BatchItem{Action: o.Action, ID: o.ID, Data: o.Data}
+}
+
+// TransactionRequest is a list of operations on multiple entities executed in order
+// inside a single database transaction
+type TransactionRequest struct {
+	Operations []TransactionOperation `json:"operations"`
+}
+
+// TransactionOperationResult is the outcome of a single transaction operation
+type TransactionOperationResult struct {
+	Index        int         `json:"index"`
+	Entity       string      `json:"entity"`
+	Action       string      `json:"action"`
+	Ref          string      `json:"ref,omitempty"`
+	Success      bool        `json:"success"`
+	ID           interface{} `json:"id,omitempty"`
+	AffectedRows int64       `json:"affected_rows"`
+	Data         interface{} `json:"data,omitempty"`
+	Error        string      `json:"error,omitempty"`
+	Code         string      `json:"code,omitempty"` // Error code, see HandlerConfig.ItemError
+}
+
+// TransactionResult is the outcome of a transaction request
+type TransactionResult struct {
+	Committed bool                         `json:"committed"`
+	Results   []TransactionOperationResult `json:"results"`
+}
+
+// StatusCode returns 200 when the transaction committed and 422 when it was rolled back
+func (r *TransactionResult) StatusCode() int {
+	if r.Committed {
+		return http.StatusOK
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// TransactionOperationFunc executes one operation against tx, the shared transaction.
+// pkName must be the primary key column of the operation's entity.
+type TransactionOperationFunc func(ctx context.Context, tx Database, op TransactionOperation) (result *ProcessResult, pkName string, err error)
+
+// ParseTransactionRequest parses and validates a transaction from request data of
+// the form {"operations": [{"entity": ..., "action": ..., "ref": ..., "id": ..., "data": {...}}]}
+func ParseTransactionRequest(data interface{}) (*TransactionRequest, error) {
+	body, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("transaction must be an object with operations")
+	}
+	rawOps, ok := body["operations"].([]interface{})
+	if !ok || len(rawOps) == 0 {
+		return nil, fmt.Errorf("transaction contains no operations")
+	}
+	if len(rawOps) > MaxBatchSize {
+		return nil, fmt.Errorf("transaction contains %d operations, maximum is %d", len(rawOps), MaxBatchSize)
+	}
+
+	// The item validation of batches applies to every operation
+	batch, err := ParseBatchRequest(rawOps)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &TransactionRequest{Operations: make([]TransactionOperation, 0, len(rawOps))}
+	refs := make(map[string]bool)
+	for idx, rawOp := range rawOps {
+		opMap := rawOp.(map[string]interface{})
+
+		entity, _ := opMap["entity"].(string)
+		if strings.TrimSpace(entity) == "" {
+			return nil, fmt.Errorf("operation %d: entity is required", idx)
+		}
+		ref, _ := opMap["ref"].(string)
+		if ref != "" {
+			if _, err := strconv.Atoi(ref); err == nil {
+				return nil, fmt.Errorf("operation %d: ref %q must not be a number", idx, ref)
+			}
+			if refs[ref] {
+				return nil, fmt.Errorf("operation %d: duplicate ref %q", idx, ref)
+			}
+			refs[ref] = true
+		}
+
+		item := batch.Items[idx]
+		req.Operations = append(req.Operations, TransactionOperation{
+			Entity: strings.TrimSpace(entity),
+			Action: item.Action,
+			Ref:    ref,
+			ID:     item.ID,
+			Data:   item.Data,
+		})
+	}
+
+	return req, nil
+}
+
+// transactionVarPattern matches "${ref.field}" variables
+var transactionVarPattern = regexp.MustCompile(`\$\{([^.}]+)\.([^}]+)\}`)
+
+// transactionVars holds the results of executed operations by ref and by index
+type transactionVars map[string]map[string]interface{}
+
+func (v transactionVars) lookup(ref, field string) (interface{}, error) {
+	values, ok := v[ref]
+	if !ok {
+		return nil, fmt.Errorf("unknown reference %q", ref)
+	}
+	value, ok := values[field]
+	if !ok {
+		return nil, fmt.Errorf("reference %q has no field %q", ref, field)
+	}
+	return value, nil
+}
+
+// substitute replaces variables in value. A string that is exactly one variable takes
+// the referenced value with its type; variables inside longer strings are interpolated.
+func (v transactionVars) substitute(value interface{}) (interface{}, error) {
+	switch val := value.(type) {
+	case string:
+		matches := transactionVarPattern.FindAllStringSubmatchIndex(val, -1)
+		if len(matches) == 0 {
+			return val, nil
+		}
+		if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(val) {
+			return v.lookup(val[matches[0][2]:matches[0][3]], val[matches[0][4]:matches[0][5]])
+		}
+		var lookupErr error
+		result := transactionVarPattern.ReplaceAllStringFunc(val, func(match string) string {
+			parts := transactionVarPattern.FindStringSubmatch(match)
+			resolved, err := v.lookup(parts[1], parts[2])
+			if err != nil {
+				lookupErr = err
+				return match
+			}
+			return fmt.Sprintf("%v", resolved)
+		})
+		return result, lookupErr
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(val))
+		for key, item := range val {
+			resolved, err := v.substitute(item)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = resolved
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(val))
+		for idx, item := range val {
+			resolved, err := v.substitute(item)
+			if err != nil {
+				return nil, err
+			}
+			result[idx] = resolved
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+// errTransactionOperationFailed aborts the shared transaction
+var errTransactionOperationFailed = errors.New("transaction operation failed")
+
+// ExecuteTransaction runs the operations in order inside one transaction using exec.
+// Variables in the ID and data of an operation are resolved from the results of the
+// operations before it. Any failure rolls back every operation. Operation errors are reported
+// under the error policy of config.
+func ExecuteTransaction(ctx context.Context, db Database, config HandlerConfig, req *TransactionRequest, exec TransactionOperationFunc) *TransactionResult {
+	result := &TransactionResult{Results: make([]TransactionOperationResult, len(req.Operations))}
+	for idx, op := range req.Operations {
+		result.Results[idx] = TransactionOperationResult{Index: idx, Entity: op.Entity, Action: op.Action, Ref: op.Ref}
+	}
+
+	runOperation := func(ctx context.Context, tx Database, vars transactionVars, idx int) error {
+		op := req.Operations[idx]
+		opResult := &result.Results[idx]
+
+		resolved, err := vars.substitute(op.Data)
+		if err != nil {
+			return err
+		}
+		op.Data = resolved.(map[string]interface{})
+		if op.ID, err = vars.substitute(op.ID); err != nil {
+			return err
+		}
+
+		processed, pkName, err := exec(ctx, tx, op)
+		if err != nil {
+			return err
+		}
+
+		values := make(map[string]interface{}, len(op.Data)+2)
+		for key, value := range op.Data {
+			values[key] = value
+		}
+		if processed != nil {
+			for key, value := range processed.Data {
+				values[key] = value
+			}
+			if processed.ID != nil {
+				values[pkName] = processed.ID
+				values["id"] = processed.ID
+			}
+			opResult.ID = processed.ID
+			opResult.AffectedRows = processed.AffectedRows
+			opResult.Data = processed.Data
+		}
+		vars[strconv.Itoa(idx)] = values
+		if op.Ref != "" {
+			vars[op.Ref] = values
+		}
+		opResult.Success = true
+		return nil
+	}
+
+	failedIdx := -1
+	err := RunInTransaction(ctx, db, func(ctx context.Context, tx Database) error {
+		vars := make(transactionVars)
+		for idx := range req.Operations {
+			if err := runOperation(ctx, tx, vars, idx); err != nil {
+				logger.Warn("Transaction operation %d (%s %s) failed: %v", idx, req.Operations[idx].Action, req.Operations[idx].Entity, err)
+				result.Results[idx].Code, result.Results[idx].Error = config.ItemError(err)
+				failedIdx = idx
+				return errTransactionOperationFailed
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		result.Committed = true
+		return result
+	}
+
+	for idx := range result.Results {
+		opResult := &result.Results[idx]
+		switch {
+		case idx == failedIdx:
+		case failedIdx < 0:
+			// The commit itself failed
+			opResult.Code, opResult.Error = config.ItemError(fmt.Errorf("transaction failed: %w", err))
+		case idx < failedIdx:
+			opResult.Error = "rolled back"
+		default:
+			opResult.Error = "not executed"
+		}
+		opResult.Success = false
+		opResult.ID = nil
+		opResult.AffectedRows = 0
+		opResult.Data = nil
+	}
+	return result
+}
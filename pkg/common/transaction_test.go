@@ -0,0 +1,112 @@
+package common
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseTransactionRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantErr  bool
+		entities []string
+		refs     []string
+	}{
+		{
+			name:     "operations on multiple entities",
+			body:     `{"operations":[{"entity":"orders","action":"create","ref":"order","data":{"total":10}},{"entity":"order_items","action":"insert","data":{"order_id":"${order.id}"}}]}`,
+			entities: []string{"orders", "order_items"},
+			refs:     []string{"order", ""},
+		},
+		{name: "missing entity", body: `{"operations":[{"action":"create","data":{}}]}`, wantErr: true},
+		{name: "invalid action", body: `{"operations":[{"entity":"orders","action":"merge","data":{}}]}`, wantErr: true},
+		{name: "duplicate ref", body: `{"operations":[{"entity":"a","action":"delete","id":1,"ref":"x"},{"entity":"b","action":"delete","id":2,"ref":"x"}]}`, wantErr: true},
+		{name: "numeric ref", body: `{"operations":[{"entity":"a","action":"delete","id":1,"ref":"1"}]}`, wantErr: true},
+		{name: "no operations", body: `{"operations":[]}`, wantErr: true},
+		{name: "array body", body: `[{"entity":"a","action":"delete","id":1}]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(tt.body), &data); err != nil {
+				t.Fatalf("invalid test body: %v", err)
+			}
+
+			req, err := ParseTransactionRequest(data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", req)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(req.Operations) != len(tt.entities) {
+				t.Fatalf("expected %d operations, got %d", len(tt.entities), len(req.Operations))
+			}
+			for idx, op := range req.Operations {
+				if op.Entity != tt.entities[idx] || op.Ref != tt.refs[idx] {
+					t.Errorf("operation %d = %s/%q, want %s/%q", idx, op.Entity, op.Ref, tt.entities[idx], tt.refs[idx])
+				}
+			}
+		})
+	}
+}
+
+func TestTransactionVarsSubstitute(t *testing.T) {
+	vars := transactionVars{
+		"order": {"id": int64(42), "code": "A-1"},
+		"0":     {"id": int64(42), "code": "A-1"},
+	}
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "whole value keeps type", value: "${order.id}", want: int64(42)},
+		{name: "by index", value: "${0.code}", want: "A-1"},
+		{name: "interpolated", value: "item of ${order.code} (#${order.id})", want: "item of A-1 (#42)"},
+		{name: "plain string", value: "no variables", want: "no variables"},
+		{name: "non string", value: 3.5, want: 3.5},
+		{
+			name:  "nested",
+			value: map[string]interface{}{"order_id": "${order.id}", "tags": []interface{}{"${order.code}", 1}},
+			want:  map[string]interface{}{"order_id": int64(42), "tags": []interface{}{"A-1", 1}},
+		},
+		{name: "unknown ref", value: "${customer.id}", wantErr: true},
+		{name: "unknown field", value: "x ${order.total}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := vars.substitute(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("substitute() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransactionResultStatusCode(t *testing.T) {
+	if code := (&TransactionResult{Committed: true}).StatusCode(); code != 200 {
+		t.Errorf("committed: got %d, want 200", code)
+	}
+	if code := (&TransactionResult{}).StatusCode(); code != 422 {
+		t.Errorf("rolled back: got %d, want 422", code)
+	}
+}
@@ -4,7 +4,16 @@ type RequestBody struct {
 	Operation string         `json:"operation"`
 	Data      interface{}    `json:"data"`
 	ID        *int64         `json:"id"`
+	IDs       IDList         `json:"ids"` // Primary keys of a read by id list
 	Options   RequestOptions `json:"options"`
+
+	// Row limit or confirmation of an update by filter, see WriteGuard. The limit also applies
+	// to a delete by filter, which is confirmed with the X-Confirm-Bulk header.
+	WriteLimit int  `json:"write_limit"`
+	WriteAll   bool `json:"write_all"`
+
+	// Counts the rows a delete by filter would remove without deleting them
+	DryRun bool `json:"dry_run"`
 }
 
 type RequestOptions struct {
@@ -12,6 +21,7 @@ type RequestOptions struct {
 	Columns         []string         `json:"columns"`
 	OmitColumns     []string         `json:"omit_columns"`
 	Filters         []FilterOption   `json:"filters"`
+	FilterGroup     *FilterGroup     `json:"filter_group"` // Nested AND/OR expression, combined with Filters using AND
 	Sort            []SortOption     `json:"sort"`
 	Limit           *int             `json:"limit"`
 	Offset          *int             `json:"offset"`
@@ -23,6 +33,20 @@ type RequestOptions struct {
 	CursorForward  string  `json:"cursor_forward"`
 	CursorBackward string  `json:"cursor_backward"`
 	FetchRowNumber *string `json:"fetch_row_number"`
+
+	// Explain returns the query plan of a read in the metadata: "true" or "analyze"
+	Explain string `json:"explain,omitempty"`
+
+	// Aggregates are computed over every row a list read matches and returned in the metadata
+	Aggregates []AggregateOption `json:"aggregates,omitempty"`
+
+	// Scopes selects named filters of the model, see ScopeProvider
+	Scopes []string `json:"scopes,omitempty"`
+
+	// ReturnRecords answers updates and deletes with the affected records, see ReturnRecordsHeader
+	ReturnRecords bool `json:"return_records,omitempty"`
+	// LookupKey is the unique column the id of a read matches instead of the primary key, see LookupKeyHeader
+	LookupKey string `json:"lookup_key,omitempty"`
 }
 
 type Parameter struct {
@@ -57,6 +81,14 @@ type FilterOption struct {
 	LogicOperator string      `json:"logic_operator"` // "AND" or "OR" - how this filter combines with previous filters
 }
 
+// FilterGroup is a recursive filter expression. Its filters and sub-groups are
+// combined with Logic ("AND" when empty, or "OR") inside one parenthesized clause.
+type FilterGroup struct {
+	Logic   string         `json:"logic"`
+	Filters []FilterOption `json:"filters"`
+	Groups  []FilterGroup  `json:"groups"`
+}
+
 type SortOption struct {
 	Column    string `json:"column"`
 	Direction string `json:"direction"`
@@ -87,13 +119,39 @@ type Metadata struct {
 	Limit     int    `json:"limit"`
 	Offset    int    `json:"offset"`
 	RowNumber *int64 `json:"row_number,omitempty"`
+	// Estimated is true when Total and Filtered are the estimate of the planner, see CountEstimate
+	Estimated bool `json:"estimated,omitempty"`
+
+	// Search holds the ranking and snippets of the records of a full-text search
+	Search *SearchMetadata `json:"search,omitempty"`
+	// Explain holds the query plan of a read with X-Explain
+	Explain *ExplainMetadata `json:"explain,omitempty"`
+	// Aggregates holds the aggregates of a read by column and function, e.g. {"amount": {"sum": 10}}
+	Aggregates map[string]map[string]interface{} `json:"aggregates,omitempty"`
+	// Facets holds the counts of the values of the facet columns of a read, e.g. {"status": {"open": 3}}
+	Facets map[string]map[string]int64 `json:"facets,omitempty"`
+}
+
+// SearchMetadata describes the results of a full-text search
+type SearchMetadata struct {
+	Query string      `json:"query"`
+	Hits  []SearchHit `json:"hits"`
+}
+
+// SearchHit is the rank and highlighted snippet of one returned record, identified by its primary key
+type SearchHit struct {
+	ID      interface{} `json:"id"`
+	Rank    float64     `json:"rank"`
+	Snippet string      `json:"snippet,omitempty"`
 }
 
 type APIError struct {
-	Code    string      `json:"code"`
-	Message string      `json:"message"`
-	Details interface{} `json:"details,omitempty"`
-	Detail  string      `json:"detail,omitempty"`
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	Detail    string      `json:"detail,omitempty"`
+	Column    string      `json:"column,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
 }
 
 type Column struct {
@@ -103,11 +161,21 @@ type Column struct {
 	IsPrimary  bool   `json:"is_primary"`
 	IsUnique   bool   `json:"is_unique"`
 	HasIndex   bool   `json:"has_index"`
+	// Enum lists the allowed values of the column, see EnumValues
+	Enum []string `json:"enum,omitempty"`
+
+	DefaultValue string `json:"default_value,omitempty"` // SQL default of the column
+	Comment      string `json:"comment,omitempty"`
+	MaxLength    int    `json:"max_length,omitempty"` // Length of character columns
+	Precision    int    `json:"precision,omitempty"`  // Total digits of numeric columns
+	Scale        int    `json:"scale,omitempty"`      // Fractional digits of numeric columns
 }
 
 type TableMetadata struct {
-	Schema    string   `json:"schema"`
-	Table     string   `json:"table"`
-	Columns   []Column `json:"columns"`
-	Relations []string `json:"relations"`
+	Schema    string             `json:"schema"`
+	Table     string             `json:"table"`
+	Columns   []Column           `json:"columns"`
+	Relations []RelationMetadata `json:"relations"`
+	Indexes   []IndexMetadata    `json:"indexes,omitempty"` // Only reported by schema introspection
+	Scopes    []string           `json:"scopes,omitempty"`  // Names of the scopes of the model
 }
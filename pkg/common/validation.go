@@ -2,7 +2,6 @@ package common
 
 import (
 	"fmt"
-	"reflect"
 	"strings"
 
 	"github.com/bitechdev/ResolveSpec/pkg/logger"
@@ -25,72 +24,19 @@ func NewColumnValidator(model interface{}) *ColumnValidator {
 	return validator
 }
 
-// buildValidColumns extracts all valid column names from the model using reflection
+// buildValidColumns collects the column names of the model's fields, including promoted fields
+// of embedded structs, from its reflection.ModelInfo, which for registered models is the one
+// their descriptor was built from
 func (v *ColumnValidator) buildValidColumns() {
-	modelType := reflect.TypeOf(v.model)
-
-	// Unwrap pointers, slices, and arrays to get to the base struct type
-	for modelType != nil && (modelType.Kind() == reflect.Ptr || modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Array) {
-		modelType = modelType.Elem()
-	}
-
-	// Validate that we have a struct type
-	if modelType == nil || modelType.Kind() != reflect.Struct {
+	info := reflection.Describe(v.model)
+	if info == nil {
 		return
 	}
-
-	// Extract column names from struct fields
-	for i := 0; i < modelType.NumField(); i++ {
-		field := modelType.Field(i)
-
-		if !field.IsExported() {
-			continue
-		}
-
-		// Get column name from bun, gorm, or json tag
-		columnName := v.getColumnName(field)
-		if columnName != "" && columnName != "-" {
-			v.validColumns[strings.ToLower(columnName)] = true
-		}
-	}
-}
-
-// getColumnName extracts the column name from a struct field's tags
-// Supports both Bun and GORM tags
-func (v *ColumnValidator) getColumnName(field reflect.StructField) string {
-	// First check Bun tag for column name
-	bunTag := field.Tag.Get("bun")
-	if bunTag != "" && bunTag != "-" {
-		parts := strings.Split(bunTag, ",")
-		// The first part is usually the column name
-		columnName := strings.TrimSpace(parts[0])
-		if columnName != "" && columnName != "-" {
-			return columnName
-		}
-	}
-
-	// Check GORM tag for column name
-	gormTag := field.Tag.Get("gorm")
-	if strings.Contains(gormTag, "column:") {
-		parts := strings.Split(gormTag, ";")
-		for _, part := range parts {
-			part = strings.TrimSpace(part)
-			if strings.HasPrefix(part, "column:") {
-				return strings.TrimPrefix(part, "column:")
-			}
+	for _, field := range info.Fields {
+		if field.Exported && !field.Ignored {
+			v.validColumns[strings.ToLower(field.Column)] = true
 		}
 	}
-
-	// Fall back to JSON tag
-	jsonTag := field.Tag.Get("json")
-	if jsonTag != "" && jsonTag != "-" {
-		// Extract just the name part (before any comma)
-		jsonName := strings.Split(jsonTag, ",")[0]
-		return jsonName
-	}
-
-	// Fall back to field name in lowercase (snake_case conversion would be better)
-	return strings.ToLower(field.Name)
 }
 
 // ValidateColumn validates a single column name
@@ -161,6 +107,28 @@ func (v *ColumnValidator) ValidateColumns(columns []string) error {
 	return nil
 }
 
+// ValidateFilters validates the columns of filters and of a filter group and its nested groups,
+// which may nest at most MaxFilterGroupDepth levels
+func (v *ColumnValidator) ValidateFilters(filters []FilterOption, group *FilterGroup) error {
+	for _, filter := range filters {
+		if err := v.ValidateColumn(filter.Column); err != nil {
+			return fmt.Errorf("in filter: %w", err)
+		}
+	}
+	if group == nil {
+		return nil
+	}
+	if group.Depth() > MaxFilterGroupDepth {
+		return fmt.Errorf("filter group nests deeper than %d levels", MaxFilterGroupDepth)
+	}
+	for idx := range group.Groups {
+		if err := v.ValidateFilters(nil, &group.Groups[idx]); err != nil {
+			return err
+		}
+	}
+	return v.ValidateFilters(group.Filters, nil)
+}
+
 // ValidateRequestOptions validates all column references in RequestOptions
 func (v *ColumnValidator) ValidateRequestOptions(options RequestOptions) error {
 	// Validate Columns
@@ -232,6 +200,9 @@ func (v *ColumnValidator) FilterRequestOptions(options RequestOptions) RequestOp
 	}
 	filtered.Filters = validFilters
 
+	// Filter nested filter group columns
+	filtered.FilterGroup = v.filterGroup(options.FilterGroup)
+
 	// Filter Sort columns
 	validSorts := make([]SortOption, 0, len(options.Sort))
 	for _, sort := range options.Sort {
@@ -243,18 +214,24 @@ func (v *ColumnValidator) FilterRequestOptions(options RequestOptions) RequestOp
 	}
 	filtered.Sort = validSorts
 
-	// Filter Preload columns
+	// Filter Preload columns against the related model when it can be resolved
 	validPreloads := make([]PreloadOption, 0, len(options.Preload))
 	for idx := range options.Preload {
 		preload := options.Preload[idx]
+		preloadValidator := v
+		if relatedModel := reflection.GetRelationModel(v.model, preload.Relation); relatedModel != nil {
+			preloadValidator = NewColumnValidator(relatedModel)
+		}
+
 		filteredPreload := preload
-		filteredPreload.Columns = v.FilterValidColumns(preload.Columns)
-		filteredPreload.OmitColumns = v.FilterValidColumns(preload.OmitColumns)
+		filteredPreload.Columns = preloadValidator.FilterValidColumns(preload.Columns)
+		filteredPreload.OmitColumns = preloadValidator.FilterValidColumns(preload.OmitColumns)
+		filteredPreload.ComputedQL = preloadValidator.FilterComputedQL(preload.ComputedQL)
 
 		// Filter preload filters
 		validPreloadFilters := make([]FilterOption, 0, len(preload.Filters))
 		for _, filter := range preload.Filters {
-			if v.IsValidColumn(filter.Column) {
+			if preloadValidator.IsValidColumn(filter.Column) {
 				validPreloadFilters = append(validPreloadFilters, filter)
 			} else {
 				logger.Warn("Invalid column in preload '%s' filter '%s' removed", preload.Relation, filter.Column)
@@ -269,6 +246,29 @@ func (v *ColumnValidator) FilterRequestOptions(options RequestOptions) RequestOp
 	return filtered
 }
 
+// filterGroup returns a copy of the group without filters on invalid columns
+func (v *ColumnValidator) filterGroup(group *FilterGroup) *FilterGroup {
+	if group == nil {
+		return nil
+	}
+
+	filtered := &FilterGroup{Logic: group.Logic}
+	for _, filter := range group.Filters {
+		if v.IsValidColumn(filter.Column) {
+			filtered.Filters = append(filtered.Filters, filter)
+		} else {
+			logger.Warn("Invalid column in filter group '%s' removed", filter.Column)
+		}
+	}
+	for idx := range group.Groups {
+		if sub := v.filterGroup(&group.Groups[idx]); !sub.IsEmpty() {
+			filtered.Groups = append(filtered.Groups, *sub)
+		}
+	}
+
+	return filtered
+}
+
 // GetValidColumns returns a list of all valid column names for debugging purposes
 func (v *ColumnValidator) GetValidColumns() []string {
 	columns := make([]string, 0, len(v.validColumns))
@@ -282,6 +282,15 @@ func QuoteIdent(qualifier string) string {
 	return `"` + strings.ReplaceAll(qualifier, `"`, `""`) + `"`
 }
 
+// QuoteDialectIdent quotes an identifier for the dialect: with backticks on MySQL and
+// double quotes elsewhere
+func QuoteDialectIdent(dialect, ident string) string {
+	if strings.EqualFold(dialect, "mysql") {
+		return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+	}
+	return QuoteIdent(ident)
+}
+
 func QuoteLiteral(value string) string {
 	return `'` + strings.ReplaceAll(value, `'`, `''`) + `'`
 }
@@ -0,0 +1,297 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ViewHeader names the saved view a read runs, also given as the view query parameter
+const ViewHeader = "X-View"
+
+// DefaultViewTable is the table of a TableViewStore without a table name
+const DefaultViewTable = "saved_views"
+
+// View is a named, stored read of an entity: the option headers of the read, e.g. X-Sort or
+// X-Searchop-Eq-Status, by their lowercase name. Headers and query parameters of the request
+// running a view take precedence over its params.
+type View struct {
+	Schema    string            `json:"schema,omitempty"`
+	Entity    string            `json:"entity"`
+	Name      string            `json:"name"`
+	Owner     string            `json:"owner,omitempty"` // User who saved the view, "" without users
+	Shared    bool              `json:"shared"`          // Visible to every user, not only the owner
+	Params    map[string]string `json:"params"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+var viewNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,127}$`)
+
+// Validate checks the name and params of a view and lowercases the param names
+func (v *View) Validate() error {
+	if !viewNamePattern.MatchString(v.Name) {
+		return fmt.Errorf("invalid view name %q: use letters, digits, '_', '.' and '-'", v.Name)
+	}
+	if len(v.Params) == 0 {
+		return fmt.Errorf("view %s has no params", v.Name)
+	}
+	params := make(map[string]string, len(v.Params))
+	for key, value := range v.Params {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !strings.HasPrefix(key, "x-") || key == strings.ToLower(ViewHeader) {
+			return fmt.Errorf("invalid view param %q: views hold the X- option headers of a read", key)
+		}
+		params[key] = value
+	}
+	v.Params = params
+	return nil
+}
+
+// visibleTo reports whether the user may run the view
+func (v *View) visibleTo(owner string) bool {
+	return v.Shared || v.Owner == owner
+}
+
+// ViewStore keeps the saved views of entities. Views are keyed by schema, entity, name and
+// owner, so users may each have a view of the same name.
+type ViewStore interface {
+	// SaveView creates or replaces the view of its owner
+	SaveView(ctx context.Context, view View) error
+	// FindView returns the view of the owner with the name, else a shared view of the name,
+	// or nil
+	FindView(ctx context.Context, schema, entity, name, owner string) (*View, error)
+	// ListViews returns the views of the owner and the shared views, by name
+	ListViews(ctx context.Context, schema, entity, owner string) ([]View, error)
+	// DeleteView deletes the view of the owner and reports whether it existed
+	DeleteView(ctx context.Context, schema, entity, name, owner string) (bool, error)
+}
+
+// ViewOwner returns the owner of the views saved by the request of ctx: the user of AuditUser,
+// or "" without one
+func (c HandlerConfig) ViewOwner(ctx context.Context) string {
+	if c.AuditUser == nil {
+		return ""
+	}
+	user, _ := c.AuditUser(ctx)
+	return user
+}
+
+// MemoryViewStore keeps views in memory, e.g. for tests or a single instance
+type MemoryViewStore struct {
+	mu    sync.RWMutex
+	views map[string]View
+}
+
+// NewMemoryViewStore creates an empty view store
+func NewMemoryViewStore() *MemoryViewStore {
+	return &MemoryViewStore{views: make(map[string]View)}
+}
+
+func viewKey(schema, entity, name, owner string) string {
+	return strings.Join([]string{schema, entity, name, owner}, "\x00")
+}
+
+// SaveView implements ViewStore
+func (s *MemoryViewStore) SaveView(ctx context.Context, view View) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.views[viewKey(view.Schema, view.Entity, view.Name, view.Owner)] = view
+	return nil
+}
+
+// FindView implements ViewStore
+func (s *MemoryViewStore) FindView(ctx context.Context, schema, entity, name, owner string) (*View, error) {
+	views, err := s.ListViews(ctx, schema, entity, owner)
+	if err != nil {
+		return nil, err
+	}
+	var found *View
+	for i := range views {
+		if views[i].Name != name {
+			continue
+		}
+		if views[i].Owner == owner {
+			return &views[i], nil
+		}
+		if found == nil {
+			found = &views[i]
+		}
+	}
+	return found, nil
+}
+
+// ListViews implements ViewStore
+func (s *MemoryViewStore) ListViews(ctx context.Context, schema, entity, owner string) ([]View, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	views := make([]View, 0)
+	for _, view := range s.views {
+		if view.Schema == schema && view.Entity == entity && view.visibleTo(owner) {
+			views = append(views, view)
+		}
+	}
+	sort.Slice(views, func(i, j int) bool {
+		if views[i].Name != views[j].Name {
+			return views[i].Name < views[j].Name
+		}
+		return views[i].Owner < views[j].Owner
+	})
+	return views, nil
+}
+
+// DeleteView implements ViewStore
+func (s *MemoryViewStore) DeleteView(ctx context.Context, schema, entity, name, owner string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := viewKey(schema, entity, name, owner)
+	_, exists := s.views[key]
+	delete(s.views, key)
+	return exists, nil
+}
+
+// TableViewStore keeps views as rows of a table of db. Migrate creates the table with the
+// columns
+//
+//	schema_name  the schema of the entity
+//	entity       the entity of the view
+//	name         the name of the view
+//	owner        the user who saved the view
+//	shared       whether every user sees the view
+//	params       the option headers of the view as JSON
+//	updated_at   when the view was saved
+type TableViewStore struct {
+	DB    Database
+	Table string
+}
+
+// NewTableViewStore creates a view store of table in db, DefaultViewTable when empty
+func NewTableViewStore(db Database, table string) *TableViewStore {
+	if table == "" {
+		table = DefaultViewTable
+	}
+	return &TableViewStore{DB: db, Table: table}
+}
+
+type viewRow struct {
+	Schema    string    `bun:"schema_name" gorm:"column:schema_name"`
+	Entity    string    `bun:"entity" gorm:"column:entity"`
+	Name      string    `bun:"name" gorm:"column:name"`
+	Owner     string    `bun:"owner" gorm:"column:owner"`
+	Shared    bool      `bun:"shared" gorm:"column:shared"`
+	Params    string    `bun:"params" gorm:"column:params"`
+	UpdatedAt time.Time `bun:"updated_at" gorm:"column:updated_at"`
+}
+
+const viewColumns = "schema_name, entity, name, owner, shared, params, updated_at"
+
+// SaveView implements ViewStore, replacing the row of the view in a transaction
+func (s *TableViewStore) SaveView(ctx context.Context, view View) error {
+	params, err := json.Marshal(view.Params)
+	if err != nil {
+		return fmt.Errorf("failed to encode view %s: %w", view.Name, err)
+	}
+	return s.DB.RunInTransaction(ctx, func(tx Database) error {
+		if _, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE schema_name = ? AND entity = ? AND name = ? AND owner = ?", s.Table),
+			view.Schema, view.Entity, view.Name, view.Owner); err != nil {
+			return fmt.Errorf("failed to save view %s: %w", view.Name, err)
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (?, ?, ?, ?, ?, ?, ?)", s.Table, viewColumns),
+			view.Schema, view.Entity, view.Name, view.Owner, view.Shared, string(params), view.UpdatedAt.UTC()); err != nil {
+			return fmt.Errorf("failed to save view %s: %w", view.Name, err)
+		}
+		return nil
+	})
+}
+
+// FindView implements ViewStore
+func (s *TableViewStore) FindView(ctx context.Context, schema, entity, name, owner string) (*View, error) {
+	views, err := s.query(ctx, " AND name = ? AND (owner = ? OR shared = ?) ORDER BY CASE WHEN owner = ? THEN 0 ELSE 1 END, owner",
+		schema, entity, name, owner, true, owner)
+	if err != nil || len(views) == 0 {
+		return nil, err
+	}
+	return &views[0], nil
+}
+
+// ListViews implements ViewStore
+func (s *TableViewStore) ListViews(ctx context.Context, schema, entity, owner string) ([]View, error) {
+	return s.query(ctx, " AND (owner = ? OR shared = ?) ORDER BY name, owner", schema, entity, owner, true)
+}
+
+// query loads the views of an entity matching the rest of a query
+func (s *TableViewStore) query(ctx context.Context, rest string, args ...interface{}) ([]View, error) {
+	var rows []viewRow
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE schema_name = ? AND entity = ?", viewColumns, s.Table) + rest
+	if err := s.DB.Query(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to load views: %w", err)
+	}
+	views := make([]View, 0, len(rows))
+	for _, row := range rows {
+		view := View{Schema: row.Schema, Entity: row.Entity, Name: row.Name, Owner: row.Owner, Shared: row.Shared, UpdatedAt: row.UpdatedAt}
+		if err := json.Unmarshal([]byte(row.Params), &view.Params); err != nil {
+			return nil, fmt.Errorf("failed to decode view %s: %w", row.Name, err)
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// DeleteView implements ViewStore
+func (s *TableViewStore) DeleteView(ctx context.Context, schema, entity, name, owner string) (bool, error) {
+	result, err := s.DB.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE schema_name = ? AND entity = ? AND name = ? AND owner = ?", s.Table),
+		schema, entity, name, owner)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete view %s: %w", name, err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// Migrations returns the statement creating the view table unless it exists, for the
+// postgres, mysql and sqlite dialects
+func (s *TableViewStore) Migrations(dialect string) ([]string, error) {
+	switch strings.ToLower(dialect) {
+	case "postgres", "sqlite":
+		return []string{fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	schema_name TEXT NOT NULL DEFAULT '',
+	entity TEXT NOT NULL,
+	name TEXT NOT NULL,
+	owner TEXT NOT NULL DEFAULT '',
+	shared BOOLEAN NOT NULL DEFAULT FALSE,
+	params TEXT NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (schema_name, entity, name, owner)
+)`, s.Table)}, nil
+	case "mysql":
+		return []string{fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	schema_name VARCHAR(128) NOT NULL DEFAULT '',
+	entity VARCHAR(128) NOT NULL,
+	name VARCHAR(128) NOT NULL,
+	owner VARCHAR(255) NOT NULL DEFAULT '',
+	shared BOOLEAN NOT NULL DEFAULT FALSE,
+	params LONGTEXT NOT NULL,
+	updated_at DATETIME(6) NOT NULL,
+	PRIMARY KEY (schema_name, entity, name, owner)
+)`, s.Table)}, nil
+	default:
+		return nil, fmt.Errorf("view migrations don't support the %q dialect", dialect)
+	}
+}
+
+// Migrate creates the view table unless it exists
+func (s *TableViewStore) Migrate(ctx context.Context) error {
+	statements, err := s.Migrations(s.DB.Dialect().Name)
+	if err != nil {
+		return err
+	}
+	for _, statement := range statements {
+		if _, err := s.DB.Exec(ctx, statement); err != nil {
+			return fmt.Errorf("failed to migrate view table %s: %w", s.Table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViewValidate(t *testing.T) {
+	view := View{Name: "open-tickets", Params: map[string]string{"X-Sort": "-created_at", " X-Searchop-Eq-Status ": "open"}}
+	require.NoError(t, view.Validate())
+	assert.Equal(t, map[string]string{"x-sort": "-created_at", "x-searchop-eq-status": "open"}, view.Params)
+
+	for _, invalid := range []View{
+		{Name: "", Params: map[string]string{"X-Sort": "id"}},
+		{Name: "../x", Params: map[string]string{"X-Sort": "id"}},
+		{Name: strings.Repeat("a", 129), Params: map[string]string{"X-Sort": "id"}},
+		{Name: "empty"},
+		{Name: "auth", Params: map[string]string{"Authorization": "secret"}},
+		{Name: "nested", Params: map[string]string{"X-View": "other"}},
+	} {
+		assert.Error(t, invalid.Validate(), invalid.Name)
+	}
+}
+
+func TestMemoryViewStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryViewStore()
+	params := map[string]string{"x-sort": "id"}
+	require.NoError(t, store.SaveView(ctx, View{Entity: "tickets", Name: "open", Owner: "alice", Params: params}))
+	require.NoError(t, store.SaveView(ctx, View{Entity: "tickets", Name: "open", Owner: "bob", Shared: true, Params: params}))
+	require.NoError(t, store.SaveView(ctx, View{Entity: "tickets", Name: "mine", Owner: "bob", Params: params}))
+	require.NoError(t, store.SaveView(ctx, View{Entity: "users", Name: "open", Owner: "alice", Params: params}))
+
+	view, err := store.FindView(ctx, "", "tickets", "open", "alice")
+	require.NoError(t, err)
+	require.NotNil(t, view)
+	assert.Equal(t, "alice", view.Owner, "own views hide shared views of the name")
+
+	view, err = store.FindView(ctx, "", "tickets", "open", "carol")
+	require.NoError(t, err)
+	require.NotNil(t, view)
+	assert.Equal(t, "bob", view.Owner)
+
+	view, err = store.FindView(ctx, "", "tickets", "mine", "carol")
+	require.NoError(t, err)
+	assert.Nil(t, view, "views are private unless shared")
+
+	views, err := store.ListViews(ctx, "", "tickets", "alice")
+	require.NoError(t, err)
+	require.Len(t, views, 2)
+	assert.Equal(t, []string{"alice", "bob"}, []string{views[0].Owner, views[1].Owner})
+
+	deleted, err := store.DeleteView(ctx, "", "tickets", "open", "carol")
+	require.NoError(t, err)
+	assert.False(t, deleted, "only the owner deletes a view")
+	deleted, err = store.DeleteView(ctx, "", "tickets", "open", "alice")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+}
+
+func TestTableViewStoreMigrations(t *testing.T) {
+	store := NewTableViewStore(nil, "")
+	assert.Equal(t, DefaultViewTable, store.Table)
+	for _, dialect := range []string{"postgres", "sqlite", "mysql"} {
+		statements, err := store.Migrations(dialect)
+		require.NoError(t, err, dialect)
+		require.Len(t, statements, 1)
+		assert.Contains(t, statements[0], "CREATE TABLE IF NOT EXISTS saved_views")
+		assert.Contains(t, statements[0], "PRIMARY KEY (schema_name, entity, name, owner)")
+	}
+	_, err := store.Migrations("mssql")
+	assert.Error(t, err)
+}
@@ -0,0 +1,148 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Headers of restheadspec writes by filter
+const (
+	WriteLimitHeader  = "X-Write-Limit"  // Most rows an update or delete by filter may change
+	WriteAllHeader    = "X-Write-All"    // "true" confirms an update by filter without a row limit
+	ConfirmBulkHeader = "X-Confirm-Bulk" // "true" confirms a delete by filter
+)
+
+// WriteGuard protects an update or delete by filter from changing more rows than intended.
+// Such a write needs a row limit, or All to confirm that it may change any number of rows.
+type WriteGuard struct {
+	Limit int  // Most rows the write may change; a write changing more is rolled back
+	All   bool // Confirms a write without a row limit
+}
+
+// Check rejects a write by filter without a row limit or confirmation with 400
+func (g WriteGuard) Check() *RequestLimitError {
+	if g.Limit > 0 || g.All {
+		return nil
+	}
+	return &RequestLimitError{
+		Status:  http.StatusBadRequest,
+		Code:    "unguarded_write",
+		Message: "A write by filter needs a row limit or has to confirm it may change all matching rows",
+	}
+}
+
+// CheckRows rejects a write by filter that changed n rows, more than its limit, with 409.
+// Returned from a transaction it rolls the write back.
+func (g WriteGuard) CheckRows(n int64) *RequestLimitError {
+	if g.Limit <= 0 || n <= int64(g.Limit) {
+		return nil
+	}
+	return &RequestLimitError{
+		Status:  http.StatusConflict,
+		Code:    "row_limit_exceeded",
+		Message: fmt.Sprintf("The write matched %d rows, at most %d are allowed", n, g.Limit),
+	}
+}
+
+// ConfirmDelete rejects a delete by filter without the X-Confirm-Bulk: true header with 400
+func ConfirmDelete(header string) *RequestLimitError {
+	if strings.EqualFold(header, "true") {
+		return nil
+	}
+	return &RequestLimitError{
+		Status:  http.StatusBadRequest,
+		Code:    "unconfirmed_delete",
+		Message: fmt.Sprintf("A delete by filter needs the %s: true header", ConfirmBulkHeader),
+	}
+}
+
+// DeleteFilter is the body of a delete by filter
+type DeleteFilter struct {
+	Filters     []FilterOption `json:"filters"`
+	FilterGroup *FilterGroup   `json:"filter_group"`
+	DryRun      bool           `json:"dry_run"` // Counts the matching rows without deleting them
+}
+
+// ParseDeleteFilter returns the delete by filter of a decoded DELETE body: an object with
+// filters, a filter group or dry_run. Other bodies, like the ids of a batch delete, return false.
+func ParseDeleteFilter(data interface{}) (DeleteFilter, bool, error) {
+	var filter DeleteFilter
+	values, ok := data.(map[string]interface{})
+	if !ok {
+		return filter, false, nil
+	}
+	_, hasFilters := values["filters"]
+	_, hasGroup := values["filter_group"]
+	_, hasDryRun := values["dry_run"]
+	if !hasFilters && !hasGroup && !hasDryRun {
+		return filter, false, nil
+	}
+	body, err := json.Marshal(values)
+	if err != nil {
+		return filter, true, err
+	}
+	if err := json.Unmarshal(body, &filter); err != nil {
+		return filter, true, fmt.Errorf("invalid delete filter: %w", err)
+	}
+	return filter, true, nil
+}
+
+// AndWhere joins two conditions of FilterWhere with AND, either may be empty
+func AndWhere(where string, args []interface{}, other string, otherArgs []interface{}) (string, []interface{}) {
+	switch {
+	case other == "":
+		return where, args
+	case where == "":
+		return other, otherArgs
+	}
+	return "(" + where + ") AND (" + other + ")", append(append([]interface{}{}, args...), otherArgs...)
+}
+
+// FilterWhere renders the filters, joined by their logic operators, and the filter group of a
+// write by filter as a single condition. Unlike reads, a write fails on filters that can't be
+// rendered, since skipping them would change more rows than asked for. An empty condition
+// matches all rows.
+func FilterWhere(filters []FilterOption, group *FilterGroup, condition FilterConditionFunc) (string, []interface{}, error) {
+	var invalid *FilterOption
+	render := func(filter FilterOption) (string, []interface{}) {
+		cond, args := condition(filter)
+		if cond == "" && invalid == nil {
+			invalid = &filter
+		}
+		return cond, args
+	}
+
+	var sb strings.Builder
+	var args []interface{}
+	for i, filter := range filters {
+		cond, condArgs := render(filter)
+		if cond == "" {
+			continue
+		}
+		if i > 0 {
+			if strings.EqualFold(filter.LogicOperator, "OR") {
+				sb.WriteString(" OR ")
+			} else {
+				sb.WriteString(" AND ")
+			}
+		}
+		sb.WriteString("(" + cond + ")")
+		args = append(args, condArgs...)
+	}
+	where := sb.String()
+
+	if groupSQL, groupArgs := group.ToSQL(render); groupSQL != "" {
+		if where != "" {
+			where = "(" + where + ") AND "
+		}
+		where += groupSQL
+		args = append(args, groupArgs...)
+	}
+
+	if invalid != nil {
+		return "", nil, fmt.Errorf("filter on %s with operator %q can't be applied to a write", invalid.Column, invalid.Operator)
+	}
+	return where, args, nil
+}
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteGuard(t *testing.T) {
+	assert.NotNil(t, WriteGuard{}.Check())
+	assert.Nil(t, WriteGuard{Limit: 10}.Check())
+	assert.Nil(t, WriteGuard{All: true}.Check())
+
+	assert.Nil(t, WriteGuard{Limit: 2}.CheckRows(2))
+	assert.Nil(t, WriteGuard{All: true}.CheckRows(1000))
+	err := WriteGuard{Limit: 2}.CheckRows(3)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusConflict, err.Status)
+	assert.Equal(t, "row_limit_exceeded", err.Code)
+}
+
+func TestFilterWhere(t *testing.T) {
+	condition := func(filter FilterOption) (string, []interface{}) {
+		if filter.Operator != "eq" {
+			return "", nil
+		}
+		return filter.Column + " = ?", []interface{}{filter.Value}
+	}
+
+	where, args, err := FilterWhere([]FilterOption{
+		{Column: "status", Operator: "eq", Value: "open"},
+		{Column: "status", Operator: "eq", Value: "late", LogicOperator: "OR"},
+	}, &FilterGroup{Filters: []FilterOption{{Column: "owner", Operator: "eq", Value: "ann"}}}, condition)
+	require.NoError(t, err)
+	assert.Equal(t, "((status = ?) OR (status = ?)) AND (owner = ?)", where)
+	assert.Equal(t, []interface{}{"open", "late", "ann"}, args)
+
+	where, args, err = FilterWhere(nil, nil, condition)
+	require.NoError(t, err)
+	assert.Empty(t, where, "no filters match all rows")
+	assert.Empty(t, args)
+
+	_, _, err = FilterWhere([]FilterOption{{Column: "status", Operator: "unknown"}}, nil, condition)
+	assert.ErrorContains(t, err, "status", "filters that can't be rendered fail the write")
+	_, _, err = FilterWhere(nil, &FilterGroup{Filters: []FilterOption{{Column: "owner", Operator: "unknown"}}}, condition)
+	assert.Error(t, err)
+}
+
+func TestParseDeleteFilter(t *testing.T) {
+	filter, ok, err := ParseDeleteFilter(map[string]interface{}{
+		"filters": []interface{}{map[string]interface{}{"column": "status", "operator": "eq", "value": "closed"}},
+		"dry_run": true,
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.True(t, filter.DryRun)
+	require.Len(t, filter.Filters, 1)
+	assert.Equal(t, "status", filter.Filters[0].Column)
+
+	_, ok, _ = ParseDeleteFilter([]interface{}{"1", "2"})
+	assert.False(t, ok, "ids of a batch delete")
+	_, ok, _ = ParseDeleteFilter(map[string]interface{}{"id": 1})
+	assert.False(t, ok)
+	_, ok, err = ParseDeleteFilter(map[string]interface{}{"filters": "status"})
+	assert.True(t, ok)
+	assert.Error(t, err)
+
+	assert.NotNil(t, ConfirmDelete(""))
+	assert.Nil(t, ConfirmDelete("true"))
+}
+
+func TestAndWhere(t *testing.T) {
+	where, args := AndWhere("a = ?", []interface{}{1}, "b = ? OR c = ?", []interface{}{2, 3})
+	assert.Equal(t, "(a = ?) AND (b = ? OR c = ?)", where)
+	assert.Equal(t, []interface{}{1, 2, 3}, args)
+
+	where, args = AndWhere("", nil, "b = ?", []interface{}{2})
+	assert.Equal(t, "b = ?", where)
+	assert.Equal(t, []interface{}{2}, args)
+}
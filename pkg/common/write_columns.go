@@ -0,0 +1,179 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// WriteWarningsHeader lists the columns removed from the payload of a create or update,
+// separated by "; "
+const WriteWarningsHeader = "X-Write-Warnings"
+
+// ProtectedColumn is the error code of a write setting a column clients may not write
+const ProtectedColumn = "protected_column"
+
+// WriteColumnError rejects a write payload setting a protected column or changing a primary key
+type WriteColumnError struct {
+	Column string
+	Reason string
+}
+
+func (e *WriteColumnError) Error() string {
+	return fmt.Sprintf("column %s can't be written: %s", e.Column, e.Reason)
+}
+
+// ServerManaged is implemented by column types whose values only the server sets, e.g. the file
+// references of pkg/attachments. Their columns are protected like ProtectedColumns.
+type ServerManaged interface {
+	ServerManaged()
+}
+
+var serverManagedType = reflect.TypeOf((*ServerManaged)(nil)).Elem()
+
+// serverManagedColumns returns the column and JSON names of the ServerManaged fields of model
+func serverManagedColumns(model interface{}) []string {
+	info := reflection.Describe(model)
+	if info == nil {
+		return nil
+	}
+	var columns []string
+	for _, field := range info.Fields {
+		if !field.IsColumn() {
+			continue
+		}
+		if field.Type.Implements(serverManagedType) || reflect.PointerTo(field.Type).Implements(serverManagedType) {
+			columns = append(columns, field.Column)
+			if field.JSONName != "" && field.JSONName != field.Column {
+				columns = append(columns, field.JSONName)
+			}
+		}
+	}
+	return columns
+}
+
+// SanitizeWrite prepares the records of a create or update payload, a record or a list of
+// records. Read-only columns of model (Bun scanonly, GORM "->" or "<-:false") are removed with a
+// warning each. Records setting one of the protected columns or a ServerManaged column, or
+// changing the primary key of the updated record id, are rejected with a *WriteColumnError.
+// Columns are also checked in JSON path keys ("settings->theme"), in the json_patch section and
+// in the nested records of relations, with the rules of the related model.
+func SanitizeWrite(model, data interface{}, update bool, id string, protected []string) ([]string, error) {
+	var keys map[string]string
+	if update && id != "" {
+		if names, values, err := KeyValues(model, id); err == nil {
+			keys = make(map[string]string, len(names))
+			for i, name := range names {
+				keys[name] = fmt.Sprint(values[i])
+			}
+		}
+	}
+
+	stripped := make(map[string]bool)
+	if err := sanitizeRecords(model, data, "", keys, protected, stripped); err != nil {
+		return nil, err
+	}
+
+	warnings := make([]string, 0, len(stripped))
+	for column := range stripped {
+		warnings = append(warnings, fmt.Sprintf("column %s is read-only and was ignored", column))
+	}
+	sort.Strings(warnings)
+	return warnings, nil
+}
+
+// sanitizeRecords sanitizes the records of model in data. Columns are reported with prefix, the
+// path of the relation of nested records. keys are the primary key values of the updated record,
+// nil for creates and nested records.
+func sanitizeRecords(model, data interface{}, prefix string, keys map[string]string, protected []string, stripped map[string]bool) error {
+	info := reflection.Describe(model)
+	if info == nil {
+		return nil
+	}
+	modelProtected := append(append([]string{}, protected...), serverManagedColumns(model)...)
+	check := func(record map[string]interface{}, key, column string) error {
+		if containsFold(modelProtected, column) {
+			return &WriteColumnError{Column: prefix + column, Reason: "it is managed by the server"}
+		}
+		if !reflection.IsColumnWritable(model, column) {
+			delete(record, key)
+			stripped[prefix+column] = true
+		}
+		return nil
+	}
+
+	for _, record := range writeRecords(data) {
+		for key, value := range record {
+			if strings.HasPrefix(key, "_") {
+				continue
+			}
+			if relation := writeRelation(info, key); relation != nil {
+				if relation.RelatedType == nil {
+					continue
+				}
+				related := reflect.New(relation.RelatedType).Elem().Interface()
+				if err := sanitizeRecords(related, value, prefix+key+".", nil, protected, stripped); err != nil {
+					return err
+				}
+				continue
+			}
+			if key == JSONPatchKey {
+				documents, _ := value.(map[string]interface{})
+				for column := range documents {
+					if err := check(documents, column, column); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			column := key
+			if IsJSONPatchKey(key) {
+				column = strings.TrimSpace(strings.SplitN(key, "->", 2)[0])
+			}
+			if expected, isKey := keys[column]; isKey && (column != key || value != nil && fmt.Sprint(value) != expected) {
+				return &WriteColumnError{Column: prefix + column, Reason: "the primary key can't be changed"}
+			}
+			if err := check(record, key, column); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeRecords returns the records of a write payload, a record or a list of records
+func writeRecords(data interface{}) []map[string]interface{} {
+	records := make([]map[string]interface{}, 0, 1)
+	switch v := data.(type) {
+	case map[string]interface{}:
+		records = append(records, v)
+	case []map[string]interface{}:
+		records = append(records, v...)
+	case []interface{}:
+		for _, item := range v {
+			if record, ok := item.(map[string]interface{}); ok {
+				records = append(records, record)
+			}
+		}
+	}
+	return records
+}
+
+// writeRelation returns the relation field of a payload key, matched by JSON, field or column
+// name, or nil
+func writeRelation(info *reflection.ModelInfo, key string) *reflection.FieldInfo {
+	for i := range info.Fields {
+		field := &info.Fields[i]
+		if field.Relation == "" || !field.Exported {
+			continue
+		}
+		if strings.EqualFold(field.JSONName, key) || strings.EqualFold(field.Name, key) || strings.EqualFold(field.Column, key) {
+			return field
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type writeArticle struct {
+	ID        int64                  `json:"id" bun:"id,pk"`
+	Title     string                 `json:"title" bun:"title"`
+	Views     int                    `json:"views" bun:"views,scanonly"`
+	Slug      string                 `json:"slug" gorm:"column:slug;->"`
+	CreatedBy string                 `json:"created_by" bun:"created_by"`
+	Settings  map[string]interface{} `json:"settings" bun:"settings"`
+	Comments  []writeComment         `json:"comments" bun:"rel:has-many,join:id=article_id"`
+}
+
+type writeComment struct {
+	ID        int64         `json:"id" bun:"id,pk"`
+	ArticleID int64         `json:"article_id" bun:"article_id"`
+	Body      string        `json:"body" bun:"body"`
+	Likes     int           `json:"likes" bun:"likes,scanonly"`
+	CreatedBy string        `json:"created_by" bun:"created_by"`
+	Upload    *writeUpload  `json:"upload" bun:"upload"`
+	Author    *writeComment `json:"author" bun:"rel:belongs-to,join:author_id=id"`
+}
+
+// writeUpload is a column only the server sets
+type writeUpload struct {
+	Key string `json:"key"`
+}
+
+func (writeUpload) ServerManaged() {}
+
+type writeLine struct {
+	OrderID int64  `json:"order_id" bun:"order_id,pk"`
+	LineNo  int64  `json:"line_no" bun:"line_no,pk"`
+	Item    string `json:"item" bun:"item"`
+}
+
+func TestSanitizeWrite(t *testing.T) {
+	protected := []string{"created_by"}
+	tests := []struct {
+		name     string
+		data     interface{}
+		update   bool
+		id       string
+		want     interface{}
+		warnings []string
+		column   string
+	}{
+		{
+			name:     "read-only columns are removed",
+			data:     map[string]interface{}{"title": "a", "views": 3, "slug": "a", "_request": "insert"},
+			want:     map[string]interface{}{"title": "a", "_request": "insert"},
+			warnings: []string{"column slug is read-only and was ignored", "column views is read-only and was ignored"},
+		},
+		{
+			name:     "lists of records",
+			data:     []interface{}{map[string]interface{}{"title": "a"}, map[string]interface{}{"views": 1}},
+			want:     []interface{}{map[string]interface{}{"title": "a"}, map[string]interface{}{}},
+			warnings: []string{"column views is read-only and was ignored"},
+		},
+		{
+			name:   "protected column",
+			data:   map[string]interface{}{"title": "a", "created_by": "mallory"},
+			column: "created_by",
+		},
+		{
+			name:   "changed primary key",
+			data:   map[string]interface{}{"id": 7, "title": "a"},
+			update: true,
+			id:     "3",
+			column: "id",
+		},
+		{
+			name:     "unchanged primary key",
+			data:     map[string]interface{}{"id": 3, "title": "a"},
+			update:   true,
+			id:       "3",
+			want:     map[string]interface{}{"id": 3, "title": "a"},
+			warnings: []string{},
+		},
+		{
+			name:   "protected column by JSON path",
+			data:   map[string]interface{}{"created_by->name": "mallory"},
+			column: "created_by",
+		},
+		{
+			name:   "protected column in the json_patch section",
+			data:   map[string]interface{}{"json_patch": map[string]interface{}{"created_by": map[string]interface{}{"name": "mallory"}}},
+			column: "created_by",
+		},
+		{
+			name: "read-only columns by JSON path and in the json_patch section",
+			data: map[string]interface{}{
+				"slug->x":     "a",
+				"settings->x": 1,
+				"json_patch":  map[string]interface{}{"views": map[string]interface{}{"x": 1}, "settings": map[string]interface{}{"y": 2}},
+			},
+			want: map[string]interface{}{
+				"settings->x": 1,
+				"json_patch":  map[string]interface{}{"settings": map[string]interface{}{"y": 2}},
+			},
+			warnings: []string{"column slug is read-only and was ignored", "column views is read-only and was ignored"},
+		},
+		{
+			name:   "primary key by JSON path",
+			data:   map[string]interface{}{"id->x": 7},
+			update: true,
+			id:     "3",
+			column: "id",
+		},
+		{
+			name:   "protected column of a nested record",
+			data:   map[string]interface{}{"comments": []interface{}{map[string]interface{}{"body": "a", "created_by": "mallory"}}},
+			column: "comments.created_by",
+		},
+		{
+			name:   "server managed column of a nested record",
+			data:   map[string]interface{}{"comments": []interface{}{map[string]interface{}{"upload": map[string]interface{}{"key": "x"}}}},
+			column: "comments.upload",
+		},
+		{
+			name:   "protected column of a record nested twice",
+			data:   map[string]interface{}{"comments": map[string]interface{}{"author": map[string]interface{}{"created_by": "mallory"}}},
+			column: "comments.author.created_by",
+		},
+		{
+			name:     "read-only column of a nested record",
+			data:     map[string]interface{}{"comments": []interface{}{map[string]interface{}{"body": "a", "likes": 9}}},
+			want:     map[string]interface{}{"comments": []interface{}{map[string]interface{}{"body": "a"}}},
+			warnings: []string{"column comments.likes is read-only and was ignored"},
+		},
+		{
+			name:     "primary key of a create",
+			data:     map[string]interface{}{"id": 7},
+			want:     map[string]interface{}{"id": 7},
+			warnings: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := SanitizeWrite(writeArticle{}, tt.data, tt.update, tt.id, protected)
+			if tt.column != "" {
+				var columnErr *WriteColumnError
+				require.ErrorAs(t, err, &columnErr)
+				assert.Equal(t, tt.column, columnErr.Column)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tt.data)
+			assert.Equal(t, tt.warnings, warnings)
+		})
+	}
+}
+
+func TestSanitizeWriteCompositeKey(t *testing.T) {
+	_, err := SanitizeWrite(writeLine{}, map[string]interface{}{"order_id": 7, "line_no": 2, "item": "a"}, true, "7,2", nil)
+	require.NoError(t, err)
+	_, err = SanitizeWrite(writeLine{}, map[string]interface{}{"order_id": 7, "line_no": 3}, true, "7,2", nil)
+	var columnErr *WriteColumnError
+	require.ErrorAs(t, err, &columnErr, "the second key column can't be changed")
+	assert.Equal(t, "line_no", columnErr.Column)
+	_, err = SanitizeWrite(writeLine{}, map[string]interface{}{"order_id": 8}, true, `{"order_id": 7, "line_no": 2}`, nil)
+	require.ErrorAs(t, err, &columnErr)
+	assert.Equal(t, "order_id", columnErr.Column)
+}
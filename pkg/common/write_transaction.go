@@ -0,0 +1,79 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// ErrWriteFailed rolls back the write transaction of a request answered with an error
+var ErrWriteFailed = errors.New("request failed")
+
+// IsWriteRequest reports whether a request of the method may write, i.e. runs in a write
+// transaction when HandlerConfig.WriteTransactions is set
+func IsWriteRequest(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+}
+
+// TransactionWriter holds back the response of a request running in a write transaction until
+// the transaction committed. Headers are passed on right away.
+type TransactionWriter struct {
+	ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// NewTransactionWriter wraps the response writer of a request running in a write transaction
+func NewTransactionWriter(w ResponseWriter) *TransactionWriter {
+	return &TransactionWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (w *TransactionWriter) WriteHeader(statusCode int) {
+	w.status = statusCode
+}
+
+func (w *TransactionWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *TransactionWriter) WriteJSON(data interface{}) error {
+	return json.NewEncoder(&w.body).Encode(data)
+}
+
+// Failed reports whether the request was answered with an error, which rolls the transaction back
+func (w *TransactionWriter) Failed() bool {
+	return w.status >= http.StatusBadRequest
+}
+
+// Finish writes the held back response
+func (w *TransactionWriter) Finish() error {
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.body.Bytes())
+	return err
+}
+
+// requestWithContext is a request with the context of its write transaction
+type requestWithContext struct {
+	Request
+	ctx context.Context
+}
+
+func (r *requestWithContext) Context() context.Context {
+	return r.ctx
+}
+
+func (r *requestWithContext) UnderlyingRequest() *http.Request {
+	if unwrapper, ok := r.Request.(HTTPRequestUnwrapper); ok {
+		if req := unwrapper.UnderlyingRequest(); req != nil {
+			return req.WithContext(r.ctx)
+		}
+	}
+	return nil
+}
+
+// WithRequestContext returns the request with its context replaced by ctx
+func WithRequestContext(r Request, ctx context.Context) Request {
+	return &requestWithContext{Request: r, ctx: ctx}
+}
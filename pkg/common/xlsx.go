@@ -0,0 +1,281 @@
+package common
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XLSXContentType is the media type of Excel workbooks
+const XLSXContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// Styles of the cells of a sheet, indexes into the cellXfs of xlsxStyles
+const (
+	xlsxStyleHeader   = 1
+	xlsxStyleDate     = 2
+	xlsxStyleDateTime = 3
+)
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`
+
+const xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="4"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/><xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs></styleSheet>`
+
+// XLSXWriter streams rows into an Excel workbook of a single sheet. Rows are written as they
+// come; the workbook is complete once the writer is closed.
+type XLSXWriter struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+	rows    int
+}
+
+// NewXLSXWriter starts a workbook on w with a sheet of the name, see XLSXSheetName
+func NewXLSXWriter(w io.Writer, sheetName string) (*XLSXWriter, error) {
+	archive := zip.NewWriter(w)
+	var name strings.Builder
+	_ = xml.EscapeText(&name, []byte(XLSXSheetName(sheetName)))
+	workbook := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", workbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	// The sheet is the last part, so its rows are streamed
+	file, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(file)
+	_, err = sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, err
+	}
+	return &XLSXWriter{archive: archive, sheet: sheet}, nil
+}
+
+// WriteHeader writes a row of bold titles
+func (x *XLSXWriter) WriteHeader(titles []string) error {
+	values := make([]interface{}, len(titles))
+	for i, title := range titles {
+		values[i] = title
+	}
+	return x.writeRow(values, xlsxStyleHeader)
+}
+
+// WriteRow writes a row of typed cells: numbers, including json.Number, and booleans keep their
+// type, times and strings in RFC 3339 or "2006-01-02" become dates, nil leaves the cell empty
+// and other values are written as text
+func (x *XLSXWriter) WriteRow(values []interface{}) error {
+	return x.writeRow(values, 0)
+}
+
+func (x *XLSXWriter) writeRow(values []interface{}, style int) error {
+	x.rows++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.rows)
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		ref := xlsxColumn(i) + strconv.Itoa(x.rows)
+		if err := x.writeCell(ref, value, style); err != nil {
+			return err
+		}
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (x *XLSXWriter) writeCell(ref string, value interface{}, style int) error {
+	styled := func(s int) string {
+		if s == 0 {
+			return ""
+		}
+		return fmt.Sprintf(` s="%d"`, s)
+	}
+	if style == 0 {
+		if text, ok := value.(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, text); err == nil {
+				value = t
+			} else if t, err := time.Parse("2006-01-02", text); err == nil {
+				_, err = fmt.Fprintf(x.sheet, `<c r="%s"%s><v>%s</v></c>`, ref, styled(xlsxStyleDate), xlsxSerial(t))
+				return err
+			}
+		}
+	}
+
+	var err error
+	switch v := value.(type) {
+	case json.Number:
+		_, err = fmt.Fprintf(x.sheet, `<c r="%s"%s><v>%s</v></c>`, ref, styled(style), v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		_, err = fmt.Fprintf(x.sheet, `<c r="%s"%s><v>%v</v></c>`, ref, styled(style), v)
+	case bool:
+		bit := 0
+		if v {
+			bit = 1
+		}
+		_, err = fmt.Fprintf(x.sheet, `<c r="%s"%s t="b"><v>%d</v></c>`, ref, styled(style), bit)
+	case time.Time:
+		_, err = fmt.Fprintf(x.sheet, `<c r="%s"%s><v>%s</v></c>`, ref, styled(xlsxStyleDateTime), xlsxSerial(v))
+	default:
+		text, ok := value.(string)
+		if !ok {
+			encoded, jsonErr := json.Marshal(value)
+			if jsonErr != nil {
+				return jsonErr
+			}
+			text = string(encoded)
+		}
+		if _, err = fmt.Fprintf(x.sheet, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">`, ref, styled(style)); err != nil {
+			return err
+		}
+		if err = xml.EscapeText(x.sheet, []byte(text)); err != nil {
+			return err
+		}
+		_, err = x.sheet.WriteString(`</t></is></c>`)
+	}
+	return err
+}
+
+// Close completes the sheet and the workbook
+func (x *XLSXWriter) Close() error {
+	if _, err := x.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.archive.Close()
+}
+
+// XLSXSheetName returns name as a valid sheet name: at most 31 characters, without []:*?/\ and
+// not empty
+func XLSXSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	name = strings.Trim(name, "'")
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if name == "" {
+		return "Sheet1"
+	}
+	return name
+}
+
+// xlsxColumn returns the letters of a zero-based column, e.g. 0 is A and 27 is AB
+func xlsxColumn(index int) string {
+	letters := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		letters = string(rune('A'+(index-1)%26)) + letters
+	}
+	return letters
+}
+
+// xlsxEpoch is day 0 of the serial dates of Excel, which counts 1900 as a leap year
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// xlsxSerial returns the serial date of Excel of the wall clock time of t
+func xlsxSerial(t time.Time) string {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return strconv.FormatFloat(wall.Sub(xlsxEpoch).Hours()/24, 'f', -1, 64)
+}
+
+// WriteXLSX writes the records of data, models or maps, as a workbook with a header row. The
+// columns present in the records are written in the order of columns, followed by the other
+// fields of the records by name except the hidden ones; without records every column is
+// written. Relations and other nested values are written as JSON text.
+func WriteXLSX(w io.Writer, sheetName string, columns, hidden []string, data interface{}) error {
+	value, err := jsonValue(data)
+	if err != nil {
+		return err
+	}
+	var records []map[string]interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		records = append(records, v)
+	case []interface{}:
+		for _, item := range v {
+			if record, ok := item.(map[string]interface{}); ok {
+				records = append(records, record)
+			}
+		}
+	}
+
+	header := columns
+	if len(records) > 0 {
+		present := make(map[string]bool)
+		for _, record := range records {
+			for key := range record {
+				present[key] = true
+			}
+		}
+		for _, column := range hidden {
+			delete(present, column)
+		}
+		header = make([]string, 0, len(present))
+		for _, column := range columns {
+			if present[column] {
+				header = append(header, column)
+				delete(present, column)
+			}
+		}
+		extra := make([]string, 0, len(present))
+		for key := range present {
+			extra = append(extra, key)
+		}
+		sort.Strings(extra)
+		header = append(header, extra...)
+	}
+
+	workbook, err := NewXLSXWriter(w, sheetName)
+	if err != nil {
+		return err
+	}
+	if err := workbook.WriteHeader(header); err != nil {
+		return err
+	}
+	row := make([]interface{}, len(header))
+	for _, record := range records {
+		for i, column := range header {
+			row[i] = record[column]
+		}
+		if err := workbook.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return workbook.Close()
+}
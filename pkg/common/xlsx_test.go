@@ -0,0 +1,84 @@
+package common
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readXLSXPart returns the content of a part of a workbook
+func readXLSXPart(t *testing.T, workbook []byte, name string) string {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	require.NoError(t, err)
+	file, err := archive.Open(name)
+	require.NoError(t, err)
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestWriteXLSX(t *testing.T) {
+	type row struct {
+		ID      int64     `json:"id"`
+		Name    string    `json:"name"`
+		Active  bool      `json:"active"`
+		Born    string    `json:"born"`
+		Created time.Time `json:"created"`
+		Note    *string   `json:"note"`
+	}
+	data := []row{
+		{ID: 1, Name: "Ada <Lovelace>", Active: true, Born: "1990-05-17", Created: time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteXLSX(&buf, "Employees", []string{"name", "id", "missing"}, []string{"note"}, data))
+
+	workbook := readXLSXPart(t, buf.Bytes(), "xl/workbook.xml")
+	assert.Contains(t, workbook, `<sheet name="Employees"`)
+
+	sheet := readXLSXPart(t, buf.Bytes(), "xl/worksheets/sheet1.xml")
+	assert.Contains(t, sheet, `<row r="1"><c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">name</t></is></c><c r="B1" s="1" t="inlineStr"><is><t xml:space="preserve">id</t></is></c>`)
+	assert.NotContains(t, sheet, "missing", "columns absent from the records are left out")
+	assert.Contains(t, sheet, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">Ada &lt;Lovelace&gt;</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="B2"><v>1</v></c>`)
+	// Fields missing from columns follow by name: active, born and created; note is hidden
+	assert.Contains(t, sheet, `<c r="C2" t="b"><v>1</v></c>`)
+	assert.Contains(t, sheet, `<c r="D2" s="2"><v>33010</v></c>`)
+	assert.Contains(t, sheet, `<c r="E2" s="3"><v>45293.5</v></c>`)
+	assert.NotContains(t, sheet, `r="F1"`)
+
+	types := readXLSXPart(t, buf.Bytes(), "[Content_Types].xml")
+	assert.Contains(t, types, "/xl/worksheets/sheet1.xml")
+}
+
+func TestWriteXLSXWithoutRecords(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteXLSX(&buf, "", []string{"id", "name"}, nil, []interface{}{}))
+
+	assert.Contains(t, readXLSXPart(t, buf.Bytes(), "xl/workbook.xml"), `<sheet name="Sheet1"`)
+	sheet := readXLSXPart(t, buf.Bytes(), "xl/worksheets/sheet1.xml")
+	assert.Contains(t, sheet, `<c r="B1" s="1" t="inlineStr"><is><t xml:space="preserve">name</t></is></c></row></sheetData>`)
+}
+
+func TestXLSXSheetName(t *testing.T) {
+	assert.Equal(t, "Sheet1", XLSXSheetName("  "))
+	assert.Equal(t, "Q1_Q2 sales", XLSXSheetName("Q1/Q2 sales"))
+	assert.Equal(t, strings.Repeat("a", 31), XLSXSheetName(strings.Repeat("a", 40)))
+}
+
+func TestXLSXColumn(t *testing.T) {
+	assert.Equal(t, "A", xlsxColumn(0))
+	assert.Equal(t, "Z", xlsxColumn(25))
+	assert.Equal(t, "AA", xlsxColumn(26))
+	assert.Equal(t, "AB", xlsxColumn(27))
+	assert.Equal(t, "ZZ", xlsxColumn(701))
+	assert.Equal(t, "AAA", xlsxColumn(702))
+}
@@ -0,0 +1,172 @@
+// Package events publishes change data capture messages of the writes of restheadspec and
+// resolvespec handlers to a message broker, e.g. Kafka or NATS.
+//
+// Every create, update and delete becomes a Message with the primary key of the record and
+// its state before and after the write. Messages are published once the write committed, or,
+// with an Outbox, written in the transaction of the write and published from there:
+//
+//	emitter := events.New(events.Config{Publisher: kafka.New("localhost:9092")})
+//	defer emitter.Close()
+//	handler.Use(emitter)
+//
+// A TableOutbox stores the messages in a table, which a Relay publishes:
+//
+//	outbox := events.NewTableOutbox("")
+//	outbox.Migrate(ctx, db)
+//	handler.Use(events.New(events.Config{Outbox: outbox}))
+//	relay := events.NewRelay(db, outbox, kafka.New("localhost:9092"), events.RelayConfig{})
+//	relay.Start()
+//	defer relay.Stop()
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// Operations of a message
+const (
+	OpCreate = common.BatchActionCreate
+	OpUpdate = common.BatchActionUpdate
+	OpDelete = common.BatchActionDelete
+)
+
+// Message is the change data capture message of a committed write
+type Message struct {
+	ID        string      `json:"id"`
+	Op        string      `json:"op"`
+	Schema    string      `json:"schema,omitempty"`
+	Entity    string      `json:"entity"`
+	PK        interface{} `json:"pk,omitempty"`
+	Before    interface{} `json:"before,omitempty"` // Record before an update or delete
+	After     interface{} `json:"after,omitempty"`  // Record after a create or update
+	RequestID string      `json:"request_id,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// Key returns the key of the message, its primary key, so the messages of a record keep their
+// order on brokers partitioning by key
+func (m Message) Key() string {
+	if m.PK == nil {
+		return ""
+	}
+	return fmt.Sprint(m.PK)
+}
+
+// Publisher publishes messages to a broker
+type Publisher interface {
+	Publish(ctx context.Context, topic string, msg Message) error
+	Close() error
+}
+
+// Outbox writes messages in the transaction of their write, to be published once it committed
+type Outbox interface {
+	Write(ctx context.Context, tx common.Database, topic string, msg Message) error
+}
+
+// TopicFunc returns the topic of a message
+type TopicFunc func(msg Message) string
+
+// DefaultTopic returns "resolvespec.<schema>.<entity>", or "resolvespec.<entity>" without schema
+func DefaultTopic(msg Message) string {
+	if msg.Schema == "" {
+		return "resolvespec." + msg.Entity
+	}
+	return "resolvespec." + msg.Schema + "." + msg.Entity
+}
+
+// Config configures an Emitter. Either Publisher or Outbox is required.
+type Config struct {
+	// Publisher publishes the messages of writes once they committed
+	Publisher Publisher
+	// Outbox writes the messages in the transaction of their write instead. Use it with
+	// HandlerConfig.WriteTransactions, so a message is stored if and only if its write committed.
+	Outbox Outbox
+
+	Topic    TopicFunc // Defaults to DefaultTopic
+	Entities []string  // Entity patterns of the published writes, all when empty, see hooks.ForEntity
+
+	// OnError is called when a message could not be published
+	OnError func(msg Message, err error)
+}
+
+// Emitter is a plugin emitting the messages of the writes of a handler
+type Emitter struct {
+	common.BasePlugin
+	config Config
+}
+
+// New creates an emitter
+func New(config Config) *Emitter {
+	if config.Topic == nil {
+		config.Topic = DefaultTopic
+	}
+	return &Emitter{config: config}
+}
+
+// Name returns the plugin name
+func (e *Emitter) Name() string {
+	return "events"
+}
+
+// Close closes the publisher
+func (e *Emitter) Close() error {
+	if e.config.Publisher == nil {
+		return nil
+	}
+	return e.config.Publisher.Close()
+}
+
+// newMessage returns the message of a write
+func newMessage(op, schema, entity, requestID string, pk, before, after interface{}) Message {
+	return Message{
+		ID:        uuid.NewString(),
+		Op:        op,
+		Schema:    schema,
+		Entity:    entity,
+		PK:        pk,
+		Before:    before,
+		After:     after,
+		RequestID: requestID,
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+// emit publishes the message of a write once its transaction committed, or writes it to the
+// outbox in the transaction of the write. db is used when the write has no transaction.
+func (e *Emitter) emit(ctx context.Context, db common.Database, msg Message) error {
+	topic := e.config.Topic(msg)
+	if e.config.Outbox != nil {
+		if tx, ok := common.Transaction(ctx); ok {
+			db = tx
+		}
+		return e.config.Outbox.Write(ctx, db, topic, msg)
+	}
+	common.AfterCommit(ctx, func(ctx context.Context) {
+		if err := e.config.Publisher.Publish(ctx, topic, msg); err != nil {
+			e.failed(ctx, msg, err)
+		}
+	})
+	return nil
+}
+
+func (e *Emitter) failed(ctx context.Context, msg Message, err error) {
+	if e.config.OnError != nil {
+		e.config.OnError(msg, err)
+		return
+	}
+	logError(ctx, msg, err)
+}
+
+func (e *Emitter) validate() error {
+	if e.config.Publisher == nil && e.config.Outbox == nil {
+		return errors.New("events needs a publisher or an outbox")
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type order struct {
+	ID    int64  `json:"id" bun:"id,pk"`
+	Title string `json:"title" bun:"title"`
+}
+
+func TestDefaultTopic(t *testing.T) {
+	assert.Equal(t, "resolvespec.orders", DefaultTopic(Message{Entity: "orders"}))
+	assert.Equal(t, "resolvespec.sales.orders", DefaultTopic(Message{Schema: "sales", Entity: "orders"}))
+}
+
+func TestMessageKey(t *testing.T) {
+	assert.Equal(t, "", Message{}.Key())
+	assert.Equal(t, "7", Message{PK: int64(7)}.Key())
+	assert.Equal(t, "a1", Message{PK: "a1"}.Key())
+}
+
+func TestRecords(t *testing.T) {
+	one := &order{ID: 1}
+	assert.Equal(t, []interface{}{one}, records(one))
+	assert.Len(t, records([]*order{{ID: 1}, {ID: 2}}), 2)
+	assert.Len(t, records(&[]order{{ID: 1}, {ID: 2}}), 2)
+	assert.Len(t, records(map[string]interface{}{"created": 2, "data": []interface{}{1, 2}}), 2)
+	assert.Len(t, records(map[string]interface{}{"data": "value"}), 1, "a record with a data column")
+}
+
+func TestRecordPK(t *testing.T) {
+	assert.EqualValues(t, 3, recordPK(&order{ID: 3}, "id"))
+	assert.Equal(t, 4, recordPK(map[string]interface{}{"id": 4}, "id"))
+}
+
+func TestOutboxMigrations(t *testing.T) {
+	outbox := NewTableOutbox("events.outbox")
+	for _, dialect := range []string{"postgres", "sqlite", "mysql"} {
+		statements, err := outbox.Migrations(dialect)
+		assert.NoError(t, err)
+		assert.Contains(t, statements[0], "CREATE TABLE IF NOT EXISTS events.outbox")
+		assert.Contains(t, statements[len(statements)-1], "events_outbox_pending_idx")
+	}
+	_, err := outbox.Migrations("oracle")
+	assert.Error(t, err)
+}
+
+func TestRelayStopWithoutStart(t *testing.T) {
+	relay := NewRelay(nil, NewTableOutbox(""), PublisherFunc(nil), RelayConfig{})
+	relay.Stop()
+	relay.Stop()
+}
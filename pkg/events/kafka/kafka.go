@@ -0,0 +1,53 @@
+// Package kafka publishes the messages of an events.Emitter to Kafka
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	kafkago "github.com/segmentio/kafka-go"
+
+	"github.com/bitechdev/ResolveSpec/pkg/events"
+)
+
+// Publisher writes messages to the topic of their entity, keyed by their primary key so the
+// messages of a record land on the same partition in order
+type Publisher struct {
+	writer *kafkago.Writer
+}
+
+// New creates a publisher writing to the brokers. Writes wait for all in-sync replicas.
+func New(brokers ...string) *Publisher {
+	return NewWithWriter(&kafkago.Writer{
+		Addr:                   kafkago.TCP(brokers...),
+		Balancer:               &kafkago.Hash{},
+		RequiredAcks:           kafkago.RequireAll,
+		AllowAutoTopicCreation: true,
+	})
+}
+
+// NewWithWriter creates a publisher writing with writer, which must not have a topic
+func NewWithWriter(writer *kafkago.Writer) *Publisher {
+	return &Publisher{writer: writer}
+}
+
+// Publish writes the message to topic
+func (p *Publisher) Publish(ctx context.Context, topic string, msg events.Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	return p.writer.WriteMessages(ctx, kafkago.Message{
+		Topic:   topic,
+		Key:     []byte(msg.Key()),
+		Value:   payload,
+		Time:    msg.Timestamp,
+		Headers: []kafkago.Header{{Key: "event-id", Value: []byte(msg.ID)}, {Key: "event-op", Value: []byte(msg.Op)}},
+	})
+}
+
+// Close flushes pending messages and closes the writer
+func (p *Publisher) Close() error {
+	return p.writer.Close()
+}
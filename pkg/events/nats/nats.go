@@ -0,0 +1,57 @@
+// Package nats publishes the messages of an events.Emitter to NATS or NATS JetStream
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	natsgo "github.com/nats-io/nats.go"
+
+	"github.com/bitechdev/ResolveSpec/pkg/events"
+)
+
+// Publisher publishes messages to the subject of their entity. The message id is sent in the
+// Nats-Msg-Id header, which JetStream uses to drop duplicates.
+type Publisher struct {
+	conn *natsgo.Conn
+	js   natsgo.JetStreamContext
+}
+
+// New creates a publisher publishing with core NATS, at most once
+func New(conn *natsgo.Conn) *Publisher {
+	return &Publisher{conn: conn}
+}
+
+// NewJetStream creates a publisher publishing to JetStream, waiting for the acknowledgement of
+// every message. The subjects need a stream.
+func NewJetStream(conn *natsgo.Conn, opts ...natsgo.JSOpt) (*Publisher, error) {
+	js, err := conn.JetStream(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open JetStream: %w", err)
+	}
+	return &Publisher{conn: conn, js: js}, nil
+}
+
+// Publish publishes the message to subject
+func (p *Publisher) Publish(ctx context.Context, subject string, msg events.Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	natsMsg := natsgo.NewMsg(subject)
+	natsMsg.Data = payload
+	natsMsg.Header.Set(natsgo.MsgIdHdr, msg.ID)
+	natsMsg.Header.Set("Event-Op", msg.Op)
+
+	if p.js != nil {
+		_, err = p.js.PublishMsg(natsMsg, natsgo.Context(ctx))
+		return err
+	}
+	return p.conn.PublishMsg(natsMsg)
+}
+
+// Close flushes published messages; the connection stays open
+func (p *Publisher) Close() error {
+	return p.conn.Flush()
+}
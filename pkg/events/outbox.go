@@ -0,0 +1,121 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// DefaultOutboxTable is the table of a TableOutbox without a table name
+const DefaultOutboxTable = "event_outbox"
+
+// TableOutbox writes messages as rows of an outbox table, which a Relay publishes. Migrate
+// creates the table with the columns
+//
+//	id            the message id
+//	topic         the topic of the message
+//	msg_key       the key of the message
+//	payload       the message as JSON
+//	created_at    when the message was written
+//	delivered_at  when the relay published the message, NULL until then
+//	attempts      failed publish attempts
+//	last_error    the error of the last failed attempt
+type TableOutbox struct {
+	Table string
+}
+
+// NewTableOutbox creates an outbox writing to table, DefaultOutboxTable when empty
+func NewTableOutbox(table string) *TableOutbox {
+	if table == "" {
+		table = DefaultOutboxTable
+	}
+	return &TableOutbox{Table: table}
+}
+
+// Write inserts the row of a message with tx
+func (o *TableOutbox) Write(ctx context.Context, tx common.Database, topic string, msg Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	_, err = tx.NewInsert().Table(o.Table).
+		Value("id", msg.ID).
+		Value("topic", topic).
+		Value("msg_key", msg.Key()).
+		Value("payload", string(payload)).
+		Value("created_at", msg.Timestamp).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to write event to outbox: %w", err)
+	}
+	return nil
+}
+
+// Migrations returns the statements creating the outbox table and the index of its pending
+// rows unless they exist, for the postgres, mysql and sqlite dialects
+func (o *TableOutbox) Migrations(dialect string) ([]string, error) {
+	index := strings.ReplaceAll(o.Table, ".", "_") + "_pending_idx"
+	switch strings.ToLower(dialect) {
+	case "postgres":
+		return []string{
+			fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id TEXT PRIMARY KEY,
+	topic TEXT NOT NULL,
+	msg_key TEXT NOT NULL DEFAULT '',
+	payload TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	delivered_at TIMESTAMPTZ,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT
+)`, o.Table),
+			fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (created_at) WHERE delivered_at IS NULL", index, o.Table),
+		}, nil
+	case "sqlite":
+		return []string{
+			fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id TEXT PRIMARY KEY,
+	topic TEXT NOT NULL,
+	msg_key TEXT NOT NULL DEFAULT '',
+	payload TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	delivered_at TIMESTAMP,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT
+)`, o.Table),
+			fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (created_at) WHERE delivered_at IS NULL", index, o.Table),
+		}, nil
+	case "mysql":
+		return []string{
+			fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id VARCHAR(36) PRIMARY KEY,
+	topic VARCHAR(255) NOT NULL,
+	msg_key VARCHAR(255) NOT NULL DEFAULT '',
+	payload LONGTEXT NOT NULL,
+	created_at DATETIME(6) NOT NULL,
+	delivered_at DATETIME(6) NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT,
+	INDEX %s (delivered_at, created_at)
+)`, o.Table, index),
+		}, nil
+	default:
+		return nil, fmt.Errorf("outbox migrations don't support the %q dialect", dialect)
+	}
+}
+
+// Migrate creates the outbox table and its index with db unless they exist
+func (o *TableOutbox) Migrate(ctx context.Context, db common.Database) error {
+	statements, err := o.Migrations(db.Dialect().Name)
+	if err != nil {
+		return err
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(ctx, statement); err != nil {
+			return fmt.Errorf("failed to migrate outbox table %s: %w", o.Table, err)
+		}
+	}
+	return nil
+}
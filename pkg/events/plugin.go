@@ -0,0 +1,172 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/hooks"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// beforeKey holds the record loaded by a before hook in the context of its hook context
+type beforeKey struct{}
+
+// write is a write of a handler, as seen by its hooks
+type write struct {
+	ctx       context.Context
+	db        common.Database
+	schema    string
+	entity    string
+	model     interface{}
+	id        string
+	requestID string
+	result    interface{}
+	data      interface{}
+}
+
+// RegisterHooks emits the messages of the writes of a restheadspec or resolvespec handler. The
+// records before updates and deletes are loaded by their before hooks.
+func (e *Emitter) RegisterHooks(host common.PluginHost) error {
+	if err := e.validate(); err != nil {
+		return err
+	}
+	var opts []hooks.Option
+	if len(e.config.Entities) > 0 {
+		opts = append(opts, hooks.ForEntity(e.config.Entities...))
+	}
+
+	switch handler := host.(type) {
+	case *restheadspec.Handler:
+		registry := handler.Hooks()
+		registry.RegisterMultiple([]restheadspec.HookType{restheadspec.BeforeUpdate, restheadspec.BeforeDelete}, func(ctx *restheadspec.HookContext) error {
+			var err error
+			ctx.Context, err = loadBefore(ctx.Context, ctx.Handler.GetDatabase(), ctx.Model, ctx.ID)
+			return err
+		}, opts...)
+		for hookType, op := range map[restheadspec.HookType]string{restheadspec.AfterCreate: OpCreate, restheadspec.AfterUpdate: OpUpdate, restheadspec.AfterDelete: OpDelete} {
+			op := op
+			registry.Register(hookType, func(ctx *restheadspec.HookContext) error {
+				return e.emitWrite(op, write{ctx.Context, ctx.Handler.GetDatabase(), ctx.Schema, ctx.Entity, ctx.Model, ctx.ID, ctx.RequestID, ctx.Result, ctx.Data})
+			}, opts...)
+		}
+	case *resolvespec.Handler:
+		registry := handler.Hooks()
+		registry.RegisterMultiple([]resolvespec.HookType{resolvespec.BeforeUpdate, resolvespec.BeforeDelete}, func(ctx *resolvespec.HookContext) error {
+			var err error
+			ctx.Context, err = loadBefore(ctx.Context, ctx.Handler.GetDatabase(), ctx.Model, ctx.ID)
+			return err
+		}, opts...)
+		for hookType, op := range map[resolvespec.HookType]string{resolvespec.AfterCreate: OpCreate, resolvespec.AfterUpdate: OpUpdate, resolvespec.AfterDelete: OpDelete} {
+			op := op
+			registry.Register(hookType, func(ctx *resolvespec.HookContext) error {
+				return e.emitWrite(op, write{ctx.Context, ctx.Handler.GetDatabase(), ctx.Schema, ctx.Entity, ctx.Model, ctx.ID, ctx.RequestID, ctx.Result, ctx.Data})
+			}, opts...)
+		}
+	default:
+		return fmt.Errorf("events can't be loaded into %T", host)
+	}
+	return nil
+}
+
+// emitWrite emits a message per record of a write
+func (e *Emitter) emitWrite(op string, w write) error {
+	pkName := reflection.GetPrimaryKeyName(w.model)
+	before := w.ctx.Value(beforeKey{})
+
+	if op == OpDelete {
+		var pk interface{} = w.id
+		if before != nil {
+			pk = recordPK(before, pkName)
+		}
+		if pk == nil || pk == "" {
+			return nil // A delete by filter has no record to report
+		}
+		return e.emit(w.ctx, w.db, newMessage(op, w.schema, w.entity, w.requestID, pk, before, nil))
+	}
+
+	after := w.result
+	if after == nil {
+		after = w.data
+	}
+	for _, record := range records(after) {
+		pk := recordPK(record, pkName)
+		if pk == nil && op == OpUpdate {
+			pk = w.id
+		}
+		if (pk == nil || pk == "") && op == OpUpdate {
+			continue // An update by filter has no record to report
+		}
+		if err := e.emit(w.ctx, w.db, newMessage(op, w.schema, w.entity, w.requestID, pk, before, record)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadBefore loads the record with the id before it is updated or deleted into the returned
+// context. Writes without a single id, e.g. batches, have no record before.
+func loadBefore(ctx context.Context, db common.Database, model interface{}, id string) (context.Context, error) {
+	if id == "" || model == nil {
+		return ctx, nil
+	}
+	if tx, ok := common.Transaction(ctx); ok {
+		db = tx
+	}
+	modelType := reflect.TypeOf(model)
+	for modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+
+	// Scan into a slice so a missing record is reported the same way by every adapter
+	records := reflect.New(reflect.SliceOf(reflect.PointerTo(modelType)))
+	pkName := reflection.GetPrimaryKeyName(model)
+	query := db.NewSelect().Model(records.Interface()).Where(fmt.Sprintf("%s = ?", common.QuoteIdent(pkName)), id)
+	if err := query.ScanModel(ctx); err != nil {
+		return ctx, fmt.Errorf("failed to load record before write: %w", err)
+	}
+	if records.Elem().Len() == 0 {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, beforeKey{}, records.Elem().Index(0).Interface()), nil
+}
+
+// records returns the records of the result of a write: a record, a list of records, or the
+// {"created": n, "data": [...]} summary of a multi-record create
+func records(result interface{}) []interface{} {
+	if summary, ok := result.(map[string]interface{}); ok {
+		if data, ok := summary["data"]; ok {
+			if _, ok := summary["created"]; ok {
+				result = data
+			}
+		}
+	}
+	value := reflect.ValueOf(result)
+	for value.Kind() == reflect.Ptr && !value.IsNil() && value.Elem().Kind() == reflect.Slice {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Slice {
+		return []interface{}{result}
+	}
+	list := make([]interface{}, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		list = append(list, value.Index(i).Interface())
+	}
+	return list
+}
+
+// recordPK returns the primary key of a record, a model or a map
+func recordPK(record interface{}, pkName string) interface{} {
+	if values, ok := record.(map[string]interface{}); ok {
+		return values[pkName]
+	}
+	return reflection.GetPrimaryKeyValue(record)
+}
+
+func logError(ctx context.Context, msg Message, err error) {
+	logger.ErrorContext(ctx, "Error publishing %s event of %s.%s %v: %v", msg.Op, msg.Schema, msg.Entity, msg.PK, err)
+}
@@ -0,0 +1,218 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// PublisherFunc adapts a function to a Publisher without resources to close
+type PublisherFunc func(ctx context.Context, topic string, msg Message) error
+
+// Publish calls f
+func (f PublisherFunc) Publish(ctx context.Context, topic string, msg Message) error {
+	return f(ctx, topic, msg)
+}
+
+// Close does nothing
+func (f PublisherFunc) Close() error {
+	return nil
+}
+
+// RelayConfig configures a Relay. Zero values get the defaults.
+type RelayConfig struct {
+	Interval    time.Duration // Time between polls of the outbox, default 1s
+	BatchSize   int           // Rows published per poll, default 100
+	MaxAttempts int           // Rows failing this often are left for inspection; 0 retries forever
+	Retention   time.Duration // Delivered rows are deleted after this long; 0 keeps them
+
+	// OnError is called when a message could not be published
+	OnError func(msg Message, err error)
+}
+
+// Relay publishes the pending rows of a TableOutbox in the order they were written and marks
+// them delivered. A row that fails to publish holds back the later rows with its key until it
+// succeeds, so the messages of a record keep their order. On postgres and mysql the rows of a
+// poll are locked, so relays of several instances share an outbox. A message is published at
+// least once; JetStream and idempotent consumers drop duplicates by message id.
+type Relay struct {
+	db        common.Database
+	outbox    *TableOutbox
+	publisher Publisher
+	config    RelayConfig
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// outboxRow is a pending row of an outbox table
+type outboxRow struct {
+	ID       string `bun:"id" gorm:"column:id"`
+	Topic    string `bun:"topic" gorm:"column:topic"`
+	Key      string `bun:"msg_key" gorm:"column:msg_key"`
+	Payload  string `bun:"payload" gorm:"column:payload"`
+	Attempts int    `bun:"attempts" gorm:"column:attempts"`
+}
+
+// NewRelay creates a relay publishing the rows of outbox in db with publisher
+func NewRelay(db common.Database, outbox *TableOutbox, publisher Publisher, config RelayConfig) *Relay {
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	return &Relay{
+		db:        db,
+		outbox:    outbox,
+		publisher: publisher,
+		config:    config,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start polls the outbox in the background until Stop
+func (r *Relay) Start() {
+	r.startOnce.Do(func() {
+		go r.run()
+	})
+}
+
+// Stop stops polling and waits for the running poll
+func (r *Relay) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+	r.startOnce.Do(func() {
+		close(r.done) // Never started
+	})
+	<-r.done
+}
+
+func (r *Relay) run() {
+	defer close(r.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-r.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+	for {
+		// Drain the outbox before waiting for the next tick
+		for {
+			n, err := r.RunOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				logger.Error("Error relaying outbox %s: %v", r.outbox.Table, err)
+			}
+			if err != nil || n < r.config.BatchSize {
+				break
+			}
+		}
+		if r.config.Retention > 0 {
+			if _, err := r.Purge(ctx, time.Now().Add(-r.config.Retention)); err != nil && ctx.Err() == nil {
+				logger.Error("Error purging outbox %s: %v", r.outbox.Table, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce publishes a batch of pending rows and returns the number of rows it read
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	read := 0
+	err := r.db.RunInTransaction(ctx, func(tx common.Database) error {
+		rows, err := r.pending(ctx, tx)
+		if err != nil {
+			return err
+		}
+		read = len(rows)
+
+		failedKeys := make(map[string]bool)
+		for _, row := range rows {
+			if row.Key != "" && failedKeys[row.Key] {
+				continue
+			}
+			if err := r.publish(ctx, row); err != nil {
+				if row.Key != "" {
+					failedKeys[row.Key] = true
+				}
+				if _, err := tx.Exec(ctx, fmt.Sprintf("UPDATE %s SET attempts = attempts + 1, last_error = ? WHERE id = ?", r.outbox.Table), err.Error(), row.ID); err != nil {
+					return fmt.Errorf("failed to record outbox attempt: %w", err)
+				}
+				continue
+			}
+			if _, err := tx.Exec(ctx, fmt.Sprintf("UPDATE %s SET delivered_at = ? WHERE id = ?", r.outbox.Table), time.Now().UTC(), row.ID); err != nil {
+				return fmt.Errorf("failed to mark outbox row delivered: %w", err)
+			}
+		}
+		return nil
+	})
+	return read, err
+}
+
+// pending loads the next batch of pending rows, locked on dialects that skip locked rows
+func (r *Relay) pending(ctx context.Context, tx common.Database) ([]outboxRow, error) {
+	var query strings.Builder
+	var args []interface{}
+	fmt.Fprintf(&query, "SELECT id, topic, msg_key, payload, attempts FROM %s WHERE delivered_at IS NULL", r.outbox.Table)
+	if r.config.MaxAttempts > 0 {
+		query.WriteString(" AND attempts < ?")
+		args = append(args, r.config.MaxAttempts)
+	}
+	fmt.Fprintf(&query, " ORDER BY created_at, id LIMIT %d", r.config.BatchSize)
+	switch tx.Dialect().Name {
+	case "postgres", "mysql":
+		query.WriteString(" FOR UPDATE SKIP LOCKED")
+	}
+
+	var rows []outboxRow
+	if err := tx.Query(ctx, &rows, query.String(), args...); err != nil {
+		return nil, fmt.Errorf("failed to load outbox rows: %w", err)
+	}
+	return rows, nil
+}
+
+// publish publishes the message of a row
+func (r *Relay) publish(ctx context.Context, row outboxRow) error {
+	var msg Message
+	if err := json.Unmarshal([]byte(row.Payload), &msg); err != nil {
+		return fmt.Errorf("failed to decode outbox row %s: %w", row.ID, err)
+	}
+	if err := r.publisher.Publish(ctx, row.Topic, msg); err != nil {
+		if r.config.OnError != nil {
+			r.config.OnError(msg, err)
+		} else {
+			logError(ctx, msg, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// Purge deletes the rows delivered before t and returns their number
+func (r *Relay) Purge(ctx context.Context, t time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE delivered_at IS NOT NULL AND delivered_at < ?", r.outbox.Table), t.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge outbox: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,293 @@
+// Package fixtures loads seed and test data from YAML or JSON files into the database.
+//
+// A fixture file maps registered model names to their records, inserted in the order of the
+// file. Relations are given nested, like in a recursive create, and inserted through the
+// common.NestedCUDProcessor with their foreign keys resolved. A record named by _ref can be
+// referenced by later records, also of later files: "$ref:name" is its primary key and
+// "$ref:name.column" any of its columns. Nested records can use references but can't be named.
+//
+//	departments:
+//	  - _ref: engineering
+//	    id: dept-1
+//	    name: Engineering
+//	    employees:
+//	      - id: emp-1
+//	        first_name: Jane
+//	employees:
+//	  - id: emp-2
+//	    first_name: John
+//	    department_id: $ref:engineering
+//
+// # Usage Example
+//
+//	loader := fixtures.NewLoader(db, registry, handler)
+//	records, err := loader.LoadFiles(ctx, "testdata/departments.yaml", "testdata/projects.yaml")
+//	departmentID := records.ID("engineering")
+//
+// The handler of resolvespec or restheadspec provides the relations of the models.
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+const (
+	// RefKey names a record for references of later records; it is not inserted
+	RefKey = "_ref"
+	// RefPrefix starts a reference to a named record, "$ref:name" or "$ref:name.column"
+	RefPrefix = "$ref:"
+)
+
+// Loader inserts fixture files into a database
+type Loader struct {
+	db        common.Database
+	registry  common.ModelRegistry
+	relations common.RelationshipInfoProvider
+}
+
+// NewLoader creates a loader for the models of registry. relations resolves the nested
+// relations of records, e.g. the resolvespec or restheadspec handler.
+func NewLoader(db common.Database, registry common.ModelRegistry, relations common.RelationshipInfoProvider) *Loader {
+	return &Loader{db: db, registry: registry, relations: relations}
+}
+
+// Records are the records named by _ref, as stored: with generated keys and defaults
+type Records struct {
+	values map[string]map[string]interface{}
+	keys   map[string]string
+}
+
+// ID returns the primary key of a named record, nil when there is no such record
+func (r *Records) ID(name string) interface{} {
+	if r == nil {
+		return nil
+	}
+	return r.values[name][r.keys[name]]
+}
+
+// Record returns the columns of a named record, nil when there is no such record
+func (r *Records) Record(name string) map[string]interface{} {
+	if r == nil {
+		return nil
+	}
+	return r.values[name]
+}
+
+// fixture is a parsed file: the records of each model in the order of the file
+type fixture struct {
+	name   string
+	tables []table
+}
+
+type table struct {
+	model   string
+	records []map[string]interface{}
+}
+
+// Load inserts the records of a YAML or JSON document
+func (l *Loader) Load(ctx context.Context, data []byte) (*Records, error) {
+	parsed, err := parse("fixture", data)
+	if err != nil {
+		return nil, err
+	}
+	return l.load(ctx, []fixture{parsed})
+}
+
+// LoadFiles inserts the records of fixture files in the given order, in one transaction
+func (l *Loader) LoadFiles(ctx context.Context, paths ...string) (*Records, error) {
+	fixtures := make([]fixture, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := parse(path, data)
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, parsed)
+	}
+	return l.load(ctx, fixtures)
+}
+
+// LoadFS inserts the fixture files of fsys matching the glob patterns, e.g. an embed.FS. The
+// files of a pattern are loaded in lexical order.
+func (l *Loader) LoadFS(ctx context.Context, fsys fs.FS, patterns ...string) (*Records, error) {
+	var fixtures []fixture
+	for _, pattern := range patterns {
+		paths, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no fixture files match %q", pattern)
+		}
+		for _, path := range paths {
+			data, err := fs.ReadFile(fsys, path)
+			if err != nil {
+				return nil, err
+			}
+			parsed, err := parse(path, data)
+			if err != nil {
+				return nil, err
+			}
+			fixtures = append(fixtures, parsed)
+		}
+	}
+	return l.load(ctx, fixtures)
+}
+
+// parse reads a fixture document. JSON is valid YAML; parsing nodes keeps the order of the models.
+func parse(name string, data []byte) (fixture, error) {
+	result := fixture{name: name}
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return result, fmt.Errorf("%s: %w", name, err)
+	}
+	if len(document.Content) == 0 {
+		return result, nil
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return result, fmt.Errorf("%s: a fixture maps model names to records", name)
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		model, value := root.Content[i].Value, root.Content[i+1]
+		entry := table{model: model}
+		switch value.Kind {
+		case yaml.SequenceNode:
+			if err := value.Decode(&entry.records); err != nil {
+				return result, fmt.Errorf("%s: records of %s: %w", name, model, err)
+			}
+		case yaml.MappingNode:
+			var record map[string]interface{}
+			if err := value.Decode(&record); err != nil {
+				return result, fmt.Errorf("%s: record of %s: %w", name, model, err)
+			}
+			entry.records = append(entry.records, record)
+		default:
+			return result, fmt.Errorf("%s: %s needs a list of records (line %d)", name, model, value.Line)
+		}
+		result.tables = append(result.tables, entry)
+	}
+	return result, nil
+}
+
+func (l *Loader) load(ctx context.Context, fixtures []fixture) (*Records, error) {
+	records := &Records{values: make(map[string]map[string]interface{}), keys: make(map[string]string)}
+	err := l.db.RunInTransaction(ctx, func(tx common.Database) error {
+		processor := common.NewNestedCUDProcessor(tx, l.registry, l.relations)
+		for _, fixture := range fixtures {
+			for _, entry := range fixture.tables {
+				model, err := l.registry.GetModel(entry.model)
+				if err != nil {
+					return fmt.Errorf("%s: %w", fixture.name, err)
+				}
+				tableName := tableName(model, entry.model)
+
+				for i, record := range entry.records {
+					ref, _ := record[RefKey].(string)
+					data := make(map[string]interface{}, len(record))
+					for key, value := range record {
+						if key == RefKey {
+							continue
+						}
+						if data[key], err = records.resolve(value); err != nil {
+							return fmt.Errorf("%s: %s[%d].%s: %w", fixture.name, entry.model, i, key, err)
+						}
+					}
+
+					result, err := processor.ProcessNestedCUD(ctx, "insert", data, model, nil, tableName)
+					if err != nil {
+						return fmt.Errorf("%s: %s[%d]: %w", fixture.name, entry.model, i, err)
+					}
+					if ref != "" {
+						if _, exists := records.values[ref]; exists {
+							return fmt.Errorf("%s: %s[%d]: the name %q is taken", fixture.name, entry.model, i, ref)
+						}
+						records.values[ref] = result.Data
+						records.keys[ref] = reflection.GetPrimaryKeyName(model)
+					}
+				}
+				logger.Debug("Loaded %d fixture records of %s", len(entry.records), entry.model)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// resolve copies a value of a record replacing references
+func (r *Records) resolve(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if !strings.HasPrefix(v, RefPrefix) {
+			return v, nil
+		}
+		return r.lookup(strings.TrimPrefix(v, RefPrefix))
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved, err := r.resolve(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = resolved
+		}
+		return items, nil
+	case map[string]interface{}:
+		// The processor doesn't return the keys of nested records, so they can't be named
+		if _, ok := v[RefKey]; ok {
+			return nil, fmt.Errorf("only top-level records can be named by %s", RefKey)
+		}
+		record := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			resolved, err := r.resolve(item)
+			if err != nil {
+				return nil, err
+			}
+			record[key] = resolved
+		}
+		return record, nil
+	default:
+		return value, nil
+	}
+}
+
+func (r *Records) lookup(reference string) (interface{}, error) {
+	name, column, hasColumn := strings.Cut(reference, ".")
+	record, ok := r.values[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown reference %q, a record must be named before it is referenced", name)
+	}
+	if !hasColumn {
+		column = r.keys[name]
+	}
+	value, ok := record[column]
+	if !ok {
+		return nil, fmt.Errorf("reference %q: record %s has no column %s", reference, name, column)
+	}
+	return value, nil
+}
+
+// tableName returns the table of a model: its TableName, or the name it was registered under
+func tableName(model interface{}, name string) string {
+	if provider, ok := model.(common.TableNameProvider); ok && provider.TableName() != "" {
+		return provider.TableName()
+	}
+	return name
+}
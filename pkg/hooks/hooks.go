@@ -0,0 +1,197 @@
+// Package hooks provides the lifecycle hook registry shared by the resolvespec and
+// restheadspec handlers. Each handler passes its own hook context type, so hooks keep access
+// to the request options of their API.
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// Type defines the type of hook to execute
+type Type string
+
+const (
+	// Read operation hooks
+	BeforeRead Type = "before_read"
+	AfterRead  Type = "after_read"
+
+	// Create operation hooks
+	BeforeCreate Type = "before_create"
+	AfterCreate  Type = "after_create"
+
+	// Update operation hooks
+	BeforeUpdate Type = "before_update"
+	AfterUpdate  Type = "after_update"
+
+	// Delete operation hooks
+	BeforeDelete Type = "before_delete"
+	AfterDelete  Type = "after_delete"
+
+	// Scan/Execute operation hooks
+	BeforeScan Type = "before_scan"
+	AfterScan  Type = "after_scan" // Runs after the query executed, with its Duration
+
+	// AfterCommit runs in the background once the transaction of a create, update or delete
+	// committed; it never runs for rolled back writes or dry runs
+	AfterCommit Type = "after_commit"
+)
+
+// Func is the signature for hook functions
+// It receives the hook context of the handler and can modify it or return an error
+// If an error is returned, the operation will be aborted
+type Func[C any] func(C) error
+
+// Target is implemented by the hook contexts of the handlers; it names the schema and entity
+// of the request, matched against the entity patterns of hooks
+type Target interface {
+	HookTarget() (schema, entity string)
+}
+
+// Option configures a hook on registration
+type Option func(*options)
+
+type options struct {
+	patterns []string
+	priority int
+}
+
+// ForEntity limits a hook to requests on entities matching one of the patterns. A pattern is
+// "schema.entity" or "entity" and may use the wildcards of path.Match, e.g. "public.*",
+// "*.users" or "audit_*". Matching ignores case.
+func ForEntity(patterns ...string) Option {
+	return func(o *options) {
+		o.patterns = append(o.patterns, patterns...)
+	}
+}
+
+// WithPriority orders a hook among the hooks of its type: lower priorities run first, hooks of
+// the same priority run in the order they were registered. The default priority is 0.
+func WithPriority(priority int) Option {
+	return func(o *options) {
+		o.priority = priority
+	}
+}
+
+// entry is a registered hook with its options
+type entry[C any] struct {
+	hook Func[C]
+	options
+}
+
+// matches reports whether the hook applies to a request on schema.entity
+func (e entry[C]) matches(schema, entity string) bool {
+	if len(e.patterns) == 0 {
+		return true
+	}
+	for _, pattern := range e.patterns {
+		target := entity
+		if strings.Contains(pattern, ".") {
+			target = schema + "." + entity
+		}
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(target)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry manages all registered hooks of a handler
+type Registry[C Target] struct {
+	hooks map[Type][]entry[C]
+}
+
+// NewRegistry creates a new hook registry
+func NewRegistry[C Target]() *Registry[C] {
+	return &Registry[C]{
+		hooks: make(map[Type][]entry[C]),
+	}
+}
+
+// Register adds a new hook for the specified hook type. Without options the hook runs for
+// every entity with priority 0.
+func (r *Registry[C]) Register(hookType Type, hook Func[C], opts ...Option) {
+	if r.hooks == nil {
+		r.hooks = make(map[Type][]entry[C])
+	}
+	e := entry[C]{hook: hook}
+	for _, opt := range opts {
+		opt(&e.options)
+	}
+	hooks := append(r.hooks[hookType], e)
+	sort.SliceStable(hooks, func(i, j int) bool {
+		return hooks[i].priority < hooks[j].priority
+	})
+	r.hooks[hookType] = hooks
+	logger.Info("Registered hook for %s (total: %d)", hookType, len(hooks))
+}
+
+// RegisterMultiple registers a hook for multiple hook types
+func (r *Registry[C]) RegisterMultiple(hookTypes []Type, hook Func[C], opts ...Option) {
+	for _, hookType := range hookTypes {
+		r.Register(hookType, hook, opts...)
+	}
+}
+
+// Execute runs the hooks of the specified type matching the entity of ctx in order
+// If any hook returns an error, execution stops and the error is returned
+func (r *Registry[C]) Execute(hookType Type, ctx C) error {
+	hooks, exists := r.hooks[hookType]
+	if !exists || len(hooks) == 0 {
+		return nil
+	}
+
+	schema, entity := ctx.HookTarget()
+	logger.Debug("Executing hook(s) for %s on %s.%s", hookType, schema, entity)
+	for i, hook := range hooks {
+		if !hook.matches(schema, entity) {
+			continue
+		}
+		if err := hook.hook(ctx); err != nil {
+			var response *Response
+			if errors.As(err, &response) {
+				logger.Debug("Hook %d for %s answered with status %d", i+1, hookType, response.Status)
+			} else {
+				logger.Error("Hook %d for %s failed: %v", i+1, hookType, err)
+			}
+			return fmt.Errorf("hook execution failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// Clear removes all hooks for the specified type
+func (r *Registry[C]) Clear(hookType Type) {
+	delete(r.hooks, hookType)
+	logger.Info("Cleared all hooks for %s", hookType)
+}
+
+// ClearAll removes all registered hooks
+func (r *Registry[C]) ClearAll() {
+	r.hooks = make(map[Type][]entry[C])
+	logger.Info("Cleared all hooks")
+}
+
+// Count returns the number of hooks registered for a specific type
+func (r *Registry[C]) Count(hookType Type) int {
+	return len(r.hooks[hookType])
+}
+
+// HasHooks returns true if there are any hooks registered for the specified type
+func (r *Registry[C]) HasHooks(hookType Type) bool {
+	return r.Count(hookType) > 0
+}
+
+// GetAllHookTypes returns all hook types that have registered hooks
+func (r *Registry[C]) GetAllHookTypes() []Type {
+	types := make([]Type, 0, len(r.hooks))
+	for hookType := range r.hooks {
+		types = append(types, hookType)
+	}
+	return types
+}
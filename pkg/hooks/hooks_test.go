@@ -0,0 +1,79 @@
+package hooks
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testContext struct {
+	schema, entity string
+	calls          []string
+}
+
+func (c *testContext) HookTarget() (string, string) {
+	return c.schema, c.entity
+}
+
+func record(name string) Func[*testContext] {
+	return func(ctx *testContext) error {
+		ctx.calls = append(ctx.calls, name)
+		return nil
+	}
+}
+
+func TestRegistryPriority(t *testing.T) {
+	registry := NewRegistry[*testContext]()
+	registry.Register(BeforeRead, record("default"))
+	registry.Register(BeforeRead, record("late"), WithPriority(10))
+	registry.Register(BeforeRead, record("early"), WithPriority(-10))
+	registry.Register(BeforeRead, record("default2"))
+
+	ctx := &testContext{schema: "public", entity: "users"}
+	require.NoError(t, registry.Execute(BeforeRead, ctx))
+	assert.Equal(t, []string{"early", "default", "default2", "late"}, ctx.calls)
+}
+
+func TestRegistryForEntity(t *testing.T) {
+	registry := NewRegistry[*testContext]()
+	registry.Register(BeforeRead, record("all"))
+	registry.Register(BeforeRead, record("public"), ForEntity("public.*"))
+	registry.Register(BeforeRead, record("users"), ForEntity("Users"))
+	registry.Register(BeforeRead, record("any schema"), ForEntity("*.users"))
+	registry.Register(BeforeRead, record("audit"), ForEntity("audit_*", "hr.employees"))
+
+	tests := []struct {
+		schema, entity string
+		want           []string
+	}{
+		{"public", "users", []string{"all", "public", "users", "any schema"}},
+		{"hr", "users", []string{"all", "users", "any schema"}},
+		{"hr", "employees", []string{"all", "audit"}},
+		{"public", "audit_log", []string{"all", "public", "audit"}},
+		{"", "orders", []string{"all"}},
+	}
+	for _, tt := range tests {
+		ctx := &testContext{schema: tt.schema, entity: tt.entity}
+		require.NoError(t, registry.Execute(BeforeRead, ctx))
+		assert.Equal(t, tt.want, ctx.calls, "%s.%s", tt.schema, tt.entity)
+	}
+	assert.Equal(t, 5, registry.Count(BeforeRead))
+}
+
+func TestRegistryResponse(t *testing.T) {
+	registry := NewRegistry[*testContext]()
+	registry.Register(BeforeRead, func(ctx *testContext) error {
+		return Respond(http.StatusAccepted, map[string]string{"status": "queued"})
+	})
+	registry.Register(BeforeRead, record("skipped"), WithPriority(1))
+
+	ctx := &testContext{entity: "jobs"}
+	err := registry.Execute(BeforeRead, ctx)
+	var response *Response
+	require.True(t, errors.As(err, &response))
+	assert.Equal(t, http.StatusAccepted, response.Status)
+	assert.Empty(t, ctx.calls)
+}
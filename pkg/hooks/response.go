@@ -0,0 +1,53 @@
+package hooks
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ResponseWriter is the part of the response writer of a handler a Response is written to
+type ResponseWriter interface {
+	SetHeader(key, value string)
+	WriteHeader(statusCode int)
+	WriteJSON(data interface{}) error
+}
+
+// Response short-circuits the pipeline of a request: a hook returning it as error stops the
+// operation, and the handler answers with the response instead of an error.
+type Response struct {
+	Status int               // Status code, 200 when 0
+	Header map[string]string // Headers added to the response
+	Body   interface{}       // Body, encoded as JSON; nil sends no body
+}
+
+// Respond returns a Response to return from a hook, e.g.
+//
+//	return hooks.Respond(http.StatusOK, cachedRecords)
+func Respond(status int, body interface{}) *Response {
+	return &Response{Status: status, Body: body}
+}
+
+func (r *Response) Error() string {
+	return fmt.Sprintf("hook answered with status %d", r.status())
+}
+
+func (r *Response) status() int {
+	if r.Status == 0 {
+		return http.StatusOK
+	}
+	return r.Status
+}
+
+// Write writes the response to w
+func (r *Response) Write(w ResponseWriter) error {
+	for key, value := range r.Header {
+		w.SetHeader(key, value)
+	}
+	if r.Body == nil {
+		w.WriteHeader(r.status())
+		return nil
+	}
+	w.SetHeader("Content-Type", "application/json")
+	w.WriteHeader(r.status())
+	return w.WriteJSON(r.Body)
+}
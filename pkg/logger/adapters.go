@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/rs/zerolog"
+	"go.uber.org/zap"
+)
+
+// NewSlog adapts a log/slog logger
+func NewSlog(l *slog.Logger) Interface {
+	return slogLogger{logger: l}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (s slogLogger) Debug(msg string, fields ...Field) { s.log(slog.LevelDebug, msg, fields) }
+func (s slogLogger) Info(msg string, fields ...Field)  { s.log(slog.LevelInfo, msg, fields) }
+func (s slogLogger) Warn(msg string, fields ...Field)  { s.log(slog.LevelWarn, msg, fields) }
+func (s slogLogger) Error(msg string, fields ...Field) { s.log(slog.LevelError, msg, fields) }
+
+func (s slogLogger) log(level slog.Level, msg string, fields []Field) {
+	attrs := make([]slog.Attr, len(fields))
+	for i, field := range fields {
+		attrs[i] = slog.Any(field.Key, field.Value)
+	}
+	s.logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+// NewZap adapts a zap logger
+func NewZap(l *zap.Logger) Interface {
+	return zapLogger{logger: l}
+}
+
+type zapLogger struct {
+	logger *zap.Logger
+}
+
+func (z zapLogger) Debug(msg string, fields ...Field) { z.logger.Debug(msg, zapFields(fields)...) }
+func (z zapLogger) Info(msg string, fields ...Field)  { z.logger.Info(msg, zapFields(fields)...) }
+func (z zapLogger) Warn(msg string, fields ...Field)  { z.logger.Warn(msg, zapFields(fields)...) }
+func (z zapLogger) Error(msg string, fields ...Field) { z.logger.Error(msg, zapFields(fields)...) }
+
+func zapFields(fields []Field) []zap.Field {
+	zapFields := make([]zap.Field, len(fields))
+	for i, field := range fields {
+		zapFields[i] = zap.Any(field.Key, field.Value)
+	}
+	return zapFields
+}
+
+// NewZerolog adapts a zerolog logger
+func NewZerolog(l zerolog.Logger) Interface {
+	return zerologLogger{logger: l}
+}
+
+type zerologLogger struct {
+	logger zerolog.Logger
+}
+
+func (z zerologLogger) Debug(msg string, fields ...Field) { z.log(z.logger.Debug(), msg, fields) }
+func (z zerologLogger) Info(msg string, fields ...Field)  { z.log(z.logger.Info(), msg, fields) }
+func (z zerologLogger) Warn(msg string, fields ...Field)  { z.log(z.logger.Warn(), msg, fields) }
+func (z zerologLogger) Error(msg string, fields ...Field) { z.log(z.logger.Error(), msg, fields) }
+
+// log writes an event, which is nil when its level is disabled
+func (z zerologLogger) log(event *zerolog.Event, msg string, fields []Field) {
+	for _, field := range fields {
+		event = event.Interface(field.Key, field.Value)
+	}
+	event.Msg(msg)
+}
+
+// LogFunc writes a log line with its fields
+type LogFunc func(level Level, msg string, fields map[string]interface{})
+
+// NewFunc adapts a function, e.g. for a logging library without an adapter:
+//
+//	logger.NewFunc(func(level logger.Level, msg string, fields map[string]interface{}) {
+//		log.Printf("%s %s %v", level, msg, fields)
+//	})
+func NewFunc(fn LogFunc) Interface {
+	return funcLogger{fn: fn}
+}
+
+type funcLogger struct {
+	fn LogFunc
+}
+
+func (f funcLogger) Debug(msg string, fields ...Field) { f.log(LevelDebug, msg, fields) }
+func (f funcLogger) Info(msg string, fields ...Field)  { f.log(LevelInfo, msg, fields) }
+func (f funcLogger) Warn(msg string, fields ...Field)  { f.log(LevelWarn, msg, fields) }
+func (f funcLogger) Error(msg string, fields ...Field) { f.log(LevelError, msg, fields) }
+
+func (f funcLogger) log(level Level, msg string, fields []Field) {
+	values := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		values[field.Key] = field.Value
+	}
+	f.fn(level, msg, values)
+}
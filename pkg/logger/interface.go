@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+)
+
+// Field is a key/value pair of a structured log line
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F creates a log field
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Level is the severity of a log line
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the level name: debug, info, warn or error
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+// Interface is a structured logging backend. Adapters exist for slog (NewSlog), zap (NewZap),
+// zerolog (NewZerolog) and plain functions (NewFunc).
+type Interface interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+}
+
+// backend holds the Interface set with SetDefault
+var backend atomic.Value
+
+type backendHolder struct {
+	logger Interface
+}
+
+// SetDefault makes the package functions (Info, Error, ...) log through l. nil restores the
+// built-in zap logger.
+func SetDefault(l Interface) {
+	backend.Store(backendHolder{logger: l})
+}
+
+// Default returns the logger of the package functions
+func Default() Interface {
+	if holder, ok := backend.Load().(backendHolder); ok && holder.logger != nil {
+		return holder.logger
+	}
+	return builtin{}
+}
+
+// builtin logs through the zap Logger, or the standard log package before Init
+type builtin struct{}
+
+func (builtin) Debug(msg string, fields ...Field) { builtinLog(LevelDebug, msg, fields) }
+func (builtin) Info(msg string, fields ...Field)  { builtinLog(LevelInfo, msg, fields) }
+func (builtin) Warn(msg string, fields ...Field)  { builtinLog(LevelWarn, msg, fields) }
+func (builtin) Error(msg string, fields ...Field) { builtinLog(LevelError, msg, fields) }
+
+func builtinLog(level Level, msg string, fields []Field) {
+	if Logger == nil {
+		for _, field := range fields {
+			msg += fmt.Sprintf(" %s=%v", field.Key, field.Value)
+		}
+		log.Print(msg)
+		return
+	}
+	keysAndValues := make([]interface{}, 0, 2*len(fields)+2)
+	keysAndValues = append(keysAndValues, "process_id", os.Getpid())
+	for _, field := range fields {
+		keysAndValues = append(keysAndValues, field.Key, field.Value)
+	}
+	switch level {
+	case LevelDebug:
+		Logger.Debugw(msg, keysAndValues...)
+	case LevelInfo:
+		Logger.Infow(msg, keysAndValues...)
+	case LevelWarn:
+		Logger.Warnw(msg, keysAndValues...)
+	default:
+		Logger.Errorw(msg, keysAndValues...)
+	}
+}
+
+// With returns a logger adding fields to every line of l
+func With(l Interface, fields ...Field) Interface {
+	if len(fields) == 0 {
+		return l
+	}
+	if w, ok := l.(withFields); ok {
+		return withFields{logger: w.logger, fields: append(append([]Field(nil), w.fields...), fields...)}
+	}
+	return withFields{logger: l, fields: fields}
+}
+
+type withFields struct {
+	logger Interface
+	fields []Field
+}
+
+func (w withFields) Debug(msg string, fields ...Field) { w.logger.Debug(msg, w.with(fields)...) }
+func (w withFields) Info(msg string, fields ...Field)  { w.logger.Info(msg, w.with(fields)...) }
+func (w withFields) Warn(msg string, fields ...Field)  { w.logger.Warn(msg, w.with(fields)...) }
+func (w withFields) Error(msg string, fields ...Field) { w.logger.Error(msg, w.with(fields)...) }
+
+func (w withFields) with(fields []Field) []Field {
+	if len(fields) == 0 {
+		return w.fields
+	}
+	return append(append([]Field(nil), w.fields...), fields...)
+}
+
+type contextKey struct{}
+
+// WithContext returns a context carrying the logger of a request
+func WithContext(ctx context.Context, l Interface) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger of a request, or the default logger
+func FromContext(ctx context.Context) Interface {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(Interface); ok {
+			return l
+		}
+	}
+	return Default()
+}
+
+// DebugContext logs through the logger of the request context
+func DebugContext(ctx context.Context, template string, args ...interface{}) {
+	FromContext(ctx).Debug(fmt.Sprintf(template, args...))
+}
+
+// InfoContext logs through the logger of the request context
+func InfoContext(ctx context.Context, template string, args ...interface{}) {
+	FromContext(ctx).Info(fmt.Sprintf(template, args...))
+}
+
+// WarnContext logs through the logger of the request context
+func WarnContext(ctx context.Context, template string, args ...interface{}) {
+	FromContext(ctx).Warn(fmt.Sprintf(template, args...))
+}
+
+// ErrorContext logs through the logger of the request context
+func ErrorContext(ctx context.Context, template string, args ...interface{}) {
+	FromContext(ctx).Error(fmt.Sprintf(template, args...))
+}
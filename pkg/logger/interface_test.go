@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type recordedLine struct {
+	level  Level
+	msg    string
+	fields map[string]interface{}
+}
+
+func recorder(lines *[]recordedLine) Interface {
+	return NewFunc(func(level Level, msg string, fields map[string]interface{}) {
+		*lines = append(*lines, recordedLine{level, msg, fields})
+	})
+}
+
+func TestSetDefaultAndWith(t *testing.T) {
+	var lines []recordedLine
+	SetDefault(recorder(&lines))
+	defer SetDefault(nil)
+
+	Info("created %d records", 3)
+	ctx := WithContext(context.Background(), With(Default(), F("request_id", "abc")))
+	WarnContext(ctx, "slow query")
+	With(FromContext(ctx), F("table", "users")).Error("failed", F("code", 7))
+
+	assert.Equal(t, []recordedLine{
+		{LevelInfo, "created 3 records", map[string]interface{}{}},
+		{LevelWarn, "slow query", map[string]interface{}{"request_id": "abc"}},
+		{LevelError, "failed", map[string]interface{}{"request_id": "abc", "table": "users", "code": 7}},
+	}, lines)
+
+	SetDefault(nil)
+	assert.Equal(t, builtin{}, Default())
+	assert.Equal(t, builtin{}, FromContext(context.Background()))
+}
+
+func TestAdapters(t *testing.T) {
+	var buf bytes.Buffer
+	NewSlog(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))).Debug("hello", F("request_id", "abc"))
+	assert.Contains(t, buf.String(), "level=DEBUG msg=hello request_id=abc")
+
+	core, logs := observer.New(zap.DebugLevel)
+	NewZap(zap.New(core)).Warn("hello", F("request_id", "abc"))
+	entries := logs.All()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "hello", entries[0].Message)
+		assert.Equal(t, "abc", entries[0].ContextMap()["request_id"])
+	}
+
+	buf.Reset()
+	zl := NewZerolog(zerolog.New(&buf).Level(zerolog.InfoLevel))
+	zl.Debug("hidden")
+	zl.Error("hello", F("request_id", "abc"), F("rows", 3))
+	assert.JSONEq(t, `{"level":"error","message":"hello","request_id":"abc","rows":3}`, buf.String())
+
+	assert.Equal(t, "warn", LevelWarn.String())
+}
@@ -3,7 +3,6 @@ package logger
 import (
 	"fmt"
 	"log"
-	"os"
 	"runtime/debug"
 
 	"go.uber.org/zap"
@@ -41,35 +40,19 @@ func UpdateLogger(config *zap.Config) {
 }
 
 func Info(template string, args ...interface{}) {
-	if Logger == nil {
-		log.Printf(template, args...)
-		return
-	}
-	Logger.Infow(fmt.Sprintf(template, args...), "process_id", os.Getpid())
+	Default().Info(fmt.Sprintf(template, args...))
 }
 
 func Warn(template string, args ...interface{}) {
-	if Logger == nil {
-		log.Printf(template, args...)
-		return
-	}
-	Logger.Warnw(fmt.Sprintf(template, args...), "process_id", os.Getpid())
+	Default().Warn(fmt.Sprintf(template, args...))
 }
 
 func Error(template string, args ...interface{}) {
-	if Logger == nil {
-		log.Printf(template, args...)
-		return
-	}
-	Logger.Errorw(fmt.Sprintf(template, args...), "process_id", os.Getpid())
+	Default().Error(fmt.Sprintf(template, args...))
 }
 
 func Debug(template string, args ...interface{}) {
-	if Logger == nil {
-		log.Printf(template, args...)
-		return
-	}
-	Logger.Debugw(fmt.Sprintf(template, args...), "process_id", os.Getpid())
+	Default().Debug(fmt.Sprintf(template, args...))
 }
 
 // CatchPanic - Handle panic
@@ -0,0 +1,6 @@
+//go:build memdebug
+
+package membudget
+
+// Enabled reports whether memory tracking is compiled in (build tag "memdebug")
+const Enabled = true
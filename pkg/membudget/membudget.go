@@ -0,0 +1,314 @@
+// Package membudget tracks approximate allocations and result sizes per request,
+// to find the queries that cause GC pressure.
+//
+// Tracking is compiled in only with the "memdebug" build tag. In regular builds
+// Start returns a nil *Tracker and every Tracker method is a no-op.
+//
+// # Usage Example
+//
+//	// go build -tags memdebug ./...
+//	membudget.SetBudget(membudget.Budget{MaxAllocBytes: 64 << 20, MaxRows: 5000})
+//	router.Handle("/debug/membudget", membudget.Handler())
+//
+// Requests exceeding the budget are logged as warnings and kept in Reports().
+package membudget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime/metrics"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// maxReports is the number of over-budget reports kept in memory
+const maxReports = 100
+
+const allocsMetric = "/gc/heap/allocs:bytes"
+
+// Budget configures the limits a request may reach before it is reported.
+// Zero values disable the corresponding limit.
+type Budget struct {
+	// MaxAllocBytes limits heap bytes allocated while the request ran.
+	// Allocations are sampled process-wide, so concurrent requests inflate the value.
+	MaxAllocBytes uint64 `json:"max_alloc_bytes"`
+	// MaxRows limits the number of rows in the main result
+	MaxRows int `json:"max_rows"`
+	// MaxPreloadRows limits the number of rows loaded for a single preloaded relation
+	MaxPreloadRows int `json:"max_preload_rows"`
+}
+
+// Report describes the memory usage of one request
+type Report struct {
+	Name        string         `json:"name"`
+	Started     time.Time      `json:"started"`
+	Duration    time.Duration  `json:"duration"`
+	AllocBytes  uint64         `json:"alloc_bytes"`
+	Rows        int            `json:"rows"`
+	PreloadRows map[string]int `json:"preload_rows,omitempty"`
+	PeakSlice   int            `json:"peak_slice"`
+	Exceeded    []string       `json:"exceeded,omitempty"`
+}
+
+var (
+	mu      sync.RWMutex
+	budget  Budget
+	reports []Report
+)
+
+// SetBudget sets the budget applied to all requests
+func SetBudget(b Budget) {
+	mu.Lock()
+	defer mu.Unlock()
+	budget = b
+}
+
+// GetBudget returns the current budget
+func GetBudget() Budget {
+	mu.RLock()
+	defer mu.RUnlock()
+	return budget
+}
+
+// Reports returns the most recent over-budget reports, oldest first
+func Reports() []Report {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]Report(nil), reports...)
+}
+
+// ResetReports clears the stored reports
+func ResetReports() {
+	mu.Lock()
+	defer mu.Unlock()
+	reports = nil
+}
+
+// Handler serves the budget and the stored reports as JSON
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"enabled": Enabled,
+			"budget":  GetBudget(),
+			"reports": Reports(),
+		})
+	})
+}
+
+type contextKey struct{}
+
+// Tracker collects the memory usage of a single request
+type Tracker struct {
+	mu          sync.Mutex
+	name        string
+	started     time.Time
+	startAlloc  uint64
+	rows        int
+	preloadRows map[string]int
+	peakSlice   int
+}
+
+// Start begins tracking a request and stores the tracker in the returned context.
+// Without the "memdebug" build tag it returns ctx unchanged and a nil tracker.
+func Start(ctx context.Context, name string) (context.Context, *Tracker) {
+	if !Enabled {
+		return ctx, nil
+	}
+	t := newTracker(name)
+	return context.WithValue(ctx, contextKey{}, t), t
+}
+
+// FromContext returns the tracker of the current request, or nil
+func FromContext(ctx context.Context) *Tracker {
+	t, _ := ctx.Value(contextKey{}).(*Tracker)
+	return t
+}
+
+func newTracker(name string) *Tracker {
+	return &Tracker{
+		name:        name,
+		started:     time.Now(),
+		startAlloc:  heapAllocs(),
+		preloadRows: make(map[string]int),
+	}
+}
+
+// RecordRows records the number of rows in the main result
+func (t *Tracker) RecordRows(n int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rows += n
+	t.peakSlice = max(t.peakSlice, n)
+}
+
+// RecordPreload records the number of rows loaded for a relation
+func (t *Tracker) RecordPreload(relation string, n int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.preloadRows[relation] += n
+	t.peakSlice = max(t.peakSlice, n)
+}
+
+// RecordResult records the rows of a scanned result and the fan-out of its preloaded relations
+func (t *Tracker) RecordResult(records interface{}, relations ...string) {
+	if t == nil {
+		return
+	}
+	t.RecordRows(countRecords(records))
+	for _, relation := range relations {
+		t.RecordPreload(relation, CountRelationRows(records, relation))
+	}
+}
+
+// Finish completes tracking, checks the budget and returns the report.
+// Over-budget reports are logged as warnings and kept in Reports().
+func (t *Tracker) Finish() *Report {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	report := Report{
+		Name:        t.name,
+		Started:     t.started,
+		Duration:    time.Since(t.started),
+		AllocBytes:  heapAllocs() - t.startAlloc,
+		Rows:        t.rows,
+		PreloadRows: make(map[string]int, len(t.preloadRows)),
+		PeakSlice:   t.peakSlice,
+	}
+	for relation, n := range t.preloadRows {
+		report.PreloadRows[relation] = n
+	}
+	t.mu.Unlock()
+
+	report.Exceeded = GetBudget().check(report)
+	if len(report.Exceeded) == 0 {
+		logger.Debug("Memory usage for %s: %d bytes allocated, %d rows, peak slice %d",
+			report.Name, report.AllocBytes, report.Rows, report.PeakSlice)
+		return &report
+	}
+
+	logger.Warn("Memory budget exceeded for %s: %s (%d bytes allocated, %d rows, preloads %v)",
+		report.Name, strings.Join(report.Exceeded, ", "), report.AllocBytes, report.Rows, report.PreloadRows)
+
+	mu.Lock()
+	reports = append(reports, report)
+	if len(reports) > maxReports {
+		reports = reports[len(reports)-maxReports:]
+	}
+	mu.Unlock()
+
+	return &report
+}
+
+// check returns a description of every limit the report exceeds
+func (b Budget) check(report Report) []string {
+	var exceeded []string
+	if b.MaxAllocBytes > 0 && report.AllocBytes > b.MaxAllocBytes {
+		exceeded = append(exceeded, fmt.Sprintf("allocated %d > %d bytes", report.AllocBytes, b.MaxAllocBytes))
+	}
+	if b.MaxRows > 0 && report.Rows > b.MaxRows {
+		exceeded = append(exceeded, fmt.Sprintf("rows %d > %d", report.Rows, b.MaxRows))
+	}
+	if b.MaxPreloadRows > 0 {
+		relations := make([]string, 0, len(report.PreloadRows))
+		for relation := range report.PreloadRows {
+			relations = append(relations, relation)
+		}
+		sort.Strings(relations)
+		for _, relation := range relations {
+			if n := report.PreloadRows[relation]; n > b.MaxPreloadRows {
+				exceeded = append(exceeded, fmt.Sprintf("preload %s rows %d > %d", relation, n, b.MaxPreloadRows))
+			}
+		}
+	}
+	return exceeded
+}
+
+// heapAllocs returns the cumulative heap bytes allocated by the process
+func heapAllocs() uint64 {
+	sample := []metrics.Sample{{Name: allocsMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+// CountRelationRows counts the records loaded into a relation field (dot notation for
+// nested relations) across all records. Slices count their length, pointers count one.
+func CountRelationRows(records interface{}, relation string) int {
+	if relation == "" {
+		return 0
+	}
+	return countPath(reflect.ValueOf(records), strings.Split(relation, "."))
+}
+
+func countPath(val reflect.Value, path []string) int {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return 0
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array:
+		total := 0
+		for i := 0; i < val.Len(); i++ {
+			total += countPath(val.Index(i), path)
+		}
+		return total
+	case reflect.Struct:
+		if len(path) == 0 {
+			return 1
+		}
+		field := val.FieldByName(path[0])
+		if !field.IsValid() {
+			return 0
+		}
+		if len(path) == 1 {
+			return countValue(field)
+		}
+		return countPath(field, path[1:])
+	default:
+		return 0
+	}
+}
+
+// countRecords returns the number of records held by a value: the length of a slice,
+// one for a non-nil struct pointer or struct
+func countRecords(records interface{}) int {
+	return countValue(reflect.ValueOf(records))
+}
+
+func countValue(val reflect.Value) int {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return 0
+		}
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array:
+		return val.Len()
+	case reflect.Struct:
+		return 1
+	default:
+		return 0
+	}
+}
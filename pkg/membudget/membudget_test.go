@@ -0,0 +1,113 @@
+package membudget
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testTag struct {
+	Name string
+}
+
+type testItem struct {
+	ID   int64
+	Tags []testTag
+}
+
+type testOrder struct {
+	ID       int64
+	Items    []*testItem
+	Customer *testTag
+}
+
+func TestCountRelationRows(t *testing.T) {
+	orders := []*testOrder{
+		{Items: []*testItem{{Tags: []testTag{{}, {}}}, {}}, Customer: &testTag{}},
+		{Items: []*testItem{{Tags: []testTag{{}}}}},
+		nil,
+	}
+
+	assert.Equal(t, 3, CountRelationRows(orders, "Items"))
+	assert.Equal(t, 3, CountRelationRows(&orders, "Items.Tags"))
+	assert.Equal(t, 1, CountRelationRows(orders, "Customer"))
+	assert.Equal(t, 0, CountRelationRows(orders, "Missing"))
+	assert.Equal(t, 2, CountRelationRows(orders[0], "Items"))
+}
+
+func TestTrackerBudget(t *testing.T) {
+	SetBudget(Budget{MaxRows: 2, MaxPreloadRows: 2})
+	defer SetBudget(Budget{})
+	ResetReports()
+	defer ResetReports()
+
+	within := newTracker("within")
+	within.RecordRows(2)
+	report := within.Finish()
+	require.NotNil(t, report)
+	assert.Empty(t, report.Exceeded)
+	assert.Empty(t, Reports())
+
+	orders := []*testOrder{
+		{Items: []*testItem{{}, {}}},
+		{Items: []*testItem{{}}},
+	}
+	over := newTracker("over")
+	over.RecordResult(orders, "Items")
+	report = over.Finish()
+	require.NotNil(t, report)
+	assert.Equal(t, 2, report.Rows)
+	assert.Equal(t, map[string]int{"Items": 3}, report.PreloadRows)
+	assert.Equal(t, 3, report.PeakSlice)
+	assert.Equal(t, []string{"preload Items rows 3 > 2"}, report.Exceeded)
+
+	reports := Reports()
+	require.Len(t, reports, 1)
+	assert.Equal(t, "over", reports[0].Name)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/membudget", nil))
+	var body struct {
+		Enabled bool     `json:"enabled"`
+		Budget  Budget   `json:"budget"`
+		Reports []Report `json:"reports"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, Enabled, body.Enabled)
+	assert.Equal(t, 2, body.Budget.MaxRows)
+	assert.Len(t, body.Reports, 1)
+}
+
+func TestTrackerAllocations(t *testing.T) {
+	SetBudget(Budget{MaxAllocBytes: 1 << 10})
+	defer SetBudget(Budget{})
+	defer ResetReports()
+
+	tracker := newTracker("alloc")
+	buffers := make([][]byte, 0, 64)
+	for i := 0; i < 64; i++ {
+		buffers = append(buffers, make([]byte, 1<<12))
+	}
+	report := tracker.Finish()
+	require.Len(t, buffers, 64)
+	assert.GreaterOrEqual(t, report.AllocBytes, uint64(64<<12))
+	assert.Len(t, report.Exceeded, 1)
+}
+
+func TestStart(t *testing.T) {
+	ctx, tracker := Start(context.Background(), "request")
+	if !Enabled {
+		assert.Nil(t, tracker)
+		assert.Nil(t, FromContext(ctx))
+		// Nil trackers are no-ops
+		tracker.RecordRows(10)
+		assert.Nil(t, tracker.Finish())
+		return
+	}
+	require.NotNil(t, tracker)
+	assert.Same(t, tracker, FromContext(ctx))
+}
@@ -0,0 +1,519 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// ChangeKind is the kind of a schema change
+type ChangeKind string
+
+const (
+	CreateTable ChangeKind = "create_table"
+	AddColumn   ChangeKind = "add_column"
+	AlterColumn ChangeKind = "alter_column"
+	DropColumn  ChangeKind = "drop_column"
+	CreateIndex ChangeKind = "create_index"
+	DropIndex   ChangeKind = "drop_index"
+)
+
+// Change is a difference between the registered models and the database, with the statements
+// that apply and revert it
+type Change struct {
+	Kind  ChangeKind
+	Table string
+	// Name is the column or index of the change
+	Name string
+	// Detail describes an altered column, e.g. "type text -> bigint"
+	Detail string
+	Up     []string
+	Down   []string
+}
+
+// String describes the change, e.g. "add_column employees.title"
+func (c Change) String() string {
+	description := fmt.Sprintf("%s %s", c.Kind, c.Table)
+	if c.Name != "" {
+		description += "." + c.Name
+	}
+	if c.Detail != "" {
+		description += " (" + c.Detail + ")"
+	}
+	return description
+}
+
+// DiffOptions configures Diff
+type DiffOptions struct {
+	// Drop also drops the columns and indexes that the models don't declare. It is off by default,
+	// as tables may have columns and indexes of hand-written migrations.
+	Drop bool
+}
+
+// Diff compares the registered models with the tables of the database. It finds missing tables,
+// columns and indexes, and, unlike AutoMigrate, changed column types and nullability and, with
+// DiffOptions.Drop, columns and indexes the models no longer declare. SQLite can't alter
+// columns, so its types and nullability are not compared. Foreign keys are not generated.
+func Diff(ctx context.Context, db common.Database, registry common.ModelRegistry, options DiffOptions) ([]Change, error) {
+	dialect := common.DialectOf(db).Name
+	models := registry.GetAllModels()
+	seen := make(map[string]bool)
+	var changes []Change
+	for _, name := range sortedModels(models) {
+		expected, err := describeModel(models[name], name, dialect)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkIndexes(expected, dialect); err != nil {
+			return nil, err
+		}
+		key := expected.schema + "." + expected.name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		columns, indexes, err := common.IntrospectTable(ctx, db, expected.schema, expected.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to introspect %s: %w", expected.name, err)
+		}
+		r := renderer{dialect: dialect, table: expected}
+		if len(columns) == 0 {
+			changes = append(changes, r.createTable())
+			continue
+		}
+		changes = append(changes, r.columnChanges(columns, options)...)
+		changes = append(changes, r.indexChanges(indexes, options)...)
+	}
+	return changes, nil
+}
+
+// Generate writes the changes of Diff as a new migration named name into dir. It returns nil
+// without writing files when the database matches the models.
+func Generate(ctx context.Context, db common.Database, registry common.ModelRegistry, dir, name string, options DiffOptions) (*Migration, error) {
+	changes, err := Diff(ctx, db, registry, options)
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+	migration := NewMigration(time.Now(), name, changes)
+	if err := WriteMigration(dir, migration); err != nil {
+		return nil, err
+	}
+	return &migration, nil
+}
+
+var unsafeName = regexp.MustCompile(`[^a-z0-9]+`)
+
+// NewMigration builds a migration of changes, versioned by the UTC time as YYYYMMDDHHMMSS. The
+// down migration reverts the changes in reverse order.
+func NewMigration(at time.Time, name string, changes []Change) Migration {
+	migration := Migration{
+		Version: at.UTC().Format("20060102150405"),
+		Name:    strings.Trim(unsafeName.ReplaceAllString(strings.ToLower(name), "_"), "_"),
+	}
+	if migration.Name == "" {
+		migration.Name = "schema"
+	}
+
+	var up, down strings.Builder
+	header := "-- Generated from the registered models; review before applying\n"
+	up.WriteString(header)
+	down.WriteString(header)
+	for _, change := range changes {
+		fmt.Fprintf(&up, "\n-- %s\n", change)
+		for _, statement := range change.Up {
+			up.WriteString(statement + ";\n")
+		}
+	}
+	for i := len(changes) - 1; i >= 0; i-- {
+		fmt.Fprintf(&down, "\n-- revert %s\n", changes[i])
+		for j := len(changes[i].Down) - 1; j >= 0; j-- {
+			down.WriteString(changes[i].Down[j] + ";\n")
+		}
+	}
+	migration.Up, migration.Down = up.String(), down.String()
+	return migration
+}
+
+// WriteMigration writes the up and down files of a migration into dir; existing files are not
+// overwritten
+func WriteMigration(dir string, migration Migration) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, file := range []struct{ direction, content string }{{"up", migration.Up}, {"down", migration.Down}} {
+		path := filepath.Join(dir, fmt.Sprintf("%s_%s.%s.sql", migration.Version, migration.Name, file.direction))
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return err
+		}
+		if _, err := f.WriteString(file.content); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderer writes the statements of the changes of a table in a dialect
+type renderer struct {
+	dialect string
+	table   *table
+}
+
+func (r renderer) quote(name string) string {
+	if r.dialect == "mysql" {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return common.QuoteIdent(name)
+}
+
+// tableName is the qualified table; SQLite has no schemas
+func (r renderer) tableName() string {
+	if r.table.schema != "" && r.dialect != "sqlite" {
+		return r.quote(r.table.schema) + "." + r.quote(r.table.name)
+	}
+	return r.quote(r.table.name)
+}
+
+// columnDefinition renders a column of the model for CREATE TABLE or ADD COLUMN
+func (r renderer) columnDefinition(column common.Column, create bool) string {
+	definition := r.quote(column.Name) + " " + column.Type
+	generated := column.Name == r.table.autoIncrement
+	switch {
+	case generated && r.dialect == "sqlite" && create:
+		// Only an inline integer primary key is generated by SQLite
+		definition += " PRIMARY KEY AUTOINCREMENT"
+	case generated && r.dialect == "mysql":
+		definition += " AUTO_INCREMENT"
+	}
+	// Also for primary keys, as SQLite allows NULL in keys other than integers
+	if !column.IsNullable {
+		definition += " NOT NULL"
+	}
+	if column.DefaultValue != "" {
+		definition += " DEFAULT " + column.DefaultValue
+	}
+	if column.IsUnique && create {
+		definition += " UNIQUE"
+	}
+	return definition
+}
+
+// existingDefinition renders a column as the database reports it, to revert dropping it
+func (r renderer) existingDefinition(column common.Column) string {
+	definition := r.quote(column.Name) + " " + existingType(column)
+	if !column.IsNullable && !column.IsPrimary {
+		definition += " NOT NULL"
+	}
+	if column.DefaultValue != "" {
+		definition += " DEFAULT " + column.DefaultValue
+	}
+	return definition
+}
+
+func (r renderer) createTable() Change {
+	definitions := make([]string, 0, len(r.table.columns)+1)
+	var primary []string
+	for _, column := range r.table.columns {
+		definitions = append(definitions, "  "+r.columnDefinition(column, true))
+		if column.IsPrimary {
+			primary = append(primary, r.quote(column.Name))
+		}
+	}
+	if len(primary) > 0 && !(r.dialect == "sqlite" && r.table.autoIncrement != "") {
+		definitions = append(definitions, "  PRIMARY KEY ("+strings.Join(primary, ", ")+")")
+	}
+
+	change := Change{
+		Kind:  CreateTable,
+		Table: r.table.name,
+		Up:    []string{fmt.Sprintf("CREATE TABLE %s (\n%s\n)", r.tableName(), strings.Join(definitions, ",\n"))},
+		Down:  []string{"DROP TABLE " + r.tableName()},
+	}
+	for _, index := range r.table.indexes {
+		change.Up = append(change.Up, r.createIndex(index))
+	}
+	return change
+}
+
+func (r renderer) columnChanges(existing []common.Column, options DiffOptions) []Change {
+	byName := make(map[string]common.Column, len(existing))
+	for _, column := range existing {
+		byName[strings.ToLower(column.Name)] = column
+	}
+	declared := make(map[string]bool, len(r.table.columns))
+
+	var changes []Change
+	for _, column := range r.table.columns {
+		declared[strings.ToLower(column.Name)] = true
+		current, ok := byName[strings.ToLower(column.Name)]
+		if !ok {
+			changes = append(changes, Change{
+				Kind:  AddColumn,
+				Table: r.table.name,
+				Name:  column.Name,
+				Up:    []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", r.tableName(), r.columnDefinition(column, false))},
+				Down:  []string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", r.tableName(), r.quote(column.Name))},
+			})
+			continue
+		}
+		if change, ok := r.alterColumn(column, current); ok {
+			changes = append(changes, change)
+		}
+	}
+
+	if options.Drop {
+		for _, column := range existing {
+			if declared[strings.ToLower(column.Name)] {
+				continue
+			}
+			changes = append(changes, Change{
+				Kind:  DropColumn,
+				Table: r.table.name,
+				Name:  column.Name,
+				Up:    []string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", r.tableName(), r.quote(column.Name))},
+				Down:  []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", r.tableName(), r.existingDefinition(column))},
+			})
+		}
+	}
+	return changes
+}
+
+// alterColumn changes the type and nullability of a column on PostgreSQL and MySQL
+func (r renderer) alterColumn(column, current common.Column) (Change, bool) {
+	if r.dialect == "sqlite" || column.IsPrimary {
+		return Change{}, false
+	}
+	typeChanged := !sameType(r.dialect, column.Type, existingType(current))
+	nullChanged := column.IsNullable != current.IsNullable
+	if !typeChanged && !nullChanged {
+		return Change{}, false
+	}
+
+	var details []string
+	if typeChanged {
+		details = append(details, fmt.Sprintf("type %s -> %s", existingType(current), column.Type))
+	}
+	if nullChanged {
+		details = append(details, fmt.Sprintf("nullable %t -> %t", current.IsNullable, column.IsNullable))
+	}
+	change := Change{Kind: AlterColumn, Table: r.table.name, Name: column.Name, Detail: strings.Join(details, ", ")}
+
+	if r.dialect == "mysql" {
+		modify := func(columnType string, nullable bool) string {
+			null := " NULL"
+			if !nullable {
+				null = " NOT NULL"
+			}
+			return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s%s", r.tableName(), r.quote(column.Name), columnType, null)
+		}
+		change.Up = []string{modify(column.Type, column.IsNullable)}
+		change.Down = []string{modify(existingType(current), current.IsNullable)}
+		return change, true
+	}
+
+	alter := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s ", r.tableName(), r.quote(column.Name))
+	nullability := func(nullable bool) string {
+		if nullable {
+			return alter + "DROP NOT NULL"
+		}
+		return alter + "SET NOT NULL"
+	}
+	if typeChanged {
+		change.Up = append(change.Up, fmt.Sprintf("%sTYPE %s USING %s::%s", alter, column.Type, r.quote(column.Name), column.Type))
+		change.Down = append(change.Down, fmt.Sprintf("%sTYPE %s USING %s::%s", alter, existingType(current), r.quote(column.Name), existingType(current)))
+	}
+	if nullChanged {
+		change.Up = append(change.Up, nullability(column.IsNullable))
+		change.Down = append(change.Down, nullability(current.IsNullable))
+	}
+	return change, true
+}
+
+func (r renderer) indexChanges(existing []common.IndexMetadata, options DiffOptions) []Change {
+	expected := append([]common.IndexSpec(nil), r.table.indexes...)
+	for _, column := range r.table.columns {
+		if column.IsUnique && !column.IsPrimary {
+			expected = append(expected, common.IndexSpec{
+				Name:    fmt.Sprintf("idx_%s_%s", r.table.name, column.Name),
+				Columns: []string{column.Name},
+				Unique:  true,
+			})
+		}
+	}
+
+	matched := make([]bool, len(existing))
+	var changes []Change
+	for _, index := range expected {
+		// By name first, as the columns of expression indexes aren't introspected
+		found := -1
+		for i, current := range existing {
+			if !matched[i] && strings.EqualFold(current.Name, index.Name) {
+				found = i
+				break
+			}
+		}
+		for i, current := range existing {
+			if found < 0 && !matched[i] && !current.Primary && current.Unique == index.Unique && sameColumns(current.Columns, index.Columns) {
+				found = i
+			}
+		}
+		if found >= 0 {
+			matched[found] = true
+			continue
+		}
+		changes = append(changes, Change{
+			Kind:  CreateIndex,
+			Table: r.table.name,
+			Name:  index.Name,
+			Up:    []string{r.createIndex(index)},
+			Down:  []string{r.dropIndex(index.Name, index.Unique)},
+		})
+	}
+
+	if options.Drop {
+		for i, current := range existing {
+			// SQLite indexes of UNIQUE constraints can't be dropped on their own
+			if matched[i] || current.Primary || strings.HasPrefix(current.Name, "sqlite_autoindex_") {
+				continue
+			}
+			changes = append(changes, Change{
+				Kind:  DropIndex,
+				Table: r.table.name,
+				Name:  current.Name,
+				Up:    []string{r.dropIndex(current.Name, current.Unique)},
+				Down:  []string{r.createIndex(common.IndexSpec{Name: current.Name, Columns: current.Columns, Unique: current.Unique})},
+			})
+		}
+	}
+	return changes
+}
+
+// plainIdentifier matches index columns that are quoted; others are expressions, e.g. lower(email)
+var plainIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (r renderer) createIndex(index common.IndexSpec) string {
+	columns := make([]string, len(index.Columns))
+	for i, column := range index.Columns {
+		if plainIdentifier.MatchString(column) {
+			column = r.quote(column)
+		}
+		columns[i] = column
+	}
+	statement := "CREATE INDEX "
+	if index.Unique {
+		statement = "CREATE UNIQUE INDEX "
+	}
+	statement += r.quote(index.Name) + " ON " + r.tableName() + " (" + strings.Join(columns, ", ") + ")"
+	if index.Where != "" {
+		statement += " WHERE " + index.Where
+	}
+	return statement
+}
+
+func (r renderer) dropIndex(name string, unique bool) string {
+	switch {
+	case r.dialect == "mysql":
+		return "DROP INDEX " + r.quote(name) + " ON " + r.tableName()
+	case r.dialect == "postgres" && unique && strings.HasSuffix(name, "_key"):
+		// The index of a UNIQUE constraint
+		return "ALTER TABLE " + r.tableName() + " DROP CONSTRAINT " + r.quote(name)
+	case r.table.schema != "" && r.dialect != "sqlite":
+		return "DROP INDEX " + r.quote(r.table.schema) + "." + r.quote(name)
+	default:
+		return "DROP INDEX " + r.quote(name)
+	}
+}
+
+// existingType renders the type of an introspected column with its length or precision
+func existingType(column common.Column) string {
+	columnType := column.Type
+	if strings.Contains(columnType, "(") {
+		return columnType
+	}
+	lower := strings.ToLower(columnType)
+	switch {
+	case column.MaxLength > 0 && strings.Contains(lower, "char"):
+		return fmt.Sprintf("%s(%d)", columnType, column.MaxLength)
+	case column.Precision > 0 && (strings.Contains(lower, "numeric") || strings.Contains(lower, "decimal")):
+		return fmt.Sprintf("%s(%d,%d)", columnType, column.Precision, column.Scale)
+	default:
+		return columnType
+	}
+}
+
+// sameType compares column types by family, so varchar(100) matches character varying(100)
+// and bigserial matches bigint. Types of unknown families always match.
+func sameType(dialect, a, b string) bool {
+	familyA, familyB := typeFamily(dialect, a), typeFamily(dialect, b)
+	if familyA == "" || familyB == "" {
+		return true
+	}
+	if familyA != familyB {
+		return false
+	}
+	sizeA, sizeB := typeSizePattern.FindString(a), typeSizePattern.FindString(b)
+	return familyA != "text" || sizeA == "" || sizeB == "" || strings.ReplaceAll(sizeA, " ", "") == strings.ReplaceAll(sizeB, " ", "")
+}
+
+var typeSizePattern = regexp.MustCompile(`\([\d\s,]+\)`)
+
+// typeFamily groups the type names of the dialects; "" is an unknown type
+func typeFamily(dialect, columnType string) string {
+	name := strings.TrimSpace(strings.ToLower(typeSizePattern.ReplaceAllString(columnType, "")))
+	switch {
+	case name == "tinyint" && dialect == "mysql", strings.HasPrefix(name, "bool"):
+		return "boolean"
+	case name == "smallint", name == "int2", name == "smallserial":
+		return "smallint"
+	case name == "integer", name == "int", name == "int4", name == "serial", name == "mediumint":
+		return "integer"
+	case name == "bigint", name == "int8", name == "bigserial":
+		return "bigint"
+	case strings.Contains(name, "char"), name == "text", strings.HasSuffix(name, "text"), name == "string":
+		return "text"
+	case name == "real", name == "float4", name == "float":
+		return "real"
+	case strings.HasPrefix(name, "double"), name == "float8":
+		return "double"
+	case name == "numeric", name == "decimal":
+		return "numeric"
+	case strings.HasPrefix(name, "timestamp"), name == "datetime", name == "timestamptz":
+		return "timestamp"
+	case name == "date":
+		return "date"
+	case strings.HasPrefix(name, "time"):
+		return "time"
+	case name == "bytea", strings.HasSuffix(name, "blob"), strings.HasSuffix(name, "binary"):
+		return "bytes"
+	case name == "json", name == "jsonb":
+		return name
+	case name == "uuid":
+		return "uuid"
+	default:
+		return ""
+	}
+}
+
+// sameColumns compares the columns of indexes in order
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
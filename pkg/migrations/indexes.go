@@ -0,0 +1,71 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// EnsureIndexes creates the declared indexes that are missing on the tables of the registered
+// models and returns their changes. It completes AutoMigrate, which doesn't create the indexes of
+// resolvespec tags and common.IndexProvider; it never drops or alters indexes.
+func EnsureIndexes(ctx context.Context, db common.Database, registry common.ModelRegistry) ([]Change, error) {
+	dialect := common.DialectOf(db).Name
+	models := registry.GetAllModels()
+	seen := make(map[string]bool)
+	var created []Change
+	for _, name := range sortedModels(models) {
+		expected, err := describeModel(models[name], name, dialect)
+		if err != nil {
+			return created, err
+		}
+		if err := checkIndexes(expected, dialect); err != nil {
+			return created, err
+		}
+		key := expected.schema + "." + expected.name
+		if seen[key] || (len(expected.indexes) == 0 && !hasUniqueColumn(expected)) {
+			continue
+		}
+		seen[key] = true
+
+		columns, indexes, err := common.IntrospectTable(ctx, db, expected.schema, expected.name)
+		if err != nil {
+			return created, fmt.Errorf("failed to introspect %s: %w", expected.name, err)
+		}
+		if len(columns) == 0 {
+			return created, fmt.Errorf("table %s of model %s doesn't exist", expected.name, name)
+		}
+		r := renderer{dialect: dialect, table: expected}
+		for _, change := range r.indexChanges(indexes, DiffOptions{}) {
+			for _, statement := range change.Up {
+				if _, err := db.Exec(ctx, statement); err != nil {
+					return created, fmt.Errorf("failed to create index %s: %w", change.Name, err)
+				}
+			}
+			logger.Info("Created index %s on %s", change.Name, change.Table)
+			created = append(created, change)
+		}
+	}
+	return created, nil
+}
+
+// checkIndexes rejects indexes the dialect can't create
+func checkIndexes(t *table, dialect string) error {
+	for _, index := range t.indexes {
+		if index.Where != "" && dialect == "mysql" {
+			return fmt.Errorf("index %s of %s is partial, which MySQL doesn't support", index.Name, t.name)
+		}
+	}
+	return nil
+}
+
+func hasUniqueColumn(t *table) bool {
+	for _, column := range t.columns {
+		if column.IsUnique && !column.IsPrimary {
+			return true
+		}
+	}
+	return false
+}
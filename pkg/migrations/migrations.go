@@ -0,0 +1,321 @@
+// Package migrations versions the schema of the registered models with SQL migration files.
+//
+// Diff compares the models of a registry with the database and Generate writes the changes as
+// a pair of files, "<version>_<name>.up.sql" and "<version>_<name>.down.sql", to review and
+// commit. A Migrator applies the files of a directory or embed.FS in version order and records
+// them in the schema_migrations table:
+//
+//	migration, err := migrations.Generate(ctx, db, registry, "migrations", "add_titles", migrations.DiffOptions{})
+//
+//	migrator := migrations.NewMigrator(db, os.DirFS("migrations"))
+//	applied, err := migrator.Up(ctx)          // apply pending migrations
+//	reverted, err := migrator.Down(ctx, 1)    // revert the last migration
+//	status, err := migrator.Status(ctx)       // applied and pending migrations
+//
+// Every migration runs in a transaction with its record; MySQL commits DDL statements
+// implicitly, so a failing MySQL migration can be applied in part.
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// DefaultTable records the applied migrations
+const DefaultTable = "schema_migrations"
+
+// Migration is a versioned schema change with the SQL that applies and reverts it
+type Migration struct {
+	Version string
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration with whether and when it was applied
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	// Missing is true for an applied migration whose files are gone
+	Missing bool
+}
+
+// Migrator applies the migration files of a file system
+type Migrator struct {
+	db     common.Database
+	source fs.FS
+	table  string
+}
+
+// Option configures a Migrator
+type Option func(*Migrator)
+
+// WithTable records the applied migrations in another table than schema_migrations
+func WithTable(name string) Option {
+	return func(m *Migrator) {
+		m.table = name
+	}
+}
+
+// NewMigrator creates a migrator for the migration files at the root of source, e.g.
+// os.DirFS("migrations") or an embed.FS narrowed with fs.Sub
+func NewMigrator(db common.Database, source fs.FS, options ...Option) *Migrator {
+	m := &Migrator{db: db, source: source, table: DefaultTable}
+	for _, option := range options {
+		option(m)
+	}
+	return m
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Load reads the migration files at the root of source in version order. Other files are
+// ignored; a migration without a down file can't be reverted. A missing directory has no
+// migrations.
+func Load(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[string]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, name, direction := match[1], match[2], match[3]
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("migration %s is named both %s and %s", version, migration.Name, name)
+		}
+		data, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if direction == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" {
+			return nil, fmt.Errorf("migration %s_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return versionLess(migrations[i].Version, migrations[j].Version) })
+	return migrations, nil
+}
+
+// versionLess orders numeric versions of any length
+func versionLess(a, b string) bool {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// Status lists the migration files and the applied migrations in version order
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	migrations, err := Load(m.source)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]Status, 0, len(migrations))
+	for _, migration := range migrations {
+		entry := Status{Migration: migration}
+		if record, ok := applied[migration.Version]; ok {
+			entry.Applied, entry.AppliedAt = true, record.AppliedAt
+			delete(applied, migration.Version)
+		}
+		status = append(status, entry)
+	}
+	for _, record := range applied {
+		status = append(status, record)
+	}
+	sort.SliceStable(status, func(i, j int) bool { return versionLess(status[i].Version, status[j].Version) })
+	return status, nil
+}
+
+// Up applies the pending migrations in version order and returns them. It stops at the first
+// failing migration, which is rolled back.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var applied []Migration
+	for _, entry := range status {
+		if entry.Applied {
+			continue
+		}
+		if err := m.run(ctx, entry.Migration, entry.Up, true); err != nil {
+			return applied, err
+		}
+		applied = append(applied, entry.Migration)
+	}
+	return applied, nil
+}
+
+// Down reverts the last steps applied migrations, the latest first, and returns them
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var reverted []Migration
+	for i := len(status) - 1; i >= 0 && len(reverted) < steps; i-- {
+		entry := status[i]
+		if !entry.Applied {
+			continue
+		}
+		if entry.Missing {
+			return reverted, fmt.Errorf("migration %s was applied, but its files are missing", entry.Version)
+		}
+		if strings.TrimSpace(entry.Down) == "" {
+			return reverted, fmt.Errorf("migration %s_%s has no down file", entry.Version, entry.Name)
+		}
+		if err := m.run(ctx, entry.Migration, entry.Down, false); err != nil {
+			return reverted, err
+		}
+		reverted = append(reverted, entry.Migration)
+	}
+	return reverted, nil
+}
+
+// run executes the statements of a migration and records it in one transaction
+func (m *Migrator) run(ctx context.Context, migration Migration, script string, up bool) error {
+	direction := "up"
+	if !up {
+		direction = "down"
+	}
+	err := m.db.RunInTransaction(ctx, func(tx common.Database) error {
+		for _, statement := range SplitStatements(script) {
+			if _, err := tx.Exec(ctx, statement); err != nil {
+				return fmt.Errorf("%w\n%s", err, statement)
+			}
+		}
+		if up {
+			_, err := tx.Exec(ctx, fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (?, ?, ?)", m.table),
+				migration.Version, migration.Name, time.Now().UTC().Format(time.RFC3339))
+			return err
+		}
+		_, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = ?", m.table), migration.Version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("migration %s_%s %s failed: %w", migration.Version, migration.Name, direction, err)
+	}
+	logger.Info("Migrated %s: %s_%s", direction, migration.Version, migration.Name)
+	return nil
+}
+
+// applied creates the migrations table if needed and reads its records
+func (m *Migrator) applied(ctx context.Context) (map[string]Status, error) {
+	_, err := m.db.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		version varchar(64) NOT NULL PRIMARY KEY,
+		name varchar(255) NOT NULL,
+		applied_at varchar(64) NOT NULL
+	)`, m.table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", m.table, err)
+	}
+
+	rows := []map[string]interface{}{}
+	if err := m.db.Query(ctx, &rows, fmt.Sprintf("SELECT version, name, applied_at FROM %s", m.table)); err != nil {
+		return nil, err
+	}
+	applied := make(map[string]Status, len(rows))
+	for _, row := range rows {
+		record := Status{Applied: true, Missing: true}
+		record.Version = fmt.Sprint(row["version"])
+		record.Name = fmt.Sprint(row["name"])
+		record.AppliedAt, _ = time.Parse(time.RFC3339, fmt.Sprint(row["applied_at"]))
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// SplitStatements splits a script into its statements at semicolons outside of quotes,
+// comments and PostgreSQL dollar quotes, and drops the comments
+func SplitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end
+				current.WriteByte('\n')
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 3
+				current.WriteByte(' ')
+			}
+		case c == '\'' || c == '"' || c == '`':
+			stop := len(script)
+			if end := strings.IndexByte(script[i+1:], c); end >= 0 {
+				stop = i + end + 2
+			}
+			current.WriteString(script[i:stop])
+			i = stop - 1
+		case c == '$' && dollarQuote.MatchString(script[i:]):
+			tag := dollarQuote.FindString(script[i:])
+			stop := len(script)
+			if end := strings.Index(script[i+len(tag):], tag); end >= 0 {
+				stop = i + len(tag) + end + len(tag)
+			}
+			current.WriteString(script[i:stop])
+			i = stop - 1
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
+}
+
+// dollarQuote matches the opening tag of a PostgreSQL dollar quote, e.g. $$ or $body$
+var dollarQuote = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
@@ -0,0 +1,234 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type audit struct {
+	CreatedAt time.Time
+	CreatedBy *string `gorm:"size:50"`
+}
+
+type account struct {
+	ID      int64             `gorm:"primaryKey"`
+	Email   string            `gorm:"size:200;not null;uniqueIndex"`
+	OrgID   int32             `gorm:"index:idx_accounts_org_status"`
+	Status  string            `gorm:"index:idx_accounts_org_status;default:'active'"`
+	Balance float64           `gorm:"precision:12;scale:2"`
+	Tags    []string          `gorm:"type:text[]"`
+	Profile common.SqlJSONB   `json:"profile"`
+	Ignored string            `gorm:"-"`
+	Owner   *account          `gorm:"foreignKey:OrgID"`
+	Items   []accountItem     `json:"items"`
+	Meta    map[string]string `json:"meta"`
+	audit
+}
+
+func (account) TableName() string { return "billing.accounts" }
+
+type accountItem struct {
+	ID string
+}
+
+type bunNote struct {
+	ID      int64    `bun:"id,pk,autoincrement"`
+	Title   string   `bun:"title,notnull,unique"`
+	Kind    string   `bun:"kind,unique:note_kind_title"`
+	Body    string   `bun:",type:varchar(500)"`
+	Account *account `bun:"rel:belongs-to,join:account_id=id"`
+}
+
+func TestDescribeModel(t *testing.T) {
+	table, err := describeModel(account{}, "accounts", "postgres")
+	require.NoError(t, err)
+	assert.Equal(t, "billing", table.schema)
+	assert.Equal(t, "accounts", table.name)
+	assert.Equal(t, "id", table.autoIncrement)
+
+	types := make(map[string]string)
+	nullable := make(map[string]bool)
+	for _, column := range table.columns {
+		types[column.Name] = column.Type
+		nullable[column.Name] = column.IsNullable
+	}
+	assert.Equal(t, map[string]string{
+		"id":         "bigserial",
+		"email":      "varchar(200)",
+		"org_id":     "integer",
+		"status":     "text",
+		"balance":    "numeric(12,2)",
+		"tags":       "text[]",
+		"profile":    "jsonb",
+		"meta":       "jsonb",
+		"created_at": "timestamptz",
+		"created_by": "varchar(50)",
+	}, types, "relations and ignored fields are no columns, embedded structs are")
+	assert.False(t, nullable["email"])
+	assert.True(t, nullable["status"])
+	assert.Equal(t, []common.IndexSpec{
+		{Name: "idx_accounts_email", Columns: []string{"email"}, Unique: true},
+		{Name: "idx_accounts_org_status", Columns: []string{"org_id", "status"}},
+	}, table.indexes)
+
+	table, err = describeModel(&bunNote{}, "notes", "mysql")
+	require.NoError(t, err)
+	require.Len(t, table.columns, 4)
+	assert.Equal(t, "id", table.autoIncrement)
+	assert.Equal(t, common.Column{Name: "title", Type: "varchar(255)", IsUnique: true}, table.columns[1])
+	assert.Equal(t, "body", table.columns[3].Name)
+	assert.Equal(t, "varchar(500)", table.columns[3].Type)
+	assert.Equal(t, []common.IndexSpec{{Name: "note_kind_title", Columns: []string{"kind"}, Unique: true}}, table.indexes)
+
+	_, err = describeModel("accounts", "accounts", "sqlite")
+	assert.Error(t, err)
+}
+
+func TestRenderer(t *testing.T) {
+	table, err := describeModel(account{}, "accounts", "postgres")
+	require.NoError(t, err)
+	r := renderer{dialect: "postgres", table: table}
+
+	create := r.createTable()
+	require.Len(t, create.Up, 3)
+	assert.Contains(t, create.Up[0], `CREATE TABLE "billing"."accounts" (`)
+	assert.Contains(t, create.Up[0], `"email" varchar(200) NOT NULL,`)
+	assert.Contains(t, create.Up[0], `"status" text DEFAULT 'active',`)
+	assert.Contains(t, create.Up[0], `PRIMARY KEY ("id")`)
+	assert.Equal(t, `CREATE UNIQUE INDEX "idx_accounts_email" ON "billing"."accounts" ("email")`, create.Up[1])
+	assert.Equal(t, []string{`DROP TABLE "billing"."accounts"`}, create.Down)
+
+	existing := []common.Column{
+		{Name: "id", Type: "bigint"},
+		{Name: "email", Type: "character varying", MaxLength: 100, IsNullable: true},
+		{Name: "org_id", Type: "integer", IsNullable: true},
+		{Name: "status", Type: "text", IsNullable: true},
+		{Name: "balance", Type: "numeric", Precision: 12, Scale: 2, IsNullable: true},
+		{Name: "tags", Type: "ARRAY", IsNullable: true},
+		{Name: "profile", Type: "jsonb", IsNullable: true},
+		{Name: "meta", Type: "jsonb", IsNullable: true},
+		{Name: "created_at", Type: "timestamp with time zone", IsNullable: true},
+		{Name: "legacy", Type: "text", IsNullable: true},
+	}
+	changes := r.columnChanges(existing, DiffOptions{})
+	require.Len(t, changes, 2)
+	assert.Equal(t, "alter_column accounts.email (type character varying(100) -> varchar(200), nullable true -> false)", changes[0].String())
+	assert.Equal(t, []string{
+		`ALTER TABLE "billing"."accounts" ALTER COLUMN "email" TYPE varchar(200) USING "email"::varchar(200)`,
+		`ALTER TABLE "billing"."accounts" ALTER COLUMN "email" SET NOT NULL`,
+	}, changes[0].Up)
+	assert.Equal(t, AddColumn, changes[1].Kind)
+	assert.Equal(t, []string{`ALTER TABLE "billing"."accounts" ADD COLUMN "created_by" varchar(50)`}, changes[1].Up)
+
+	changes = r.columnChanges(existing, DiffOptions{Drop: true})
+	require.Len(t, changes, 3)
+	assert.Equal(t, []string{`ALTER TABLE "billing"."accounts" ADD COLUMN "legacy" text`}, changes[2].Down)
+
+	indexes := []common.IndexMetadata{
+		{Name: "accounts_pkey", Columns: []string{"id"}, Unique: true, Primary: true},
+		{Name: "accounts_email_key", Columns: []string{"email"}, Unique: true},
+		{Name: "idx_accounts_legacy", Columns: []string{"legacy"}},
+	}
+	changes = r.indexChanges(indexes, DiffOptions{Drop: true})
+	require.Len(t, changes, 2)
+	assert.Equal(t, CreateIndex, changes[0].Kind)
+	assert.Equal(t, []string{`CREATE INDEX "idx_accounts_org_status" ON "billing"."accounts" ("org_id", "status")`}, changes[0].Up)
+	assert.Equal(t, []string{`DROP INDEX "billing"."idx_accounts_org_status"`}, changes[0].Down)
+	assert.Equal(t, []string{`DROP INDEX "billing"."idx_accounts_legacy"`}, changes[1].Up)
+}
+
+type ticket struct {
+	ID        int64      `gorm:"primaryKey"`
+	QueueID   int64      `resolvespec:"index:idx_tickets_queue_state"`
+	State     string     `resolvespec:"index:idx_tickets_queue_state;index_where:deleted_at IS NULL"`
+	Reference string     `resolvespec:"unique_index"`
+	Email     string     `gorm:"index:,where:email <> ''"`
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+func (ticket) Indexes() []common.IndexSpec {
+	return []common.IndexSpec{
+		{Columns: []string{"lower(email)"}, Unique: true},
+		{Name: "idx_tickets_open", Columns: []string{"queue_id", "id"}, Where: "state <> 'closed'"},
+	}
+}
+
+func TestDeclaredIndexes(t *testing.T) {
+	table, err := describeModel(ticket{}, "tickets", "postgres")
+	require.NoError(t, err)
+	assert.Equal(t, []common.IndexSpec{
+		{Name: "idx_tickets_queue_state", Columns: []string{"queue_id", "state"}, Where: "deleted_at IS NULL"},
+		{Name: "idx_tickets_reference", Columns: []string{"reference"}, Unique: true},
+		{Name: "idx_tickets_email", Columns: []string{"email"}, Where: "email <> ''"},
+		{Name: "idx_tickets_lower_email", Columns: []string{"lower(email)"}, Unique: true},
+		{Name: "idx_tickets_open", Columns: []string{"queue_id", "id"}, Where: "state <> 'closed'"},
+	}, table.indexes)
+
+	r := renderer{dialect: "postgres", table: table}
+	changes := r.indexChanges([]common.IndexMetadata{
+		{Name: "idx_tickets_lower_email", Unique: true},
+		{Name: "tickets_reference_key", Columns: []string{"reference"}, Unique: true},
+	}, DiffOptions{})
+	require.Len(t, changes, 3, "indexes match by name or columns")
+	assert.Equal(t, []string{`CREATE INDEX "idx_tickets_queue_state" ON "tickets" ("queue_id", "state") WHERE deleted_at IS NULL`}, changes[0].Up)
+	assert.Equal(t, []string{`CREATE INDEX "idx_tickets_email" ON "tickets" ("email") WHERE email <> ''`}, changes[1].Up)
+	assert.Equal(t, []string{`DROP INDEX "idx_tickets_open"`}, changes[2].Down)
+
+	r = renderer{dialect: "mysql", table: table}
+	assert.Equal(t, "CREATE UNIQUE INDEX `idx_tickets_lower_email` ON `tickets` (lower(email))", r.createIndex(table.indexes[3]))
+	assert.Error(t, checkIndexes(table, "mysql"), "MySQL has no partial indexes")
+	assert.NoError(t, checkIndexes(table, "sqlite"))
+}
+
+func TestNewMigration(t *testing.T) {
+	changes := []Change{
+		{Kind: CreateTable, Table: "a", Up: []string{"CREATE TABLE a (id int)", "CREATE INDEX i ON a (id)"}, Down: []string{"DROP TABLE a"}},
+		{Kind: AddColumn, Table: "b", Name: "c", Up: []string{"ALTER TABLE b ADD COLUMN c text"}, Down: []string{"ALTER TABLE b DROP COLUMN c"}},
+	}
+	migration := NewMigration(time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC), "Add C!", changes)
+	assert.Equal(t, "20260304050607", migration.Version)
+	assert.Equal(t, "add_c", migration.Name)
+	assert.Equal(t, []string{"CREATE TABLE a (id int)", "CREATE INDEX i ON a (id)", "ALTER TABLE b ADD COLUMN c text"}, SplitStatements(migration.Up))
+	assert.Equal(t, []string{"ALTER TABLE b DROP COLUMN c", "DROP TABLE a"}, SplitStatements(migration.Down))
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(fstest.MapFS{
+		"10_third.up.sql":     {Data: []byte("SELECT 3")},
+		"2_second.up.sql":     {Data: []byte("SELECT 2")},
+		"2_second.down.sql":   {Data: []byte("SELECT -2")},
+		"001_first.up.sql":    {Data: []byte("SELECT 1")},
+		"README.md":           {Data: []byte("notes")},
+		"seeds/3_seed.up.sql": {Data: []byte("SELECT 0")},
+	})
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+	assert.Equal(t, []string{"001", "2", "10"}, []string{migrations[0].Version, migrations[1].Version, migrations[2].Version})
+	assert.Equal(t, Migration{Version: "2", Name: "second", Up: "SELECT 2", Down: "SELECT -2"}, migrations[1])
+
+	_, err = Load(fstest.MapFS{"1_a.down.sql": {Data: []byte("SELECT 1")}})
+	assert.Error(t, err, "a down file needs an up file")
+	_, err = Load(fstest.MapFS{"1_a.up.sql": {Data: []byte("SELECT 1")}, "1_b.up.sql": {Data: []byte("SELECT 1")}})
+	assert.Error(t, err, "versions are unique")
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- comment; not a statement
+CREATE TABLE a (name text DEFAULT 'x;y'); /* block; comment */
+INSERT INTO "we;ird" VALUES ('it''s');
+CREATE FUNCTION f() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql;
+SELECT $1;`
+	assert.Equal(t, []string{
+		"CREATE TABLE a (name text DEFAULT 'x;y')",
+		`INSERT INTO "we;ird" VALUES ('it''s')`,
+		"CREATE FUNCTION f() RETURNS int AS $body$ SELECT 1; $body$ LANGUAGE sql",
+		"SELECT $1",
+	}, SplitStatements(script))
+	assert.Empty(t, SplitStatements("-- only a comment\n"))
+}
@@ -0,0 +1,406 @@
+package migrations
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/schema"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// table is the schema a model expects
+type table struct {
+	schema  string
+	name    string
+	columns []common.Column
+	// autoIncrement is the integer primary key the database generates, if any
+	autoIncrement string
+	indexes       []common.IndexSpec
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	naming      = schema.NamingStrategy{}
+)
+
+// describeModel reads the table of a model from its struct tags: GORM (column, type, primaryKey,
+// autoIncrement, not null, unique, index, uniqueIndex, default, size, precision, scale), Bun
+// (name, pk, autoincrement, notnull, unique, type, default) and resolvespec (index,
+// unique_index, index_where), and the indexes of common.IndexProvider. Columns without a name
+// in the tags are named like GORM and Bun do, e.g. DepartmentID is department_id.
+func describeModel(model interface{}, name, dialect string) (*table, error) {
+	modelType := reflect.TypeOf(model)
+	for modelType != nil && modelType.Kind() == reflect.Pointer {
+		modelType = modelType.Elem()
+	}
+	if modelType == nil || modelType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("model %s is not a struct", name)
+	}
+
+	tableName := name
+	if provider, ok := model.(common.TableNameProvider); ok && provider.TableName() != "" {
+		tableName = provider.TableName()
+	}
+	result := &table{}
+	if i := strings.LastIndex(tableName, "."); i >= 0 {
+		result.schema, result.name = tableName[:i], tableName[i+1:]
+	} else {
+		result.name = tableName
+	}
+
+	named := make(map[string]*common.IndexSpec)
+	var order []string
+	addIndex := func(indexName, column string, unique bool, where string) {
+		if indexName == "" {
+			indexName = common.IndexSpec{Columns: []string{column}}.IndexName(result.name)
+		}
+		index, ok := named[indexName]
+		if !ok {
+			index = &common.IndexSpec{Name: indexName}
+			named[indexName] = index
+			order = append(order, indexName)
+		}
+		index.Columns = append(index.Columns, column)
+		index.Unique = index.Unique || unique
+		if where != "" {
+			index.Where = where
+		}
+	}
+
+	var autoIncrement []string
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			gormTag, bunTag := field.Tag.Get("gorm"), field.Tag.Get("bun")
+			gormOptions := tagOptions(gormTag, ";")
+			// Embedded structs of unexported types still promote their exported fields
+			if (!field.IsExported() && !field.Anonymous) || gormTag == "-" || bunTag == "-" || isRelation(gormTag, bunTag) {
+				continue
+			}
+			if _, skip := gormOptions["-"]; skip {
+				continue
+			}
+
+			fieldType := field.Type
+			for fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if _, embedded := gormOptions["embedded"]; field.Anonymous || embedded {
+				if fieldType.Kind() == reflect.Struct && !isScalarStruct(fieldType) {
+					walk(fieldType, prefix+gormOptions["embeddedprefix"])
+					continue
+				}
+			}
+			if !field.IsExported() || isModelType(fieldType) {
+				// Unexported fields and relations without tags, e.g. a belongs-to struct or a has-many slice
+				continue
+			}
+
+			bunOptions := bunTagOptions(bunTag)
+			column := common.Column{Name: prefix + columnName(field, gormOptions, bunTag)}
+			column.IsPrimary = hasOption(gormOptions, "primarykey", "primary_key") || hasOption(bunOptions, "pk")
+			column.IsUnique = hasOption(gormOptions, "unique") || bunOptions["unique"] == "true"
+			column.IsNullable = !column.IsPrimary && !hasOption(gormOptions, "not null") && !hasOption(bunOptions, "notnull")
+			common.ApplyColumnTags(&column, field)
+
+			explicitType := gormOptions["type"]
+			if explicitType == "" {
+				explicitType = bunOptions["type"]
+			}
+			generated := column.IsPrimary && isInteger(fieldType) && gormOptions["autoincrement"] != "false"
+			if (hasOption(gormOptions, "autoincrement") && gormOptions["autoincrement"] != "false") || hasOption(bunOptions, "autoincrement", "identity") {
+				generated = true
+			}
+			if generated {
+				autoIncrement = append(autoIncrement, column.Name)
+			}
+			column.Type = columnType(dialect, fieldType, explicitType, column, generated)
+			result.columns = append(result.columns, column)
+
+			// GORM: index:name,unique,where:predicate and uniqueIndex:name
+			for _, key := range []string{"index", "uniqueindex"} {
+				value, ok := gormOptions[key]
+				if !ok {
+					continue
+				}
+				indexName, rest, _ := strings.Cut(value, ",")
+				unique, where := key == "uniqueindex", ""
+				for _, option := range strings.Split(rest, ",") {
+					optionKey, optionValue, _ := strings.Cut(strings.TrimSpace(option), ":")
+					switch strings.ToLower(optionKey) {
+					case "unique":
+						unique = true
+					case "class":
+						unique = unique || strings.EqualFold(optionValue, "unique")
+					case "where":
+						where = optionValue
+					}
+				}
+				addIndex(indexName, column.Name, unique, where)
+			}
+			if group := bunOptions["unique"]; group != "" && group != "true" {
+				addIndex(group, column.Name, true, "")
+			}
+			// resolvespec: index[:name], unique_index[:name] and index_where:predicate
+			resolvespecOptions := tagOptions(field.Tag.Get("resolvespec"), ";")
+			for _, key := range []string{"index", "unique_index"} {
+				if indexName, ok := resolvespecOptions[key]; ok {
+					addIndex(indexName, column.Name, key == "unique_index", resolvespecOptions["index_where"])
+				}
+			}
+		}
+	}
+	walk(modelType, "")
+
+	if len(result.columns) == 0 {
+		return nil, fmt.Errorf("model %s has no columns", name)
+	}
+	if len(autoIncrement) == 1 {
+		result.autoIncrement = autoIncrement[0]
+	}
+	for _, indexName := range order {
+		result.indexes = append(result.indexes, *named[indexName])
+	}
+	for _, index := range common.ModelIndexes(model) {
+		if len(index.Columns) == 0 {
+			return nil, fmt.Errorf("an index of model %s has no columns", name)
+		}
+		index.Name = index.IndexName(result.name)
+		result.indexes = append(result.indexes, index)
+	}
+	return result, nil
+}
+
+// columnName is the name of the column of a field: column: of GORM, the name of Bun or the
+// naming convention of both
+func columnName(field reflect.StructField, gormOptions map[string]string, bunTag string) string {
+	if name := gormOptions["column"]; name != "" {
+		return name
+	}
+	if name, _, _ := strings.Cut(bunTag, ","); name != "" && !strings.Contains(name, ":") && !bunFlags[name] {
+		return name
+	}
+	return naming.ColumnName("", field.Name)
+}
+
+// isRelation reports relation fields by their tags
+func isRelation(gormTag, bunTag string) bool {
+	for _, marker := range []string{"foreignKey:", "references:", "many2many:", "polymorphic:", "constraint:"} {
+		if strings.Contains(gormTag, marker) {
+			return true
+		}
+	}
+	for _, marker := range []string{"rel:", "m2m:", "join:"} {
+		if strings.Contains(bunTag, marker) {
+			return true
+		}
+	}
+	return strings.Contains(bunTag, "scanonly")
+}
+
+// isModelType reports structs and slices of structs that are stored in tables of their own
+func isModelType(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+	}
+	return t.Kind() == reflect.Struct && !isScalarStruct(t)
+}
+
+// isScalarStruct reports structs stored in a single column, e.g. time.Time and sql.NullString
+func isScalarStruct(t reflect.Type) bool {
+	return t == timeType || t.Implements(valuerType) || reflect.PointerTo(t).Implements(scannerType)
+}
+
+func isInteger(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// gormDataTypes are the portable type names of GORM, which GORM maps per dialect
+var gormDataTypes = map[string]bool{"bool": true, "int": true, "uint": true, "float": true, "string": true, "time": true, "bytes": true}
+
+// columnType returns the SQL type of a column. An explicit type of the tags wins, except for the
+// portable names of GORM; generated keys get the serial types of the dialect.
+func columnType(dialect string, t reflect.Type, explicit string, column common.Column, generated bool) string {
+	if explicit != "" && !gormDataTypes[strings.ToLower(explicit)] {
+		return explicit
+	}
+	if generated {
+		switch dialect {
+		case "postgres":
+			if t.Kind() == reflect.Int32 || t.Kind() == reflect.Uint32 || t.Kind() == reflect.Int16 {
+				return "serial"
+			}
+			return "bigserial"
+		case "sqlite":
+			return "integer"
+		}
+	}
+
+	switch t {
+	case reflect.TypeOf(common.SqlJSONB{}):
+		return pick(dialect, "jsonb", "json", "text")
+	case reflect.TypeOf(common.SqlUUID{}):
+		return pick(dialect, "uuid", "char(36)", "text")
+	case reflect.TypeOf(common.SqlDate{}):
+		return "date"
+	case reflect.TypeOf(common.SqlTime{}):
+		return "time"
+	case reflect.TypeOf(common.SqlFloat64{}), reflect.TypeOf(sql.NullFloat64{}):
+		return pick(dialect, "double precision", "double", "real")
+	case reflect.TypeOf(sql.NullString{}):
+		return stringType(dialect, column)
+	case reflect.TypeOf(sql.NullInt64{}):
+		return pick(dialect, "bigint", "bigint", "integer")
+	case reflect.TypeOf(sql.NullInt32{}), reflect.TypeOf(sql.NullInt16{}):
+		return "integer"
+	case reflect.TypeOf(sql.NullBool{}):
+		return "boolean"
+	case timeType, reflect.TypeOf(common.SqlTimeStamp{}), reflect.TypeOf(sql.NullTime{}):
+		return pick(dialect, "timestamptz", "datetime(3)", "datetime")
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return pick(dialect, "smallint", "smallint", "integer")
+	case reflect.Int32, reflect.Uint16:
+		return "integer"
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return pick(dialect, "bigint", "bigint", "integer")
+	case reflect.Float32:
+		return "real"
+	case reflect.Float64:
+		if column.Precision > 0 {
+			return fmt.Sprintf("numeric(%d,%d)", column.Precision, column.Scale)
+		}
+		return pick(dialect, "double precision", "double", "real")
+	case reflect.String:
+		return stringType(dialect, column)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return pick(dialect, "bytea", "longblob", "blob")
+		}
+		return pick(dialect, "jsonb", "json", "text")
+	case reflect.Map, reflect.Struct:
+		return pick(dialect, "jsonb", "json", "text")
+	default:
+		return "text"
+	}
+}
+
+// stringType is varchar with a size, else text; MySQL can only index sized strings
+func stringType(dialect string, column common.Column) string {
+	switch {
+	case column.MaxLength > 0:
+		return "varchar(" + strconv.Itoa(column.MaxLength) + ")"
+	case dialect == "mysql":
+		return "varchar(255)"
+	default:
+		return "text"
+	}
+}
+
+// pick selects the type of PostgreSQL, MySQL or SQLite; other dialects get the PostgreSQL type
+func pick(dialect, postgres, mysql, sqlite string) string {
+	switch dialect {
+	case "mysql":
+		return mysql
+	case "sqlite":
+		return sqlite
+	default:
+		return postgres
+	}
+}
+
+// tagOptions parses "key:value;flag" tags into lower-cased keys; flags map to ""
+func tagOptions(tag, separator string) map[string]string {
+	options := make(map[string]string)
+	for _, option := range strings.Split(tag, separator) {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), ":")
+		if key != "" {
+			options[strings.ToLower(key)] = strings.TrimSpace(value)
+		}
+	}
+	return options
+}
+
+// bunFlags are the options of bun tags without a value
+var bunFlags = map[string]bool{"pk": true, "notnull": true, "unique": true, "autoincrement": true, "nullzero": true, "identity": true, "soft_delete": true, "scanonly": true}
+
+// bunTagOptions parses a bun tag; the first element is the column name unless it is an option.
+// A bare unique flag is "true", unique:group names a composite unique index.
+func bunTagOptions(tag string) map[string]string {
+	options := make(map[string]string)
+	for i, option := range splitOutsideParens(tag, ',') {
+		key, value, hasValue := strings.Cut(strings.TrimSpace(option), ":")
+		if i == 0 && !hasValue && !bunFlags[key] {
+			continue
+		}
+		if key == "unique" && !hasValue {
+			value = "true"
+		}
+		if key != "" {
+			options[key] = value
+		}
+	}
+	return options
+}
+
+// splitOutsideParens splits s at separators that are not inside parentheses, e.g. of
+// type:numeric(10,2)
+func splitOutsideParens(s string, separator rune) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case separator:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func hasOption(options map[string]string, keys ...string) bool {
+	for _, key := range keys {
+		if _, ok := options[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedModels returns the names of the registered models in order
+func sortedModels(models map[string]interface{}) []string {
+	names := make([]string, 0, len(models))
+	for name := range models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,158 @@
+package modelregistry
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+func init() {
+	reflection.SetModelLookup(GetModelByName)
+}
+
+// ModelDescriptor is the metadata of a registered model: its table, columns, relations and
+// validation rules. RegisterModel builds it once from reflection.DescribeType, so request
+// handling looks the model up instead of reflecting over it. It is shared and must not be
+// modified.
+type ModelDescriptor struct {
+	EntityInfo
+	Info        *reflection.ModelInfo // Reflected metadata, the same as reflection.Describe of the model
+	Type        reflect.Type          // Struct type of the model
+	Columns     []ColumnDescriptor    // Column fields in order, including promoted fields of embedded structs
+	PrimaryKeys []string              // Primary key columns, bun tags before gorm tags
+	Relations   []RelationDescriptor  // Relation fields in order
+	Validators  map[string][]string   // Rules of the validate tags by column, e.g. "required", "oneof=a b"
+
+	columns   map[string]int // Index in Columns by column name
+	relations map[string]int // Index in Relations by field and JSON name
+}
+
+// ColumnDescriptor is a column field of a model
+type ColumnDescriptor struct {
+	Field      string       // Go name of the field
+	Name       string       // Column name: bun tag, gorm tag, json tag or lowercase field name
+	JSONName   string       // Name of the json tag, "" without one
+	Type       reflect.Type // Go type of the field
+	Index      []int        // Index sequence for reflect.Value.FieldByIndex
+	PrimaryKey bool         // Tagged as primary key, see ModelDescriptor.PrimaryKeys
+	ReadOnly   bool         // Bun scanonly or gorm read-only, not written by inserts and updates
+	Unique     bool         // Tagged unique
+}
+
+// RelationDescriptor is a relation field of a model, tagged with bun rel: or m2m:, or gorm
+// foreignKey, references or many2many
+type RelationDescriptor struct {
+	Field    string       // Go name of the field
+	JSONName string       // Name of the json tag, "" without one
+	Kind     string       // "belongsTo", "hasMany", "hasOne" or "many2many"
+	Type     reflect.Type // Struct type of the related model
+	Model    interface{}  // Zero value of the related model
+	Index    []int        // Index sequence for reflect.Value.FieldByIndex
+}
+
+// Column returns the column of a model by column name, or nil
+func (d *ModelDescriptor) Column(name string) *ColumnDescriptor {
+	if i, ok := d.columns[name]; ok {
+		return &d.Columns[i]
+	}
+	return nil
+}
+
+// Relation returns the relation of a model by field or JSON name, or nil
+func (d *ModelDescriptor) Relation(name string) *RelationDescriptor {
+	if i, ok := d.relations[name]; ok {
+		return &d.Relations[i]
+	}
+	return nil
+}
+
+// ColumnNames returns the names of the columns of a model in order
+func (d *ModelDescriptor) ColumnNames() []string {
+	names := make([]string, len(d.Columns))
+	for i, column := range d.Columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// GetDescriptor returns the descriptor of the model registered under name, e.g. "core.users"
+func (r *DefaultModelRegistry) GetDescriptor(name string) (*ModelDescriptor, error) {
+	descriptor, exists := r.load().descriptors[name]
+	if !exists {
+		return nil, fmt.Errorf("model %s not found", name)
+	}
+	return descriptor, nil
+}
+
+// GetDescriptorByType returns the descriptor of a registered struct type, which may be a
+// pointer, slice or array of it. A type registered under several names has the descriptor of
+// its first registration.
+func (r *DefaultModelRegistry) GetDescriptorByType(modelType reflect.Type) (*ModelDescriptor, error) {
+	for modelType != nil && (modelType.Kind() == reflect.Ptr || modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Array) {
+		modelType = modelType.Elem()
+	}
+	descriptor, exists := r.load().types[modelType]
+	if !exists {
+		return nil, fmt.Errorf("model of type %v not found", modelType)
+	}
+	return descriptor, nil
+}
+
+// newModelDescriptor describes the model of a registered entity
+func newModelDescriptor(entity EntityInfo) *ModelDescriptor {
+	info := reflection.Describe(entity.Model)
+	d := &ModelDescriptor{
+		EntityInfo:  entity,
+		Info:        info,
+		Type:        info.Type,
+		PrimaryKeys: info.PrimaryKeys,
+		Validators:  make(map[string][]string),
+		columns:     make(map[string]int),
+		relations:   make(map[string]int),
+	}
+	for _, field := range info.Fields {
+		switch {
+		case !field.Exported || field.Ignored:
+		case field.Relation != "":
+			if field.RelatedType != nil {
+				d.Relations = append(d.Relations, RelationDescriptor{
+					Field:    field.Name,
+					JSONName: field.JSONName,
+					Kind:     field.Relation,
+					Type:     field.RelatedType,
+					Model:    reflect.Zero(field.RelatedType).Interface(),
+					Index:    field.Index,
+				})
+			}
+		default:
+			d.Columns = append(d.Columns, ColumnDescriptor{
+				Field:      field.Name,
+				Name:       field.Column,
+				JSONName:   field.JSONName,
+				Type:       field.Type,
+				Index:      field.Index,
+				PrimaryKey: field.PrimaryKey,
+				ReadOnly:   !field.Writable,
+				Unique:     field.Unique,
+			})
+			if len(field.Rules) > 0 {
+				d.Validators[field.Column] = append(d.Validators[field.Column], field.Rules...)
+			}
+		}
+	}
+
+	for i, column := range d.Columns {
+		if _, exists := d.columns[column.Name]; !exists {
+			d.columns[column.Name] = i
+		}
+	}
+	for i, relation := range d.Relations {
+		for _, name := range []string{relation.Field, relation.JSONName} {
+			if _, exists := d.relations[name]; name != "" && !exists {
+				d.relations[name] = i
+			}
+		}
+	}
+	return d
+}
@@ -0,0 +1,82 @@
+package modelregistry
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testAudit struct {
+	CreatedAt string `bun:"created_at" json:"created_at"`
+	RowNumber int64  `bun:",scanonly" json:"_rownumber"`
+}
+
+type testInvoice struct {
+	ID       int64             `bun:"id,pk" json:"id"`
+	Number   string            `bun:"number" json:"number" validate:"required,max=20"`
+	Status   string            `gorm:"column:status" json:"status" validate:"oneof=open paid"`
+	Total    float64           `json:"total"`
+	Customer *testUser         `bun:"rel:belongs-to,join:customer_id=id" json:"customer"`
+	Lines    []testInvoiceLine `gorm:"foreignKey:InvoiceID" json:"lines"`
+	Tags     []testNote        `bun:"m2m:invoice_tags,join:Invoice=Note" json:"tags"`
+	Ignored  string            `bun:"-"`
+	internal string
+	testAudit
+}
+
+func (testInvoice) TableName() string { return "billing.invoices" }
+
+type testInvoiceLine struct {
+	ID        int64 `gorm:"column:id;primaryKey" json:"id"`
+	InvoiceID int64 `gorm:"column:invoice_id" json:"invoice_id"`
+}
+
+func TestModelDescriptor(t *testing.T) {
+	r := NewModelRegistry()
+	require.NoError(t, r.RegisterModel("billing.invoices", &testInvoice{}))
+	require.NoError(t, r.RegisterModel("invoice_lines", testInvoiceLine{}))
+
+	d, err := r.GetDescriptor("billing.invoices")
+	require.NoError(t, err)
+	assert.Equal(t, "billing", d.Schema)
+	assert.Equal(t, "invoices", d.Table)
+	assert.Equal(t, reflect.TypeOf(testInvoice{}), d.Type)
+	assert.Same(t, reflection.Describe(testInvoice{}), d.Info, "built from the reflected metadata")
+	assert.Equal(t, []string{"id", "number", "status", "total", "created_at", "_rownumber"}, d.ColumnNames())
+	assert.Equal(t, []string{"id"}, d.PrimaryKeys)
+	assert.Equal(t, map[string][]string{"number": {"required", "max=20"}, "status": {"oneof=open paid"}}, d.Validators)
+
+	rowNumber := d.Column("_rownumber")
+	require.NotNil(t, rowNumber)
+	assert.True(t, rowNumber.ReadOnly)
+	assert.Equal(t, []int{9, 1}, rowNumber.Index, "promoted from the embedded struct")
+	assert.False(t, d.Column("number").ReadOnly)
+	assert.Nil(t, d.Column("customer"), "relations aren't columns")
+
+	kinds := map[string]string{}
+	for _, relation := range d.Relations {
+		kinds[relation.Field] = relation.Kind
+	}
+	assert.Equal(t, map[string]string{"Customer": "belongsTo", "Lines": "hasMany", "Tags": "many2many"}, kinds)
+	lines := d.Relation("lines")
+	require.NotNil(t, lines)
+	assert.Same(t, lines, d.Relation("Lines"), "by field and JSON name")
+	assert.IsType(t, testInvoiceLine{}, lines.Model)
+	assert.Nil(t, d.Relation("total"))
+
+	byType, err := r.GetDescriptorByType(reflect.TypeOf([]*testInvoice{}))
+	require.NoError(t, err)
+	assert.Same(t, d, byType)
+
+	line, err := r.GetDescriptorByType(lines.Type)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id"}, line.PrimaryKeys, "gorm primary keys")
+
+	_, err = r.GetDescriptor("invoices")
+	assert.Error(t, err)
+	_, err = r.GetDescriptorByType(reflect.TypeOf(testNote{}))
+	assert.Error(t, err)
+}
@@ -3,19 +3,61 @@ package modelregistry
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
+// EntityInfo describes a registered model
+type EntityInfo struct {
+	Name   string      // Name the model was registered under
+	Schema string      // Schema part of the name or model, empty if none
+	Entity string      // Entity part of the name
+	Table  string      // Table name, from TableName() or the entity name
+	Model  interface{} // The registered model (non-pointer struct)
+}
+
+// entityKey identifies a model by schema and entity
+type entityKey struct {
+	schema string
+	entity string
+}
+
+// registrySnapshot is an immutable view of the registry contents
+// Readers load the current snapshot without locking; writers build a new one
+type registrySnapshot struct {
+	models   map[string]interface{}
+	entities map[entityKey]interface{}
+	tables   map[string]interface{}
+	infos    []EntityInfo // Sorted by name
+
+	descriptors map[string]*ModelDescriptor       // By registered name
+	types       map[reflect.Type]*ModelDescriptor // By struct type, first registration wins
+
+	version uint64 // Number of registrations, see Version
+}
+
+var emptySnapshot = &registrySnapshot{
+	models:   map[string]interface{}{},
+	entities: map[entityKey]interface{}{},
+	tables:   map[string]interface{}{},
+
+	descriptors: map[string]*ModelDescriptor{},
+	types:       map[reflect.Type]*ModelDescriptor{},
+}
+
 // DefaultModelRegistry implements ModelRegistry interface
+// Lookups are lock-free reads of a copy-on-write snapshot, since the registry is
+// consulted on every request but only written during startup.
 type DefaultModelRegistry struct {
-	models map[string]interface{}
-	mutex  sync.RWMutex
+	snapshot  atomic.Pointer[registrySnapshot]
+	mutex     sync.Mutex // Serializes writers
+	pluralize Pluralizer // Derives entity names from type names, see SetPluralizer
 }
 
 // Global default registry instance
-var defaultRegistry = &DefaultModelRegistry{
-	models: make(map[string]interface{}),
-}
+var defaultRegistry = NewModelRegistry()
 
 // Global list of registries (searched in order)
 var registries = []*DefaultModelRegistry{defaultRegistry}
@@ -23,9 +65,17 @@ var registriesMutex sync.RWMutex
 
 // NewModelRegistry creates a new model registry
 func NewModelRegistry() *DefaultModelRegistry {
-	return &DefaultModelRegistry{
-		models: make(map[string]interface{}),
+	r := &DefaultModelRegistry{}
+	r.snapshot.Store(emptySnapshot)
+	return r
+}
+
+// load returns the current snapshot
+func (r *DefaultModelRegistry) load() *registrySnapshot {
+	if snap := r.snapshot.Load(); snap != nil {
+		return snap
 	}
+	return emptySnapshot
 }
 
 func SetDefaultRegistry(registry *DefaultModelRegistry) {
@@ -56,18 +106,31 @@ func AddRegistry(registry *DefaultModelRegistry) {
 	registries = append(registries, registry)
 }
 
+// RegisterModel registers a model under name and builds its ModelDescriptor, see GetDescriptor
 func (r *DefaultModelRegistry) RegisterModel(name string, model interface{}) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.models[name]; exists {
+	current := r.load()
+	if _, exists := current.models[name]; exists {
 		return fmt.Errorf("model %s already registered", name)
 	}
 
+	model, err := validateModel(model)
+	if err != nil {
+		return err
+	}
+
+	r.snapshot.Store(current.with(newEntityInfo(name, model)))
+	return nil
+}
+
+// validateModel checks that model is a struct and returns it as a non-pointer struct
+func validateModel(model interface{}) (interface{}, error) {
 	// Validate that model is a non-pointer struct
 	modelType := reflect.TypeOf(model)
 	if modelType == nil {
-		return fmt.Errorf("model cannot be nil")
+		return nil, fmt.Errorf("model cannot be nil")
 	}
 
 	originalType := modelType
@@ -79,7 +142,7 @@ func (r *DefaultModelRegistry) RegisterModel(name string, model interface{}) err
 
 	// Validate that the underlying type is a struct
 	if modelType.Kind() != reflect.Struct {
-		return fmt.Errorf("model must be a struct or pointer to struct, got %s", originalType.String())
+		return nil, fmt.Errorf("model must be a struct or pointer to struct, got %s", originalType.String())
 	}
 
 	// If a pointer/slice/array was passed, unwrap to the base struct
@@ -91,18 +154,19 @@ func (r *DefaultModelRegistry) RegisterModel(name string, model interface{}) err
 	// Additional check: ensure model is not a pointer
 	finalType := reflect.TypeOf(model)
 	if finalType.Kind() == reflect.Ptr {
-		return fmt.Errorf("model must be a non-pointer struct, got pointer to %s. Use MyModel{} instead of &MyModel{}", finalType.Elem().Name())
+		return nil, fmt.Errorf("model must be a non-pointer struct, got pointer to %s. Use MyModel{} instead of &MyModel{}", finalType.Elem().Name())
 	}
+	return model, nil
+}
 
-	r.models[name] = model
-	return nil
+// Version returns the number of models registered so far. It changes with every registration,
+// so what is derived from the registered models can be cached until it does.
+func (r *DefaultModelRegistry) Version() uint64 {
+	return r.load().version
 }
 
 func (r *DefaultModelRegistry) GetModel(name string) (interface{}, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
-
-	model, exists := r.models[name]
+	model, exists := r.load().models[name]
 	if !exists {
 		return nil, fmt.Errorf("model %s not found", name)
 	}
@@ -111,25 +175,173 @@ func (r *DefaultModelRegistry) GetModel(name string) (interface{}, error) {
 }
 
 func (r *DefaultModelRegistry) GetAllModels() map[string]interface{} {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
+	models := r.load().models
 
-	result := make(map[string]interface{})
-	for k, v := range r.models {
+	result := make(map[string]interface{}, len(models))
+	for k, v := range models {
 		result[k] = v
 	}
 	return result
 }
 
 func (r *DefaultModelRegistry) GetModelByEntity(schema, entity string) (interface{}, error) {
+	snap := r.load()
+
 	// Try full name first
-	fullName := fmt.Sprintf("%s.%s", schema, entity)
-	if model, err := r.GetModel(fullName); err == nil {
+	if model, exists := snap.entities[entityKey{schema: schema, entity: entity}]; exists {
 		return model, nil
 	}
 
 	// Fallback to entity name only
-	return r.GetModel(entity)
+	if model, exists := snap.entities[entityKey{entity: entity}]; exists {
+		return model, nil
+	}
+
+	return nil, fmt.Errorf("model %s not found", entity)
+}
+
+// GetModelByTable retrieves a model by its table name
+// Accepts either "table" or "schema.table"
+func (r *DefaultModelRegistry) GetModelByTable(table string) (interface{}, error) {
+	snap := r.load()
+
+	if model, exists := snap.tables[table]; exists {
+		return model, nil
+	}
+
+	// Retry without the schema prefix
+	if idx := strings.LastIndex(table, "."); idx >= 0 {
+		if model, exists := snap.tables[table[idx+1:]]; exists {
+			return model, nil
+		}
+	}
+
+	return nil, fmt.Errorf("model for table %s not found", table)
+}
+
+// ListEntities returns all registered models sorted by registration name
+func (r *DefaultModelRegistry) ListEntities() []EntityInfo {
+	infos := r.load().infos
+
+	result := make([]EntityInfo, len(infos))
+	copy(result, infos)
+	return result
+}
+
+// ListBySchema returns the registered models of a schema sorted by registration name
+// Use an empty schema to list models registered without one
+func (r *DefaultModelRegistry) ListBySchema(schema string) []EntityInfo {
+	var result []EntityInfo
+	for _, info := range r.load().infos {
+		if info.Schema == schema {
+			result = append(result, info)
+		}
+	}
+	return result
+}
+
+// with returns a copy of the snapshot with the entity added
+func (s *registrySnapshot) with(info EntityInfo) *registrySnapshot {
+	next := &registrySnapshot{
+		models:   make(map[string]interface{}, len(s.models)+1),
+		entities: make(map[entityKey]interface{}, len(s.entities)+1),
+		tables:   make(map[string]interface{}, len(s.tables)+2),
+		infos:    make([]EntityInfo, 0, len(s.infos)+1),
+
+		descriptors: make(map[string]*ModelDescriptor, len(s.descriptors)+1),
+		types:       make(map[reflect.Type]*ModelDescriptor, len(s.types)+1),
+
+		version: s.version + 1,
+	}
+	for k, v := range s.models {
+		next.models[k] = v
+	}
+	for k, v := range s.entities {
+		next.entities[k] = v
+	}
+	for k, v := range s.tables {
+		next.tables[k] = v
+	}
+	next.infos = append(next.infos, s.infos...)
+	for k, v := range s.descriptors {
+		next.descriptors[k] = v
+	}
+	for k, v := range s.types {
+		next.types[k] = v
+	}
+
+	next.models[info.Name] = info.Model
+	// The name as registered is the lookup key, e.g. "public.users" -> {public, users}
+	nameSchema, nameEntity := splitName(info.Name)
+	next.entities[entityKey{schema: nameSchema, entity: nameEntity}] = info.Model
+
+	// First registration wins for table lookups
+	if _, exists := next.tables[info.Table]; !exists {
+		next.tables[info.Table] = info.Model
+	}
+	if info.Schema != "" {
+		qualified := info.Schema + "." + info.Table
+		if _, exists := next.tables[qualified]; !exists {
+			next.tables[qualified] = info.Model
+		}
+	}
+
+	descriptor := newModelDescriptor(info)
+	next.descriptors[info.Name] = descriptor
+	if _, exists := next.types[descriptor.Type]; !exists {
+		next.types[descriptor.Type] = descriptor
+	}
+
+	next.infos = append(next.infos, info)
+	sort.Slice(next.infos, func(i, j int) bool {
+		return next.infos[i].Name < next.infos[j].Name
+	})
+
+	return next
+}
+
+// newEntityInfo builds the entity description for a registered model
+func newEntityInfo(name string, model interface{}) EntityInfo {
+	schema, entity := splitName(name)
+	info := EntityInfo{
+		Name:   name,
+		Schema: schema,
+		Entity: entity,
+		Table:  entity,
+		Model:  model,
+	}
+
+	// Check both the value and a pointer, since TableName() is often declared on the pointer
+	ptr := reflect.New(reflect.TypeOf(model)).Interface()
+	for _, candidate := range []interface{}{model, ptr} {
+		if provider, ok := candidate.(interface{ TableName() string }); ok && provider.TableName() != "" {
+			tableSchema, table := splitName(provider.TableName())
+			info.Table = table
+			if tableSchema != "" {
+				info.Schema = tableSchema
+			}
+			break
+		}
+	}
+
+	if info.Schema == "" {
+		for _, candidate := range []interface{}{model, ptr} {
+			if provider, ok := candidate.(interface{ SchemaName() string }); ok {
+				info.Schema = provider.SchemaName()
+				break
+			}
+		}
+	}
+
+	return info
+}
+
+// splitName splits "schema.entity" into its parts
+func splitName(name string) (schema, entity string) {
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		return name[:idx], name[idx+1:]
+	}
+	return "", name
 }
 
 // Global convenience functions using the default registry
@@ -156,10 +368,7 @@ func GetModelByName(name string) (interface{}, error) {
 
 // IterateModels iterates over all models in the default global registry
 func IterateModels(fn func(name string, model interface{})) {
-	defaultRegistry.mutex.RLock()
-	defer defaultRegistry.mutex.RUnlock()
-
-	for name, model := range defaultRegistry.models {
+	for name, model := range defaultRegistry.load().models {
 		fn(name, model)
 	}
 }
@@ -174,15 +383,13 @@ func GetModels() []interface{} {
 	seen := make(map[string]bool)
 
 	for _, registry := range registries {
-		registry.mutex.RLock()
-		for name, model := range registry.models {
+		for name, model := range registry.load().models {
 			// Only add the first occurrence of each model name
 			if !seen[name] {
 				models = append(models, model)
 				seen[name] = true
 			}
 		}
-		registry.mutex.RUnlock()
 	}
 
 	return models
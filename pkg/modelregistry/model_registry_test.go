@@ -0,0 +1,105 @@
+package modelregistry
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testUser struct {
+	ID   int64
+	Name string
+}
+
+func (testUser) TableName() string { return "core.users" }
+
+type testOrder struct {
+	ID int64
+}
+
+func (*testOrder) TableName() string { return "orders" }
+func (testOrder) SchemaName() string { return "sales" }
+
+type testNote struct {
+	ID int64
+}
+
+func TestRegistryLookups(t *testing.T) {
+	r := NewModelRegistry()
+	require.NoError(t, r.RegisterModel("core.users", testUser{}))
+	require.NoError(t, r.RegisterModel("orders", &testOrder{}))
+	require.NoError(t, r.RegisterModel("notes", []testNote{}))
+
+	model, err := r.GetModelByEntity("core", "users")
+	require.NoError(t, err)
+	assert.IsType(t, testUser{}, model)
+
+	// Fallback to entity name only
+	model, err = r.GetModelByEntity("other", "notes")
+	require.NoError(t, err)
+	assert.IsType(t, testNote{}, model)
+
+	_, err = r.GetModelByEntity("core", "missing")
+	assert.Error(t, err)
+
+	// Table lookups with and without schema
+	model, err = r.GetModelByTable("users")
+	require.NoError(t, err)
+	assert.IsType(t, testUser{}, model)
+
+	model, err = r.GetModelByTable("sales.orders")
+	require.NoError(t, err)
+	assert.IsType(t, testOrder{}, model)
+
+	_, err = r.GetModelByTable("missing")
+	assert.Error(t, err)
+
+	assert.Error(t, r.RegisterModel("notes", testNote{}), "duplicate names must be rejected")
+	assert.Equal(t, uint64(3), r.Version(), "failed registrations keep the version")
+}
+
+func TestRegistryListEntities(t *testing.T) {
+	r := NewModelRegistry()
+	require.NoError(t, r.RegisterModel("orders", testOrder{}))
+	require.NoError(t, r.RegisterModel("core.users", testUser{}))
+	require.NoError(t, r.RegisterModel("notes", testNote{}))
+
+	entities := r.ListEntities()
+	require.Len(t, entities, 3)
+	assert.Equal(t, "core.users", entities[0].Name)
+	assert.Equal(t, EntityInfo{Name: "core.users", Schema: "core", Entity: "users", Table: "users", Model: testUser{}}, entities[0])
+	assert.Equal(t, EntityInfo{Name: "orders", Schema: "sales", Entity: "orders", Table: "orders", Model: testOrder{}}, entities[2])
+
+	sales := r.ListBySchema("sales")
+	require.Len(t, sales, 1)
+	assert.Equal(t, "orders", sales[0].Name)
+
+	noSchema := r.ListBySchema("")
+	require.Len(t, noSchema, 1)
+	assert.Equal(t, "notes", noSchema[0].Name)
+}
+
+func TestRegistryConcurrentAccess(t *testing.T) {
+	r := NewModelRegistry()
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, r.RegisterModel(fmt.Sprintf("entity%d", i), testNote{}))
+		}(i)
+		go func() {
+			defer wg.Done()
+			_, _ = r.GetModelByEntity("", "entity0")
+			_ = r.ListEntities()
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, r.ListEntities(), 20)
+	assert.Len(t, r.GetAllModels(), 20)
+}
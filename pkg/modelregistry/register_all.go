@@ -0,0 +1,153 @@
+package modelregistry
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Pluralizer turns the snake_case name of a model type into its entity name
+type Pluralizer func(name string) string
+
+// irregularPlurals are the English nouns Pluralize doesn't derive by suffix
+var irregularPlurals = map[string]string{
+	"person": "people",
+	"child":  "children",
+	"man":    "men",
+	"woman":  "women",
+	"mouse":  "mice",
+	"goose":  "geese",
+	"tooth":  "teeth",
+	"foot":   "feet",
+	"datum":  "data",
+	"index":  "indices",
+	"status": "statuses",
+	"news":   "news",
+	"series": "series",
+	"data":   "data",
+}
+
+// Pluralize is the default Pluralizer. It pluralizes the last word of a snake_case name with
+// English rules, e.g. project_task -> project_tasks, category -> categories, box -> boxes,
+// person -> people.
+func Pluralize(name string) string {
+	prefix, word := "", name
+	if idx := strings.LastIndex(name, "_"); idx >= 0 {
+		prefix, word = name[:idx+1], name[idx+1:]
+	}
+	if plural, ok := irregularPlurals[word]; ok {
+		return prefix + plural
+	}
+
+	switch {
+	case word == "":
+		return name
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "z"),
+		strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		word += "es"
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		word = word[:len(word)-1] + "ies"
+	default:
+		word += "s"
+	}
+	return prefix + word
+}
+
+// SetPluralizer sets how entity names are derived from type names by EntityName and
+// RegisterAll. Use an identity function for singular names; nil restores Pluralize.
+func (r *DefaultModelRegistry) SetPluralizer(pluralize Pluralizer) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.pluralize = pluralize
+}
+
+// EntityName derives the entity name of a model: the table of TableName(), without its schema,
+// or else the pluralized snake_case name of its type, e.g. ProjectTask -> project_tasks
+func (r *DefaultModelRegistry) EntityName(model interface{}) string {
+	r.mutex.Lock()
+	pluralize := r.pluralize
+	r.mutex.Unlock()
+
+	_, entity := entityNameOf(model, pluralize)
+	return entity
+}
+
+// RegisterAll registers models under schema with the names derived by EntityName, e.g.
+// RegisterAll("core", User{}, Order{}) registers "core.users" and "core.orders". Without a
+// schema the schema of TableName() is used. Either all models are registered or, when a model
+// is invalid or a name is taken, none.
+func (r *DefaultModelRegistry) RegisterAll(schema string, models ...interface{}) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	current := r.load()
+	next := current
+	for _, model := range models {
+		model, err := validateModel(model)
+		if err != nil {
+			return err
+		}
+		tableSchema, entity := entityNameOf(model, r.pluralize)
+		name := entity
+		switch {
+		case schema != "":
+			name = schema + "." + entity
+		case tableSchema != "":
+			name = tableSchema + "." + entity
+		}
+		if _, exists := next.models[name]; exists {
+			return fmt.Errorf("model %s already registered", name)
+		}
+		next = next.with(newEntityInfo(name, model))
+	}
+	r.snapshot.Store(next)
+	return nil
+}
+
+// entityNameOf returns the schema of TableName(), if any, and the entity name of a model
+func entityNameOf(model interface{}, pluralize Pluralizer) (schema, entity string) {
+	modelType := reflect.TypeOf(model)
+	if modelType == nil {
+		return "", ""
+	}
+	for modelType.Kind() == reflect.Ptr || modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Array {
+		modelType = modelType.Elem()
+	}
+
+	ptr := reflect.New(modelType).Interface()
+	for _, candidate := range []interface{}{reflect.Zero(modelType).Interface(), ptr} {
+		if provider, ok := candidate.(interface{ TableName() string }); ok && provider.TableName() != "" {
+			return splitName(provider.TableName())
+		}
+	}
+
+	if pluralize == nil {
+		pluralize = Pluralize
+	}
+	return "", pluralize(snakeCase(modelType.Name()))
+}
+
+// snakeCase converts a type name to snake_case, keeping acronyms together, e.g. HTTPLog -> http_log
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var result strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				result.WriteRune('_')
+			}
+		}
+		result.WriteRune(unicode.ToLower(r))
+	}
+	return result.String()
+}
+
+// Global convenience functions using the default registry
+
+// RegisterAll registers models with the default global registry, see DefaultModelRegistry.RegisterAll
+func RegisterAll(schema string, models ...interface{}) error {
+	return defaultRegistry.RegisterAll(schema, models...)
+}
@@ -0,0 +1,65 @@
+package modelregistry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCategory struct{ ID int64 }
+type testHTTPLog struct{ ID int64 }
+type testPerson struct{ ID int64 }
+
+func TestPluralize(t *testing.T) {
+	tests := map[string]string{
+		"user":         "users",
+		"project_task": "project_tasks",
+		"category":     "categories",
+		"day":          "days",
+		"box":          "boxes",
+		"address":      "addresses",
+		"branch":       "branches",
+		"person":       "people",
+		"order_status": "order_statuses",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, Pluralize(name), name)
+	}
+}
+
+func TestEntityName(t *testing.T) {
+	r := NewModelRegistry()
+	assert.Equal(t, "users", r.EntityName(testUser{}), "table of TableName() without its schema")
+	assert.Equal(t, "orders", r.EntityName(&testOrder{}))
+	assert.Equal(t, "test_categories", r.EntityName(testCategory{}))
+	assert.Equal(t, "test_http_logs", r.EntityName([]testHTTPLog{}))
+	assert.Equal(t, "test_people", r.EntityName(testPerson{}))
+
+	r.SetPluralizer(func(name string) string { return strings.TrimPrefix(name, "test_") })
+	assert.Equal(t, "category", r.EntityName(testCategory{}))
+	r.SetPluralizer(nil)
+	assert.Equal(t, "test_categories", r.EntityName(testCategory{}))
+}
+
+func TestRegisterAll(t *testing.T) {
+	r := NewModelRegistry()
+	require.NoError(t, r.RegisterAll("", testUser{}, &testCategory{}))
+	require.NoError(t, r.RegisterAll("audit", testHTTPLog{}))
+
+	names := make([]string, 0)
+	for _, info := range r.ListEntities() {
+		names = append(names, info.Name)
+	}
+	assert.Equal(t, []string{"audit.test_http_logs", "core.users", "test_categories"}, names)
+
+	model, err := r.GetModelByEntity("audit", "test_http_logs")
+	require.NoError(t, err)
+	assert.IsType(t, testHTTPLog{}, model)
+
+	assert.Error(t, r.RegisterAll("", testPerson{}, testCategory{}), "taken names are rejected")
+	_, err = r.GetModel("test_people")
+	assert.Error(t, err, "nothing is registered when one model fails")
+	assert.Error(t, r.RegisterAll("", testPerson{}, "not a struct"))
+}
@@ -0,0 +1,205 @@
+// Package ratelimit limits requests per entity and per authenticated user with
+// token buckets. Handlers check the limiter before running an operation and answer
+// 429 Too Many Requests with a Retry-After header when a bucket is empty.
+//
+// # Usage Example
+//
+//	limiter := ratelimit.New(ratelimit.Config{UserKey: security.UserKey},
+//		// Expensive reads: 5 per second per user, bursts of 10
+//		ratelimit.Rule{Entity: "public.reports", Operations: []string{"read"}, Rate: 5, Burst: 10, PerUser: true},
+//		// Everything else: 200 per second in total per entity
+//		ratelimit.Rule{Rate: 200, Burst: 400},
+//	)
+//	restheadspecHandler.SetRateLimiter(limiter)
+//	resolvespecHandler.SetRateLimiter(limiter)
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Operations checked by the handlers
+const (
+	OperationRead        = "read"
+	OperationCreate      = "create"
+	OperationUpdate      = "update"
+	OperationDelete      = "delete"
+	OperationBatch       = "batch"
+	OperationTransaction = "transaction"
+)
+
+// idleTimeout is how long an unused bucket is kept before it is pruned
+const idleTimeout = 10 * time.Minute
+
+// Rule limits the requests matching an entity and a set of operations
+type Rule struct {
+	// Entity is "schema.entity" or "entity"; empty or "*" matches every entity
+	Entity string
+	// Operations limits the rule to these operations; empty matches every operation
+	Operations []string
+	// Rate is the number of requests per second added to the bucket; zero blocks all matching requests
+	Rate float64
+	// Burst is the bucket size; values below 1 use max(1, Rate)
+	Burst int
+	// PerUser keeps a bucket per authenticated user instead of one per entity.
+	// Anonymous requests share one bucket.
+	PerUser bool
+}
+
+func (r *Rule) matches(schema, entity, operation string) bool {
+	if r.Entity != "" && r.Entity != "*" &&
+		!strings.EqualFold(r.Entity, entity) && !strings.EqualFold(r.Entity, schema+"."+entity) {
+		return false
+	}
+	if len(r.Operations) == 0 {
+		return true
+	}
+	for _, op := range r.Operations {
+		if strings.EqualFold(op, operation) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) burst() float64 {
+	if r.Burst >= 1 {
+		return float64(r.Burst)
+	}
+	return math.Max(1, r.Rate)
+}
+
+// Config configures a Limiter
+type Config struct {
+	// UserKey returns the authenticated user of a request context, used by PerUser rules
+	UserKey func(ctx context.Context) (string, bool)
+	// Now returns the current time; defaults to time.Now (overridable for tests)
+	Now func() time.Time
+}
+
+// bucket is a token bucket
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter applies the first matching rule to each request
+type Limiter struct {
+	config Config
+
+	mu        sync.Mutex
+	rules     []Rule
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+// New creates a limiter with the given rules. Rules are checked in order and only
+// the first matching rule applies, so specific rules go before general ones.
+func New(config Config, rules ...Rule) *Limiter {
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return &Limiter{
+		config:  config,
+		rules:   rules,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// AddRule appends a rule
+func (l *Limiter) AddRule(rule Rule) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rules = append(l.rules, rule)
+}
+
+// SetRules replaces all rules and resets the buckets
+func (l *Limiter) SetRules(rules ...Rule) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rules = rules
+	l.buckets = make(map[string]*bucket)
+}
+
+// Allow takes a token for the operation on schema.entity. When no token is left it
+// returns false and the time until the next token is available.
+func (l *Limiter) Allow(ctx context.Context, schema, entity, operation string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ruleIdx := -1
+	for idx := range l.rules {
+		if l.rules[idx].matches(schema, entity, operation) {
+			ruleIdx = idx
+			break
+		}
+	}
+	if ruleIdx < 0 {
+		return true, 0
+	}
+	rule := &l.rules[ruleIdx]
+	if rule.Rate <= 0 {
+		return false, 0
+	}
+
+	// Buckets are kept per rule and entity, so a rule without an entity limits each entity separately
+	key := fmt.Sprintf("%d|%s.%s", ruleIdx, schema, entity)
+	if rule.PerUser {
+		user := ""
+		if l.config.UserKey != nil {
+			user, _ = l.config.UserKey(ctx)
+		}
+		key += "|" + user
+	}
+
+	now := l.config.Now()
+	l.prune(now)
+
+	capacity := rule.burst()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[key] = b
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*rule.Rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / rule.Rate * float64(time.Second))
+	return false, wait
+}
+
+// prune removes buckets that were not used for idleTimeout; they would be full again
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < idleTimeout {
+		return
+	}
+	l.lastPrune = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RetryAfterSeconds formats a wait duration for the Retry-After header (whole seconds, at least 1)
+func RetryAfterSeconds(wait time.Duration) int {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type userKey struct{}
+
+func withUser(user string) context.Context {
+	return context.WithValue(context.Background(), userKey{}, user)
+}
+
+func contextUser(ctx context.Context) (string, bool) {
+	user, ok := ctx.Value(userKey{}).(string)
+	return user, ok
+}
+
+// fakeClock is a manually advanced clock
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestLimiterTokenBucket(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := New(Config{Now: clock.Now}, Rule{Entity: "reports", Rate: 2, Burst: 3})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := limiter.Allow(ctx, "public", "reports", OperationRead); !ok {
+			t.Fatalf("request %d within burst was limited", i)
+		}
+	}
+
+	ok, wait := limiter.Allow(ctx, "public", "reports", OperationRead)
+	if ok {
+		t.Fatal("request beyond burst was allowed")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("wait = %v, want 500ms", wait)
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	if ok, _ := limiter.Allow(ctx, "public", "reports", OperationRead); !ok {
+		t.Error("request after refill was limited")
+	}
+	if ok, _ := limiter.Allow(ctx, "public", "reports", OperationRead); ok {
+		t.Error("bucket should be empty again")
+	}
+
+	// Refill never exceeds the burst
+	clock.Advance(time.Hour)
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if ok, _ := limiter.Allow(ctx, "public", "reports", OperationRead); ok {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Errorf("allowed %d requests after a long pause, want 3", allowed)
+	}
+}
+
+func TestLimiterRuleMatching(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := New(Config{Now: clock.Now},
+		Rule{Entity: "public.reports", Operations: []string{OperationRead}, Rate: 1, Burst: 1},
+		Rule{Entity: "*", Rate: 1, Burst: 2},
+	)
+	ctx := context.Background()
+
+	allow := func(schema, entity, operation string) bool {
+		ok, _ := limiter.Allow(ctx, schema, entity, operation)
+		return ok
+	}
+
+	if !allow("public", "reports", OperationRead) || allow("public", "reports", OperationRead) {
+		t.Error("reads of public.reports should be limited to 1")
+	}
+	// Writes fall through to the catch-all rule with its own bucket
+	if !allow("public", "reports", OperationCreate) || !allow("public", "reports", OperationCreate) || allow("public", "reports", OperationCreate) {
+		t.Error("creates of public.reports should be limited to 2")
+	}
+	// The catch-all rule limits each entity separately
+	if !allow("public", "users", OperationRead) || !allow("public", "users", OperationRead) {
+		t.Error("users should have their own bucket")
+	}
+	// Entity names without schema match as well
+	if !allow("other", "reports", OperationDelete) {
+		t.Error("other.reports delete should use the catch-all rule")
+	}
+}
+
+func TestLimiterPerUser(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := New(Config{Now: clock.Now, UserKey: contextUser}, Rule{Rate: 1, Burst: 1, PerUser: true})
+
+	if ok, _ := limiter.Allow(withUser("1"), "public", "orders", OperationRead); !ok {
+		t.Fatal("first request of user 1 was limited")
+	}
+	if ok, _ := limiter.Allow(withUser("1"), "public", "orders", OperationRead); ok {
+		t.Error("second request of user 1 was allowed")
+	}
+	if ok, _ := limiter.Allow(withUser("2"), "public", "orders", OperationRead); !ok {
+		t.Error("user 2 should have an own bucket")
+	}
+	if ok, _ := limiter.Allow(context.Background(), "public", "orders", OperationRead); !ok {
+		t.Error("first anonymous request was limited")
+	}
+	if ok, _ := limiter.Allow(context.Background(), "public", "orders", OperationRead); ok {
+		t.Error("anonymous requests should share one bucket")
+	}
+}
+
+func TestLimiterWithoutMatchingRule(t *testing.T) {
+	limiter := New(Config{}, Rule{Entity: "reports", Rate: 0})
+	if ok, _ := limiter.Allow(context.Background(), "public", "users", OperationRead); !ok {
+		t.Error("requests without a matching rule must be allowed")
+	}
+	if ok, _ := limiter.Allow(context.Background(), "public", "reports", OperationRead); ok {
+		t.Error("a zero rate must block")
+	}
+
+	var nilLimiter *Limiter
+	if ok, _ := nilLimiter.Allow(context.Background(), "public", "reports", OperationRead); !ok {
+		t.Error("a nil limiter must allow everything")
+	}
+}
+
+func TestLimiterPrunesIdleBuckets(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := New(Config{Now: clock.Now, UserKey: contextUser}, Rule{Rate: 1, PerUser: true})
+
+	for _, user := range []string{"1", "2", "3"} {
+		limiter.Allow(withUser(user), "public", "orders", OperationRead)
+	}
+	clock.Advance(idleTimeout)
+	limiter.Allow(withUser("4"), "public", "orders", OperationRead)
+
+	if len(limiter.buckets) != 1 {
+		t.Errorf("expected idle buckets to be pruned, %d left", len(limiter.buckets))
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	tests := map[time.Duration]int{0: 1, 200 * time.Millisecond: 1, time.Second: 1, 1500 * time.Millisecond: 2}
+	for wait, want := range tests {
+		if got := RetryAfterSeconds(wait); got != want {
+			t.Errorf("RetryAfterSeconds(%v) = %d, want %d", wait, got, want)
+		}
+	}
+}
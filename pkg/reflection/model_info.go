@@ -0,0 +1,234 @@
+package reflection
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ModelInfo is the reflected metadata of a model type: its columns, primary keys, relations and
+// fields. Describe builds it once per type, so the helpers of this package reflect over a model
+// only on its first request, and the model registry builds the descriptors of registered models
+// from it when they are registered. It is read-only once built.
+type ModelInfo struct {
+	Type        reflect.Type // The struct type of the model
+	Columns     []string     // Columns of the fields, see GetModelColumns
+	SQLColumns  []string     // Columns mapped to SQL, see GetSQLModelColumns
+	PrimaryKeys []string     // Columns tagged as primary key, bun tags before gorm tags
+	Fields      []FieldInfo  // Fields, including the promoted fields of embedded structs
+
+	byColumn   map[string]int // Index in Fields of the first field of a column
+	fieldTypes sync.Map       // Column name -> reflect.Type, see ColumnFieldType
+	relations  sync.Map       // Field name -> reflect.Type of the related struct
+}
+
+// FieldInfo is a field of a model
+type FieldInfo struct {
+	Name     string       // Go name of the field
+	Column   string       // Column name: bun tag, gorm tag, json tag or lowercase field name
+	JSONName string       // Name of the json tag, "" without one
+	Type     reflect.Type // Go type of the field
+	Index    []int        // Index sequence for reflect.Value.FieldByIndex
+	Writable bool         // False for bun scanonly and gorm read-only fields, also in scanonly embedded structs
+	Unique   bool         // Tagged unique, see IsColumnUnique
+	Exported bool         // False for unexported fields
+	Ignored  bool         // Tagged bun:"-", or gorm:"-" without a bun tag
+
+	PrimaryKey  bool         // Column of ModelInfo.PrimaryKeys
+	Relation    string       // Kind of a relation field: "belongsTo", "hasMany", "hasOne" or "many2many"
+	RelatedType reflect.Type // Struct type of the related model of a relation field, nil if none
+	Rules       []string     // Rules of the validate tag, e.g. "required", "oneof=a b"
+}
+
+// IsColumn reports whether the field maps to a column: exported, not ignored and not a relation
+func (f *FieldInfo) IsColumn() bool {
+	return f.Exported && !f.Ignored && f.Relation == ""
+}
+
+// modelInfos caches the ModelInfo of model types. The tags of a type can't change, so an entry
+// never goes stale. Registered models are described when they are registered.
+var modelInfos sync.Map // reflect.Type -> *ModelInfo
+
+// Describe returns the metadata of the struct type of model, which may be a pointer, slice or
+// array of it, building it on the first call for the type. It returns nil for other types.
+func Describe(model any) *ModelInfo {
+	return DescribeType(reflect.TypeOf(model))
+}
+
+// DescribeType is Describe for a type
+func DescribeType(typ reflect.Type) *ModelInfo {
+	for typ != nil && (typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil
+	}
+	if info, ok := modelInfos.Load(typ); ok {
+		return info.(*ModelInfo)
+	}
+	info, _ := modelInfos.LoadOrStore(typ, buildModelInfo(typ))
+	return info.(*ModelInfo)
+}
+
+func buildModelInfo(typ reflect.Type) *ModelInfo {
+	info := &ModelInfo{Type: typ, byColumn: make(map[string]int)}
+	collectFieldInfos(typ, nil, false, &info.Fields)
+	info.Columns = make([]string, 0, len(info.Fields))
+	for i, field := range info.Fields {
+		info.Columns = append(info.Columns, field.Column)
+		if _, ok := info.byColumn[field.Column]; !ok {
+			info.byColumn[field.Column] = i
+		}
+	}
+	collectSQLColumnsFromType(typ, &info.SQLColumns, false)
+	for _, ormType := range []string{"bun", "gorm"} {
+		if info.PrimaryKeys = findPrimaryKeyNamesFromType(typ, ormType); len(info.PrimaryKeys) > 0 {
+			break
+		}
+	}
+	for _, key := range info.PrimaryKeys {
+		if i, ok := info.byColumn[key]; ok {
+			info.Fields[i].PrimaryKey = true
+		}
+	}
+	return info
+}
+
+// collectFieldInfos collects the fields of typ in order, descending into embedded structs
+func collectFieldInfos(typ reflect.Type, index []int, scanOnly bool, fields *[]FieldInfo) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fieldIndex := append(append([]int(nil), index...), i)
+		bunTag := field.Tag.Get("bun")
+		gormTag := field.Tag.Get("gorm")
+
+		if field.Anonymous {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				collectFieldInfos(fieldType, fieldIndex, scanOnly || (bunTag != "" && isBunFieldScanOnly(bunTag)), fields)
+				continue
+			}
+		}
+
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if jsonName == "-" {
+			jsonName = ""
+		}
+		info := FieldInfo{
+			Name:     field.Name,
+			Column:   getColumnNameFromField(field),
+			JSONName: jsonName,
+			Type:     field.Type,
+			Index:    fieldIndex,
+			Writable: !scanOnly && !(bunTag != "" && isBunFieldScanOnly(bunTag)) && !(gormTag != "" && isGormFieldReadOnly(gormTag)),
+			Unique:   isFieldUnique(field),
+			Exported: field.IsExported(),
+			Ignored:  bunTag == "-" || (bunTag == "" && gormTag == "-"),
+			Relation: relationKind(field),
+		}
+		if info.Relation != "" {
+			info.RelatedType = relatedStructType(field.Type)
+		}
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			if rule = strings.TrimSpace(rule); rule != "" && rule != "-" {
+				info.Rules = append(info.Rules, rule)
+			}
+		}
+		*fields = append(*fields, info)
+	}
+}
+
+// relationKind returns the kind of a relation field from its tags, or "" for other fields
+func relationKind(field reflect.StructField) string {
+	for _, option := range strings.Split(field.Tag.Get("bun"), ",") {
+		switch option = strings.TrimSpace(option); {
+		case option == "rel:has-one":
+			return "hasOne"
+		case option == "rel:has-many":
+			return "hasMany"
+		case option == "rel:belongs-to":
+			return "belongsTo"
+		case strings.HasPrefix(option, "m2m:"):
+			return "many2many"
+		}
+	}
+
+	gormTag := field.Tag.Get("gorm")
+	switch {
+	case strings.Contains(gormTag, "many2many:"):
+		return "many2many"
+	case strings.Contains(gormTag, "foreignKey:"), strings.Contains(gormTag, "references:"):
+		if field.Type.Kind() == reflect.Slice {
+			return "hasMany"
+		}
+		return "belongsTo"
+	}
+	return ""
+}
+
+// relatedStructType returns the struct type of a relation field, unwrapping a slice and a
+// pointer, or nil
+func relatedStructType(fieldType reflect.Type) reflect.Type {
+	if fieldType.Kind() == reflect.Slice {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType.Kind() != reflect.Struct {
+		return nil
+	}
+	return fieldType
+}
+
+// Field returns the first field of a column, or nil
+func (m *ModelInfo) Field(column string) *FieldInfo {
+	if i, ok := m.byColumn[column]; ok {
+		return &m.Fields[i]
+	}
+	return nil
+}
+
+// ColumnFieldType returns the Go type of the top-level field of a column, matched by JSON
+// name, field name or snake_case field name, see GetColumnFieldType. Found types are cached.
+func (m *ModelInfo) ColumnFieldType(column string) reflect.Type {
+	if fieldType, ok := m.fieldTypes.Load(column); ok {
+		return fieldType.(reflect.Type)
+	}
+	var found reflect.Type
+	for i := 0; i < m.Type.NumField(); i++ {
+		field := m.Type.Field(i)
+		if jsonTag := field.Tag.Get("json"); jsonTag != "" && strings.Split(jsonTag, ",")[0] == column {
+			found = field.Type
+			break
+		}
+		if strings.EqualFold(field.Name, column) || ToSnakeCase(field.Name) == column {
+			found = field.Type
+			break
+		}
+	}
+	if found != nil {
+		m.fieldTypes.Store(column, found) // Only found columns, names come from requests
+	}
+	return found
+}
+
+// relationType returns the struct type of the top-level relation field named name, matched
+// by field name or the name of its bun, gorm or json tag, see GetRelationModel. Found types
+// are cached.
+func (m *ModelInfo) relationType(name string) reflect.Type {
+	if relation, ok := m.relations.Load(name); ok {
+		return relation.(reflect.Type)
+	}
+	var found reflect.Type
+	if field := findRelationField(m.Type, name); field != nil {
+		found = relatedStructType(field.Type)
+	}
+	if found != nil {
+		m.relations.Store(name, found)
+	}
+	return found
+}
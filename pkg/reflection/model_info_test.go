@@ -0,0 +1,103 @@
+package reflection
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribe(t *testing.T) {
+	info := Describe(&ModelWithEmbedded{})
+	require.NotNil(t, info)
+	assert.Same(t, info, Describe([]ModelWithEmbedded{}), "one description per type")
+	assert.Same(t, info, DescribeType(reflect.TypeOf(&[]*ModelWithEmbedded{})))
+	assert.Nil(t, Describe(42))
+	assert.Nil(t, Describe(nil))
+
+	assert.Equal(t, reflect.TypeOf(ModelWithEmbedded{}), info.Type)
+	assert.Equal(t, []string{"rid_base", "created_at", "name", "description", "cql1", "cql2", "_rownumber"}, info.Columns)
+	assert.Equal(t, []string{"rid_base", "created_at", "name", "description"}, info.SQLColumns)
+	assert.Equal(t, []string{"rid_base"}, info.PrimaryKeys)
+
+	field := info.Field("cql1")
+	require.NotNil(t, field)
+	assert.Equal(t, "CQL1", field.Name)
+	assert.Equal(t, "cql1", field.JSONName)
+	assert.Equal(t, []int{3, 0}, field.Index, "promoted from the embedded struct")
+	assert.False(t, field.Writable)
+	assert.True(t, info.Field("name").Writable)
+	assert.Nil(t, info.Field("missing"))
+
+	assert.Equal(t, reflect.TypeOf(""), info.ColumnFieldType("name"))
+	assert.Nil(t, info.ColumnFieldType("missing"))
+
+	assert.True(t, info.Field("rid_base").Writable)
+	assert.True(t, info.Field("rid_base").PrimaryKey)
+
+	unique := Describe(ModelWithUniqueColumns{})
+	assert.True(t, unique.Field("email").Unique)
+	assert.False(t, unique.Field("name").Unique)
+}
+
+func TestDescribeRelations(t *testing.T) {
+	info := Describe(User{})
+	assert.Equal(t, reflect.TypeOf(Post{}), info.relationType("Posts"))
+	assert.Equal(t, reflect.TypeOf(Profile{}), info.relationType("profile"), "matched by json name")
+	assert.Nil(t, info.relationType("Name"), "not a struct")
+	assert.Nil(t, info.relationType("missing"))
+
+	kinds := map[string]string{}
+	for _, field := range info.Fields {
+		if field.Relation != "" {
+			kinds[field.Name] = field.Relation
+		}
+	}
+	assert.Equal(t, map[string]string{"Posts": "hasMany", "Profile": "hasOne"}, kinds)
+	assert.Equal(t, reflect.TypeOf(Profile{}), info.Field("profile").RelatedType)
+	assert.False(t, info.Field("profile").IsColumn())
+	assert.True(t, info.Field("id").PrimaryKey)
+	assert.True(t, info.Field("name").IsColumn())
+
+	post := Describe(Post{})
+	assert.Equal(t, "belongsTo", post.Field("user").Relation, "gorm foreignKey")
+	assert.Equal(t, "many2many", post.Field("tags").Relation)
+
+	// The helpers return copies of the cached slices
+	columns := GetModelColumns(&User{})
+	columns[0] = "changed"
+	assert.Equal(t, "id", GetModelColumns(&User{})[0])
+}
+
+func TestDescribeTags(t *testing.T) {
+	type audit struct {
+		UpdatedBy string `bun:"updated_by" json:"updated_by"`
+	}
+	type invoice struct {
+		ID       int64  `bun:"id,pk" json:"id"`
+		Number   string `bun:"number" json:"number" validate:"required, max=20"`
+		Ignored  string `bun:"-" json:"-"`
+		Skipped  string `gorm:"-"`
+		internal string
+		audit    `bun:",scanonly"`
+	}
+
+	info := Describe(invoice{})
+	assert.Equal(t, []string{"required", "max=20"}, info.Field("number").Rules)
+	assert.Nil(t, info.Field("id").Rules)
+
+	ignored := info.Field("ignored")
+	require.NotNil(t, ignored)
+	assert.True(t, ignored.Ignored)
+	assert.Empty(t, ignored.JSONName)
+	assert.False(t, ignored.IsColumn())
+	assert.True(t, info.Field("skipped").Ignored, "gorm:\"-\" without a bun tag")
+	assert.False(t, info.Field("internal").Exported)
+	assert.False(t, info.Field("internal").IsColumn())
+
+	updatedBy := info.Field("updated_by")
+	require.NotNil(t, updatedBy)
+	assert.False(t, updatedBy.Writable, "promoted from a scanonly embedded struct")
+	assert.True(t, updatedBy.IsColumn())
+}
@@ -5,10 +5,18 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
-
-	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
 )
 
+// modelLookup resolves the model registered under a name, see SetModelLookup
+var modelLookup func(name string) (any, error)
+
+// SetModelLookup sets how GetPrimaryKeyName resolves a model given by name. The model registry
+// sets its GetModelByName when imported, which this package can't import since the registry
+// describes its models with it.
+func SetModelLookup(lookup func(name string) (any, error)) {
+	modelLookup = lookup
+}
+
 type PrimaryKeyNameProvider interface {
 	GetIDName() string
 }
@@ -22,10 +30,10 @@ func GetPrimaryKeyName(model any) string {
 	}
 	// If we are given a string model name, look up the model
 	if reflect.TypeOf(model).Kind() == reflect.String {
-		name := model.(string)
-		m, err := modelregistry.GetModelByName(name)
-		if err == nil {
-			model = m
+		if modelLookup != nil {
+			if m, err := modelLookup(model.(string)); err == nil {
+				model = m
+			}
 		}
 	}
 
@@ -34,19 +42,37 @@ func GetPrimaryKeyName(model any) string {
 		return provider.GetIDName()
 	}
 
-	// Try Bun tag first
-	if pkName := getPrimaryKeyFromReflection(model, "bun"); pkName != "" {
-		return pkName
-	}
-
-	// Fall back to GORM tag
-	if pkName := getPrimaryKeyFromReflection(model, "gorm"); pkName != "" {
-		return pkName
+	// Bun tags first, then GORM tags
+	if info := Describe(model); info != nil && len(info.PrimaryKeys) > 0 {
+		return info.PrimaryKeys[0]
 	}
 
 	return ""
 }
 
+// PrimaryKeyNamesProvider is implemented by models with a composite primary key
+type PrimaryKeyNamesProvider interface {
+	GetIDNames() []string
+}
+
+// GetPrimaryKeyNames returns the primary key columns of a model, several for a composite key.
+// It checks PrimaryKeyNamesProvider, then the bun:",pk" and gorm:"primaryKey" tags in the order
+// of the fields, and falls back to GetPrimaryKeyName.
+func GetPrimaryKeyNames(model any) []string {
+	if provider, ok := model.(PrimaryKeyNamesProvider); ok {
+		return provider.GetIDNames()
+	}
+	if _, ok := model.(PrimaryKeyNameProvider); !ok {
+		if info := Describe(model); info != nil && len(info.PrimaryKeys) > 0 {
+			return append([]string(nil), info.PrimaryKeys...)
+		}
+	}
+	if pkName := GetPrimaryKeyName(model); pkName != "" {
+		return []string{pkName}
+	}
+	return nil
+}
+
 // GetPrimaryKeyValue extracts the primary key value from a model instance
 // Returns the value of the primary key field
 func GetPrimaryKeyValue(model any) any {
@@ -146,52 +172,11 @@ func findFieldByName(val reflect.Value, name string) any {
 // It checks bun tags first, then gorm tags, then json tags, and finally falls back to lowercase field names
 // This function recursively processes embedded structs to include their fields
 func GetModelColumns(model any) []string {
-	var columns []string
-
-	modelType := reflect.TypeOf(model)
-
-	// Unwrap pointers, slices, and arrays to get to the base struct type
-	for modelType != nil && (modelType.Kind() == reflect.Pointer || modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Array) {
-		modelType = modelType.Elem()
-	}
-
-	// Validate that we have a struct type
-	if modelType == nil || modelType.Kind() != reflect.Struct {
-		return columns
-	}
-
-	collectColumnsFromType(modelType, &columns)
-
-	return columns
-}
-
-// collectColumnsFromType recursively collects column names from a struct type and its embedded fields
-func collectColumnsFromType(typ reflect.Type, columns *[]string) {
-	for i := 0; i < typ.NumField(); i++ {
-		field := typ.Field(i)
-
-		// Check if this is an embedded struct
-		if field.Anonymous {
-			// Unwrap pointer type if necessary
-			fieldType := field.Type
-			if fieldType.Kind() == reflect.Pointer {
-				fieldType = fieldType.Elem()
-			}
-
-			// Recursively process embedded struct
-			if fieldType.Kind() == reflect.Struct {
-				collectColumnsFromType(fieldType, columns)
-				continue
-			}
-		}
-
-		// Get column name using the same logic as primary key extraction
-		columnName := getColumnNameFromField(field)
-
-		if columnName != "" {
-			*columns = append(*columns, columnName)
-		}
+	info := Describe(model)
+	if info == nil {
+		return nil
 	}
+	return append([]string(nil), info.Columns...)
 }
 
 // getColumnNameFromField extracts the column name from a struct field
@@ -227,24 +212,36 @@ func getColumnNameFromField(field reflect.StructField) string {
 	return strings.ToLower(field.Name)
 }
 
-// getPrimaryKeyFromReflection uses reflection to find the primary key field
-// This function recursively searches embedded structs
-func getPrimaryKeyFromReflection(model any, ormType string) string {
-	val := reflect.ValueOf(model)
-	if val.Kind() == reflect.Pointer {
-		val = val.Elem()
+// GetColumnNameForField returns the column name of the named struct field (including promoted
+// fields of embedded structs), or "" if the model has no such field
+func GetColumnNameForField(model any, fieldName string) string {
+	modelType := reflect.TypeOf(model)
+	for modelType != nil && modelType.Kind() == reflect.Pointer {
+		modelType = modelType.Elem()
 	}
-
-	if val.Kind() != reflect.Struct {
+	if modelType == nil || modelType.Kind() != reflect.Struct {
 		return ""
 	}
 
-	typ := val.Type()
-	return findPrimaryKeyNameFromType(typ, ormType)
+	field, ok := modelType.FieldByName(fieldName)
+	if !ok {
+		return ""
+	}
+	return getColumnNameFromField(field)
 }
 
 // findPrimaryKeyNameFromType recursively searches for the primary key field name in a struct type
 func findPrimaryKeyNameFromType(typ reflect.Type, ormType string) string {
+	if names := findPrimaryKeyNamesFromType(typ, ormType); len(names) > 0 {
+		return names[0]
+	}
+	return ""
+}
+
+// findPrimaryKeyNamesFromType recursively collects the primary key column names of a struct type
+// in the order of its fields
+func findPrimaryKeyNamesFromType(typ reflect.Type, ormType string) []string {
+	var names []string
 	for i := 0; i < typ.NumField(); i++ {
 		field := typ.Field(i)
 
@@ -258,44 +255,42 @@ func findPrimaryKeyNameFromType(typ reflect.Type, ormType string) string {
 
 			// Recursively search in embedded struct
 			if fieldType.Kind() == reflect.Struct {
-				if pkName := findPrimaryKeyNameFromType(fieldType, ormType); pkName != "" {
-					return pkName
-				}
+				names = append(names, findPrimaryKeyNamesFromType(fieldType, ormType)...)
 			}
 			continue
 		}
 
+		var colName string
 		switch ormType {
 		case "gorm":
 			// Check for gorm tag with primaryKey
 			gormTag := field.Tag.Get("gorm")
-			if strings.Contains(gormTag, "primaryKey") {
-				// Try to extract column name from gorm tag
-				if colName := ExtractColumnFromGormTag(gormTag); colName != "" {
-					return colName
-				}
-				// Fall back to json tag
-				if jsonTag := field.Tag.Get("json"); jsonTag != "" {
-					return strings.Split(jsonTag, ",")[0]
-				}
+			if !strings.Contains(gormTag, "primaryKey") {
+				continue
 			}
+			// Try to extract column name from gorm tag
+			colName = ExtractColumnFromGormTag(gormTag)
 		case "bun":
 			// Check for bun tag with pk flag
 			bunTag := field.Tag.Get("bun")
-			if strings.Contains(bunTag, "pk") {
-				// Extract column name from bun tag
-				if colName := ExtractColumnFromBunTag(bunTag); colName != "" {
-					return colName
-				}
-				// Fall back to json tag
-				if jsonTag := field.Tag.Get("json"); jsonTag != "" {
-					return strings.Split(jsonTag, ",")[0]
-				}
+			if !strings.Contains(bunTag, "pk") {
+				continue
+			}
+			// Extract column name from bun tag
+			colName = ExtractColumnFromBunTag(bunTag)
+		}
+		// Fall back to json tag
+		if colName == "" {
+			if jsonTag := field.Tag.Get("json"); jsonTag != "" {
+				colName = strings.Split(jsonTag, ",")[0]
 			}
 		}
+		if colName != "" {
+			names = append(names, colName)
+		}
 	}
 
-	return ""
+	return names
 }
 
 // ExtractColumnFromGormTag extracts the column name from a gorm tag
@@ -331,23 +326,11 @@ func ExtractColumnFromBunTag(tag string) string {
 // 2. Are not relations (no rel:, join:, foreignKey, references, many2many tags)
 // 3. Are not scan-only embedded fields
 func GetSQLModelColumns(model any) []string {
-	var columns []string
-
-	modelType := reflect.TypeOf(model)
-
-	// Unwrap pointers, slices, and arrays to get to the base struct type
-	for modelType != nil && (modelType.Kind() == reflect.Pointer || modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Array) {
-		modelType = modelType.Elem()
-	}
-
-	// Validate that we have a struct type
-	if modelType == nil || modelType.Kind() != reflect.Struct {
-		return columns
+	info := Describe(model)
+	if info == nil {
+		return nil
 	}
-
-	collectSQLColumnsFromType(modelType, &columns, false)
-
-	return columns
+	return append([]string(nil), info.SQLColumns...)
 }
 
 // collectSQLColumnsFromType recursively collects SQL column names from a struct type
@@ -451,81 +434,18 @@ func collectSQLColumnsFromType(typ reflect.Type, columns *[]string, scanOnlyEmbe
 // For gorm: returns false if the field has "<-:false" or "->" (read-only) tag
 // This function recursively searches embedded structs
 func IsColumnWritable(model any, columnName string) bool {
-	modelType := reflect.TypeOf(model)
-
-	// Unwrap pointers to get to the base struct type
-	for modelType != nil && modelType.Kind() == reflect.Pointer {
-		modelType = modelType.Elem()
-	}
-
-	// Validate that we have a struct type
-	if modelType == nil || modelType.Kind() != reflect.Struct {
+	info := Describe(model)
+	if info == nil {
 		return false
 	}
-
-	found, writable := isColumnWritableInType(modelType, columnName)
-	if found {
-		return writable
+	if field := info.Field(columnName); field != nil {
+		return field.Writable
 	}
 
 	// Column not found in model, allow it (might be a dynamic column)
 	return true
 }
 
-// isColumnWritableInType recursively searches for a column and checks if it's writable
-// Returns (found, writable) where found indicates if the column was found
-func isColumnWritableInType(typ reflect.Type, columnName string) (found bool, writable bool) {
-	for i := 0; i < typ.NumField(); i++ {
-		field := typ.Field(i)
-
-		// Check if this is an embedded struct
-		if field.Anonymous {
-			// Unwrap pointer type if necessary
-			fieldType := field.Type
-			if fieldType.Kind() == reflect.Pointer {
-				fieldType = fieldType.Elem()
-			}
-
-			// Recursively search in embedded struct
-			if fieldType.Kind() == reflect.Struct {
-				if found, writable := isColumnWritableInType(fieldType, columnName); found {
-					return true, writable
-				}
-			}
-			continue
-		}
-
-		// Check if this field matches the column name
-		fieldColumnName := getColumnNameFromField(field)
-		if fieldColumnName != columnName {
-			continue
-		}
-
-		// Found the field, now check if it's writable
-		// Check bun tag for scanonly
-		bunTag := field.Tag.Get("bun")
-		if bunTag != "" {
-			if isBunFieldScanOnly(bunTag) {
-				return true, false
-			}
-		}
-
-		// Check gorm tag for write restrictions
-		gormTag := field.Tag.Get("gorm")
-		if gormTag != "" {
-			if isGormFieldReadOnly(gormTag) {
-				return true, false
-			}
-		}
-
-		// Column is writable
-		return true, true
-	}
-
-	// Column not found
-	return false, false
-}
-
 // isBunFieldScanOnly checks if a bun tag indicates the field is scan-only
 // Example: "column_name,scanonly" -> true
 func isBunFieldScanOnly(tag string) bool {
@@ -564,6 +484,39 @@ func isGormFieldReadOnly(tag string) bool {
 	return false
 }
 
+// IsColumnUnique checks if a column holds unique values: a single primary key, or a field tagged
+// unique (bun:",unique", gorm:"unique" or gorm:"uniqueIndex"). Unique groups of several columns
+// (bun:",unique:group") don't make a column unique on its own.
+// This function recursively searches embedded structs
+func IsColumnUnique(model any, columnName string) bool {
+	info := Describe(model)
+	if info == nil {
+		return false
+	}
+
+	if pkNames := GetPrimaryKeyNames(model); len(pkNames) == 1 && pkNames[0] == columnName {
+		return true
+	}
+	field := info.Field(columnName)
+	return field != nil && field.Unique
+}
+
+// isFieldUnique checks the unique tags of a field
+func isFieldUnique(field reflect.StructField) bool {
+	for _, part := range strings.Split(field.Tag.Get("bun"), ",") {
+		if strings.TrimSpace(part) == "unique" {
+			return true
+		}
+	}
+	for _, part := range strings.Split(field.Tag.Get("gorm"), ";") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "unique" || part == "uniqueindex" || strings.HasPrefix(part, "uniqueindex:") {
+			return true
+		}
+	}
+	return false
+}
+
 // ExtractSourceColumn extracts the base column name from PostgreSQL JSON operators
 // Examples:
 //   - "columna->>'val'" returns "columna"
@@ -595,51 +548,47 @@ func ToSnakeCase(s string) string {
 
 // GetColumnTypeFromModel uses reflection to determine the Go type of a column in a model
 func GetColumnTypeFromModel(model interface{}, colName string) reflect.Kind {
-	if model == nil {
+	fieldType := GetColumnFieldType(model, colName)
+	if fieldType == nil {
 		return reflect.Invalid
 	}
+	return fieldType.Kind()
+}
+
+// GetColumnFieldType returns the Go type of the struct field of a column in a model,
+// matched by JSON name, field name or snake_case field name; nil if there is no such field
+func GetColumnFieldType(model interface{}, colName string) reflect.Type {
+	info := Describe(model)
+	if info == nil {
+		return nil
+	}
 
 	// Extract the source column name (remove JSON operators like ->> or ->)
-	sourceColName := ExtractSourceColumn(colName)
+	return info.ColumnFieldType(ExtractSourceColumn(colName))
+}
 
-	modelType := reflect.TypeOf(model)
-	// Dereference pointer if needed
-	if modelType.Kind() == reflect.Ptr {
-		modelType = modelType.Elem()
+// GetArrayElementKind returns the element kind of an array column, e.g. string for a
+// []string or pq.StringArray field. ok is false if the column is not a slice; byte slices
+// ([]byte, JSON columns) are not arrays.
+func GetArrayElementKind(model interface{}, colName string) (kind reflect.Kind, ok bool) {
+	fieldType := GetColumnFieldType(model, colName)
+	if fieldType == nil {
+		return reflect.Invalid, false
 	}
-
-	// Ensure it's a struct
-	if modelType.Kind() != reflect.Struct {
-		return reflect.Invalid
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
 	}
-
-	// Find the field by JSON tag or field name
-	for i := 0; i < modelType.NumField(); i++ {
-		field := modelType.Field(i)
-
-		// Check JSON tag
-		jsonTag := field.Tag.Get("json")
-		if jsonTag != "" {
-			// Parse JSON tag (format: "name,omitempty")
-			parts := strings.Split(jsonTag, ",")
-			if parts[0] == sourceColName {
-				return field.Type.Kind()
-			}
-		}
-
-		// Check field name (case-insensitive)
-		if strings.EqualFold(field.Name, sourceColName) {
-			return field.Type.Kind()
-		}
-
-		// Check snake_case conversion
-		snakeCaseName := ToSnakeCase(field.Name)
-		if snakeCaseName == sourceColName {
-			return field.Type.Kind()
-		}
+	if fieldType.Kind() != reflect.Slice && fieldType.Kind() != reflect.Array {
+		return reflect.Invalid, false
 	}
-
-	return reflect.Invalid
+	elem := fieldType.Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() == reflect.Uint8 {
+		return reflect.Invalid, false
+	}
+	return elem.Kind(), true
 }
 
 // IsNumericType checks if a reflect.Kind is a numeric type
@@ -792,21 +741,22 @@ func getRelationModelSingleLevel(model interface{}, fieldName string) interface{
 		return nil
 	}
 
-	modelType := reflect.TypeOf(model)
-	if modelType == nil {
+	info := Describe(model)
+	if info == nil {
 		return nil
 	}
-
-	if modelType.Kind() == reflect.Ptr {
-		modelType = modelType.Elem()
-	}
-
-	if modelType == nil || modelType.Kind() != reflect.Struct {
+	targetType := info.relationType(fieldName)
+	if targetType == nil {
 		return nil
 	}
 
-	// Find the field by checking in priority order (case-insensitive)
-	var field *reflect.StructField
+	// Create a zero value of the target type
+	return reflect.New(targetType).Elem().Interface()
+}
+
+// findRelationField finds the top-level field named name, checking in priority order
+// (case-insensitive) the field name and the names of its bun, gorm and json tags
+func findRelationField(modelType reflect.Type, fieldName string) *reflect.StructField {
 	normalizedFieldName := strings.ToLower(fieldName)
 
 	for i := 0; i < modelType.NumField(); i++ {
@@ -814,8 +764,7 @@ func getRelationModelSingleLevel(model interface{}, fieldName string) interface{
 
 		// 1. Check actual field name (case-insensitive)
 		if strings.EqualFold(f.Name, fieldName) {
-			field = &f
-			break
+			return &f
 		}
 
 		// 2. Check bun tag name
@@ -823,8 +772,7 @@ func getRelationModelSingleLevel(model interface{}, fieldName string) interface{
 		if bunTag != "" {
 			bunColName := ExtractColumnFromBunTag(bunTag)
 			if bunColName != "" && strings.EqualFold(bunColName, normalizedFieldName) {
-				field = &f
-				break
+				return &f
 			}
 		}
 
@@ -833,8 +781,7 @@ func getRelationModelSingleLevel(model interface{}, fieldName string) interface{
 		if gormTag != "" {
 			gormColName := ExtractColumnFromGormTag(gormTag)
 			if gormColName != "" && strings.EqualFold(gormColName, normalizedFieldName) {
-				field = &f
-				break
+				return &f
 			}
 		}
 
@@ -844,40 +791,10 @@ func getRelationModelSingleLevel(model interface{}, fieldName string) interface{
 			parts := strings.Split(jsonTag, ",")
 			if len(parts) > 0 && parts[0] != "" && parts[0] != "-" {
 				if strings.EqualFold(parts[0], normalizedFieldName) {
-					field = &f
-					break
+					return &f
 				}
 			}
 		}
 	}
-
-	if field == nil {
-		return nil
-	}
-
-	// Get the target type
-	targetType := field.Type
-	if targetType == nil {
-		return nil
-	}
-
-	if targetType.Kind() == reflect.Slice {
-		targetType = targetType.Elem()
-		if targetType == nil {
-			return nil
-		}
-	}
-	if targetType.Kind() == reflect.Ptr {
-		targetType = targetType.Elem()
-		if targetType == nil {
-			return nil
-		}
-	}
-
-	if targetType.Kind() != reflect.Struct {
-		return nil
-	}
-
-	// Create a zero value of the target type
-	return reflect.New(targetType).Elem().Interface()
+	return nil
 }
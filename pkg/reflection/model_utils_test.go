@@ -1,6 +1,7 @@
 package reflection
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -308,6 +309,69 @@ func TestGetPrimaryKeyNameWithEmbedded(t *testing.T) {
 	}
 }
 
+type GormModelWithCompositeKey struct {
+	OrderID int    `gorm:"column:order_id;primaryKey" json:"order_id"`
+	LineNo  int    `gorm:"column:line_no;primaryKey" json:"line_no"`
+	Product string `json:"product"`
+}
+
+type BunModelWithCompositeKey struct {
+	TenantID string `bun:"tenant_id,pk" json:"tenant_id"`
+	Code     string `bun:"code,pk" json:"code"`
+}
+
+type ModelWithGetIDNames struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func (m ModelWithGetIDNames) GetIDNames() []string {
+	return []string{"b", "a"}
+}
+
+func TestGetPrimaryKeyNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    any
+		expected []string
+	}{
+		{
+			name:     "GORM composite key",
+			model:    GormModelWithCompositeKey{},
+			expected: []string{"order_id", "line_no"},
+		},
+		{
+			name:     "Bun composite key (pointer)",
+			model:    &BunModelWithCompositeKey{},
+			expected: []string{"tenant_id", "code"},
+		},
+		{
+			name:     "GetIDNames",
+			model:    ModelWithGetIDNames{},
+			expected: []string{"b", "a"},
+		},
+		{
+			name:     "Single key with GetIDName",
+			model:    GormModelWithGetIDName{},
+			expected: []string{"rid_test"},
+		},
+		{
+			name:     "Single key of embedded base",
+			model:    ModelWithEmbedded{},
+			expected: []string{"rid_base"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := GetPrimaryKeyNames(tt.model)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("GetPrimaryKeyNames() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestGetPrimaryKeyValueWithEmbedded(t *testing.T) {
 	bunModel := ModelWithEmbedded{
 		BaseModel: BaseModel{
@@ -614,3 +678,63 @@ func TestGetSQLModelColumnsVsGetModelColumns(t *testing.T) {
 		t.Errorf("GetModelColumns should include 'profile_data' (has json tag)")
 	}
 }
+
+func TestGetArrayElementKind(t *testing.T) {
+	type stringArray []string
+	type model struct {
+		Tags   stringArray `json:"tags"`
+		Sizes  *[]int64    `json:"sizes"`
+		Data   []byte      `json:"data"`
+		Name   string      `json:"name"`
+		Scores [3]float64  `json:"scores"`
+	}
+
+	tests := []struct {
+		column   string
+		wantKind reflect.Kind
+		wantOK   bool
+	}{
+		{"tags", reflect.String, true},
+		{"sizes", reflect.Int64, true},
+		{"scores", reflect.Float64, true},
+		{"data", reflect.Invalid, false},
+		{"name", reflect.Invalid, false},
+		{"missing", reflect.Invalid, false},
+	}
+	for _, tt := range tests {
+		kind, ok := GetArrayElementKind(model{}, tt.column)
+		if kind != tt.wantKind || ok != tt.wantOK {
+			t.Errorf("%s: got %v %v, want %v %v", tt.column, kind, ok, tt.wantKind, tt.wantOK)
+		}
+	}
+}
+
+type ModelWithUniqueColumns struct {
+	ID    int    `gorm:"column:id;primaryKey" json:"id"`
+	Email string `gorm:"column:email;uniqueIndex" json:"email"`
+	Code  string `bun:"code,unique" json:"code"`
+	Slug  string `bun:"slug,unique:slug_tenant" json:"slug"`
+	Name  string `gorm:"column:name" json:"name"`
+}
+
+func TestIsColumnUnique(t *testing.T) {
+	tests := []struct {
+		column   string
+		expected bool
+	}{
+		{"id", true},
+		{"email", true},
+		{"code", true},
+		{"slug", false},
+		{"name", false},
+		{"missing", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.column, func(t *testing.T) {
+			if result := IsColumnUnique(&ModelWithUniqueColumns{}, tt.column); result != tt.expected {
+				t.Errorf("IsColumnUnique(%q) = %v, want %v", tt.column, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package resolvespec
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// SetBulkInsertThreshold sets the number of records above which a batch create is loaded
+// with a single bulk insert (COPY FROM on PostgreSQL) when the database implements
+// common.BulkInserter. Bulk inserts don't return generated keys. 0 disables bulk inserts.
+func (h *Handler) SetBulkInsertThreshold(n int) {
+	h.bulkThreshold = n
+}
+
+// bulkInsertRows returns the columns and rows of a bulk insert of records keyed by column.
+// ok is false for batches up to the threshold, databases without bulk inserts, and records
+// with keys that are no columns or different keys.
+func (h *Handler) bulkInsertRows(records []interface{}, model interface{}) (columns []string, rows [][]interface{}, ok bool) {
+	if h.bulkThreshold <= 0 || len(records) <= h.bulkThreshold {
+		return nil, nil, false
+	}
+	if _, ok := h.db.(common.BulkInserter); !ok {
+		return nil, nil, false
+	}
+
+	maps := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		if maps[i], ok = record.(map[string]interface{}); !ok {
+			return nil, nil, false
+		}
+	}
+	modelColumns := make(map[string]bool)
+	for _, column := range reflection.GetSQLModelColumns(model) {
+		modelColumns[column] = true
+	}
+	return common.BulkInsertRows(maps, func(key string) (string, bool) {
+		return key, modelColumns[key]
+	})
+}
+
+// handleBulkCreate creates the records with a single bulk insert in a transaction and
+// answers with the records as sent
+func (h *Handler) handleBulkCreate(ctx context.Context, w common.ResponseWriter, hookCtx *HookContext, tableName string, records interface{}, columns []string, rows [][]interface{}) {
+	var created int64
+	err := h.db.RunInTransaction(ctx, func(tx common.Database) error {
+		bulk, ok := tx.(common.BulkInserter)
+		if !ok {
+			return fmt.Errorf("transaction %T has no bulk insert", tx)
+		}
+		var err error
+		created, err = bulk.BulkInsert(ctx, tableName, columns, rows)
+		return err
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error bulk inserting records: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "create_error", "Error creating records", err)
+		return
+	}
+	logger.InfoContext(ctx, "Successfully created %d records with a bulk insert", created)
+	h.sendAfterHook(w, AfterCreate, hookCtx, records, nil)
+}
@@ -0,0 +1,119 @@
+package resolvespec
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/tracing"
+)
+
+// handleDeleteWhere deletes all rows matching the filters of options with a single DELETE and
+// answers with the number of deleted rows. It needs the X-Confirm-Bulk header; a delete of more
+// rows than limit is rolled back. A dry run answers with the number of matching rows instead.
+func (h *Handler) handleDeleteWhere(ctx context.Context, w common.ResponseWriter, options common.RequestOptions, limit int, dryRun bool, confirm string) {
+	// Capture panics and return error response
+	defer func() {
+		if err := recover(); err != nil {
+			h.handlePanic(w, "handleDeleteWhere", err)
+		}
+	}()
+
+	ctx, span := tracing.StartSpan(ctx, "resolvespec.delete_where", tracing.AttrOperation.String("delete"))
+	defer span.End()
+
+	schema := GetSchema(ctx)
+	entity := GetEntity(ctx)
+	tableName := GetTableName(ctx)
+
+	logger.InfoContext(ctx, "Deleting records by filter from %s.%s", schema, entity)
+
+	if !dryRun {
+		if limitErr := common.ConfirmDelete(confirm); limitErr != nil {
+			h.rejectLimit(w, limitErr)
+			return
+		}
+	}
+
+	hookCtx := h.newHookContext(ctx, w, "", nil, options)
+	if !h.runBeforeHook(w, BeforeDelete, hookCtx) {
+		return
+	}
+
+	where, args, err := common.FilterWhere(options.Filters, options.FilterGroup, h.filterCondition)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid_filter", "Invalid filter", err)
+		return
+	}
+	if where == "" {
+		// Confirmed deletes of all rows still need a condition for the adapters
+		where = "1 = 1"
+	}
+
+	if dryRun {
+		matched, err := h.db.NewSelect().Table(tableName).Where(where, args...).Count(ctx)
+		if err != nil {
+			logger.ErrorContext(ctx, "Error counting records to delete: %v", err)
+			h.sendError(w, http.StatusInternalServerError, "query_error", "Error counting records", err)
+			return
+		}
+		h.sendResponse(w, map[string]interface{}{"dry_run": true, "matched": matched}, nil)
+		return
+	}
+
+	var deleted int64
+	var records []interface{}
+	err = h.db.RunInTransaction(ctx, func(tx common.Database) error {
+		if options.ReturnRecords {
+			loaded, err := common.LoadRecords(ctx, tx, GetModel(ctx), tableName, where, args...)
+			if err != nil {
+				return err
+			}
+			records = loaded
+		}
+
+		query := tx.NewDelete().Table(tableName).Where(where, args...)
+
+		hookCtx.Query = query
+		if err := h.hooks.Execute(BeforeScan, hookCtx); err != nil {
+			return fmt.Errorf("BeforeScan hook failed: %w", err)
+		}
+		if modifiedQuery, ok := hookCtx.Query.(common.DeleteQuery); ok {
+			query = modifiedQuery
+		}
+
+		started := time.Now()
+		result, err := query.Exec(ctx)
+		hookCtx.Duration = time.Since(started)
+		h.reportSlowQuery(ctx, "delete", options, hookCtx.Duration)
+		if err != nil {
+			return err
+		}
+		deleted = result.RowsAffected()
+		if limitErr := (common.WriteGuard{Limit: limit}).CheckRows(deleted); limitErr != nil {
+			return limitErr
+		}
+		if err := h.hooks.Execute(AfterScan, hookCtx); err != nil {
+			return fmt.Errorf("AfterScan hook failed: %w", err)
+		}
+		return nil
+	})
+	if h.rejectLimit(w, err) {
+		return
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error deleting records by filter: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "delete_error", "Error deleting records", err)
+		return
+	}
+
+	logger.InfoContext(ctx, "Successfully deleted %d records by filter", deleted)
+	result := map[string]interface{}{"deleted": deleted}
+	if options.ReturnRecords {
+		result["records"] = records
+	}
+	h.sendAfterHook(w, AfterDelete, hookCtx, result, nil)
+}
@@ -3,15 +3,23 @@ package resolvespec
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
 	"runtime/debug"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/hooks"
 	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/membudget"
+	"github.com/bitechdev/ResolveSpec/pkg/ratelimit"
 	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+	"github.com/bitechdev/ResolveSpec/pkg/tracing"
 )
 
 // Handler handles API requests using database and model abstractions
@@ -19,21 +27,157 @@ type Handler struct {
 	db              common.Database
 	registry        common.ModelRegistry
 	nestedProcessor *common.NestedCUDProcessor
+	plugins         *common.PluginManager
+	hooks           *HookRegistry
+	rateLimiter     *ratelimit.Limiter
+	bulkThreshold   int
+	config          common.HandlerConfig
+	log             logger.Interface
+	dryRun          *common.DryRunRecorder   // Set on the handler copy of a dry run request
+	tenant          *common.TenantScope      // Set on the handler copy of a request of a tenant
+	writeTx         bool                     // Set on the handler copy of a write request in a transaction
+	counts          *common.CountCache       // Totals of the entities with CountCached
+	tenantTables    *common.TenantTableCache // Tables with the tenant column, see scopedTables
+}
+
+// HandlerOption configures a Handler on creation
+type HandlerOption func(*Handler)
+
+// WithLogger makes the handler log through l; log lines of a request carry its request id
+func WithLogger(l logger.Interface) HandlerOption {
+	return func(h *Handler) {
+		h.log = l
+	}
 }
 
 // NewHandler creates a new API handler with database and registry abstractions
-func NewHandler(db common.Database, registry common.ModelRegistry) *Handler {
+func NewHandler(db common.Database, registry common.ModelRegistry, options ...HandlerOption) *Handler {
 	handler := &Handler{
-		db:       db,
-		registry: registry,
+		db:            db,
+		registry:      registry,
+		plugins:       common.NewPluginManager(),
+		hooks:         NewHookRegistry(),
+		bulkThreshold: common.DefaultBulkInsertThreshold,
+		counts:        common.NewCountCache(),
+		tenantTables:  common.NewTenantTableCache(),
 	}
 	// Initialize nested processor
 	handler.nestedProcessor = common.NewNestedCUDProcessor(db, registry, handler)
+	for _, option := range options {
+		option(handler)
+	}
+	return handler
+}
+
+// NewHandlerWithConfig creates a new API handler with pagination guardrails
+func NewHandlerWithConfig(db common.Database, registry common.ModelRegistry, config common.HandlerConfig, options ...HandlerOption) *Handler {
+	handler := NewHandler(db, registry, options...)
+	handler.config = config
 	return handler
 }
 
+// countCache returns the count cache shared through the config, or the one of the handler
+func (h *Handler) countCache() *common.CountCache {
+	if h.config.CountCache != nil {
+		return h.config.CountCache
+	}
+	return h.counts
+}
+
+// GetDatabase returns the database used by this handler
+func (h *Handler) GetDatabase() common.Database {
+	return h.db
+}
+
+// GetRegistry returns the model registry used by this handler
+func (h *Handler) GetRegistry() common.ModelRegistry {
+	return h.registry
+}
+
+// HealthHandler serves the database ping status, pool statistics and registered model count
+// as JSON, for liveness and readiness probes
+func (h *Handler) HealthHandler() http.Handler {
+	return common.HealthHandler(h.db, h.registry)
+}
+
+// Use loads plugins into this handler
+// Each plugin registers its types, is initialized and registers its hooks.
+// To load globally registered plugins use handler.Use(common.RegisteredPlugins()...)
+func (h *Handler) Use(plugins ...common.Plugin) error {
+	return h.plugins.Load(h, plugins...)
+}
+
+// Plugins returns the plugin manager for this handler
+func (h *Handler) Plugins() *common.PluginManager {
+	return h.plugins
+}
+
+// Hooks returns the hook registry of the handler
+// Use this to register hooks for the lifecycle of reads, creates, updates and deletes
+func (h *Handler) Hooks() *HookRegistry {
+	return h.hooks
+}
+
+// withRequestLogger puts the logger of a request in the context, adding the request id to
+// every log line of the request
+func (h *Handler) withRequestLogger(ctx context.Context) context.Context {
+	l := h.log
+	if l == nil {
+		l = logger.Default()
+	}
+	if requestID := common.RequestIDFromContext(ctx); requestID != "" {
+		l = logger.With(l, logger.F("request_id", requestID))
+	}
+	return logger.WithContext(ctx, l)
+}
+
+// SetRateLimiter limits requests per entity and user; nil disables rate limiting
+func (h *Handler) SetRateLimiter(limiter *ratelimit.Limiter) {
+	h.rateLimiter = limiter
+}
+
+// RateLimiter returns the rate limiter of this handler, or nil
+func (h *Handler) RateLimiter() *ratelimit.Limiter {
+	return h.rateLimiter
+}
+
+// checkRateLimit answers 429 with a Retry-After header and returns false when the
+// operation exceeds the rate limit
+func (h *Handler) checkRateLimit(ctx context.Context, w common.ResponseWriter, schema, entity, operation string) bool {
+	allowed, wait := h.rateLimiter.Allow(ctx, schema, entity, operation)
+	if allowed {
+		return true
+	}
+	logger.WarnContext(ctx, "Rate limit exceeded for %s on %s.%s", operation, schema, entity)
+	w.SetHeader("Retry-After", strconv.Itoa(ratelimit.RetryAfterSeconds(wait)))
+	h.sendError(w, http.StatusTooManyRequests, "rate_limited", "Rate limit exceeded", nil)
+	return false
+}
+
+// reportSlowQuery reports a query of the request entity that ran past the slow query threshold
+func (h *Handler) reportSlowQuery(ctx context.Context, operation string, options common.RequestOptions, duration time.Duration) {
+	h.config.ReportSlowQuery(ctx, common.SlowQuery{
+		Schema:    GetSchema(ctx),
+		Entity:    GetEntity(ctx),
+		Operation: operation,
+		Options:   common.NormalizeOptions(options),
+		Duration:  duration,
+	})
+}
+
+// abortResponse aborts a response that failed to write after its status was sent: the server
+// closes the connection, so clients see a broken response instead of a truncated body with a
+// success status. handlePanic passes http.ErrAbortHandler on to the server.
+func abortResponse(err error) {
+	logger.Error("Aborting response, failed to write it: %v", err)
+	panic(http.ErrAbortHandler)
+}
+
 // handlePanic is a helper function to handle panics with stack traces
 func (h *Handler) handlePanic(w common.ResponseWriter, method string, err interface{}) {
+	if err == http.ErrAbortHandler {
+		panic(err) // The response is aborted, see abortResponse
+	}
 	stack := debug.Stack()
 	logger.Error("Panic in %s: %v\nStack trace:\n%s", method, err, string(stack))
 	h.sendError(w, http.StatusInternalServerError, "internal_error", fmt.Sprintf("Internal server error in %s", method), fmt.Errorf("%v", err))
@@ -41,6 +185,31 @@ func (h *Handler) handlePanic(w common.ResponseWriter, method string, err interf
 
 // Handle processes API requests through router-agnostic interface
 func (h *Handler) Handle(w common.ResponseWriter, r common.Request, params map[string]string) {
+	// Responses are compressed by the writer of the router adapter, negotiated before any wrapper
+	defer h.config.CompressResponse(w, r)()
+
+	// X-Dry-Run runs the request on a copy of the handler that records its statements instead
+	if h.dryRun == nil && common.IsDryRun(r.Header(common.DryRunHeader)) {
+		if !h.config.DryRunAllowed(r.Context()) {
+			h.sendError(w, http.StatusForbidden, "dry_run_forbidden", "Dry runs are not allowed for this request", nil)
+			return
+		}
+		h.handleDryRun(w, r, params)
+		return
+	}
+
+	// With a tenant column the request runs on a copy of the handler scoped to its tenant
+	if h.tenant == nil && h.config.TenantColumn != "" {
+		h.handleTenant(w, r, params)
+		return
+	}
+
+	// With write transactions a write request runs on a copy of the handler in a transaction
+	if !h.writeTx && h.dryRun == nil && h.config.WriteTransactions && common.IsWriteRequest(r.Method()) {
+		h.handleWriteTransaction(w, r, params)
+		return
+	}
+
 	// Capture panics and return error response
 	defer func() {
 		if err := recover(); err != nil {
@@ -48,32 +217,76 @@ func (h *Handler) Handle(w common.ResponseWriter, r common.Request, params map[s
 		}
 	}()
 
-	ctx := context.Background()
+	// Every request gets an id, returned in X-Request-ID, error responses and log lines
+	requestID := common.RequestIDOf(r.Header(common.RequestIDHeader))
+	w = common.NewRequestIDWriter(w, requestID)
 
-	body, err := r.Body()
+	ctx, cancel, err := h.config.WithQueryTimeout(common.WithRequestID(r.Context(), requestID), r.Header(common.QueryTimeoutHeader))
+	defer cancel()
 	if err != nil {
-		logger.Error("Failed to read request body: %v", err)
+		h.sendError(w, http.StatusBadRequest, "invalid_timeout", "Invalid query timeout", err)
+		return
+	}
+	ctx = h.withRequestLogger(ctx)
+
+	body, err := h.config.ReadBody(r)
+	if h.rejectLimit(w, err) {
+		return
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to read request body: %v", err)
 		h.sendError(w, http.StatusBadRequest, "invalid_request", "Failed to read request body", err)
 		return
 	}
 
 	var req common.RequestBody
 	if err := json.Unmarshal(body, &req); err != nil {
-		logger.Error("Failed to decode request body: %v", err)
+		logger.ErrorContext(ctx, "Failed to decode request body: %v", err)
 		h.sendError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", err)
 		return
 	}
+	if limitErr := h.config.CheckBatchSize(req.Data); limitErr != nil {
+		h.rejectLimit(w, limitErr)
+		return
+	}
 
 	schema := params["schema"]
 	entity := params["entity"]
 	id := params["id"]
+	if id == "" {
+		// X-PK addresses records whose key can't be part of the URL
+		id = r.Header(common.PKHeader)
+	}
+
+	logger.InfoContext(ctx, "Handling %s operation for %s.%s", req.Operation, schema, entity)
+
+	ctx, span := tracing.StartSpan(ctx, "resolvespec.Handle",
+		tracing.AttrSchema.String(schema),
+		tracing.AttrEntity.String(entity),
+		tracing.AttrOperation.String(req.Operation),
+	)
+	defer span.End()
+
+	if !h.checkRateLimit(ctx, w, schema, entity, req.Operation) {
+		return
+	}
+
+	// Cross-entity transactions are not bound to the model of the route
+	if req.Operation == "transaction" {
+		h.handleTransaction(ctx, w, schema, req.Data)
+		return
+	}
 
-	logger.Info("Handling %s operation for %s.%s", req.Operation, schema, entity)
+	if accessErr := h.config.CheckEntityAccess(ctx, schema, entity, req.Operation); accessErr != nil {
+		logger.WarnContext(ctx, "Rejected %s on %s.%s: %s", req.Operation, schema, entity, accessErr.Message)
+		h.sendError(w, accessErr.Status, accessErr.Code, accessErr.Message, nil)
+		return
+	}
 
 	// Get model and populate context with request-scoped data
 	model, err := h.registry.GetModelByEntity(schema, entity)
 	if err != nil {
-		logger.Error("Invalid entity: %v", err)
+		logger.ErrorContext(ctx, "Invalid entity: %v", err)
 		h.sendError(w, http.StatusBadRequest, "invalid_entity", "Invalid entity", err)
 		return
 	}
@@ -86,7 +299,7 @@ func (h *Handler) Handle(w common.ResponseWriter, r common.Request, params map[s
 	}
 
 	if modelType == nil || modelType.Kind() != reflect.Struct {
-		logger.Error("Model for %s.%s must be a struct type, got %v. Please register models as struct types, not slices or pointers to slices.", schema, entity, originalType)
+		logger.ErrorContext(ctx, "Model for %s.%s must be a struct type, got %v. Please register models as struct types, not slices or pointers to slices.", schema, entity, originalType)
 		h.sendError(w, http.StatusInternalServerError, "invalid_model_type",
 			fmt.Sprintf("Model must be a struct type, got %v. Ensure you register the struct (e.g., ModelCoreAccount{}) not a slice (e.g., []*ModelCoreAccount)", originalType),
 			fmt.Errorf("invalid model type: %v", originalType))
@@ -104,28 +317,144 @@ func (h *Handler) Handle(w common.ResponseWriter, r common.Request, params map[s
 
 	// Add request-scoped data to context
 	ctx = WithRequestData(ctx, schema, entity, tableName, model, modelPtr)
+	span.SetAttributes(tracing.AttrTable.String(tableName))
 
 	// Validate and filter columns in options (log warnings for invalid columns)
 	validator := common.NewColumnValidator(model)
+	if req.Operation == "delete_where" {
+		// Dropping an invalid filter would delete more rows than asked for
+		if err := validator.ValidateFilters(req.Options.Filters, req.Options.FilterGroup); err != nil {
+			h.sendError(w, http.StatusBadRequest, "invalid_filter", "Invalid filter", err)
+			return
+		}
+	}
 	req.Options = validator.FilterRequestOptions(req.Options)
+	if limitErr := h.config.CheckQueryLimits(req.Options); limitErr != nil {
+		h.rejectLimit(w, limitErr)
+		return
+	}
+
+	if explain := r.Header(common.ExplainHeader); explain != "" {
+		req.Options.Explain = explain
+	}
+	if aggregates := r.Header(common.AggregateHeader); aggregates != "" {
+		req.Options.Aggregates = append(req.Options.Aggregates, common.ParseAggregates(aggregates)...)
+	}
+	if strings.EqualFold(r.Header(common.ReturnRecordsHeader), "true") {
+		req.Options.ReturnRecords = true
+	}
+	if lookupKey := r.Header(common.LookupKeyHeader); lookupKey != "" {
+		req.Options.LookupKey = lookupKey
+	}
+
+	// Lists without a sort or filters of their own use the defaults of the model
+	if req.Operation == "read" && id == "" && len(req.IDs) == 0 {
+		common.ApplyModelDefaults(model, &req.Options, r.Header(common.ScopeHeader) != "")
+	}
+
+	owner, accessErr := h.config.OwnerFilter(ctx, schema, entity, r.Header(common.OnlyMineHeader))
+	if accessErr != nil {
+		h.sendError(w, accessErr.Status, accessErr.Code, accessErr.Message, nil)
+		return
+	}
+	if owner != nil {
+		req.Options.FilterGroup = common.AndFilterGroups(req.Options.FilterGroup, *owner)
+	}
+
+	if scope := r.Header(common.ScopeHeader); scope != "" {
+		req.Options.Scopes = append(req.Options.Scopes, strings.Split(scope, ",")...)
+	}
+	scoped, err := common.ScopeFilter(model, req.Options.Scopes)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid_scope", "Invalid scope", err)
+		return
+	}
+	if scoped != nil {
+		req.Options.FilterGroup = common.AndFilterGroups(req.Options.FilterGroup, *scoped)
+	}
+
+	if req.Operation == "create" || req.Operation == "update" || req.Operation == "update_where" {
+		if !h.prepareWrite(ctx, w, schema, entity, id, req.Operation != "create", req.Data, model) {
+			return
+		}
+	}
 
 	switch req.Operation {
 	case "read":
-		h.handleRead(ctx, w, id, req.Options)
+		if len(req.IDs) > common.MaxBatchReadIDs {
+			h.sendError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("A read is limited to %d ids", common.MaxBatchReadIDs), nil)
+			return
+		}
+		h.handleRead(ctx, w, id, req.IDs, req.Options)
 	case "create":
 		h.handleCreate(ctx, w, req.Data, req.Options)
 	case "update":
 		h.handleUpdate(ctx, w, id, req.ID, req.Data, req.Options)
+	case "update_where":
+		h.handleUpdateWhere(ctx, w, req.Data, req.Options, common.WriteGuard{Limit: req.WriteLimit, All: req.WriteAll})
 	case "delete":
-		h.handleDelete(ctx, w, id, req.Data)
+		h.handleDelete(ctx, w, id, req.Data, req.Options)
+	case "delete_where":
+		h.handleDeleteWhere(ctx, w, req.Options, req.WriteLimit, req.DryRun, r.Header(common.ConfirmBulkHeader))
+	case "batch":
+		h.handleBatch(ctx, w, req.Data)
 	default:
-		logger.Error("Invalid operation: %s", req.Operation)
+		logger.ErrorContext(ctx, "Invalid operation: %s", req.Operation)
 		h.sendError(w, http.StatusBadRequest, "invalid_operation", "Invalid operation", nil)
 	}
 }
 
+// rejectLimit answers a request exceeding a request limit. It returns false for other errors.
+func (h *Handler) rejectLimit(w common.ResponseWriter, err error) bool {
+	var limitErr *common.RequestLimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+	logger.Warn("Rejected request: %s", limitErr.Message)
+	h.sendError(w, limitErr.Status, limitErr.Code, limitErr.Message, nil)
+	return true
+}
+
+// prepareWrite deserializes the payload of a create or update, checks it against the entity
+// overrides and removes its read-only columns, listing them in the X-Write-Warnings header,
+// before the audit and tenant columns are stamped. It returns false when the payload was
+// rejected.
+func (h *Handler) prepareWrite(ctx context.Context, w common.ResponseWriter, schema, entity, id string, update bool, data, model interface{}) bool {
+	if err := h.config.Deserialize(ctx, schema, entity, data); err != nil {
+		logger.WarnContext(ctx, "Invalid data for %s.%s: %v", schema, entity, err)
+		h.sendError(w, http.StatusBadRequest, "invalid_data", "Invalid data", err)
+		return false
+	}
+	if accessErr := h.config.CheckNestedCUD(schema, entity, data, model, h); accessErr != nil {
+		h.sendError(w, accessErr.Status, accessErr.Code, accessErr.Message, nil)
+		return false
+	}
+	warnings, err := common.SanitizeWrite(model, data, update, id, h.config.ProtectedColumns)
+	if err != nil {
+		logger.WarnContext(ctx, "Rejected write on %s.%s: %v", schema, entity, err)
+		h.sendError(w, http.StatusUnprocessableEntity, common.ProtectedColumn, "Protected column", err)
+		return false
+	}
+	if len(warnings) > 0 {
+		logger.WarnContext(ctx, "Ignored columns of write on %s.%s: %s", schema, entity, strings.Join(warnings, "; "))
+		w.SetHeader(common.WriteWarningsHeader, strings.Join(warnings, "; "))
+	}
+	if !update {
+		if err := h.config.GenerateIDs(ctx, h.db, schema, entity, model, data); err != nil {
+			logger.ErrorContext(ctx, "Failed to generate ids for %s.%s: %v", schema, entity, err)
+			h.sendError(w, http.StatusInternalServerError, "id_generation_error", "Error generating ids", err)
+			return false
+		}
+	}
+	h.config.StampAudit(ctx, model, data, !update)
+	h.tenant.Stamp(model, data)
+	return true
+}
+
 // HandleGet processes GET requests for metadata
 func (h *Handler) HandleGet(w common.ResponseWriter, r common.Request, params map[string]string) {
+	defer h.config.CompressResponse(w, r)()
+
 	// Capture panics and return error response
 	defer func() {
 		if err := recover(); err != nil {
@@ -146,10 +475,21 @@ func (h *Handler) HandleGet(w common.ResponseWriter, r common.Request, params ma
 	}
 
 	metadata := h.generateMetadata(schema, entity, model)
+	metadata.Scopes = common.ScopeNames(model)
+	if h.config.IntrospectSchema {
+		tableSchema, table := h.getSchemaAndTable(schema, entity, model)
+		if err := common.IntrospectMetadata(r.Context(), h.db, tableSchema, table, metadata); err != nil {
+			logger.Warn("Failed to introspect %s.%s: %v", schema, entity, err)
+		}
+	}
+	if err := h.config.DescribeMetadata(r.Context(), metadata); err != nil {
+		logger.Warn("Failed to describe columns of %s.%s: %v", schema, entity, err)
+	}
 	h.sendResponse(w, metadata, nil)
 }
 
-func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id string, options common.RequestOptions) {
+// handleRead reads the record of id, the records of ids in their order, or the records matching the options
+func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id string, ids common.IDList, options common.RequestOptions) {
 	// Capture panics and return error response
 	defer func() {
 		if err := recover(); err != nil {
@@ -157,11 +497,28 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 		}
 	}()
 
+	ctx, span := tracing.StartSpan(ctx, "resolvespec.read", tracing.AttrOperation.String("read"),
+		tracing.AttrFilterCount.Int(len(options.Filters)),
+		tracing.AttrPreloadCount.Int(len(options.Preload)),
+	)
+	defer span.End()
+
+	// Query plans are limited to requests the AllowExplain guardrail allows
+	explain, analyze := common.ParseExplain(options.Explain)
+	if explain && !h.config.ExplainAllowed(ctx) {
+		h.sendError(w, http.StatusForbidden, "explain_forbidden", "Query plans are not allowed for this request", nil)
+		return
+	}
+
 	schema := GetSchema(ctx)
 	entity := GetEntity(ctx)
 	tableName := GetTableName(ctx)
 	model := GetModel(ctx)
 
+	// Track result sizes and allocations (only active in memdebug builds)
+	ctx, memTracker := membudget.Start(ctx, fmt.Sprintf("resolvespec.read %s.%s", schema, entity))
+	defer memTracker.Finish()
+
 	// Validate and unwrap model type to get base struct
 	modelType := reflect.TypeOf(model)
 	for modelType != nil && (modelType.Kind() == reflect.Ptr || modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Array) {
@@ -169,12 +526,18 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 	}
 
 	if modelType == nil || modelType.Kind() != reflect.Struct {
-		logger.Error("Model must be a struct type, got %v for %s.%s", modelType, schema, entity)
+		logger.ErrorContext(ctx, "Model must be a struct type, got %v for %s.%s", modelType, schema, entity)
 		h.sendError(w, http.StatusInternalServerError, "invalid_model", "Model must be a struct type", fmt.Errorf("invalid model type: %v", modelType))
 		return
 	}
 
-	logger.Info("Reading records from %s.%s", schema, entity)
+	hookCtx := h.newHookContext(ctx, w, id, nil, options)
+	if !h.runBeforeHook(w, BeforeRead, hookCtx) {
+		return
+	}
+	options = hookCtx.Options
+
+	logger.InfoContext(ctx, "Reading records from %s.%s", schema, entity)
 
 	// Create the model pointer for Scan() operations
 	sliceType := reflect.SliceOf(reflect.PointerTo(modelType))
@@ -191,14 +554,34 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 		query = query.Table(tableName)
 	}
 
+	// Omitted columns are left out of the SELECT list instead of only the response
+	options.Columns = common.SelectColumns(model, options.Columns, options.OmitColumns)
+
+	// Aggregates are computed over the selected columns of the rows the read matches
+	var aggregates []common.AggregateOption
+	if id == "" && len(options.Aggregates) > 0 {
+		columns := append([]string{}, options.Columns...)
+		if len(columns) == 0 {
+			columns = reflection.GetSQLModelColumns(model)
+		}
+		for _, computed := range options.ComputedColumns {
+			columns = append(columns, computed.Name)
+		}
+		var err error
+		if aggregates, err = common.ValidateAggregates(options.Aggregates, columns); err != nil {
+			h.sendError(w, http.StatusBadRequest, "invalid_aggregate", err.Error(), err)
+			return
+		}
+	}
+
 	if len(options.Columns) == 0 && (len(options.ComputedColumns) > 0) {
-		logger.Debug("Populating options.Columns with all model columns since computed columns are additions")
+		logger.DebugContext(ctx, "Populating options.Columns with all model columns since computed columns are additions")
 		options.Columns = reflection.GetSQLModelColumns(model)
 	}
 
 	// Apply column selection
 	if len(options.Columns) > 0 {
-		logger.Debug("Selecting columns: %v", options.Columns)
+		logger.DebugContext(ctx, "Selecting columns: %v", options.Columns)
 		for _, col := range options.Columns {
 			query = query.Column(reflection.ExtractSourceColumn(col))
 		}
@@ -206,7 +589,7 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 
 	if len(options.ComputedColumns) > 0 {
 		for _, cu := range options.ComputedColumns {
-			logger.Debug("Applying computed column: %s", cu.Name)
+			logger.DebugContext(ctx, "Applying computed column: %s", cu.Name)
 			query = query.ColumnExpr(fmt.Sprintf("(%s) AS %s", cu.Expression, cu.Name))
 		}
 	}
@@ -218,65 +601,164 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 
 	// Apply filters
 	for _, filter := range options.Filters {
-		logger.Debug("Applying filter: %s %s %v", filter.Column, filter.Operator, filter.Value)
+		logger.DebugContext(ctx, "Applying filter: %s %s %v", filter.Column, filter.Operator, filter.Value)
 		query = h.applyFilter(query, filter)
 	}
 
-	// Apply sorting
+	// Apply nested filter group as a single parenthesized condition
+	if !options.FilterGroup.IsEmpty() {
+		groupSQL, groupArgs := options.FilterGroup.ToSQL(h.filterCondition)
+		if groupSQL != "" {
+			logger.DebugContext(ctx, "Applying filter group: %s", groupSQL)
+			query = query.Where(groupSQL, groupArgs...)
+		}
+	}
+
+	// Read by id list with a single IN query
+	if len(ids) > 0 {
+		pkName := reflection.GetPrimaryKeyName(tempInstance)
+		logger.DebugContext(ctx, "Reading %d records by %s", len(ids), pkName)
+		query = query.Where(fmt.Sprintf("%s IN (?)", common.QuoteIdent(pkName)), ids.Unique())
+	}
+
+	// Apply sorting, ending with the primary key so pages are deterministic
+	options.Sort = common.WithPrimaryKeySort(options.Sort, reflection.GetPrimaryKeyNames(tempInstance))
 	for _, sort := range options.Sort {
 		direction := "ASC"
 		if strings.EqualFold(sort.Direction, "desc") {
 			direction = "DESC"
 		}
-		logger.Debug("Applying sort: %s %s", sort.Column, direction)
+		logger.DebugContext(ctx, "Applying sort: %s %s", sort.Column, direction)
 		query = query.Order(fmt.Sprintf("%s %s", sort.Column, direction))
 	}
 
-	// Get total count before pagination
-	total, err := query.Count(ctx)
+	// Keep list reads within the limit guardrails
+	if id == "" && len(ids) == 0 {
+		limit, offset, err := h.config.ForEntity(schema, entity).ApplyLimits(options.Limit, options.Offset)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, "invalid_limit", err.Error(), err)
+			return
+		}
+		options.Limit, options.Offset = limit, offset
+	}
+
+	// Get total count before pagination, unless the entity skips counts
+	total := -1
+	estimated := false
+	if entityConfig := h.config.Entity(schema, entity); !entityConfig.SkipCount {
+		count, isEstimate, err := common.CountRows(ctx, h.db, query, schema, entity, entityConfig, h.countCache())
+		if err != nil {
+			logger.ErrorContext(ctx, "Error counting records: %v", err)
+			h.sendError(w, http.StatusInternalServerError, "query_error", "Error counting records", err)
+			return
+		}
+		total, estimated = count, isEstimate
+		logger.DebugContext(ctx, "Total records before filtering: %d", total)
+	}
+
+	totals, err := common.Aggregate(ctx, h.db, query, aggregates)
 	if err != nil {
-		logger.Error("Error counting records: %v", err)
-		h.sendError(w, http.StatusInternalServerError, "query_error", "Error counting records", err)
+		logger.ErrorContext(ctx, "Error computing aggregates: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "query_error", "Error computing aggregates", err)
 		return
 	}
-	logger.Debug("Total records before filtering: %d", total)
 
-	// Apply pagination
-	if options.Limit != nil && *options.Limit > 0 {
-		logger.Debug("Applying limit: %d", *options.Limit)
+	// Apply pagination, except on reads by id list which return every id
+	if options.Limit != nil && *options.Limit > 0 && len(ids) == 0 {
+		logger.DebugContext(ctx, "Applying limit: %d", *options.Limit)
 		query = query.Limit(*options.Limit)
 	}
-	if options.Offset != nil && *options.Offset > 0 {
-		logger.Debug("Applying offset: %d", *options.Offset)
+	if options.Offset != nil && *options.Offset > 0 && len(ids) == 0 {
+		logger.DebugContext(ctx, "Applying offset: %d", *options.Offset)
 		query = query.Offset(*options.Offset)
 	}
 
+	// For single record, create a new pointer to the struct type
+	var singleResult interface{}
+	if id != "" {
+		singleResult = reflect.New(modelType).Interface()
+		where, args, err := common.LookupCondition(singleResult, options.LookupKey, id)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, "invalid_id", "Invalid id", err)
+			return
+		}
+		query = query.Where(where, args...)
+	}
+
+	hookCtx.Query = query
+	if !h.runBeforeHook(w, BeforeScan, hookCtx) {
+		return
+	}
+	if modifiedQuery, ok := hookCtx.Query.(common.SelectQuery); ok {
+		query = modifiedQuery
+	}
+
+	var plan *common.ExplainMetadata
+	if explain {
+		var err error
+		if plan, err = common.Explain(ctx, h.db, query, analyze); err != nil {
+			logger.ErrorContext(ctx, "Error explaining query: %v", err)
+			h.sendError(w, http.StatusBadRequest, "explain_error", "Error explaining query", err)
+			return
+		}
+	}
+
 	// Execute query
 	var result interface{}
+	started := time.Now()
 	if id != "" {
-		logger.Debug("Querying single record with ID: %s", id)
-		// For single record, create a new pointer to the struct type
-		singleResult := reflect.New(modelType).Interface()
-
-		query = query.Where(fmt.Sprintf("%s = ?", common.QuoteIdent(reflection.GetPrimaryKeyName(singleResult))), id)
+		logger.DebugContext(ctx, "Querying single record with ID: %s", id)
 		if err := query.Scan(ctx, singleResult); err != nil {
-			logger.Error("Error querying record: %v", err)
+			logger.ErrorContext(ctx, "Error querying record: %v", err)
 			h.sendError(w, http.StatusInternalServerError, "query_error", "Error executing query", err)
 			return
 		}
 		result = singleResult
+		for _, preload := range options.Preload {
+			common.ApplyPreloadPagination(singleResult, preload.Relation, preload.Offset, preload.Limit)
+		}
 	} else {
-		logger.Debug("Querying multiple records")
+		logger.DebugContext(ctx, "Querying multiple records")
 		// Use the modelPtr already created and set on the query
 		if err := query.Scan(ctx, modelPtr); err != nil {
-			logger.Error("Error querying records: %v", err)
+			logger.ErrorContext(ctx, "Error querying records: %v", err)
 			h.sendError(w, http.StatusInternalServerError, "query_error", "Error executing query", err)
 			return
 		}
+		for _, preload := range options.Preload {
+			common.ApplyPreloadPagination(modelPtr, preload.Relation, preload.Offset, preload.Limit)
+		}
 		result = reflect.ValueOf(modelPtr).Elem().Interface()
+		if len(ids) > 0 {
+			result = common.OrderByIDs(result, ids)
+		}
+	}
+
+	hookCtx.Duration = time.Since(started)
+	h.reportSlowQuery(ctx, "read", options, hookCtx.Duration)
+	if err := h.hooks.Execute(AfterScan, hookCtx); err != nil {
+		logger.ErrorContext(ctx, "AfterScan hook failed: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "hook_error", "Hook execution failed", err)
+		return
+	}
+
+	if memTracker != nil {
+		relations := make([]string, 0, len(options.Preload))
+		for _, preload := range options.Preload {
+			relations = append(relations, preload.Relation)
+		}
+		memTracker.RecordResult(result, relations...)
 	}
 
-	logger.Info("Successfully retrieved records")
+	logger.InfoContext(ctx, "Successfully retrieved records")
+	rowCount := 1
+	if id == "" {
+		rowCount = reflection.Len(result)
+	}
+	span.SetAttributes(
+		tracing.AttrTotalCount.Int(total),
+		tracing.AttrRowCount.Int(rowCount),
+	)
 
 	limit := 0
 	if options.Limit != nil {
@@ -287,11 +769,14 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 		offset = *options.Offset
 	}
 
-	h.sendResponse(w, result, &common.Metadata{
-		Total:    int64(total),
-		Filtered: int64(total),
-		Limit:    limit,
-		Offset:   offset,
+	h.sendAfterHook(w, AfterRead, hookCtx, result, &common.Metadata{
+		Total:      int64(total),
+		Filtered:   int64(total),
+		Estimated:  estimated,
+		Limit:      limit,
+		Offset:     offset,
+		Explain:    plan,
+		Aggregates: totals,
 	})
 }
 
@@ -303,27 +788,42 @@ func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, dat
 		}
 	}()
 
+	ctx, span := tracing.StartSpan(ctx, "resolvespec.create", tracing.AttrOperation.String("create"))
+	defer span.End()
+
 	schema := GetSchema(ctx)
 	entity := GetEntity(ctx)
 	tableName := GetTableName(ctx)
 	model := GetModel(ctx)
 
-	logger.Info("Creating records for %s.%s", schema, entity)
+	logger.InfoContext(ctx, "Creating records for %s.%s", schema, entity)
+
+	hookCtx := h.newHookContext(ctx, w, "", data, options)
+	if !h.runBeforeHook(w, BeforeCreate, hookCtx) {
+		return
+	}
+	data = hookCtx.Data
+
+	if err := common.ValidateEnums(model, data); err != nil {
+		logger.WarnContext(ctx, "Invalid data for %s.%s: %v", schema, entity, err)
+		h.sendError(w, http.StatusUnprocessableEntity, common.InvalidEnumValue, "Invalid value", err)
+		return
+	}
 
 	// Check if data contains nested relations or _request field
 	switch v := data.(type) {
 	case map[string]interface{}:
 		// Check if we should use nested processing
 		if h.shouldUseNestedProcessor(v, model) {
-			logger.Info("Using nested CUD processor for create operation")
+			logger.InfoContext(ctx, "Using nested CUD processor for create operation")
 			result, err := h.nestedProcessor.ProcessNestedCUD(ctx, "insert", v, model, make(map[string]interface{}), tableName)
 			if err != nil {
-				logger.Error("Error in nested create: %v", err)
+				logger.ErrorContext(ctx, "Error in nested create: %v", err)
 				h.sendError(w, http.StatusInternalServerError, "create_error", "Error creating record with nested data", err)
 				return
 			}
-			logger.Info("Successfully created record with nested data, ID: %v", result.ID)
-			h.sendResponse(w, result.Data, nil)
+			logger.InfoContext(ctx, "Successfully created record with nested data, ID: %v", result.ID)
+			h.sendAfterHook(w, AfterCreate, hookCtx, result.Data, nil)
 			return
 		}
 
@@ -332,14 +832,14 @@ func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, dat
 		for key, value := range v {
 			query = query.Value(key, value)
 		}
-		result, err := query.Exec(ctx)
+		result, err := query.Returning("*").Exec(ctx)
 		if err != nil {
-			logger.Error("Error creating record: %v", err)
+			logger.ErrorContext(ctx, "Error creating record: %v", err)
 			h.sendError(w, http.StatusInternalServerError, "create_error", "Error creating record", err)
 			return
 		}
-		logger.Info("Successfully created record, rows affected: %d", result.RowsAffected())
-		h.sendResponse(w, v, nil)
+		logger.InfoContext(ctx, "Successfully created record, rows affected: %d", result.RowsAffected())
+		h.sendAfterHook(w, AfterCreate, hookCtx, common.ReturnedRecord(v, result), nil)
 
 	case []map[string]interface{}:
 		// Check if any item needs nested processing
@@ -352,7 +852,7 @@ func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, dat
 		}
 
 		if hasNestedData {
-			logger.Info("Using nested CUD processor for batch create with nested data")
+			logger.InfoContext(ctx, "Using nested CUD processor for batch create with nested data")
 			results := make([]map[string]interface{}, 0, len(v))
 			err := h.db.RunInTransaction(ctx, func(tx common.Database) error {
 				// Temporarily swap the database to use transaction
@@ -372,35 +872,48 @@ func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, dat
 				return nil
 			})
 			if err != nil {
-				logger.Error("Error creating records with nested data: %v", err)
+				logger.ErrorContext(ctx, "Error creating records with nested data: %v", err)
 				h.sendError(w, http.StatusInternalServerError, "create_error", "Error creating records with nested data", err)
 				return
 			}
-			logger.Info("Successfully created %d records with nested data", len(results))
-			h.sendResponse(w, results, nil)
+			logger.InfoContext(ctx, "Successfully created %d records with nested data", len(results))
+			h.sendAfterHook(w, AfterCreate, hookCtx, results, nil)
+			return
+		}
+
+		// Large batches are loaded with a single bulk insert
+		records := make([]interface{}, len(v))
+		for i, item := range v {
+			records[i] = item
+		}
+		if columns, rows, ok := h.bulkInsertRows(records, model); ok {
+			h.handleBulkCreate(ctx, w, hookCtx, tableName, v, columns, rows)
 			return
 		}
 
 		// Standard batch insert without nested relations
+		list := make([]map[string]interface{}, 0, len(v))
 		err := h.db.RunInTransaction(ctx, func(tx common.Database) error {
 			for _, item := range v {
 				txQuery := tx.NewInsert().Table(tableName)
 				for key, value := range item {
 					txQuery = txQuery.Value(key, value)
 				}
-				if _, err := txQuery.Exec(ctx); err != nil {
+				result, err := txQuery.Returning("*").Exec(ctx)
+				if err != nil {
 					return err
 				}
+				list = append(list, common.ReturnedRecord(item, result))
 			}
 			return nil
 		})
 		if err != nil {
-			logger.Error("Error creating records: %v", err)
+			logger.ErrorContext(ctx, "Error creating records: %v", err)
 			h.sendError(w, http.StatusInternalServerError, "create_error", "Error creating records", err)
 			return
 		}
-		logger.Info("Successfully created %d records", len(v))
-		h.sendResponse(w, v, nil)
+		logger.InfoContext(ctx, "Successfully created %d records", len(v))
+		h.sendAfterHook(w, AfterCreate, hookCtx, list, nil)
 
 	case []interface{}:
 		// Handle []interface{} type from JSON unmarshaling
@@ -416,7 +929,7 @@ func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, dat
 		}
 
 		if hasNestedData {
-			logger.Info("Using nested CUD processor for batch create with nested data ([]interface{})")
+			logger.InfoContext(ctx, "Using nested CUD processor for batch create with nested data ([]interface{})")
 			results := make([]interface{}, 0, len(v))
 			err := h.db.RunInTransaction(ctx, func(tx common.Database) error {
 				// Temporarily swap the database to use transaction
@@ -438,12 +951,18 @@ func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, dat
 				return nil
 			})
 			if err != nil {
-				logger.Error("Error creating records with nested data: %v", err)
+				logger.ErrorContext(ctx, "Error creating records with nested data: %v", err)
 				h.sendError(w, http.StatusInternalServerError, "create_error", "Error creating records with nested data", err)
 				return
 			}
-			logger.Info("Successfully created %d records with nested data", len(results))
-			h.sendResponse(w, results, nil)
+			logger.InfoContext(ctx, "Successfully created %d records with nested data", len(results))
+			h.sendAfterHook(w, AfterCreate, hookCtx, results, nil)
+			return
+		}
+
+		// Large batches are loaded with a single bulk insert
+		if columns, rows, ok := h.bulkInsertRows(v, model); ok {
+			h.handleBulkCreate(ctx, w, hookCtx, tableName, v, columns, rows)
 			return
 		}
 
@@ -456,24 +975,25 @@ func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, dat
 					for key, value := range itemMap {
 						txQuery = txQuery.Value(key, value)
 					}
-					if _, err := txQuery.Exec(ctx); err != nil {
+					result, err := txQuery.Returning("*").Exec(ctx)
+					if err != nil {
 						return err
 					}
-					list = append(list, item)
+					list = append(list, common.ReturnedRecord(itemMap, result))
 				}
 			}
 			return nil
 		})
 		if err != nil {
-			logger.Error("Error creating records: %v", err)
+			logger.ErrorContext(ctx, "Error creating records: %v", err)
 			h.sendError(w, http.StatusInternalServerError, "create_error", "Error creating records", err)
 			return
 		}
-		logger.Info("Successfully created %d records", len(v))
-		h.sendResponse(w, list, nil)
+		logger.InfoContext(ctx, "Successfully created %d records", len(v))
+		h.sendAfterHook(w, AfterCreate, hookCtx, list, nil)
 
 	default:
-		logger.Error("Invalid data type for create operation: %T", data)
+		logger.ErrorContext(ctx, "Invalid data type for create operation: %T", data)
 		h.sendError(w, http.StatusBadRequest, "invalid_data", "Invalid data type for create operation", nil)
 	}
 }
@@ -486,77 +1006,155 @@ func (h *Handler) handleUpdate(ctx context.Context, w common.ResponseWriter, url
 		}
 	}()
 
+	ctx, span := tracing.StartSpan(ctx, "resolvespec.update", tracing.AttrOperation.String("update"))
+	defer span.End()
+
 	schema := GetSchema(ctx)
 	entity := GetEntity(ctx)
 	tableName := GetTableName(ctx)
 	model := GetModel(ctx)
 
-	logger.Info("Updating records for %s.%s", schema, entity)
+	logger.InfoContext(ctx, "Updating records for %s.%s", schema, entity)
+
+	hookCtx := h.newHookContext(ctx, w, urlID, data, options)
+	if !h.runBeforeHook(w, BeforeUpdate, hookCtx) {
+		return
+	}
+	data = hookCtx.Data
+
+	if err := common.ValidateEnums(model, data); err != nil {
+		logger.WarnContext(ctx, "Invalid data for %s.%s: %v", schema, entity, err)
+		h.sendError(w, http.StatusUnprocessableEntity, common.InvalidEnumValue, "Invalid value", err)
+		return
+	}
+
+	// The id of the request arrives as *int64, use it like an id of the URL
+	if id, ok := reqID.(*int64); ok {
+		if id != nil {
+			reqID = strconv.FormatInt(*id, 10)
+		} else {
+			reqID = nil
+		}
+	}
 
 	switch updates := data.(type) {
 	case map[string]interface{}:
 		// Determine the ID to use
+		pkName := reflection.GetPrimaryKeyName(model)
 		var targetID interface{}
 		switch {
 		case urlID != "":
 			targetID = urlID
 		case reqID != nil:
 			targetID = reqID
-		case updates["id"] != nil:
-			targetID = updates["id"]
+		case updates[pkName] != nil:
+			targetID = updates[pkName]
 		}
 
 		// Check if we should use nested processing
 		if h.shouldUseNestedProcessor(updates, model) {
-			logger.Info("Using nested CUD processor for update operation")
+			logger.InfoContext(ctx, "Using nested CUD processor for update operation")
 			// Ensure ID is in the data map
 			if targetID != nil {
-				updates["id"] = targetID
+				updates[pkName] = targetID
 			}
 			result, err := h.nestedProcessor.ProcessNestedCUD(ctx, "update", updates, model, make(map[string]interface{}), tableName)
 			if err != nil {
-				logger.Error("Error in nested update: %v", err)
+				logger.ErrorContext(ctx, "Error in nested update: %v", err)
 				h.sendError(w, http.StatusInternalServerError, "update_error", "Error updating record with nested data", err)
 				return
 			}
-			logger.Info("Successfully updated record with nested data, rows: %d", result.AffectedRows)
-			h.sendResponse(w, result.Data, nil)
+			logger.InfoContext(ctx, "Successfully updated record with nested data, rows: %d", result.AffectedRows)
+			h.sendAfterHook(w, AfterUpdate, hookCtx, result.Data, nil)
 			return
 		}
 
+		// Minimal update of a single record: only set the columns whose values differ
+		recordID, single := reqID.(string)
+		if urlID != "" {
+			recordID, single = urlID, true
+		} else if reqID == nil {
+			// The key columns of the data address the record
+			recordID, single = common.RecordKey(model, updates)
+		}
+		var keyWhere string
+		var keyArgs []interface{}
+		if single {
+			var err error
+			if keyWhere, keyArgs, err = common.KeyCondition(model, recordID); err != nil {
+				h.sendError(w, http.StatusBadRequest, "invalid_id", "Invalid id", err)
+				return
+			}
+		}
+		if h.config.MinimalUpdates && single {
+			record, found, err := common.LoadRecord(ctx, h.db, model, recordID)
+			if err != nil {
+				logger.ErrorContext(ctx, "Error loading record to update: %v", err)
+				h.sendError(w, http.StatusInternalServerError, "update_error", "Error updating record(s)", err)
+				return
+			}
+			if !found {
+				h.sendError(w, http.StatusNotFound, "not_found", "No records found to update", nil)
+				return
+			}
+			changed, changes, err := common.ChangedColumns(record, updates, pkName)
+			if err != nil {
+				logger.ErrorContext(ctx, "Error comparing record to update: %v", err)
+				h.sendError(w, http.StatusInternalServerError, "update_error", "Error updating record(s)", err)
+				return
+			}
+			w.SetHeader(common.ChangedColumnsHeader, strings.Join(changed, ","))
+			if len(changed) == 0 {
+				logger.InfoContext(ctx, "Record %s is unchanged, skipping the update", recordID)
+				h.sendAfterHook(w, AfterUpdate, hookCtx, data, nil)
+				return
+			}
+			updates = changes
+		}
+
 		// Standard processing without nested relations
 		query := h.db.NewUpdate().Table(tableName).SetMap(updates)
 
 		// Apply conditions
-		if urlID != "" {
-			logger.Debug("Updating by URL ID: %s", urlID)
-			query = query.Where(fmt.Sprintf("%s = ?", common.QuoteIdent(reflection.GetPrimaryKeyName(model))), urlID)
-		} else if reqID != nil {
-			switch id := reqID.(type) {
-			case string:
-				logger.Debug("Updating by request ID: %s", id)
-				query = query.Where(fmt.Sprintf("%s = ?", common.QuoteIdent(reflection.GetPrimaryKeyName(model))), id)
-			case []string:
-				logger.Debug("Updating by multiple IDs: %v", id)
-				query = query.Where(fmt.Sprintf("%s IN (?)", common.QuoteIdent(reflection.GetPrimaryKeyName(model))), id)
-			}
+		if single {
+			logger.DebugContext(ctx, "Updating by ID: %s", recordID)
+			query = query.Where(keyWhere, keyArgs...)
+		} else if ids, ok := reqID.([]string); ok {
+			logger.DebugContext(ctx, "Updating by multiple IDs: %v", ids)
+			query = query.Where(fmt.Sprintf("%s IN (?)", common.QuoteIdent(pkName)), ids)
+		}
+
+		hookCtx.Query = query
+		if !h.runBeforeHook(w, BeforeScan, hookCtx) {
+			return
+		}
+		if modifiedQuery, ok := hookCtx.Query.(common.UpdateQuery); ok {
+			query = modifiedQuery
 		}
 
+		started := time.Now()
 		result, err := query.Exec(ctx)
+		hookCtx.Duration = time.Since(started)
+		h.reportSlowQuery(ctx, "update", options, hookCtx.Duration)
 		if err != nil {
-			logger.Error("Update error: %v", err)
+			logger.ErrorContext(ctx, "Update error: %v", err)
 			h.sendError(w, http.StatusInternalServerError, "update_error", "Error updating record(s)", err)
 			return
 		}
 
 		if result.RowsAffected() == 0 {
-			logger.Warn("No records found to update")
+			logger.WarnContext(ctx, "No records found to update")
 			h.sendError(w, http.StatusNotFound, "not_found", "No records found to update", nil)
 			return
 		}
+		if err := h.hooks.Execute(AfterScan, hookCtx); err != nil {
+			logger.ErrorContext(ctx, "AfterScan hook failed: %v", err)
+			h.sendError(w, http.StatusInternalServerError, "hook_error", "Hook execution failed", err)
+			return
+		}
 
-		logger.Info("Successfully updated %d records", result.RowsAffected())
-		h.sendResponse(w, data, nil)
+		logger.InfoContext(ctx, "Successfully updated %d records", result.RowsAffected())
+		h.sendAfterHook(w, AfterUpdate, hookCtx, data, nil)
 
 	case []map[string]interface{}:
 		// Batch update with array of objects
@@ -569,7 +1167,7 @@ func (h *Handler) handleUpdate(ctx context.Context, w common.ResponseWriter, url
 		}
 
 		if hasNestedData {
-			logger.Info("Using nested CUD processor for batch update with nested data")
+			logger.InfoContext(ctx, "Using nested CUD processor for batch update with nested data")
 			results := make([]map[string]interface{}, 0, len(updates))
 			err := h.db.RunInTransaction(ctx, func(tx common.Database) error {
 				// Temporarily swap the database to use transaction
@@ -589,21 +1187,24 @@ func (h *Handler) handleUpdate(ctx context.Context, w common.ResponseWriter, url
 				return nil
 			})
 			if err != nil {
-				logger.Error("Error updating records with nested data: %v", err)
+				logger.ErrorContext(ctx, "Error updating records with nested data: %v", err)
 				h.sendError(w, http.StatusInternalServerError, "update_error", "Error updating records with nested data", err)
 				return
 			}
-			logger.Info("Successfully updated %d records with nested data", len(results))
-			h.sendResponse(w, results, nil)
+			logger.InfoContext(ctx, "Successfully updated %d records with nested data", len(results))
+			h.sendAfterHook(w, AfterUpdate, hookCtx, results, nil)
 			return
 		}
 
 		// Standard batch update without nested relations
 		err := h.db.RunInTransaction(ctx, func(tx common.Database) error {
 			for _, item := range updates {
-				if itemID, ok := item["id"]; ok {
-
-					txQuery := tx.NewUpdate().Table(tableName).SetMap(item).Where(fmt.Sprintf("%s = ?", common.QuoteIdent(reflection.GetPrimaryKeyName(model))), itemID)
+				if itemID, ok := common.RecordKey(model, item); ok {
+					where, args, err := common.KeyCondition(model, itemID)
+					if err != nil {
+						return fmt.Errorf("invalid id %v: %w", itemID, err)
+					}
+					txQuery := tx.NewUpdate().Table(tableName).SetMap(item).Where(where, args...)
 					if _, err := txQuery.Exec(ctx); err != nil {
 						return err
 					}
@@ -612,12 +1213,12 @@ func (h *Handler) handleUpdate(ctx context.Context, w common.ResponseWriter, url
 			return nil
 		})
 		if err != nil {
-			logger.Error("Error updating records: %v", err)
+			logger.ErrorContext(ctx, "Error updating records: %v", err)
 			h.sendError(w, http.StatusInternalServerError, "update_error", "Error updating records", err)
 			return
 		}
-		logger.Info("Successfully updated %d records", len(updates))
-		h.sendResponse(w, updates, nil)
+		logger.InfoContext(ctx, "Successfully updated %d records", len(updates))
+		h.sendAfterHook(w, AfterUpdate, hookCtx, updates, nil)
 
 	case []interface{}:
 		// Batch update with []interface{}
@@ -632,7 +1233,7 @@ func (h *Handler) handleUpdate(ctx context.Context, w common.ResponseWriter, url
 		}
 
 		if hasNestedData {
-			logger.Info("Using nested CUD processor for batch update with nested data ([]interface{})")
+			logger.InfoContext(ctx, "Using nested CUD processor for batch update with nested data ([]interface{})")
 			results := make([]interface{}, 0, len(updates))
 			err := h.db.RunInTransaction(ctx, func(tx common.Database) error {
 				// Temporarily swap the database to use transaction
@@ -654,12 +1255,12 @@ func (h *Handler) handleUpdate(ctx context.Context, w common.ResponseWriter, url
 				return nil
 			})
 			if err != nil {
-				logger.Error("Error updating records with nested data: %v", err)
+				logger.ErrorContext(ctx, "Error updating records with nested data: %v", err)
 				h.sendError(w, http.StatusInternalServerError, "update_error", "Error updating records with nested data", err)
 				return
 			}
-			logger.Info("Successfully updated %d records with nested data", len(results))
-			h.sendResponse(w, results, nil)
+			logger.InfoContext(ctx, "Successfully updated %d records with nested data", len(results))
+			h.sendAfterHook(w, AfterUpdate, hookCtx, results, nil)
 			return
 		}
 
@@ -668,9 +1269,12 @@ func (h *Handler) handleUpdate(ctx context.Context, w common.ResponseWriter, url
 		err := h.db.RunInTransaction(ctx, func(tx common.Database) error {
 			for _, item := range updates {
 				if itemMap, ok := item.(map[string]interface{}); ok {
-					if itemID, ok := itemMap["id"]; ok {
-
-						txQuery := tx.NewUpdate().Table(tableName).SetMap(itemMap).Where(fmt.Sprintf("%s = ?", common.QuoteIdent(reflection.GetPrimaryKeyName(model))), itemID)
+					if itemID, ok := common.RecordKey(model, itemMap); ok {
+						where, args, err := common.KeyCondition(model, itemID)
+						if err != nil {
+							return fmt.Errorf("invalid id %v: %w", itemID, err)
+						}
+						txQuery := tx.NewUpdate().Table(tableName).SetMap(itemMap).Where(where, args...)
 						if _, err := txQuery.Exec(ctx); err != nil {
 							return err
 						}
@@ -681,21 +1285,21 @@ func (h *Handler) handleUpdate(ctx context.Context, w common.ResponseWriter, url
 			return nil
 		})
 		if err != nil {
-			logger.Error("Error updating records: %v", err)
+			logger.ErrorContext(ctx, "Error updating records: %v", err)
 			h.sendError(w, http.StatusInternalServerError, "update_error", "Error updating records", err)
 			return
 		}
-		logger.Info("Successfully updated %d records", len(list))
-		h.sendResponse(w, list, nil)
+		logger.InfoContext(ctx, "Successfully updated %d records", len(list))
+		h.sendAfterHook(w, AfterUpdate, hookCtx, list, nil)
 
 	default:
-		logger.Error("Invalid data type for update operation: %T", data)
+		logger.ErrorContext(ctx, "Invalid data type for update operation: %T", data)
 		h.sendError(w, http.StatusBadRequest, "invalid_data", "Invalid data type for update operation", nil)
 		return
 	}
 }
 
-func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id string, data interface{}) {
+func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id string, data interface{}, options common.RequestOptions) {
 	// Capture panics and return error response
 	defer func() {
 		if err := recover(); err != nil {
@@ -703,23 +1307,36 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 		}
 	}()
 
+	ctx, span := tracing.StartSpan(ctx, "resolvespec.delete", tracing.AttrOperation.String("delete"))
+	defer span.End()
+
 	schema := GetSchema(ctx)
 	entity := GetEntity(ctx)
 	tableName := GetTableName(ctx)
 	model := GetModel(ctx)
 
-	logger.Info("Deleting records from %s.%s", schema, entity)
+	logger.InfoContext(ctx, "Deleting records from %s.%s", schema, entity)
+
+	hookCtx := h.newHookContext(ctx, w, id, data, options)
+	if !h.runBeforeHook(w, BeforeDelete, hookCtx) {
+		return
+	}
+	data = hookCtx.Data
 
 	// Handle batch delete from request data
 	if data != nil {
 		switch v := data.(type) {
 		case []string:
 			// Array of IDs as strings
-			logger.Info("Batch delete with %d IDs ([]string)", len(v))
+			logger.InfoContext(ctx, "Batch delete with %d IDs ([]string)", len(v))
 			err := h.db.RunInTransaction(ctx, func(tx common.Database) error {
 				for _, itemID := range v {
 
-					query := tx.NewDelete().Table(tableName).Where(fmt.Sprintf("%s = ?", common.QuoteIdent(reflection.GetPrimaryKeyName(model))), itemID)
+					where, args, err := common.KeyCondition(model, itemID)
+					if err != nil {
+						return fmt.Errorf("invalid id %v: %w", itemID, err)
+					}
+					query := tx.NewDelete().Table(tableName).Where(where, args...)
 					if _, err := query.Exec(ctx); err != nil {
 						return fmt.Errorf("failed to delete record %s: %w", itemID, err)
 					}
@@ -727,17 +1344,17 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 				return nil
 			})
 			if err != nil {
-				logger.Error("Error in batch delete: %v", err)
+				logger.ErrorContext(ctx, "Error in batch delete: %v", err)
 				h.sendError(w, http.StatusInternalServerError, "delete_error", "Error deleting records", err)
 				return
 			}
-			logger.Info("Successfully deleted %d records", len(v))
-			h.sendResponse(w, map[string]interface{}{"deleted": len(v)}, nil)
+			logger.InfoContext(ctx, "Successfully deleted %d records", len(v))
+			h.sendAfterHook(w, AfterDelete, hookCtx, map[string]interface{}{"deleted": len(v)}, nil)
 			return
 
 		case []interface{}:
 			// Array of IDs or objects with ID field
-			logger.Info("Batch delete with %d items ([]interface{})", len(v))
+			logger.InfoContext(ctx, "Batch delete with %d items ([]interface{})", len(v))
 			deletedCount := 0
 			err := h.db.RunInTransaction(ctx, func(tx common.Database) error {
 				for _, item := range v {
@@ -748,7 +1365,9 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 					case string:
 						itemID = v
 					case map[string]interface{}:
-						itemID = v["id"]
+						if key, ok := common.RecordKey(model, v); ok {
+							itemID = key
+						}
 					default:
 						// Try to use the item directly as ID
 						itemID = item
@@ -758,7 +1377,11 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 						continue // Skip items without ID
 					}
 
-					query := tx.NewDelete().Table(tableName).Where(fmt.Sprintf("%s = ?", common.QuoteIdent(reflection.GetPrimaryKeyName(model))), itemID)
+					where, args, err := common.KeyCondition(model, itemID)
+					if err != nil {
+						return fmt.Errorf("invalid id %v: %w", itemID, err)
+					}
+					query := tx.NewDelete().Table(tableName).Where(where, args...)
 					result, err := query.Exec(ctx)
 					if err != nil {
 						return fmt.Errorf("failed to delete record %v: %w", itemID, err)
@@ -768,22 +1391,26 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 				return nil
 			})
 			if err != nil {
-				logger.Error("Error in batch delete: %v", err)
+				logger.ErrorContext(ctx, "Error in batch delete: %v", err)
 				h.sendError(w, http.StatusInternalServerError, "delete_error", "Error deleting records", err)
 				return
 			}
-			logger.Info("Successfully deleted %d records", deletedCount)
-			h.sendResponse(w, map[string]interface{}{"deleted": deletedCount}, nil)
+			logger.InfoContext(ctx, "Successfully deleted %d records", deletedCount)
+			h.sendAfterHook(w, AfterDelete, hookCtx, map[string]interface{}{"deleted": deletedCount}, nil)
 			return
 
 		case []map[string]interface{}:
 			// Array of objects with id field
-			logger.Info("Batch delete with %d items ([]map[string]interface{})", len(v))
+			logger.InfoContext(ctx, "Batch delete with %d items ([]map[string]interface{})", len(v))
 			deletedCount := 0
 			err := h.db.RunInTransaction(ctx, func(tx common.Database) error {
 				for _, item := range v {
-					if itemID, ok := item["id"]; ok && itemID != nil {
-						query := tx.NewDelete().Table(tableName).Where(fmt.Sprintf("%s = ?", common.QuoteIdent(reflection.GetPrimaryKeyName(model))), itemID)
+					if itemID, ok := common.RecordKey(model, item); ok {
+						where, args, err := common.KeyCondition(model, itemID)
+						if err != nil {
+							return fmt.Errorf("invalid id %v: %w", itemID, err)
+						}
+						query := tx.NewDelete().Table(tableName).Where(where, args...)
 						result, err := query.Exec(ctx)
 						if err != nil {
 							return fmt.Errorf("failed to delete record %v: %w", itemID, err)
@@ -794,70 +1421,132 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 				return nil
 			})
 			if err != nil {
-				logger.Error("Error in batch delete: %v", err)
+				logger.ErrorContext(ctx, "Error in batch delete: %v", err)
 				h.sendError(w, http.StatusInternalServerError, "delete_error", "Error deleting records", err)
 				return
 			}
-			logger.Info("Successfully deleted %d records", deletedCount)
-			h.sendResponse(w, map[string]interface{}{"deleted": deletedCount}, nil)
+			logger.InfoContext(ctx, "Successfully deleted %d records", deletedCount)
+			h.sendAfterHook(w, AfterDelete, hookCtx, map[string]interface{}{"deleted": deletedCount}, nil)
 			return
 
 		case map[string]interface{}:
 			// Single object with id field
-			if itemID, ok := v["id"]; ok && itemID != nil {
-				id = fmt.Sprintf("%v", itemID)
+			if itemID, ok := common.RecordKey(model, v); ok {
+				id = itemID
 			}
 		}
 	}
 
 	// Single delete with URL ID
 	if id == "" {
-		logger.Error("Delete operation requires an ID")
+		logger.ErrorContext(ctx, "Delete operation requires an ID")
 		h.sendError(w, http.StatusBadRequest, "missing_id", "Delete operation requires an ID", nil)
 		return
 	}
 
-	query := h.db.NewDelete().Table(tableName).Where(fmt.Sprintf("%s = ?", common.QuoteIdent(reflection.GetPrimaryKeyName(model))), id)
+	keyWhere, keyArgs, err := common.KeyCondition(model, id)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid_id", "Invalid id", err)
+		return
+	}
+	query := h.db.NewDelete().Table(tableName).Where(keyWhere, keyArgs...)
+
+	// Select the record before it is deleted to return it
+	var records []interface{}
+	if options.ReturnRecords {
+		loaded, err := common.LoadRecords(ctx, h.db, model, tableName, keyWhere, keyArgs...)
+		if err != nil {
+			logger.ErrorContext(ctx, "Error loading record to delete: %v", err)
+			h.sendError(w, http.StatusInternalServerError, "delete_error", "Error deleting record", err)
+			return
+		}
+		records = loaded
+	}
+
+	hookCtx.ID = id
+	hookCtx.Query = query
+	if !h.runBeforeHook(w, BeforeScan, hookCtx) {
+		return
+	}
+	if modifiedQuery, ok := hookCtx.Query.(common.DeleteQuery); ok {
+		query = modifiedQuery
+	}
 
+	started := time.Now()
 	result, err := query.Exec(ctx)
+	hookCtx.Duration = time.Since(started)
+	h.reportSlowQuery(ctx, "delete", common.RequestOptions{}, hookCtx.Duration)
 	if err != nil {
-		logger.Error("Error deleting record: %v", err)
+		logger.ErrorContext(ctx, "Error deleting record: %v", err)
 		h.sendError(w, http.StatusInternalServerError, "delete_error", "Error deleting record", err)
 		return
 	}
 
 	if result.RowsAffected() == 0 {
-		logger.Warn("No record found to delete with ID: %s", id)
+		logger.WarnContext(ctx, "No record found to delete with ID: %s", id)
 		h.sendError(w, http.StatusNotFound, "not_found", "Record not found", nil)
 		return
 	}
+	if err := h.hooks.Execute(AfterScan, hookCtx); err != nil {
+		logger.ErrorContext(ctx, "AfterScan hook failed: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "hook_error", "Hook execution failed", err)
+		return
+	}
 
-	logger.Info("Successfully deleted record with ID: %s", id)
-	h.sendResponse(w, nil, nil)
+	logger.InfoContext(ctx, "Successfully deleted record with ID: %s", id)
+	var response interface{}
+	if options.ReturnRecords {
+		response = map[string]interface{}{"deleted": result.RowsAffected(), "records": records}
+	}
+	h.sendAfterHook(w, AfterDelete, hookCtx, response, nil)
 }
 
 func (h *Handler) applyFilter(query common.SelectQuery, filter common.FilterOption) common.SelectQuery {
+	condition, args := h.filterCondition(filter)
+	if condition == "" {
+		return query
+	}
+	return query.Where(condition, args...)
+}
+
+// filterCondition renders a filter as a SQL condition with ? placeholders.
+// Unknown operators render an empty condition and are skipped.
+func (h *Handler) filterCondition(filter common.FilterOption) (string, []interface{}) {
 	switch filter.Operator {
 	case "eq":
-		return query.Where(fmt.Sprintf("%s = ?", filter.Column), filter.Value)
+		return fmt.Sprintf("%s = ?", filter.Column), []interface{}{filter.Value}
 	case "neq":
-		return query.Where(fmt.Sprintf("%s != ?", filter.Column), filter.Value)
+		return fmt.Sprintf("%s != ?", filter.Column), []interface{}{filter.Value}
 	case "gt":
-		return query.Where(fmt.Sprintf("%s > ?", filter.Column), filter.Value)
+		return fmt.Sprintf("%s > ?", filter.Column), []interface{}{filter.Value}
 	case "gte":
-		return query.Where(fmt.Sprintf("%s >= ?", filter.Column), filter.Value)
+		return fmt.Sprintf("%s >= ?", filter.Column), []interface{}{filter.Value}
 	case "lt":
-		return query.Where(fmt.Sprintf("%s < ?", filter.Column), filter.Value)
+		return fmt.Sprintf("%s < ?", filter.Column), []interface{}{filter.Value}
 	case "lte":
-		return query.Where(fmt.Sprintf("%s <= ?", filter.Column), filter.Value)
+		return fmt.Sprintf("%s <= ?", filter.Column), []interface{}{filter.Value}
 	case "like":
-		return query.Where(fmt.Sprintf("%s LIKE ?", filter.Column), filter.Value)
+		return fmt.Sprintf("%s LIKE ?", filter.Column), []interface{}{filter.Value}
 	case "ilike":
-		return query.Where(fmt.Sprintf("%s ILIKE ?", filter.Column), filter.Value)
+		return common.DialectOf(h.db).ILikeExpression(filter.Column, "?"), []interface{}{filter.Value}
 	case "in":
-		return query.Where(fmt.Sprintf("%s IN (?)", filter.Column), filter.Value)
+		return fmt.Sprintf("%s IN (?)", filter.Column), []interface{}{filter.Value}
+	case "contains", "contained_by", "overlaps", "any_eq":
+		condition, args, err := common.ArrayFilterCondition(filter.Operator, filter.Column, filter.Value)
+		if err != nil {
+			logger.Warn("Invalid array filter: %v", err)
+			return "", nil
+		}
+		return condition, args
+	case "st_within", "st_intersects", "st_dwithin":
+		condition, args, err := common.GeoFilterCondition(filter.Operator, filter.Column, filter.Value)
+		if err != nil {
+			logger.Warn("Invalid geo filter: %v", err)
+			return "", nil
+		}
+		return condition, args
 	default:
-		return query
+		return "", nil
 	}
 }
 
@@ -937,7 +1626,7 @@ func (h *Handler) generateMetadata(schema, entity string, model interface{}) *co
 			Schema:    schema,
 			Table:     entity,
 			Columns:   make([]common.Column, 0),
-			Relations: make([]string, 0),
+			Relations: make([]common.RelationMetadata, 0),
 		}
 	}
 
@@ -945,7 +1634,11 @@ func (h *Handler) generateMetadata(schema, entity string, model interface{}) *co
 		Schema:    schema,
 		Table:     entity,
 		Columns:   make([]common.Column, 0),
-		Relations: make([]string, 0),
+		Relations: common.ModelRelations(model, h.registry, h, nil),
+	}
+	relationNames := make(map[string]bool, len(metadata.Relations))
+	for _, relation := range metadata.Relations {
+		relationNames[relation.Name] = true
 	}
 
 	// Generate metadata using reflection (same logic as before)
@@ -968,9 +1661,9 @@ func (h *Handler) generateMetadata(schema, entity string, model interface{}) *co
 			jsonName = field.Name
 		}
 
-		if field.Type.Kind() == reflect.Slice ||
+		// Relations are described in metadata.Relations
+		if relationNames[jsonName] || field.Type.Kind() == reflect.Slice ||
 			(field.Type.Kind() == reflect.Struct && field.Type.Name() != "Time") {
-			metadata.Relations = append(metadata.Relations, jsonName)
 			continue
 		}
 
@@ -981,7 +1674,9 @@ func (h *Handler) generateMetadata(schema, entity string, model interface{}) *co
 			IsPrimary:  strings.Contains(gormTag, "primaryKey"),
 			IsUnique:   strings.Contains(gormTag, "unique") || strings.Contains(gormTag, "uniqueIndex"),
 			HasIndex:   strings.Contains(gormTag, "index") || strings.Contains(gormTag, "uniqueIndex"),
+			Enum:       common.EnumValues(field),
 		}
+		common.ApplyColumnTags(&column, field)
 
 		metadata.Columns = append(metadata.Columns, column)
 	}
@@ -989,6 +1684,143 @@ func (h *Handler) generateMetadata(schema, entity string, model interface{}) *co
 	return metadata
 }
 
+// handleBatch executes a list of create/update/delete items, atomically unless the
+// batch sets "atomic": false, and responds with the result of every item
+func (h *Handler) handleBatch(ctx context.Context, w common.ResponseWriter, data interface{}) {
+	// Capture panics and return error response
+	defer func() {
+		if err := recover(); err != nil {
+			h.handlePanic(w, "handleBatch", err)
+		}
+	}()
+
+	ctx, span := tracing.StartSpan(ctx, "resolvespec.batch", tracing.AttrOperation.String("batch"))
+	defer span.End()
+
+	schema := GetSchema(ctx)
+	entity := GetEntity(ctx)
+	tableName := GetTableName(ctx)
+	model := GetModel(ctx)
+
+	batch, err := common.ParseBatchRequest(data)
+	if err != nil {
+		logger.ErrorContext(ctx, "Invalid batch request: %v", err)
+		h.sendError(w, http.StatusBadRequest, "invalid_batch", "Invalid batch request", err)
+		return
+	}
+
+	logger.InfoContext(ctx, "Executing batch of %d item(s) for %s.%s (atomic: %v)", len(batch.Items), schema, entity, batch.IsAtomic())
+
+	pkName := reflection.GetPrimaryKeyName(model)
+	result := common.ExecuteBatch(ctx, h.db, h.config, batch, func(ctx context.Context, tx common.Database, item common.BatchItem) (*common.ProcessResult, error) {
+		record := item.RecordData(pkName)
+		if item.Action != "delete" {
+			if err := h.config.Deserialize(ctx, schema, entity, record); err != nil {
+				return nil, err
+			}
+			if err := common.ValidateEnums(model, record); err != nil {
+				return nil, err
+			}
+			if item.Action == common.BatchActionCreate {
+				if err := h.config.GenerateIDs(ctx, tx, schema, entity, model, record); err != nil {
+					return nil, err
+				}
+			}
+			h.config.StampAudit(ctx, model, record, item.Action == common.BatchActionCreate)
+			h.tenant.Stamp(model, record)
+		}
+		processor := common.NewNestedCUDProcessor(tx, h.registry, h)
+		return processor.ProcessNestedCUD(ctx, item.Action, record, model, make(map[string]interface{}), tableName)
+	})
+
+	logger.InfoContext(ctx, "Batch completed: %d succeeded, %d failed", result.Succeeded, result.Failed)
+
+	response := common.Response{
+		Success: result.Failed == 0,
+		Data:    result,
+	}
+	if result.RolledBack {
+		response.Error = &common.APIError{
+			Code:    "batch_error",
+			Message: "Batch failed and was rolled back",
+		}
+	}
+
+	w.SetHeader("Content-Type", "application/json")
+	w.WriteHeader(result.StatusCode())
+	if err := w.WriteJSON(response); err != nil {
+		abortResponse(err)
+	}
+}
+
+// handleTransaction executes create/update/delete operations on multiple entities of
+// schema in one transaction. Later operations reference earlier results with "${ref.field}".
+func (h *Handler) handleTransaction(ctx context.Context, w common.ResponseWriter, schema string, data interface{}) {
+	// Capture panics and return error response
+	defer func() {
+		if err := recover(); err != nil {
+			h.handlePanic(w, "handleTransaction", err)
+		}
+	}()
+
+	ctx, span := tracing.StartSpan(ctx, "resolvespec.transaction",
+		tracing.AttrSchema.String(schema),
+		tracing.AttrOperation.String("transaction"),
+	)
+	defer span.End()
+
+	req, err := common.ParseTransactionRequest(data)
+	if err != nil {
+		logger.ErrorContext(ctx, "Invalid transaction request: %v", err)
+		h.sendError(w, http.StatusBadRequest, "invalid_transaction", "Invalid transaction request", err)
+		return
+	}
+
+	// Resolve all entities before starting the transaction
+	models := make(map[string]interface{}, len(req.Operations))
+	for _, op := range req.Operations {
+		if _, ok := models[op.Entity]; ok {
+			continue
+		}
+		model, err := h.registry.GetModelByEntity(schema, op.Entity)
+		if err != nil {
+			logger.ErrorContext(ctx, "Invalid entity in transaction: %v", err)
+			h.sendError(w, http.StatusBadRequest, "invalid_entity", fmt.Sprintf("Invalid entity %q", op.Entity), err)
+			return
+		}
+		models[op.Entity] = model
+	}
+
+	logger.InfoContext(ctx, "Executing transaction of %d operation(s) in schema %s", len(req.Operations), schema)
+
+	result := common.ExecuteTransaction(ctx, h.db, h.config, req, func(ctx context.Context, tx common.Database, op common.TransactionOperation) (*common.ProcessResult, string, error) {
+		model := models[op.Entity]
+		pkName := reflection.GetPrimaryKeyName(model)
+		processor := common.NewNestedCUDProcessor(tx, h.registry, h)
+		processed, err := processor.ProcessNestedCUD(ctx, op.Action, op.BatchItem().RecordData(pkName), model, make(map[string]interface{}), h.getTableName(schema, op.Entity, model))
+		return processed, pkName, err
+	})
+
+	logger.InfoContext(ctx, "Transaction completed (committed: %v)", result.Committed)
+
+	response := common.Response{
+		Success: result.Committed,
+		Data:    result,
+	}
+	if !result.Committed {
+		response.Error = &common.APIError{
+			Code:    "transaction_error",
+			Message: "Transaction failed and was rolled back",
+		}
+	}
+
+	w.SetHeader("Content-Type", "application/json")
+	w.WriteHeader(result.StatusCode())
+	if err := w.WriteJSON(response); err != nil {
+		abortResponse(err)
+	}
+}
+
 func (h *Handler) sendResponse(w common.ResponseWriter, data interface{}, metadata *common.Metadata) {
 	w.SetHeader("Content-Type", "application/json")
 	err := w.WriteJSON(common.Response{
@@ -997,21 +1829,55 @@ func (h *Handler) sendResponse(w common.ResponseWriter, data interface{}, metada
 		Metadata: metadata,
 	})
 	if err != nil {
-		logger.Error("Error sending response: %v", err)
+		abortResponse(err)
 	}
 }
 
 func (h *Handler) sendError(w common.ResponseWriter, status int, code, message string, details interface{}) {
+	// A hook answered the request itself
+	var hookResponse *hooks.Response
+	if err, isErr := details.(error); isErr && errors.As(err, &hookResponse) {
+		if writeErr := hookResponse.Write(w); writeErr != nil {
+			logger.Error("Error sending response: %v", writeErr)
+		}
+		return
+	}
+
+	// Errors caused by a client disconnect or an expired deadline are not server errors
+	requestID := common.RequestIDOfWriter(w)
+	column := ""
+	if err, isErr := details.(error); isErr {
+		if ctxStatus, ctxCode, ok := common.ContextErrorStatus(err); ok {
+			logger.Info("Request aborted (%s): %v", message, err)
+			status, code = ctxStatus, ctxCode
+		} else {
+			resolved, expose := h.config.ResolveError(status, code, message, err)
+			status, code, message, column = resolved.Status, resolved.Code, resolved.Message, resolved.Column
+			if !expose {
+				logger.Error("Request %s failed (%s): %v", requestID, code, err)
+				details = nil
+			}
+		}
+	} else if details != nil && h.config.ErrorDetail == common.ErrorDetailProduction && status >= http.StatusInternalServerError {
+		details = nil
+	}
+
+	apiError := &common.APIError{
+		Code:      code,
+		Message:   message,
+		Column:    column,
+		RequestID: requestID,
+	}
+	if details != nil {
+		apiError.Details = details
+		apiError.Detail = fmt.Sprintf("%v", details)
+	}
+
 	w.SetHeader("Content-Type", "application/json")
 	w.WriteHeader(status)
 	err := w.WriteJSON(common.Response{
 		Success: false,
-		Error: &common.APIError{
-			Code:    code,
-			Message: message,
-			Details: details,
-			Detail:  fmt.Sprintf("%v", details),
-		},
+		Error:   apiError,
 	})
 	if err != nil {
 		logger.Error("Error sending response: %v", err)
@@ -1087,18 +1953,21 @@ func isNullable(field reflect.StructField) bool {
 // GetRelationshipInfo implements common.RelationshipInfoProvider interface
 func (h *Handler) GetRelationshipInfo(modelType reflect.Type, relationName string) *common.RelationshipInfo {
 	info := h.getRelationshipInfo(modelType, relationName)
-	if info == nil {
+	if info == nil || info.relationType == "" {
+		// Fields without GORM relationship tags are regular columns
 		return nil
 	}
 	// Convert internal type to common type
 	return &common.RelationshipInfo{
-		FieldName:    info.fieldName,
-		JSONName:     info.jsonName,
-		RelationType: info.relationType,
-		ForeignKey:   info.foreignKey,
-		References:   info.references,
-		JoinTable:    info.joinTable,
-		RelatedModel: info.relatedModel,
+		FieldName:      info.fieldName,
+		JSONName:       info.jsonName,
+		RelationType:   info.relationType,
+		ForeignKey:     info.foreignKey,
+		References:     info.references,
+		JoinTable:      info.joinTable,
+		JoinForeignKey: info.joinForeignKey,
+		JoinReferences: info.joinReferences,
+		RelatedModel:   info.relatedModel,
 	}
 }
 
@@ -1109,7 +1978,10 @@ type relationshipInfo struct {
 	foreignKey   string
 	references   string
 	joinTable    string
-	relatedModel interface{}
+	// join table columns of many2many relations, empty for the defaults
+	joinForeignKey string
+	joinReferences string
+	relatedModel   interface{}
 }
 
 func (h *Handler) applyPreloads(model interface{}, query common.SelectQuery, preloads []common.PreloadOption) common.SelectQuery {
@@ -1149,31 +2021,26 @@ func (h *Handler) applyPreloads(model interface{}, query common.SelectQuery, pre
 			preload.Where = fixedWhere
 		}
 
+		// Columns and computed columns refer to the related model
+		relatedModel := reflection.GetRelationModel(model, relationFieldName)
+		if relatedModel == nil {
+			relatedModel = model
+		}
+
 		logger.Debug("Applying preload: %s", relationFieldName)
 		query = query.PreloadRelation(relationFieldName, func(sq common.SelectQuery) common.SelectQuery {
 			if len(preload.Columns) == 0 && (len(preload.ComputedQL) > 0 || len(preload.OmitColumns) > 0) {
-				preload.Columns = reflection.GetSQLModelColumns(model)
+				preload.Columns = reflection.GetSQLModelColumns(relatedModel)
 			}
 
-			// Handle column selection and omission
-			if len(preload.OmitColumns) > 0 {
-				allCols := reflection.GetSQLModelColumns(model)
-				// Remove omitted columns
-				preload.Columns = []string{}
-				for _, col := range allCols {
-					addCols := true
-					for _, omitCol := range preload.OmitColumns {
-						if col == omitCol {
-							addCols = false
-							break
-						}
-					}
-					if addCols {
-						preload.Columns = append(preload.Columns, col)
-					}
-				}
+			for colName, colExpr := range preload.ComputedQL {
+				logger.Debug("Applying computed column to preload %s: %s", relationFieldName, colName)
+				sq = sq.ColumnExpr(fmt.Sprintf("(%s) AS %s", colExpr, colName))
 			}
 
+			// Handle column selection and omission
+			preload.Columns = common.SelectColumns(relatedModel, preload.Columns, preload.OmitColumns)
+
 			if len(preload.Columns) > 0 {
 				// Ensure foreign key is included in column selection for GORM to establish the relationship
 				columns := make([]string, len(preload.Columns))
@@ -1217,9 +2084,7 @@ func (h *Handler) applyPreloads(model interface{}, query common.SelectQuery, pre
 				}
 			}
 
-			if preload.Limit != nil && *preload.Limit > 0 {
-				sq = sq.Limit(*preload.Limit)
-			}
+			// Limit and offset are applied per parent after loading, see common.ApplyPreloadPagination
 
 			return sq
 		})
@@ -1230,7 +2095,30 @@ func (h *Handler) applyPreloads(model interface{}, query common.SelectQuery, pre
 	return query
 }
 
+// relationshipInfos caches the relationship info of model types by relation name; found
+// relations only, as relation names come from requests
+var relationshipInfos sync.Map // relationshipKey -> *relationshipInfo
+
+type relationshipKey struct {
+	modelType reflect.Type
+	relation  string
+}
+
+// getRelationshipInfo returns the relationship of modelType named relationName, reflecting over
+// the type on the first lookup only. The info is shared and must not be modified.
 func (h *Handler) getRelationshipInfo(modelType reflect.Type, relationName string) *relationshipInfo {
+	key := relationshipKey{modelType: modelType, relation: relationName}
+	if info, ok := relationshipInfos.Load(key); ok {
+		return info.(*relationshipInfo)
+	}
+	info := h.buildRelationshipInfo(modelType, relationName)
+	if info != nil {
+		relationshipInfos.Store(key, info)
+	}
+	return info
+}
+
+func (h *Handler) buildRelationshipInfo(modelType reflect.Type, relationName string) *relationshipInfo {
 	// Ensure we have a struct type
 	if modelType == nil || modelType.Kind() != reflect.Struct {
 		logger.Warn("Cannot get relationship info from non-struct type: %v", modelType)
@@ -1263,6 +2151,12 @@ func (h *Handler) getRelationshipInfo(modelType reflect.Type, relationName strin
 			} else if strings.Contains(gormTag, "many2many") {
 				info.relationType = "many2many"
 				info.joinTable = h.extractTagValue(gormTag, "many2many")
+				h.extractJoinColumns(info, gormTag)
+			} else if joinTable, joinForeignKey, joinReferences, ok := common.ParseBunM2MTag(field.Tag.Get("bun")); ok {
+				info.relationType = "many2many"
+				info.joinTable = joinTable
+				info.joinForeignKey = joinForeignKey
+				info.joinReferences = joinReferences
 			}
 
 			return info
@@ -1271,6 +2165,16 @@ func (h *Handler) getRelationshipInfo(modelType reflect.Type, relationName strin
 	return nil
 }
 
+// extractJoinColumns reads the join table columns of a GORM many2many tag
+func (h *Handler) extractJoinColumns(info *relationshipInfo, gormTag string) {
+	if joinForeignKey := h.extractTagValue(gormTag, "joinForeignKey"); joinForeignKey != "" {
+		info.joinForeignKey = common.JoinColumnFromField(joinForeignKey)
+	}
+	if joinReferences := h.extractTagValue(gormTag, "joinReferences"); joinReferences != "" {
+		info.joinReferences = common.JoinColumnFromField(joinReferences)
+	}
+}
+
 func (h *Handler) extractTagValue(tag, key string) string {
 	parts := strings.Split(tag, ";")
 	for _, part := range parts {
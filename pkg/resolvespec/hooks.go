@@ -0,0 +1,177 @@
+package resolvespec
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/hooks"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// HookType defines the type of hook to execute, shared with restheadspec
+type HookType = hooks.Type
+
+const (
+	// Read operation hooks
+	BeforeRead = hooks.BeforeRead
+	AfterRead  = hooks.AfterRead
+
+	// Create operation hooks
+	BeforeCreate = hooks.BeforeCreate
+	AfterCreate  = hooks.AfterCreate
+
+	// Update operation hooks
+	BeforeUpdate = hooks.BeforeUpdate
+	AfterUpdate  = hooks.AfterUpdate
+
+	// Delete operation hooks
+	BeforeDelete = hooks.BeforeDelete
+	AfterDelete  = hooks.AfterDelete
+
+	// Scan/Execute operation hooks
+	BeforeScan = hooks.BeforeScan
+	AfterScan  = hooks.AfterScan // Runs after the query executed, with its Duration
+
+	// Runs in the background after the write committed
+	AfterCommit = hooks.AfterCommit
+)
+
+// HookContext contains all the data available to a hook
+type HookContext struct {
+	Context   context.Context
+	Handler   *Handler // Reference to the handler for accessing database, registry, etc.
+	Schema    string
+	Entity    string
+	TableName string
+	Model     interface{}
+	Options   common.RequestOptions // Options of reads, hooks may change them in BeforeRead
+	RequestID string                // Id of the request, as returned in X-Request-ID
+
+	// Operation-specific fields
+	Operation string // create, update or delete, set for AfterCommit hooks
+	ID        string
+	Data      interface{} // For create/update/delete operations, hooks may replace it
+	Result    interface{} // For after hooks, hooks may replace the response data
+	Error     error       // For after hooks
+
+	// Query chain - allows hooks to modify the query before execution
+	// Can be SelectQuery, UpdateQuery or DeleteQuery
+	Query interface{}
+	// Duration is the execution time of Query, set for AfterScan hooks
+	Duration time.Duration
+
+	// Response writer - allows hooks to modify response
+	Writer common.ResponseWriter
+}
+
+// HookTarget returns the schema and entity of the request, matched against the entity
+// patterns of hooks registered with hooks.ForEntity
+func (c *HookContext) HookTarget() (schema, entity string) {
+	return c.Schema, c.Entity
+}
+
+// queueAfterCommit runs the AfterCommit hooks of a write once its transaction committed, with
+// a copy of the hook context of its after hook. The write drops the cached totals of the entity.
+func (h *Handler) queueAfterCommit(hookCtx *HookContext, operation string) {
+	if h.dryRun != nil {
+		return
+	}
+	if h.config.Entity(hookCtx.Schema, hookCtx.Entity).CountStrategy == common.CountCached {
+		h.countCache().InvalidateOnWrite(hookCtx.Context, hookCtx.Schema, hookCtx.Entity)
+	}
+	if !h.hooks.HasHooks(AfterCommit) {
+		return
+	}
+	commitCtx := *hookCtx
+	commitCtx.Operation = operation
+	commitCtx.Writer = nil // The response may be sent already
+	common.AfterCommit(hookCtx.Context, func(ctx context.Context) {
+		commitCtx.Context = ctx
+		if err := h.hooks.Execute(AfterCommit, &commitCtx); err != nil {
+			logger.WarnContext(ctx, "AfterCommit hook failed for %s.%s: %v", commitCtx.Schema, commitCtx.Entity, err)
+		}
+	})
+}
+
+// HookFunc is the signature for hook functions
+// It receives a HookContext and can modify it or return an error
+// If an error is returned, the operation will be aborted
+type HookFunc = hooks.Func[*HookContext]
+
+// HookRegistry manages all registered hooks
+type HookRegistry struct {
+	hooks.Registry[*HookContext]
+}
+
+// NewHookRegistry creates a new hook registry
+func NewHookRegistry() *HookRegistry {
+	return &HookRegistry{Registry: *hooks.NewRegistry[*HookContext]()}
+}
+
+// Execute runs all hooks for the specified type in order
+// If any hook returns an error, execution stops and the error is returned
+func (r *HookRegistry) Execute(hookType HookType, ctx *HookContext) error {
+	if ctx.RequestID == "" {
+		ctx.RequestID = common.RequestIDFromContext(ctx.Context)
+	}
+	return r.Registry.Execute(hookType, ctx)
+}
+
+// newHookContext returns the hook context of the request of ctx
+func (h *Handler) newHookContext(ctx context.Context, w common.ResponseWriter, id string, data interface{}, options common.RequestOptions) *HookContext {
+	return &HookContext{
+		Context:   ctx,
+		Handler:   h,
+		Schema:    GetSchema(ctx),
+		Entity:    GetEntity(ctx),
+		TableName: GetTableName(ctx),
+		Model:     GetModel(ctx),
+		Options:   options,
+		ID:        id,
+		Data:      data,
+		Writer:    w,
+	}
+}
+
+// runBeforeHook runs the hooks of a before hook type. A failing hook answers 400 and
+// returns false.
+func (h *Handler) runBeforeHook(w common.ResponseWriter, hookType HookType, hookCtx *HookContext) bool {
+	if err := h.hooks.Execute(hookType, hookCtx); err != nil {
+		logger.ErrorContext(hookCtx.Context, "%s hook failed: %v", hookType, err)
+		h.sendError(w, http.StatusBadRequest, "hook_error", "Hook execution failed", err)
+		return false
+	}
+	return true
+}
+
+// sendAfterHook runs the hooks of an after hook type with the result of the operation and
+// sends the result, which the hooks may replace, transformed by the serializer of the entity. A
+// failing hook answers 500.
+func (h *Handler) sendAfterHook(w common.ResponseWriter, hookType HookType, hookCtx *HookContext, result interface{}, metadata *common.Metadata) {
+	hookCtx.Result = result
+	hookCtx.Error = nil
+	if err := h.hooks.Execute(hookType, hookCtx); err != nil {
+		logger.ErrorContext(hookCtx.Context, "%s hook failed: %v", hookType, err)
+		h.sendError(w, http.StatusInternalServerError, "hook_error", "Hook execution failed", err)
+		return
+	}
+	switch hookType {
+	case AfterCreate:
+		h.queueAfterCommit(hookCtx, common.BatchActionCreate)
+	case AfterUpdate:
+		h.queueAfterCommit(hookCtx, common.BatchActionUpdate)
+	case AfterDelete:
+		h.queueAfterCommit(hookCtx, common.BatchActionDelete)
+		h.sendResponse(w, hookCtx.Result, metadata)
+		return
+	}
+	data, err := h.config.Serialize(hookCtx.Context, hookCtx.Schema, hookCtx.Entity, hookCtx.Result)
+	if err != nil {
+		logger.ErrorContext(hookCtx.Context, "Failed to serialize %s.%s: %v", hookCtx.Schema, hookCtx.Entity, err)
+		h.sendError(w, http.StatusInternalServerError, "serialization_error", "Error serializing the response", err)
+		return
+	}
+	h.sendResponse(w, data, metadata)
+}
@@ -39,6 +39,8 @@ func NewStandardBunRouter() *router.StandardBunRouterAdapter {
 
 // SetupMuxRoutes sets up routes for the ResolveSpec API with Mux
 func SetupMuxRoutes(muxRouter *mux.Router, handler *Handler) {
+	router.RegisterPluginRoutes(router.NewMuxAdapter(muxRouter), handler.Plugins())
+
 	muxRouter.HandleFunc("/{schema}/{entity}", func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		reqAdapter := router.NewHTTPRequest(r)
@@ -97,6 +99,8 @@ func ExampleWithBun(bunDB *bun.DB) {
 func SetupBunRouterRoutes(bunRouter *router.StandardBunRouterAdapter, handler *Handler) {
 	r := bunRouter.GetBunRouter()
 
+	router.RegisterPluginRoutes(bunRouter, handler.Plugins())
+
 	r.Handle("POST", "/:schema/:entity", func(w http.ResponseWriter, req bunrouter.Request) error {
 		params := map[string]string{
 			"schema": req.Param("schema"),
@@ -180,3 +184,28 @@ func ExampleBunRouterWithBunDB(bunDB *bun.DB) {
 	// This gives you the full uptrace stack: bunrouter + Bun ORM
 	// http.ListenAndServe(":8080", bunRouter.GetBunRouter())
 }
+
+// SetupHTTPRoutes sets up routes for the ResolveSpec API on chi, echo, gin and other routers
+// adapted by router.HTTPRouterAdapter
+func SetupHTTPRoutes(r *router.HTTPRouterAdapter, handler *Handler) {
+	router.RegisterPluginRoutes(r, handler.Plugins())
+
+	r.Handle("POST", "/{schema}/{entity}", func(w http.ResponseWriter, req *http.Request, params map[string]string) {
+		handler.Handle(router.NewHTTPResponseWriter(w), router.NewHTTPRequest(req), params)
+	})
+	r.Handle("POST", "/{schema}/{entity}/{id}", func(w http.ResponseWriter, req *http.Request, params map[string]string) {
+		handler.Handle(router.NewHTTPResponseWriter(w), router.NewHTTPRequest(req), params)
+	})
+	r.Handle("GET", "/{schema}/{entity}", func(w http.ResponseWriter, req *http.Request, params map[string]string) {
+		handler.HandleGet(router.NewHTTPResponseWriter(w), router.NewHTTPRequest(req), params)
+	})
+	r.Handle("GET", "/{schema}/{entity}/{id}", func(w http.ResponseWriter, req *http.Request, params map[string]string) {
+		handler.HandleGet(router.NewHTTPResponseWriter(w), router.NewHTTPRequest(req), params)
+	})
+}
+
+// MountStdlib sets up routes for the ResolveSpec API on a net/http ServeMux below prefix, using
+// Go 1.22 pattern routing instead of gorilla/mux
+func MountStdlib(mux *http.ServeMux, handler *Handler, prefix string) {
+	SetupHTTPRoutes(router.NewServeMuxAdapter(mux, prefix), handler)
+}
@@ -0,0 +1,56 @@
+package resolvespec
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// handleTenant handles a request on a copy of the handler whose database restricts every query
+// on a table with the tenant column to the tenant of the request. Requests without a tenant
+// are rejected with 403.
+func (h *Handler) handleTenant(w common.ResponseWriter, r common.Request, params map[string]string) {
+	scope, err := h.config.ResolveTenant(r, h.scopedTables())
+	if err != nil {
+		logger.Warn("Rejected request for %s.%s without tenant: %v", params["schema"], params["entity"], err)
+		h.sendError(w, http.StatusForbidden, "tenant_required", "Request has no tenant", err)
+		return
+	}
+	h.withTenant(scope).Handle(w, r, params)
+}
+
+// withTenant returns a copy of the handler whose database is scoped to a tenant
+func (h *Handler) withTenant(scope *common.TenantScope) *Handler {
+	tenant := *h
+	tenant.db = common.NewTenantDatabase(h.db, scope)
+	tenant.nestedProcessor = common.NewNestedCUDProcessor(tenant.db, h.registry, &tenant)
+	tenant.tenant = scope
+	return &tenant
+}
+
+// scopedTables returns whether a table of a registered model has the tenant column, by table
+// name with and without schema. The tables are built once per version of the registry.
+func (h *Handler) scopedTables() func(table string) bool {
+	return h.tenantTables.Tables(h.registry, h.buildScopedTables)
+}
+
+func (h *Handler) buildScopedTables() func(table string) bool {
+	scope := common.TenantScope{Column: h.config.TenantColumn}
+	tables := make(map[string]bool)
+	for name, model := range h.registry.GetAllModels() {
+		if !scope.HasColumn(model) {
+			continue
+		}
+		schema, entity := h.parseTableName(name)
+		tableSchema, table := h.getSchemaAndTable(schema, entity, model)
+		tables[strings.ToLower(table)] = true
+		if tableSchema != "" {
+			tables[strings.ToLower(tableSchema+"."+table)] = true
+		}
+	}
+	return func(table string) bool {
+		return tables[strings.ToLower(table)]
+	}
+}
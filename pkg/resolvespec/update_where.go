@@ -0,0 +1,131 @@
+package resolvespec
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+	"github.com/bitechdev/ResolveSpec/pkg/tracing"
+)
+
+// handleUpdateWhere sets the columns of data on all rows matching the filters of options with
+// a single UPDATE and answers with the number of updated rows. The guard needs a row limit or
+// the confirmation to update all matching rows; an update exceeding the limit is rolled back.
+func (h *Handler) handleUpdateWhere(ctx context.Context, w common.ResponseWriter, data interface{}, options common.RequestOptions, guard common.WriteGuard) {
+	// Capture panics and return error response
+	defer func() {
+		if err := recover(); err != nil {
+			h.handlePanic(w, "handleUpdateWhere", err)
+		}
+	}()
+
+	ctx, span := tracing.StartSpan(ctx, "resolvespec.update_where", tracing.AttrOperation.String("update"))
+	defer span.End()
+
+	schema := GetSchema(ctx)
+	entity := GetEntity(ctx)
+	tableName := GetTableName(ctx)
+	model := GetModel(ctx)
+
+	logger.InfoContext(ctx, "Updating records by filter in %s.%s", schema, entity)
+
+	if limitErr := guard.Check(); limitErr != nil {
+		h.rejectLimit(w, limitErr)
+		return
+	}
+
+	hookCtx := h.newHookContext(ctx, w, "", data, options)
+	if !h.runBeforeHook(w, BeforeUpdate, hookCtx) {
+		return
+	}
+
+	updates, ok := hookCtx.Data.(map[string]interface{})
+	if !ok || len(updates) == 0 {
+		h.sendError(w, http.StatusBadRequest, "invalid_data", "An update by filter needs an object of columns to set", nil)
+		return
+	}
+	if _, ok := updates[reflection.GetPrimaryKeyName(model)]; ok {
+		h.sendError(w, http.StatusBadRequest, "invalid_data", "An update by filter can't set the primary key", nil)
+		return
+	}
+	if err := common.ValidateEnums(model, updates); err != nil {
+		logger.WarnContext(ctx, "Invalid data for %s.%s: %v", schema, entity, err)
+		h.sendError(w, http.StatusUnprocessableEntity, common.InvalidEnumValue, "Invalid value", err)
+		return
+	}
+
+	where, args, err := common.FilterWhere(options.Filters, options.FilterGroup, h.filterCondition)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid_filter", "Invalid filter", err)
+		return
+	}
+
+	var updated int64
+	var records []interface{}
+	err = h.db.RunInTransaction(ctx, func(tx common.Database) error {
+		if options.ReturnRecords {
+			// Select the rows first, the update may change the columns they match by
+			loaded, err := common.LoadRecords(ctx, tx, model, tableName, where, args...)
+			if err != nil {
+				return err
+			}
+			records = loaded
+		}
+
+		query := tx.NewUpdate().Table(tableName).SetMap(updates)
+		if where != "" {
+			query = query.Where(where, args...)
+		} else {
+			// Confirmed updates of all rows still need a condition for the adapters
+			query = query.Where("1 = 1")
+		}
+
+		hookCtx.Query = query
+		if err := h.hooks.Execute(BeforeScan, hookCtx); err != nil {
+			return fmt.Errorf("BeforeScan hook failed: %w", err)
+		}
+		if modifiedQuery, ok := hookCtx.Query.(common.UpdateQuery); ok {
+			query = modifiedQuery
+		}
+
+		started := time.Now()
+		result, err := query.Exec(ctx)
+		hookCtx.Duration = time.Since(started)
+		h.reportSlowQuery(ctx, "update", options, hookCtx.Duration)
+		if err != nil {
+			return err
+		}
+		updated = result.RowsAffected()
+		if limitErr := guard.CheckRows(updated); limitErr != nil {
+			return limitErr
+		}
+		if options.ReturnRecords {
+			if records, err = common.ReloadRecords(ctx, tx, model, tableName, reflection.GetPrimaryKeyName(model), records); err != nil {
+				return err
+			}
+		}
+		if err := h.hooks.Execute(AfterScan, hookCtx); err != nil {
+			return fmt.Errorf("AfterScan hook failed: %w", err)
+		}
+		return nil
+	})
+	if h.rejectLimit(w, err) {
+		return
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error updating records by filter: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "update_error", "Error updating records", err)
+		return
+	}
+
+	logger.InfoContext(ctx, "Successfully updated %d records by filter", updated)
+	result := map[string]interface{}{"updated": updated}
+	if options.ReturnRecords {
+		result["records"] = records
+	}
+	h.sendAfterHook(w, AfterUpdate, hookCtx, result, nil)
+}
@@ -0,0 +1,115 @@
+package resolvespectest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/client"
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// Request is a request to the server, sent by Do
+type Request struct {
+	server *Server
+	method string
+	path   string
+	header http.Header
+	body   interface{}
+}
+
+// Get reads records of RestHeadSpec, e.g. "employees" or "employees/emp-1". Responses use the
+// detail format, with metadata.
+func (s *Server) Get(path string) *Request {
+	return s.restHeadSpec(http.MethodGet, path, nil).Header("X-DetailApi", "true")
+}
+
+// Create inserts records with RestHeadSpec; data is a record or a list of records
+func (s *Server) Create(path string, data interface{}) *Request {
+	return s.restHeadSpec(http.MethodPost, path, data)
+}
+
+// Update replaces a record with RestHeadSpec
+func (s *Server) Update(path string, data interface{}) *Request {
+	return s.restHeadSpec(http.MethodPut, path, data)
+}
+
+// Patch changes columns of a record with RestHeadSpec
+func (s *Server) Patch(path string, data interface{}) *Request {
+	return s.restHeadSpec(http.MethodPatch, path, data)
+}
+
+// Delete deletes a record with RestHeadSpec
+func (s *Server) Delete(path string) *Request {
+	return s.restHeadSpec(http.MethodDelete, path, nil)
+}
+
+// Operation posts a ResolveSpec request body, e.g.
+//
+//	srv.Operation("employees", common.RequestBody{Operation: "read", Options: options})
+func (s *Server) Operation(path string, body common.RequestBody) *Request {
+	return s.newRequest(http.MethodPost, "/resolvespec/"+strings.TrimPrefix(path, "/"), body)
+}
+
+func (s *Server) restHeadSpec(method, path string, body interface{}) *Request {
+	return s.newRequest(method, "/restheadspec/"+strings.TrimPrefix(path, "/"), body)
+}
+
+func (s *Server) newRequest(method, path string, body interface{}) *Request {
+	return &Request{server: s, method: method, path: path, header: make(http.Header), body: body}
+}
+
+// Header sets a request header
+func (r *Request) Header(name, value string) *Request {
+	r.header.Set(name, value)
+	return r
+}
+
+// Options sets the RestHeadSpec headers of query options, see client.Query
+func (r *Request) Options(options *client.QueryOptions) *Request {
+	r.server.t.Helper()
+	headers, err := options.RequestHeaders()
+	if err != nil {
+		r.server.t.Fatalf("resolvespectest: %v", err)
+	}
+	for name, value := range headers {
+		r.header.Set(name, value)
+	}
+	return r
+}
+
+// Do sends the request. Failing to send it or to read the response fails the test.
+func (r *Request) Do() *Response {
+	t := r.server.t
+	t.Helper()
+
+	var reader io.Reader
+	if r.body != nil {
+		data, err := json.Marshal(r.body)
+		if err != nil {
+			t.Fatalf("resolvespectest: failed to encode request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(r.method, r.server.URL+r.path, reader)
+	if err != nil {
+		t.Fatalf("resolvespectest: %v", err)
+	}
+	req.Header = r.header.Clone()
+	if r.body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("resolvespectest: %s %s: %v", r.method, r.path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("resolvespectest: %s %s: failed to read response: %v", r.method, r.path, err)
+	}
+	return newResponse(t, r.method+" "+r.path, resp, body)
+}
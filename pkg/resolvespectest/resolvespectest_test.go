@@ -0,0 +1,93 @@
+package resolvespectest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/client"
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/testmodels"
+)
+
+func TestServer(t *testing.T) {
+	srv := NewServer(t, WithTestModels(), WithFixtures("testdata/employees.yaml"))
+	assert.Equal(t, "dept-eng", srv.Fixtures.ID("engineering"))
+
+	srv.Get("employees").
+		Options(client.Query().Where(client.Eq("status", "active")).OrderBy(client.Asc("first_name"))).
+		Do().
+		AssertOK().
+		AssertCount(2).
+		AssertTotal(2).
+		AssertRecord(0, map[string]interface{}{"first_name": "Jane", "department_id": "dept-eng"})
+
+	srv.Get("employees").Options(client.Query().Page(1, 1)).Do().AssertCount(1).AssertTotal(3)
+
+	var employee testmodels.Employee
+	srv.Get("employees/emp-lea").Do().AssertOK().Decode(&employee)
+	assert.Equal(t, "Miller", employee.LastName)
+
+	srv.Create("employees", map[string]interface{}{"id": "emp-jim", "first_name": "Jim", "email": "jim@example.com", "department_id": "dept-eng"}).
+		Do().
+		AssertOK().
+		AssertRecord(0, map[string]interface{}{"id": "emp-jim"})
+	srv.Patch("employees/emp-jim", map[string]interface{}{"title": "Developer"}).Do().AssertOK()
+
+	var title string
+	require.NoError(t, srv.DB.Raw("SELECT title FROM employees WHERE id = ?", "emp-jim").Scan(&title).Error)
+	assert.Equal(t, "Developer", title)
+
+	srv.Operation("employees", common.RequestBody{
+		Operation: "read",
+		Options:   common.RequestOptions{Filters: []common.FilterOption{{Column: "first_name", Operator: "eq", Value: "Jim"}}},
+	}).Do().AssertOK().AssertCount(1)
+
+	srv.Delete("employees/emp-jim").Do().AssertOK()
+	srv.Get("employees").Do().AssertCount(3)
+
+	missing := srv.Get("teams").Do().AssertError(http.StatusBadRequest, "invalid_entity")
+	assert.Nil(t, missing.Data)
+	srv.Operation("teams", common.RequestBody{Operation: "read"}).Do().AssertError(http.StatusBadRequest, "invalid_entity")
+}
+
+func TestServerIsolation(t *testing.T) {
+	first := NewServer(t, WithTestModels(), WithFixtures("testdata/employees.yaml"))
+	second := NewServer(t, WithTestModels())
+
+	first.Get("employees").Do().AssertCount(3)
+	second.Get("employees").Do().AssertOK().AssertCount(0)
+}
+
+// recorder collects failures instead of failing the test
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, format)
+}
+
+func TestAssertions(t *testing.T) {
+	srv := NewServer(t, WithTestModels(), WithFixtures("testdata/employees.yaml"))
+	rec := &recorder{TB: t}
+	srv.t = rec
+
+	response := srv.Get("employees").Do()
+	response.AssertStatus(http.StatusOK).AssertCount(3).AssertRecord(0, map[string]interface{}{"email": "jane@example.com"})
+	assert.Empty(t, rec.errors)
+
+	response.
+		AssertStatus(http.StatusCreated).
+		AssertCount(1).
+		AssertTotal(1).
+		AssertRecord(0, map[string]interface{}{"email": "x", "missing": 1}).
+		AssertRecord(5, nil).
+		AssertError(http.StatusBadRequest, "")
+	assert.Len(t, rec.errors, 8)
+}
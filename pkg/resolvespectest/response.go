@@ -0,0 +1,197 @@
+package resolvespectest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// Response is a server response. Responses in the common.Response format are decoded into it,
+// and the errors of RestHeadSpec into Error; for other responses, e.g. RestHeadSpec writes, Data
+// is the decoded body and Success tells whether the status is below 400.
+//
+// The Assert methods report failures with t.Errorf and return the response for chaining.
+type Response struct {
+	common.Response
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	t       testing.TB
+	request string
+	data    json.RawMessage
+}
+
+func newResponse(t testing.TB, request string, resp *http.Response, body []byte) *Response {
+	t.Helper()
+	r := &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: body, t: t, request: request}
+	r.Success = resp.StatusCode < http.StatusBadRequest
+	if len(bytes.TrimSpace(body)) == 0 {
+		return r
+	}
+
+	var envelope struct {
+		Success  *bool            `json:"success"`
+		Data     json.RawMessage  `json:"data"`
+		Metadata *common.Metadata `json:"metadata"`
+		Error    *common.APIError `json:"error"`
+
+		// The error format of RestHeadSpec
+		Code      string `json:"_code"`
+		Message   string `json:"_error"`
+		Column    string `json:"_column"`
+		RequestID string `json:"_request_id"`
+	}
+	err := json.Unmarshal(body, &envelope)
+	switch {
+	case err == nil && envelope.Success != nil:
+		r.Success = *envelope.Success
+		r.Metadata = envelope.Metadata
+		r.Error = envelope.Error
+		r.data = envelope.Data
+	case err == nil && envelope.Code != "" && !r.Success:
+		r.Error = &common.APIError{Code: envelope.Code, Message: envelope.Message, Column: envelope.Column, RequestID: envelope.RequestID}
+	default:
+		r.data = body
+	}
+	if len(r.data) > 0 {
+		if err := json.Unmarshal(r.data, &r.Data); err != nil {
+			t.Fatalf("resolvespectest: %s: response is not JSON: %v\n%s", request, err, body)
+		}
+	}
+	return r
+}
+
+// Decode decodes the data of the response into dest, e.g. a model or a slice of models
+func (r *Response) Decode(dest interface{}) *Response {
+	r.t.Helper()
+	if err := json.Unmarshal(r.data, dest); err != nil {
+		r.t.Fatalf("resolvespectest: %s: failed to decode data into %T: %v\n%s", r.request, dest, err, r.Body)
+	}
+	return r
+}
+
+// Records returns the records of the response data, which is a list or a single record
+func (r *Response) Records() []map[string]interface{} {
+	r.t.Helper()
+	switch data := r.Data.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		return []map[string]interface{}{data}
+	default:
+		var records []map[string]interface{}
+		r.Decode(&records)
+		return records
+	}
+}
+
+// Record returns the only or first record of the response, nil when there is none
+func (r *Response) Record() map[string]interface{} {
+	r.t.Helper()
+	records := r.Records()
+	if len(records) == 0 {
+		return nil
+	}
+	return records[0]
+}
+
+// AssertStatus checks the HTTP status code
+func (r *Response) AssertStatus(status int) *Response {
+	r.t.Helper()
+	if r.StatusCode != status {
+		r.t.Errorf("%s: status %d, want %d\n%s", r.request, r.StatusCode, status, r.Body)
+	}
+	return r
+}
+
+// AssertOK checks that the request succeeded
+func (r *Response) AssertOK() *Response {
+	r.t.Helper()
+	if r.StatusCode >= http.StatusBadRequest || !r.Success {
+		r.t.Errorf("%s: failed with status %d\n%s", r.request, r.StatusCode, r.Body)
+	}
+	return r
+}
+
+// AssertError checks that the request failed with a status and an error code; an empty code
+// accepts any
+func (r *Response) AssertError(status int, code string) *Response {
+	r.t.Helper()
+	r.AssertStatus(status)
+	if r.Success {
+		r.t.Errorf("%s: succeeded, want an error\n%s", r.request, r.Body)
+		return r
+	}
+	if code == "" {
+		return r
+	}
+	if r.Error == nil {
+		r.t.Errorf("%s: no error in the response, want %q\n%s", r.request, code, r.Body)
+	} else if r.Error.Code != code {
+		r.t.Errorf("%s: error code %q, want %q (%s)", r.request, r.Error.Code, code, r.Error.Message)
+	}
+	return r
+}
+
+// AssertCount checks the number of records in the response
+func (r *Response) AssertCount(count int) *Response {
+	r.t.Helper()
+	if got := len(r.Records()); got != count {
+		r.t.Errorf("%s: %d records, want %d\n%s", r.request, got, count, r.Body)
+	}
+	return r
+}
+
+// AssertTotal checks the total of the metadata, the count of matching records before paging
+func (r *Response) AssertTotal(total int64) *Response {
+	r.t.Helper()
+	if r.Metadata == nil {
+		r.t.Errorf("%s: no metadata in the response, want a total of %d", r.request, total)
+	} else if r.Metadata.Total != total {
+		r.t.Errorf("%s: total %d, want %d", r.request, r.Metadata.Total, total)
+	}
+	return r
+}
+
+// AssertRecord checks columns of the record at index; columns not in expected are ignored.
+// Values are compared by their JSON, so 1 matches 1.0 and a time.Time matches its string.
+func (r *Response) AssertRecord(index int, expected map[string]interface{}) *Response {
+	r.t.Helper()
+	records := r.Records()
+	if index >= len(records) {
+		r.t.Errorf("%s: no record %d in %d records", r.request, index, len(records))
+		return r
+	}
+	record := records[index]
+	for column, want := range expected {
+		got, ok := record[column]
+		if !ok {
+			r.t.Errorf("%s: record %d has no column %s", r.request, index, column)
+			continue
+		}
+		if !jsonEqual(got, want) {
+			r.t.Errorf("%s: record %d: %s is %v, want %v", r.request, index, column, got, want)
+		}
+	}
+	return r
+}
+
+// jsonEqual compares values by their JSON representation
+func jsonEqual(a, b interface{}) bool {
+	normalize := func(value interface{}) interface{} {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		var normalized interface{}
+		_ = json.Unmarshal(data, &normalized)
+		return normalized
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
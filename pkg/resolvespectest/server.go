@@ -0,0 +1,213 @@
+// Package resolvespectest runs ResolveSpec and RestHeadSpec against an in-memory SQLite database
+// for integration tests of applications.
+//
+// NewServer migrates the models, loads fixtures and serves both APIs with httptest; requests are
+// built with the typed options of pkg/client and their responses checked with assertions.
+//
+// # Usage Example
+//
+//	func TestEmployees(t *testing.T) {
+//		srv := resolvespectest.NewServer(t,
+//			resolvespectest.WithModel("employees", Employee{}),
+//			resolvespectest.WithFixtures("testdata/employees.yaml"),
+//		)
+//
+//		srv.Get("employees").
+//			Options(client.Query().Where(client.Eq("status", "active"))).
+//			Do().
+//			AssertOK().
+//			AssertCount(2).
+//			AssertRecord(0, map[string]interface{}{"first_name": "Jane"})
+//
+//		srv.Create("employees", map[string]interface{}{"first_name": "Jim"}).Do().AssertOK()
+//	}
+//
+// Routes are /restheadspec/{entity}[/{id}] and /resolvespec/{entity}[/{id}]; models are
+// registered without a schema, as SQLite has none.
+package resolvespectest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	gormlog "gorm.io/gorm/logger"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/router"
+	"github.com/bitechdev/ResolveSpec/pkg/fixtures"
+	"github.com/bitechdev/ResolveSpec/pkg/migrations"
+	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+	"github.com/bitechdev/ResolveSpec/pkg/testmodels"
+)
+
+// Server serves ResolveSpec and RestHeadSpec for the registered models of an in-memory database
+type Server struct {
+	*httptest.Server
+
+	// DB is the in-memory database, for setup and checks beyond the APIs
+	DB *gorm.DB
+	// Database is the adapter the handlers use
+	Database     common.Database
+	Registry     *modelregistry.DefaultModelRegistry
+	ResolveSpec  *resolvespec.Handler
+	RestHeadSpec *restheadspec.Handler
+	// Fixtures are the records named in the fixture files
+	Fixtures *fixtures.Records
+
+	t testing.TB
+}
+
+type config struct {
+	models              []model
+	fixtures            []string
+	resolveSpecOptions  []resolvespec.HandlerOption
+	restHeadSpecOptions []restheadspec.HandlerOption
+	logSQL              bool
+}
+
+type model struct {
+	name  string
+	model interface{}
+}
+
+// Option configures a Server
+type Option func(*config)
+
+// WithModel registers and migrates a model under an entity name, e.g. "employees"
+func WithModel(name string, m interface{}) Option {
+	return func(c *config) {
+		c.models = append(c.models, model{name: name, model: m})
+	}
+}
+
+// WithTestModels registers the models of pkg/testmodels
+func WithTestModels() Option {
+	return func(c *config) {
+		names := []string{"departments", "employees", "projects", "project_tasks", "documents", "comments"}
+		for i, m := range testmodels.GetTestModels() {
+			c.models = append(c.models, model{name: names[i], model: m})
+		}
+	}
+}
+
+// WithFixtures loads fixture files after the migration, see pkg/fixtures
+func WithFixtures(paths ...string) Option {
+	return func(c *config) {
+		c.fixtures = append(c.fixtures, paths...)
+	}
+}
+
+// WithResolveSpecOptions configures the ResolveSpec handler
+func WithResolveSpecOptions(options ...resolvespec.HandlerOption) Option {
+	return func(c *config) {
+		c.resolveSpecOptions = append(c.resolveSpecOptions, options...)
+	}
+}
+
+// WithRestHeadSpecOptions configures the RestHeadSpec handler
+func WithRestHeadSpecOptions(options ...restheadspec.HandlerOption) Option {
+	return func(c *config) {
+		c.restHeadSpecOptions = append(c.restHeadSpecOptions, options...)
+	}
+}
+
+// WithSQLLog logs the SQL statements of the database
+func WithSQLLog() Option {
+	return func(c *config) {
+		c.logSQL = true
+	}
+}
+
+// databases numbers the in-memory databases, so every server starts empty
+var databases atomic.Int64
+
+// NewServer starts a server with its own in-memory database. It is closed when the test ends;
+// setup errors fail the test.
+func NewServer(t testing.TB, options ...Option) *Server {
+	t.Helper()
+	var cfg config
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	logLevel := gormlog.Silent
+	if cfg.logSQL {
+		logLevel = gormlog.Info
+	}
+	dsn := fmt.Sprintf("file:resolvespectest_%d?mode=memory&cache=shared", databases.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: gormlog.Default.LogMode(logLevel)})
+	if err != nil {
+		t.Fatalf("resolvespectest: failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("resolvespectest: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	registry := modelregistry.NewModelRegistry()
+	for _, m := range cfg.models {
+		if err := registry.RegisterModel(m.name, m.model); err != nil {
+			t.Fatalf("resolvespectest: failed to register %s: %v", m.name, err)
+		}
+		if err := db.AutoMigrate(m.model); err != nil {
+			t.Fatalf("resolvespectest: failed to migrate %s: %v", m.name, err)
+		}
+	}
+
+	adapter := database.NewGormAdapter(db)
+	if _, err := migrations.EnsureIndexes(context.Background(), adapter, registry); err != nil {
+		t.Fatalf("resolvespectest: %v", err)
+	}
+	server := &Server{
+		DB:           db,
+		Database:     adapter,
+		Registry:     registry,
+		ResolveSpec:  resolvespec.NewHandler(adapter, registry, cfg.resolveSpecOptions...),
+		RestHeadSpec: restheadspec.NewHandler(adapter, registry, cfg.restHeadSpecOptions...),
+		t:            t,
+	}
+
+	if len(cfg.fixtures) > 0 {
+		loader := fixtures.NewLoader(adapter, registry, server.RestHeadSpec)
+		if server.Fixtures, err = loader.LoadFiles(context.Background(), cfg.fixtures...); err != nil {
+			t.Fatalf("resolvespectest: failed to load fixtures: %v", err)
+		}
+	}
+
+	server.Server = httptest.NewServer(server.routes())
+	t.Cleanup(server.Close)
+	return server
+}
+
+// routes mounts the APIs below /resolvespec and /restheadspec, without a schema in the path
+func (s *Server) routes() http.Handler {
+	r := mux.NewRouter()
+	handle := func(serve func(common.ResponseWriter, common.Request, map[string]string)) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			vars := mux.Vars(req)
+			vars["schema"] = ""
+			serve(router.NewHTTPResponseWriter(w), router.NewHTTPRequest(req), vars)
+		}
+	}
+
+	resolveSpec := r.PathPrefix("/resolvespec").Subrouter()
+	resolveSpec.HandleFunc("/{entity}", handle(s.ResolveSpec.Handle)).Methods("POST")
+	resolveSpec.HandleFunc("/{entity}/{id}", handle(s.ResolveSpec.Handle)).Methods("POST")
+	resolveSpec.HandleFunc("/{entity}", handle(s.ResolveSpec.HandleGet)).Methods("GET")
+
+	restHeadSpec := r.PathPrefix("/restheadspec").Subrouter()
+	restHeadSpec.HandleFunc("/{entity}", handle(s.RestHeadSpec.Handle)).Methods("GET", "POST", "PUT", "PATCH", "DELETE")
+	restHeadSpec.HandleFunc("/{entity}/{id}", handle(s.RestHeadSpec.Handle)).Methods("GET", "POST", "PUT", "PATCH", "DELETE")
+	return r
+}
@@ -0,0 +1,64 @@
+package restheadspec
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// Authenticator authenticates a request. It returns the context to continue with,
+// carrying the authenticated user, or an error to reject the request with 401.
+// The returned context is passed to all hooks (HookContext.Context), so hooks and
+// row/column security can read the user from it.
+type Authenticator func(ctx context.Context, r common.Request) (context.Context, error)
+
+// HandlerOption configures a Handler on creation
+type HandlerOption func(*Handler)
+
+// WithAuth requires every request to pass the authenticator, e.g.
+//
+//	handler := restheadspec.NewHandler(db, registry,
+//		restheadspec.WithAuth(security.GlobalSecurity.Authenticator()))
+func WithAuth(auth Authenticator) HandlerOption {
+	return func(h *Handler) {
+		h.authenticator = auth
+	}
+}
+
+// WithLogger makes the handler log through l; log lines of a request carry its request id
+func WithLogger(l logger.Interface) HandlerOption {
+	return func(h *Handler) {
+		h.log = l
+	}
+}
+
+// SetAuthenticator sets the authenticator every request must pass; nil disables authentication
+func (h *Handler) SetAuthenticator(auth Authenticator) {
+	h.authenticator = auth
+}
+
+// Authenticator returns the authenticator of this handler, or nil
+func (h *Handler) Authenticator() Authenticator {
+	return h.authenticator
+}
+
+// authenticate runs the authenticator, answering 401 and returning false when the
+// request is rejected
+func (h *Handler) authenticate(ctx context.Context, w common.ResponseWriter, r common.Request) (context.Context, bool) {
+	if h.authenticator == nil {
+		return ctx, true
+	}
+
+	authCtx, err := h.authenticator(ctx, r)
+	if err != nil {
+		logger.Warn("Authentication failed for %s %s: %v", r.Method(), r.URL(), err)
+		h.sendError(w, http.StatusUnauthorized, "unauthorized", "Authentication failed", err)
+		return ctx, false
+	}
+	if authCtx == nil {
+		authCtx = ctx
+	}
+	return authCtx, true
+}
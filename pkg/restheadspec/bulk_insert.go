@@ -0,0 +1,166 @@
+package restheadspec
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// SetBulkInsertThreshold sets the number of records above which an array create is loaded
+// with a single bulk insert (COPY FROM on PostgreSQL) when the database implements
+// common.BulkInserter. Bulk inserts skip the per-record BeforeScan hooks and don't load
+// generated keys into the response. 0 disables bulk inserts.
+func (h *Handler) SetBulkInsertThreshold(n int) {
+	h.bulkThreshold = n
+}
+
+// bulkInsertRows returns the columns and rows of a bulk insert of the created items. ok is
+// false for creates up to the threshold, databases without bulk inserts, and items with
+// nested relations, keys that are no columns, or different keys.
+func (h *Handler) bulkInsertRows(items []interface{}, model interface{}) (columns []string, rows [][]interface{}, ok bool) {
+	if h.bulkThreshold <= 0 || len(items) <= h.bulkThreshold {
+		return nil, nil, false
+	}
+	if _, ok := h.db.(common.BulkInserter); !ok {
+		return nil, nil, false
+	}
+
+	records := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		record, ok := item.(map[string]interface{})
+		if !ok || h.shouldUseNestedProcessor(record, model) {
+			return nil, nil, false
+		}
+		records[i] = record
+	}
+
+	columnsByKey := modelColumnsByKey(model)
+	return common.BulkInsertRows(records, func(key string) (string, bool) {
+		column, ok := columnsByKey[key]
+		return column, ok
+	})
+}
+
+// modelColumnsByKey maps the JSON names and column names of the writable columns of a model
+// to the column names
+func modelColumnsByKey(model interface{}) map[string]string {
+	columns := make(map[string]string)
+	for _, column := range reflection.GetSQLModelColumns(model) {
+		columns[column] = column
+	}
+
+	modelType := reflect.TypeOf(model)
+	for modelType.Kind() == reflect.Pointer {
+		modelType = modelType.Elem()
+	}
+	for _, field := range reflect.VisibleFields(modelType) {
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if jsonName == "" || jsonName == "-" {
+			continue
+		}
+		if column := reflection.GetColumnNameForField(model, field.Name); columns[column] == column && column != "" {
+			columns[jsonName] = column
+		}
+	}
+	return columns
+}
+
+// handleBulkCreate creates the items with a single bulk insert in a transaction and answers
+// with the records as sent
+func (h *Handler) handleBulkCreate(ctx context.Context, w common.ResponseWriter, hookCtx *HookContext, items []interface{}, columns []string, rows [][]interface{}, options ExtendedRequestOptions) {
+	var created int64
+	err := h.db.RunInTransaction(ctx, func(tx common.Database) error {
+		bulk, ok := tx.(common.BulkInserter)
+		if !ok {
+			return fmt.Errorf("transaction %T has no bulk insert", tx)
+		}
+		var err error
+		created, err = bulk.BulkInsert(ctx, hookCtx.TableName, columns, rows)
+		return err
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Error bulk inserting records: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "create_error", "Error creating records", err)
+		return
+	}
+
+	hookCtx.Result = map[string]interface{}{"created": len(items), "data": items}
+	hookCtx.Error = nil
+	if err := h.hooks.Execute(AfterCreate, hookCtx); err != nil {
+		logger.ErrorContext(ctx, "AfterCreate hook failed: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "hook_error", "Hook execution failed", err)
+		return
+	}
+	h.queueAfterCommit(hookCtx, common.BatchActionCreate)
+
+	logger.InfoContext(ctx, "Successfully created %d record(s) with a bulk insert", created)
+	h.sendResponseWithOptions(w, items, nil, &options)
+}
+
+// insertChunked reports whether the models of an array create are inserted with multi-row
+// INSERT statements: arrays of more than one item without nested relations, unless
+// HandlerConfig.InsertChunkSize is negative
+func (h *Handler) insertChunked(relations []map[string]interface{}) bool {
+	if h.config.InsertChunkSize < 0 || len(relations) < 2 {
+		return false
+	}
+	for _, nested := range relations {
+		if len(nested) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// insertModelChunks inserts the models of an array create with InsertQuery.Bulk in chunks of
+// HandlerConfig.InsertChunkSize rows and returns them with their generated keys. The BeforeScan
+// and AfterScan hooks run once, with the slice of models as Data.
+func (h *Handler) insertModelChunks(ctx context.Context, tx common.Database, hookCtx *HookContext, models []interface{}) ([]interface{}, error) {
+	slice := reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(models[0])), len(models), len(models))
+	for i, model := range models {
+		slice.Index(i).Set(reflect.ValueOf(model))
+	}
+	modelsPtr := reflect.New(slice.Type())
+	modelsPtr.Elem().Set(slice)
+
+	query := tx.NewInsert().Bulk(modelsPtr.Interface(), h.config.InsertChunkSize)
+	// Only set Table() if the model doesn't provide a table name via TableNameProvider
+	if provider, ok := models[0].(common.TableNameProvider); !ok || provider.TableName() == "" {
+		query = query.Table(hookCtx.TableName)
+	}
+	query = query.Returning("*")
+
+	scanCtx := *hookCtx
+	scanCtx.Data = modelsPtr.Interface()
+	scanCtx.Query = query
+	if err := h.hooks.Execute(BeforeScan, &scanCtx); err != nil {
+		return nil, fmt.Errorf("BeforeScan hook failed: %w", err)
+	}
+	if modifiedQuery, ok := scanCtx.Query.(common.InsertQuery); ok {
+		query = modifiedQuery
+	}
+
+	started := time.Now()
+	if _, err := query.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to insert %d items: %w", len(models), err)
+	}
+	scanCtx.Duration = time.Since(started)
+	if err := h.hooks.Execute(AfterScan, &scanCtx); err != nil {
+		return nil, fmt.Errorf("AfterScan hook failed: %w", err)
+	}
+
+	// Adapters may scan returned rows into new models, so the results are read from the slice
+	inserted := modelsPtr.Elem()
+	results := make([]interface{}, inserted.Len())
+	for i := range results {
+		results[i] = inserted.Index(i).Interface()
+	}
+	return results, nil
+}
@@ -0,0 +1,76 @@
+package restheadspec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// errPreconditionFailed is returned when the If-Match header of a write does not
+// match the current entity tag of the record
+var errPreconditionFailed = errors.New("precondition failed: the record was modified or does not exist")
+
+// readETag computes the entity tag of a read result. Single record reads are tagged
+// with the record itself, so the tag can be sent back with If-Match on a write;
+// list reads are tagged with the result set and the total count.
+func readETag(id string, records interface{}, total int) (string, error) {
+	if id != "" {
+		value := reflect.ValueOf(records)
+		for value.Kind() == reflect.Ptr {
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.Slice && value.Len() == 1 {
+			return common.ComputeETag(value.Index(0).Interface())
+		}
+	}
+	return common.ComputeETag(map[string]interface{}{"data": records, "total": total})
+}
+
+// writeETag sets the ETag header and answers 304 Not Modified when the If-None-Match
+// header of the request matches. It returns true when the response was written.
+func (h *Handler) writeETag(w common.ResponseWriter, etag string, options ExtendedRequestOptions) bool {
+	if etag == "" {
+		return false
+	}
+	w.SetHeader("ETag", etag)
+	if common.MatchETag(options.IfNoneMatch, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// recordETag loads the record with the given primary key and returns its entity tag.
+// found is false when no such record exists.
+func recordETag(ctx context.Context, db common.Database, model interface{}, id interface{}) (etag string, found bool, err error) {
+	record, found, err := common.LoadRecord(ctx, db, model, id)
+	if err != nil || !found {
+		return "", false, err
+	}
+
+	etag, err = common.ComputeETag(record)
+	return etag, err == nil, err
+}
+
+// checkIfMatch verifies an If-Match precondition against the current state of a record.
+// It returns errPreconditionFailed when the record changed or does not exist.
+func checkIfMatch(ctx context.Context, db common.Database, model interface{}, id interface{}, ifMatch string) error {
+	if ifMatch == "" {
+		return nil
+	}
+
+	etag, found, err := recordETag(ctx, db, model, id)
+	if err != nil {
+		return fmt.Errorf("failed to load record for If-Match: %w", err)
+	}
+	if !found || !common.MatchETag(ifMatch, etag, false) {
+		logger.Debug("If-Match precondition failed for %T %v", model, id)
+		return errPreconditionFailed
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package restheadspec
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/bitechdev/ResolveSpec/pkg/common"
@@ -32,9 +33,26 @@ func (opts *ExtendedRequestOptions) GetCursorFilter(
 	modelColumns []string, // optional: for validation
 	expandJoins map[string]string, // optional: alias → JOIN SQL
 ) (string, error) {
+	return opts.GetCursorFilterWithAlias(tableName, "", pkName, modelColumns, expandJoins)
+}
+
+// GetCursorFilterWithAlias is GetCursorFilter for a main query qualifying the columns of its table
+// with an alias, e.g. "golden_employee" for FROM "employees" AS "golden_employee". An empty alias
+// qualifies them with the table name.
+func (opts *ExtendedRequestOptions) GetCursorFilterWithAlias(
+	tableName string,
+	alias string,
+	pkName string,
+	modelColumns []string,
+	expandJoins map[string]string,
+) (string, error) {
+	fromTable := tableName
 	if strings.Contains(tableName, ".") {
 		tableName = strings.SplitN(tableName, ".", 2)[1]
 	}
+	if alias == "" {
+		alias = tableName
+	}
 	// --------------------------------------------------------------------- //
 	// 1. Determine active cursor
 	// --------------------------------------------------------------------- //
@@ -54,8 +72,8 @@ func (opts *ExtendedRequestOptions) GetCursorFilter(
 	// --------------------------------------------------------------------- //
 	// 3. Prepare
 	// --------------------------------------------------------------------- //
-	var whereClauses []string
-	joinSQL := ""
+	var whereClauses, equalClauses, joins []string
+	joined := make(map[string]bool)
 	reverse := direction < 0
 
 	// --------------------------------------------------------------------- //
@@ -83,21 +101,29 @@ func (opts *ExtendedRequestOptions) GetCursorFilter(
 
 		// Resolve column
 		cursorCol, targetCol, isJoin, err := opts.resolveColumn(
-			field, prefix, tableName, modelColumns,
+			field, prefix, tableName, alias, modelColumns,
 		)
 		if err != nil {
 			logger.Warn("Skipping invalid sort column %q: %v", col, err)
 			continue
 		}
 
-		// Handle joins
-		if isJoin && expandJoins != nil {
-			if joinClause, ok := expandJoins[prefix]; ok {
-				jSQL, cRef := rewriteJoin(joinClause, tableName, prefix)
-				joinSQL = jSQL
-				cursorCol = cRef + "." + field
-				targetCol = prefix + "." + field
+		// Joined columns compare the row of the cursor joined like the main query
+		if isJoin {
+			joinClause, ok := lookupJoin(expandJoins, strings.Trim(prefix, "\"`"))
+			if !ok {
+				return "", fmt.Errorf("sort column %s belongs to relation %s, which is not joined: expand it to page by its columns", col, prefix)
+			}
+			jSQL, joinAlias, cRef, err := rewriteJoin(joinClause, tableName)
+			if err != nil {
+				return "", err
+			}
+			if !joined[cRef] {
+				joined[cRef] = true
+				joins = append(joins, jSQL)
 			}
+			cursorCol = cRef + "." + field
+			targetCol = joinAlias + "." + field
 		}
 
 		// Build inequality
@@ -106,6 +132,7 @@ func (opts *ExtendedRequestOptions) GetCursorFilter(
 			op = ">"
 		}
 		whereClauses = append(whereClauses, fmt.Sprintf("%s %s %s", cursorCol, op, targetCol))
+		equalClauses = append(equalClauses, fmt.Sprintf("%s = %s", cursorCol, targetCol))
 	}
 
 	if len(whereClauses) == 0 {
@@ -115,7 +142,7 @@ func (opts *ExtendedRequestOptions) GetCursorFilter(
 	// --------------------------------------------------------------------- //
 	// 5. Build priority OR-AND chain
 	// --------------------------------------------------------------------- //
-	orSQL := buildPriorityChain(whereClauses)
+	orSQL := buildPriorityChain(whereClauses, equalClauses)
 
 	// --------------------------------------------------------------------- //
 	// 6. Final EXISTS subquery
@@ -127,8 +154,8 @@ func (opts *ExtendedRequestOptions) GetCursorFilter(
   WHERE cursor_select.%s = %s
     AND (%s)
 )`,
-		tableName,
-		joinSQL,
+		fromTable,
+		strings.Join(joins, "\n  "),
 		pkName,
 		cursorID,
 		orSQL,
@@ -168,13 +195,13 @@ func (opts *ExtendedRequestOptions) cleanSortField(field string) string {
 
 // Helper: resolve column (main, JSON, CQL, join)
 func (opts *ExtendedRequestOptions) resolveColumn(
-	field, prefix, tableName string,
+	field, prefix, tableName, alias string,
 	modelColumns []string,
 ) (cursorCol, targetCol string, isJoin bool, err error) {
 
 	// JSON field
 	if strings.Contains(field, "->") {
-		return "cursor_select." + field, tableName + "." + field, false, nil
+		return "cursor_select." + field, alias + "." + field, false, nil
 	}
 
 	// CQL via ComputedQL
@@ -184,43 +211,68 @@ func (opts *ExtendedRequestOptions) resolveColumn(
 		}
 	}
 
+	// Joined column, whether or not the main table has a column of the name
+	if prefix != "" && !strings.EqualFold(prefix, tableName) && !strings.EqualFold(prefix, alias) {
+		return "", "", true, nil
+	}
+
 	// Main table column
 	if modelColumns != nil {
 		for _, col := range modelColumns {
 			if strings.EqualFold(col, field) {
-				return "cursor_select." + field, tableName + "." + field, false, nil
+				return "cursor_select." + field, alias + "." + field, false, nil
 			}
 		}
 	} else {
 		// No validation → allow all main-table fields
-		return "cursor_select." + field, tableName + "." + field, false, nil
-	}
-
-	// Joined column
-	if prefix != "" && prefix != tableName {
-		return "", "", true, nil
+		return "cursor_select." + field, alias + "." + field, false, nil
 	}
 
 	return "", "", false, fmt.Errorf("invalid column: %s", field)
 }
 
 // ------------------------------------------------------------------------- //
-// Helper: rewrite JOIN clause for cursor subquery
-func rewriteJoin(joinClause, mainTable, alias string) (joinSQL, cursorAlias string) {
-	joinSQL = strings.ReplaceAll(joinClause, mainTable+".", "cursor_select.")
-	cursorAlias = "cursor_select_" + alias
-	joinSQL = strings.ReplaceAll(joinSQL, " "+alias+" ", " "+cursorAlias+" ")
-	joinSQL = strings.ReplaceAll(joinSQL, " "+alias+".", " "+cursorAlias+".")
-	return joinSQL, cursorAlias
+// Helper: find the JOIN clause of a relation, by its name in X-Expand or its JSON name
+func lookupJoin(expandJoins map[string]string, relation string) (string, bool) {
+	if joinClause, ok := expandJoins[relation]; ok {
+		return joinClause, true
+	}
+	for name, joinClause := range expandJoins {
+		if strings.EqualFold(name, relation) {
+			return joinClause, true
+		}
+	}
+	return "", false
+}
+
+// Helper: rewrite a "LEFT JOIN table alias ON condition" clause of the main query for the cursor
+// subquery, joining the cursor row under its own alias. alias is the alias of the main query.
+func rewriteJoin(joinClause, mainTable string) (joinSQL, alias, cursorAlias string, err error) {
+	fields := strings.Fields(joinClause)
+	if len(fields) < 6 || !strings.EqualFold(fields[0], "left") || !strings.EqualFold(fields[1], "join") || !strings.EqualFold(fields[4], "on") {
+		return "", "", "", fmt.Errorf("unsupported join for cursor pagination: %s", joinClause)
+	}
+	table, alias := fields[2], fields[3]
+	cursorAlias = "cursor_select_" + strings.Trim(alias, "\"`")
+	on := strings.Join(fields[5:], " ")
+	on = qualifierPattern(alias).ReplaceAllString(on, "${1}"+cursorAlias+".")
+	on = qualifierPattern(mainTable).ReplaceAllString(on, "${1}cursor_select.")
+	return fmt.Sprintf("LEFT JOIN %s %s ON %s", table, cursorAlias, on), alias, cursorAlias, nil
+}
+
+// Helper: match the columns of a table qualified by its name or alias
+func qualifierPattern(qualifier string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|[^\w.])` + regexp.QuoteMeta(qualifier) + `\.`)
 }
 
 // ------------------------------------------------------------------------- //
-// Helper: build OR-AND priority chain
-func buildPriorityChain(clauses []string) string {
+// Helper: build OR-AND priority chain comparing the sort columns in order: a row follows the
+// cursor when a column compares past it and the columns before are equal
+func buildPriorityChain(clauses, equals []string) string {
 	var or []string
 	for i := 0; i < len(clauses); i++ {
-		and := strings.Join(clauses[:i+1], "\n    AND ")
-		or = append(or, "("+and+")")
+		and := append(append([]string{}, equals[:i]...), clauses[i])
+		or = append(or, "("+strings.Join(and, "\n    AND ")+")")
 	}
 	return strings.Join(or, "\n  OR ")
 }
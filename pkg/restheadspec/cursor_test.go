@@ -0,0 +1,53 @@
+package restheadspec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+func TestRewriteJoin(t *testing.T) {
+	joinSQL, alias, cursorAlias, err := rewriteJoin("LEFT JOIN department department ON department.id = employees.department_id", "employees")
+	require.NoError(t, err)
+	assert.Equal(t, "department", alias)
+	assert.Equal(t, "cursor_select_department", cursorAlias)
+	assert.Equal(t, "LEFT JOIN department cursor_select_department ON cursor_select_department.id = cursor_select.department_id", joinSQL,
+		"a table named like its alias keeps its name")
+
+	_, _, _, err = rewriteJoin("JOIN departments ON true", "employees")
+	assert.Error(t, err)
+}
+
+func TestGetCursorFilterJoins(t *testing.T) {
+	options := ExtendedRequestOptions{}
+	options.CursorForward = "7"
+	options.Sort = sortOptions("department.name", "asc", "manager.name", "desc", "id", "asc")
+	joins := map[string]string{
+		"Department": "LEFT JOIN departments department ON department.id = employees.department_id",
+		"manager":    "LEFT JOIN users manager ON manager.id = employees.manager_id",
+	}
+
+	filter, err := options.GetCursorFilterWithAlias("hr.employees", "employee", "id", []string{"id", "name"}, joins)
+	require.NoError(t, err)
+	assert.Contains(t, filter, "FROM hr.employees cursor_select")
+	assert.Contains(t, filter, "LEFT JOIN departments cursor_select_department ON cursor_select_department.id = cursor_select.department_id")
+	assert.Contains(t, filter, "LEFT JOIN users cursor_select_manager ON cursor_select_manager.id = cursor_select.manager_id")
+	assert.Contains(t, filter, "(cursor_select_department.name < department.name)")
+	assert.Contains(t, filter, "cursor_select_department.name = department.name\n    AND cursor_select_manager.name > manager.name")
+	assert.Contains(t, filter, "cursor_select.id < employee.id", "main table columns are qualified by the alias")
+
+	options.Sort = sortOptions("owner.name", "asc")
+	_, err = options.GetCursorFilterWithAlias("employees", "", "id", nil, joins)
+	assert.Error(t, err, "sorts on relations that aren't joined are rejected")
+}
+
+func sortOptions(pairs ...string) []common.SortOption {
+	sorts := make([]common.SortOption, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		sorts = append(sorts, common.SortOption{Column: pairs[i], Direction: pairs[i+1]})
+	}
+	return sorts
+}
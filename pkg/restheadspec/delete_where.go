@@ -0,0 +1,151 @@
+package restheadspec
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/tracing"
+)
+
+// handleDeleteWhere deletes all rows matching the filters of the body and the filter headers with
+// a single DELETE and answers with the number of deleted rows. It needs the X-Confirm-Bulk header;
+// X-Write-Limit limits the rows it may delete, rolling back deletes of more rows. A dry run
+// answers with the number of matching rows without deleting them.
+func (h *Handler) handleDeleteWhere(ctx context.Context, w common.ResponseWriter, filter common.DeleteFilter, options ExtendedRequestOptions, confirm string) {
+	// Capture panics and return error response
+	defer func() {
+		if err := recover(); err != nil {
+			h.handlePanic(w, "handleDeleteWhere", err)
+		}
+	}()
+
+	ctx, span := tracing.StartSpan(ctx, "restheadspec.delete_where", tracing.AttrOperation.String("delete"))
+	defer span.End()
+
+	schema := GetSchema(ctx)
+	entity := GetEntity(ctx)
+	tableName := GetTableName(ctx)
+	model := GetModel(ctx)
+
+	logger.InfoContext(ctx, "Deleting records by filter from %s.%s", schema, entity)
+
+	if !filter.DryRun {
+		if limitErr := common.ConfirmDelete(confirm); limitErr != nil {
+			h.rejectLimit(w, limitErr)
+			return
+		}
+	}
+	if err := common.NewColumnValidator(model).ValidateFilters(filter.Filters, filter.FilterGroup); err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid_filter", "Invalid filter", err)
+		return
+	}
+
+	hookCtx := &HookContext{
+		Context:   ctx,
+		Handler:   h,
+		Schema:    schema,
+		Entity:    entity,
+		TableName: tableName,
+		Model:     model,
+		Options:   options,
+		Data:      filter,
+		Writer:    w,
+	}
+	if err := h.hooks.Execute(BeforeDelete, hookCtx); err != nil {
+		logger.ErrorContext(ctx, "BeforeDelete hook failed: %v", err)
+		h.sendError(w, http.StatusBadRequest, "hook_error", "Hook execution failed", err)
+		return
+	}
+
+	where, args, err := h.filterWhere(options.Filters, options.FilterGroup, model, tableName)
+	if err == nil {
+		var bodyWhere string
+		var bodyArgs []interface{}
+		bodyWhere, bodyArgs, err = h.filterWhere(filter.Filters, filter.FilterGroup, model, tableName)
+		where, args = common.AndWhere(where, args, bodyWhere, bodyArgs)
+	}
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid_filter", "Invalid filter", err)
+		return
+	}
+	if where == "" {
+		// Confirmed deletes of all rows still need a condition for the adapters
+		where = "1 = 1"
+	}
+
+	if filter.DryRun {
+		matched, err := h.db.NewSelect().Table(tableName).Where(where, args...).Count(ctx)
+		if err != nil {
+			logger.ErrorContext(ctx, "Error counting records to delete: %v", err)
+			h.sendError(w, http.StatusInternalServerError, "query_error", "Error counting records", err)
+			return
+		}
+		h.sendResponse(w, map[string]interface{}{"dry_run": true, "matched": matched}, nil)
+		return
+	}
+
+	var deleted int64
+	var records []interface{}
+	err = h.db.RunInTransaction(ctx, func(tx common.Database) error {
+		if options.ReturnRecords {
+			loaded, err := common.LoadRecords(ctx, tx, model, tableName, where, args...)
+			if err != nil {
+				return err
+			}
+			records = loaded
+		}
+
+		query := tx.NewDelete().Table(tableName).Where(where, args...)
+
+		hookCtx.Query = query
+		if err := h.hooks.Execute(BeforeScan, hookCtx); err != nil {
+			return fmt.Errorf("BeforeScan hook failed: %w", err)
+		}
+		if modifiedQuery, ok := hookCtx.Query.(common.DeleteQuery); ok {
+			query = modifiedQuery
+		}
+
+		started := time.Now()
+		result, err := query.Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to delete records: %w", err)
+		}
+		hookCtx.Duration = time.Since(started)
+		deleted = result.RowsAffected()
+		if limitErr := (common.WriteGuard{Limit: options.WriteGuard.Limit}).CheckRows(deleted); limitErr != nil {
+			return limitErr
+		}
+		if err := h.hooks.Execute(AfterScan, hookCtx); err != nil {
+			return fmt.Errorf("AfterScan hook failed: %w", err)
+		}
+		return nil
+	})
+	if h.rejectLimit(w, err) {
+		return
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error deleting records by filter: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "delete_error", "Error deleting records", err)
+		return
+	}
+
+	result := map[string]interface{}{"deleted": deleted}
+	if options.ReturnRecords {
+		result["records"] = records
+	}
+	hookCtx.Result = result
+	hookCtx.Error = nil
+	if err := h.hooks.Execute(AfterDelete, hookCtx); err != nil {
+		logger.ErrorContext(ctx, "AfterDelete hook failed: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "hook_error", "Hook execution failed", err)
+		return
+	}
+	h.queueAfterCommit(hookCtx, common.BatchActionDelete)
+
+	logger.InfoContext(ctx, "Successfully deleted %d records by filter", deleted)
+	h.sendResponse(w, hookCtx.Result, nil)
+}
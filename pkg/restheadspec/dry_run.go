@@ -0,0 +1,23 @@
+package restheadspec
+
+import (
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// handleDryRun handles a request with X-Dry-Run on a copy of the handler whose database
+// records the statements instead of executing them. Validation and hooks run as usual; the
+// response lists the statements with their bind parameters, or the error of the request.
+func (h *Handler) handleDryRun(w common.ResponseWriter, r common.Request, params map[string]string) {
+	db, recorder := common.NewDryRunDatabase(h.db)
+	dryRun := *h
+	dryRun.db = db
+	dryRun.nestedProcessor = common.NewNestedCUDProcessor(db, h.registry, &dryRun)
+	dryRun.dryRun = recorder
+
+	writer := common.NewDryRunWriter(w)
+	dryRun.Handle(writer, r, params)
+	if err := writer.Finish(recorder); err != nil {
+		logger.Error("Error sending dry run response: %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+package restheadspec
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// expandJoin describes a belongsTo/hasOne relation that can be loaded in the
+// main query with a LEFT JOIN instead of a separate preload query
+type expandJoin struct {
+	fieldName    string // Go field name, used by the ORM to resolve the relation
+	jsonName     string
+	relationType string // "belongsTo" or "hasOne"
+	alias        string // SQL alias for the joined table, the one the database joins it under
+	table        string // related table name
+	baseColumns  []string
+	joinColumns  []string
+	relatedModel interface{}
+}
+
+// onClause builds the JOIN condition against the given main table name
+func (j *expandJoin) onClause(tableName string) string {
+	conds := make([]string, len(j.baseColumns))
+	for i := range j.baseColumns {
+		conds[i] = fmt.Sprintf("%s.%s = %s.%s", j.alias, j.joinColumns[i], tableName, j.baseColumns[i])
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// joinSQL returns the LEFT JOIN clause for raw SQL queries (row numbers, cursors)
+func (j *expandJoin) joinSQL(tableName string) string {
+	return fmt.Sprintf("LEFT JOIN %s %s ON %s", j.table, j.alias, j.onClause(tableName))
+}
+
+// resolveExpandJoin returns JOIN metadata for an expand relation, using the
+// foreign key/references from the bun or gorm tags of the relation field.
+// It returns nil for hasMany and many2many relations, nested paths and fields
+// without relationship tags; those are expanded through Preload instead.
+func (h *Handler) resolveExpandJoin(model interface{}, relation string) *expandJoin {
+	if relation == "" || strings.Contains(relation, ".") {
+		return nil
+	}
+
+	modelType := reflect.TypeOf(model)
+	for modelType != nil && (modelType.Kind() == reflect.Ptr || modelType.Kind() == reflect.Slice) {
+		modelType = modelType.Elem()
+	}
+	if modelType == nil || modelType.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var field *reflect.StructField
+	for i := 0; i < modelType.NumField(); i++ {
+		f := modelType.Field(i)
+		jsonName := strings.Split(f.Tag.Get("json"), ",")[0]
+		if f.Name == relation || (jsonName != "" && jsonName == relation) {
+			field = &f
+			break
+		}
+	}
+	if field == nil {
+		return nil
+	}
+
+	// Only single-valued relations can be joined without multiplying the main rows
+	relatedType := field.Type
+	if relatedType.Kind() == reflect.Ptr {
+		relatedType = relatedType.Elem()
+	}
+	if relatedType.Kind() != reflect.Struct {
+		return nil
+	}
+	relatedModel := reflect.New(relatedType).Elem().Interface()
+	baseModel := reflect.New(modelType).Elem().Interface()
+
+	// Columns of the relation are qualified by the alias the database joins it under, e.g.
+	// "Department" with gorm, which may differ from the snake case name in case and quoting
+	alias := reflection.ToSnakeCase(field.Name)
+	if provider, ok := h.db.(common.RelationReferenceProvider); ok {
+		if reference := provider.RelationReference(baseModel, field.Name); reference != "" {
+			alias = reference
+		}
+	}
+
+	join := &expandJoin{
+		fieldName:    field.Name,
+		jsonName:     strings.Split(field.Tag.Get("json"), ",")[0],
+		alias:        alias,
+		table:        reflection.ExtractTableNameOnly(h.getTableNameForRelatedModel(relatedModel, reflection.ToSnakeCase(field.Name))),
+		relatedModel: relatedModel,
+	}
+
+	if !h.parseBunJoinTag(join, field.Tag.Get("bun")) && !h.parseGormJoinTag(join, field.Tag.Get("gorm"), baseModel, relatedModel) {
+		return nil
+	}
+	if len(join.baseColumns) == 0 || len(join.baseColumns) != len(join.joinColumns) {
+		return nil
+	}
+
+	return join
+}
+
+// parseBunJoinTag reads rel:belongs-to/has-one with join:base_col=join_col pairs
+func (h *Handler) parseBunJoinTag(join *expandJoin, bunTag string) bool {
+	if bunTag == "" {
+		return false
+	}
+
+	for _, part := range strings.Split(bunTag, ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == "rel:belongs-to":
+			join.relationType = "belongsTo"
+		case part == "rel:has-one":
+			join.relationType = "hasOne"
+		case strings.HasPrefix(part, "rel:"):
+			return false
+		case strings.HasPrefix(part, "join:"):
+			pair := strings.SplitN(strings.TrimPrefix(part, "join:"), "=", 2)
+			if len(pair) != 2 {
+				return false
+			}
+			join.baseColumns = append(join.baseColumns, strings.TrimSpace(pair[0]))
+			join.joinColumns = append(join.joinColumns, strings.TrimSpace(pair[1]))
+		}
+	}
+
+	return join.relationType != ""
+}
+
+// parseGormJoinTag reads foreignKey/references. The relation is belongsTo when the
+// foreign key field lives on the base model and hasOne when it lives on the related model.
+func (h *Handler) parseGormJoinTag(join *expandJoin, gormTag string, baseModel, relatedModel interface{}) bool {
+	if gormTag == "-" || strings.Contains(gormTag, "many2many") {
+		return false
+	}
+
+	foreignKey := h.extractTagValue(gormTag, "foreignKey")
+	references := h.extractTagValue(gormTag, "references")
+	if foreignKey == "" {
+		// GORM convention for belongsTo: <Field>ID on the base model
+		foreignKey = join.fieldName + "ID"
+		if reflection.GetColumnNameForField(baseModel, foreignKey) == "" {
+			return false
+		}
+	}
+
+	if fkColumn := reflection.GetColumnNameForField(baseModel, foreignKey); fkColumn != "" {
+		refColumn := reflection.GetPrimaryKeyName(relatedModel)
+		if references != "" {
+			refColumn = reflection.GetColumnNameForField(relatedModel, references)
+		}
+		if refColumn == "" {
+			return false
+		}
+		join.relationType = "belongsTo"
+		join.baseColumns = []string{fkColumn}
+		join.joinColumns = []string{refColumn}
+		return true
+	}
+
+	if fkColumn := reflection.GetColumnNameForField(relatedModel, foreignKey); fkColumn != "" {
+		refColumn := reflection.GetPrimaryKeyName(baseModel)
+		if references != "" {
+			refColumn = reflection.GetColumnNameForField(baseModel, references)
+		}
+		if refColumn == "" {
+			return false
+		}
+		join.relationType = "hasOne"
+		join.baseColumns = []string{refColumn}
+		join.joinColumns = []string{fkColumn}
+		return true
+	}
+
+	return false
+}
+
+// expandSorts returns the sorts of a read with the sorts on columns of relations expanded with a
+// LEFT JOIN, e.g. "department.name", which the column validator of the model drops. The relation
+// is qualified by its join alias; sorts on other relations or unknown columns are dropped.
+func (h *Handler) expandSorts(model interface{}, sorts []common.SortOption, validator *common.ColumnValidator, expands []ExpandOption) []common.SortOption {
+	result := make([]common.SortOption, 0, len(sorts))
+	for _, sort := range sorts {
+		if validator.IsValidColumn(sort.Column) {
+			result = append(result, sort)
+			continue
+		}
+		dot := strings.LastIndex(sort.Column, ".")
+		if dot <= 0 {
+			logger.Warn("Invalid column in sort '%s' removed", sort.Column)
+			continue
+		}
+		relation, column := sort.Column[:dot], sort.Column[dot+1:]
+		var join *expandJoin
+		for _, expand := range expands {
+			if expand.Where != "" {
+				continue
+			}
+			if candidate := h.resolveExpandJoin(model, expand.Relation); candidate != nil &&
+				(strings.EqualFold(candidate.fieldName, relation) || strings.EqualFold(candidate.jsonName, relation)) {
+				join = candidate
+				break
+			}
+		}
+		if join == nil || !common.NewColumnValidator(join.relatedModel).IsValidColumn(column) {
+			logger.Warn("Sort '%s' removed: sorts on relations need an expanded belongsTo/hasOne relation and a column of it", sort.Column)
+			continue
+		}
+		sort.Column = join.alias + "." + column
+		result = append(result, sort)
+	}
+	return result
+}
+
+// plainColumn matches the unqualified column names of sorts
+var plainColumn = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// qualifySort qualifies a sort on a column of the main table with the reference of the table, so
+// it stays unambiguous next to joined tables with a column of the same name. Computed (cql)
+// columns, JSON paths and qualified columns are returned as they are.
+func qualifySort(column, tableRef string) string {
+	if !plainColumn.MatchString(column) || strings.HasPrefix(strings.ToLower(column), "cql") {
+		return column
+	}
+	return tableRef + "." + column
+}
@@ -0,0 +1,75 @@
+package restheadspec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type expandCompany struct {
+	ID   int64  `json:"id" gorm:"column:id;primaryKey"`
+	Name string `json:"name" gorm:"column:name"`
+}
+
+func (expandCompany) TableName() string { return "public.companies" }
+
+type expandProfile struct {
+	ID     int64  `json:"id" gorm:"column:id;primaryKey"`
+	UserID int64  `json:"user_id" gorm:"column:user_id"`
+	Bio    string `json:"bio" gorm:"column:bio"`
+}
+
+type expandOrder struct {
+	ID     int64 `json:"id" bun:"id,pk"`
+	UserID int64 `json:"user_id" bun:"user_id"`
+}
+
+type expandUser struct {
+	ID        int64          `json:"id" bun:"id,pk" gorm:"column:id;primaryKey"`
+	CompanyID int64          `json:"company_id" bun:"company_id" gorm:"column:company_id"`
+	Company   *expandCompany `json:"company" bun:"rel:belongs-to,join:company_id=id"`
+	Employer  *expandCompany `json:"employer" gorm:"foreignKey:CompanyID;references:ID"`
+	Profile   *expandProfile `json:"profile" gorm:"foreignKey:UserID"`
+	Orders    []expandOrder  `json:"orders" bun:"rel:has-many,join:id=user_id"`
+	Tags      []string       `json:"tags"`
+}
+
+func TestResolveExpandJoin(t *testing.T) {
+	h := &Handler{}
+
+	t.Run("bun belongs-to", func(t *testing.T) {
+		join := h.resolveExpandJoin(expandUser{}, "Company")
+		require.NotNil(t, join)
+		assert.Equal(t, "belongsTo", join.relationType)
+		assert.Equal(t, "companies", join.table)
+		assert.Equal(t, "LEFT JOIN companies company ON company.id = users.company_id", join.joinSQL("users"))
+	})
+
+	t.Run("lookup by json name", func(t *testing.T) {
+		join := h.resolveExpandJoin(&expandUser{}, "company")
+		require.NotNil(t, join)
+		assert.Equal(t, "Company", join.fieldName)
+	})
+
+	t.Run("gorm belongsTo", func(t *testing.T) {
+		join := h.resolveExpandJoin(expandUser{}, "Employer")
+		require.NotNil(t, join)
+		assert.Equal(t, "belongsTo", join.relationType)
+		assert.Equal(t, "employer.id = users.company_id", join.onClause("users"))
+	})
+
+	t.Run("gorm hasOne", func(t *testing.T) {
+		join := h.resolveExpandJoin(expandUser{}, "Profile")
+		require.NotNil(t, join)
+		assert.Equal(t, "hasOne", join.relationType)
+		assert.Equal(t, "profile.user_id = users.id", join.onClause("users"))
+	})
+
+	t.Run("not joinable", func(t *testing.T) {
+		assert.Nil(t, h.resolveExpandJoin(expandUser{}, "Orders"), "hasMany falls back to preload")
+		assert.Nil(t, h.resolveExpandJoin(expandUser{}, "Tags"))
+		assert.Nil(t, h.resolveExpandJoin(expandUser{}, "Company.Name"), "nested paths fall back to preload")
+		assert.Nil(t, h.resolveExpandJoin(expandUser{}, "Missing"))
+	})
+}
@@ -0,0 +1,36 @@
+package restheadspec
+
+import (
+	"context"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// facetCounts counts the values of the facet columns of a list read over its filters. The
+// filters of a facet's own column are left out, so the other values of the column keep their
+// counts while one is selected, e.g. in a filter sidebar. Facets read the table without the
+// joins of expands.
+func (h *Handler) facetCounts(ctx context.Context, model interface{}, tableName string, options ExtendedRequestOptions, columns []string) (map[string]map[string]int64, error) {
+	if len(columns) == 0 {
+		return nil, nil
+	}
+	facets := make(map[string]map[string]int64, len(columns))
+	for _, column := range columns {
+		// Filters are adjusted to the column types in place, so each facet gets its own copy
+		facetOptions := options
+		facetOptions.Filters = append([]common.FilterOption(nil), options.Filters...)
+
+		query, _, err := h.applyReadFilters(ctx, h.db.NewSelect().Table(tableName), model, tableName, facetOptions, column)
+		if err != nil {
+			return nil, err
+		}
+		counts, err := common.FacetCounts(ctx, query, column)
+		if err != nil {
+			return nil, err
+		}
+		logger.DebugContext(ctx, "Counted %d values of facet %s", len(counts), column)
+		facets[column] = counts
+	}
+	return facets, nil
+}
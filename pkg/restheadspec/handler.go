@@ -1,20 +1,34 @@
 package restheadspec
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
 	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/hooks"
 	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/membudget"
+	"github.com/bitechdev/ResolveSpec/pkg/ratelimit"
 	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+	"github.com/bitechdev/ResolveSpec/pkg/tracing"
 )
 
+// BatchPath is the id segment of the batch endpoint: POST /{schema}/{entity}/_batch
+const BatchPath = "_batch"
+
+// TransactionPath is the entity segment of the cross-entity transaction endpoint: POST /{schema}/_transaction
+const TransactionPath = "_transaction"
+
 // Handler handles API requests using database and model abstractions
 // This handler reads filters, columns, and options from HTTP headers
 type Handler struct {
@@ -22,17 +36,45 @@ type Handler struct {
 	registry        common.ModelRegistry
 	hooks           *HookRegistry
 	nestedProcessor *common.NestedCUDProcessor
+	plugins         *common.PluginManager
+	rateLimiter     *ratelimit.Limiter
+	authenticator   Authenticator
+	bulkThreshold   int
+	config          common.HandlerConfig
+	log             logger.Interface
+	dryRun          *common.DryRunRecorder   // Set on the handler copy of a dry run request
+	tenant          *common.TenantScope      // Set on the handler copy of a request of a tenant
+	writeTx         bool                     // Set on the handler copy of a write request in a transaction
+	counts          *common.CountCache       // Totals of the entities with CountCached
+	tenantTables    *common.TenantTableCache // Tables with the tenant column, see scopedTables
 }
 
 // NewHandler creates a new API handler with database and registry abstractions
-func NewHandler(db common.Database, registry common.ModelRegistry) *Handler {
+func NewHandler(db common.Database, registry common.ModelRegistry, options ...HandlerOption) *Handler {
 	handler := &Handler{
-		db:       db,
-		registry: registry,
-		hooks:    NewHookRegistry(),
+		db:            db,
+		registry:      registry,
+		hooks:         NewHookRegistry(),
+		plugins:       common.NewPluginManager(),
+		bulkThreshold: common.DefaultBulkInsertThreshold,
+		counts:        common.NewCountCache(),
+		tenantTables:  common.NewTenantTableCache(),
 	}
 	// Initialize nested processor
 	handler.nestedProcessor = common.NewNestedCUDProcessor(db, registry, handler)
+	for _, option := range options {
+		option(handler)
+	}
+	return handler
+}
+
+// NewHandlerWithConfig creates a new API handler with pagination guardrails
+func NewHandlerWithConfig(db common.Database, registry common.ModelRegistry, config common.HandlerConfig, options ...HandlerOption) *Handler {
+	handler := NewHandler(db, registry, options...)
+	handler.config = config
+	if config.SlowQueryThreshold > 0 {
+		handler.hooks.Register(AfterScan, SlowQueryHook(config))
+	}
 	return handler
 }
 
@@ -42,8 +84,91 @@ func (h *Handler) Hooks() *HookRegistry {
 	return h.hooks
 }
 
+// countCache returns the count cache shared through the config, or the one of the handler
+func (h *Handler) countCache() *common.CountCache {
+	if h.config.CountCache != nil {
+		return h.config.CountCache
+	}
+	return h.counts
+}
+
+// GetDatabase returns the database used by this handler
+func (h *Handler) GetDatabase() common.Database {
+	return h.db
+}
+
+// GetRegistry returns the model registry used by this handler
+func (h *Handler) GetRegistry() common.ModelRegistry {
+	return h.registry
+}
+
+// HealthHandler serves the database ping status, pool statistics and registered model count
+// as JSON, for liveness and readiness probes
+func (h *Handler) HealthHandler() http.Handler {
+	return common.HealthHandler(h.db, h.registry)
+}
+
+// Use loads plugins into this handler
+// Each plugin registers its types, is initialized and registers its hooks.
+// To load globally registered plugins use handler.Use(common.RegisteredPlugins()...)
+func (h *Handler) Use(plugins ...common.Plugin) error {
+	return h.plugins.Load(h, plugins...)
+}
+
+// Plugins returns the plugin manager for this handler
+func (h *Handler) Plugins() *common.PluginManager {
+	return h.plugins
+}
+
+// withRequestLogger puts the logger of a request in the context, adding the request id to
+// every log line of the request
+func (h *Handler) withRequestLogger(ctx context.Context) context.Context {
+	l := h.log
+	if l == nil {
+		l = logger.Default()
+	}
+	if requestID := common.RequestIDFromContext(ctx); requestID != "" {
+		l = logger.With(l, logger.F("request_id", requestID))
+	}
+	return logger.WithContext(ctx, l)
+}
+
+// SetRateLimiter limits requests per entity and user; nil disables rate limiting
+func (h *Handler) SetRateLimiter(limiter *ratelimit.Limiter) {
+	h.rateLimiter = limiter
+}
+
+// RateLimiter returns the rate limiter of this handler, or nil
+func (h *Handler) RateLimiter() *ratelimit.Limiter {
+	return h.rateLimiter
+}
+
+// checkRateLimit answers 429 with a Retry-After header and returns false when the
+// operation exceeds the rate limit
+func (h *Handler) checkRateLimit(ctx context.Context, w common.ResponseWriter, schema, entity, operation string) bool {
+	allowed, wait := h.rateLimiter.Allow(ctx, schema, entity, operation)
+	if allowed {
+		return true
+	}
+	logger.WarnContext(ctx, "Rate limit exceeded for %s on %s.%s", operation, schema, entity)
+	w.SetHeader("Retry-After", strconv.Itoa(ratelimit.RetryAfterSeconds(wait)))
+	h.sendError(w, http.StatusTooManyRequests, "rate_limited", "Rate limit exceeded", nil)
+	return false
+}
+
+// abortResponse aborts a response that failed to write after its status was sent: the server
+// closes the connection, so clients see a broken response instead of a truncated body with a
+// success status. handlePanic passes http.ErrAbortHandler on to the server.
+func abortResponse(err error) {
+	logger.Error("Aborting response, failed to write it: %v", err)
+	panic(http.ErrAbortHandler)
+}
+
 // handlePanic is a helper function to handle panics with stack traces
 func (h *Handler) handlePanic(w common.ResponseWriter, method string, err interface{}) {
+	if err == http.ErrAbortHandler {
+		panic(err) // The response is aborted, see abortResponse
+	}
 	stack := debug.Stack()
 	logger.Error("Panic in %s: %v\nStack trace:\n%s", method, err, string(stack))
 	h.sendError(w, http.StatusInternalServerError, "internal_error", fmt.Sprintf("Internal server error in %s", method), fmt.Errorf("%v", err))
@@ -52,6 +177,32 @@ func (h *Handler) handlePanic(w common.ResponseWriter, method string, err interf
 // Handle processes API requests through router-agnostic interface
 // Options are read from HTTP headers instead of request body
 func (h *Handler) Handle(w common.ResponseWriter, r common.Request, params map[string]string) {
+	// Responses are compressed by the writer of the router adapter, negotiated before any wrapper
+	defer h.config.CompressResponse(w, r)()
+
+	// X-Dry-Run runs the request on a copy of the handler that records its statements instead
+	if h.dryRun == nil && common.IsDryRun(r.Header(common.DryRunHeader)) {
+		if !h.config.DryRunAllowed(r.Context()) {
+			h.sendError(w, http.StatusForbidden, "dry_run_forbidden", "Dry runs are not allowed for this request", nil)
+			return
+		}
+		h.handleDryRun(w, r, params)
+		return
+	}
+
+	// With a tenant column the request runs on a copy of the handler scoped to its tenant, once it
+	// is authenticated
+	if h.tenant == nil && h.config.TenantColumn != "" {
+		h.handleTenant(w, r, params)
+		return
+	}
+
+	// With write transactions a write request runs on a copy of the handler in a transaction
+	if !h.writeTx && h.dryRun == nil && h.config.WriteTransactions && common.IsWriteRequest(r.Method()) {
+		h.handleWriteTransaction(w, r, params)
+		return
+	}
+
 	// Capture panics and return error response
 	defer func() {
 		if err := recover(); err != nil {
@@ -59,21 +210,81 @@ func (h *Handler) Handle(w common.ResponseWriter, r common.Request, params map[s
 		}
 	}()
 
-	ctx := context.Background()
+	// Every request gets an id, returned in X-Request-ID, error responses and log lines
+	requestID := common.RequestIDOf(r.Header(common.RequestIDHeader))
+	w = common.NewRequestIDWriter(w, requestID)
+
+	ctx, cancel, err := h.config.WithQueryTimeout(common.WithRequestID(r.Context(), requestID), r.Header(common.QueryTimeoutHeader))
+	defer cancel()
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid_timeout", "Invalid query timeout", err)
+		return
+	}
+	ctx = h.withRequestLogger(ctx)
 
 	schema := params["schema"]
 	entity := params["entity"]
 	id := params["id"]
+	if id == "" {
+		// X-PK addresses records whose key can't be part of the URL
+		id = r.Header(common.PKHeader)
+	}
 
 	// Determine operation based on HTTP method
 	method := r.Method()
 
-	logger.Info("Handling %s request for %s.%s", method, schema, entity)
+	logger.InfoContext(ctx, "Handling %s request for %s.%s", method, schema, entity)
+
+	ctx, span := tracing.StartSpan(ctx, "restheadspec.Handle",
+		tracing.AttrSchema.String(schema),
+		tracing.AttrEntity.String(entity),
+		tracing.AttrOperation.String(method),
+	)
+	defer span.End()
+
+	// Authenticate first, so per-user rate limits and hooks see the user
+	ctx, ok := h.authenticate(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	operation := requestOperation(method, entity, id)
+	if !h.checkRateLimit(ctx, w, schema, entity, operation) {
+		return
+	}
+
+	// Cross-entity transactions are not bound to a single model
+	if entity == TransactionPath && method == "POST" {
+		data, ok := h.readBody(ctx, w, r)
+		if !ok {
+			return
+		}
+		h.handleTransaction(ctx, w, schema, data)
+		return
+	}
+
+	// Saved views of the entity are managed on their own path
+	if id == ViewsPath {
+		h.handleViews(ctx, w, r, schema, entity)
+		return
+	}
+
+	// Series are reads of their own path
+	if id == SeriesPath && method != "GET" {
+		h.sendError(w, http.StatusMethodNotAllowed, "invalid_method", "Invalid HTTP method", nil)
+		return
+	}
+
+	if accessErr := h.config.CheckEntityAccess(ctx, schema, entity, operation); accessErr != nil {
+		logger.WarnContext(ctx, "Rejected %s on %s.%s: %s", operation, schema, entity, accessErr.Message)
+		h.sendError(w, accessErr.Status, accessErr.Code, accessErr.Message, nil)
+		return
+	}
 
 	// Get model and populate context with request-scoped data
 	model, err := h.registry.GetModelByEntity(schema, entity)
 	if err != nil {
-		logger.Error("Invalid entity: %v", err)
+		logger.ErrorContext(ctx, "Invalid entity: %v", err)
 		h.sendError(w, http.StatusBadRequest, "invalid_entity", "Invalid entity", err)
 		return
 	}
@@ -86,7 +297,7 @@ func (h *Handler) Handle(w common.ResponseWriter, r common.Request, params map[s
 	}
 
 	if modelType == nil || modelType.Kind() != reflect.Struct {
-		logger.Error("Model for %s.%s must be a struct type, got %v. Please register models as struct types, not slices or pointers to slices.", schema, entity, originalType)
+		logger.ErrorContext(ctx, "Model for %s.%s must be a struct type, got %v. Please register models as struct types, not slices or pointers to slices.", schema, entity, originalType)
 		h.sendError(w, http.StatusInternalServerError, "invalid_model_type",
 			fmt.Sprintf("Model must be a struct type, got %v. Ensure you register the struct (e.g., ModelCoreAccount{}) not a slice (e.g., []*ModelCoreAccount)", originalType),
 			fmt.Errorf("invalid model type: %v", originalType))
@@ -101,19 +312,71 @@ func (h *Handler) Handle(w common.ResponseWriter, r common.Request, params map[s
 	modelPtr := reflect.New(reflect.TypeOf(model)).Interface()
 	tableName := h.getTableName(schema, entity, model)
 
+	// A read naming a saved view runs with the params of the view below its own headers
+	if method == "GET" {
+		viewParams, ok := h.resolveView(ctx, w, r, schema, entity)
+		if !ok {
+			return
+		}
+		r = withView(r, viewParams)
+	}
+
 	// Parse options from headers - this now includes relation name resolution
 	options := h.parseOptionsFromHeaders(r, model)
 
 	// Validate and filter columns in options (log warnings for invalid columns)
 	validator := common.NewColumnValidator(model)
-	options = filterExtendedOptions(validator, options)
+	sorts := options.Sort
+	options = filterExtendedOptions(validator, options, model)
+	options.Sort = h.expandSorts(model, sorts, validator, options.Expand)
+	expands := make([]string, 0, len(options.Expand))
+	for _, expand := range options.Expand {
+		expands = append(expands, expand.Relation)
+	}
+	if limitErr := h.config.CheckQueryLimits(options.RequestOptions, expands...); limitErr != nil {
+		h.rejectLimit(w, limitErr)
+		return
+	}
+	if err := h.config.CheckCustomSQL(tableName, reflection.GetSQLModelColumns(model), options.CustomSQLWhere, options.CustomSQLOr); err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid_custom_sql", err.Error(), err)
+		return
+	}
+	if h.config.Entity(schema, entity).SkipCount {
+		options.SkipCount = true
+	}
+	// Lists without a sort or filters of their own use the defaults of the model
+	if method == "GET" && (id == "" || id == SeriesPath) && len(options.IDs) == 0 {
+		common.ApplyModelDefaults(model, &options.RequestOptions,
+			options.Search != "" || options.CustomSQLWhere != "" || options.CustomSQLOr != "")
+	}
+	owner, accessErr := h.config.OwnerFilter(ctx, schema, entity, r.Header(common.OnlyMineHeader))
+	if accessErr != nil {
+		h.sendError(w, accessErr.Status, accessErr.Code, accessErr.Message, nil)
+		return
+	}
+	if owner != nil {
+		addFilterGroup(&options, *owner)
+	}
+	scoped, err := common.ScopeFilter(model, options.Scopes)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid_scope", "Invalid scope", err)
+		return
+	}
+	if scoped != nil {
+		normalizeFilterGroupValues(scoped)
+		addFilterGroup(&options, *scoped)
+	}
 
 	// Add request-scoped data to context (including options)
 	ctx = WithRequestData(ctx, schema, entity, tableName, model, modelPtr, options)
+	span.SetAttributes(tracing.AttrTable.String(tableName))
 
 	switch method {
 	case "GET":
-		if id != "" {
+		if id == SeriesPath {
+			// GET of the series path - count the records per period
+			h.handleSeries(ctx, w, options)
+		} else if id != "" {
 			// GET with ID - read single record
 			h.handleRead(ctx, w, id, options)
 		} else {
@@ -122,19 +385,18 @@ func (h *Handler) Handle(w common.ResponseWriter, r common.Request, params map[s
 		}
 	case "POST":
 		// Create operation
-		body, err := r.Body()
-		if err != nil {
-			logger.Error("Failed to read request body: %v", err)
-			h.sendError(w, http.StatusBadRequest, "invalid_request", "Failed to read request body", err)
+		data, ok := h.readBody(ctx, w, r)
+		if !ok {
 			return
 		}
-		var data interface{}
-		if err := json.Unmarshal(body, &data); err != nil {
-			logger.Error("Failed to decode request body: %v", err)
-			h.sendError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", err)
+		if id == BatchPath {
+			h.handleBatch(ctx, w, data, options)
 			return
 		}
 		validId, _ := strconv.ParseInt(id, 10, 64)
+		if !h.prepareWrite(ctx, w, schema, entity, id, validId > 0, data, model) {
+			return
+		}
 		if validId > 0 {
 			h.handleUpdate(ctx, w, id, nil, data, options)
 		} else {
@@ -142,39 +404,133 @@ func (h *Handler) Handle(w common.ResponseWriter, r common.Request, params map[s
 		}
 	case "PUT", "PATCH":
 		// Update operation
-
-		body, err := r.Body()
-		if err != nil {
-			logger.Error("Failed to read request body: %v", err)
-			h.sendError(w, http.StatusBadRequest, "invalid_request", "Failed to read request body", err)
+		data, ok := h.readBody(ctx, w, r)
+		if !ok {
 			return
 		}
-		var data interface{}
-		if err := json.Unmarshal(body, &data); err != nil {
-			logger.Error("Failed to decode request body: %v", err)
-			h.sendError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", err)
+		if data, ok = h.applyPatch(ctx, w, r, method, id, data, model); !ok {
+			return
+		}
+		if !h.prepareWrite(ctx, w, schema, entity, id, true, data, model) {
+			return
+		}
+		if id == "" && options.isWriteByFilter() {
+			h.handleUpdateWhere(ctx, w, data, options)
 			return
 		}
 		h.handleUpdate(ctx, w, id, nil, data, options)
 	case "DELETE":
 		// Try to read body for batch delete support
 		var data interface{}
-		body, err := r.Body()
+		body, err := h.config.ReadBody(r)
+		if h.rejectLimit(w, err) {
+			return
+		}
 		if err == nil && len(body) > 0 {
 			if err := json.Unmarshal(body, &data); err != nil {
-				logger.Warn("Failed to decode delete request body (will try single delete): %v", err)
+				logger.WarnContext(ctx, "Failed to decode delete request body (will try single delete): %v", err)
 				data = nil
 			}
 		}
+		if limitErr := h.config.CheckBatchSize(data); limitErr != nil {
+			h.rejectLimit(w, limitErr)
+			return
+		}
+		if id == "" {
+			// A body with filters, or filter headers without a body, delete by filter
+			filter, ok, err := common.ParseDeleteFilter(data)
+			if err != nil {
+				h.sendError(w, http.StatusBadRequest, "invalid_filter", "Invalid filter", err)
+				return
+			}
+			if ok || (data == nil && options.isWriteByFilter()) {
+				h.handleDeleteWhere(ctx, w, filter, options, r.Header(common.ConfirmBulkHeader))
+				return
+			}
+		}
 		h.handleDelete(ctx, w, id, data)
 	default:
-		logger.Error("Invalid HTTP method: %s", method)
+		logger.ErrorContext(ctx, "Invalid HTTP method: %s", method)
 		h.sendError(w, http.StatusMethodNotAllowed, "invalid_method", "Invalid HTTP method", nil)
 	}
 }
 
+// readBody reads and decodes the body of a request within the request limits. It returns
+// false when the request was answered with an error.
+func (h *Handler) readBody(ctx context.Context, w common.ResponseWriter, r common.Request) (interface{}, bool) {
+	body, err := h.config.ReadBody(r)
+	if h.rejectLimit(w, err) {
+		return nil, false
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to read request body: %v", err)
+		h.sendError(w, http.StatusBadRequest, "invalid_request", "Failed to read request body", err)
+		return nil, false
+	}
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		logger.ErrorContext(ctx, "Failed to decode request body: %v", err)
+		h.sendError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", err)
+		return nil, false
+	}
+	if limitErr := h.config.CheckBatchSize(data); limitErr != nil {
+		h.rejectLimit(w, limitErr)
+		return nil, false
+	}
+	return data, true
+}
+
+// rejectLimit answers a request exceeding a request limit. It returns false for other errors.
+func (h *Handler) rejectLimit(w common.ResponseWriter, err error) bool {
+	var limitErr *common.RequestLimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+	logger.Warn("Rejected request: %s", limitErr.Message)
+	h.sendError(w, limitErr.Status, limitErr.Code, limitErr.Message, nil)
+	return true
+}
+
+// prepareWrite deserializes the payload of a create or update, checks it against the entity
+// overrides and removes its read-only columns, listing them in the X-Write-Warnings header,
+// before the audit and tenant columns are stamped. It returns false when the payload was
+// rejected.
+func (h *Handler) prepareWrite(ctx context.Context, w common.ResponseWriter, schema, entity, id string, update bool, data, model interface{}) bool {
+	if err := h.config.Deserialize(ctx, schema, entity, data); err != nil {
+		logger.WarnContext(ctx, "Invalid data for %s.%s: %v", schema, entity, err)
+		h.sendError(w, http.StatusBadRequest, "invalid_data", "Invalid data", err)
+		return false
+	}
+	if accessErr := h.config.CheckNestedCUD(schema, entity, data, model, h); accessErr != nil {
+		h.sendError(w, accessErr.Status, accessErr.Code, accessErr.Message, nil)
+		return false
+	}
+	warnings, err := common.SanitizeWrite(model, data, update, id, h.config.ProtectedColumns)
+	if err != nil {
+		logger.WarnContext(ctx, "Rejected write on %s.%s: %v", schema, entity, err)
+		h.sendError(w, http.StatusUnprocessableEntity, common.ProtectedColumn, "Protected column", err)
+		return false
+	}
+	if len(warnings) > 0 {
+		logger.WarnContext(ctx, "Ignored columns of write on %s.%s: %s", schema, entity, strings.Join(warnings, "; "))
+		w.SetHeader(common.WriteWarningsHeader, strings.Join(warnings, "; "))
+	}
+	if !update {
+		if err := h.config.GenerateIDs(ctx, h.db, schema, entity, model, data); err != nil {
+			logger.ErrorContext(ctx, "Failed to generate ids for %s.%s: %v", schema, entity, err)
+			h.sendError(w, http.StatusInternalServerError, "id_generation_error", "Error generating ids", err)
+			return false
+		}
+	}
+	h.config.StampAudit(ctx, model, data, !update)
+	h.tenant.Stamp(model, data)
+	return true
+}
+
 // HandleGet processes GET requests for metadata
 func (h *Handler) HandleGet(w common.ResponseWriter, r common.Request, params map[string]string) {
+	defer h.config.CompressResponse(w, r)()
+
 	// Capture panics and return error response
 	defer func() {
 		if err := recover(); err != nil {
@@ -187,6 +543,10 @@ func (h *Handler) HandleGet(w common.ResponseWriter, r common.Request, params ma
 
 	logger.Info("Getting metadata for %s.%s", schema, entity)
 
+	if _, ok := h.authenticate(r.Context(), w, r); !ok {
+		return
+	}
+
 	model, err := h.registry.GetModelByEntity(schema, entity)
 	if err != nil {
 		logger.Error("Failed to get model: %v", err)
@@ -195,6 +555,16 @@ func (h *Handler) HandleGet(w common.ResponseWriter, r common.Request, params ma
 	}
 
 	metadata := h.generateMetadata(schema, entity, model)
+	metadata.Scopes = common.ScopeNames(model)
+	if h.config.IntrospectSchema {
+		tableSchema, table := h.getSchemaAndTable(schema, entity, model)
+		if err := common.IntrospectMetadata(r.Context(), h.db, tableSchema, table, metadata); err != nil {
+			logger.Warn("Failed to introspect %s.%s: %v", schema, entity, err)
+		}
+	}
+	if err := h.config.DescribeMetadata(r.Context(), metadata); err != nil {
+		logger.Warn("Failed to describe columns of %s.%s: %v", schema, entity, err)
+	}
 	h.sendResponse(w, metadata, nil)
 }
 
@@ -208,14 +578,39 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 		}
 	}()
 
+	ctx, span := tracing.StartSpan(ctx, "restheadspec.read", tracing.AttrOperation.String("read"),
+		tracing.AttrFilterCount.Int(len(options.Filters)),
+		tracing.AttrPreloadCount.Int(len(options.Preload)),
+	)
+	defer span.End()
+
+	// Query plans are limited to requests the AllowExplain guardrail allows
+	explain, analyze := common.ParseExplain(options.Explain)
+	if explain && !h.config.ExplainAllowed(ctx) {
+		h.sendError(w, http.StatusForbidden, "explain_forbidden", "Query plans are not allowed for this request", nil)
+		return
+	}
+
 	schema := GetSchema(ctx)
 	entity := GetEntity(ctx)
 	tableName := GetTableName(ctx)
 	model := GetModel(ctx)
 
+	// Track result sizes and allocations (only active in memdebug builds)
+	ctx, memTracker := membudget.Start(ctx, fmt.Sprintf("restheadspec.read %s.%s", schema, entity))
+	defer memTracker.Finish()
+
 	if id == "" {
 		options.SingleRecordAsObject = false
 	}
+	if len(options.IDs) > common.MaxBatchReadIDs {
+		h.sendError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("A read is limited to %d ids", common.MaxBatchReadIDs), nil)
+		return
+	}
+	if dialect := common.DialectOf(h.db); len(options.DistinctOn) > 0 && !dialect.DistinctOn {
+		h.sendError(w, http.StatusBadRequest, "unsupported_feature", fmt.Sprintf("DISTINCT ON is not supported by the %s database", dialect.Name), nil)
+		return
+	}
 
 	// Execute BeforeRead hooks
 	hookCtx := &HookContext{
@@ -231,7 +626,7 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 	}
 
 	if err := h.hooks.Execute(BeforeRead, hookCtx); err != nil {
-		logger.Error("BeforeRead hook failed: %v", err)
+		logger.ErrorContext(ctx, "BeforeRead hook failed: %v", err)
 		h.sendError(w, http.StatusBadRequest, "hook_error", "Hook execution failed", err)
 		return
 	}
@@ -243,7 +638,7 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 	}
 
 	if modelType == nil || modelType.Kind() != reflect.Struct {
-		logger.Error("Model must be a struct type, got %v for %s.%s", modelType, schema, entity)
+		logger.ErrorContext(ctx, "Model must be a struct type, got %v for %s.%s", modelType, schema, entity)
 		h.sendError(w, http.StatusInternalServerError, "invalid_model", "Model must be a struct type", fmt.Errorf("invalid model type: %v", modelType))
 		return
 	}
@@ -251,7 +646,7 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 	// Create a pointer to a slice of pointers to the model type for query results
 	modelPtr := reflect.New(reflect.SliceOf(reflect.PointerTo(modelType))).Interface()
 
-	logger.Info("Reading records from %s.%s", schema, entity)
+	logger.InfoContext(ctx, "Reading records from %s.%s", schema, entity)
 
 	// Start with Model() using the slice pointer to avoid "Model(nil)" errors in Count()
 	// Bun's Model() accepts both single pointers and slice pointers
@@ -264,17 +659,46 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 		query = query.Table(tableName)
 	}
 
+	// Columns of the main table are qualified by the alias of the query, else by the table name
+	tableRef := reflection.ExtractTableNameOnly(tableName)
+	if provider, ok := query.(common.TableReferenceProvider); ok && provider.TableReference() != "" {
+		tableRef = provider.TableReference()
+	}
+
+	// Omitted columns are left out of the SELECT list instead of only the response
+	options.Columns = common.SelectColumns(model, options.Columns, options.OmitColumns)
+
+	// Aggregates are computed over the selected columns of the rows the read matches
+	var aggregates []common.AggregateOption
+	if id == "" && len(options.Aggregates) > 0 {
+		var err error
+		if aggregates, err = common.ValidateAggregates(options.Aggregates, aggregateColumns(model, options)); err != nil {
+			h.sendError(w, http.StatusBadRequest, "invalid_aggregate", err.Error(), err)
+			return
+		}
+	}
+
+	// Facets count the values of model columns
+	var facetColumns []string
+	if id == "" && len(options.Facets) > 0 {
+		var err error
+		if facetColumns, err = common.ValidateFacets(options.Facets, reflection.GetSQLModelColumns(model)); err != nil {
+			h.sendError(w, http.StatusBadRequest, "invalid_facet", err.Error(), err)
+			return
+		}
+	}
+
 	// If we have computed columns/expressions but options.Columns is empty,
 	// populate it with all model columns first since computed columns are additions
-	if len(options.Columns) == 0 && (len(options.ComputedQL) > 0 || len(options.ComputedColumns) > 0) {
-		logger.Debug("Populating options.Columns with all model columns since computed columns are additions")
+	if len(options.Columns) == 0 && (len(options.ComputedQL) > 0 || len(options.ComputedColumns) > 0 || len(options.AdvancedSQL) > 0) {
+		logger.DebugContext(ctx, "Populating options.Columns with all model columns since computed columns are additions")
 		options.Columns = reflection.GetSQLModelColumns(model)
 	}
 
 	// Apply ComputedQL fields if any
 	if len(options.ComputedQL) > 0 {
 		for colName, colExpr := range options.ComputedQL {
-			logger.Debug("Applying computed column: %s", colName)
+			logger.DebugContext(ctx, "Applying computed column: %s", colName)
 			query = query.ColumnExpr(fmt.Sprintf("(%s) AS %s", colExpr, colName))
 			for colIndex := range options.Columns {
 				if options.Columns[colIndex] == colName {
@@ -288,7 +712,7 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 
 	if len(options.ComputedColumns) > 0 {
 		for _, cu := range options.ComputedColumns {
-			logger.Debug("Applying computed column: %s", cu.Name)
+			logger.DebugContext(ctx, "Applying computed column: %s", cu.Name)
 			query = query.ColumnExpr(fmt.Sprintf("(%s) AS %s", cu.Expression, cu.Name))
 			for colIndex := range options.Columns {
 				if options.Columns[colIndex] == cu.Name {
@@ -300,18 +724,51 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 		}
 	}
 
+	// Apply AdvancedSQL fields: a selected column is replaced by its expression, keeping the column name
+	if len(options.AdvancedSQL) > 0 {
+		for colName, colExpr := range options.AdvancedSQL {
+			for colIndex := range options.Columns {
+				if strings.EqualFold(options.Columns[colIndex], colName) {
+					logger.DebugContext(ctx, "Applying advanced SQL for column: %s", colName)
+					query = query.ColumnExpr(fmt.Sprintf("(%s) AS %s", colExpr, options.Columns[colIndex]))
+					options.Columns = append(options.Columns[:colIndex], options.Columns[colIndex+1:]...)
+					break
+				}
+			}
+		}
+	}
+
 	// Apply column selection
 	if len(options.Columns) > 0 {
-		logger.Debug("Selecting columns: %v", options.Columns)
+		logger.DebugContext(ctx, "Selecting columns: %v", options.Columns)
 		for _, col := range options.Columns {
 			query = query.Column(reflection.ExtractSourceColumn(col))
 		}
 
 	}
 
-	// Apply expand (Just expand to Preload for now)
+	// Apply expand: belongsTo/hasOne relations are LEFT JOINed into the main query,
+	// other relations (and expands with a WHERE clause) fall back to Preload
+	expandJoins := make(map[string]string)
 	for _, expand := range options.Expand {
-		logger.Debug("Applying expand: %s", expand.Relation)
+		logger.DebugContext(ctx, "Applying expand: %s", expand.Relation)
+		if join := h.resolveExpandJoin(model, expand.Relation); join != nil && expand.Where == "" {
+			logger.DebugContext(ctx, "Joining expand relation %s (%s) on %s", expand.Relation, join.relationType, join.onClause(reflection.ExtractTableNameOnly(tableName)))
+			columns := expand.Columns
+			query = query.JoinRelation(join.fieldName, func(sq common.SelectQuery) common.SelectQuery {
+				if len(columns) > 0 {
+					sq = sq.Column(columns...)
+				}
+				return sq
+			})
+			joinSQL := join.joinSQL(reflection.ExtractTableNameOnly(tableName))
+			expandJoins[expand.Relation] = joinSQL
+			if join.jsonName != "" {
+				expandJoins[join.jsonName] = joinSQL
+			}
+			continue
+		}
+
 		sorts := make([]common.SortOption, 0)
 		for _, s := range strings.Split(expand.Sort, ",") {
 			if s == "" {
@@ -327,9 +784,6 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 				Column: s, Direction: dir,
 			})
 		}
-		// Note: Expand would require JOIN implementation
-		// For now, we'll use Preload as a fallback
-		// query = query.Preload(expand.Relation)
 		if options.Preload == nil {
 			options.Preload = make([]common.PreloadOption, 0)
 		}
@@ -353,13 +807,13 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 	// Apply preloading
 	for idx := range options.Preload {
 		preload := options.Preload[idx]
-		logger.Debug("Applying preload: %s", preload.Relation)
+		logger.DebugContext(ctx, "Applying preload: %s", preload.Relation)
 
 		// Validate and fix WHERE clause to ensure it contains the relation prefix
 		if len(preload.Where) > 0 {
 			fixedWhere, err := common.ValidateAndFixPreloadWhere(preload.Where, preload.Relation)
 			if err != nil {
-				logger.Error("Invalid preload WHERE clause for relation '%s': %v", preload.Relation, err)
+				logger.ErrorContext(ctx, "Invalid preload WHERE clause for relation '%s': %v", preload.Relation, err)
 				h.sendError(w, http.StatusBadRequest, "invalid_preload_where",
 					fmt.Sprintf("Invalid preload WHERE clause for relation '%s'", preload.Relation), err)
 				return
@@ -372,96 +826,127 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 	}
 
 	// Apply DISTINCT if requested
-	if options.Distinct {
-		logger.Debug("Applying DISTINCT")
-		// Note: DISTINCT implementation depends on ORM support
-		// This may need to be handled differently per database adapter
-	}
-
-	// Apply filters - validate and adjust for column types first
-	for i := range options.Filters {
-		filter := &options.Filters[i]
-
-		// Validate and adjust filter based on column type
-		castInfo := h.ValidateAndAdjustFilterForColumnType(filter, model)
-
-		// Default to AND if LogicOperator is not set
-		logicOp := filter.LogicOperator
-		if logicOp == "" {
-			logicOp = "AND"
-		}
-
-		logger.Debug("Applying filter: %s %s %v (needsCast=%v, logic=%s)", filter.Column, filter.Operator, filter.Value, castInfo.NeedsCast, logicOp)
-		query = h.applyFilter(query, *filter, tableName, castInfo.NeedsCast, logicOp)
+	// Count() honours it as well, so the total reflects distinct rows only
+	if len(options.DistinctOn) > 0 {
+		logger.DebugContext(ctx, "Applying DISTINCT ON: %v", options.DistinctOn)
+		query = query.DistinctOn(options.DistinctOn...)
+	} else if options.Distinct {
+		logger.DebugContext(ctx, "Applying DISTINCT")
+		query = query.Distinct()
+	}
+
+	// Facets count the values of their column with the filters of the request before they are
+	// adjusted to the column types
+	facetOptions := options
+	facetOptions.Filters = append([]common.FilterOption(nil), options.Filters...)
+
+	// Apply filters, custom SQL and full-text search
+	query, search, err := h.applyReadFilters(ctx, query, model, tableName, options, "")
+	if err != nil {
+		logger.ErrorContext(ctx, "Invalid full-text search: %v", err)
+		h.sendError(w, http.StatusBadRequest, "invalid_search", "Invalid full-text search", err)
+		return
 	}
 
-	// Apply custom SQL WHERE clause (AND condition)
-	if options.CustomSQLWhere != "" {
-		logger.Debug("Applying custom SQL WHERE: %s", options.CustomSQLWhere)
-		// Sanitize without auto-prefixing since custom SQL may reference multiple tables
-		sanitizedWhere := common.SanitizeWhereClause(options.CustomSQLWhere, reflection.ExtractTableNameOnly(tableName))
-		if sanitizedWhere != "" {
-			query = query.Where(sanitizedWhere)
+	// If ID is provided, filter by ID
+	if id != "" {
+		where, args, err := common.LookupCondition(model, options.LookupKey, id)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, "invalid_id", "Invalid id", err)
+			return
 		}
-	}
+		logger.DebugContext(ctx, "Filtering by ID: %s", id)
 
-	// Apply custom SQL WHERE clause (OR condition)
-	if options.CustomSQLOr != "" {
-		logger.Debug("Applying custom SQL OR: %s", options.CustomSQLOr)
-		// Sanitize without auto-prefixing since custom SQL may reference multiple tables
-		sanitizedOr := common.SanitizeWhereClause(options.CustomSQLOr, reflection.ExtractTableNameOnly(tableName))
-		if sanitizedOr != "" {
-			query = query.WhereOr(sanitizedOr)
-		}
+		query = query.Where(where, args...)
 	}
 
-	// If ID is provided, filter by ID
-	if id != "" {
+	// Read by id list with a single IN query
+	if len(options.IDs) > 0 {
 		pkName := reflection.GetPrimaryKeyName(model)
-		logger.Debug("Filtering by ID=%s: %s", pkName, id)
-
-		query = query.Where(fmt.Sprintf("%s = ?", common.QuoteIdent(pkName)), id)
+		logger.DebugContext(ctx, "Reading %d records by %s", len(options.IDs), pkName)
+		query = query.Where(fmt.Sprintf("%s IN (?)", common.QuoteIdent(pkName)), options.IDs.Unique())
 	}
 
-	// Apply sorting
+	// Apply sorting, ending with the primary key so pages are deterministic
+	if !options.isDistinct() {
+		options.Sort = common.WithPrimaryKeySort(options.Sort, reflection.GetPrimaryKeyNames(model))
+	}
 	for _, sort := range options.Sort {
 		direction := "ASC"
 		if strings.EqualFold(sort.Direction, "desc") {
 			direction = "DESC"
 		}
-		logger.Debug("Applying sort: %s %s", sort.Column, direction)
-		query = query.Order(fmt.Sprintf("%s %s", sort.Column, direction))
+		column := sort.Column
+		if len(expandJoins) > 0 {
+			column = qualifySort(column, tableRef)
+		}
+		logger.DebugContext(ctx, "Applying sort: %s %s", column, direction)
+		query = query.Order(fmt.Sprintf("%s %s", column, direction))
+	}
+	// Without an explicit sort, search results are ordered by relevance
+	if search != nil && len(options.Sort) == 0 {
+		query = query.OrderExpr(search.Rank+" DESC", search.RankArgs...)
+		if pkName := reflection.GetPrimaryKeyName(model); pkName != "" {
+			if len(expandJoins) > 0 {
+				pkName = qualifySort(pkName, tableRef)
+			}
+			query = query.Order(fmt.Sprintf("%s ASC", pkName))
+		}
+	}
+
+	// Keep list reads within the limit guardrails
+	if id == "" && len(options.IDs) == 0 {
+		limit, offset, err := h.config.ForEntity(schema, entity).ApplyLimits(options.Limit, options.Offset)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, "invalid_limit", err.Error(), err)
+			return
+		}
+		options.Limit, options.Offset = limit, offset
 	}
 
 	// Get total count before pagination (unless skip count is requested)
 	var total int
+	var estimated bool
 	if !options.SkipCount {
-		count, err := query.Count(ctx)
+		count, isEstimate, err := common.CountRows(ctx, h.db, query, schema, entity, h.config.Entity(schema, entity), h.countCache())
 		if err != nil {
-			logger.Error("Error counting records: %v", err)
+			logger.ErrorContext(ctx, "Error counting records: %v", err)
 			h.sendError(w, http.StatusInternalServerError, "query_error", "Error counting records", err)
 			return
 		}
-		total = count
-		logger.Debug("Total records: %d", total)
+		total, estimated = count, isEstimate
+		logger.DebugContext(ctx, "Total records: %d", total)
 	} else {
-		logger.Debug("Skipping count as requested")
+		logger.DebugContext(ctx, "Skipping count as requested")
 		total = -1 // Indicate count was skipped
 	}
 
-	// Apply pagination
-	if options.Limit != nil && *options.Limit > 0 {
-		logger.Debug("Applying limit: %d", *options.Limit)
+	totals, err := common.Aggregate(ctx, h.db, query, aggregates)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error computing aggregates: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "query_error", "Error computing aggregates", err)
+		return
+	}
+	facets, err := h.facetCounts(ctx, model, tableName, facetOptions, facetColumns)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error counting facets: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "query_error", "Error counting facets", err)
+		return
+	}
+
+	// Apply pagination, except on reads by id list which return every id
+	if options.Limit != nil && *options.Limit > 0 && len(options.IDs) == 0 {
+		logger.DebugContext(ctx, "Applying limit: %d", *options.Limit)
 		query = query.Limit(*options.Limit)
 	}
-	if options.Offset != nil && *options.Offset > 0 {
-		logger.Debug("Applying offset: %d", *options.Offset)
+	if options.Offset != nil && *options.Offset > 0 && len(options.IDs) == 0 {
+		logger.DebugContext(ctx, "Applying offset: %d", *options.Offset)
 		query = query.Offset(*options.Offset)
 	}
 
 	// Apply cursor-based pagination
 	if len(options.CursorForward) > 0 || len(options.CursorBackward) > 0 {
-		logger.Debug("Applying cursor pagination")
+		logger.DebugContext(ctx, "Applying cursor pagination")
 
 		// Get primary key name
 		pkName := reflection.GetPrimaryKeyName(model)
@@ -469,25 +954,17 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 		// Extract model columns for validation using the generic database function
 		modelColumns := reflection.GetModelColumns(model)
 
-		// Build expand joins map (if needed in future)
-		var expandJoins map[string]string
-		if len(options.Expand) > 0 {
-			expandJoins = make(map[string]string)
-			// TODO: Build actual JOIN SQL for each expand relation
-			// For now, pass empty map as joins are handled via Preload
-		}
-
 		// Get cursor filter SQL
-		cursorFilter, err := options.GetCursorFilter(tableName, pkName, modelColumns, expandJoins)
+		cursorFilter, err := options.GetCursorFilterWithAlias(tableName, tableRef, pkName, modelColumns, expandJoins)
 		if err != nil {
-			logger.Error("Error building cursor filter: %v", err)
+			logger.ErrorContext(ctx, "Error building cursor filter: %v", err)
 			h.sendError(w, http.StatusBadRequest, "cursor_error", "Invalid cursor pagination", err)
 			return
 		}
 
 		// Apply cursor filter to query
 		if cursorFilter != "" {
-			logger.Debug("Applying cursor filter: %s", cursorFilter)
+			logger.DebugContext(ctx, "Applying cursor filter: %s", cursorFilter)
 			sanitizedCursor := common.SanitizeWhereClause(cursorFilter, reflection.ExtractTableNameOnly(tableName))
 			if sanitizedCursor != "" {
 				query = query.Where(sanitizedCursor)
@@ -498,7 +975,7 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 	// Execute BeforeScan hooks - pass query chain so hooks can modify it
 	hookCtx.Query = query
 	if err := h.hooks.Execute(BeforeScan, hookCtx); err != nil {
-		logger.Error("BeforeScan hook failed: %v", err)
+		logger.ErrorContext(ctx, "BeforeScan hook failed: %v", err)
 		h.sendError(w, http.StatusBadRequest, "hook_error", "Hook execution failed", err)
 		return
 	}
@@ -508,13 +985,42 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 		query = modifiedQuery
 	}
 
+	var plan *common.ExplainMetadata
+	if explain {
+		var err error
+		if plan, err = common.Explain(ctx, h.db, query, analyze); err != nil {
+			logger.ErrorContext(ctx, "Error explaining query: %v", err)
+			h.sendError(w, http.StatusBadRequest, "explain_error", "Error explaining query", err)
+			return
+		}
+	}
+
 	// Execute query - modelPtr was already created earlier
+	started := time.Now()
 	if err := query.ScanModel(ctx); err != nil {
-		logger.Error("Error executing query: %v", err)
+		logger.ErrorContext(ctx, "Error executing query: %v", err)
 		h.sendError(w, http.StatusInternalServerError, "query_error", "Error executing query", err)
 		return
 	}
 
+	// Execute AfterScan hooks with the query duration
+	hookCtx.Duration = time.Since(started)
+	if err := h.hooks.Execute(AfterScan, hookCtx); err != nil {
+		logger.ErrorContext(ctx, "AfterScan hook failed: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "hook_error", "Hook execution failed", err)
+		return
+	}
+
+	paginatePreloads(modelPtr, options.Preload)
+
+	if memTracker != nil {
+		relations := make([]string, 0, len(options.Preload))
+		for _, preload := range options.Preload {
+			relations = append(relations, preload.Relation)
+		}
+		memTracker.RecordResult(modelPtr, relations...)
+	}
+
 	limit := 0
 	if options.Limit != nil {
 		limit = *options.Limit
@@ -527,12 +1033,30 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 	// Set row numbers on each record if the model has a RowNumber field
 	h.setRowNumbersOnRecords(modelPtr, offset)
 
+	span.SetAttributes(
+		tracing.AttrTotalCount.Int(total),
+		tracing.AttrRowCount.Int(reflection.Len(modelPtr)),
+	)
+
 	metadata := &common.Metadata{
-		Total:    int64(total),
-		Count:    int64(reflection.Len(modelPtr)),
-		Filtered: int64(total),
-		Limit:    limit,
-		Offset:   offset,
+		Total:      int64(total),
+		Count:      int64(reflection.Len(modelPtr)),
+		Filtered:   int64(total),
+		Estimated:  estimated,
+		Limit:      limit,
+		Offset:     offset,
+		Explain:    plan,
+		Aggregates: totals,
+		Facets:     facets,
+	}
+
+	if search != nil {
+		hits, err := h.searchHits(ctx, tableName, model, modelPtr, search)
+		if err != nil {
+			logger.WarnContext(ctx, "Failed to load search ranking: %v", err)
+		} else {
+			metadata.Search = &common.SearchMetadata{Query: options.Search, Hits: hits}
+		}
 	}
 
 	// Fetch row number for a specific record if requested
@@ -540,15 +1064,15 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 		pkName := reflection.GetPrimaryKeyName(model)
 		pkValue := *options.FetchRowNumber
 
-		logger.Debug("Fetching row number for specific PK %s = %s", pkName, pkValue)
+		logger.DebugContext(ctx, "Fetching row number for specific PK %s = %s", pkName, pkValue)
 
 		rowNum, err := h.FetchRowNumber(ctx, tableName, pkName, pkValue, options, model)
 		if err != nil {
-			logger.Warn("Failed to fetch row number: %v", err)
+			logger.WarnContext(ctx, "Failed to fetch row number: %v", err)
 			// Don't fail the entire request, just log the warning
 		} else {
 			metadata.RowNumber = &rowNum
-			logger.Debug("Row number for PK %s: %d", pkValue, rowNum)
+			logger.DebugContext(ctx, "Row number for PK %s: %d", pkValue, rowNum)
 		}
 	}
 
@@ -557,12 +1081,36 @@ func (h *Handler) handleRead(ctx context.Context, w common.ResponseWriter, id st
 	hookCtx.Error = nil
 
 	if err := h.hooks.Execute(AfterRead, hookCtx); err != nil {
-		logger.Error("AfterRead hook failed: %v", err)
+		logger.ErrorContext(ctx, "AfterRead hook failed: %v", err)
 		h.sendError(w, http.StatusInternalServerError, "hook_error", "Hook execution failed", err)
 		return
 	}
 
-	h.sendFormattedResponse(w, modelPtr, metadata, options)
+	// Tag the response so clients can revalidate it with If-None-Match
+	etag, err := readETag(id, modelPtr, total)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to compute ETag: %v", err)
+	}
+	if h.writeETag(w, etag, options) {
+		logger.DebugContext(ctx, "Read of %s.%s not modified", schema, entity)
+		return
+	}
+
+	// Reads by id list answer in the order of the ids, with entries for missing ids
+	var data interface{} = modelPtr
+	if len(options.IDs) > 0 {
+		data = common.OrderByIDs(modelPtr, options.IDs)
+	}
+	if data, err = h.config.Serialize(ctx, schema, entity, data); err != nil {
+		logger.ErrorContext(ctx, "Failed to serialize %s.%s: %v", schema, entity, err)
+		h.sendError(w, http.StatusInternalServerError, "serialization_error", "Error serializing the response", err)
+		return
+	}
+	if options.ResponseFormat == XLSXResponseFormat {
+		h.sendXLSX(w, schema, entity, model, data, options)
+		return
+	}
+	h.sendFormattedResponse(w, data, metadata, options)
 }
 
 // applyPreloadWithRecursion applies a preload with support for ComputedQL and recursive preloading
@@ -622,23 +1170,7 @@ func (h *Handler) applyPreloadWithRecursion(query common.SelectQuery, preload co
 			}
 
 			// Handle OmitColumns
-			if len(preload.OmitColumns) > 0 {
-				allCols := preload.Columns
-				// Remove omitted columns
-				preload.Columns = []string{}
-				for _, col := range allCols {
-					addCols := true
-					for _, omitCol := range preload.OmitColumns {
-						if col == omitCol {
-							addCols = false
-							break
-						}
-					}
-					if addCols {
-						preload.Columns = append(preload.Columns, col)
-					}
-				}
-			}
+			preload.Columns = common.SelectColumns(relatedModel, preload.Columns, preload.OmitColumns)
 
 			// Apply column selection
 			if len(preload.Columns) > 0 {
@@ -668,14 +1200,7 @@ func (h *Handler) applyPreloadWithRecursion(query common.SelectQuery, preload co
 			}
 		}
 
-		// Apply limit
-		if preload.Limit != nil && *preload.Limit > 0 {
-			sq = sq.Limit(*preload.Limit)
-		}
-
-		if preload.Offset != nil && *preload.Offset > 0 {
-			sq = sq.Offset(*preload.Offset)
-		}
+		// Limit and offset are applied per parent after loading, see paginatePreloads
 
 		return sq
 	})
@@ -701,6 +1226,22 @@ func (h *Handler) applyPreloadWithRecursion(query common.SelectQuery, preload co
 	return query
 }
 
+// paginatePreloads applies each preload's limit and offset to the related records of every
+// parent, following recursive preloads down to the same depth applyPreloadWithRecursion loads
+func paginatePreloads(records interface{}, preloads []common.PreloadOption) {
+	for _, preload := range preloads {
+		relation := preload.Relation
+		lastRelationName := relation[strings.LastIndex(relation, ".")+1:]
+		for depth := 0; ; depth++ {
+			common.ApplyPreloadPagination(records, relation, preload.Offset, preload.Limit)
+			if !preload.Recursive || depth >= 5 {
+				break
+			}
+			relation += "." + lastRelationName
+		}
+	}
+}
+
 func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, data interface{}, options ExtendedRequestOptions) {
 	// Capture panics and return error response
 	defer func() {
@@ -709,12 +1250,15 @@ func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, dat
 		}
 	}()
 
+	ctx, span := tracing.StartSpan(ctx, "restheadspec.create", tracing.AttrOperation.String("create"))
+	defer span.End()
+
 	schema := GetSchema(ctx)
 	entity := GetEntity(ctx)
 	tableName := GetTableName(ctx)
 	model := GetModel(ctx)
 
-	logger.Info("Creating record in %s.%s", schema, entity)
+	logger.InfoContext(ctx, "Creating record in %s.%s", schema, entity)
 
 	// Execute BeforeCreate hooks
 	hookCtx := &HookContext{
@@ -730,7 +1274,7 @@ func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, dat
 	}
 
 	if err := h.hooks.Execute(BeforeCreate, hookCtx); err != nil {
-		logger.Error("BeforeCreate hook failed: %v", err)
+		logger.ErrorContext(ctx, "BeforeCreate hook failed: %v", err)
 		h.sendError(w, http.StatusBadRequest, "hook_error", "Hook execution failed", err)
 		return
 	}
@@ -738,9 +1282,21 @@ func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, dat
 	// Use potentially modified data from hook context
 	data = hookCtx.Data
 
+	if err := common.ValidateEnums(model, data); err != nil {
+		logger.WarnContext(ctx, "Invalid data for %s.%s: %v", schema, entity, err)
+		h.sendError(w, http.StatusUnprocessableEntity, common.InvalidEnumValue, "Invalid value", err)
+		return
+	}
+
 	// Normalize data to slice for unified processing
 	dataSlice := h.normalizeToSlice(data)
-	logger.Debug("Processing %d item(s) for creation", len(dataSlice))
+	logger.DebugContext(ctx, "Processing %d item(s) for creation", len(dataSlice))
+
+	// Large arrays of flat records are loaded with a single bulk insert
+	if columns, rows, ok := h.bulkInsertRows(dataSlice, model); ok {
+		h.handleBulkCreate(ctx, w, hookCtx, dataSlice, columns, rows, options)
+		return
+	}
 
 	// Store original data maps for merging later
 	originalDataMaps := make([]map[string]interface{}, 0, len(dataSlice))
@@ -751,6 +1307,9 @@ func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, dat
 		// Create temporary nested processor with transaction
 		txNestedProcessor := common.NewNestedCUDProcessor(tx, h.registry, h)
 
+		// Convert the items to models; nested relations are created once their parent has its key
+		models := make([]interface{}, 0, len(dataSlice))
+		relations := make([]map[string]interface{}, 0, len(dataSlice))
 		for i, item := range dataSlice {
 			itemMap, ok := item.(map[string]interface{})
 			if !ok {
@@ -775,7 +1334,7 @@ func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, dat
 			// Extract nested relations if present (but don't process them yet)
 			var nestedRelations map[string]interface{}
 			if h.shouldUseNestedProcessor(itemMap, model) {
-				logger.Debug("Extracting nested relations for item %d", i)
+				logger.DebugContext(ctx, "Extracting nested relations for item %d", i)
 				cleanedData, relations, err := h.extractNestedRelations(itemMap, model)
 				if err != nil {
 					return fmt.Errorf("failed to extract nested relations for item %d: %w", i, err)
@@ -793,6 +1352,19 @@ func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, dat
 			if err := json.Unmarshal(jsonData, modelValue); err != nil {
 				return fmt.Errorf("failed to unmarshal item %d: %w", i, err)
 			}
+			models = append(models, modelValue)
+			relations = append(relations, nestedRelations)
+		}
+
+		// Arrays of flat items are inserted with multi-row statements
+		if h.insertChunked(relations) {
+			var err error
+			results, err = h.insertModelChunks(ctx, tx, hookCtx, models)
+			return err
+		}
+
+		for i, modelValue := range models {
+			nestedRelations := relations[i]
 
 			// Create insert query
 			query := tx.NewInsert().Model(modelValue)
@@ -827,16 +1399,21 @@ func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, dat
 			}
 
 			// Execute insert and get the ID
+			started := time.Now()
 			if _, err := query.Exec(ctx); err != nil {
 				return fmt.Errorf("failed to insert item %d: %w", i, err)
 			}
+			itemHookCtx.Duration = time.Since(started)
+			if err := h.hooks.Execute(AfterScan, itemHookCtx); err != nil {
+				return fmt.Errorf("AfterScan hook failed for item %d: %w", i, err)
+			}
 
 			// Get the inserted ID
 			insertedID := reflection.GetPrimaryKeyValue(modelValue)
 
 			// Now process nested relations with the parent ID
 			if len(nestedRelations) > 0 {
-				logger.Debug("Processing nested relations for item %d with parent ID: %v", i, insertedID)
+				logger.DebugContext(ctx, "Processing nested relations for item %d with parent ID: %v", i, insertedID)
 				if err := h.processChildRelationsWithParentID(ctx, txNestedProcessor, "insert", nestedRelations, model, insertedID); err != nil {
 					return fmt.Errorf("failed to process nested relations for item %d: %w", i, err)
 				}
@@ -848,7 +1425,7 @@ func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, dat
 	})
 
 	if err != nil {
-		logger.Error("Error creating records: %v", err)
+		logger.ErrorContext(ctx, "Error creating records: %v", err)
 		h.sendError(w, http.StatusInternalServerError, "create_error", "Error creating records", err)
 		return
 	}
@@ -877,12 +1454,19 @@ func (h *Handler) handleCreate(ctx context.Context, w common.ResponseWriter, dat
 	hookCtx.Error = nil
 
 	if err := h.hooks.Execute(AfterCreate, hookCtx); err != nil {
-		logger.Error("AfterCreate hook failed: %v", err)
+		logger.ErrorContext(ctx, "AfterCreate hook failed: %v", err)
 		h.sendError(w, http.StatusInternalServerError, "hook_error", "Hook execution failed", err)
 		return
 	}
+	h.queueAfterCommit(hookCtx, common.BatchActionCreate)
 
-	logger.Info("Successfully created %d record(s)", len(mergedResults))
+	responseData, err = h.config.Serialize(ctx, schema, entity, responseData)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to serialize %s.%s: %v", schema, entity, err)
+		h.sendError(w, http.StatusInternalServerError, "serialization_error", "Error serializing the response", err)
+		return
+	}
+	logger.InfoContext(ctx, "Successfully created %d record(s)", len(mergedResults))
 	h.sendResponseWithOptions(w, responseData, nil, &options)
 }
 
@@ -894,12 +1478,15 @@ func (h *Handler) handleUpdate(ctx context.Context, w common.ResponseWriter, id
 		}
 	}()
 
+	ctx, span := tracing.StartSpan(ctx, "restheadspec.update", tracing.AttrOperation.String("update"))
+	defer span.End()
+
 	schema := GetSchema(ctx)
 	entity := GetEntity(ctx)
 	tableName := GetTableName(ctx)
 	model := GetModel(ctx)
 
-	logger.Info("Updating record in %s.%s", schema, entity)
+	logger.InfoContext(ctx, "Updating record in %s.%s", schema, entity)
 
 	// Execute BeforeUpdate hooks
 	hookCtx := &HookContext{
@@ -916,7 +1503,7 @@ func (h *Handler) handleUpdate(ctx context.Context, w common.ResponseWriter, id
 	}
 
 	if err := h.hooks.Execute(BeforeUpdate, hookCtx); err != nil {
-		logger.Error("BeforeUpdate hook failed: %v", err)
+		logger.ErrorContext(ctx, "BeforeUpdate hook failed: %v", err)
 		h.sendError(w, http.StatusBadRequest, "hook_error", "Hook execution failed", err)
 		return
 	}
@@ -929,43 +1516,60 @@ func (h *Handler) handleUpdate(ctx context.Context, w common.ResponseWriter, id
 	if !ok {
 		jsonData, err := json.Marshal(data)
 		if err != nil {
-			logger.Error("Error marshaling data: %v", err)
+			logger.ErrorContext(ctx, "Error marshaling data: %v", err)
 			h.sendError(w, http.StatusBadRequest, "invalid_data", "Invalid data format", err)
 			return
 		}
 		if err := json.Unmarshal(jsonData, &dataMap); err != nil {
-			logger.Error("Error unmarshaling data: %v", err)
+			logger.ErrorContext(ctx, "Error unmarshaling data: %v", err)
 			h.sendError(w, http.StatusBadRequest, "invalid_data", "Invalid data format", err)
 			return
 		}
 	}
 
+	if err := common.ValidateEnums(model, dataMap); err != nil {
+		logger.WarnContext(ctx, "Invalid data for %s.%s: %v", schema, entity, err)
+		h.sendError(w, http.StatusUnprocessableEntity, common.InvalidEnumValue, "Invalid value", err)
+		return
+	}
+
 	// Determine target ID
 	var targetID interface{}
 	if id != "" {
 		targetID = id
 	} else if idPtr != nil {
 		targetID = *idPtr
+	} else if key, ok := common.RecordKey(model, dataMap); ok {
+		// The key columns of the data address the record
+		targetID = key
 	} else {
 		h.sendError(w, http.StatusBadRequest, "missing_id", "ID is required for update", nil)
 		return
 	}
 
-	// Get the primary key name for the model
+	// Get the primary key columns and their values for the model
 	pkName := reflection.GetPrimaryKeyName(model)
+	pkNames, pkValues, err := common.KeyValues(model, targetID)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid_id", "Invalid id", err)
+		return
+	}
+	keyWhere, keyArgs, _ := common.KeyCondition(model, targetID)
 
 	// Variable to store the updated record
 	var updatedRecord interface{}
+	// Columns a minimal update changes, nil when every column of the data is set
+	var changedColumns []string
 
 	// Process nested relations if present
-	err := h.db.RunInTransaction(ctx, func(tx common.Database) error {
+	err = h.db.RunInTransaction(ctx, func(tx common.Database) error {
 		// Create temporary nested processor with transaction
 		txNestedProcessor := common.NewNestedCUDProcessor(tx, h.registry, h)
 
 		// Extract nested relations if present (but don't process them yet)
 		var nestedRelations map[string]interface{}
 		if h.shouldUseNestedProcessor(dataMap, model) {
-			logger.Debug("Extracting nested relations for update")
+			logger.DebugContext(ctx, "Extracting nested relations for update")
 			cleanedData, relations, err := h.extractNestedRelations(dataMap, model)
 			if err != nil {
 				return fmt.Errorf("failed to extract nested relations: %w", err)
@@ -974,33 +1578,61 @@ func (h *Handler) handleUpdate(ctx context.Context, w common.ResponseWriter, id
 			nestedRelations = relations
 		}
 
-		// Ensure ID is in the data map for the update
-		dataMap[pkName] = targetID
-
-		// Create update query
-		query := tx.NewUpdate().Table(tableName).SetMap(dataMap)
-		query = query.Where(fmt.Sprintf("%s = ?", common.QuoteIdent(pkName)), targetID)
+		// Conditional update: the record must still match the tag the client read
+		if err := checkIfMatch(ctx, tx, model, targetID, options.IfMatch); err != nil {
+			return err
+		}
 
-		// Execute BeforeScan hooks - pass query chain so hooks can modify it
-		hookCtx.Query = query
-		if err := h.hooks.Execute(BeforeScan, hookCtx); err != nil {
-			return fmt.Errorf("BeforeScan hook failed: %w", err)
+		// Minimal update: only set the columns whose values differ from the stored record
+		if h.config.MinimalUpdates {
+			record, found, err := common.LoadRecord(ctx, tx, model, targetID)
+			if err != nil {
+				return fmt.Errorf("failed to load record: %w", err)
+			}
+			if found {
+				if changedColumns, dataMap, err = common.ChangedColumns(record, dataMap, pkName); err != nil {
+					return fmt.Errorf("failed to compare record: %w", err)
+				}
+			}
 		}
 
-		// Use potentially modified query from hook context
-		if modifiedQuery, ok := hookCtx.Query.(common.UpdateQuery); ok {
-			query = modifiedQuery
+		// Ensure the key is in the data map for the update
+		for i, name := range pkNames {
+			dataMap[name] = pkValues[i]
 		}
 
-		// Execute update
-		result, err := query.Exec(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to update record: %w", err)
+		if changedColumns == nil || len(changedColumns) > 0 {
+			// Create update query
+			query := tx.NewUpdate().Table(tableName).SetMap(dataMap)
+			query = query.Where(keyWhere, keyArgs...)
+
+			// Execute BeforeScan hooks - pass query chain so hooks can modify it
+			hookCtx.Query = query
+			if err := h.hooks.Execute(BeforeScan, hookCtx); err != nil {
+				return fmt.Errorf("BeforeScan hook failed: %w", err)
+			}
+
+			// Use potentially modified query from hook context
+			if modifiedQuery, ok := hookCtx.Query.(common.UpdateQuery); ok {
+				query = modifiedQuery
+			}
+
+			// Execute update
+			started := time.Now()
+			if _, err := query.Exec(ctx); err != nil {
+				return fmt.Errorf("failed to update record: %w", err)
+			}
+			hookCtx.Duration = time.Since(started)
+			if err := h.hooks.Execute(AfterScan, hookCtx); err != nil {
+				return fmt.Errorf("AfterScan hook failed: %w", err)
+			}
+		} else {
+			logger.DebugContext(ctx, "Record %v is unchanged, skipping the update", targetID)
 		}
 
 		// Now process nested relations with the parent ID
 		if len(nestedRelations) > 0 {
-			logger.Debug("Processing nested relations for update with parent ID: %v", targetID)
+			logger.DebugContext(ctx, "Processing nested relations for update with parent ID: %v", targetID)
 			if err := h.processChildRelationsWithParentID(ctx, txNestedProcessor, "update", nestedRelations, model, targetID); err != nil {
 				return fmt.Errorf("failed to process nested relations: %w", err)
 			}
@@ -1008,7 +1640,7 @@ func (h *Handler) handleUpdate(ctx context.Context, w common.ResponseWriter, id
 
 		// Fetch the updated record to return the new values
 		modelValue := reflect.New(reflect.TypeOf(model)).Interface()
-		selectQuery := tx.NewSelect().Model(modelValue).Where(fmt.Sprintf("%s = ?", common.QuoteIdent(pkName)), targetID)
+		selectQuery := tx.NewSelect().Model(modelValue).Where(keyWhere, keyArgs...)
 		if err := selectQuery.ScanModel(ctx); err != nil {
 			return fmt.Errorf("failed to fetch updated record: %w", err)
 		}
@@ -1017,12 +1649,15 @@ func (h *Handler) handleUpdate(ctx context.Context, w common.ResponseWriter, id
 
 		// Store result for hooks
 		hookCtx.Result = updatedRecord
-		_ = result // Keep result variable for potential future use
 		return nil
 	})
 
+	if errors.Is(err, errPreconditionFailed) {
+		h.sendError(w, http.StatusPreconditionFailed, "precondition_failed", "Record was modified", err)
+		return
+	}
 	if err != nil {
-		logger.Error("Error updating record: %v", err)
+		logger.ErrorContext(ctx, "Error updating record: %v", err)
 		h.sendError(w, http.StatusInternalServerError, "update_error", "Error updating record", err)
 		return
 	}
@@ -1035,13 +1670,28 @@ func (h *Handler) handleUpdate(ctx context.Context, w common.ResponseWriter, id
 	hookCtx.Result = mergedData
 	hookCtx.Error = nil
 	if err := h.hooks.Execute(AfterUpdate, hookCtx); err != nil {
-		logger.Error("AfterUpdate hook failed: %v", err)
+		logger.ErrorContext(ctx, "AfterUpdate hook failed: %v", err)
 		h.sendError(w, http.StatusInternalServerError, "hook_error", "Hook execution failed", err)
 		return
 	}
+	h.queueAfterCommit(hookCtx, common.BatchActionUpdate)
+
+	// Tag the response with the stored record so the next write can be conditional
+	if etag, err := common.ComputeETag(updatedRecord); err == nil {
+		w.SetHeader("ETag", etag)
+	}
+	if changedColumns != nil {
+		w.SetHeader(common.ChangedColumnsHeader, strings.Join(changedColumns, ","))
+	}
 
-	logger.Info("Successfully updated record with ID: %v", targetID)
-	h.sendResponseWithOptions(w, mergedData, nil, &options)
+	response, err := h.config.Serialize(ctx, schema, entity, mergedData)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to serialize %s.%s: %v", schema, entity, err)
+		h.sendError(w, http.StatusInternalServerError, "serialization_error", "Error serializing the response", err)
+		return
+	}
+	logger.InfoContext(ctx, "Successfully updated record with ID: %v", targetID)
+	h.sendResponseWithOptions(w, response, nil, &options)
 }
 
 func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id string, data interface{}) {
@@ -1052,21 +1702,24 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 		}
 	}()
 
+	ctx, span := tracing.StartSpan(ctx, "restheadspec.delete", tracing.AttrOperation.String("delete"))
+	defer span.End()
+
 	schema := GetSchema(ctx)
 	entity := GetEntity(ctx)
 	tableName := GetTableName(ctx)
 	model := GetModel(ctx)
 
-	logger.Info("Deleting record(s) from %s.%s", schema, entity)
+	logger.InfoContext(ctx, "Deleting record(s) from %s.%s", schema, entity)
 
 	// Handle batch delete from request data
 	if data != nil {
 		switch v := data.(type) {
 		case []string:
 			// Array of IDs as strings
-			logger.Info("Batch delete with %d IDs ([]string)", len(v))
+			logger.InfoContext(ctx, "Batch delete with %d IDs ([]string)", len(v))
 			deletedCount := 0
-			err := h.db.RunInTransaction(ctx, func(tx common.Database) error {
+			err := common.RunInTransaction(ctx, h.db, func(ctx context.Context, tx common.Database) error {
 				for _, itemID := range v {
 					// Execute hooks for each item
 					hookCtx := &HookContext{
@@ -1081,11 +1734,15 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 					}
 
 					if err := h.hooks.Execute(BeforeDelete, hookCtx); err != nil {
-						logger.Warn("BeforeDelete hook failed for ID %s: %v", itemID, err)
+						logger.WarnContext(ctx, "BeforeDelete hook failed for ID %s: %v", itemID, err)
 						continue
 					}
 
-					query := tx.NewDelete().Table(tableName).Where(fmt.Sprintf("%s = ?", common.QuoteIdent(reflection.GetPrimaryKeyName(model))), itemID)
+					where, args, err := common.KeyCondition(model, itemID)
+					if err != nil {
+						return fmt.Errorf("invalid id %v: %w", itemID, err)
+					}
+					query := tx.NewDelete().Table(tableName).Where(where, args...)
 
 					result, err := query.Exec(ctx)
 					if err != nil {
@@ -1097,26 +1754,26 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 					hookCtx.Result = map[string]interface{}{"deleted": result.RowsAffected()}
 					hookCtx.Error = nil
 					if err := h.hooks.Execute(AfterDelete, hookCtx); err != nil {
-						logger.Warn("AfterDelete hook failed for ID %s: %v", itemID, err)
+						logger.WarnContext(ctx, "AfterDelete hook failed for ID %s: %v", itemID, err)
 					}
+					h.queueAfterCommit(hookCtx, common.BatchActionDelete)
 				}
 				return nil
 			})
 			if err != nil {
-				logger.Error("Error in batch delete: %v", err)
+				logger.ErrorContext(ctx, "Error in batch delete: %v", err)
 				h.sendError(w, http.StatusInternalServerError, "delete_error", "Error deleting records", err)
 				return
 			}
-			logger.Info("Successfully deleted %d records", deletedCount)
+			logger.InfoContext(ctx, "Successfully deleted %d records", deletedCount)
 			h.sendResponse(w, map[string]interface{}{"deleted": deletedCount}, nil)
 			return
 
 		case []interface{}:
 			// Array of IDs or objects with ID field
-			logger.Info("Batch delete with %d items ([]interface{})", len(v))
+			logger.InfoContext(ctx, "Batch delete with %d items ([]interface{})", len(v))
 			deletedCount := 0
-			pkName := reflection.GetPrimaryKeyName(model)
-			err := h.db.RunInTransaction(ctx, func(tx common.Database) error {
+			err := common.RunInTransaction(ctx, h.db, func(ctx context.Context, tx common.Database) error {
 				for _, item := range v {
 					var itemID interface{}
 
@@ -1125,7 +1782,9 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 					case string:
 						itemID = v
 					case map[string]interface{}:
-						itemID = v[pkName]
+						if key, ok := common.RecordKey(model, v); ok {
+							itemID = key
+						}
 					default:
 						itemID = item
 					}
@@ -1149,11 +1808,15 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 					}
 
 					if err := h.hooks.Execute(BeforeDelete, hookCtx); err != nil {
-						logger.Warn("BeforeDelete hook failed for ID %v: %v", itemID, err)
+						logger.WarnContext(ctx, "BeforeDelete hook failed for ID %v: %v", itemID, err)
 						continue
 					}
 
-					query := tx.NewDelete().Table(tableName).Where(fmt.Sprintf("%s = ?", common.QuoteIdent(reflection.GetPrimaryKeyName(model))), itemID)
+					where, args, err := common.KeyCondition(model, itemID)
+					if err != nil {
+						return fmt.Errorf("invalid id %v: %w", itemID, err)
+					}
+					query := tx.NewDelete().Table(tableName).Where(where, args...)
 					result, err := query.Exec(ctx)
 					if err != nil {
 						return fmt.Errorf("failed to delete record %v: %w", itemID, err)
@@ -1164,29 +1827,29 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 					hookCtx.Result = map[string]interface{}{"deleted": result.RowsAffected()}
 					hookCtx.Error = nil
 					if err := h.hooks.Execute(AfterDelete, hookCtx); err != nil {
-						logger.Warn("AfterDelete hook failed for ID %v: %v", itemID, err)
+						logger.WarnContext(ctx, "AfterDelete hook failed for ID %v: %v", itemID, err)
 					}
+					h.queueAfterCommit(hookCtx, common.BatchActionDelete)
 				}
 				return nil
 			})
 			if err != nil {
-				logger.Error("Error in batch delete: %v", err)
+				logger.ErrorContext(ctx, "Error in batch delete: %v", err)
 				h.sendError(w, http.StatusInternalServerError, "delete_error", "Error deleting records", err)
 				return
 			}
-			logger.Info("Successfully deleted %d records", deletedCount)
+			logger.InfoContext(ctx, "Successfully deleted %d records", deletedCount)
 			h.sendResponse(w, map[string]interface{}{"deleted": deletedCount}, nil)
 			return
 
 		case []map[string]interface{}:
 			// Array of objects with id field
-			logger.Info("Batch delete with %d items ([]map[string]interface{})", len(v))
+			logger.InfoContext(ctx, "Batch delete with %d items ([]map[string]interface{})", len(v))
 			deletedCount := 0
-			pkName := reflection.GetPrimaryKeyName(model)
-			err := h.db.RunInTransaction(ctx, func(tx common.Database) error {
+			err := common.RunInTransaction(ctx, h.db, func(ctx context.Context, tx common.Database) error {
 				for _, item := range v {
-					if itemID, ok := item[pkName]; ok && itemID != nil {
-						itemIDStr := fmt.Sprintf("%v", itemID)
+					if itemID, ok := common.RecordKey(model, item); ok {
+						itemIDStr := itemID
 
 						// Execute hooks for each item
 						hookCtx := &HookContext{
@@ -1201,11 +1864,15 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 						}
 
 						if err := h.hooks.Execute(BeforeDelete, hookCtx); err != nil {
-							logger.Warn("BeforeDelete hook failed for ID %v: %v", itemID, err)
+							logger.WarnContext(ctx, "BeforeDelete hook failed for ID %v: %v", itemID, err)
 							continue
 						}
 
-						query := tx.NewDelete().Table(tableName).Where(fmt.Sprintf("%s = ?", common.QuoteIdent(reflection.GetPrimaryKeyName(model))), itemID)
+						where, args, err := common.KeyCondition(model, itemID)
+						if err != nil {
+							return fmt.Errorf("invalid id %v: %w", itemID, err)
+						}
+						query := tx.NewDelete().Table(tableName).Where(where, args...)
 						result, err := query.Exec(ctx)
 						if err != nil {
 							return fmt.Errorf("failed to delete record %v: %w", itemID, err)
@@ -1216,26 +1883,26 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 						hookCtx.Result = map[string]interface{}{"deleted": result.RowsAffected()}
 						hookCtx.Error = nil
 						if err := h.hooks.Execute(AfterDelete, hookCtx); err != nil {
-							logger.Warn("AfterDelete hook failed for ID %v: %v", itemID, err)
+							logger.WarnContext(ctx, "AfterDelete hook failed for ID %v: %v", itemID, err)
 						}
+						h.queueAfterCommit(hookCtx, common.BatchActionDelete)
 					}
 				}
 				return nil
 			})
 			if err != nil {
-				logger.Error("Error in batch delete: %v", err)
+				logger.ErrorContext(ctx, "Error in batch delete: %v", err)
 				h.sendError(w, http.StatusInternalServerError, "delete_error", "Error deleting records", err)
 				return
 			}
-			logger.Info("Successfully deleted %d records", deletedCount)
+			logger.InfoContext(ctx, "Successfully deleted %d records", deletedCount)
 			h.sendResponse(w, map[string]interface{}{"deleted": deletedCount}, nil)
 			return
 
 		case map[string]interface{}:
 			// Single object with id field
-			pkName := reflection.GetPrimaryKeyName(model)
-			if itemID, ok := v[pkName]; ok && itemID != nil {
-				id = fmt.Sprintf("%v", itemID)
+			if itemID, ok := common.RecordKey(model, v); ok {
+				id = itemID
 			}
 		}
 	}
@@ -1254,7 +1921,7 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 	}
 
 	if err := h.hooks.Execute(BeforeDelete, hookCtx); err != nil {
-		logger.Error("BeforeDelete hook failed: %v", err)
+		logger.ErrorContext(ctx, "BeforeDelete hook failed: %v", err)
 		h.sendError(w, http.StatusBadRequest, "hook_error", "Hook execution failed", err)
 		return
 	}
@@ -1265,13 +1932,45 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 		h.sendError(w, http.StatusBadRequest, "missing_id", "ID is required for delete", nil)
 		return
 	}
+	keyWhere, keyArgs, err := common.KeyCondition(model, id)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid_id", "Invalid id", err)
+		return
+	}
+
+	// Conditional delete: the record must still match the tag the client read
+	if options := GetOptions(ctx); options != nil && options.IfMatch != "" {
+		if err := checkIfMatch(ctx, h.db, model, id, options.IfMatch); err != nil {
+			if errors.Is(err, errPreconditionFailed) {
+				h.sendError(w, http.StatusPreconditionFailed, "precondition_failed", "Record was modified", err)
+			} else {
+				logger.ErrorContext(ctx, "Error checking If-Match: %v", err)
+				h.sendError(w, http.StatusInternalServerError, "delete_error", "Error deleting record", err)
+			}
+			return
+		}
+	}
+
+	query = query.Where(keyWhere, keyArgs...)
 
-	query = query.Where(fmt.Sprintf("%s = ?", common.QuoteIdent(reflection.GetPrimaryKeyName(model))), id)
+	// Select the record before it is deleted to return it
+	var records []interface{}
+	options := GetOptions(ctx)
+	returnRecords := options != nil && options.ReturnRecords
+	if returnRecords {
+		loaded, err := common.LoadRecords(ctx, h.db, model, tableName, keyWhere, keyArgs...)
+		if err != nil {
+			logger.ErrorContext(ctx, "Error loading record to delete: %v", err)
+			h.sendError(w, http.StatusInternalServerError, "delete_error", "Error deleting record", err)
+			return
+		}
+		records = loaded
+	}
 
 	// Execute BeforeScan hooks - pass query chain so hooks can modify it
 	hookCtx.Query = query
 	if err := h.hooks.Execute(BeforeScan, hookCtx); err != nil {
-		logger.Error("BeforeScan hook failed: %v", err)
+		logger.ErrorContext(ctx, "BeforeScan hook failed: %v", err)
 		h.sendError(w, http.StatusBadRequest, "hook_error", "Hook execution failed", err)
 		return
 	}
@@ -1281,29 +1980,245 @@ func (h *Handler) handleDelete(ctx context.Context, w common.ResponseWriter, id
 		query = modifiedQuery
 	}
 
+	started := time.Now()
 	result, err := query.Exec(ctx)
 	if err != nil {
-		logger.Error("Error deleting record: %v", err)
+		logger.ErrorContext(ctx, "Error deleting record: %v", err)
 		h.sendError(w, http.StatusInternalServerError, "delete_error", "Error deleting record", err)
 		return
 	}
 
+	// Execute AfterScan hooks with the query duration
+	hookCtx.Duration = time.Since(started)
+	if err := h.hooks.Execute(AfterScan, hookCtx); err != nil {
+		logger.ErrorContext(ctx, "AfterScan hook failed: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "hook_error", "Hook execution failed", err)
+		return
+	}
+
 	// Execute AfterDelete hooks
 	responseData := map[string]interface{}{
 		"deleted": result.RowsAffected(),
 	}
+	if returnRecords {
+		responseData["records"] = records
+	}
 	hookCtx.Result = responseData
 	hookCtx.Error = nil
 
 	if err := h.hooks.Execute(AfterDelete, hookCtx); err != nil {
-		logger.Error("AfterDelete hook failed: %v", err)
+		logger.ErrorContext(ctx, "AfterDelete hook failed: %v", err)
 		h.sendError(w, http.StatusInternalServerError, "hook_error", "Hook execution failed", err)
 		return
 	}
+	h.queueAfterCommit(hookCtx, common.BatchActionDelete)
 
 	h.sendResponse(w, responseData, nil)
 }
 
+// handleBatch executes a list of create/update/delete items, atomically unless the
+// batch sets "atomic": false. The create, update and delete hooks run for every item.
+func (h *Handler) handleBatch(ctx context.Context, w common.ResponseWriter, data interface{}, options ExtendedRequestOptions) {
+	// Capture panics and return error response
+	defer func() {
+		if err := recover(); err != nil {
+			h.handlePanic(w, "handleBatch", err)
+		}
+	}()
+
+	ctx, span := tracing.StartSpan(ctx, "restheadspec.batch", tracing.AttrOperation.String("batch"))
+	defer span.End()
+
+	schema := GetSchema(ctx)
+	entity := GetEntity(ctx)
+	tableName := GetTableName(ctx)
+	model := GetModel(ctx)
+
+	batch, err := common.ParseBatchRequest(data)
+	if err != nil {
+		logger.ErrorContext(ctx, "Invalid batch request: %v", err)
+		h.sendError(w, http.StatusBadRequest, "invalid_batch", "Invalid batch request", err)
+		return
+	}
+
+	logger.InfoContext(ctx, "Executing batch of %d item(s) for %s.%s (atomic: %v)", len(batch.Items), schema, entity, batch.IsAtomic())
+
+	result := common.ExecuteBatch(ctx, h.db, h.config, batch, func(ctx context.Context, tx common.Database, item common.BatchItem) (*common.ProcessResult, error) {
+		return h.executeWriteItem(ctx, tx, w, schema, entity, tableName, model, item, options)
+	})
+
+	logger.InfoContext(ctx, "Batch completed: %d succeeded, %d failed", result.Succeeded, result.Failed)
+
+	w.SetHeader("Content-Type", "application/json")
+	w.WriteHeader(result.StatusCode())
+	if err := w.WriteJSON(result); err != nil {
+		abortResponse(err)
+	}
+}
+
+// executeWriteItem runs a batch or transaction item against tx with the create,
+// update or delete hooks of its action
+func (h *Handler) executeWriteItem(ctx context.Context, tx common.Database, w common.ResponseWriter, schema, entity, tableName string, model interface{}, item common.BatchItem, options ExtendedRequestOptions) (*common.ProcessResult, error) {
+	before, after := batchHooks(item.Action)
+	pkName := reflection.GetPrimaryKeyName(model)
+	record := item.RecordData(pkName)
+	if item.Action != "delete" {
+		if err := h.config.Deserialize(ctx, schema, entity, record); err != nil {
+			return nil, err
+		}
+	}
+
+	hookCtx := &HookContext{
+		Context:   ctx,
+		Handler:   h,
+		Schema:    schema,
+		Entity:    entity,
+		TableName: tableName,
+		Model:     model,
+		Options:   options,
+		Data:      record,
+		Writer:    w,
+	}
+	if id, ok := record[pkName]; ok && id != nil {
+		hookCtx.ID = fmt.Sprintf("%v", id)
+	}
+
+	if err := h.hooks.Execute(before, hookCtx); err != nil {
+		return nil, fmt.Errorf("%s hook failed: %w", before, err)
+	}
+
+	// Use potentially modified data from hook context
+	if modified, ok := hookCtx.Data.(map[string]interface{}); ok {
+		record = modified
+	}
+	if item.Action != "delete" {
+		if err := common.ValidateEnums(model, record); err != nil {
+			return nil, err
+		}
+		if item.Action == common.BatchActionCreate {
+			if err := h.config.GenerateIDs(ctx, tx, schema, entity, model, record); err != nil {
+				return nil, err
+			}
+		}
+		h.config.StampAudit(ctx, model, record, item.Action == common.BatchActionCreate)
+		h.tenant.Stamp(model, record)
+	}
+
+	processor := common.NewNestedCUDProcessor(tx, h.registry, h)
+	processed, err := processor.ProcessNestedCUD(ctx, item.Action, record, model, make(map[string]interface{}), tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	hookCtx.Result = processed.Data
+	if err := h.hooks.Execute(after, hookCtx); err != nil {
+		return nil, fmt.Errorf("%s hook failed: %w", after, err)
+	}
+	h.queueAfterCommit(hookCtx, item.Action)
+	return processed, nil
+}
+
+// handleTransaction executes create/update/delete operations on multiple entities of
+// schema in one transaction. Later operations reference earlier results with "${ref.field}".
+func (h *Handler) handleTransaction(ctx context.Context, w common.ResponseWriter, schema string, data interface{}) {
+	// Capture panics and return error response
+	defer func() {
+		if err := recover(); err != nil {
+			h.handlePanic(w, "handleTransaction", err)
+		}
+	}()
+
+	ctx, span := tracing.StartSpan(ctx, "restheadspec.transaction",
+		tracing.AttrSchema.String(schema),
+		tracing.AttrOperation.String("transaction"),
+	)
+	defer span.End()
+
+	req, err := common.ParseTransactionRequest(data)
+	if err != nil {
+		logger.ErrorContext(ctx, "Invalid transaction request: %v", err)
+		h.sendError(w, http.StatusBadRequest, "invalid_transaction", "Invalid transaction request", err)
+		return
+	}
+
+	// Resolve all entities before starting the transaction
+	models := make(map[string]interface{}, len(req.Operations))
+	for _, op := range req.Operations {
+		if _, ok := models[op.Entity]; ok {
+			continue
+		}
+		model, err := h.registry.GetModelByEntity(schema, op.Entity)
+		if err != nil {
+			logger.ErrorContext(ctx, "Invalid entity in transaction: %v", err)
+			h.sendError(w, http.StatusBadRequest, "invalid_entity", fmt.Sprintf("Invalid entity %q", op.Entity), err)
+			return
+		}
+		models[op.Entity] = unwrapModel(model)
+	}
+
+	logger.InfoContext(ctx, "Executing transaction of %d operation(s) in schema %s", len(req.Operations), schema)
+
+	result := common.ExecuteTransaction(ctx, h.db, h.config, req, func(ctx context.Context, tx common.Database, op common.TransactionOperation) (*common.ProcessResult, string, error) {
+		model := models[op.Entity]
+		tableName := h.getTableName(schema, op.Entity, model)
+		processed, err := h.executeWriteItem(ctx, tx, w, schema, op.Entity, tableName, model, op.BatchItem(), ExtendedRequestOptions{})
+		return processed, reflection.GetPrimaryKeyName(model), err
+	})
+
+	logger.InfoContext(ctx, "Transaction completed (committed: %v)", result.Committed)
+
+	w.SetHeader("Content-Type", "application/json")
+	w.WriteHeader(result.StatusCode())
+	if err := w.WriteJSON(result); err != nil {
+		abortResponse(err)
+	}
+}
+
+// unwrapModel returns the struct value of a model registered as a pointer or slice
+func unwrapModel(model interface{}) interface{} {
+	modelType := reflect.TypeOf(model)
+	originalType := modelType
+	for modelType != nil && (modelType.Kind() == reflect.Ptr || modelType.Kind() == reflect.Slice || modelType.Kind() == reflect.Array) {
+		modelType = modelType.Elem()
+	}
+	if modelType == nil || modelType == originalType {
+		return model
+	}
+	return reflect.New(modelType).Elem().Interface()
+}
+
+// requestOperation returns the operation of a request for rate limiting
+func requestOperation(method, entity, id string) string {
+	switch {
+	case method == "GET" || method == "HEAD" || id == ViewsPath:
+		return ratelimit.OperationRead
+	case method == "DELETE":
+		return ratelimit.OperationDelete
+	case method == "PUT" || method == "PATCH":
+		return ratelimit.OperationUpdate
+	case entity == TransactionPath:
+		return ratelimit.OperationTransaction
+	case id == BatchPath:
+		return ratelimit.OperationBatch
+	case id != "":
+		return ratelimit.OperationUpdate
+	default:
+		return ratelimit.OperationCreate
+	}
+}
+
+// batchHooks returns the before and after hook types of a batch action
+func batchHooks(action string) (HookType, HookType) {
+	switch action {
+	case common.BatchActionUpdate:
+		return BeforeUpdate, AfterUpdate
+	case common.BatchActionDelete:
+		return BeforeDelete, AfterDelete
+	default:
+		return BeforeCreate, AfterCreate
+	}
+}
+
 // mergeRecordWithRequest merges a database record with the original request data
 // This preserves extra keys from the request that aren't in the database model
 // and updates values from the database (e.g., from SQL triggers or defaults)
@@ -1323,9 +2238,13 @@ func (h *Handler) mergeRecordWithRequest(dbRecord interface{}, requestData map[s
 		return requestData
 	}
 
-	// Start with the request data (preserves extra keys)
+	// Start with the request data (preserves extra keys); partial JSON updates are
+	// reflected by the patched column instead
 	result := make(map[string]interface{})
 	for k, v := range requestData {
+		if common.IsJSONPatchKey(k) {
+			continue
+		}
 		result[k] = v
 	}
 
@@ -1423,7 +2342,7 @@ func (h *Handler) processChildRelationsWithParentID(
 		// Get relationship info
 		relInfo := h.GetRelationshipInfo(modelType, relationName)
 		if relInfo == nil {
-			logger.Warn("No relationship info found for %s, skipping", relationName)
+			logger.WarnContext(ctx, "No relationship info found for %s, skipping", relationName)
 			continue
 		}
 
@@ -1451,6 +2370,11 @@ func (h *Handler) processChildRelationsForField(
 		return nil
 	}
 
+	// Many2many relations are written through their join table
+	if relInfo.RelationType == "many2many" {
+		return processor.ProcessManyToMany(ctx, operation, parentID, parentModelType, relInfo, relationValue)
+	}
+
 	// Get the related model
 	field, found := parentModelType.FieldByName(relInfo.FieldName)
 	if !found {
@@ -1550,73 +2474,170 @@ func (h *Handler) qualifyColumnName(columnName, fullTableName string) string {
 	return fmt.Sprintf("%s.%s", tableOnly, columnName)
 }
 
+// applyReadFilters applies the filters, filter group, custom SQL and full-text search of a read
+// to query, and returns the search for the ranking of its results. Filters of the except column
+// are left out, e.g. for the counts of a facet of the column; the filter group holds owner and
+// scope filters and is always applied.
+func (h *Handler) applyReadFilters(ctx context.Context, query common.SelectQuery, model interface{}, tableName string, options ExtendedRequestOptions, except string) (common.SelectQuery, *common.FullTextSQL, error) {
+	// Apply filters - validate and adjust for column types first
+	for i := range options.Filters {
+		filter := &options.Filters[i]
+		if except != "" && strings.EqualFold(filter.Column, except) {
+			continue
+		}
+
+		// Validate and adjust filter based on column type
+		castInfo := h.ValidateAndAdjustFilterForColumnType(filter, model)
+
+		// Default to AND if LogicOperator is not set
+		logicOp := filter.LogicOperator
+		if logicOp == "" {
+			logicOp = "AND"
+		}
+
+		logger.DebugContext(ctx, "Applying filter: %s %s %v (needsCast=%v, logic=%s)", filter.Column, filter.Operator, filter.Value, castInfo.NeedsCast, logicOp)
+		query = h.applyFilter(query, *filter, tableName, castInfo.NeedsCast, logicOp)
+	}
+
+	// Apply nested filter group as a single parenthesized condition (AND)
+	if !options.FilterGroup.IsEmpty() {
+		groupSQL, groupArgs := options.FilterGroup.ToSQL(func(filter common.FilterOption) (string, []interface{}) {
+			castInfo := h.ValidateAndAdjustFilterForColumnType(&filter, model)
+			return h.buildFilterCondition(filter, tableName, castInfo.NeedsCast)
+		})
+		if groupSQL != "" {
+			logger.DebugContext(ctx, "Applying filter group: %s", groupSQL)
+			query = query.Where(groupSQL, groupArgs...)
+		}
+	}
+
+	// Apply custom SQL WHERE clause (AND condition)
+	if options.CustomSQLWhere != "" {
+		logger.DebugContext(ctx, "Applying custom SQL WHERE: %s", options.CustomSQLWhere)
+		// Sanitize without auto-prefixing since custom SQL may reference multiple tables
+		sanitizedWhere := common.SanitizeWhereClause(options.CustomSQLWhere, reflection.ExtractTableNameOnly(tableName))
+		if sanitizedWhere != "" {
+			query = query.Where(sanitizedWhere)
+		}
+	}
+
+	// Apply custom SQL WHERE clause (OR condition)
+	if options.CustomSQLOr != "" {
+		logger.DebugContext(ctx, "Applying custom SQL OR: %s", options.CustomSQLOr)
+		// Sanitize without auto-prefixing since custom SQL may reference multiple tables
+		sanitizedOr := common.SanitizeWhereClause(options.CustomSQLOr, reflection.ExtractTableNameOnly(tableName))
+		if sanitizedOr != "" {
+			query = query.WhereOr(sanitizedOr)
+		}
+	}
+
+	// Apply full-text search
+	var search *common.FullTextSQL
+	if options.Search != "" {
+		var err error
+		search, err = h.buildFullTextSearch(model, tableName, options)
+		if err != nil {
+			return nil, nil, err
+		}
+		logger.DebugContext(ctx, "Applying full-text search: %s", search.Where)
+		query = query.Where(search.Where, search.Args...)
+	}
+
+	return query, search, nil
+}
+
 func (h *Handler) applyFilter(query common.SelectQuery, filter common.FilterOption, tableName string, needsCast bool, logicOp string) common.SelectQuery {
+	condition, args := h.buildFilterCondition(filter, tableName, needsCast)
+	if condition == "" {
+		return query
+	}
+	if logicOp == "OR" {
+		return query.WhereOr(condition, args...)
+	}
+	return query.Where(condition, args...)
+}
+
+// buildFilterCondition renders a filter as a SQL condition with ? placeholders.
+// It returns an empty condition when the filter cannot be applied.
+func (h *Handler) buildFilterCondition(filter common.FilterOption, tableName string, needsCast bool) (string, []interface{}) {
 	// Qualify the column name with table name if not already qualified
 	qualifiedColumn := h.qualifyColumnName(filter.Column, tableName)
 
-	// Apply casting to text if needed for non-numeric columns or non-numeric values
-	if needsCast {
-		qualifiedColumn = fmt.Sprintf("CAST(%s AS TEXT)", qualifiedColumn)
+	// Geo operators compare the geometry itself, never its text
+	if common.IsGeoOperator(filter.Operator) {
+		condition, args, err := common.GeoFilterCondition(filter.Operator, qualifiedColumn, filter.Value)
+		if err != nil {
+			logger.Warn("Invalid geo filter: %v", err)
+			return "", nil
+		}
+		return condition, args
 	}
 
-	// Helper function to apply the correct Where method based on logic operator
-	applyWhere := func(condition string, args ...interface{}) common.SelectQuery {
-		if logicOp == "OR" {
-			return query.WhereOr(condition, args...)
+	// Array operators compare the elements of the array column
+	if common.IsArrayOperator(filter.Operator) {
+		condition, args, err := common.ArrayFilterCondition(filter.Operator, qualifiedColumn, filter.Value)
+		if err != nil {
+			logger.Warn("Invalid array filter: %v", err)
+			return "", nil
 		}
-		return query.Where(condition, args...)
+		return condition, args
+	}
+
+	// Apply casting to text if needed for non-numeric columns or non-numeric values
+	if needsCast {
+		qualifiedColumn = common.DialectOf(h.db).CastToText(qualifiedColumn)
 	}
 
 	switch strings.ToLower(filter.Operator) {
 	case "eq", "equals":
-		return applyWhere(fmt.Sprintf("%s = ?", qualifiedColumn), filter.Value)
+		return fmt.Sprintf("%s = ?", qualifiedColumn), []interface{}{filter.Value}
 	case "neq", "not_equals", "ne":
-		return applyWhere(fmt.Sprintf("%s != ?", qualifiedColumn), filter.Value)
+		return fmt.Sprintf("%s != ?", qualifiedColumn), []interface{}{filter.Value}
 	case "gt", "greater_than":
-		return applyWhere(fmt.Sprintf("%s > ?", qualifiedColumn), filter.Value)
+		return fmt.Sprintf("%s > ?", qualifiedColumn), []interface{}{filter.Value}
 	case "gte", "greater_than_equals", "ge":
-		return applyWhere(fmt.Sprintf("%s >= ?", qualifiedColumn), filter.Value)
+		return fmt.Sprintf("%s >= ?", qualifiedColumn), []interface{}{filter.Value}
 	case "lt", "less_than":
-		return applyWhere(fmt.Sprintf("%s < ?", qualifiedColumn), filter.Value)
+		return fmt.Sprintf("%s < ?", qualifiedColumn), []interface{}{filter.Value}
 	case "lte", "less_than_equals", "le":
-		return applyWhere(fmt.Sprintf("%s <= ?", qualifiedColumn), filter.Value)
+		return fmt.Sprintf("%s <= ?", qualifiedColumn), []interface{}{filter.Value}
 	case "like":
-		return applyWhere(fmt.Sprintf("%s LIKE ?", qualifiedColumn), filter.Value)
+		return fmt.Sprintf("%s LIKE ?", qualifiedColumn), []interface{}{filter.Value}
 	case "ilike":
-		// Use ILIKE for case-insensitive search (PostgreSQL)
+		// Case-insensitive search: ILIKE on PostgreSQL, LOWER() LIKE LOWER() elsewhere
 		// Column is already cast to TEXT if needed
-		return applyWhere(fmt.Sprintf("%s ILIKE ?", qualifiedColumn), filter.Value)
+		return common.DialectOf(h.db).ILikeExpression(qualifiedColumn, "?"), []interface{}{filter.Value}
 	case "in":
-		return applyWhere(fmt.Sprintf("%s IN (?)", qualifiedColumn), filter.Value)
+		return fmt.Sprintf("%s IN (?)", qualifiedColumn), []interface{}{filter.Value}
 	case "between":
 		// Handle between operator - exclusive (> val1 AND < val2)
 		if values, ok := filter.Value.([]interface{}); ok && len(values) == 2 {
-			return applyWhere(fmt.Sprintf("%s > ? AND %s < ?", qualifiedColumn, qualifiedColumn), values[0], values[1])
+			return fmt.Sprintf("(%s > ? AND %s < ?)", qualifiedColumn, qualifiedColumn), []interface{}{values[0], values[1]}
 		} else if values, ok := filter.Value.([]string); ok && len(values) == 2 {
-			return applyWhere(fmt.Sprintf("%s > ? AND %s < ?", qualifiedColumn, qualifiedColumn), values[0], values[1])
+			return fmt.Sprintf("(%s > ? AND %s < ?)", qualifiedColumn, qualifiedColumn), []interface{}{values[0], values[1]}
 		}
 		logger.Warn("Invalid BETWEEN filter value format")
-		return query
+		return "", nil
 	case "between_inclusive":
 		// Handle between inclusive operator - inclusive (>= val1 AND <= val2)
 		if values, ok := filter.Value.([]interface{}); ok && len(values) == 2 {
-			return applyWhere(fmt.Sprintf("%s >= ? AND %s <= ?", qualifiedColumn, qualifiedColumn), values[0], values[1])
+			return fmt.Sprintf("(%s >= ? AND %s <= ?)", qualifiedColumn, qualifiedColumn), []interface{}{values[0], values[1]}
 		} else if values, ok := filter.Value.([]string); ok && len(values) == 2 {
-			return applyWhere(fmt.Sprintf("%s >= ? AND %s <= ?", qualifiedColumn, qualifiedColumn), values[0], values[1])
+			return fmt.Sprintf("(%s >= ? AND %s <= ?)", qualifiedColumn, qualifiedColumn), []interface{}{values[0], values[1]}
 		}
 		logger.Warn("Invalid BETWEEN INCLUSIVE filter value format")
-		return query
+		return "", nil
 	case "is_null", "isnull":
 		// Check for NULL values - don't use cast for NULL checks
 		colName := h.qualifyColumnName(filter.Column, tableName)
-		return applyWhere(fmt.Sprintf("(%s IS NULL OR %s = '')", colName, colName))
+		return fmt.Sprintf("(%s IS NULL OR %s = '')", colName, colName), nil
 	case "is_not_null", "isnotnull":
 		// Check for NOT NULL values - don't use cast for NULL checks
 		colName := h.qualifyColumnName(filter.Column, tableName)
-		return applyWhere(fmt.Sprintf("(%s IS NOT NULL AND %s != '')", colName, colName))
+		return fmt.Sprintf("(%s IS NOT NULL AND %s != '')", colName, colName), nil
 	default:
 		logger.Warn("Unknown filter operator: %s, defaulting to equals", filter.Operator)
-		return applyWhere(fmt.Sprintf("%s = ?", qualifiedColumn), filter.Value)
+		return fmt.Sprintf("%s = ?", qualifiedColumn), []interface{}{filter.Value}
 	}
 }
 
@@ -1693,9 +2714,10 @@ func (h *Handler) generateMetadata(schema, entity string, model interface{}) *co
 	if modelType.Kind() != reflect.Struct {
 		logger.Error("Model type must be a struct, got %s for %s.%s", modelType.Kind(), schema, entity)
 		return &common.TableMetadata{
-			Schema:  schema,
-			Table:   h.getTableName(schema, entity, model),
-			Columns: []common.Column{},
+			Schema:    schema,
+			Table:     h.getTableName(schema, entity, model),
+			Columns:   []common.Column{},
+			Relations: []common.RelationMetadata{},
 		}
 	}
 
@@ -1705,11 +2727,23 @@ func (h *Handler) generateMetadata(schema, entity string, model interface{}) *co
 		Schema:  schema,
 		Table:   tableName,
 		Columns: []common.Column{},
+		Relations: common.ModelRelations(model, h.registry, h, func(info *common.RelationshipInfo) bool {
+			return h.resolveExpandJoin(model, info.FieldName) != nil
+		}),
+	}
+	relationNames := make(map[string]bool, len(metadata.Relations))
+	for _, relation := range metadata.Relations {
+		relationNames[relation.Name] = true
 	}
 
 	for i := 0; i < modelType.NumField(); i++ {
 		field := modelType.Field(i)
 
+		// Relations are described in metadata.Relations
+		if relationNames[strings.Split(field.Tag.Get("json"), ",")[0]] {
+			continue
+		}
+
 		// Get column name from gorm tag or json tag
 		columnName := field.Tag.Get("gorm")
 		if strings.Contains(columnName, "column:") {
@@ -1737,7 +2771,9 @@ func (h *Handler) generateMetadata(schema, entity string, model interface{}) *co
 			IsPrimary:  strings.Contains(gormTag, "primaryKey") || strings.Contains(gormTag, "primary_key"),
 			IsUnique:   strings.Contains(gormTag, "unique"),
 			HasIndex:   strings.Contains(gormTag, "index"),
+			Enum:       common.EnumValues(field),
 		}
+		common.ApplyColumnTags(&column, field)
 
 		metadata.Columns = append(metadata.Columns, column)
 	}
@@ -1778,12 +2814,16 @@ func (h *Handler) sendResponseWithOptions(w common.ResponseWriter, data interfac
 	if options != nil && options.SingleRecordAsObject {
 		data = h.normalizeResultArray(data)
 	}
+	if options != nil && options.CleanJSON {
+		data = h.cleanJSON(data, *options)
+	}
 
 	// Return data as-is without wrapping in common.Response
-	w.WriteHeader(http.StatusOK)
-	if err := w.WriteJSON(data); err != nil {
-		logger.Error("Failed to write JSON response: %v", err)
+	accept := ""
+	if options != nil {
+		accept = options.Accept
 	}
+	h.writeEncoded(w, data, accept)
 }
 
 // normalizeResultArray converts a single-element array to an object if requested
@@ -1817,7 +2857,7 @@ func (h *Handler) sendFormattedResponse(w common.ResponseWriter, data interface{
 
 	// Clean JSON if requested (remove null/empty fields)
 	if options.CleanJSON {
-		data = h.cleanJSON(data)
+		data = h.cleanJSON(data, options)
 	}
 
 	w.SetHeader("Content-Type", "application/json")
@@ -1826,51 +2866,108 @@ func (h *Handler) sendFormattedResponse(w common.ResponseWriter, data interface{
 	w.SetHeader("X-Api-Range-Size", fmt.Sprintf("%d", metadata.Count))
 
 	// Format response based on response format option
+	var response interface{}
 	switch options.ResponseFormat {
 	case "simple":
 		// Simple format: just return the data array
-		w.WriteHeader(http.StatusOK)
-		if err := w.WriteJSON(data); err != nil {
-			logger.Error("Failed to write JSON response: %v", err)
+		response = data
+	case ODataResponseFormat:
+		// OData format: { "@odata.count": total, value: data }; single records are returned as-is
+		response = data
+		if kind := reflect.Indirect(reflect.ValueOf(data)).Kind(); kind == reflect.Slice || kind == reflect.Array {
+			odata := map[string]interface{}{"value": data}
+			if metadata != nil && metadata.Total >= 0 {
+				odata["@odata.count"] = metadata.Total
+			}
+			response = odata
 		}
 	case "syncfusion":
 		// Syncfusion format: { result: data, count: total }
-		response := map[string]interface{}{
+		syncfusion := map[string]interface{}{
 			"result": data,
 		}
 		if metadata != nil {
-			response["count"] = metadata.Total
-		}
-		w.WriteHeader(http.StatusOK)
-		if err := w.WriteJSON(response); err != nil {
-			logger.Error("Failed to write JSON response: %v", err)
+			syncfusion["count"] = metadata.Total
 		}
+		response = syncfusion
 	default:
 		// Default/detail format: standard response with metadata
-		response := common.Response{
+		response = common.Response{
 			Success:  true,
 			Data:     data,
 			Metadata: metadata,
 		}
+	}
+	h.writeEncoded(w, response, options.Accept)
+}
+
+// writeEncoded writes a successful response as JSON, or in the media type of a response encoder
+// negotiated with the Accept header of the request, e.g. MessagePack
+func (h *Handler) writeEncoded(w common.ResponseWriter, response interface{}, accept string) {
+	mediaType, encoder := h.config.NegotiateResponseEncoder(accept)
+	if encoder == nil {
 		w.WriteHeader(http.StatusOK)
 		if err := w.WriteJSON(response); err != nil {
-			logger.Error("Failed to write JSON response: %v", err)
+			abortResponse(err)
 		}
+		return
+	}
+
+	var encoded bytes.Buffer
+	if err := encoder(&encoded, response); err != nil {
+		h.sendError(w, http.StatusInternalServerError, "encoding_error", fmt.Sprintf("Error encoding response as %s", mediaType), err)
+		return
+	}
+	w.SetHeader("Content-Type", mediaType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(encoded.Bytes()); err != nil {
+		logger.Error("Failed to write %s response: %v", mediaType, err)
 	}
 }
 
-// cleanJSON removes null and empty fields from the response
-func (h *Handler) cleanJSON(data interface{}) interface{} {
-	// This is a simplified implementation
-	// A full implementation would recursively clean nested structures
-	// For now, we'll return the data as-is
-	// TODO: Implement recursive cleaning
-	return data
+// cleanJSON removes the null and empty fields selected by the options from the response, at
+// any depth. Data that can't be pruned is returned as it is.
+func (h *Handler) cleanJSON(data interface{}, options ExtendedRequestOptions) interface{} {
+	prune := options.CleanJSONPrune
+	if prune == 0 {
+		prune = common.PruneDefault
+	}
+	cleaned, err := common.PruneJSON(data, prune)
+	if err != nil {
+		logger.Warn("Failed to clean JSON response: %v", err)
+		return data
+	}
+	return cleaned
 }
 
 func (h *Handler) sendError(w common.ResponseWriter, statusCode int, code, message string, err error) {
+	// A hook answered the request itself
+	var hookResponse *hooks.Response
+	if errors.As(err, &hookResponse) {
+		if writeErr := hookResponse.Write(w); writeErr != nil {
+			logger.Error("Failed to write hook response: %v", writeErr)
+		}
+		return
+	}
+
+	// Errors caused by a client disconnect or an expired deadline are not server errors
+	requestID := common.RequestIDOfWriter(w)
+	expose := true
+	column := ""
+	if ctxStatus, ctxCode, ok := common.ContextErrorStatus(err); ok {
+		logger.Info("Request aborted (%s): %v", message, err)
+		statusCode, code = ctxStatus, ctxCode
+	} else if err != nil {
+		var resolved common.MappedError
+		resolved, expose = h.config.ResolveError(statusCode, code, message, err)
+		statusCode, code, message, column = resolved.Status, resolved.Code, resolved.Message, resolved.Column
+		if !expose {
+			logger.Error("Request %s failed (%s): %v", requestID, code, err)
+		}
+	}
+
 	var errorMsg string
-	if err != nil {
+	if err != nil && expose {
 		errorMsg = err.Error()
 	} else if message != "" {
 		errorMsg = message
@@ -1880,8 +2977,15 @@ func (h *Handler) sendError(w common.ResponseWriter, statusCode int, code, messa
 
 	response := map[string]interface{}{
 		"_error":  errorMsg,
+		"_code":   code,
 		"_retval": 1,
 	}
+	if column != "" {
+		response["_column"] = column
+	}
+	if requestID != "" {
+		response["_request_id"] = requestID
+	}
 	w.WriteHeader(statusCode)
 	if jsonErr := w.WriteJSON(response); jsonErr != nil {
 		logger.Error("Failed to write JSON error response: %v", jsonErr)
@@ -1893,7 +2997,7 @@ func (h *Handler) sendError(w common.ResponseWriter, statusCode int, code, messa
 func (h *Handler) FetchRowNumber(ctx context.Context, tableName string, pkName string, pkValue string, options ExtendedRequestOptions, model any) (int64, error) {
 	defer func() {
 		if r := recover(); r != nil {
-			logger.Error("Panic during FetchRowNumber: %v", r)
+			logger.ErrorContext(ctx, "Panic during FetchRowNumber: %v", r)
 		}
 	}()
 
@@ -1927,6 +3031,22 @@ func (h *Handler) FetchRowNumber(ctx context.Context, tableName string, pkName s
 		}
 	}
 
+	if !options.FilterGroup.IsEmpty() {
+		groupSQL, _ := options.FilterGroup.ToSQL(func(filter common.FilterOption) (string, []interface{}) {
+			return h.buildFilterSQL(&filter, tableName), nil
+		})
+		if groupSQL != "" {
+			whereClauses = append(whereClauses, groupSQL)
+		}
+	}
+
+	// The tenant database doesn't scope raw SQL, so the tenant condition is added here
+	args := make([]interface{}, 0, 2)
+	if h.tenant != nil && h.tenant.HasColumn(model) {
+		whereClauses = append(whereClauses, h.tenant.Condition(tableName))
+		args = append(args, h.tenant.Tenant)
+	}
+
 	// Combine WHERE clauses
 	whereSQL := ""
 	if len(whereClauses) > 0 {
@@ -1947,9 +3067,10 @@ func (h *Handler) FetchRowNumber(ctx context.Context, tableName string, pkName s
 	if len(options.Expand) > 0 {
 		joinParts := make([]string, 0, len(options.Expand))
 		for _, expand := range options.Expand {
-			// Note: This is a simplified join - in production you'd need proper FK mapping
-			joinParts = append(joinParts, fmt.Sprintf("LEFT JOIN %s ON %s.%s_id = %s.id",
-				expand.Relation, tableName, expand.Relation, expand.Relation))
+			// Only joined expands affect the row set; preloaded ones are separate queries
+			if join := h.resolveExpandJoin(model, expand.Relation); join != nil {
+				joinParts = append(joinParts, join.joinSQL(tableName))
+			}
 		}
 		joinSQL = strings.Join(joinParts, "\n")
 	}
@@ -1973,13 +3094,13 @@ func (h *Handler) FetchRowNumber(ctx context.Context, tableName string, pkName s
 		joinSQL,   // [5] - JOIN clauses
 	)
 
-	logger.Debug("FetchRowNumber query: %s, pkValue: %s", queryStr, pkValue)
+	logger.DebugContext(ctx, "FetchRowNumber query: %s, pkValue: %s", queryStr, pkValue)
 
 	// Execute the raw query with parameterized PK value
 	var result []struct {
 		RN int64 `bun:"rn"`
 	}
-	err := h.db.Query(ctx, &result, queryStr, pkValue)
+	err := h.db.Query(ctx, &result, queryStr, append(args, pkValue)...)
 	if err != nil {
 		return 0, fmt.Errorf("failed to fetch row number: %w", err)
 	}
@@ -2011,7 +3132,7 @@ func (h *Handler) buildFilterSQL(filter *common.FilterOption, tableName string)
 	case "like":
 		return fmt.Sprintf("%s LIKE '%v'", qualifiedColumn, filter.Value)
 	case "ilike":
-		return fmt.Sprintf("%s ILIKE '%v'", qualifiedColumn, filter.Value)
+		return common.DialectOf(h.db).ILikeExpression(qualifiedColumn, fmt.Sprintf("'%v'", filter.Value))
 	case "in":
 		if values, ok := filter.Value.([]any); ok {
 			valueStrs := make([]string, len(values))
@@ -2031,6 +3152,26 @@ func (h *Handler) buildFilterSQL(filter *common.FilterOption, tableName string)
 	}
 }
 
+// aggregateColumns returns the columns a read selects, which its aggregates may use: the
+// selected columns, or every column of the model, and the computed columns
+func aggregateColumns(model interface{}, options ExtendedRequestOptions) []string {
+	columns := make([]string, 0, len(options.Columns)+len(options.ComputedQL)+len(options.ComputedColumns))
+	if len(options.Columns) > 0 {
+		for _, column := range options.Columns {
+			columns = append(columns, reflection.ExtractSourceColumn(column))
+		}
+	} else {
+		columns = append(columns, reflection.GetSQLModelColumns(model)...)
+	}
+	for name := range options.ComputedQL {
+		columns = append(columns, name)
+	}
+	for _, computed := range options.ComputedColumns {
+		columns = append(columns, computed.Name)
+	}
+	return columns
+}
+
 // setRowNumbersOnRecords sets the RowNumber field on each record if it exists
 // The row number is calculated as offset + index + 1 (1-based)
 func (h *Handler) setRowNumbersOnRecords(records any, offset int) {
@@ -2077,36 +3218,46 @@ func (h *Handler) setRowNumbersOnRecords(records any, offset int) {
 }
 
 // filterExtendedOptions filters all column references, removing invalid ones and logging warnings
-func filterExtendedOptions(validator *common.ColumnValidator, options ExtendedRequestOptions) ExtendedRequestOptions {
+func filterExtendedOptions(validator *common.ColumnValidator, options ExtendedRequestOptions, model interface{}) ExtendedRequestOptions {
 	filtered := options
 
-	// Filter base RequestOptions
+	// Filter base RequestOptions (preloads are validated against their related models)
 	filtered.RequestOptions = validator.FilterRequestOptions(options.RequestOptions)
 
 	// Filter SearchColumns
 	filtered.SearchColumns = validator.FilterValidColumns(options.SearchColumns)
 
-	// Filter AdvancedSQL column keys
+	// Filter DistinctOn columns
+	filtered.DistinctOn = validator.FilterValidColumns(options.DistinctOn)
+
+	// Filter AdvancedSQL: the column must exist and the expression must be a valid scalar expression over the model
 	filteredAdvSQL := make(map[string]string)
 	for colName, sqlExpr := range options.AdvancedSQL {
-		if validator.IsValidColumn(colName) {
-			filteredAdvSQL[colName] = sqlExpr
-		} else {
+		if !validator.IsValidColumn(colName) {
 			logger.Warn("Invalid column in advanced SQL removed: %s", colName)
+			continue
+		}
+		if err := validator.ValidateComputedQL(sqlExpr); err != nil {
+			logger.Warn("Invalid advanced SQL expression for column '%s' removed: %v", colName, err)
+			continue
 		}
+		filteredAdvSQL[colName] = sqlExpr
 	}
 	filtered.AdvancedSQL = filteredAdvSQL
 
-	// ComputedQL columns are allowed to be any name since they're computed
-	// No filtering needed for ComputedQL keys
-	filtered.ComputedQL = options.ComputedQL
+	// Filter ComputedQL: the alias must be a model field to scan into and the expression must be valid CQL
+	filtered.ComputedQL = validator.FilterComputedQL(options.ComputedQL)
 
 	// Filter Expand columns
 	filteredExpands := make([]ExpandOption, 0, len(options.Expand))
 	for _, expand := range options.Expand {
 		filteredExpand := expand
-		// Don't validate relation name, only columns
-		filteredExpand.Columns = validator.FilterValidColumns(expand.Columns)
+		// Don't validate relation name, only columns - against the related model when it can be resolved
+		expandValidator := validator
+		if relatedModel := reflection.GetRelationModel(model, expand.Relation); relatedModel != nil {
+			expandValidator = common.NewColumnValidator(relatedModel)
+		}
+		filteredExpand.Columns = expandValidator.FilterValidColumns(expand.Columns)
 		filteredExpands = append(filteredExpands, filteredExpand)
 	}
 	filtered.Expand = filteredExpands
@@ -2131,13 +3282,15 @@ func (h *Handler) GetRelationshipInfo(modelType reflect.Type, relationName strin
 	}
 	// Convert internal type to common type
 	return &common.RelationshipInfo{
-		FieldName:    info.fieldName,
-		JSONName:     info.jsonName,
-		RelationType: info.relationType,
-		ForeignKey:   info.foreignKey,
-		References:   info.references,
-		JoinTable:    info.joinTable,
-		RelatedModel: info.relatedModel,
+		FieldName:      info.fieldName,
+		JSONName:       info.jsonName,
+		RelationType:   info.relationType,
+		ForeignKey:     info.foreignKey,
+		References:     info.references,
+		JoinTable:      info.joinTable,
+		JoinForeignKey: info.joinForeignKey,
+		JoinReferences: info.joinReferences,
+		RelatedModel:   info.relatedModel,
 	}
 }
 
@@ -2148,10 +3301,36 @@ type relationshipInfo struct {
 	foreignKey   string
 	references   string
 	joinTable    string
-	relatedModel interface{}
+	// join table columns of many2many relations, empty for the defaults
+	joinForeignKey string
+	joinReferences string
+	relatedModel   interface{}
 }
 
+// relationshipInfos caches the relationship info of model types by relation name; found
+// relations only, as relation names come from requests
+var relationshipInfos sync.Map // relationshipKey -> *relationshipInfo
+
+type relationshipKey struct {
+	modelType reflect.Type
+	relation  string
+}
+
+// getRelationshipInfo returns the relationship of modelType named relationName, reflecting over
+// the type on the first lookup only. The info is shared and must not be modified.
 func (h *Handler) getRelationshipInfo(modelType reflect.Type, relationName string) *relationshipInfo {
+	key := relationshipKey{modelType: modelType, relation: relationName}
+	if info, ok := relationshipInfos.Load(key); ok {
+		return info.(*relationshipInfo)
+	}
+	info := h.buildRelationshipInfo(modelType, relationName)
+	if info != nil {
+		relationshipInfos.Store(key, info)
+	}
+	return info
+}
+
+func (h *Handler) buildRelationshipInfo(modelType reflect.Type, relationName string) *relationshipInfo {
 	// Ensure we have a struct type
 	if modelType == nil || modelType.Kind() != reflect.Struct {
 		logger.Warn("Cannot get relationship info from non-struct type: %v", modelType)
@@ -2199,16 +3378,14 @@ func (h *Handler) getRelationshipInfo(modelType reflect.Type, relationName strin
 			} else if strings.Contains(gormTag, "many2many") {
 				info.relationType = "many2many"
 				info.joinTable = h.extractTagValue(gormTag, "many2many")
-				// Get the element type for many2many (always slice)
-				if field.Type.Kind() == reflect.Slice {
-					elemType := field.Type.Elem()
-					if elemType.Kind() == reflect.Ptr {
-						elemType = elemType.Elem()
-					}
-					if elemType.Kind() == reflect.Struct {
-						info.relatedModel = reflect.New(elemType).Elem().Interface()
-					}
-				}
+				h.extractJoinColumns(info, gormTag)
+				info.relatedModel = sliceElementModel(field.Type)
+			} else if joinTable, joinForeignKey, joinReferences, ok := common.ParseBunM2MTag(field.Tag.Get("bun")); ok {
+				info.relationType = "many2many"
+				info.joinTable = joinTable
+				info.joinForeignKey = joinForeignKey
+				info.joinReferences = joinReferences
+				info.relatedModel = sliceElementModel(field.Type)
 			} else {
 				// Field has no GORM relationship tags, so it's not a relation
 				return nil
@@ -2220,6 +3397,31 @@ func (h *Handler) getRelationshipInfo(modelType reflect.Type, relationName strin
 	return nil
 }
 
+// sliceElementModel returns a zero value of the struct element of a many2many field (always a slice)
+func sliceElementModel(fieldType reflect.Type) interface{} {
+	if fieldType.Kind() != reflect.Slice {
+		return nil
+	}
+	elemType := fieldType.Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return nil
+	}
+	return reflect.New(elemType).Elem().Interface()
+}
+
+// extractJoinColumns reads the join table columns of a GORM many2many tag
+func (h *Handler) extractJoinColumns(info *relationshipInfo, gormTag string) {
+	if joinForeignKey := h.extractTagValue(gormTag, "joinForeignKey"); joinForeignKey != "" {
+		info.joinForeignKey = common.JoinColumnFromField(joinForeignKey)
+	}
+	if joinReferences := h.extractTagValue(gormTag, "joinReferences"); joinReferences != "" {
+		info.joinReferences = common.JoinColumnFromField(joinReferences)
+	}
+}
+
 func (h *Handler) extractTagValue(tag, key string) string {
 	parts := strings.Split(tag, ";")
 	for _, part := range parts {
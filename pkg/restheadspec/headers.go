@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -18,9 +19,12 @@ type ExtendedRequestOptions struct {
 	common.RequestOptions
 
 	// Field selection
-	CleanJSON bool
+	CleanJSON      bool
+	CleanJSONPrune common.JSONPrune // Classes of empty fields CleanJSON removes, PruneDefault when 0
 
 	// Advanced filtering
+	Search         string // Full-text search text
+	SearchConfig   string // PostgreSQL text search configuration for Search
 	SearchColumns  []string
 	CustomSQLWhere string
 	CustomSQLOr    string
@@ -32,12 +36,18 @@ type ExtendedRequestOptions struct {
 	AdvancedSQL map[string]string // Column -> SQL expression
 	ComputedQL  map[string]string // Column -> CQL expression
 	Distinct    bool
+	DistinctOn  []string // DISTINCT ON columns (PostgreSQL only)
 	SkipCount   bool
 	SkipCache   bool
 	PKRow       *string
+	IDs         common.IDList // Primary keys of a read by id list (X-IDs)
+	Facets      []string      // Columns whose values are counted in the metadata (X-Facets)
+	Bucket      string        // Period and timestamp column of a series read (X-Bucket)
 
 	// Response format
-	ResponseFormat string // "simple", "detail", "syncfusion"
+	ResponseFormat string // "simple", "detail", "syncfusion", "xlsx"
+	Accept         string // Accept header, choosing JSON, MessagePack, CBOR or an added encoding
+	SheetName      string // Sheet of an xlsx response, the entity when empty
 
 	// Single record normalization - convert single-element arrays to objects
 	SingleRecordAsObject bool
@@ -45,6 +55,13 @@ type ExtendedRequestOptions struct {
 	// Transaction
 	AtomicTransaction bool
 
+	// Conditional requests (If-None-Match on reads, If-Match on updates and deletes)
+	IfNoneMatch string
+	IfMatch     string
+
+	// Row limit or confirmation of updates and deletes by filter (X-Write-Limit, X-Write-All)
+	WriteGuard common.WriteGuard
+
 	// X-Files configuration - comprehensive query options as a single JSON object
 	XFiles *XFiles
 }
@@ -132,9 +149,17 @@ func (h *Handler) parseOptionsFromHeaders(r common.Request, model interface{}) E
 		combinedParams[strings.ToLower(key)] = value
 	}
 
-	// Process each parameter (from both headers and query params)
+	// Process each parameter (from both headers and query params) in key order,
+	// so filters and sorts are applied deterministically
 	// Note: keys are already normalized to lowercase in combinedParams
-	for key, value := range combinedParams {
+	keys := make([]string, 0, len(combinedParams))
+	for key := range combinedParams {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := combinedParams[key]
 		// Decode value if it's base64 encoded
 		decodedValue := decodeHeaderValue(value)
 
@@ -146,7 +171,7 @@ func (h *Handler) parseOptionsFromHeaders(r common.Request, model interface{}) E
 		case strings.HasPrefix(key, "x-not-select-fields"):
 			h.parseNotSelectFields(&options, decodedValue)
 		case strings.HasPrefix(key, "x-clean-json"):
-			options.CleanJSON = strings.EqualFold(decodedValue, "true")
+			h.parseCleanJSON(&options, decodedValue)
 
 		// Filtering & Search
 		case strings.HasPrefix(key, "x-fieldfilter-"):
@@ -154,11 +179,17 @@ func (h *Handler) parseOptionsFromHeaders(r common.Request, model interface{}) E
 		case strings.HasPrefix(key, "x-searchfilter-"):
 			h.parseSearchFilter(&options, key, decodedValue)
 		case strings.HasPrefix(key, "x-searchop-"):
-			h.parseSearchOp(&options, key, decodedValue, "AND")
+			h.parseSearchOp(&options, key, decodedValue, "AND", model)
 		case strings.HasPrefix(key, "x-searchor-"):
-			h.parseSearchOp(&options, key, decodedValue, "OR")
+			h.parseSearchOp(&options, key, decodedValue, "OR", model)
 		case strings.HasPrefix(key, "x-searchand-"):
-			h.parseSearchOp(&options, key, decodedValue, "AND")
+			h.parseSearchOp(&options, key, decodedValue, "AND", model)
+		case strings.HasPrefix(key, "x-filter-json"):
+			h.parseFilterJSON(&options, decodedValue)
+		case key == "x-search":
+			options.Search = decodedValue
+		case key == "x-search-config":
+			options.SearchConfig = decodedValue
 		case strings.HasPrefix(key, "x-searchcols"):
 			options.SearchColumns = h.parseCommaSeparated(decodedValue)
 		case strings.HasPrefix(key, "x-custom-sql-w"):
@@ -235,6 +266,8 @@ func (h *Handler) parseOptionsFromHeaders(r common.Request, model interface{}) E
 			colName := strings.TrimPrefix(key, "x-cql-sel-")
 			options.ComputedQL[colName] = decodedValue
 
+		case strings.HasPrefix(key, "x-distinct-on"):
+			options.DistinctOn = h.parseCommaSeparated(decodedValue)
 		case strings.HasPrefix(key, "x-distinct"):
 			options.Distinct = strings.EqualFold(decodedValue, "true")
 		case strings.HasPrefix(key, "x-skipcount"):
@@ -245,14 +278,35 @@ func (h *Handler) parseOptionsFromHeaders(r common.Request, model interface{}) E
 			options.FetchRowNumber = &decodedValue
 		case strings.HasPrefix(key, "x-pkrow"):
 			options.PKRow = &decodedValue
+		case key == "x-ids":
+			options.IDs = common.ParseIDList(decodedValue)
+		case key == "x-explain":
+			options.Explain = decodedValue
+		case key == "x-bucket":
+			options.Bucket = decodedValue
+		case key == "x-facets":
+			options.Facets = append(options.Facets, h.parseCommaSeparated(decodedValue)...)
+		case key == "x-aggregate":
+			options.Aggregates = append(options.Aggregates, common.ParseAggregates(decodedValue)...)
+		case key == "x-scope":
+			options.Scopes = append(options.Scopes, h.parseCommaSeparated(decodedValue)...)
 
 		// Response Format
+		case key == "accept":
+			options.Accept = value
+			if strings.Contains(strings.ToLower(value), common.XLSXContentType) {
+				options.ResponseFormat = XLSXResponseFormat
+			}
 		case strings.HasPrefix(key, "x-simpleapi"):
 			options.ResponseFormat = "simple"
 		case strings.HasPrefix(key, "x-detailapi"):
 			options.ResponseFormat = "detail"
 		case strings.HasPrefix(key, "x-syncfusion"):
 			options.ResponseFormat = "syncfusion"
+		case key == "x-xlsx-sheet":
+			options.SheetName = decodedValue
+		case key == "x-xlsx":
+			options.ResponseFormat = XLSXResponseFormat
 		case strings.HasPrefix(key, "x-single-record-as-object"):
 			// Parse as boolean - "false" disables, "true" enables (default is true)
 			if strings.EqualFold(decodedValue, "false") {
@@ -265,9 +319,31 @@ func (h *Handler) parseOptionsFromHeaders(r common.Request, model interface{}) E
 		case strings.HasPrefix(key, "x-transaction-atomic"):
 			options.AtomicTransaction = strings.EqualFold(decodedValue, "true")
 
+		// Conditional Requests - entity tags are never encoded
+		case key == "if-none-match":
+			options.IfNoneMatch = value
+		case key == "if-match":
+			options.IfMatch = value
+
+		// Writes by filter
+		case key == "x-write-limit":
+			if limit, err := strconv.Atoi(decodedValue); err == nil {
+				options.WriteGuard.Limit = limit
+			}
+		case key == "x-write-all":
+			options.WriteGuard.All = strings.EqualFold(decodedValue, "true")
+		case key == "x-return-records":
+			options.ReturnRecords = strings.EqualFold(decodedValue, "true")
+		case key == "x-lookup-key":
+			options.LookupKey = decodedValue
+
 		// X-Files - comprehensive JSON configuration
 		case strings.HasPrefix(key, "x-files"):
 			h.parseXFiles(&options, decodedValue)
+
+		// OData system query options ($filter, $orderby, $top, ...) - values are never encoded
+		case strings.HasPrefix(key, "$"):
+			h.parseODataOption(&options, key, value)
 		}
 	}
 
@@ -276,8 +352,12 @@ func (h *Handler) parseOptionsFromHeaders(r common.Request, model interface{}) E
 		h.resolveRelationNamesInOptions(&options, model)
 	}
 
-	// Always sort according to the primary key if no sorting is specified
-	if len(options.Sort) == 0 {
+	// Without a sort, reads are sorted by the default sort of the model, else by the primary key,
+	// except distinct reads; full-text searches are sorted by relevance first
+	if len(options.Sort) == 0 && options.Search == "" {
+		options.Sort = common.ModelDefaultSort(model)
+	}
+	if len(options.Sort) == 0 && options.Search == "" && !options.isDistinct() {
 		pkName := reflection.GetPrimaryKeyName(model)
 		options.Sort = []common.SortOption{{Column: pkName, Direction: "ASC"}}
 	}
@@ -285,6 +365,13 @@ func (h *Handler) parseOptionsFromHeaders(r common.Request, model interface{}) E
 	return options
 }
 
+// isDistinct reports whether the read selects distinct rows. Its sort isn't extended by the
+// primary key: with DISTINCT every sort column must be selected, and with DISTINCT ON the sort
+// picks the row of each group.
+func (o ExtendedRequestOptions) isDistinct() bool {
+	return o.Distinct || len(o.DistinctOn) > 0
+}
+
 // parseSelectFields parses x-select-fields header
 func (h *Handler) parseSelectFields(options *ExtendedRequestOptions, value string) {
 	if value == "" {
@@ -307,6 +394,18 @@ func (h *Handler) parseNotSelectFields(options *ExtendedRequestOptions, value st
 	}
 }
 
+// parseCleanJSON parses x-clean-json header: true, false or the classes of empty fields to
+// remove, e.g. "null,array"
+func (h *Handler) parseCleanJSON(options *ExtendedRequestOptions, value string) {
+	prune, err := common.ParseJSONPrune(value)
+	if err != nil {
+		logger.Warn("Failed to parse x-clean-json header: %v", err)
+		return
+	}
+	options.CleanJSON = prune != 0
+	options.CleanJSONPrune = prune
+}
+
 // parseFieldFilter parses x-fieldfilter-{colname} header (exact match)
 func (h *Handler) parseFieldFilter(options *ExtendedRequestOptions, headerKey, value string) {
 	colName := strings.TrimPrefix(headerKey, "x-fieldfilter-")
@@ -331,7 +430,7 @@ func (h *Handler) parseSearchFilter(options *ExtendedRequestOptions, headerKey,
 }
 
 // parseSearchOp parses x-searchop-{operator}-{colname} and x-searchor-{operator}-{colname}
-func (h *Handler) parseSearchOp(options *ExtendedRequestOptions, headerKey, value, logicOp string) {
+func (h *Handler) parseSearchOp(options *ExtendedRequestOptions, headerKey, value, logicOp string, model interface{}) {
 	// Extract operator and column name
 	// Format: x-searchop-{operator}-{colname} or x-searchor-{operator}-{colname}
 	var prefix string
@@ -355,7 +454,7 @@ func (h *Handler) parseSearchOp(options *ExtendedRequestOptions, headerKey, valu
 	colName := parts[1]
 
 	// Map operator names to filter operators
-	filterOp := h.mapSearchOperator(colName, operator, value)
+	filterOp := h.mapSearchOperator(colName, operator, value, model)
 
 	// Set the logic operator (AND or OR)
 	filterOp.LogicOperator = logicOp
@@ -365,12 +464,24 @@ func (h *Handler) parseSearchOp(options *ExtendedRequestOptions, headerKey, valu
 	logger.Debug("%s logic filter: %s %s %v", logicOp, colName, filterOp.Operator, filterOp.Value)
 }
 
-// mapSearchOperator maps search operator names to filter operators
-func (h *Handler) mapSearchOperator(colName, operator, value string) common.FilterOption {
+// mapSearchOperator maps search operator names to filter operators.
+// contains on an array column of the model checks for elements instead of a substring.
+func (h *Handler) mapSearchOperator(colName, operator, value string, model interface{}) common.FilterOption {
 	operator = strings.ToLower(operator)
 
 	switch operator {
-	case "contains", "contain", "like":
+	case "contains", "contain":
+		if _, isArray := reflection.GetArrayElementKind(model, colName); isArray {
+			return common.FilterOption{Column: colName, Operator: "contains", Value: value}
+		}
+		return common.FilterOption{Column: colName, Operator: "ilike", Value: "%" + value + "%"}
+	case "containedby", "contained_by":
+		return common.FilterOption{Column: colName, Operator: "contained_by", Value: value}
+	case "overlaps", "overlap":
+		return common.FilterOption{Column: colName, Operator: "overlaps", Value: value}
+	case "anyeq", "any_eq":
+		return common.FilterOption{Column: colName, Operator: "any_eq", Value: value}
+	case "like":
 		return common.FilterOption{Column: colName, Operator: "ilike", Value: "%" + value + "%"}
 	case "beginswith", "startswith":
 		return common.FilterOption{Column: colName, Operator: "ilike", Value: value + "%"}
@@ -408,6 +519,9 @@ func (h *Handler) mapSearchOperator(colName, operator, value string) common.Filt
 		// Parse IN values (format: "value1,value2,value3")
 		values := strings.Split(value, ",")
 		return common.FilterOption{Column: colName, Operator: "in", Value: values}
+	case "st_within", "st_intersects", "st_dwithin":
+		// Geo values are parsed when the condition is built (bounding box, radius, GeoJSON or WKT)
+		return common.FilterOption{Column: colName, Operator: operator, Value: value}
 	case "empty", "isnull", "null":
 		// Check for NULL or empty string
 		return common.FilterOption{Column: colName, Operator: "is_null", Value: nil}
@@ -489,6 +603,56 @@ func (h *Handler) parseExpand(options *ExtendedRequestOptions, value string) {
 	}
 }
 
+// parseFilterJSON parses x-filter-json header (nested AND/OR filter groups)
+// Format: {"logic":"or","filters":[{"column":"status","operator":"eq","value":"active"}],"groups":[...]}
+func (h *Handler) parseFilterJSON(options *ExtendedRequestOptions, value string) {
+	if value == "" {
+		return
+	}
+
+	var group common.FilterGroup
+	if err := json.Unmarshal([]byte(value), &group); err != nil {
+		logger.Warn("Failed to parse x-filter-json header: %v", err)
+		return
+	}
+
+	normalizeFilterGroupValues(&group)
+	addFilterGroup(options, group)
+}
+
+// addFilterGroup adds a filter group to the request, combining it with an existing group using AND
+func addFilterGroup(options *ExtendedRequestOptions, group common.FilterGroup) {
+	options.FilterGroup = common.AndFilterGroups(options.FilterGroup, group)
+}
+
+// normalizeFilterGroupValues converts JSON filter values to the string form used by the
+// other filter headers, so column type validation and casting treat them the same way
+func normalizeFilterGroupValues(group *common.FilterGroup) {
+	for idx := range group.Filters {
+		group.Filters[idx].Value = normalizeFilterValue(group.Filters[idx].Value)
+	}
+	for idx := range group.Groups {
+		normalizeFilterGroupValues(&group.Groups[idx])
+	}
+}
+
+func normalizeFilterValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		values := make([]string, len(v))
+		for idx, item := range v {
+			values[idx] = fmt.Sprintf("%v", normalizeFilterValue(item))
+		}
+		return values
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
 // parseSorting parses x-sort header
 // Format: +field1,-field2,field3 (+ for ASC, - for DESC, default ASC)
 func (h *Handler) parseSorting(options *ExtendedRequestOptions, value string) {
@@ -797,6 +961,16 @@ func (h *Handler) resolveRelationName(model interface{}, nameOrTable string) str
 		}
 	}
 
+	// Then match the JSON name of a field or its name ignoring case (e.g. "employees" from OData clients)
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if jsonName == nameOrTable || strings.EqualFold(field.Name, nameOrTable) {
+			logger.Debug("Resolved relation name '%s' to field '%s'", nameOrTable, field.Name)
+			return field.Name
+		}
+	}
+
 	// If not found as a field name, try to look it up as a table name
 	normalizedInput := strings.ToLower(strings.ReplaceAll(nameOrTable, "_", ""))
 
@@ -973,6 +1147,25 @@ func (h *Handler) ValidateAndAdjustFilterForColumnType(filter *common.FilterOpti
 		return ColumnCastInfo{NeedsCast: false, IsNumericType: false}
 	}
 
+	// Array operators compare elements, coerced to the element type of the array column;
+	// "contains" on a text column is a substring match
+	if common.IsArrayOperator(filter.Operator) {
+		elemKind, isArray := reflection.GetArrayElementKind(model, filter.Column)
+		if !isArray && strings.EqualFold(filter.Operator, "contains") && reflection.IsStringType(reflection.GetColumnTypeFromModel(model, filter.Column)) {
+			filter.Operator = "ilike"
+			filter.Value = fmt.Sprintf("%%%v%%", filter.Value)
+			return ColumnCastInfo{NeedsCast: false, IsNumericType: false}
+		}
+		values, err := common.CoerceArrayFilterValue(filter.Value, elemKind)
+		if err != nil {
+			logger.Warn("Invalid value for array filter on %s: %v", filter.Column, err)
+			filter.Value = nil
+		} else {
+			filter.Value = values
+		}
+		return ColumnCastInfo{NeedsCast: false, IsNumericType: false}
+	}
+
 	colType := reflection.GetColumnTypeFromModel(model, filter.Column)
 	if colType == reflect.Invalid {
 		// Column not found in model, no casting needed
@@ -985,6 +1178,9 @@ func (h *Handler) ValidateAndAdjustFilterForColumnType(filter *common.FilterOpti
 	if strVal, ok := filter.Value.(string); ok {
 		strVal = strings.Trim(strVal, "%")
 		valueIsNumeric = reflection.IsNumericValue(strVal)
+	} else if filter.Value != nil {
+		// Numbers of JSON bodies, e.g. the filters of a delete by filter
+		valueIsNumeric = reflection.IsNumericType(reflect.TypeOf(filter.Value).Kind())
 	}
 
 	// Adjust based on column type
@@ -2,34 +2,39 @@ package restheadspec
 
 import (
 	"context"
-	"fmt"
+	"time"
 
 	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/hooks"
 	"github.com/bitechdev/ResolveSpec/pkg/logger"
 )
 
-// HookType defines the type of hook to execute
-type HookType string
+// HookType defines the type of hook to execute, shared with resolvespec
+type HookType = hooks.Type
 
 const (
 	// Read operation hooks
-	BeforeRead HookType = "before_read"
-	AfterRead  HookType = "after_read"
+	BeforeRead = hooks.BeforeRead
+	AfterRead  = hooks.AfterRead
 
 	// Create operation hooks
-	BeforeCreate HookType = "before_create"
-	AfterCreate  HookType = "after_create"
+	BeforeCreate = hooks.BeforeCreate
+	AfterCreate  = hooks.AfterCreate
 
 	// Update operation hooks
-	BeforeUpdate HookType = "before_update"
-	AfterUpdate  HookType = "after_update"
+	BeforeUpdate = hooks.BeforeUpdate
+	AfterUpdate  = hooks.AfterUpdate
 
 	// Delete operation hooks
-	BeforeDelete HookType = "before_delete"
-	AfterDelete  HookType = "after_delete"
+	BeforeDelete = hooks.BeforeDelete
+	AfterDelete  = hooks.AfterDelete
 
 	// Scan/Execute operation hooks
-	BeforeScan HookType = "before_scan"
+	BeforeScan = hooks.BeforeScan
+	AfterScan  = hooks.AfterScan // Runs after the query executed, with its Duration
+
+	// Runs in the background after the write committed
+	AfterCommit = hooks.AfterCommit
 )
 
 // HookContext contains all the data available to a hook
@@ -41,8 +46,10 @@ type HookContext struct {
 	TableName string
 	Model     interface{}
 	Options   ExtendedRequestOptions
+	RequestID string // Id of the request, as returned in X-Request-ID
 
 	// Operation-specific fields
+	Operation   string // create, update or delete, set for AfterCommit hooks
 	ID          string
 	Data        interface{} // For create/update operations
 	Result      interface{} // For after hooks
@@ -52,96 +59,62 @@ type HookContext struct {
 	// Query chain - allows hooks to modify the query before execution
 	// Can be SelectQuery, InsertQuery, UpdateQuery, or DeleteQuery
 	Query interface{}
+	// Duration is the execution time of Query, set for AfterScan hooks
+	Duration time.Duration
 
 	// Response writer - allows hooks to modify response
 	Writer common.ResponseWriter
 }
 
+// HookTarget returns the schema and entity of the request, matched against the entity
+// patterns of hooks registered with hooks.ForEntity
+func (c *HookContext) HookTarget() (schema, entity string) {
+	return c.Schema, c.Entity
+}
+
+// queueAfterCommit runs the AfterCommit hooks of a write once its transaction committed, with
+// a copy of the hook context of its after hook. The write drops the cached totals of the entity.
+func (h *Handler) queueAfterCommit(hookCtx *HookContext, operation string) {
+	if h.dryRun != nil {
+		return
+	}
+	if h.config.Entity(hookCtx.Schema, hookCtx.Entity).CountStrategy == common.CountCached {
+		h.countCache().InvalidateOnWrite(hookCtx.Context, hookCtx.Schema, hookCtx.Entity)
+	}
+	if !h.hooks.HasHooks(AfterCommit) {
+		return
+	}
+	commitCtx := *hookCtx
+	commitCtx.Operation = operation
+	commitCtx.Writer = nil // The response may be sent already
+	common.AfterCommit(hookCtx.Context, func(ctx context.Context) {
+		commitCtx.Context = ctx
+		if err := h.hooks.Execute(AfterCommit, &commitCtx); err != nil {
+			logger.WarnContext(ctx, "AfterCommit hook failed for %s.%s: %v", commitCtx.Schema, commitCtx.Entity, err)
+		}
+	})
+}
+
 // HookFunc is the signature for hook functions
 // It receives a HookContext and can modify it or return an error
 // If an error is returned, the operation will be aborted
-type HookFunc func(*HookContext) error
+type HookFunc = hooks.Func[*HookContext]
 
 // HookRegistry manages all registered hooks
 type HookRegistry struct {
-	hooks map[HookType][]HookFunc
+	hooks.Registry[*HookContext]
 }
 
 // NewHookRegistry creates a new hook registry
 func NewHookRegistry() *HookRegistry {
-	return &HookRegistry{
-		hooks: make(map[HookType][]HookFunc),
-	}
-}
-
-// Register adds a new hook for the specified hook type
-func (r *HookRegistry) Register(hookType HookType, hook HookFunc) {
-	if r.hooks == nil {
-		r.hooks = make(map[HookType][]HookFunc)
-	}
-	r.hooks[hookType] = append(r.hooks[hookType], hook)
-	logger.Info("Registered hook for %s (total: %d)", hookType, len(r.hooks[hookType]))
-}
-
-// RegisterMultiple registers a hook for multiple hook types
-func (r *HookRegistry) RegisterMultiple(hookTypes []HookType, hook HookFunc) {
-	for _, hookType := range hookTypes {
-		r.Register(hookType, hook)
-	}
+	return &HookRegistry{Registry: *hooks.NewRegistry[*HookContext]()}
 }
 
 // Execute runs all hooks for the specified type in order
 // If any hook returns an error, execution stops and the error is returned
 func (r *HookRegistry) Execute(hookType HookType, ctx *HookContext) error {
-	hooks, exists := r.hooks[hookType]
-	if !exists || len(hooks) == 0 {
-		// logger.Debug("No hooks registered for %s", hookType)
-		return nil
-	}
-
-	logger.Debug("Executing %d hook(s) for %s", len(hooks), hookType)
-
-	for i, hook := range hooks {
-		if err := hook(ctx); err != nil {
-			logger.Error("Hook %d for %s failed: %v", i+1, hookType, err)
-			return fmt.Errorf("hook execution failed: %w", err)
-		}
-	}
-
-	// logger.Debug("All hooks for %s executed successfully", hookType)
-	return nil
-}
-
-// Clear removes all hooks for the specified type
-func (r *HookRegistry) Clear(hookType HookType) {
-	delete(r.hooks, hookType)
-	logger.Info("Cleared all hooks for %s", hookType)
-}
-
-// ClearAll removes all registered hooks
-func (r *HookRegistry) ClearAll() {
-	r.hooks = make(map[HookType][]HookFunc)
-	logger.Info("Cleared all hooks")
-}
-
-// Count returns the number of hooks registered for a specific type
-func (r *HookRegistry) Count(hookType HookType) int {
-	if hooks, exists := r.hooks[hookType]; exists {
-		return len(hooks)
-	}
-	return 0
-}
-
-// HasHooks returns true if there are any hooks registered for the specified type
-func (r *HookRegistry) HasHooks(hookType HookType) bool {
-	return r.Count(hookType) > 0
-}
-
-// GetAllHookTypes returns all hook types that have registered hooks
-func (r *HookRegistry) GetAllHookTypes() []HookType {
-	types := make([]HookType, 0, len(r.hooks))
-	for hookType := range r.hooks {
-		types = append(types, hookType)
+	if ctx.RequestID == "" {
+		ctx.RequestID = common.RequestIDFromContext(ctx.Context)
 	}
-	return types
+	return r.Registry.Execute(hookType, ctx)
 }
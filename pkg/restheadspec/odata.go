@@ -0,0 +1,657 @@
+package restheadspec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// ODataResponseFormat is the response format used when a request carries OData
+// system query options: {"@odata.count": total, "value": [...]}
+const ODataResponseFormat = "odata"
+
+// parseODataOption maps an OData v4 system query option ($filter, $orderby, $top,
+// $skip, $select, $expand, $count) onto the request options. key is lowercase.
+func (h *Handler) parseODataOption(options *ExtendedRequestOptions, key, value string) {
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case "$filter":
+		group, err := ParseODataFilter(value)
+		if err != nil {
+			logger.Warn("Failed to parse $filter: %v", err)
+			return
+		}
+		addFilterGroup(options, *group)
+	case "$orderby":
+		options.Sort = append(options.Sort, parseODataOrderBy(value)...)
+	case "$top":
+		if top, err := strconv.Atoi(value); err == nil && top >= 0 {
+			options.Limit = &top
+		}
+	case "$skip":
+		if skip, err := strconv.Atoi(value); err == nil && skip >= 0 {
+			options.Offset = &skip
+		}
+	case "$select":
+		options.Columns = parseODataSelect(value)
+	case "$expand":
+		preloads, err := parseODataExpand(value, "")
+		if err != nil {
+			logger.Warn("Failed to parse $expand: %v", err)
+			return
+		}
+		options.Preload = append(options.Preload, preloads...)
+	case "$count", "$inlinecount":
+		// $count=true (v4) and $inlinecount=allpages (v2, used by Syncfusion) request the total
+		options.SkipCount = strings.EqualFold(value, "false") || strings.EqualFold(value, "none")
+	default:
+		logger.Debug("Unsupported OData query option: %s", key)
+		return
+	}
+
+	options.ResponseFormat = ODataResponseFormat
+}
+
+// parseODataOrderBy parses "name desc,id" into sort options
+func parseODataOrderBy(value string) []common.SortOption {
+	sorts := make([]common.SortOption, 0)
+	for _, item := range splitODataList(value, ',') {
+		fields := strings.Fields(item)
+		if len(fields) == 0 {
+			continue
+		}
+		direction := "ASC"
+		if len(fields) > 1 && strings.EqualFold(fields[1], "desc") {
+			direction = "DESC"
+		}
+		sorts = append(sorts, common.SortOption{Column: odataPath(fields[0]), Direction: direction})
+	}
+	return sorts
+}
+
+// parseODataSelect parses "id,name" into columns; "*" selects every column
+func parseODataSelect(value string) []string {
+	columns := make([]string, 0)
+	for _, item := range splitODataList(value, ',') {
+		if item == "" || item == "*" {
+			continue
+		}
+		if strings.Contains(item, "/") {
+			logger.Warn("Selecting properties of related entities is not supported in $select: %s", item)
+			continue
+		}
+		columns = append(columns, item)
+	}
+	return columns
+}
+
+// parseODataExpand parses "orders($select=id;$top=5;$expand=items),customer" into
+// preload options. Nested expansions are added with their full relation path.
+func parseODataExpand(value, parent string) ([]common.PreloadOption, error) {
+	preloads := make([]common.PreloadOption, 0)
+	for _, item := range splitODataList(value, ',') {
+		if item == "" {
+			continue
+		}
+
+		relation, nested := item, ""
+		if idx := strings.Index(item, "("); idx >= 0 {
+			if !strings.HasSuffix(item, ")") {
+				return nil, fmt.Errorf("unbalanced parentheses in %q", item)
+			}
+			relation, nested = strings.TrimSpace(item[:idx]), item[idx+1:len(item)-1]
+		}
+		relation = odataPath(relation)
+		if parent != "" {
+			relation = parent + "." + relation
+		}
+
+		preload := common.PreloadOption{Relation: relation}
+		var children []common.PreloadOption
+		for _, option := range splitODataList(nested, ';') {
+			name, optionValue, ok := strings.Cut(option, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid option %q in $expand of %s", option, relation)
+			}
+			optionValue = strings.TrimSpace(optionValue)
+
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "$select":
+				preload.Columns = parseODataSelect(optionValue)
+			case "$orderby":
+				preload.Sort = parseODataOrderBy(optionValue)
+			case "$top":
+				if top, err := strconv.Atoi(optionValue); err == nil && top >= 0 {
+					preload.Limit = &top
+				}
+			case "$skip":
+				if skip, err := strconv.Atoi(optionValue); err == nil && skip >= 0 {
+					preload.Offset = &skip
+				}
+			case "$filter":
+				group, err := ParseODataFilter(optionValue)
+				if err != nil {
+					return nil, err
+				}
+				// Preload filters are combined with AND, so only plain conjunctions are supported
+				if len(group.Groups) > 0 || (group.LogicOperator() == "OR" && len(group.Filters) > 1) {
+					return nil, fmt.Errorf("only 'and' conditions are supported in $filter of %s", relation)
+				}
+				preload.Filters = group.Filters
+			case "$expand":
+				nestedPreloads, err := parseODataExpand(optionValue, relation)
+				if err != nil {
+					return nil, err
+				}
+				children = append(children, nestedPreloads...)
+			default:
+				logger.Debug("Unsupported option in $expand of %s: %s", relation, name)
+			}
+		}
+
+		preloads = append(preloads, preload)
+		preloads = append(preloads, children...)
+	}
+	return preloads, nil
+}
+
+// odataPath converts a property path (Customer/Name) to the dotted form used by the handler
+func odataPath(path string) string {
+	return strings.ReplaceAll(strings.TrimSpace(path), "/", ".")
+}
+
+// splitODataList splits a list on sep, ignoring separators inside parentheses and string literals
+func splitODataList(value string, sep rune) []string {
+	var parts []string
+	depth, inString, start := 0, false, 0
+	for idx, r := range value {
+		switch {
+		case r == '\'':
+			inString = !inString
+		case inString:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == sep && depth == 0:
+			parts = append(parts, strings.TrimSpace(value[start:idx]))
+			start = idx + 1
+		}
+	}
+	if rest := strings.TrimSpace(value[start:]); rest != "" || len(parts) > 0 {
+		parts = append(parts, rest)
+	}
+	return parts
+}
+
+// ParseODataFilter translates an OData $filter expression into a filter group.
+//
+// Supported: eq, ne, gt, ge, lt, le, in, and, or, not, parentheses, null, the
+// functions contains, startswith, endswith and substringof, and tolower/toupper
+// around a property for case-insensitive comparisons. Example:
+//
+//	status eq 'active' and (total gt 100 or contains(tolower(name),'acme'))
+func ParseODataFilter(expr string) (*common.FilterGroup, error) {
+	tokens, err := tokenizeOData(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return &common.FilterGroup{}, nil
+	}
+
+	p := &odataParser{tokens: tokens}
+	group, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.tokens[p.pos].text, p.tokens[p.pos].offset)
+	}
+	return group, nil
+}
+
+type odataTokenKind int
+
+const (
+	odataIdent odataTokenKind = iota
+	odataString
+	odataLiteral
+	odataOpen
+	odataClose
+	odataComma
+)
+
+type odataToken struct {
+	kind   odataTokenKind
+	text   string
+	offset int
+}
+
+func tokenizeOData(expr string) ([]odataToken, error) {
+	var tokens []odataToken
+	runes := []rune(expr)
+
+	for pos := 0; pos < len(runes); {
+		r := runes[pos]
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+		case r == '(':
+			tokens = append(tokens, odataToken{kind: odataOpen, text: "(", offset: pos})
+			pos++
+		case r == ')':
+			tokens = append(tokens, odataToken{kind: odataClose, text: ")", offset: pos})
+			pos++
+		case r == ',':
+			tokens = append(tokens, odataToken{kind: odataComma, text: ",", offset: pos})
+			pos++
+		case r == '\'':
+			text, next, err := readODataString(runes, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, odataToken{kind: odataString, text: text, offset: pos})
+			pos = next
+		case unicode.IsDigit(r) || r == '-':
+			// Numbers and unquoted date/time literals (2024-01-31T10:00:00Z)
+			start := pos
+			for pos < len(runes) && (unicode.IsLetter(runes[pos]) || unicode.IsDigit(runes[pos]) || strings.ContainsRune(".:+-", runes[pos])) {
+				pos++
+			}
+			tokens = append(tokens, odataToken{kind: odataLiteral, text: string(runes[start:pos]), offset: start})
+		case unicode.IsLetter(r) || r == '_':
+			start := pos
+			for pos < len(runes) && (unicode.IsLetter(runes[pos]) || unicode.IsDigit(runes[pos]) || strings.ContainsRune("_/.", runes[pos])) {
+				pos++
+			}
+			// Typed literals of OData v2/v3: datetime'2024-01-31T10:00:00', guid'...'
+			if pos < len(runes) && runes[pos] == '\'' {
+				text, next, err := readODataString(runes, pos)
+				if err != nil {
+					return nil, err
+				}
+				tokens = append(tokens, odataToken{kind: odataString, text: text, offset: start})
+				pos = next
+				continue
+			}
+			tokens = append(tokens, odataToken{kind: odataIdent, text: string(runes[start:pos]), offset: start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, pos)
+		}
+	}
+	return tokens, nil
+}
+
+// readODataString reads a quoted literal starting at pos; quotes are escaped by doubling them
+func readODataString(runes []rune, pos int) (string, int, error) {
+	var sb strings.Builder
+	for idx := pos + 1; idx < len(runes); idx++ {
+		if runes[idx] == '\'' {
+			if idx+1 < len(runes) && runes[idx+1] == '\'' {
+				sb.WriteRune('\'')
+				idx++
+				continue
+			}
+			return sb.String(), idx + 1, nil
+		}
+		sb.WriteRune(runes[idx])
+	}
+	return "", 0, fmt.Errorf("unterminated string starting at position %d", pos)
+}
+
+// odataComparisons maps OData comparison operators to filter operators
+var odataComparisons = map[string]string{
+	"eq": "eq",
+	"ne": "neq",
+	"gt": "gt",
+	"ge": "gte",
+	"lt": "lt",
+	"le": "lte",
+}
+
+// odataNegations maps filter operators to their negation, used to push "not" down to the filters
+var odataNegations = map[string]string{
+	"eq":          "neq",
+	"neq":         "eq",
+	"gt":          "lte",
+	"gte":         "lt",
+	"lt":          "gte",
+	"lte":         "gt",
+	"is_null":     "is_not_null",
+	"is_not_null": "is_null",
+}
+
+type odataParser struct {
+	tokens []odataToken
+	pos    int
+}
+
+func (p *odataParser) peek() *odataToken {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+func (p *odataParser) next() (odataToken, error) {
+	if p.pos >= len(p.tokens) {
+		return odataToken{}, fmt.Errorf("unexpected end of expression")
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *odataParser) expect(kind odataTokenKind, text string) error {
+	tok, err := p.next()
+	if err != nil {
+		return err
+	}
+	if tok.kind != kind {
+		return fmt.Errorf("expected %q at position %d, got %q", text, tok.offset, tok.text)
+	}
+	return nil
+}
+
+// keyword reports whether the next token is the given keyword and consumes it
+func (p *odataParser) keyword(word string) bool {
+	if tok := p.peek(); tok != nil && tok.kind == odataIdent && strings.EqualFold(tok.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *odataParser) parseOr() (*common.FilterGroup, error) {
+	return p.parseLogic("or", p.parseAnd)
+}
+
+func (p *odataParser) parseAnd() (*common.FilterGroup, error) {
+	return p.parseLogic("and", p.parseUnary)
+}
+
+// parseLogic combines operands separated by the logic keyword into one flat group
+func (p *odataParser) parseLogic(logic string, operand func() (*common.FilterGroup, error)) (*common.FilterGroup, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() == nil || !strings.EqualFold(p.peek().text, logic) || p.peek().kind != odataIdent {
+		return first, nil
+	}
+
+	group := &common.FilterGroup{Logic: strings.ToUpper(logic)}
+	appendOperand(group, first)
+	for p.keyword(logic) {
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		appendOperand(group, next)
+	}
+	return group, nil
+}
+
+// appendOperand adds an operand to a group, inlining single filters and groups with the same logic
+func appendOperand(group, operand *common.FilterGroup) {
+	if len(operand.Groups) == 0 && len(operand.Filters) == 1 || operand.LogicOperator() == group.LogicOperator() {
+		group.Filters = append(group.Filters, operand.Filters...)
+		group.Groups = append(group.Groups, operand.Groups...)
+		return
+	}
+	group.Groups = append(group.Groups, *operand)
+}
+
+func (p *odataParser) parseUnary() (*common.FilterGroup, error) {
+	if p.keyword("not") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negateODataGroup(operand)
+	}
+	return p.parsePrimary()
+}
+
+func (p *odataParser) parsePrimary() (*common.FilterGroup, error) {
+	tok := p.peek()
+	if tok == nil {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	if tok.kind == odataOpen {
+		p.pos++
+		group, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(odataClose, ")"); err != nil {
+			return nil, err
+		}
+		return group, nil
+	}
+
+	if tok.kind == odataIdent {
+		switch strings.ToLower(tok.text) {
+		case "contains", "startswith", "endswith", "substringof":
+			return p.parseStringFunction()
+		}
+	}
+
+	return p.parseComparison()
+}
+
+// parseStringFunction parses contains/startswith/endswith/substringof, optionally
+// followed by "eq true" or "eq false" as generated by some OData v2/v3 clients
+func (p *odataParser) parseStringFunction() (*common.FilterGroup, error) {
+	nameTok, _ := p.next()
+	name := strings.ToLower(nameTok.text)
+	if err := p.expect(odataOpen, "("); err != nil {
+		return nil, err
+	}
+
+	var column, value string
+	var caseInsensitive bool
+	var err error
+	if name == "substringof" {
+		// substringof('value', Property) has the arguments the other way around
+		if value, err = p.parseValueString(); err != nil {
+			return nil, err
+		}
+		if err = p.expect(odataComma, ","); err != nil {
+			return nil, err
+		}
+		if column, caseInsensitive, err = p.parseProperty(); err != nil {
+			return nil, err
+		}
+	} else {
+		if column, caseInsensitive, err = p.parseProperty(); err != nil {
+			return nil, err
+		}
+		if err = p.expect(odataComma, ","); err != nil {
+			return nil, err
+		}
+		if value, err = p.parseValueString(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect(odataClose, ")"); err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "startswith":
+		value += "%"
+	case "endswith":
+		value = "%" + value
+	default:
+		value = "%" + value + "%"
+	}
+	operator := "like"
+	if caseInsensitive {
+		operator = "ilike"
+	}
+	group := &common.FilterGroup{Filters: []common.FilterOption{{Column: column, Operator: operator, Value: value}}}
+
+	if p.keyword("eq") {
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToLower(tok.text) {
+		case "true":
+		case "false":
+			return negateODataGroup(group)
+		default:
+			return nil, fmt.Errorf("%s() can only be compared with true or false", name)
+		}
+	}
+	return group, nil
+}
+
+// parseComparison parses "Property op value" and "Property in (values)"
+func (p *odataParser) parseComparison() (*common.FilterGroup, error) {
+	column, caseInsensitive, err := p.parseProperty()
+	if err != nil {
+		return nil, err
+	}
+
+	opTok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	opName := strings.ToLower(opTok.text)
+
+	if opName == "in" {
+		if err := p.expect(odataOpen, "("); err != nil {
+			return nil, err
+		}
+		values := make([]string, 0)
+		for {
+			value, err := p.parseValueString()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			if p.peek() != nil && p.peek().kind == odataComma {
+				p.pos++
+				continue
+			}
+			break
+		}
+		if err := p.expect(odataClose, ")"); err != nil {
+			return nil, err
+		}
+		return &common.FilterGroup{Filters: []common.FilterOption{{Column: column, Operator: "in", Value: values}}}, nil
+	}
+
+	operator, ok := odataComparisons[opName]
+	if opTok.kind != odataIdent || !ok {
+		return nil, fmt.Errorf("unsupported operator %q at position %d", opTok.text, opTok.offset)
+	}
+
+	value, isNull, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := common.FilterOption{Column: column, Operator: operator, Value: value}
+	switch {
+	case isNull && operator == "eq":
+		filter = common.FilterOption{Column: column, Operator: "is_null"}
+	case isNull && operator == "neq":
+		filter = common.FilterOption{Column: column, Operator: "is_not_null"}
+	case isNull:
+		return nil, fmt.Errorf("null can only be compared with eq or ne")
+	case caseInsensitive && operator == "eq":
+		// tolower(Property) eq 'value' is a case-insensitive equality
+		filter.Operator = "ilike"
+	}
+	return &common.FilterGroup{Filters: []common.FilterOption{filter}}, nil
+}
+
+// parseProperty parses a property path, optionally wrapped in tolower() or toupper()
+func (p *odataParser) parseProperty() (column string, caseInsensitive bool, err error) {
+	tok, err := p.next()
+	if err != nil {
+		return "", false, err
+	}
+	if tok.kind != odataIdent {
+		return "", false, fmt.Errorf("expected a property at position %d, got %q", tok.offset, tok.text)
+	}
+
+	name := strings.ToLower(tok.text)
+	if (name == "tolower" || name == "toupper") && p.peek() != nil && p.peek().kind == odataOpen {
+		p.pos++
+		column, _, err = p.parseProperty()
+		if err != nil {
+			return "", false, err
+		}
+		if err := p.expect(odataClose, ")"); err != nil {
+			return "", false, err
+		}
+		return column, true, nil
+	}
+	return odataPath(tok.text), false, nil
+}
+
+// parseValue parses a literal into the string form used by the other filter headers
+func (p *odataParser) parseValue() (value string, isNull bool, err error) {
+	tok, err := p.next()
+	if err != nil {
+		return "", false, err
+	}
+	switch tok.kind {
+	case odataString, odataLiteral:
+		return tok.text, false, nil
+	case odataIdent:
+		switch strings.ToLower(tok.text) {
+		case "null":
+			return "", true, nil
+		case "true", "false":
+			return strings.ToLower(tok.text), false, nil
+		}
+	}
+	return "", false, fmt.Errorf("expected a value at position %d, got %q", tok.offset, tok.text)
+}
+
+func (p *odataParser) parseValueString() (string, error) {
+	value, isNull, err := p.parseValue()
+	if err != nil {
+		return "", err
+	}
+	if isNull {
+		return "", fmt.Errorf("null is not allowed here")
+	}
+	return value, nil
+}
+
+// negateODataGroup applies "not" to a group using De Morgan's laws
+func negateODataGroup(group *common.FilterGroup) (*common.FilterGroup, error) {
+	negated := &common.FilterGroup{Logic: "OR"}
+	if group.LogicOperator() == "OR" {
+		negated.Logic = "AND"
+	}
+
+	for _, filter := range group.Filters {
+		operator, ok := odataNegations[filter.Operator]
+		if !ok {
+			return nil, fmt.Errorf("not is not supported for %s filters", filter.Operator)
+		}
+		filter.Operator = operator
+		negated.Filters = append(negated.Filters, filter)
+	}
+	for idx := range group.Groups {
+		sub, err := negateODataGroup(&group.Groups[idx])
+		if err != nil {
+			return nil, err
+		}
+		negated.Groups = append(negated.Groups, *sub)
+	}
+	return negated, nil
+}
@@ -0,0 +1,178 @@
+package restheadspec
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+func TestParseODataFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    *common.FilterGroup
+		wantErr bool
+	}{
+		{
+			name: "comparison",
+			expr: "status eq 'active'",
+			want: &common.FilterGroup{Filters: []common.FilterOption{{Column: "status", Operator: "eq", Value: "active"}}},
+		},
+		{
+			name: "and chain is flattened",
+			expr: "age ge 18 and age lt 65 and name ne 'O''Brien'",
+			want: &common.FilterGroup{Logic: "AND", Filters: []common.FilterOption{
+				{Column: "age", Operator: "gte", Value: "18"},
+				{Column: "age", Operator: "lt", Value: "65"},
+				{Column: "name", Operator: "neq", Value: "O'Brien"},
+			}},
+		},
+		{
+			name: "precedence and parentheses",
+			expr: "a eq 1 or b eq 2 and (c eq 3 or d eq null)",
+			want: &common.FilterGroup{Logic: "OR",
+				Filters: []common.FilterOption{{Column: "a", Operator: "eq", Value: "1"}},
+				Groups: []common.FilterGroup{{Logic: "AND",
+					Filters: []common.FilterOption{{Column: "b", Operator: "eq", Value: "2"}},
+					Groups: []common.FilterGroup{{Logic: "OR", Filters: []common.FilterOption{
+						{Column: "c", Operator: "eq", Value: "3"},
+						{Column: "d", Operator: "is_null"},
+					}}},
+				}},
+			},
+		},
+		{
+			name: "string functions",
+			expr: "contains(name,'ab') and startswith(tolower(code),'x') and substringof('z',city) eq true",
+			want: &common.FilterGroup{Logic: "AND", Filters: []common.FilterOption{
+				{Column: "name", Operator: "like", Value: "%ab%"},
+				{Column: "code", Operator: "ilike", Value: "x%"},
+				{Column: "city", Operator: "like", Value: "%z%"},
+			}},
+		},
+		{name: "negated string function", expr: "not contains(name,'ab')", wantErr: true},
+		{
+			name: "case insensitive functions",
+			expr: "contains(tolower(name),'ab') and endswith(city,'burg') and tolower(code) eq 'x1'",
+			want: &common.FilterGroup{Logic: "AND", Filters: []common.FilterOption{
+				{Column: "name", Operator: "ilike", Value: "%ab%"},
+				{Column: "city", Operator: "like", Value: "%burg"},
+				{Column: "code", Operator: "ilike", Value: "x1"},
+			}},
+		},
+		{
+			name: "in and navigation path",
+			expr: "Department/Code in ('A', 'B') and created_at gt 2024-01-31T10:00:00Z",
+			want: &common.FilterGroup{Logic: "AND", Filters: []common.FilterOption{
+				{Column: "Department.Code", Operator: "in", Value: []string{"A", "B"}},
+				{Column: "created_at", Operator: "gt", Value: "2024-01-31T10:00:00Z"},
+			}},
+		},
+		{
+			name: "not uses De Morgan",
+			expr: "not (a eq 1 or b gt 2) and not c eq null",
+			want: &common.FilterGroup{Logic: "AND", Filters: []common.FilterOption{
+				{Column: "a", Operator: "neq", Value: "1"},
+				{Column: "b", Operator: "lte", Value: "2"},
+				{Column: "c", Operator: "is_not_null"},
+			}},
+		},
+		{
+			name: "typed literal and boolean",
+			expr: "modified lt datetime'2024-01-31T10:00:00' and active eq true",
+			want: &common.FilterGroup{Logic: "AND", Filters: []common.FilterOption{
+				{Column: "modified", Operator: "lt", Value: "2024-01-31T10:00:00"},
+				{Column: "active", Operator: "eq", Value: "true"},
+			}},
+		},
+		{name: "unknown operator", expr: "a has 1", wantErr: true},
+		{name: "unterminated string", expr: "a eq 'x", wantErr: true},
+		{name: "missing parenthesis", expr: "(a eq 1", wantErr: true},
+		{name: "trailing tokens", expr: "a eq 1 b", wantErr: true},
+		{name: "null comparison", expr: "a gt null", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseODataFilter(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseODataFilter(%q)\n got  %+v\n want %+v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseODataQueryOptions(t *testing.T) {
+	handler := NewHandler(nil, nil)
+	req := &MockRequest{
+		headers: map[string]string{},
+		queryParams: map[string]string{
+			"$filter":  "status eq 'active'",
+			"$orderby": "last_name desc, first_name",
+			"$top":     "20",
+			"$skip":    "40",
+			"$select":  "id,first_name,last_name",
+			"$expand":  "department($select=id,name),projects($filter=status eq 'open';$orderby=name;$top=3;$expand=tasks)",
+			"$count":   "true",
+		},
+	}
+
+	options := handler.parseOptionsFromHeaders(req, nil)
+
+	if options.ResponseFormat != ODataResponseFormat {
+		t.Errorf("ResponseFormat = %q, want %q", options.ResponseFormat, ODataResponseFormat)
+	}
+	if options.FilterGroup == nil || len(options.FilterGroup.Filters) != 1 || options.FilterGroup.Filters[0].Column != "status" {
+		t.Errorf("unexpected filter group: %+v", options.FilterGroup)
+	}
+	wantSort := []common.SortOption{{Column: "last_name", Direction: "DESC"}, {Column: "first_name", Direction: "ASC"}}
+	if !reflect.DeepEqual(options.Sort, wantSort) {
+		t.Errorf("Sort = %+v, want %+v", options.Sort, wantSort)
+	}
+	if options.Limit == nil || *options.Limit != 20 || options.Offset == nil || *options.Offset != 40 {
+		t.Errorf("unexpected limit/offset: %v/%v", options.Limit, options.Offset)
+	}
+	if !reflect.DeepEqual(options.Columns, []string{"id", "first_name", "last_name"}) {
+		t.Errorf("Columns = %v", options.Columns)
+	}
+	if options.SkipCount {
+		t.Error("$count=true must not skip the count")
+	}
+
+	if len(options.Preload) != 3 {
+		t.Fatalf("expected 3 preloads, got %+v", options.Preload)
+	}
+	if options.Preload[0].Relation != "department" || !reflect.DeepEqual(options.Preload[0].Columns, []string{"id", "name"}) {
+		t.Errorf("unexpected department preload: %+v", options.Preload[0])
+	}
+	projects := options.Preload[1]
+	if projects.Relation != "projects" || len(projects.Filters) != 1 || projects.Limit == nil || *projects.Limit != 3 || len(projects.Sort) != 1 {
+		t.Errorf("unexpected projects preload: %+v", projects)
+	}
+	if options.Preload[2].Relation != "projects.tasks" {
+		t.Errorf("nested expand relation = %q, want projects.tasks", options.Preload[2].Relation)
+	}
+}
+
+func TestODataExplicitResponseFormat(t *testing.T) {
+	handler := NewHandler(nil, nil)
+	req := &MockRequest{
+		headers:     map[string]string{"X-DetailApi": "true"},
+		queryParams: map[string]string{"$top": "5"},
+	}
+
+	options := handler.parseOptionsFromHeaders(req, nil)
+	if options.ResponseFormat != "detail" {
+		t.Errorf("explicit response format header should win, got %q", options.ResponseFormat)
+	}
+}
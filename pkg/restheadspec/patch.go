@@ -0,0 +1,98 @@
+package restheadspec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// applyPatch turns a JSON Merge Patch or JSON Patch body of a PATCH into the columns it changes,
+// applying it to the current record. Other bodies are returned unchanged. It returns false when
+// the request was answered with an error.
+func (h *Handler) applyPatch(ctx context.Context, w common.ResponseWriter, r common.Request, method, id string, data interface{}, model interface{}) (interface{}, bool) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header("Content-Type"))
+	if mediaType != common.MergePatchContentType && mediaType != common.JSONPatchContentType {
+		return data, true
+	}
+	if method != "PATCH" {
+		h.sendError(w, http.StatusUnsupportedMediaType, "unsupported_media_type", fmt.Sprintf("%s is only accepted by PATCH", mediaType), nil)
+		return nil, false
+	}
+	if id == "" {
+		h.sendError(w, http.StatusBadRequest, "missing_id", "A patch needs the id of the record", nil)
+		return nil, false
+	}
+
+	var operations []common.PatchOperation
+	if mediaType == common.JSONPatchContentType {
+		var err error
+		if operations, err = common.ParsePatchOperations(data); err != nil {
+			h.sendError(w, http.StatusBadRequest, "invalid_patch", "Invalid JSON Patch", err)
+			return nil, false
+		}
+	}
+
+	record, found, err := common.LoadRecord(ctx, h.db, model, id)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error loading record to patch: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "query_error", "Error loading record", err)
+		return nil, false
+	}
+	if !found {
+		h.sendError(w, http.StatusNotFound, "not_found", "Record not found", nil)
+		return nil, false
+	}
+
+	// Patches apply to the JSON representation of the record
+	var before map[string]interface{}
+	encoded, err := json.Marshal(record)
+	if err == nil {
+		err = json.Unmarshal(encoded, &before)
+	}
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, "query_error", "Error encoding record", err)
+		return nil, false
+	}
+
+	var patched interface{}
+	if mediaType == common.MergePatchContentType {
+		patched = common.ApplyMergePatch(before, data)
+	} else if patched, err = common.ApplyPatchOperations(before, operations); err != nil {
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, common.ErrPatchTestFailed) {
+			status = http.StatusConflict
+		}
+		h.sendError(w, status, "patch_failed", "Patch can't be applied to the record", err)
+		return nil, false
+	}
+
+	after, ok := patched.(map[string]interface{})
+	if !ok {
+		h.sendError(w, http.StatusUnprocessableEntity, "patch_failed", "A patch must leave the record an object", nil)
+		return nil, false
+	}
+	changes := common.PatchChanges(before, after)
+	if pkNames := reflection.GetPrimaryKeyNames(model); changesAny(changes, pkNames) {
+		h.sendError(w, http.StatusUnprocessableEntity, "patch_failed", "A patch can't change the primary key", nil)
+		return nil, false
+	}
+	logger.DebugContext(ctx, "%s changes %d columns of record %s", mediaType, len(changes), id)
+	return changes, true
+}
+
+// changesAny reports whether changes sets any of the columns
+func changesAny(changes map[string]interface{}, columns []string) bool {
+	for _, column := range columns {
+		if _, ok := changes[column]; ok {
+			return true
+		}
+	}
+	return false
+}
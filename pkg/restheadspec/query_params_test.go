@@ -1,7 +1,10 @@
 package restheadspec
 
 import (
+	"context"
 	"testing"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
 )
 
 // MockRequest implements common.Request interface for testing
@@ -14,6 +17,10 @@ func (m *MockRequest) Method() string {
 	return "GET"
 }
 
+func (m *MockRequest) Context() context.Context {
+	return context.Background()
+}
+
 func (m *MockRequest) URL() string {
 	return "http://example.com/test"
 }
@@ -84,6 +91,20 @@ func TestParseOptionsFromQueryParams(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "Parse clean JSON classes from query params",
+			queryParams: map[string]string{
+				"x-clean-json": "null,array",
+			},
+			validate: func(t *testing.T, options ExtendedRequestOptions) {
+				if !options.CleanJSON {
+					t.Error("Expected CleanJSON to be set")
+				}
+				if options.CleanJSONPrune != common.PruneNull|common.PruneEmptyArray {
+					t.Errorf("Expected null and array pruning, got %b", options.CleanJSONPrune)
+				}
+			},
+		},
 		{
 			name: "Parse limit and offset from query params",
 			queryParams: map[string]string{
@@ -99,6 +120,26 @@ func TestParseOptionsFromQueryParams(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "Parse geo search operators from query params",
+			queryParams: map[string]string{
+				"x-searchop-st_dwithin-location": "13.4,52.5,1000",
+			},
+			validate: func(t *testing.T, options ExtendedRequestOptions) {
+				if len(options.Filters) != 1 {
+					t.Fatalf("Expected 1 filter, got %d", len(options.Filters))
+				}
+				filter := options.Filters[0]
+				if filter.Column != "location" || filter.Operator != "st_dwithin" || filter.Value != "13.4,52.5,1000" {
+					t.Errorf("Unexpected filter: %+v", filter)
+				}
+				condition, args := handler.buildFilterCondition(filter, "places", true)
+				expected := "ST_DWithin(CAST(places.location AS geography), CAST(ST_SetSRID(ST_MakePoint(?, ?), 4326) AS geography), ?)"
+				if condition != expected || len(args) != 3 {
+					t.Errorf("Expected %s with 3 args, got %s %v", expected, condition, args)
+				}
+			},
+		},
 		{
 			name: "Parse field filters from query params",
 			queryParams: map[string]string{
@@ -401,3 +442,65 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestArraySearchOperators(t *testing.T) {
+	handler := NewHandler(nil, nil)
+	type taggedItem struct {
+		ID    int64    `json:"id"`
+		Name  string   `json:"name"`
+		Tags  []string `json:"tags"`
+		Sizes []int64  `json:"sizes"`
+	}
+	model := taggedItem{}
+
+	req := &MockRequest{
+		headers: make(map[string]string),
+		queryParams: map[string]string{
+			"x-searchop-contains-tags":   "red,blue",
+			"x-searchop-contains-name":   "wid",
+			"x-searchop-overlaps-sizes":  "1,2",
+			"x-searchop-anyeq-sizes":     "3",
+			"x-searchor-containedby-tag": "a",
+		},
+	}
+	options := handler.parseOptionsFromHeaders(req, model)
+
+	conditions := make(map[string]string)
+	arguments := make(map[string][]interface{})
+	for i := range options.Filters {
+		filter := options.Filters[i]
+		castInfo := handler.ValidateAndAdjustFilterForColumnType(&filter, model)
+		condition, args := handler.buildFilterCondition(filter, "items", castInfo.NeedsCast)
+		conditions[filter.Column+" "+filter.Operator] = condition
+		arguments[filter.Column+" "+filter.Operator] = args
+	}
+
+	expected := map[string]string{
+		"tags contains":    "items.tags @> ?",
+		"name ilike":       "items.name ILIKE ?",
+		"sizes overlaps":   "items.sizes && ?",
+		"sizes any_eq":     "? = ANY(items.sizes)",
+		"tag contained_by": "items.tag <@ ?",
+	}
+	for key, want := range expected {
+		if conditions[key] != want {
+			t.Errorf("%s: expected %q, got %q (all: %v)", key, want, conditions[key], conditions)
+		}
+	}
+	if got := arguments["tags contains"]; len(got) != 1 || got[0] != `{"red","blue"}` {
+		t.Errorf("tags contains: unexpected args %v", got)
+	}
+	if got := arguments["sizes overlaps"]; len(got) != 1 || got[0] != "{1,2}" {
+		t.Errorf("sizes overlaps: unexpected args %v", got)
+	}
+	if got := arguments["sizes any_eq"]; len(got) != 1 || got[0] != int64(3) {
+		t.Errorf("sizes any_eq: unexpected args %v", got)
+	}
+
+	// Values that don't match the element type drop the filter
+	filter := common.FilterOption{Column: "sizes", Operator: "contains", Value: "1,x"}
+	handler.ValidateAndAdjustFilterForColumnType(&filter, model)
+	if condition, _ := handler.buildFilterCondition(filter, "items", false); condition != "" {
+		t.Errorf("expected invalid array filter to be skipped, got %s", condition)
+	}
+}
@@ -0,0 +1,158 @@
+package restheadspec
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/ratelimit"
+)
+
+// ReadRecord implements common.RecordHost. The record is read like a read of its id, through the
+// BeforeRead, BeforeScan and AfterRead hooks.
+func (h *Handler) ReadRecord(r common.Request, schema, entity, id string) (context.Context, interface{}, error) {
+	scoped, hookCtx, err := h.recordScope(r, schema, entity, id, ratelimit.OperationRead)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx := hookCtx.Context
+	where, args, err := common.KeyCondition(hookCtx.Model, id)
+	if err != nil {
+		return nil, nil, &common.RecordError{Status: http.StatusBadRequest, Code: "invalid_id", Message: "Invalid id", Err: err}
+	}
+	if err := scoped.hooks.Execute(BeforeRead, hookCtx); err != nil {
+		return nil, nil, recordHookError(err)
+	}
+
+	records := reflect.New(reflect.SliceOf(reflect.TypeOf(hookCtx.Model))).Interface()
+	hookCtx.Query = scoped.db.NewSelect().Model(records).Table(hookCtx.TableName).Where(where, args...)
+	if err := scoped.hooks.Execute(BeforeScan, hookCtx); err != nil {
+		return nil, nil, recordHookError(err)
+	}
+	query, ok := hookCtx.Query.(common.SelectQuery)
+	if !ok {
+		return nil, nil, &common.RecordError{Status: http.StatusInternalServerError, Code: "hook_error", Message: "Hook replaced the read query"}
+	}
+	started := time.Now()
+	if err := query.Scan(ctx, records); err != nil {
+		return nil, nil, &common.RecordError{Status: http.StatusInternalServerError, Code: "query_error", Message: "Error loading the record", Err: err}
+	}
+	hookCtx.Duration = time.Since(started)
+	if err := scoped.hooks.Execute(AfterScan, hookCtx); err != nil {
+		return nil, nil, recordHookError(err)
+	}
+
+	hookCtx.Result = records
+	if err := scoped.hooks.Execute(AfterRead, hookCtx); err != nil {
+		return nil, nil, recordHookError(err)
+	}
+	record := common.FirstRecord(hookCtx.Result)
+	if record == nil {
+		return nil, nil, &common.RecordError{Status: http.StatusNotFound, Code: "not_found", Message: "Record not found"}
+	}
+	return ctx, record, nil
+}
+
+// UpdateRecord implements common.RecordHost. The columns are set like an update of the record,
+// through the BeforeUpdate, BeforeScan, AfterScan and AfterUpdate hooks.
+func (h *Handler) UpdateRecord(r common.Request, schema, entity, id string, values map[string]interface{}) error {
+	scoped, hookCtx, err := h.recordScope(r, schema, entity, id, ratelimit.OperationUpdate)
+	if err != nil {
+		return err
+	}
+	ctx := hookCtx.Context
+	where, args, err := common.KeyCondition(hookCtx.Model, id)
+	if err != nil {
+		return &common.RecordError{Status: http.StatusBadRequest, Code: "invalid_id", Message: "Invalid id", Err: err}
+	}
+	hookCtx.Data = values
+	if err := scoped.hooks.Execute(BeforeUpdate, hookCtx); err != nil {
+		return recordHookError(err)
+	}
+	if data, ok := hookCtx.Data.(map[string]interface{}); ok {
+		values = data
+	}
+
+	hookCtx.Query = scoped.db.NewUpdate().Table(hookCtx.TableName).SetMap(values).Where(where, args...)
+	if err := scoped.hooks.Execute(BeforeScan, hookCtx); err != nil {
+		return recordHookError(err)
+	}
+	query, ok := hookCtx.Query.(common.UpdateQuery)
+	if !ok {
+		return &common.RecordError{Status: http.StatusInternalServerError, Code: "hook_error", Message: "Hook replaced the update query"}
+	}
+	started := time.Now()
+	result, err := query.Exec(ctx)
+	if err != nil {
+		return &common.RecordError{Status: http.StatusInternalServerError, Code: "update_error", Message: "Error updating the record", Err: err}
+	}
+	if result.RowsAffected() == 0 {
+		return &common.RecordError{Status: http.StatusNotFound, Code: "not_found", Message: "Record not found"}
+	}
+	hookCtx.Duration = time.Since(started)
+	if err := scoped.hooks.Execute(AfterScan, hookCtx); err != nil {
+		return recordHookError(err)
+	}
+
+	hookCtx.Result = values
+	if err := scoped.hooks.Execute(AfterUpdate, hookCtx); err != nil {
+		return recordHookError(err)
+	}
+	scoped.queueAfterCommit(hookCtx, common.BatchActionUpdate)
+	return nil
+}
+
+// recordScope authenticates a request of a plugin route on a record and checks the operation
+// against the entity access. It returns the handler of the request, scoped to its tenant, and the
+// hook context of the record.
+func (h *Handler) recordScope(r common.Request, schema, entity, id, operation string) (*Handler, *HookContext, error) {
+	ctx := r.Context()
+	if h.authenticator != nil {
+		authCtx, err := h.authenticator(ctx, r)
+		if err != nil {
+			return nil, nil, &common.RecordError{Status: http.StatusUnauthorized, Code: "unauthorized", Message: "Authentication failed", Err: err}
+		}
+		if authCtx != nil {
+			ctx = authCtx
+		}
+	}
+
+	scoped := h
+	if h.tenant == nil && h.config.TenantColumn != "" {
+		scope, err := h.config.ResolveTenant(common.WithRequestContext(r, ctx), h.scopedTables())
+		if err != nil {
+			return nil, nil, &common.RecordError{Status: http.StatusForbidden, Code: "tenant_required", Message: "Request has no tenant", Err: err}
+		}
+		scoped = h.withTenant(scope)
+	}
+
+	if accessErr := h.config.CheckEntityAccess(ctx, schema, entity, operation); accessErr != nil {
+		return nil, nil, &common.RecordError{Status: accessErr.Status, Code: accessErr.Code, Message: accessErr.Message}
+	}
+	model, err := h.registry.GetModelByEntity(schema, entity)
+	if err != nil {
+		return nil, nil, &common.RecordError{Status: http.StatusNotFound, Code: "not_found", Message: "Unknown entity", Err: err}
+	}
+	modelType := reflect.TypeOf(model)
+	for modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	model = reflect.New(modelType).Elem().Interface()
+
+	return scoped, &HookContext{
+		Context:   ctx,
+		Handler:   scoped,
+		Schema:    schema,
+		Entity:    entity,
+		TableName: h.getTableName(schema, entity, model),
+		Model:     model,
+		ID:        id,
+	}, nil
+}
+
+// recordHookError is the error of a failed hook of a record of a plugin route
+func recordHookError(err error) error {
+	return &common.RecordError{Status: http.StatusBadRequest, Code: "hook_error", Message: "Hook execution failed", Err: err}
+}
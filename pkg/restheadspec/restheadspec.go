@@ -67,17 +67,17 @@ import (
 )
 
 // NewHandlerWithGORM creates a new Handler with GORM adapter
-func NewHandlerWithGORM(db *gorm.DB) *Handler {
+func NewHandlerWithGORM(db *gorm.DB, options ...HandlerOption) *Handler {
 	gormAdapter := database.NewGormAdapter(db)
 	registry := modelregistry.NewModelRegistry()
-	return NewHandler(gormAdapter, registry)
+	return NewHandler(gormAdapter, registry, options...)
 }
 
 // NewHandlerWithBun creates a new Handler with Bun adapter
-func NewHandlerWithBun(db *bun.DB) *Handler {
+func NewHandlerWithBun(db *bun.DB, options ...HandlerOption) *Handler {
 	bunAdapter := database.NewBunAdapter(db)
 	registry := modelregistry.NewModelRegistry()
-	return NewHandler(bunAdapter, registry)
+	return NewHandler(bunAdapter, registry, options...)
 }
 
 // NewStandardMuxRouter creates a router with standard Mux HTTP handlers
@@ -92,13 +92,15 @@ func NewStandardBunRouter() *router.StandardBunRouterAdapter {
 
 // SetupMuxRoutes sets up routes for the RestHeadSpec API with Mux
 func SetupMuxRoutes(muxRouter *mux.Router, handler *Handler) {
+	router.RegisterPluginRoutes(router.NewMuxAdapter(muxRouter), handler.Plugins())
+
 	// GET, POST, PUT, PATCH, DELETE for /{schema}/{entity}
 	muxRouter.HandleFunc("/{schema}/{entity}", func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		reqAdapter := router.NewHTTPRequest(r)
 		respAdapter := router.NewHTTPResponseWriter(w)
 		handler.Handle(respAdapter, reqAdapter, vars)
-	}).Methods("GET", "POST")
+	}).Methods("GET", "POST", "PUT", "PATCH", "DELETE")
 
 	// GET, PUT, PATCH, DELETE for /{schema}/{entity}/{id}
 	muxRouter.HandleFunc("/{schema}/{entity}/{id}", func(w http.ResponseWriter, r *http.Request) {
@@ -153,6 +155,8 @@ func ExampleWithBun(bunDB *bun.DB) {
 func SetupBunRouterRoutes(bunRouter *router.StandardBunRouterAdapter, handler *Handler) {
 	r := bunRouter.GetBunRouter()
 
+	router.RegisterPluginRoutes(bunRouter, handler.Plugins())
+
 	// GET and POST for /:schema/:entity
 	r.Handle("GET", "/:schema/:entity", func(w http.ResponseWriter, req bunrouter.Request) error {
 		params := map[string]string{
@@ -176,6 +180,20 @@ func SetupBunRouterRoutes(bunRouter *router.StandardBunRouterAdapter, handler *H
 		return nil
 	})
 
+	// PUT, PATCH and DELETE for /:schema/:entity update or delete the rows matching their filters
+	for _, method := range []string{"PUT", "PATCH", "DELETE"} {
+		r.Handle(method, "/:schema/:entity", func(w http.ResponseWriter, req bunrouter.Request) error {
+			params := map[string]string{
+				"schema": req.Param("schema"),
+				"entity": req.Param("entity"),
+			}
+			reqAdapter := router.NewBunRouterRequest(req)
+			respAdapter := router.NewHTTPResponseWriter(w)
+			handler.Handle(respAdapter, reqAdapter, params)
+			return nil
+		})
+	}
+
 	// GET, PUT, PATCH, DELETE for /:schema/:entity/:id
 	r.Handle("GET", "/:schema/:entity/:id", func(w http.ResponseWriter, req bunrouter.Request) error {
 		params := map[string]string{
@@ -269,3 +287,30 @@ func ExampleBunRouterWithBunDB(bunDB *bun.DB) {
 		logger.Error("Server failed to start: %v", err)
 	}
 }
+
+// SetupHTTPRoutes sets up routes for the RestHeadSpec API on chi, echo, gin and other routers
+// adapted by router.HTTPRouterAdapter
+func SetupHTTPRoutes(r *router.HTTPRouterAdapter, handler *Handler) {
+	router.RegisterPluginRoutes(r, handler.Plugins())
+
+	handle := func(w http.ResponseWriter, req *http.Request, params map[string]string) {
+		handler.Handle(router.NewHTTPResponseWriter(w), router.NewHTTPRequest(req), params)
+	}
+	for _, method := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+		r.Handle(method, "/{schema}/{entity}", handle)
+	}
+	for _, method := range []string{"GET", "PUT", "PATCH", "DELETE", "POST"} {
+		r.Handle(method, "/{schema}/{entity}/{id}", handle)
+	}
+
+	// Metadata endpoint
+	r.Handle("GET", "/{schema}/{entity}/metadata", func(w http.ResponseWriter, req *http.Request, params map[string]string) {
+		handler.HandleGet(router.NewHTTPResponseWriter(w), router.NewHTTPRequest(req), params)
+	})
+}
+
+// MountStdlib sets up routes for the RestHeadSpec API on a net/http ServeMux below prefix, using
+// Go 1.22 pattern routing instead of gorilla/mux
+func MountStdlib(mux *http.ServeMux, handler *Handler, prefix string) {
+	SetupHTTPRoutes(router.NewServeMuxAdapter(mux, prefix), handler)
+}
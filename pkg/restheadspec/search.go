@@ -0,0 +1,105 @@
+package restheadspec
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// buildFullTextSearch builds the full-text search of the request for the dialect of the database.
+// Without x-searchcols the string columns of the model are searched on PostgreSQL and MySQL,
+// and all columns of the FTS index on SQLite.
+func (h *Handler) buildFullTextSearch(model interface{}, tableName string, options ExtendedRequestOptions) (*common.FullTextSQL, error) {
+	dialect := common.DialectOf(h.db).Name
+	columns := options.SearchColumns
+	if len(columns) == 0 && dialect != "sqlite" {
+		for _, column := range reflection.GetSQLModelColumns(model) {
+			if reflection.IsStringType(reflection.GetColumnTypeFromModel(model, column)) {
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	return common.BuildFullTextSearch(dialect, reflection.ExtractTableNameOnly(tableName), common.FullTextSearch{
+		Query:   options.Search,
+		Columns: columns,
+		Config:  options.SearchConfig,
+	})
+}
+
+// searchHits loads the rank and snippet of the returned records, in the order of the records
+func (h *Handler) searchHits(ctx context.Context, tableName string, model interface{}, records interface{}, search *common.FullTextSQL) ([]common.SearchHit, error) {
+	pkName := reflection.GetPrimaryKeyName(model)
+	if pkName == "" {
+		return nil, fmt.Errorf("model has no primary key")
+	}
+
+	ids := make([]interface{}, 0)
+	value := reflect.ValueOf(records)
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	for i := 0; value.Kind() == reflect.Slice && i < value.Len(); i++ {
+		if id := reflection.GetPrimaryKeyValue(value.Index(i).Interface()); id != nil {
+			ids = append(ids, id)
+		}
+	}
+	hits := make([]common.SearchHit, 0, len(ids))
+	if len(ids) == 0 {
+		return hits, nil
+	}
+
+	pkColumn := common.QuoteDialectIdent(common.DialectOf(h.db).Name, pkName)
+	// One column expression holds all bound columns, since GORM replaces the selection of
+	// an expression with args
+	columns := fmt.Sprintf("%s AS search_id, (%s) AS search_rank", pkColumn, search.Rank)
+	args := append([]interface{}{}, search.RankArgs...)
+	if search.Snippet != "" {
+		columns += fmt.Sprintf(", (%s) AS search_snippet", search.Snippet)
+		args = append(args, search.SnippetArgs...)
+	}
+	query := h.db.NewSelect().Table(tableName).
+		ColumnExpr(columns, args...).
+		Where(fmt.Sprintf("%s IN (?)", pkColumn), ids)
+
+	var rows []map[string]interface{}
+	if err := query.Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+	byID := make(map[string]map[string]interface{}, len(rows))
+	for _, row := range rows {
+		byID[fmt.Sprint(searchValue(row["search_id"]))] = row
+	}
+
+	for _, id := range ids {
+		row, ok := byID[fmt.Sprint(id)]
+		if !ok {
+			continue
+		}
+		hit := common.SearchHit{ID: id}
+		switch rank := row["search_rank"].(type) {
+		case float64:
+			hit.Rank = rank
+		case float32:
+			hit.Rank = float64(rank)
+		case int64:
+			hit.Rank = float64(rank)
+		}
+		if snippet := searchValue(row["search_snippet"]); snippet != nil {
+			hit.Snippet = fmt.Sprint(snippet)
+		}
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
+
+// searchValue converts the byte slices some drivers scan text into to strings
+func searchValue(value interface{}) interface{} {
+	if b, ok := value.([]byte); ok {
+		return string(b)
+	}
+	return value
+}
@@ -0,0 +1,88 @@
+package restheadspec
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+)
+
+// SeriesPath is the id segment of the time series of an entity: GET /{schema}/{entity}/_series
+// with X-Bucket, e.g. "day(created_at)", and the filters and X-Aggregate of a list read
+const SeriesPath = "_series"
+
+// handleSeries counts the rows of a read per period of a timestamp column, for charts. Series
+// run the BeforeRead and BeforeScan hooks of reads, so hooks restricting reads restrict them
+// as well; they read the table without the joins of expands.
+func (h *Handler) handleSeries(ctx context.Context, w common.ResponseWriter, options ExtendedRequestOptions) {
+	defer func() {
+		if err := recover(); err != nil {
+			h.handlePanic(w, "handleSeries", err)
+		}
+	}()
+
+	schema := GetSchema(ctx)
+	entity := GetEntity(ctx)
+	tableName := GetTableName(ctx)
+	model := GetModel(ctx)
+
+	columns := reflection.GetSQLModelColumns(model)
+	period, column, err := common.ParseBucket(options.Bucket, columns)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid_bucket", err.Error(), err)
+		return
+	}
+	aggregates, err := common.ValidateAggregates(options.Aggregates, columns)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid_aggregate", err.Error(), err)
+		return
+	}
+
+	hookCtx := &HookContext{
+		Context:   ctx,
+		Handler:   h,
+		Schema:    schema,
+		Entity:    entity,
+		TableName: tableName,
+		Model:     model,
+		Options:   options,
+		Writer:    w,
+	}
+	if err := h.hooks.Execute(BeforeRead, hookCtx); err != nil {
+		logger.ErrorContext(ctx, "BeforeRead hook failed: %v", err)
+		h.sendError(w, http.StatusBadRequest, "hook_error", "Hook execution failed", err)
+		return
+	}
+
+	query, _, err := h.applyReadFilters(ctx, h.db.NewSelect().Table(tableName), model, tableName, options, "")
+	if err != nil {
+		logger.ErrorContext(ctx, "Invalid full-text search: %v", err)
+		h.sendError(w, http.StatusBadRequest, "invalid_search", "Invalid full-text search", err)
+		return
+	}
+	hookCtx.Query = query
+	if err := h.hooks.Execute(BeforeScan, hookCtx); err != nil {
+		logger.ErrorContext(ctx, "BeforeScan hook failed: %v", err)
+		h.sendError(w, http.StatusBadRequest, "hook_error", "Hook execution failed", err)
+		return
+	}
+	if modifiedQuery, ok := hookCtx.Query.(common.SelectQuery); ok {
+		query = modifiedQuery
+	}
+
+	series, err := common.ComputeSeries(ctx, h.db, query, period, column, aggregates)
+	if errors.Is(err, common.ErrTooManyBuckets) {
+		h.sendError(w, http.StatusBadRequest, "too_many_buckets", err.Error(), err)
+		return
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error computing series: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "query_error", "Error computing series", err)
+		return
+	}
+	logger.DebugContext(ctx, "Computed %d %s buckets of %s.%s", len(series.Points), period, schema, entity)
+	h.sendResponse(w, series, nil)
+}
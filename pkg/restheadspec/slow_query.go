@@ -0,0 +1,37 @@
+package restheadspec
+
+import (
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// SlowQueryHook returns the AfterScan hook reporting queries that ran at least the
+// SlowQueryThreshold of config. NewHandlerWithConfig registers it when a threshold is set.
+func SlowQueryHook(config common.HandlerConfig) HookFunc {
+	return func(ctx *HookContext) error {
+		config.ReportSlowQuery(ctx.Context, common.SlowQuery{
+			Schema:    ctx.Schema,
+			Entity:    ctx.Entity,
+			Operation: queryOperation(ctx.Query),
+			Options:   common.NormalizeOptions(ctx.Options.RequestOptions),
+			Duration:  ctx.Duration,
+			RequestID: ctx.RequestID,
+		})
+		return nil
+	}
+}
+
+// queryOperation returns the operation of a query chain passed to the scan hooks
+func queryOperation(query interface{}) string {
+	switch query.(type) {
+	case common.SelectQuery:
+		return "read"
+	case common.InsertQuery:
+		return "create"
+	case common.UpdateQuery:
+		return "update"
+	case common.DeleteQuery:
+		return "delete"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,165 @@
+package restheadspec
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/reflection"
+	"github.com/bitechdev/ResolveSpec/pkg/tracing"
+)
+
+// isWriteByFilter reports whether a write without an id targets the rows matching its filters:
+// it has filters or a write guard (X-Write-Limit, X-Write-All)
+func (o ExtendedRequestOptions) isWriteByFilter() bool {
+	return len(o.Filters) > 0 || !o.FilterGroup.IsEmpty() || o.WriteGuard != (common.WriteGuard{})
+}
+
+// filterWhere renders the filters of a write by filter as a single condition
+func (h *Handler) filterWhere(filters []common.FilterOption, group *common.FilterGroup, model interface{}, tableName string) (string, []interface{}, error) {
+	return common.FilterWhere(filters, group, func(filter common.FilterOption) (string, []interface{}) {
+		castInfo := h.ValidateAndAdjustFilterForColumnType(&filter, model)
+		return h.buildFilterCondition(filter, tableName, castInfo.NeedsCast)
+	})
+}
+
+// handleUpdateWhere sets the columns of data on all rows matching the filter headers with a
+// single UPDATE and answers with the number of updated rows. X-Write-Limit limits the rows it
+// may change, rolling back updates of more rows; X-Write-All confirms an update without a limit.
+func (h *Handler) handleUpdateWhere(ctx context.Context, w common.ResponseWriter, data interface{}, options ExtendedRequestOptions) {
+	// Capture panics and return error response
+	defer func() {
+		if err := recover(); err != nil {
+			h.handlePanic(w, "handleUpdateWhere", err)
+		}
+	}()
+
+	ctx, span := tracing.StartSpan(ctx, "restheadspec.update_where", tracing.AttrOperation.String("update"))
+	defer span.End()
+
+	schema := GetSchema(ctx)
+	entity := GetEntity(ctx)
+	tableName := GetTableName(ctx)
+	model := GetModel(ctx)
+
+	logger.InfoContext(ctx, "Updating records by filter in %s.%s", schema, entity)
+
+	if limitErr := options.WriteGuard.Check(); limitErr != nil {
+		h.rejectLimit(w, limitErr)
+		return
+	}
+
+	hookCtx := &HookContext{
+		Context:   ctx,
+		Handler:   h,
+		Schema:    schema,
+		Entity:    entity,
+		TableName: tableName,
+		Model:     model,
+		Options:   options,
+		Data:      data,
+		Writer:    w,
+	}
+	if err := h.hooks.Execute(BeforeUpdate, hookCtx); err != nil {
+		logger.ErrorContext(ctx, "BeforeUpdate hook failed: %v", err)
+		h.sendError(w, http.StatusBadRequest, "hook_error", "Hook execution failed", err)
+		return
+	}
+
+	updates, ok := hookCtx.Data.(map[string]interface{})
+	if !ok || len(updates) == 0 {
+		h.sendError(w, http.StatusBadRequest, "invalid_data", "An update by filter needs an object of columns to set", nil)
+		return
+	}
+	if _, ok := updates[reflection.GetPrimaryKeyName(model)]; ok {
+		h.sendError(w, http.StatusBadRequest, "invalid_data", "An update by filter can't set the primary key", nil)
+		return
+	}
+	if err := common.ValidateEnums(model, updates); err != nil {
+		logger.WarnContext(ctx, "Invalid data for %s.%s: %v", schema, entity, err)
+		h.sendError(w, http.StatusUnprocessableEntity, common.InvalidEnumValue, "Invalid value", err)
+		return
+	}
+
+	where, args, err := h.filterWhere(options.Filters, options.FilterGroup, model, tableName)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid_filter", "Invalid filter", err)
+		return
+	}
+
+	var updated int64
+	var records []interface{}
+	err = h.db.RunInTransaction(ctx, func(tx common.Database) error {
+		if options.ReturnRecords {
+			// Select the rows first, the update may change the columns they match by
+			loaded, err := common.LoadRecords(ctx, tx, model, tableName, where, args...)
+			if err != nil {
+				return err
+			}
+			records = loaded
+		}
+
+		query := tx.NewUpdate().Table(tableName).SetMap(updates)
+		if where != "" {
+			query = query.Where(where, args...)
+		} else {
+			// Confirmed updates of all rows still need a condition for the adapters
+			query = query.Where("1 = 1")
+		}
+
+		hookCtx.Query = query
+		if err := h.hooks.Execute(BeforeScan, hookCtx); err != nil {
+			return fmt.Errorf("BeforeScan hook failed: %w", err)
+		}
+		if modifiedQuery, ok := hookCtx.Query.(common.UpdateQuery); ok {
+			query = modifiedQuery
+		}
+
+		started := time.Now()
+		result, err := query.Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to update records: %w", err)
+		}
+		hookCtx.Duration = time.Since(started)
+		updated = result.RowsAffected()
+		if limitErr := options.WriteGuard.CheckRows(updated); limitErr != nil {
+			return limitErr
+		}
+		if options.ReturnRecords {
+			if records, err = common.ReloadRecords(ctx, tx, model, tableName, reflection.GetPrimaryKeyName(model), records); err != nil {
+				return err
+			}
+		}
+		if err := h.hooks.Execute(AfterScan, hookCtx); err != nil {
+			return fmt.Errorf("AfterScan hook failed: %w", err)
+		}
+		return nil
+	})
+	if h.rejectLimit(w, err) {
+		return
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "Error updating records by filter: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "update_error", "Error updating records", err)
+		return
+	}
+
+	result := map[string]interface{}{"updated": updated}
+	if options.ReturnRecords {
+		result["records"] = records
+	}
+	hookCtx.Result = result
+	hookCtx.Error = nil
+	if err := h.hooks.Execute(AfterUpdate, hookCtx); err != nil {
+		logger.ErrorContext(ctx, "AfterUpdate hook failed: %v", err)
+		h.sendError(w, http.StatusInternalServerError, "hook_error", "Hook execution failed", err)
+		return
+	}
+	h.queueAfterCommit(hookCtx, common.BatchActionUpdate)
+
+	logger.InfoContext(ctx, "Successfully updated %d records by filter", updated)
+	h.sendResponse(w, hookCtx.Result, nil)
+}
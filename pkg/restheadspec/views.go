@@ -0,0 +1,170 @@
+package restheadspec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/ratelimit"
+)
+
+// ViewsPath is the id segment of the saved views of an entity: GET, POST and DELETE
+// /{schema}/{entity}/_views
+const ViewsPath = "_views"
+
+// viewName returns the saved view a request names with X-View or the view query parameter
+func viewName(r common.Request) string {
+	if name := r.QueryParam("view"); name != "" {
+		return name
+	}
+	return r.Header(common.ViewHeader)
+}
+
+// resolveView returns the params of the saved view a read names, or nil without one. It
+// returns false when the request was answered with an error.
+func (h *Handler) resolveView(ctx context.Context, w common.ResponseWriter, r common.Request, schema, entity string) (map[string]string, bool) {
+	name := viewName(r)
+	if name == "" {
+		return nil, true
+	}
+	if h.config.Views == nil {
+		h.sendError(w, http.StatusBadRequest, "views_disabled", "Saved views are not enabled", nil)
+		return nil, false
+	}
+	view, err := h.config.Views.FindView(ctx, schema, entity, name, h.config.ViewOwner(ctx))
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, "view_error", "Error loading the view", err)
+		return nil, false
+	}
+	if view == nil {
+		h.sendError(w, http.StatusNotFound, "view_not_found", fmt.Sprintf("View %s not found", name), nil)
+		return nil, false
+	}
+	logger.DebugContext(ctx, "Running view %s of %s.%s", name, schema, entity)
+	return view.Params, true
+}
+
+// viewRequest adds the params of a saved view to the headers of a request, below the headers
+// and query parameters of the request itself
+type viewRequest struct {
+	common.Request
+	params map[string]string
+}
+
+// withView returns r with the params of a view, or r without them
+func withView(r common.Request, params map[string]string) common.Request {
+	if len(params) == 0 {
+		return r
+	}
+	return &viewRequest{Request: r, params: params}
+}
+
+func (v *viewRequest) Header(key string) string {
+	if value := v.Request.Header(key); value != "" {
+		return value
+	}
+	return v.params[strings.ToLower(key)]
+}
+
+func (v *viewRequest) AllHeaders() map[string]string {
+	headers := make(map[string]string, len(v.params))
+	for key, value := range v.params {
+		headers[key] = value
+	}
+	for key, value := range v.Request.AllHeaders() {
+		headers[strings.ToLower(key)] = value
+	}
+	return headers
+}
+
+// handleViews lists, saves and deletes the saved views of an entity. Views are owned by the
+// user of the request; shared views are listed and run for every user, but only changed by
+// their owner.
+func (h *Handler) handleViews(ctx context.Context, w common.ResponseWriter, r common.Request, schema, entity string) {
+	if h.config.Views == nil {
+		h.sendError(w, http.StatusNotFound, "views_disabled", "Saved views are not enabled", nil)
+		return
+	}
+	if accessErr := h.config.CheckEntityAccess(ctx, schema, entity, ratelimit.OperationRead); accessErr != nil {
+		h.sendError(w, accessErr.Status, accessErr.Code, accessErr.Message, nil)
+		return
+	}
+	if _, err := h.registry.GetModelByEntity(schema, entity); err != nil {
+		h.sendError(w, http.StatusBadRequest, "invalid_entity", "Invalid entity", err)
+		return
+	}
+	owner := h.config.ViewOwner(ctx)
+	name := viewName(r)
+
+	switch r.Method() {
+	case "GET":
+		if name == "" {
+			views, err := h.config.Views.ListViews(ctx, schema, entity, owner)
+			if err != nil {
+				h.sendError(w, http.StatusInternalServerError, "view_error", "Error loading the views", err)
+				return
+			}
+			h.sendResponse(w, views, nil)
+			return
+		}
+		view, err := h.config.Views.FindView(ctx, schema, entity, name, owner)
+		if err != nil {
+			h.sendError(w, http.StatusInternalServerError, "view_error", "Error loading the view", err)
+			return
+		}
+		if view == nil {
+			h.sendError(w, http.StatusNotFound, "view_not_found", fmt.Sprintf("View %s not found", name), nil)
+			return
+		}
+		h.sendResponse(w, view, nil)
+
+	case "POST", "PUT":
+		body, err := h.config.ReadBody(r)
+		if h.rejectLimit(w, err) {
+			return
+		}
+		var view common.View
+		if err == nil {
+			err = json.Unmarshal(body, &view)
+		}
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, "invalid_request", "Invalid view", err)
+			return
+		}
+		view.Schema, view.Entity, view.Owner, view.UpdatedAt = schema, entity, owner, time.Now().UTC()
+		if err := view.Validate(); err != nil {
+			h.sendError(w, http.StatusBadRequest, "invalid_view", err.Error(), nil)
+			return
+		}
+		if err := h.config.Views.SaveView(ctx, view); err != nil {
+			h.sendError(w, http.StatusInternalServerError, "view_error", "Error saving the view", err)
+			return
+		}
+		logger.InfoContext(ctx, "Saved view %s of %s.%s", view.Name, schema, entity)
+		h.sendResponse(w, view, nil)
+
+	case "DELETE":
+		if name == "" {
+			h.sendError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("Name the view with %s or the view query parameter", common.ViewHeader), nil)
+			return
+		}
+		deleted, err := h.config.Views.DeleteView(ctx, schema, entity, name, owner)
+		if err != nil {
+			h.sendError(w, http.StatusInternalServerError, "view_error", "Error deleting the view", err)
+			return
+		}
+		if !deleted {
+			h.sendError(w, http.StatusNotFound, "view_not_found", fmt.Sprintf("View %s not found", name), nil)
+			return
+		}
+		h.sendResponse(w, map[string]interface{}{"deleted": name}, nil)
+
+	default:
+		h.sendError(w, http.StatusMethodNotAllowed, "invalid_method", "Invalid HTTP method", nil)
+	}
+}
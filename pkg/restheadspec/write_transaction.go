@@ -0,0 +1,36 @@
+package restheadspec
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// handleWriteTransaction handles a write request on a copy of the handler whose database is a
+// transaction, committed once the request succeeded and rolled back when it was answered with
+// an error. The response is held back until the transaction committed.
+func (h *Handler) handleWriteTransaction(w common.ResponseWriter, r common.Request, params map[string]string) {
+	writer := common.NewTransactionWriter(w)
+	err := common.RunInTransaction(r.Context(), h.db, func(ctx context.Context, tx common.Database) error {
+		write := *h
+		write.db = tx
+		write.nestedProcessor = common.NewNestedCUDProcessor(tx, h.registry, &write)
+		write.writeTx = true
+		write.Handle(writer, common.WithRequestContext(r, ctx), params)
+		if writer.Failed() {
+			return common.ErrWriteFailed
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, common.ErrWriteFailed) {
+		logger.Error("Error committing write transaction of %s.%s: %v", params["schema"], params["entity"], err)
+		h.sendError(w, http.StatusInternalServerError, "commit_error", "Error committing transaction", err)
+		return
+	}
+	if err := writer.Finish(); err != nil {
+		logger.Error("Error sending write transaction response: %v", err)
+	}
+}
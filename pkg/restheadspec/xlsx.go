@@ -0,0 +1,45 @@
+package restheadspec
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// XLSXResponseFormat is the response format of reads downloaded as an Excel workbook, requested
+// with x-xlsx or an Accept header of common.XLSXContentType
+const XLSXResponseFormat = "xlsx"
+
+// sendXLSX streams the records of a read as a workbook with a header row of the columns of the
+// model, in a sheet named by x-xlsx-sheet or else by the entity
+func (h *Handler) sendXLSX(w common.ResponseWriter, schema, entity string, model, data interface{}, options ExtendedRequestOptions) {
+	// Columns the read didn't select are left out, computed columns are written after the model
+	selected := make(map[string]bool)
+	for _, column := range common.SelectColumns(model, options.Columns, options.OmitColumns) {
+		selected[strings.ToLower(column)] = true
+	}
+	columns := make([]string, 0)
+	hidden := make([]string, 0)
+	for _, column := range h.generateMetadata(schema, entity, model).Columns {
+		if len(selected) == 0 || selected[strings.ToLower(column.Name)] {
+			columns = append(columns, column.Name)
+		} else {
+			hidden = append(hidden, column.Name)
+		}
+	}
+
+	sheet := options.SheetName
+	if sheet == "" {
+		sheet = entity
+	}
+	w.SetHeader("Content-Type", common.XLSXContentType)
+	w.SetHeader("Content-Disposition", fmt.Sprintf("attachment; filename=%q", common.XLSXSheetName(sheet)+".xlsx"))
+	w.WriteHeader(http.StatusOK)
+	if err := common.WriteXLSX(w, sheet, columns, hidden, data); err != nil {
+		// The workbook is streamed, so the status is already sent
+		logger.Error("Failed to write xlsx response of %s.%s: %v", schema, entity, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/bitechdev/ResolveSpec/pkg/common"
 	"github.com/bitechdev/ResolveSpec/pkg/logger"
 	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
 )
@@ -118,9 +119,7 @@ func applyRowSecurity(hookCtx *restheadspec.HookContext, securityList *SecurityL
 
 		// Apply the WHERE clause to the query
 		// The query is in hookCtx.Query
-		if selectQuery, ok := hookCtx.Query.(interface {
-			Where(string, ...interface{}) interface{}
-		}); ok {
+		if selectQuery, ok := hookCtx.Query.(common.SelectQuery); ok {
 			hookCtx.Query = selectQuery.Where(whereClause)
 		} else {
 			logger.Error("Unable to apply WHERE clause - query doesn't support Where method")
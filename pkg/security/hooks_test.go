@@ -0,0 +1,70 @@
+package security
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun/driver/sqliteshim"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+type securedNote struct {
+	ID      int64  `bun:"id,pk" json:"id"`
+	OwnerID int    `bun:"owner_id" json:"owner_id"`
+	Title   string `bun:"title" json:"title"`
+}
+
+// TestApplyRowSecurity limits the select query of a read to the rows of the row security
+// template, which the hook used to skip for the select queries of the adapters
+func TestApplyRowSecurity(t *testing.T) {
+	sqldb, err := sql.Open(sqliteshim.ShimName, "file::memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { sqldb.Close() })
+	for _, statement := range []string{
+		"CREATE TABLE notes (id INTEGER PRIMARY KEY, owner_id INTEGER NOT NULL, title TEXT NOT NULL)",
+		"INSERT INTO notes (owner_id, title) VALUES (1, 'mine'), (2, 'theirs'), (1, 'also mine')",
+	} {
+		_, err := sqldb.Exec(statement)
+		require.NoError(t, err)
+	}
+	adapter := database.NewSQLAdapter(sqldb, "sqlite")
+
+	list := &SecurityList{
+		LoadRowSecurityCallback: func(userID int, schema, table string) (RowSecurity, error) {
+			return RowSecurity{Schema: schema, Tablename: table, UserID: userID, Template: "owner_id = {UserID}"}, nil
+		},
+	}
+	_, err = list.LoadRowSecurity(1, "", "notes", false)
+	require.NoError(t, err)
+
+	ctx := withUser(context.Background(), 1, "")
+	hookCtx := &restheadspec.HookContext{
+		Context: ctx,
+		Entity:  "notes",
+		Model:   securedNote{},
+		Query:   adapter.NewSelect().Model(&securedNote{}).Table("notes").Order("id"),
+	}
+	require.NoError(t, applyRowSecurity(hookCtx, list))
+
+	query, ok := hookCtx.Query.(common.SelectQuery)
+	require.True(t, ok, "the hook keeps a select query")
+	var notes []securedNote
+	require.NoError(t, query.Scan(ctx, &notes))
+	require.Len(t, notes, 2)
+	for _, note := range notes {
+		assert.Equal(t, 1, note.OwnerID)
+	}
+
+	list.LoadRowSecurityCallback = func(userID int, schema, table string) (RowSecurity, error) {
+		return RowSecurity{Schema: schema, Tablename: table, UserID: userID, HasBlock: true}, nil
+	}
+	_, err = list.LoadRowSecurity(1, "", "notes", true)
+	require.NoError(t, err)
+	assert.Error(t, applyRowSecurity(hookCtx, list), "blocked users are denied")
+}
@@ -2,7 +2,14 @@ package security
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
 )
 
 // contextKey is a custom type for context keys to avoid collisions
@@ -33,25 +40,94 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Add user information to context
-		ctx := context.WithValue(r.Context(), UserIDKey, userID)
-		if roles != "" {
-			ctx = context.WithValue(ctx, UserRolesKey, roles)
-		}
-
 		// Continue with authenticated context
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r.WithContext(withUser(r.Context(), userID, roles)))
 	})
 }
 
+// Authenticator adapts the AuthenticateCallback of the security list for
+// restheadspec.WithAuth. Like AuthMiddleware it stores the user ID and roles in the
+// request context, so GetUserID works in hooks and the hooks registered with
+// RegisterSecurityHooks apply row and column security for the user.
+//
+//	handler := restheadspec.NewHandler(db, registry,
+//		restheadspec.WithAuth(security.GlobalSecurity.Authenticator()))
+//	security.RegisterSecurityHooks(handler, &security.GlobalSecurity)
+func (m *SecurityList) Authenticator() restheadspec.Authenticator {
+	return func(ctx context.Context, r common.Request) (context.Context, error) {
+		if m.AuthenticateCallback == nil {
+			logger.Error("AuthenticateCallback not set - you must provide an authentication callback")
+			return nil, fmt.Errorf("authentication is not configured")
+		}
+
+		userID, roles, err := m.AuthenticateCallback(httpRequest(ctx, r))
+		if err != nil {
+			return nil, err
+		}
+
+		ctx = context.WithValue(ctx, SECURITY_CONTEXT_KEY, m)
+		return withUser(ctx, userID, roles), nil
+	}
+}
+
+// withUser adds the user information to a context
+func withUser(ctx context.Context, userID int, roles string) context.Context {
+	ctx = context.WithValue(ctx, UserIDKey, userID)
+	if roles != "" {
+		ctx = context.WithValue(ctx, UserRolesKey, roles)
+	}
+	return ctx
+}
+
+// httpRequest returns the http.Request behind a router request, or rebuilds one
+// from its method, URL and headers for routers that do not expose it
+func httpRequest(ctx context.Context, r common.Request) *http.Request {
+	if unwrapper, ok := r.(common.HTTPRequestUnwrapper); ok {
+		if req := unwrapper.UnderlyingRequest(); req != nil {
+			return req.WithContext(ctx)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.Method(), r.URL(), nil)
+	if err != nil {
+		req = (&http.Request{Method: r.Method(), Header: make(http.Header)}).WithContext(ctx)
+	}
+	for key, value := range r.AllHeaders() {
+		req.Header.Set(key, value)
+	}
+	return req
+}
+
 // GetUserID extracts the user ID from context
 func GetUserID(ctx context.Context) (int, bool) {
 	userID, ok := ctx.Value(UserIDKey).(int)
 	return userID, ok
 }
 
+// UserKey returns the user ID from context as a string, e.g. for per-user rate limits
+func UserKey(ctx context.Context) (string, bool) {
+	userID, ok := GetUserID(ctx)
+	if !ok {
+		return "", false
+	}
+	return strconv.Itoa(userID), true
+}
+
 // GetUserRoles extracts user roles from context
 func GetUserRoles(ctx context.Context) (string, bool) {
 	roles, ok := ctx.Value(UserRolesKey).(string)
 	return roles, ok
 }
+
+// UserRoleList returns the comma separated user roles from context as a list, e.g. for
+// common.HandlerConfig.UserRoles
+func UserRoleList(ctx context.Context) []string {
+	roles, _ := GetUserRoles(ctx)
+	list := make([]string, 0)
+	for _, role := range strings.Split(roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			list = append(list, role)
+		}
+	}
+	return list
+}
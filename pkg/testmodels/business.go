@@ -3,6 +3,7 @@ package testmodels
 import (
 	"time"
 
+	"github.com/bitechdev/ResolveSpec/pkg/common"
 	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
 )
 
@@ -50,6 +51,13 @@ func (Employee) TableName() string {
 	return "employees"
 }
 
+// Indexes declares the index of the department listings
+func (Employee) Indexes() []common.IndexSpec {
+	return []common.IndexSpec{
+		{Columns: []string{"department_id", "status"}},
+	}
+}
+
 // Project represents a company project
 type Project struct {
 	ID          string    `json:"id" gorm:"primaryKey;type:string"`
@@ -97,6 +105,13 @@ func (ProjectTask) TableName() string {
 	return "project_tasks"
 }
 
+// Indexes declares a partial index of the open tasks of a project by due date
+func (ProjectTask) Indexes() []common.IndexSpec {
+	return []common.IndexSpec{
+		{Name: "idx_project_tasks_open", Columns: []string{"project_id", "due_date"}, Where: "status <> 'done'"},
+	}
+}
+
 // Document represents any document in the system
 type Document struct {
 	ID          string    `json:"id" gorm:"primaryKey;type:string"`
@@ -0,0 +1,93 @@
+// Package tracing provides OpenTelemetry instrumentation helpers used by the
+// ResolveSpec handlers, the NestedCUDProcessor and the database adapters.
+//
+// Spans are created through the globally registered TracerProvider
+// (otel.SetTracerProvider). When no provider is configured the OpenTelemetry
+// API falls back to a no-op implementation, so instrumentation has no effect
+// until the application opts in.
+//
+// # Usage Example
+//
+//	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+//	otel.SetTracerProvider(tp)
+//
+//	// All handler and adapter calls now emit spans
+//	handler := restheadspec.NewHandlerWithGORM(db)
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name reported for all ResolveSpec spans
+const InstrumentationName = "github.com/bitechdev/ResolveSpec"
+
+// Attribute keys attached to ResolveSpec spans
+const (
+	AttrSchema       = attribute.Key("resolvespec.schema")
+	AttrEntity       = attribute.Key("resolvespec.entity")
+	AttrTable        = attribute.Key("resolvespec.table")
+	AttrOperation    = attribute.Key("resolvespec.operation")
+	AttrFilterCount  = attribute.Key("resolvespec.filter_count")
+	AttrPreloadCount = attribute.Key("resolvespec.preload_count")
+	AttrRowCount     = attribute.Key("resolvespec.row_count")
+	AttrTotalCount   = attribute.Key("resolvespec.total_count")
+	AttrRowsAffected = attribute.Key("resolvespec.rows_affected")
+
+	AttrDBSystem    = attribute.Key("db.system")
+	AttrDBOperation = attribute.Key("db.operation")
+	AttrDBStatement = attribute.Key("db.statement")
+)
+
+// Tracer returns the ResolveSpec tracer from the global TracerProvider
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// StartSpan starts a new span as a child of any span found in ctx
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartDBSpan starts a client span for a database adapter call
+func StartDBSpan(ctx context.Context, system, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	attrs = append(attrs, AttrDBSystem.String(system), AttrDBOperation.String(operation))
+	return Tracer().Start(ctx, system+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// SetAttributes adds attributes to the span stored in ctx, if any
+func SetAttributes(ctx context.Context, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+}
+
+// RecordError marks the span as failed when err is not nil
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// EndSpan records err (if any) and ends the span
+// Intended to be deferred with a pointer to a named error return value
+func EndSpan(span trace.Span, err *error) {
+	if err != nil {
+		RecordError(span, *err)
+	}
+	span.End()
+}
@@ -0,0 +1,50 @@
+package webhooks
+
+import (
+	"fmt"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// Name returns the plugin name
+func (d *Dispatcher) Name() string {
+	return "webhooks"
+}
+
+// RegisterHooks publishes the committed writes of a restheadspec or resolvespec handler
+func (d *Dispatcher) RegisterHooks(host common.PluginHost) error {
+	switch handler := host.(type) {
+	case *restheadspec.Handler:
+		handler.Hooks().Register(restheadspec.AfterCommit, func(ctx *restheadspec.HookContext) error {
+			d.Publish(newEvent(ctx.Operation, ctx.Schema, ctx.Entity, ctx.ID, ctx.RequestID, ctx.Result, ctx.Data))
+			return nil
+		})
+	case *resolvespec.Handler:
+		handler.Hooks().Register(resolvespec.AfterCommit, func(ctx *resolvespec.HookContext) error {
+			d.Publish(newEvent(ctx.Operation, ctx.Schema, ctx.Entity, ctx.ID, ctx.RequestID, ctx.Result, ctx.Data))
+			return nil
+		})
+	default:
+		return fmt.Errorf("webhooks can't be loaded into %T", host)
+	}
+	return nil
+}
+
+// newEvent returns the event of a committed write. Its data is the result of the write, or
+// the request data when the write has no result, e.g. a delete.
+func newEvent(operation, schema, entity, id, requestID string, result, data interface{}) Event {
+	event := Event{
+		Type:      operation,
+		Schema:    schema,
+		Entity:    entity,
+		RecordID:  id,
+		Data:      result,
+		RequestID: requestID,
+	}
+	if event.Data == nil {
+		event.Data = data
+	}
+	return event
+}
@@ -0,0 +1,419 @@
+// Package webhooks delivers the creates, updates and deletes of entities to HTTP endpoints.
+// Subscriptions name an entity pattern, a URL, a secret and the event types they receive.
+// Loaded as a plugin, the dispatcher queues a delivery for every committed write; deliveries
+// are signed with the secret of their subscription, retried with exponential backoff, and
+// recorded in a dead letter log once their attempts are exhausted.
+//
+// # Usage Example
+//
+//	dispatcher := webhooks.New(webhooks.Config{MaxAttempts: 8})
+//	defer dispatcher.Close()
+//	dispatcher.Subscribe(webhooks.Subscription{
+//		Entity: "public.orders",
+//		URL:    "https://example.com/hooks/orders",
+//		Secret: os.Getenv("ORDERS_WEBHOOK_SECRET"),
+//		Events: []string{webhooks.EventCreate, webhooks.EventDelete},
+//	})
+//	restheadspecHandler.Use(dispatcher)
+//	resolvespecHandler.Use(dispatcher)
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+)
+
+// Event types
+const (
+	EventCreate = "create"
+	EventUpdate = "update"
+	EventDelete = "delete"
+)
+
+// Headers of a delivery
+const (
+	HeaderDelivery  = "X-Webhook-ID"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Subscription sends the events of the entities matching Entity to URL
+type Subscription struct {
+	// ID identifies the subscription; Subscribe generates one when empty
+	ID string
+	// Entity is "schema.entity" or "entity" and may use the wildcards of path.Match;
+	// empty or "*" matches every entity
+	Entity string
+	// URL receives the deliveries as JSON POST requests
+	URL string
+	// Secret signs the deliveries, see Sign
+	Secret string
+	// Events limits the subscription to these event types; empty receives every event
+	Events []string
+}
+
+func (s *Subscription) matches(event Event) bool {
+	if len(s.Events) > 0 && !containsFold(s.Events, event.Type) {
+		return false
+	}
+	if s.Entity == "" || s.Entity == "*" {
+		return true
+	}
+	target := event.Entity
+	if strings.Contains(s.Entity, ".") {
+		target = event.Schema + "." + event.Entity
+	}
+	ok, _ := path.Match(strings.ToLower(s.Entity), strings.ToLower(target))
+	return ok
+}
+
+// Event is the body of a delivery
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Schema    string      `json:"schema,omitempty"`
+	Entity    string      `json:"entity"`
+	RecordID  string      `json:"record_id,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// Delivery is an event on its way to the URL of a subscription
+type Delivery struct {
+	ID           string
+	Subscription Subscription
+	Event        Event
+	Attempts     int
+}
+
+// DeadLetter is a delivery that failed all its attempts
+type DeadLetter struct {
+	Delivery
+	Error    string
+	FailedAt time.Time
+}
+
+// Config configures a Dispatcher
+type Config struct {
+	// Client sends the deliveries; nil uses a client with a 10 second timeout
+	Client *http.Client
+	// MaxAttempts is the number of attempts of a delivery; default 5
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled for every further retry; default 1s
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries; default 5 minutes
+	MaxBackoff time.Duration
+	// Workers is the number of deliveries sent in parallel; default 4
+	Workers int
+	// QueueSize is the number of deliveries waiting for a worker; default 1000. Deliveries
+	// queued on a full queue go to the dead letter log.
+	QueueSize int
+	// DeadLetterSize is the number of dead letters kept; default 1000, the oldest are dropped
+	DeadLetterSize int
+	// OnDeadLetter is called for every dead letter, e.g. to persist it
+	OnDeadLetter func(DeadLetter)
+}
+
+func (c *Config) defaults() {
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 5 * time.Minute
+	}
+	if c.Workers <= 0 {
+		c.Workers = 4
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 1000
+	}
+	if c.DeadLetterSize <= 0 {
+		c.DeadLetterSize = 1000
+	}
+}
+
+// Dispatcher queues and sends the deliveries of its subscriptions. It is a common.Plugin:
+// loaded into a handler, it publishes the creates, updates and deletes of the handler once
+// they committed.
+type Dispatcher struct {
+	common.BasePlugin
+
+	config        Config
+	mu            sync.RWMutex
+	subscriptions []Subscription
+	deadLetters   []DeadLetter
+	closed        bool
+	queue         chan *Delivery
+	closing       chan struct{}
+	closeOnce     sync.Once
+	workers       sync.WaitGroup
+}
+
+// New creates a dispatcher and starts its workers
+func New(config Config) *Dispatcher {
+	config.defaults()
+	d := &Dispatcher{
+		config:  config,
+		queue:   make(chan *Delivery, config.QueueSize),
+		closing: make(chan struct{}),
+	}
+	for i := 0; i < config.Workers; i++ {
+		d.workers.Add(1)
+		go d.work()
+	}
+	return d
+}
+
+// Subscribe adds a subscription and returns its id
+func (d *Dispatcher) Subscribe(subscription Subscription) (string, error) {
+	target, err := url.Parse(subscription.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return "", fmt.Errorf("invalid webhook url %q", subscription.URL)
+	}
+	if _, err := path.Match(subscription.Entity, ""); err != nil {
+		return "", fmt.Errorf("invalid entity pattern %q: %w", subscription.Entity, err)
+	}
+	if subscription.ID == "" {
+		subscription.ID = common.NewRequestID()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, existing := range d.subscriptions {
+		if existing.ID == subscription.ID {
+			return "", fmt.Errorf("subscription %s already exists", subscription.ID)
+		}
+	}
+	d.subscriptions = append(d.subscriptions, subscription)
+	logger.Info("Subscribed %s to %s events of %q", subscription.URL, strings.Join(subscription.Events, ", "), subscription.Entity)
+	return subscription.ID, nil
+}
+
+// Unsubscribe removes a subscription; queued deliveries are still sent
+func (d *Dispatcher) Unsubscribe(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, subscription := range d.subscriptions {
+		if subscription.ID == id {
+			d.subscriptions = append(d.subscriptions[:i], d.subscriptions[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Subscriptions returns the subscriptions
+func (d *Dispatcher) Subscriptions() []Subscription {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]Subscription(nil), d.subscriptions...)
+}
+
+// DeadLetters returns the dead letter log, oldest first
+func (d *Dispatcher) DeadLetters() []DeadLetter {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]DeadLetter(nil), d.deadLetters...)
+}
+
+// Publish queues a delivery of the event for every matching subscription. The event gets an
+// id and a timestamp when it has none.
+func (d *Dispatcher) Publish(event Event) {
+	if event.ID == "" {
+		event.ID = common.NewRequestID()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	var rejected []*Delivery
+	d.mu.RLock()
+	for _, subscription := range d.subscriptions {
+		if !subscription.matches(event) {
+			continue
+		}
+		delivery := &Delivery{ID: common.NewRequestID(), Subscription: subscription, Event: event}
+		if d.closed {
+			rejected = append(rejected, delivery)
+			continue
+		}
+		select {
+		case d.queue <- delivery:
+		default:
+			rejected = append(rejected, delivery)
+		}
+	}
+	closed := d.closed
+	d.mu.RUnlock()
+
+	for _, delivery := range rejected {
+		if closed {
+			d.deadLetter(delivery, fmt.Errorf("dispatcher is closed"))
+		} else {
+			d.deadLetter(delivery, fmt.Errorf("delivery queue is full"))
+		}
+	}
+}
+
+// Close stops the dispatcher: deliveries waiting for a retry or a worker go to the dead
+// letter log, and Close returns once the deliveries being sent finished
+func (d *Dispatcher) Close() {
+	d.closeOnce.Do(func() {
+		d.mu.Lock()
+		d.closed = true
+		d.mu.Unlock()
+		close(d.closing)
+	})
+	d.workers.Wait()
+}
+
+// work sends queued deliveries until the dispatcher closes
+func (d *Dispatcher) work() {
+	defer d.workers.Done()
+	for {
+		select {
+		case <-d.closing:
+			for {
+				select {
+				case delivery := <-d.queue:
+					d.deadLetter(delivery, fmt.Errorf("dispatcher is closed"))
+				default:
+					return
+				}
+			}
+		case delivery := <-d.queue:
+			d.deliver(delivery)
+		}
+	}
+}
+
+// deliver sends a delivery, retrying failures with exponential backoff
+func (d *Dispatcher) deliver(delivery *Delivery) {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		d.deadLetter(delivery, fmt.Errorf("failed to encode event: %w", err))
+		return
+	}
+
+	backoff := d.config.Backoff
+	for {
+		delivery.Attempts++
+		retry, err := d.send(delivery, body)
+		if err == nil {
+			logger.Debug("Delivered webhook %s of event %s to %s", delivery.ID, delivery.Event.ID, delivery.Subscription.URL)
+			return
+		}
+		if !retry || delivery.Attempts >= d.config.MaxAttempts {
+			d.deadLetter(delivery, err)
+			return
+		}
+		logger.Warn("Webhook %s to %s failed (attempt %d), retrying in %s: %v", delivery.ID, delivery.Subscription.URL, delivery.Attempts, backoff, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-d.closing:
+			timer.Stop()
+			d.deadLetter(delivery, fmt.Errorf("dispatcher closed before retry: %w", err))
+			return
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, d.config.MaxBackoff)
+	}
+}
+
+// send makes one attempt of a delivery. retry is false for failures another attempt won't fix.
+func (d *Dispatcher) send(delivery *Delivery, body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, delivery.Subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderEvent, delivery.Event.Type)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	if delivery.Subscription.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(delivery.Subscription.Secret, timestamp, body))
+	}
+
+	resp, err := d.config.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return true, fmt.Errorf("endpoint answered %s", resp.Status)
+	default:
+		return false, fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+}
+
+// deadLetter records a failed delivery in the dead letter log
+func (d *Dispatcher) deadLetter(delivery *Delivery, err error) {
+	letter := DeadLetter{Delivery: *delivery, Error: err.Error(), FailedAt: time.Now().UTC()}
+	logger.Error("Webhook %s of event %s to %s failed after %d attempt(s): %v", delivery.ID, delivery.Event.ID, delivery.Subscription.URL, delivery.Attempts, err)
+
+	d.mu.Lock()
+	d.deadLetters = append(d.deadLetters, letter)
+	if excess := len(d.deadLetters) - d.config.DeadLetterSize; excess > 0 {
+		d.deadLetters = append([]DeadLetter(nil), d.deadLetters[excess:]...)
+	}
+	d.mu.Unlock()
+
+	if d.config.OnDeadLetter != nil {
+		d.config.OnDeadLetter(letter)
+	}
+}
+
+// Sign returns the X-Webhook-Signature of a delivery body sent at timestamp (Unix seconds):
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a received delivery. Receivers should also reject
+// timestamps too far from their clock to prevent replays.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(strings.TrimSpace(candidate), value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	signature := Sign("secret", 1700000000, body)
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, signature)
+	assert.True(t, Verify("secret", 1700000000, body, signature))
+	assert.False(t, Verify("other", 1700000000, body, signature))
+	assert.False(t, Verify("secret", 1700000001, body, signature))
+	assert.False(t, Verify("secret", 1700000000, []byte(`{"id":"2"}`), signature))
+}
+
+func TestSubscriptionMatches(t *testing.T) {
+	tests := []struct {
+		subscription Subscription
+		event        Event
+		want         bool
+	}{
+		{Subscription{}, Event{Type: EventCreate, Entity: "orders"}, true},
+		{Subscription{Entity: "public.orders"}, Event{Type: EventCreate, Schema: "public", Entity: "orders"}, true},
+		{Subscription{Entity: "public.*"}, Event{Type: EventCreate, Schema: "hr", Entity: "orders"}, false},
+		{Subscription{Entity: "Orders"}, Event{Type: EventCreate, Schema: "hr", Entity: "orders"}, true},
+		{Subscription{Events: []string{EventDelete}}, Event{Type: EventUpdate, Entity: "orders"}, false},
+		{Subscription{Events: []string{EventDelete}}, Event{Type: EventDelete, Entity: "orders"}, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.subscription.matches(tt.event), "%+v %+v", tt.subscription, tt.event)
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	var attempts atomic.Int32
+	received := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if !Verify("secret", timestamp, body, r.Header.Get(HeaderSignature)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var event Event
+		_ = json.Unmarshal(body, &event)
+		received <- event
+	}))
+	defer server.Close()
+
+	d := New(Config{Backoff: time.Millisecond})
+	defer d.Close()
+	_, err := d.Subscribe(Subscription{Entity: "orders", URL: server.URL, Secret: "secret"})
+	require.NoError(t, err)
+
+	d.Publish(Event{Type: EventCreate, Entity: "orders", RecordID: "7"})
+	d.Publish(Event{Type: EventCreate, Entity: "customers"})
+	select {
+	case event := <-received:
+		assert.Equal(t, "7", event.RecordID)
+		assert.NotEmpty(t, event.ID)
+	case <-time.After(time.Second):
+		t.Fatal("event was not delivered")
+	}
+	assert.EqualValues(t, 3, attempts.Load())
+	assert.Empty(t, d.DeadLetters())
+}
+
+func TestDispatcherDeadLetters(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		if r.Header.Get(HeaderEvent) == EventDelete {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	letters := make(chan DeadLetter, 2)
+	d := New(Config{MaxAttempts: 3, Backoff: time.Millisecond, Workers: 1, OnDeadLetter: func(letter DeadLetter) {
+		letters <- letter
+	}})
+	defer d.Close()
+	_, err := d.Subscribe(Subscription{URL: server.URL})
+	require.NoError(t, err)
+
+	d.Publish(Event{Type: EventUpdate, Entity: "orders"})
+	d.Publish(Event{Type: EventDelete, Entity: "orders"})
+	for i := 0; i < 2; i++ {
+		select {
+		case letter := <-letters:
+			if letter.Event.Type == EventUpdate {
+				assert.Equal(t, 3, letter.Attempts, "server errors are retried")
+			} else {
+				assert.Equal(t, 1, letter.Attempts, "client errors are not retried")
+			}
+			assert.Contains(t, letter.Error, "endpoint answered")
+		case <-time.After(time.Second):
+			t.Fatal("delivery was not dead lettered")
+		}
+	}
+	assert.Len(t, d.DeadLetters(), 2)
+	assert.EqualValues(t, 4, attempts.Load())
+}
+
+func TestSubscribeValidates(t *testing.T) {
+	d := New(Config{Workers: 1})
+	defer d.Close()
+	_, err := d.Subscribe(Subscription{URL: "ftp://example.com"})
+	assert.Error(t, err)
+	_, err = d.Subscribe(Subscription{URL: "https://example.com", Entity: "["})
+	assert.Error(t, err)
+	id, err := d.Subscribe(Subscription{URL: "https://example.com"})
+	require.NoError(t, err)
+	assert.Len(t, d.Subscriptions(), 1)
+	assert.True(t, d.Unsubscribe(id))
+	assert.Empty(t, d.Subscriptions())
+}
@@ -71,13 +71,38 @@ export interface Column {
     is_primary: boolean;
     is_unique: boolean;
     has_index: boolean;
+    enum?: string[];
+    default_value?: string;
+    comment?: string;
+    max_length?: number;
+    precision?: number;
+    scale?: number;
+}
+
+export interface Index {
+    name: string;
+    columns: string[];
+    unique: boolean;
+    primary: boolean;
+}
+
+export interface Relation {
+    name: string;
+    type: 'belongsTo' | 'hasOne' | 'hasMany' | 'many2many';
+    foreign_key?: string;
+    references?: string;
+    join_table?: string;
+    target_entity?: string;
+    preloadable: boolean;
+    expandable: boolean;
 }
 
 export interface TableMetadata {
     schema: string;
     table: string;
     columns: Column[];
-    relations: string[];
+    relations: Relation[];
+    indexes?: Index[];
 }
 
 export interface ClientConfig {
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+type commitNote struct {
+	ID    int64  `json:"id" bun:"id,pk,autoincrement"`
+	Title string `json:"title" bun:"title"`
+}
+
+func (commitNote) TableName() string { return "commit_notes" }
+
+// TestAfterCommitHooks fires AfterCommit hooks for committed writes only
+func TestAfterCommitHooks(t *testing.T) {
+	api := newAPIServer(t, "after_commit",
+		"CREATE TABLE commit_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL UNIQUE)",
+	)
+
+	api.register("commit_notes", commitNote{})
+	rs := resolvespec.NewHandler(api.Adapter, api.Registry)
+	rh := restheadspec.NewHandler(api.Adapter, api.Registry)
+	api.serveHandlers(rs, rh)
+
+	events := make(chan string, 10)
+	rh.Hooks().Register(restheadspec.AfterCommit, func(ctx *restheadspec.HookContext) error {
+		data, _ := ctx.Data.(map[string]interface{})
+		events <- fmt.Sprintf("%s %v", ctx.Operation, data["title"])
+		return nil
+	})
+	rs.Hooks().Register(resolvespec.AfterCommit, func(ctx *resolvespec.HookContext) error {
+		events <- fmt.Sprintf("%s %s", ctx.Operation, ctx.ID)
+		return nil
+	})
+
+	send := api.send
+	next := func() string {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("no AfterCommit hook ran")
+			return ""
+		}
+	}
+
+	rec := send("POST", "/restheadspec/commit_notes", `{"title":"first"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "create first", next())
+
+	rec = send("POST", "/restheadspec/commit_notes/_batch", `[{"action":"create","data":{"title":"second"}},{"action":"create","data":{"title":"first"}}]`)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	rec = send("POST", "/restheadspec/commit_notes/_batch", `{"atomic":false,"items":[{"action":"create","data":{"title":"first"}},{"action":"create","data":{"title":"third"}}]}`)
+	require.Equal(t, http.StatusMultiStatus, rec.Code, rec.Body.String())
+	assert.Equal(t, "create third", next(), "rolled back items don't fire AfterCommit")
+
+	rec = send("POST", "/resolvespec/commit_notes/1", `{"operation":"update","data":{"title":"renamed"}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "update 1", next())
+
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected AfterCommit hook: %s", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,112 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// TestAggregates returns aggregates over every row a read matches in the metadata, while the
+// read returns one page of them
+func TestAggregates(t *testing.T) {
+	for _, name := range []string{"bun", "gorm", "sql"} {
+		t.Run(name, func(t *testing.T) {
+			api := newAPIServer(t, "aggregates_"+name,
+				"CREATE TABLE departments (id INTEGER PRIMARY KEY, name TEXT NOT NULL, code TEXT NOT NULL DEFAULT '')",
+				"CREATE TABLE employees (id INTEGER PRIMARY KEY, first_name TEXT NOT NULL DEFAULT '', last_name TEXT NOT NULL DEFAULT '', status TEXT NOT NULL DEFAULT '', salary REAL NOT NULL, department_id INTEGER NOT NULL)",
+				"INSERT INTO departments (id, name) VALUES (1, 'Sales'), (2, 'Engineering'), (3, 'Marketing')",
+			)
+			// Salaries of 1000 (7 rows), 2000 (7 rows) and 0 (6 rows)
+			for i := 1; i <= 20; i++ {
+				_, err := api.DB.Exec("INSERT INTO employees (id, salary, department_id) VALUES (?, ?, ?)", i, 1000*(i%3), 1+i%3)
+				require.NoError(t, err)
+			}
+
+			var db common.Database
+			switch name {
+			case "bun":
+				db = database.NewBunAdapter(bun.NewDB(api.DB, sqlitedialect.New()))
+			case "gorm":
+				gormDB, err := gorm.Open(sqlite.Dialector{Conn: api.DB}, &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+				require.NoError(t, err)
+				db = database.NewGormAdapter(gormDB)
+			default:
+				db = database.NewSQLAdapter(api.DB, "sqlite")
+			}
+			api.register("employees", goldenEmployee{})
+			api.register("departments", goldenDepartment{})
+			api.serveHandlers(resolvespec.NewHandler(db, api.Registry), restheadspec.NewHandler(db, api.Registry))
+
+			send := api.send
+			read := func(headers map[string]string) ([]goldenEmployee, common.Metadata) {
+				t.Helper()
+				headers["X-DetailApi"] = "true"
+				rec := send("GET", "/restheadspec/employees", "", headers)
+				require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+				var detail struct {
+					Data     []goldenEmployee `json:"data"`
+					Metadata common.Metadata  `json:"metadata"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+				return detail.Data, detail.Metadata
+			}
+
+			employees, metadata := read(map[string]string{"X-Aggregate": "sum(salary),avg(salary),max(salary),min(salary)", "X-Limit": "5"})
+			assert.Len(t, employees, 5)
+			assert.Equal(t, map[string]map[string]interface{}{
+				"salary": {"sum": float64(21000), "avg": float64(1050), "max": float64(2000), "min": float64(0)},
+			}, metadata.Aggregates, "aggregates cover every row, not the page")
+
+			if name != "sql" {
+				employees, metadata = read(map[string]string{"X-Aggregate": "sum(salary)", "X-Expand": "Department", "X-Limit": "2"})
+				assert.Len(t, employees, 2)
+				assert.Equal(t, map[string]map[string]interface{}{"salary": {"sum": float64(21000)}}, metadata.Aggregates, "joins leave the aggregates alone")
+			}
+			// Filters of restheadspec are qualified by the table name, not the alias of bun models
+			if name != "bun" {
+				employees, metadata = read(map[string]string{"X-Aggregate": "sum(salary),min(salary)", "X-Searchop-Gt-Salary": "0", "X-Limit": "2"})
+				assert.Len(t, employees, 2)
+				assert.Equal(t, map[string]map[string]interface{}{"salary": {"sum": float64(21000), "min": float64(1000)}}, metadata.Aggregates,
+					"aggregates follow the filters of the read")
+
+				_, metadata = read(map[string]string{"X-Aggregate": "sum(salary)", "X-Searchop-Gt-Salary": "5000"})
+				assert.Equal(t, map[string]map[string]interface{}{"salary": {"sum": nil}}, metadata.Aggregates, "aggregates of no rows are null")
+			}
+
+			for _, invalid := range []map[string]string{
+				{"X-Aggregate": "median(salary)"},
+				{"X-Aggregate": "salary"},
+				{"X-Aggregate": "sum(missing)"},
+				{"X-Aggregate": "sum(salary)", "X-Select-Fields": "id"},
+			} {
+				assert.Equal(t, http.StatusBadRequest, send("GET", "/restheadspec/employees", "", invalid).Code, invalid["X-Aggregate"])
+			}
+
+			rec := send("POST", "/resolvespec/employees",
+				`{"operation":"read","options":{"limit":1,"filters":[{"column":"department_id","operator":"eq","value":3}],"aggregates":[{"function":"sum","column":"salary"}]}}`, nil)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			var response common.Response
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			require.NotNil(t, response.Metadata)
+			assert.Equal(t, map[string]map[string]interface{}{"salary": {"sum": float64(14000)}}, response.Metadata.Aggregates)
+
+			rec = send("POST", "/resolvespec/employees", `{"operation":"read"}`, map[string]string{"X-Aggregate": "avg(salary)"})
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, map[string]map[string]interface{}{"salary": {"avg": float64(1050)}}, response.Metadata.Aggregates)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package test
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+// apiServer is the scaffold of the integration tests: an in-memory SQLite database served by
+// ResolveSpec and RestHeadSpec through the SQL adapter and the standalone router
+type apiServer struct {
+	t            *testing.T
+	DB           *sql.DB
+	Adapter      common.Database // SQL adapter of DB, or one the test sets before serving
+	Registry     *modelregistry.DefaultModelRegistry
+	ResolveSpec  *resolvespec.Handler
+	RestHeadSpec *restheadspec.Handler
+	Router       *mux.Router
+}
+
+// newAPIServer opens the in-memory SQLite database name, shared by its connections, and runs
+// the statements on it, e.g. the CREATE TABLE and INSERT of the test. Register the models and
+// serve the handlers to send requests. The database is closed when the test ends.
+func newAPIServer(t *testing.T, name string, statements ...string) *apiServer {
+	t.Helper()
+	sqldb, err := sql.Open("sqlite", fmt.Sprintf("file:%s?mode=memory&cache=shared", name))
+	require.NoError(t, err)
+	t.Cleanup(func() { sqldb.Close() })
+	for _, statement := range statements {
+		_, err := sqldb.Exec(statement)
+		require.NoError(t, err, statement)
+	}
+	return &apiServer{
+		t:        t,
+		DB:       sqldb,
+		Adapter:  database.NewSQLAdapter(sqldb, "sqlite"),
+		Registry: modelregistry.NewModelRegistry(),
+	}
+}
+
+// register registers a model under an entity name
+func (s *apiServer) register(entity string, model interface{}) *apiServer {
+	s.t.Helper()
+	require.NoError(s.t, s.Registry.RegisterModel(entity, model))
+	return s
+}
+
+// serve serves both handlers with config
+func (s *apiServer) serve(config common.HandlerConfig) *apiServer {
+	return s.serveHandlers(
+		resolvespec.NewHandlerWithConfig(s.Adapter, s.Registry, config),
+		restheadspec.NewHandlerWithConfig(s.Adapter, s.Registry, config),
+	)
+}
+
+// serveHandlers serves handlers the test built, e.g. with a config for each
+func (s *apiServer) serveHandlers(rs *resolvespec.Handler, rh *restheadspec.Handler) *apiServer {
+	s.ResolveSpec, s.RestHeadSpec = rs, rh
+	s.Router = setupStandaloneRouter(rs, rh)
+	return s
+}
+
+// send serves a request with the headers and returns its response
+func (s *apiServer) send(method, path, body string, headers ...map[string]string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for _, header := range headers {
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+	}
+	rec := httptest.NewRecorder()
+	s.Router.ServeHTTP(rec, req)
+	return rec
+}
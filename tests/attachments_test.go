@@ -0,0 +1,283 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	goimage "image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/attachments"
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+type attachmentDocument struct {
+	ID     int64                `json:"id" gorm:"column:id;primaryKey"`
+	Title  string               `json:"title" gorm:"column:title"`
+	Scan   attachments.FileRef  `json:"scan" gorm:"column:scan"`
+	Signed *attachments.FileRef `json:"signed" gorm:"column:signed"`
+}
+
+func (attachmentDocument) TableName() string {
+	return "attachment_documents"
+}
+
+// setupAttachments returns a router of the documents of an in-memory database with attachments
+// kept in a temporary directory
+func setupAttachments(t *testing.T, name string) (*mux.Router, string) {
+	t.Helper()
+	api := newAPIServer(t, name,
+		"CREATE TABLE attachment_documents (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, scan TEXT, signed TEXT)",
+		"INSERT INTO attachment_documents (title) VALUES ('Contract'), ('Invoice')",
+	)
+
+	api.register("documents", attachmentDocument{})
+	handler := restheadspec.NewHandlerWithConfig(api.Adapter, api.Registry, common.HandlerConfig{})
+	dir := t.TempDir()
+	require.NoError(t, handler.Use(attachments.New(attachments.Config{
+		Storage: attachments.NewLocalStorage(dir),
+		Secret:  []byte("secret"),
+		MaxSize: 1 << 10,
+		Authorize: func(ctx context.Context, schema, entity, id string) error {
+			return nil
+		},
+	})))
+	router := mux.NewRouter()
+	restheadspec.SetupMuxRoutes(router, handler)
+	return router, dir
+}
+
+// TestAttachments uploads files of a record, downloads them by their signed URLs and removes
+// them with the record
+func TestAttachments(t *testing.T) {
+	router, dir := setupAttachments(t, "attachments")
+
+	upload := func(id string, files map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		require.NoError(t, form.WriteField("title", "ignored"))
+		for field, content := range files {
+			part, err := form.CreateFormFile(field, field+".txt")
+			require.NoError(t, err)
+			_, _ = part.Write([]byte(content))
+		}
+		require.NoError(t, form.Close())
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/main/documents/"+id+"/files", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	refs := func(rec *httptest.ResponseRecorder) map[string]attachments.FileRef {
+		t.Helper()
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var response struct {
+			Data map[string]attachments.FileRef `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		return response.Data
+	}
+	get := func(target string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		return rec
+	}
+
+	uploaded := refs(upload("1", map[string]string{"scan": "first scan", "signed": "signature"}))
+	require.Len(t, uploaded, 2)
+	scan := uploaded["scan"]
+	assert.Equal(t, "scan.txt", scan.Name)
+	assert.Equal(t, int64(len("first scan")), scan.Size)
+	assert.True(t, strings.HasPrefix(scan.Key, "main/documents/1/scan/"), scan.Key)
+
+	download := get(scan.URL)
+	require.Equal(t, http.StatusOK, download.Code, download.Body.String())
+	assert.Equal(t, "first scan", download.Body.String())
+	assert.Equal(t, "attachment; filename=scan.txt", download.Header().Get("Content-Disposition"))
+	assert.Equal(t, http.StatusForbidden, get(strings.Replace(scan.URL, "scan%2F", "signed%2F", 1)).Code, "the key is signed")
+
+	// The columns of the record reference the files
+	read := get("/main/documents/1")
+	require.Equal(t, http.StatusOK, read.Code, read.Body.String())
+	assert.Contains(t, read.Body.String(), scan.Key)
+
+	// A new upload replaces the file of its column
+	replaced := refs(upload("1", map[string]string{"scan": "second scan"}))["scan"]
+	_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(scan.Key)))
+	assert.True(t, os.IsNotExist(err), "the replaced file is removed")
+	listed := refs(get("/main/documents/1/files"))
+	assert.Equal(t, replaced.Key, listed["scan"].Key)
+	assert.Equal(t, uploaded["signed"].Key, listed["signed"].Key)
+	assert.NotEmpty(t, listed["scan"].URL)
+
+	assert.Equal(t, http.StatusNotFound, upload("99", map[string]string{"scan": "x"}).Code)
+	assert.Equal(t, http.StatusBadRequest, upload("2", nil).Code, "uploads need a file")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, upload("2", map[string]string{"scan": strings.Repeat("x", 2<<10)}).Code)
+	other := refs(upload("2", map[string]string{"scan": "invoice"}))["scan"]
+
+	// Deleting the record removes its files once committed
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("DELETE", "/main/documents/1", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(dir, "main", "documents", "1"))
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+	_, err = os.Stat(filepath.Join(dir, filepath.FromSlash(other.Key)))
+	assert.NoError(t, err, "other records keep their files")
+}
+
+type tenantAttachmentDocument struct {
+	ID       int64               `json:"id" gorm:"column:id;primaryKey"`
+	TenantID int64               `json:"tenant_id" gorm:"column:tenant_id"`
+	Scan     attachments.FileRef `json:"scan" gorm:"column:scan"`
+}
+
+func (tenantAttachmentDocument) TableName() string {
+	return "tenant_documents"
+}
+
+// TestAttachmentAccess reads and updates the records of the files routes through the handler,
+// scoped to the tenant of the request and authorized, and keeps file columns out of the writes
+// of the handler
+func TestAttachmentAccess(t *testing.T) {
+	api := newAPIServer(t, "attachment_access",
+		"CREATE TABLE tenant_documents (id INTEGER PRIMARY KEY AUTOINCREMENT, tenant_id INTEGER NOT NULL, scan TEXT)",
+		"INSERT INTO tenant_documents (tenant_id) VALUES (1), (2), (1)",
+	)
+	api.register("documents", tenantAttachmentDocument{})
+	handler := restheadspec.NewHandlerWithConfig(api.Adapter, api.Registry, common.HandlerConfig{
+		TenantColumn: "tenant_id",
+		TenantResolver: func(r common.Request) (interface{}, error) {
+			tenant, err := strconv.ParseInt(r.Header("X-Tenant"), 10, 64)
+			if err != nil {
+				return nil, errors.New("missing X-Tenant")
+			}
+			return tenant, nil
+		},
+	})
+	require.Error(t, handler.Use(attachments.New(attachments.Config{Storage: attachments.NewLocalStorage(t.TempDir())})),
+		"attachments need an Authorize function")
+	require.NoError(t, handler.Use(attachments.New(attachments.Config{
+		Storage: attachments.NewLocalStorage(t.TempDir()),
+		Authorize: func(ctx context.Context, schema, entity, id string) error {
+			if id == "3" {
+				return errors.New("record 3 is locked")
+			}
+			return nil
+		},
+	})))
+	router := mux.NewRouter()
+	restheadspec.SetupMuxRoutes(router, handler)
+
+	send := func(method, path, tenant, contentType string, body *bytes.Buffer) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, body)
+		req.Header.Set("X-Tenant", tenant)
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	upload := func(id, tenant string) *httptest.ResponseRecorder {
+		t.Helper()
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("scan", "scan.txt")
+		require.NoError(t, err)
+		_, _ = part.Write([]byte("scan"))
+		require.NoError(t, form.Close())
+		return send("POST", "/main/documents/"+id+"/files", tenant, form.FormDataContentType(), &body)
+	}
+
+	assert.Equal(t, http.StatusOK, upload("1", "1").Code)
+	assert.Equal(t, http.StatusNotFound, upload("2", "1").Code, "records of other tenants are not found")
+	assert.Equal(t, http.StatusNotFound, send("GET", "/main/documents/2/files", "1", "", &bytes.Buffer{}).Code)
+	assert.Equal(t, http.StatusForbidden, upload("3", "1").Code, "Authorize denies the record")
+	assert.Equal(t, http.StatusForbidden, upload("1", "").Code, "requests without a tenant are rejected")
+
+	var scan sql.NullString
+	require.NoError(t, api.DB.QueryRow("SELECT scan FROM tenant_documents WHERE id = 2").Scan(&scan))
+	assert.False(t, scan.Valid, "the record of the other tenant is unchanged")
+
+	// Regular writes can't set file columns
+	forged := `{"scan": {"key": "main/documents/2/scan/forged", "name": "forged.txt"}}`
+	rec := send("POST", "/main/documents", "1", "application/json", bytes.NewBufferString(forged))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	rec = send("PATCH", "/main/documents/1", "1", "application/json", bytes.NewBufferString(forged))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), common.ProtectedColumn)
+}
+
+// TestAttachmentThumbnails downloads scaled and converted images of an uploaded image, rendered
+// once and cached next to it
+func TestAttachmentThumbnails(t *testing.T) {
+	router, dir := setupAttachments(t, "attachment_thumbnails")
+
+	var image bytes.Buffer
+	require.NoError(t, png.Encode(&image, goimage.NewRGBA(goimage.Rect(0, 0, 120, 80))))
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("scan", "page.png")
+	require.NoError(t, err)
+	_, _ = part.Write(image.Bytes())
+	require.NoError(t, form.Close())
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/main/documents/1/files", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Data map[string]attachments.FileRef `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	scan := response.Data["scan"]
+	assert.Equal(t, "image/png", scan.ContentType, "the type of an upload without one is detected")
+
+	get := func(query string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", scan.URL+query, nil))
+		return rec
+	}
+	for i := 0; i < 2; i++ {
+		thumb := get("&w=30&fmt=jpg")
+		require.Equal(t, http.StatusOK, thumb.Code, thumb.Body.String())
+		assert.Equal(t, "image/jpeg", thumb.Header().Get("Content-Type"))
+		assert.Equal(t, "attachment; filename=page.jpeg", thumb.Header().Get("Content-Disposition"))
+		config, format, err := goimage.DecodeConfig(bytes.NewReader(thumb.Body.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, [2]int{30, 20}, [2]int{config.Width, config.Height})
+	}
+	_, err = os.Stat(filepath.Join(dir, filepath.FromSlash(scan.Key+".thumbs/30x0-contain.jpeg")))
+	assert.NoError(t, err, "thumbnails are cached in the storage")
+
+	covered := get("&w=40&h=40&fit=cover")
+	require.Equal(t, http.StatusOK, covered.Code, covered.Body.String())
+	config, format, err := goimage.DecodeConfig(bytes.NewReader(covered.Body.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, "png", format, "thumbnails keep the format of the image by default")
+	assert.Equal(t, [2]int{40, 40}, [2]int{config.Width, config.Height})
+
+	assert.Equal(t, http.StatusBadRequest, get("&w=-1").Code)
+	assert.Equal(t, http.StatusBadRequest, get("&fmt=webp").Code, "webp needs an added encoder")
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type auditedTask struct {
+	ID        int64  `json:"id" bun:"id,pk,autoincrement"`
+	Title     string `json:"title" bun:"title"`
+	CreatedAt string `json:"created_at" bun:"created_at"`
+	UpdatedAt string `json:"updated_at" bun:"updated_at"`
+	CreatedBy string `json:"created_by" bun:"created_by"`
+	UpdatedBy string `json:"updated_by" bun:"updated_by"`
+}
+
+func (auditedTask) TableName() string { return "audited_tasks" }
+
+type auditUserKey struct{}
+
+// TestAuditColumnStamping sets the audit columns of creates and updates on the server
+func TestAuditColumnStamping(t *testing.T) {
+	api := newAPIServer(t, "audit_columns",
+		`CREATE TABLE audited_tasks (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL,
+		created_at TIMESTAMP, updated_at TIMESTAMP, created_by TEXT, updated_by TEXT)`,
+	)
+
+	api.register("audited_tasks", auditedTask{})
+	config := common.HandlerConfig{AuditColumns: true, AuditUser: func(ctx context.Context) (string, bool) {
+		user, ok := ctx.Value(auditUserKey{}).(string)
+		return user, ok && user != ""
+	}}
+	api.serve(config)
+
+	send := func(method, path, body, user string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req = req.WithContext(context.WithValue(req.Context(), auditUserKey{}, user))
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("POST", "/restheadspec/audited_tasks", `{"title":"write docs","created_by":"mallory"}`, "alice")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = send("POST", "/resolvespec/audited_tasks/1", `{"operation":"update","data":{"title":"write more docs","created_at":"2000-01-01"}}`, "bob")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var createdBy, updatedBy string
+	var createdAt, updatedAt sql.NullString
+	require.NoError(t, api.DB.QueryRow("SELECT created_by, updated_by, created_at, updated_at FROM audited_tasks WHERE id = 1").
+		Scan(&createdBy, &updatedBy, &createdAt, &updatedAt))
+	assert.Equal(t, "alice", createdBy, "client values are replaced")
+	assert.Equal(t, "bob", updatedBy)
+	assert.True(t, createdAt.Valid)
+	assert.NotContains(t, createdAt.String, "2000", "updates keep the created columns")
+	assert.True(t, updatedAt.Valid)
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+	"github.com/bitechdev/ResolveSpec/pkg/security"
+	"github.com/bitechdev/ResolveSpec/pkg/testmodels"
+)
+
+// TestRestHeadSpecWithAuth authenticates restheadspec requests with a security list
+func TestRestHeadSpecWithAuth(t *testing.T) {
+	db, err := setupStandaloneDB()
+	require.NoError(t, err)
+	defer cleanupStandaloneDB(db)
+
+	prefix := fmt.Sprintf("auth_%d", time.Now().UnixNano())
+	for _, code := range []string{"a", "b"} {
+		require.NoError(t, db.Create(&testmodels.Department{ID: prefix + "_" + code, Name: code, Code: prefix + "_" + code}).Error)
+	}
+
+	securityList := &security.SecurityList{
+		// "Authorization: Bearer <user id>"
+		AuthenticateCallback: func(r *http.Request) (int, string, error) {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			userID, err := strconv.Atoi(token)
+			if err != nil {
+				return 0, "", errors.New("invalid token")
+			}
+			return userID, "reader", nil
+		},
+		LoadColumnSecurityCallback: func(int, string, string) ([]security.ColumnSecurity, error) {
+			return nil, nil
+		},
+		// User 2 only sees department "a"
+		LoadRowSecurityCallback: func(userID int, schema, table string) (security.RowSecurity, error) {
+			rowSec := security.RowSecurity{Schema: schema, Tablename: table, UserID: userID}
+			if userID == 2 {
+				rowSec.Template = fmt.Sprintf("{PrimaryKeyName} = '%s_a'", prefix)
+			}
+			return rowSec, nil
+		},
+	}
+
+	resolveSpecHandler, restHeadSpecHandler := setupStandaloneHandlers(db)
+	restHeadSpecHandler.SetAuthenticator(securityList.Authenticator())
+	security.RegisterSecurityHooks(restHeadSpecHandler, securityList)
+
+	var hookUserID int
+	var hookRoles string
+	restHeadSpecHandler.Hooks().Register(restheadspec.BeforeRead, func(hookCtx *restheadspec.HookContext) error {
+		hookUserID, _ = security.GetUserID(hookCtx.Context)
+		hookRoles, _ = security.GetUserRoles(hookCtx.Context)
+		return nil
+	})
+	router := setupStandaloneRouter(resolveSpecHandler, restHeadSpecHandler)
+
+	read := func(token string) *httptest.ResponseRecorder {
+		query := url.Values{"$filter": {fmt.Sprintf("startswith(id,'%s')", prefix)}}
+		req := httptest.NewRequest("GET", "/restheadspec/departments?"+query.Encode(), nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	departmentIDs := func(rec *httptest.ResponseRecorder) []string {
+		var response struct {
+			Value []testmodels.Department `json:"value"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		ids := make([]string, 0, len(response.Value))
+		for _, dept := range response.Value {
+			ids = append(ids, dept.ID)
+		}
+		return ids
+	}
+
+	t.Run("unauthenticated requests are rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, read("").Code)
+		assert.Equal(t, http.StatusUnauthorized, read("not-a-number").Code)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("DELETE", "/restheadspec/departments/"+prefix+"_a", nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		var count int64
+		require.NoError(t, db.Model(&testmodels.Department{}).Where("id = ?", prefix+"_a").Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("hooks see the user", func(t *testing.T) {
+		rec := read("1")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, 1, hookUserID)
+		assert.Equal(t, "reader", hookRoles)
+		assert.ElementsMatch(t, []string{prefix + "_a", prefix + "_b"}, departmentIDs(rec))
+	})
+
+	t.Run("row security applies per user", func(t *testing.T) {
+		rec := read("2")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, []string{prefix + "_a"}, departmentIDs(rec))
+	})
+}
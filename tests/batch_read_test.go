@@ -0,0 +1,55 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// TestBatchReadByIDs reads records by id list in request order on both APIs
+func TestBatchReadByIDs(t *testing.T) {
+	api := newAPIServer(t, "batch_read",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+		"INSERT INTO sql_notes (title, stars) VALUES ('one', 1), ('two', 2), ('three', 3)",
+	)
+
+	api.register("sql_notes", sqlNote{})
+	api.serve(common.HandlerConfig{})
+
+	send := api.send
+	type result struct {
+		common.BatchReadResult
+		Data *sqlNote `json:"data"`
+	}
+
+	rec := send("GET", "/restheadspec/sql_notes", "", map[string]string{"X-IDs": "3,99,1", "X-Limit": "1"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var results []result
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+	require.Len(t, results, 3)
+	assert.Equal(t, "three", results[0].Data.Title)
+	assert.Equal(t, "99", results[1].ID)
+	assert.False(t, results[1].Found)
+	assert.Equal(t, "not found", results[1].Error)
+	assert.Equal(t, "one", results[2].Data.Title)
+
+	rec = send("POST", "/resolvespec/sql_notes", `{"operation":"read","ids":[2,"1"]}`, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Data []result `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Data, 2)
+	assert.Equal(t, "two", response.Data[0].Data.Title)
+	assert.Equal(t, "one", response.Data[1].Data.Title)
+
+	ids := make([]int, common.MaxBatchReadIDs+1)
+	body, err := json.Marshal(map[string]interface{}{"operation": "read", "ids": ids})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/resolvespec/sql_notes", string(body), nil).Code)
+}
@@ -0,0 +1,144 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+	"github.com/bitechdev/ResolveSpec/pkg/testmodels"
+)
+
+// TestBatchOperations runs mixed create/update/delete batches through both APIs
+func TestBatchOperations(t *testing.T) {
+	db, err := setupStandaloneDB()
+	require.NoError(t, err)
+	defer cleanupStandaloneDB(db)
+
+	resolveSpecHandler, restHeadSpecHandler := setupStandaloneHandlers(db)
+	restHeadSpecHandler.Hooks().Register(restheadspec.BeforeCreate, func(hookCtx *restheadspec.HookContext) error {
+		if data, ok := hookCtx.Data.(map[string]interface{}); ok && data["name"] == "Blocked" {
+			return errors.New("blocked department")
+		}
+		return nil
+	})
+	router := setupStandaloneRouter(resolveSpecHandler, restHeadSpecHandler)
+
+	prefix := fmt.Sprintf("batch_%d", time.Now().UnixNano())
+	id := func(n int) string { return fmt.Sprintf("%s_%d", prefix, n) }
+	department := func(n int, name string) map[string]interface{} {
+		return map[string]interface{}{"id": id(n), "name": name, "code": id(n)}
+	}
+
+	require.NoError(t, db.Create(&testmodels.Department{ID: id(0), Name: "Existing", Code: id(0)}).Error)
+	require.NoError(t, db.Create(&testmodels.Department{ID: id(9), Name: "Obsolete", Code: id(9)}).Error)
+
+	post := func(path string, body interface{}) (int, common.BatchResult) {
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("POST", path, bytes.NewReader(payload)))
+
+		var result common.BatchResult
+		if path == "/resolvespec/departments" {
+			var response struct {
+				Data common.BatchResult `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response), rec.Body.String())
+			result = response.Data
+		} else {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result), rec.Body.String())
+		}
+		return rec.Code, result
+	}
+	exists := func(n int) bool {
+		var count int64
+		require.NoError(t, db.Model(&testmodels.Department{}).Where("id = ?", id(n)).Count(&count).Error)
+		return count > 0
+	}
+
+	t.Run("atomic mixed batch", func(t *testing.T) {
+		status, result := post("/restheadspec/departments/_batch", []interface{}{
+			map[string]interface{}{"action": "create", "data": department(1, "Sales")},
+			map[string]interface{}{"action": "update", "id": id(0), "data": map[string]interface{}{"name": "Renamed"}},
+			map[string]interface{}{"action": "delete", "id": id(9)},
+		})
+		assert.Equal(t, http.StatusOK, status)
+		assert.True(t, result.Atomic)
+		assert.Equal(t, 3, result.Succeeded)
+		assert.Equal(t, int64(1), result.Results[1].AffectedRows)
+
+		var existing testmodels.Department
+		require.NoError(t, db.First(&existing, "id = ?", id(0)).Error)
+		assert.Equal(t, "Renamed", existing.Name)
+		assert.True(t, exists(1))
+		assert.False(t, exists(9))
+	})
+
+	t.Run("atomic batch rolls back on failure", func(t *testing.T) {
+		status, result := post("/restheadspec/departments/_batch", []interface{}{
+			map[string]interface{}{"action": "create", "data": department(2, "Support")},
+			map[string]interface{}{"action": "create", "data": department(1, "Duplicate")},
+			map[string]interface{}{"action": "delete", "id": id(0)},
+		})
+		assert.Equal(t, http.StatusUnprocessableEntity, status)
+		assert.True(t, result.RolledBack)
+		assert.Equal(t, 3, result.Failed)
+		assert.Equal(t, "rolled back", result.Results[0].Error)
+		assert.NotEmpty(t, result.Results[1].Error)
+		assert.Equal(t, "not executed", result.Results[2].Error)
+		assert.False(t, exists(2))
+		assert.True(t, exists(0))
+	})
+
+	t.Run("partial batch reports per-item results", func(t *testing.T) {
+		status, result := post("/restheadspec/departments/_batch", map[string]interface{}{
+			"atomic": false,
+			"items": []interface{}{
+				map[string]interface{}{"action": "create", "data": department(3, "Blocked")},
+				map[string]interface{}{"action": "create", "data": department(4, "Legal")},
+			},
+		})
+		assert.Equal(t, http.StatusMultiStatus, status)
+		assert.False(t, result.RolledBack)
+		assert.Equal(t, 1, result.Succeeded)
+		assert.Contains(t, result.Results[0].Error, "blocked department", "hooks run for batch items")
+		assert.True(t, result.Results[1].Success)
+		assert.False(t, exists(3))
+		assert.True(t, exists(4))
+	})
+
+	t.Run("resolvespec batch operation", func(t *testing.T) {
+		status, result := post("/resolvespec/departments", map[string]interface{}{
+			"operation": "batch",
+			"data": map[string]interface{}{
+				"atomic": false,
+				"items": []interface{}{
+					map[string]interface{}{"action": "create", "data": department(5, "Research")},
+					map[string]interface{}{"action": "update", "data": map[string]interface{}{"name": "No ID"}},
+					map[string]interface{}{"action": "delete", "id": id(4)},
+				},
+			},
+		})
+		assert.Equal(t, http.StatusMultiStatus, status)
+		assert.Equal(t, 2, result.Succeeded)
+		assert.Contains(t, result.Results[1].Error, "requires an ID")
+		assert.True(t, exists(5))
+		assert.False(t, exists(4))
+	})
+
+	t.Run("invalid batch", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("POST", "/restheadspec/departments/_batch", bytes.NewBufferString(`[{"action":"merge"}]`)))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
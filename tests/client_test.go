@@ -0,0 +1,79 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/client"
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/testmodels"
+)
+
+// TestTypedClient runs the typed client against a RestHeadSpec handler
+func TestTypedClient(t *testing.T) {
+	db, err := setupStandaloneDB()
+	require.NoError(t, err)
+	defer cleanupStandaloneDB(db)
+
+	server := httptest.NewServer(setupStandaloneRouter(setupStandaloneHandlers(db)))
+	defer server.Close()
+
+	ctx := context.Background()
+	departments := client.NewClient[testmodels.Department](client.Config{
+		BaseURL: server.URL + "/restheadspec",
+		Entity:  "departments",
+	})
+
+	suffix := time.Now().UnixNano()
+	for idx, name := range []string{"Finance", "Facilities", "Legal"} {
+		created, err := departments.Create(ctx, testmodels.Department{
+			ID:   fmt.Sprintf("dept_client_%d_%d", suffix, idx),
+			Name: name,
+			Code: fmt.Sprintf("CL_%d_%d", suffix, idx),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, name, created.Name)
+	}
+
+	result, err := departments.List(ctx, &client.QueryOptions{
+		Columns: []string{"id", "name"},
+		FilterGroup: &common.FilterGroup{
+			Logic: "OR",
+			Filters: []common.FilterOption{
+				{Column: "name", Operator: "eq", Value: "Finance"},
+				{Column: "name", Operator: "eq", Value: "Legal"},
+			},
+		},
+		Filters: []common.FilterOption{{Column: "code", Operator: "like", Value: fmt.Sprintf("CL_%d_%%", suffix)}},
+		Sort:    []common.SortOption{{Column: "name", Direction: "DESC"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, "Legal", result.Items[0].Name)
+	assert.Equal(t, "Finance", result.Items[1].Name)
+	assert.Empty(t, result.Items[0].Code, "only the selected columns are returned")
+	assert.Equal(t, int64(2), result.Metadata.Filtered)
+
+	id := fmt.Sprintf("dept_client_%d_0", suffix)
+	patched, err := departments.Patch(ctx, id, map[string]interface{}{"description": "Money"})
+	require.NoError(t, err)
+	assert.Equal(t, "Money", patched.Description)
+
+	fetched, err := departments.Get(ctx, id, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Finance", fetched.Name)
+	assert.Equal(t, "Money", fetched.Description)
+
+	deleted, err := departments.Delete(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = departments.Get(ctx, id, nil)
+	assert.ErrorIs(t, err, client.ErrNotFound)
+}
@@ -0,0 +1,55 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/router"
+	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+type sizedNote struct {
+	ID    int64   `json:"id" gorm:"primaryKey"`
+	Title string  `json:"title" gorm:"size:80;comment:Shown in lists"`
+	Score float64 `json:"score" gorm:"type:numeric(5,2);default:0"`
+	State string  `json:"state"`
+}
+
+// TestColumnDetailMetadata reports defaults, comments and sizes from struct tags and from
+// the DescribeColumns pass in the metadata of HandleGet
+func TestColumnDetailMetadata(t *testing.T) {
+	registry := modelregistry.NewModelRegistry()
+	require.NoError(t, registry.RegisterModel("sized_notes", sizedNote{}))
+	handler := restheadspec.NewHandlerWithConfig(nil, registry, common.HandlerConfig{
+		DescribeColumns: func(ctx context.Context, schema, table string) ([]common.Column, error) {
+			return []common.Column{{Name: "state", DefaultValue: "'draft'", MaxLength: 16}}, nil
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/restheadspec/sized_notes", nil)
+	handler.HandleGet(router.NewHTTPResponseWriter(rec), router.NewHTTPRequest(req), map[string]string{"schema": "", "entity": "sized_notes"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var metadata common.TableMetadata
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &metadata))
+
+	columns := make(map[string]common.Column, len(metadata.Columns))
+	for _, column := range metadata.Columns {
+		columns[column.Name] = column
+	}
+	assert.Equal(t, 80, columns["title"].MaxLength)
+	assert.Equal(t, "Shown in lists", columns["title"].Comment)
+	assert.Equal(t, "0", columns["score"].DefaultValue)
+	assert.Equal(t, 5, columns["score"].Precision)
+	assert.Equal(t, 2, columns["score"].Scale)
+	assert.Equal(t, "'draft'", columns["state"].DefaultValue, "described by the database")
+	assert.Equal(t, 16, columns["state"].MaxLength)
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type orderLine struct {
+	OrderID  int64  `json:"order_id" gorm:"column:order_id;primaryKey"`
+	LineNo   int64  `json:"line_no" gorm:"column:line_no;primaryKey"`
+	Product  string `json:"product" gorm:"column:product"`
+	Quantity int64  `json:"quantity" gorm:"column:quantity"`
+}
+
+func (orderLine) TableName() string { return "order_lines" }
+
+// TestCompositeKeys reads, updates and deletes records of a composite primary key
+func TestCompositeKeys(t *testing.T) {
+	api := newAPIServer(t, "composite_keys",
+		"CREATE TABLE order_lines (order_id INTEGER NOT NULL, line_no INTEGER NOT NULL, product TEXT NOT NULL, quantity INTEGER NOT NULL DEFAULT 0, PRIMARY KEY (order_id, line_no))",
+		"INSERT INTO order_lines (order_id, line_no, product, quantity) VALUES (1, 1, 'bolt', 10), (1, 2, 'nut', 20), (2, 1, 'washer', 30), (2, 2, 'screw', 40)",
+	)
+
+	api.register("order_lines", orderLine{})
+	api.serve(common.HandlerConfig{})
+
+	send := api.send
+	quantity := func(orderID, lineNo int) int64 {
+		var n int64
+		require.NoError(t, api.DB.QueryRow("SELECT quantity FROM order_lines WHERE order_id = ? AND line_no = ?", orderID, lineNo).Scan(&n))
+		return n
+	}
+	lines := func() int {
+		var n int
+		require.NoError(t, api.DB.QueryRow("SELECT COUNT(*) FROM order_lines").Scan(&n))
+		return n
+	}
+
+	rec := send("GET", "/restheadspec/order_lines/1,2", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"product":"nut"`)
+	assert.NotContains(t, rec.Body.String(), `"bolt"`)
+
+	rec = send("GET", "/restheadspec/order_lines", "", map[string]string{common.PKHeader: `{"order_id":2,"line_no":1}`})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"product":"washer"`)
+	assert.NotContains(t, rec.Body.String(), `"screw"`)
+
+	rec = send("GET", "/restheadspec/order_lines/1", "", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "a composite key needs all its values")
+
+	rec = send("PATCH", "/restheadspec/order_lines/2,2", `{"quantity":41}`, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, int64(41), quantity(2, 2))
+	assert.Equal(t, int64(30), quantity(2, 1))
+
+	rec = send("POST", "/resolvespec/order_lines/1,1", `{"operation":"read"}`, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"product":"bolt"`)
+	assert.NotContains(t, rec.Body.String(), `"nut"`)
+
+	rec = send("POST", "/resolvespec/order_lines", `{"operation":"update","data":{"quantity":11}}`, map[string]string{common.PKHeader: "1,1"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, int64(11), quantity(1, 1))
+	assert.Equal(t, int64(20), quantity(1, 2))
+
+	rec = send("DELETE", "/restheadspec/order_lines/1,2", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, 3, lines())
+
+	rec = send("POST", "/resolvespec/order_lines", `{"operation":"delete","data":[{"order_id":2,"line_no":1}]}`, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, 2, lines())
+	assert.Equal(t, int64(41), quantity(2, 2))
+}
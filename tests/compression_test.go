@@ -0,0 +1,79 @@
+package test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type compressedNote struct {
+	ID   int64  `json:"id" gorm:"column:id;primaryKey"`
+	Body string `json:"body" gorm:"column:body"`
+}
+
+func (compressedNote) TableName() string {
+	return "compressed_notes"
+}
+
+// TestResponseCompression compresses large responses with the encoding accepted by the client
+func TestResponseCompression(t *testing.T) {
+	api := newAPIServer(t, "compression",
+		"CREATE TABLE compressed_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, body TEXT NOT NULL)",
+	)
+	for i := 0; i < 50; i++ {
+		_, err := api.DB.Exec("INSERT INTO compressed_notes (body) VALUES (?)", fmt.Sprintf("note %d of a long list of notes", i))
+		require.NoError(t, err)
+	}
+
+	api.register("notes", compressedNote{})
+	config := common.HandlerConfig{Compression: &common.CompressionConfig{MinSize: 512}}
+	api.serve(config)
+	send := func(method, path, body, acceptEncoding string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		api.Router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) []interface{} {
+		t.Helper()
+		body := io.Reader(rec.Body)
+		if rec.Header().Get("Content-Encoding") == "gzip" {
+			reader, err := gzip.NewReader(rec.Body)
+			require.NoError(t, err)
+			body = reader
+		}
+		var records []interface{}
+		require.NoError(t, json.NewDecoder(body).Decode(&records))
+		return records
+	}
+
+	list := send("GET", "/restheadspec/notes", "", "gzip, deflate")
+	assert.Equal(t, "gzip", list.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", list.Header().Get("Vary"))
+	assert.Len(t, decode(list), 50)
+
+	plain := send("GET", "/restheadspec/notes", "", "")
+	assert.Empty(t, plain.Header().Get("Content-Encoding"))
+	assert.Len(t, decode(plain), 50)
+
+	small := send("GET", "/restheadspec/notes/1", "", "gzip")
+	assert.Empty(t, small.Header().Get("Content-Encoding"), "responses below the minimum size are sent uncompressed")
+
+	read := send("POST", "/resolvespec/notes", `{"operation":"read"}`, "gzip")
+	assert.Equal(t, "gzip", read.Header().Get("Content-Encoding"))
+}
@@ -0,0 +1,114 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+	"github.com/bitechdev/ResolveSpec/pkg/testmodels"
+)
+
+// cqlDepartment is the departments table with the scan-only fields computed columns are read into
+type cqlDepartment struct {
+	ID   string `json:"id" gorm:"column:id;primaryKey"`
+	Name string `json:"name" gorm:"column:name"`
+	Code string `json:"code" gorm:"column:code"`
+	CQL1 string `json:"cql1,omitempty" gorm:"column:cql1;->" bun:",scanonly"`
+	CQL2 string `json:"cql2,omitempty" gorm:"column:cql2;->" bun:",scanonly"`
+}
+
+func (cqlDepartment) TableName() string {
+	return "departments"
+}
+
+// TestComputedQL selects computed columns into the CQL fields of the model and
+// replaces columns with advanced SQL expressions
+func TestComputedQL(t *testing.T) {
+	db, err := setupStandaloneDB()
+	require.NoError(t, err)
+	defer cleanupStandaloneDB(db)
+
+	deptID := fmt.Sprintf("cql_%d", time.Now().UnixNano())
+	require.NoError(t, db.Create(&testmodels.Department{ID: deptID, Name: "Sales", Code: "S1"}).Error)
+
+	registry := modelregistry.NewModelRegistry()
+	require.NoError(t, registry.RegisterModel("departments", cqlDepartment{}))
+	resolveSpecHandler, _ := setupStandaloneHandlers(db)
+	restHeadSpecHandler := restheadspec.NewHandler(database.NewGormAdapter(db), registry)
+	router := setupStandaloneRouter(resolveSpecHandler, restHeadSpecHandler)
+
+	read := func(headers map[string]string) cqlDepartment {
+		req := httptest.NewRequest("GET", "/restheadspec/departments/"+deptID, nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var dept cqlDepartment
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &dept))
+		return dept
+	}
+
+	t.Run("expressions are selected into the cql fields", func(t *testing.T) {
+		dept := read(map[string]string{
+			"X-Cql-Sel-Cql1": "upper(name) || '-' || code",
+			"X-Cql-Sel-Cql2": "length(name) * 2",
+		})
+		assert.Equal(t, deptID, dept.ID)
+		assert.Equal(t, "Sales", dept.Name)
+		assert.Equal(t, "SALES-S1", dept.CQL1)
+		assert.Equal(t, "10", dept.CQL2)
+	})
+
+	t.Run("invalid expressions and unknown aliases are dropped", func(t *testing.T) {
+		dept := read(map[string]string{
+			"X-Cql-Sel-Cql1":  "(select code from departments limit 1)",
+			"X-Cql-Sel-Cql2":  "name) AS cql2, (code",
+			"X-Cql-Sel-Total": "1",
+		})
+		assert.Equal(t, "Sales", dept.Name)
+		assert.Empty(t, dept.CQL1)
+		assert.Empty(t, dept.CQL2)
+	})
+
+	t.Run("advanced sql replaces the column", func(t *testing.T) {
+		dept := read(map[string]string{
+			"X-Advsql-Name": "upper(name) || ' (' || code || ')'",
+		})
+		assert.Equal(t, deptID, dept.ID)
+		assert.Equal(t, "SALES (S1)", dept.Name)
+		assert.Equal(t, "S1", dept.Code)
+	})
+
+	t.Run("invalid advanced sql keeps the column", func(t *testing.T) {
+		dept := read(map[string]string{
+			"X-Advsql-Name": "name; delete from departments",
+			"X-Advsql-Code": "(select name from employees limit 1)",
+		})
+		assert.Equal(t, "Sales", dept.Name)
+		assert.Equal(t, "S1", dept.Code)
+	})
+
+	t.Run("advanced sql may not call server functions", func(t *testing.T) {
+		for _, expr := range []string{
+			"pg_sleep_for('1 hour')",
+			"pg_read_file('/etc/passwd')",
+			"pg_terminate_backend(1)",
+			"set_config('role','x',false)",
+		} {
+			dept := read(map[string]string{"X-Advsql-Name": expr})
+			assert.Equal(t, "Sales", dept.Name, expr)
+		}
+	})
+}
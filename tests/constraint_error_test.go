@@ -0,0 +1,63 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// TestConstraintViolationResponses answers unique violations of every adapter with 409
+func TestConstraintViolationResponses(t *testing.T) {
+	api := newAPIServer(t, "constraint_errors",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL UNIQUE, stars INTEGER NOT NULL DEFAULT 0)",
+		"INSERT INTO sql_notes (title) VALUES ('taken')",
+	)
+
+	gormDB, err := gorm.Open(sqlite.Dialector{Conn: api.DB}, &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	require.NoError(t, err)
+	adapters := map[string]common.Database{
+		"gorm": database.NewGormAdapter(gormDB),
+		"bun":  database.NewBunAdapter(bun.NewDB(api.DB, sqlitedialect.New())),
+		"sql":  database.NewSQLAdapter(api.DB, "sqlite"),
+	}
+
+	for name, adapter := range adapters {
+		t.Run(name, func(t *testing.T) {
+			registry := modelregistry.NewModelRegistry()
+			require.NoError(t, registry.RegisterModel("sql_notes", sqlNote{}))
+			router := setupStandaloneRouter(resolvespec.NewHandler(adapter, registry), restheadspec.NewHandler(adapter, registry))
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest("POST", "/resolvespec/sql_notes", bytes.NewBufferString(`{"operation":"create","data":{"title":"taken"}}`)))
+			require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+			var response common.Response
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, common.UniqueViolation, response.Error.Code)
+			assert.Equal(t, "title", response.Error.Column)
+
+			rec = httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest("POST", "/restheadspec/sql_notes", bytes.NewBufferString(`{"title":"taken"}`)))
+			require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+			var headSpecError map[string]interface{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &headSpecError))
+			assert.Equal(t, common.UniqueViolation, headSpecError["_code"])
+			assert.Equal(t, "title", headSpecError["_column"])
+		})
+	}
+}
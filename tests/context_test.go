@@ -0,0 +1,72 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// TestRequestContextPropagation verifies that the handlers run their queries with the
+// context of the incoming request, so client disconnects cancel in-flight database calls.
+func TestRequestContextPropagation(t *testing.T) {
+	db, err := setupStandaloneDB()
+	require.NoError(t, err)
+	defer cleanupStandaloneDB(db)
+
+	router := setupStandaloneRouter(setupStandaloneHandlers(db))
+
+	newRequest := func(ctx context.Context, method, path, body string) *http.Request {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body)).WithContext(ctx)
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	tests := []struct {
+		name   string
+		req    *http.Request
+		status int
+	}{
+		{
+			name:   "resolvespec canceled",
+			req:    newRequest(canceled, "POST", "/resolvespec/departments", `{"operation":"read"}`),
+			status: common.StatusClientClosedRequest,
+		},
+		{
+			name:   "restheadspec canceled",
+			req:    newRequest(canceled, "GET", "/restheadspec/departments", ""),
+			status: common.StatusClientClosedRequest,
+		},
+		{
+			name:   "restheadspec deadline exceeded",
+			req:    newRequest(expired, "GET", "/restheadspec/departments", ""),
+			status: http.StatusGatewayTimeout,
+		},
+		{
+			name:   "restheadspec active context",
+			req:    newRequest(context.Background(), "GET", "/restheadspec/departments", ""),
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, tt.req)
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// TestCountStrategies keeps the totals of cached entities until a write through the handler
+// and counts estimated entities exactly on databases without planner estimates
+func TestCountStrategies(t *testing.T) {
+	api := newAPIServer(t, "count_strategies",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+		"INSERT INTO sql_notes (title, stars) VALUES ('a', 1), ('b', 1), ('c', 2)",
+	)
+
+	api.register("sql_notes", sqlNote{})
+	newRouter := func(strategy common.CountStrategy) http.Handler {
+		config := common.HandlerConfig{
+			Entities:   map[string]common.EntityConfig{"sql_notes": {CountStrategy: strategy}},
+			CountCache: common.NewCountCache(),
+		}
+		return api.serve(config).Router
+	}
+	total := func(router http.Handler, headers map[string]string) common.Metadata {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/restheadspec/sql_notes", nil)
+		req.Header.Set("X-DetailApi", "true")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var detail struct {
+			Metadata common.Metadata `json:"metadata"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+		return detail.Metadata
+	}
+	resolveTotal := func(router http.Handler) int64 {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/resolvespec/sql_notes", bytes.NewBufferString(`{"operation":"read"}`))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var response struct {
+			Metadata common.Metadata `json:"metadata"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		return response.Metadata.Total
+	}
+
+	cached := newRouter(common.CountCached)
+	assert.EqualValues(t, 3, total(cached, nil).Total)
+	assert.EqualValues(t, 2, total(cached, map[string]string{"X-Searchop-Eq-Stars": "1"}).Total, "totals are cached per filter")
+	assert.EqualValues(t, 3, resolveTotal(cached))
+
+	_, err := api.DB.Exec("INSERT INTO sql_notes (title, stars) VALUES ('d', 1)")
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, total(cached, nil).Total, "writes outside the handler wait for the TTL")
+	assert.EqualValues(t, 3, resolveTotal(cached))
+
+	req := httptest.NewRequest("POST", "/restheadspec/sql_notes", bytes.NewBufferString(`{"title":"e","stars":2}`))
+	rec := httptest.NewRecorder()
+	cached.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	metadata := total(cached, nil)
+	assert.EqualValues(t, 5, metadata.Total, "writes through the handler drop the cached totals")
+	assert.False(t, metadata.Estimated)
+	assert.EqualValues(t, 3, total(cached, map[string]string{"X-Searchop-Eq-Stars": "1"}).Total)
+	assert.EqualValues(t, 5, resolveTotal(cached), "handlers of a config share their count cache")
+
+	metadata = total(newRouter(common.CountEstimate), nil)
+	assert.EqualValues(t, 5, metadata.Total, "SQLite has no planner estimate and counts exactly")
+	assert.False(t, metadata.Estimated)
+}
@@ -148,7 +148,7 @@ func setupStandaloneRouter(resolveSpecHandler *resolvespec.Handler, restHeadSpec
 		reqAdapter := router.NewHTTPRequest(req)
 		respAdapter := router.NewHTTPResponseWriter(w)
 		restHeadSpecHandler.Handle(respAdapter, reqAdapter, vars)
-	}).Methods("GET", "POST")
+	}).Methods("GET", "POST", "PUT", "PATCH", "DELETE")
 
 	restHeadSpecRouter.HandleFunc("/{entity}/{id}", func(w http.ResponseWriter, req *http.Request) {
 		vars := mux.Vars(req)
@@ -156,7 +156,7 @@ func setupStandaloneRouter(resolveSpecHandler *resolvespec.Handler, restHeadSpec
 		reqAdapter := router.NewHTTPRequest(req)
 		respAdapter := router.NewHTTPResponseWriter(w)
 		restHeadSpecHandler.Handle(respAdapter, reqAdapter, vars)
-	}).Methods("GET", "PUT", "PATCH", "DELETE")
+	}).Methods("GET", "PUT", "PATCH", "DELETE", "POST")
 
 	logger.Info("Router setup completed")
 	return r
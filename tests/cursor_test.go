@@ -0,0 +1,87 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/router"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// TestCursorPagination pages forward with cursors through a sort on an expanded relation and
+// columns with equal values, visiting every row once
+func TestCursorPagination(t *testing.T) {
+	for _, name := range []string{"bun", "gorm"} {
+		t.Run(name, func(t *testing.T) {
+			api := newAPIServer(t, "cursor_"+name,
+				"CREATE TABLE departments (id INTEGER PRIMARY KEY, name TEXT NOT NULL, code TEXT NOT NULL DEFAULT '')",
+				"CREATE TABLE employees (id INTEGER PRIMARY KEY, first_name TEXT NOT NULL DEFAULT '', last_name TEXT NOT NULL DEFAULT '', status TEXT NOT NULL DEFAULT '', salary REAL NOT NULL, department_id INTEGER NOT NULL)",
+				"INSERT INTO departments (id, name) VALUES (1, 'Sales'), (2, 'Engineering'), (3, 'Marketing')",
+			)
+			for i := 1; i <= 20; i++ {
+				_, err := api.DB.Exec("INSERT INTO employees (id, salary, department_id) VALUES (?, ?, ?)", i, 1000*(i%3), 1+i%3)
+				require.NoError(t, err)
+			}
+
+			var db common.Database
+			if name == "bun" {
+				db = database.NewBunAdapter(bun.NewDB(api.DB, sqlitedialect.New()))
+			} else {
+				gormDB, err := gorm.Open(sqlite.Dialector{Conn: api.DB}, &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+				require.NoError(t, err)
+				db = database.NewGormAdapter(gormDB)
+			}
+			api.register("employees", goldenEmployee{}).register("departments", goldenDepartment{})
+			handler := restheadspec.NewHandler(db, api.Registry)
+
+			page := func(cursor string) []goldenEmployee {
+				t.Helper()
+				req := httptest.NewRequest("GET", "/employees", nil)
+				req.Header.Set("X-Expand", "Department")
+				req.Header.Set("X-Sort", "department.name,-salary")
+				req.Header.Set("X-Limit", "6")
+				if cursor != "" {
+					req.Header.Set("X-Cursor-Forward", cursor)
+				}
+				rec := httptest.NewRecorder()
+				handler.Handle(router.NewHTTPResponseWriter(rec), router.NewHTTPRequest(req), map[string]string{"entity": "employees"})
+				require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+				var employees []goldenEmployee
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &employees))
+				return employees
+			}
+
+			var visited []string
+			cursor := ""
+			for i := 0; i < 10; i++ {
+				employees := page(cursor)
+				if len(employees) == 0 {
+					break
+				}
+				for _, employee := range employees {
+					require.NotNil(t, employee.Department)
+					visited = append(visited, fmt.Sprintf("%s/%v/%d", employee.Department.Name, employee.Salary, employee.ID))
+				}
+				cursor = strconv.FormatInt(employees[len(employees)-1].ID, 10)
+			}
+			require.Len(t, visited, 20, "every employee is visited once: %v", visited)
+			assert.Equal(t, "Engineering/1000/1", visited[0])
+			assert.Equal(t, "Engineering/1000/4", visited[1], "equal sort values are ordered by id")
+			assert.Equal(t, "Sales/0/18", visited[19])
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// TestCustomSQLPolicy applies custom SQL conditions on the columns of the model and rejects
+// others when the handler checks custom SQL
+func TestCustomSQLPolicy(t *testing.T) {
+	api := newAPIServer(t, "custom_sql_policy",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+		"CREATE TABLE sql_secrets (id INTEGER PRIMARY KEY, secret TEXT NOT NULL)",
+		"INSERT INTO sql_notes (title, stars) VALUES ('Alpha', 1), ('beta', 2), ('Gamma', 3)",
+	)
+
+	api.register("sql_notes", sqlNote{})
+	config := common.HandlerConfig{CustomSQL: &common.CustomSQLPolicy{}}
+	api.serve(config)
+
+	read := func(headers map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/restheadspec/sql_notes", nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := read(map[string]string{"X-Custom-SQL-W": "stars >= 2 AND lower(sql_notes.title) <> 'beta'", "X-Custom-SQL-Or": "title = 'Alpha'"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var notes []sqlNote
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &notes))
+	titles := make([]string, 0, len(notes))
+	for _, note := range notes {
+		titles = append(titles, note.Title)
+	}
+	assert.ElementsMatch(t, []string{"Alpha", "Gamma"}, titles)
+
+	for _, headers := range []map[string]string{
+		{"X-Custom-SQL-W": "id IN (SELECT id FROM sql_secrets)"},
+		{"X-Custom-SQL-W": "stars = 1; DELETE FROM sql_notes"},
+		{"X-Custom-SQL-Or": "secret = 'x'"},
+		{"X-Custom-SQL-W": "randomblob(8) IS NOT NULL"},
+	} {
+		rec := read(headers)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, headers)
+		assert.Contains(t, rec.Body.String(), "invalid_custom_sql")
+	}
+
+	var count int
+	require.NoError(t, api.DB.QueryRow("SELECT COUNT(*) FROM sql_notes").Scan(&count))
+	assert.Equal(t, 3, count)
+}
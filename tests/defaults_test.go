@@ -0,0 +1,113 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type defaultTicket struct {
+	ID     int64  `json:"id" bun:"id,pk,autoincrement"`
+	Status string `json:"status" bun:"status"`
+	Rank   int    `json:"rank" bun:"rank"`
+}
+
+func (defaultTicket) TableName() string { return "default_tickets" }
+
+func (defaultTicket) DefaultSort() []common.SortOption {
+	return []common.SortOption{{Column: "rank", Direction: "asc"}, {Column: "id", Direction: "desc"}}
+}
+
+func (defaultTicket) DefaultFilters() []common.FilterOption {
+	return []common.FilterOption{{Column: "status", Operator: "neq", Value: "archived"}}
+}
+
+// TestModelDefaults orders and filters lists without a sort or filters by the defaults of the model
+func TestModelDefaults(t *testing.T) {
+	api := newAPIServer(t, "model_defaults",
+		"CREATE TABLE default_tickets (id INTEGER PRIMARY KEY AUTOINCREMENT, status TEXT NOT NULL, rank INTEGER NOT NULL)",
+		"INSERT INTO default_tickets (status, rank) VALUES ('open', 2), ('archived', 1), ('open', 1), ('closed', 2)",
+	)
+
+	api.register("default_tickets", defaultTicket{})
+	api.serve(common.HandlerConfig{})
+
+	read := func(headers map[string]string) []int64 {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/restheadspec/default_tickets", nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var tickets []defaultTicket
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tickets))
+		ids := make([]int64, 0, len(tickets))
+		for _, ticket := range tickets {
+			ids = append(ids, ticket.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []int64{3, 4, 1}, read(nil))
+	assert.Equal(t, []int64{1, 3, 4}, read(map[string]string{"X-Sort": "id"}), "the sort of the request wins")
+	assert.Equal(t, []int64{2}, read(map[string]string{"X-Searchop-Eq-Status": "archived"}), "filters of the request replace the defaults")
+
+	rec := httptest.NewRecorder()
+	api.Router.ServeHTTP(rec, httptest.NewRequest("GET", "/restheadspec/default_tickets/2", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "reads by id skip the default filters")
+
+	rec = httptest.NewRecorder()
+	api.Router.ServeHTTP(rec, httptest.NewRequest("POST", "/resolvespec/default_tickets", strings.NewReader(`{"operation":"read"}`)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Data []defaultTicket `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Data, 3)
+	assert.Equal(t, []int64{3, 4, 1}, []int64{response.Data[0].ID, response.Data[1].ID, response.Data[2].ID})
+}
+
+// TestPrimaryKeyTieBreaker pages through rows with equal sort values without skipping or repeating one
+func TestPrimaryKeyTieBreaker(t *testing.T) {
+	api := newAPIServer(t, "primary_key_tie_breaker",
+		"CREATE TABLE scoped_tickets (id INTEGER PRIMARY KEY AUTOINCREMENT, status TEXT NOT NULL, priority INTEGER NOT NULL)",
+	)
+	for i := 0; i < 12; i++ {
+		_, err := api.DB.Exec("INSERT INTO scoped_tickets (status, priority) VALUES ('open', ?)", i%2)
+		require.NoError(t, err)
+	}
+
+	api.register("scoped_tickets", scopedTicket{})
+	api.serve(common.HandlerConfig{})
+
+	seen := make(map[int64]bool)
+	for offset := 0; offset < 12; offset += 5 {
+		req := httptest.NewRequest("GET", "/restheadspec/scoped_tickets", nil)
+		req.Header.Set("X-Sort", "-priority")
+		req.Header.Set("X-Limit", "5")
+		req.Header.Set("X-Offset", strconv.Itoa(offset))
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var tickets []scopedTicket
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tickets))
+		for i, ticket := range tickets {
+			assert.False(t, seen[ticket.ID], "ticket %d repeated", ticket.ID)
+			seen[ticket.ID] = true
+			if i > 0 && tickets[i-1].Priority == ticket.Priority {
+				assert.Less(t, tickets[i-1].ID, ticket.ID, "equal priorities are ordered by id")
+			}
+		}
+	}
+	assert.Len(t, seen, 12)
+}
@@ -0,0 +1,86 @@
+package test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeleteWhere deletes the rows matching filters with the bulk confirmation header
+func TestDeleteWhere(t *testing.T) {
+	api := newAPIServer(t, "delete_where",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+		"INSERT INTO sql_notes (title, stars) VALUES ('a', 1), ('b', 2), ('c', 3), ('d', 4), ('e', 5), ('f', 6)",
+	)
+
+	api.register("sql_notes", sqlNote{})
+	api.serve(common.HandlerConfig{})
+
+	send := api.send
+	titles := func() string {
+		rows, err := api.DB.Query("SELECT title FROM sql_notes ORDER BY id")
+		require.NoError(t, err)
+		defer rows.Close()
+		var list []string
+		for rows.Next() {
+			var title string
+			require.NoError(t, rows.Scan(&title))
+			list = append(list, title)
+		}
+		return strings.Join(list, ",")
+	}
+	confirmed := map[string]string{"X-Confirm-Bulk": "true"}
+
+	// restheadspec deletes the rows matching the filters of the body
+	lowStars := `{"filters":[{"column":"stars","operator":"lte","value":2}]}`
+	rec := send("DELETE", "/restheadspec/sql_notes", lowStars, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "unconfirmed_delete")
+	assert.Equal(t, "a,b,c,d,e,f", titles())
+
+	rec = send("DELETE", "/restheadspec/sql_notes", `{"filters":[{"column":"stars","operator":"lte","value":2}],"dry_run":true}`, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"matched":2`)
+	assert.Equal(t, "a,b,c,d,e,f", titles(), "a dry run deletes nothing")
+
+	rec = send("DELETE", "/restheadspec/sql_notes", lowStars, confirmed)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"deleted":2`)
+	assert.Equal(t, "c,d,e,f", titles())
+
+	// Filters on unknown columns fail instead of widening the delete
+	rec = send("DELETE", "/restheadspec/sql_notes", `{"filters":[{"column":"missing","operator":"eq","value":1}]}`, confirmed)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "c,d,e,f", titles())
+
+	// Exceeding the row limit rolls the delete back
+	rec = send("DELETE", "/restheadspec/sql_notes", `{"filters":[{"column":"stars","operator":"gte","value":3}]}`, map[string]string{
+		"X-Confirm-Bulk": "true",
+		"X-Write-Limit":  "2",
+	})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "c,d,e,f", titles())
+
+	// Batch deletes by id keep working
+	rec = send("DELETE", "/restheadspec/sql_notes", `["3"]`, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "d,e,f", titles())
+
+	// resolvespec deletes the rows matching the filters of options
+	rec = send("POST", "/resolvespec/sql_notes", `{"operation":"delete_where","options":{"filters":[{"column":"stars","operator":"eq","value":4}]}}`, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "d,e,f", titles())
+
+	rec = send("POST", "/resolvespec/sql_notes", `{"operation":"delete_where","options":{"filters":[{"column":"stars","operator":"gte","value":5}]},"dry_run":true}`, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"matched":2`)
+
+	rec = send("POST", "/resolvespec/sql_notes", `{"operation":"delete_where","options":{"filters":[{"column":"stars","operator":"eq","value":4}]}}`, confirmed)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"deleted":1`)
+	assert.Equal(t, "e,f", titles())
+}
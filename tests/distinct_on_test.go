@@ -0,0 +1,38 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type distinctVisit struct {
+	ID     int64  `json:"id" bun:"id,pk,autoincrement"`
+	Person string `json:"person" bun:"person"`
+	Place  string `json:"place" bun:"place"`
+}
+
+func (distinctVisit) TableName() string { return "distinct_visits" }
+
+// TestDistinctOnUnsupported answers 400 for x-distinct-on on databases without DISTINCT ON
+func TestDistinctOnUnsupported(t *testing.T) {
+	api := newAPIServer(t, "distinct_on",
+		"CREATE TABLE distinct_visits (id INTEGER PRIMARY KEY AUTOINCREMENT, person TEXT NOT NULL, place TEXT NOT NULL)",
+		"INSERT INTO distinct_visits (person, place) VALUES ('ann', 'home'), ('ann', 'work')",
+	)
+
+	api.register("distinct_visits", distinctVisit{})
+	api.serve(common.HandlerConfig{})
+
+	send := api.send
+
+	rec := send("GET", "/restheadspec/distinct_visits", "", map[string]string{"X-Distinct-On": "person"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "unsupported_feature")
+	assert.Contains(t, rec.Body.String(), "DISTINCT ON is not supported by the sqlite database")
+
+	rec = send("GET", "/restheadspec/distinct_visits", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+)
+
+// distinctRow is a test model for GORM DISTINCT queries
+type distinctRow struct {
+	ID         int64  `gorm:"column:id;primaryKey"`
+	Department string `gorm:"column:department"`
+	Status     string `gorm:"column:status"`
+}
+
+func (distinctRow) TableName() string {
+	return "distinct_rows"
+}
+
+func setupDistinctDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file:distinct?mode=memory"), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&distinctRow{}))
+	rows := []distinctRow{
+		{Department: "sales", Status: "active"},
+		{Department: "sales", Status: "active"},
+		{Department: "sales", Status: "inactive"},
+		{Department: "support", Status: "active"},
+	}
+	require.NoError(t, db.Create(&rows).Error)
+	return db
+}
+
+// TestGormDistinctCount verifies COUNT honours DISTINCT in the GORM adapter
+func TestGormDistinctCount(t *testing.T) {
+	adapter := database.NewGormAdapter(setupDistinctDB(t))
+	ctx := context.Background()
+
+	// Single column uses COUNT(DISTINCT col)
+	count, err := adapter.NewSelect().Model(&distinctRow{}).Column("department").Distinct().Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// Multiple columns are counted through a subquery
+	count, err = adapter.NewSelect().Model(&distinctRow{}).Column("department", "status").Distinct().Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// Without DISTINCT every row is counted
+	count, err = adapter.NewSelect().Model(&distinctRow{}).Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	var departments []string
+	err = adapter.NewSelect().Table("distinct_rows").Column("department").Distinct().Order("department").Scan(ctx, &departments)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sales", "support"}, departments)
+}
@@ -0,0 +1,116 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// dryRunAdmin marks the context of a request of an admin, who may run dry runs
+type dryRunAdmin struct{}
+
+// TestDryRun returns the statements of a request with X-Dry-Run without executing them, for
+// the requests AllowDryRun allows
+func TestDryRun(t *testing.T) {
+	api := newAPIServer(t, "dry_run",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+		"INSERT INTO sql_notes (title, stars) VALUES ('first', 3)",
+	)
+
+	api.register("sql_notes", sqlNote{})
+	config := common.HandlerConfig{AllowDryRun: func(ctx context.Context) bool {
+		return ctx.Value(dryRunAdmin{}) != nil
+	}}
+	rh := restheadspec.NewHandlerWithConfig(api.Adapter, api.Registry, config)
+	rh.Hooks().Register(restheadspec.BeforeCreate, func(hookCtx *restheadspec.HookContext) error {
+		if data, ok := hookCtx.Data.(map[string]interface{}); ok && data["title"] == "" {
+			return errors.New("title is required")
+		}
+		return nil
+	})
+	api.serveHandlers(resolvespec.NewHandlerWithConfig(api.Adapter, api.Registry, config), rh)
+	api.Router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Role") == "admin" {
+				r = r.WithContext(context.WithValue(r.Context(), dryRunAdmin{}, true))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	send := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set(common.DryRunHeader, "true")
+		req.Header.Set("X-Role", "admin")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, req)
+		return rec
+	}
+	statements := func(rec *httptest.ResponseRecorder) []common.DryRunStatement {
+		t.Helper()
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var result common.DryRunResult
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.True(t, result.DryRun)
+		require.NotEmpty(t, result.Statements)
+		return result.Statements
+	}
+	notes := func() []string {
+		t.Helper()
+		rows, err := api.DB.Query("SELECT title FROM sql_notes ORDER BY id")
+		require.NoError(t, err)
+		defer rows.Close()
+		var titles []string
+		for rows.Next() {
+			var title string
+			require.NoError(t, rows.Scan(&title))
+			titles = append(titles, title)
+		}
+		return titles
+	}
+
+	read := statements(send("POST", "/resolvespec/sql_notes", `{"operation":"read","options":{"filters":[{"column":"stars","operator":"gt","value":2}]}}`, nil))
+	assert.Contains(t, read[0].SQL, "SELECT")
+	assert.Contains(t, read[0].SQL, "stars")
+	assert.Contains(t, read[0].Params, float64(2))
+
+	create := statements(send("POST", "/resolvespec/sql_notes", `{"operation":"create","data":{"title":"second"}}`, nil))
+	assert.Contains(t, create[0].SQL, "INSERT INTO")
+	assert.Contains(t, create[0].Params, "second")
+
+	deleted := statements(send("DELETE", "/restheadspec/sql_notes/1", "", nil))
+	found := false
+	for _, statement := range deleted {
+		found = found || bytes.Contains([]byte(statement.SQL), []byte("DELETE FROM"))
+	}
+	assert.True(t, found, "the DELETE is recorded: %v", deleted)
+
+	rec := send("POST", "/restheadspec/sql_notes", `{"title":""}`, nil)
+	assert.GreaterOrEqual(t, rec.Code, http.StatusBadRequest, "hook errors are returned")
+	assert.Contains(t, rec.Body.String(), "title is required")
+
+	assert.Equal(t, []string{"first"}, notes(), "nothing is executed")
+
+	// Other requests may not run dry runs
+	for _, path := range []string{"/resolvespec/sql_notes", "/restheadspec/sql_notes"} {
+		rec := send("POST", path, `{"operation":"create","data":{"title":"third"},"title":"third"}`, map[string]string{"X-Role": "user"})
+		assert.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+		assert.Contains(t, rec.Body.String(), "dry_run_forbidden")
+	}
+	assert.Equal(t, []string{"first"}, notes(), "rejected dry runs don't execute")
+}
@@ -0,0 +1,197 @@
+//go:build e2e
+
+// Package e2e runs end-to-end tests against a running example server
+// (examples/fullstack). Start the stack and run the tests with:
+//
+//	make e2e
+//
+// or against an already running server:
+//
+//	E2E_BASE_URL=http://localhost:8080 go test -tags e2e ./tests/e2e
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/examples/fullstack/app"
+	"github.com/bitechdev/ResolveSpec/pkg/client"
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+func baseURL() string {
+	if url := os.Getenv("E2E_BASE_URL"); url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return "http://localhost:8080"
+}
+
+func newClient[T any](entity string, userID string) *client.Client[T] {
+	return client.NewClient[T](client.Config{
+		BaseURL:    baseURL() + "/api",
+		Schema:     app.Schema,
+		Entity:     entity,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		Headers:    map[string]string{"X-User-ID": userID},
+	})
+}
+
+func doJSON(t *testing.T, method, path, userID string, body interface{}) (*http.Response, []byte) {
+	t.Helper()
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+
+	req, err := http.NewRequest(method, baseURL()+path, bytes.NewReader(payload))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if userID != "" {
+		req.Header.Set("X-User-ID", userID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(resp.Body)
+	require.NoError(t, err)
+	return resp, buf.Bytes()
+}
+
+func TestHealth(t *testing.T) {
+	resp, body := doJSON(t, "GET", "/health", "", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"status":"ok"}`, string(body))
+}
+
+func TestAuthenticationRequired(t *testing.T) {
+	resp, _ := doJSON(t, "GET", "/api/public/customers", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, _ = doJSON(t, "POST", "/rs/public/customers", "", map[string]interface{}{"operation": "read"})
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestColumnSecurityMasksCreditCards(t *testing.T) {
+	result, err := newClient[app.Customer]("customers", "1").List(context.Background(), &client.QueryOptions{
+		Sort: []common.SortOption{{Column: "id"}},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Items)
+
+	for _, customer := range result.Items {
+		assert.Contains(t, customer.CreditCard, "*", "credit card of %s must be masked", customer.Name)
+		assert.NotEqual(t, "4111111111111111", customer.CreditCard)
+	}
+}
+
+func TestRowSecurityLimitsOrdersToUser(t *testing.T) {
+	ctx := context.Background()
+	for _, userID := range []int{1, 2} {
+		result, err := newClient[app.Order]("orders", strconv.Itoa(userID)).List(ctx, nil)
+		require.NoError(t, err)
+		require.NotEmpty(t, result.Items)
+		for _, order := range result.Items {
+			assert.Equal(t, userID, order.UserID, "user %d must only see their own orders", userID)
+		}
+	}
+}
+
+func TestFiltersPreloadAndExpand(t *testing.T) {
+	ctx := context.Background()
+
+	customers, err := newClient[app.Customer]("customers", "1").List(ctx, &client.QueryOptions{
+		Filters: []common.FilterOption{{Column: "name", Operator: "eq", Value: "Acme Corp"}},
+		Preload: []common.PreloadOption{{Relation: "Orders"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, customers.Items, 1)
+	assert.NotEmpty(t, customers.Items[0].Orders)
+
+	orders, err := newClient[app.Order]("orders", "1").List(ctx, &client.QueryOptions{
+		FilterGroup: &common.FilterGroup{Logic: "OR", Filters: []common.FilterOption{
+			{Column: "status", Operator: "eq", Value: "paid"},
+			{Column: "status", Operator: "eq", Value: "new"},
+		}},
+		Expand: []common.PreloadOption{{Relation: "customer", Columns: []string{"id", "name"}}},
+		Sort:   []common.SortOption{{Column: "total", Direction: "DESC"}},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, orders.Items)
+	for _, order := range orders.Items {
+		require.NotNil(t, order.Customer)
+		assert.NotEmpty(t, order.Customer.Name)
+	}
+}
+
+func TestCreateHookStampsUser(t *testing.T) {
+	ctx := context.Background()
+	orders := newClient[app.Order]("orders", "2")
+
+	created, err := orders.Create(ctx, app.Order{CustomerID: 1, Total: 9.99})
+	require.NoError(t, err)
+	assert.Equal(t, 2, created.UserID, "BeforeCreate hook sets the user from the request context")
+	assert.Equal(t, "new", created.Status)
+
+	result, err := orders.List(ctx, &client.QueryOptions{
+		Filters: []common.FilterOption{{Column: "total", Operator: "eq", Value: 9.99}},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Items)
+	for _, order := range result.Items {
+		deleted, err := orders.Delete(ctx, order.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+	}
+}
+
+func TestBatchEndpoint(t *testing.T) {
+	resp, body := doJSON(t, "POST", "/api/public/customers/_batch", "1", []interface{}{
+		map[string]interface{}{"action": "create", "data": map[string]interface{}{"name": "Batch Co", "email": "batch@e2e.example"}},
+		map[string]interface{}{"action": "create", "data": map[string]interface{}{"name": "Batch Dup", "email": "batch@e2e.example"}},
+	})
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, string(body))
+
+	var result common.BatchResult
+	require.NoError(t, json.Unmarshal(body, &result))
+	assert.True(t, result.RolledBack)
+
+	customers, err := newClient[app.Customer]("customers", "1").List(context.Background(), &client.QueryOptions{
+		Filters: []common.FilterOption{{Column: "email", Operator: "eq", Value: "batch@e2e.example"}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, customers.Items, "atomic batch must be rolled back")
+}
+
+func TestResolveSpecRead(t *testing.T) {
+	resp, body := doJSON(t, "POST", "/rs/public/customers", "1", map[string]interface{}{
+		"operation": "read",
+		"options": map[string]interface{}{
+			"columns": []string{"id", "name"},
+			"sort":    []map[string]string{{"column": "name", "direction": "asc"}},
+		},
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+
+	var response struct {
+		Success bool           `json:"success"`
+		Data    []app.Customer `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &response))
+	assert.True(t, response.Success)
+	assert.NotEmpty(t, response.Data)
+}
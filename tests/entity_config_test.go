@@ -0,0 +1,78 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type entityRolesKey struct{}
+
+// TestEntityOverrides applies the per-entity overrides of the handler config before dispatch
+func TestEntityOverrides(t *testing.T) {
+	api := newAPIServer(t, "entity_overrides",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+	)
+	for i := 0; i < 5; i++ {
+		_, err := api.DB.Exec("INSERT INTO sql_notes (title, stars) VALUES ('note', 1)")
+		require.NoError(t, err)
+	}
+
+	api.register("sql_notes", sqlNote{})
+	config := common.HandlerConfig{
+		MaxLimit: 100,
+		Entities: map[string]common.EntityConfig{
+			"sql_notes": {Operations: []string{"read", "create"}, Roles: []string{"editor"}, MaxLimit: 2, SkipCount: true},
+		},
+		UserRoles: func(ctx context.Context) []string {
+			roles, _ := ctx.Value(entityRolesKey{}).(string)
+			return strings.Split(roles, ",")
+		},
+	}
+	withRoles := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), entityRolesKey{}, r.Header.Get("X-Roles"))))
+		})
+	}
+	router := withRoles(api.serve(config).Router)
+
+	send := func(method, path, body, roles string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("X-Roles", roles)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("POST", "/resolvespec/sql_notes", `{"operation":"read","options":{"limit":50}}`, "viewer,editor")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Data     []sqlNote       `json:"data"`
+		Metadata common.Metadata `json:"metadata"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Len(t, response.Data, 2, "max limit of the entity")
+	assert.EqualValues(t, -1, response.Metadata.Total, "counts are skipped")
+
+	assert.Equal(t, http.StatusForbidden, send("POST", "/resolvespec/sql_notes", `{"operation":"read"}`, "viewer").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, send("POST", "/resolvespec/sql_notes/1", `{"operation":"delete"}`, "editor").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, send("DELETE", "/restheadspec/sql_notes/1", "", "editor").Code)
+	assert.Equal(t, http.StatusForbidden, send("POST", "/restheadspec/sql_notes", `{"title":"new"}`, "").Code)
+
+	rec = send("POST", "/restheadspec/sql_notes", `{"title":"new"}`, "editor")
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var count int
+	require.NoError(t, api.DB.QueryRow("SELECT COUNT(*) FROM sql_notes").Scan(&count))
+	assert.Equal(t, 6, count, "only the allowed create was executed")
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type enumTicket struct {
+	ID     int64  `json:"id" bun:"id,pk,autoincrement"`
+	Title  string `json:"title" bun:"title"`
+	Status string `json:"status" bun:"status" resolvespec:"enum:open,closed"`
+}
+
+func (enumTicket) TableName() string { return "enum_tickets" }
+
+// TestEnumColumns lists the allowed values of enum columns in the metadata and rejects other
+// values on create and update
+func TestEnumColumns(t *testing.T) {
+	api := newAPIServer(t, "enum_columns",
+		"CREATE TABLE enum_tickets (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, status TEXT NOT NULL)",
+		"INSERT INTO enum_tickets (title, status) VALUES ('first', 'open')",
+	)
+
+	api.register("enum_tickets", enumTicket{})
+	api.serve(common.HandlerConfig{})
+
+	send := api.send
+
+	rec := send("GET", "/resolvespec/enum_tickets", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var metadata struct {
+		Data common.TableMetadata `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &metadata))
+	for _, column := range metadata.Data.Columns {
+		if column.Name == "status" {
+			assert.Equal(t, []string{"open", "closed"}, column.Enum)
+		} else {
+			assert.Nil(t, column.Enum, column.Name)
+		}
+	}
+
+	rec = send("POST", "/restheadspec/enum_tickets", `{"title":"second","status":"pending"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	var headError map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &headError))
+	assert.Equal(t, common.InvalidEnumValue, headError["_code"])
+	assert.Equal(t, "status", headError["_column"])
+
+	rec = send("PUT", "/restheadspec/enum_tickets/1", `{"status":"reopened"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+
+	rec = send("POST", "/resolvespec/enum_tickets", `{"operation":"create","data":{"title":"second","status":"pending"}}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	var response common.Response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.NotNil(t, response.Error)
+	assert.Equal(t, "status", response.Error.Column)
+
+	rec = send("POST", "/resolvespec/enum_tickets/1", `{"operation":"update","data":{"status":"closed"}}`)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var count int
+	require.NoError(t, api.DB.QueryRow("SELECT COUNT(*) FROM enum_tickets WHERE status NOT IN ('open', 'closed')").Scan(&count))
+	assert.Zero(t, count, "invalid values never reach the database")
+}
@@ -0,0 +1,148 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// TestErrorPolicy hides database errors in production and maps them with the error mapper
+func TestErrorPolicy(t *testing.T) {
+	api := newAPIServer(t, "error_policy",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL UNIQUE, stars INTEGER NOT NULL DEFAULT 0 CHECK (stars >= 0))",
+		"INSERT INTO sql_notes (title) VALUES ('taken')",
+	)
+
+	api.register("sql_notes", sqlNote{})
+	mapUnique := func(err error) (common.MappedError, bool) {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return common.MappedError{Status: http.StatusConflict, Code: "duplicate", Message: "The title is already taken"}, true
+		}
+		return common.MappedError{}, false
+	}
+	production := common.HandlerConfig{ErrorDetail: common.ErrorDetailProduction, MapError: mapUnique}
+
+	send := func(router http.Handler, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		return rec
+	}
+	resolveError := func(rec *httptest.ResponseRecorder) *common.APIError {
+		t.Helper()
+		var response common.Response
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response), rec.Body.String())
+		require.NotNil(t, response.Error)
+		return response.Error
+	}
+
+	t.Run("development returns the error text", func(t *testing.T) {
+		router := api.serve(common.HandlerConfig{}).Router
+		rec := send(router, "POST", "/resolvespec/sql_notes", `{"operation":"create","data":{"title":"negative","stars":-1}}`)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Contains(t, resolveError(rec).Detail, "CHECK constraint failed")
+	})
+
+	t.Run("production maps known errors", func(t *testing.T) {
+		router := api.serve(production).Router
+		rec := send(router, "POST", "/resolvespec/sql_notes", `{"operation":"create","data":{"title":"taken"}}`)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		apiError := resolveError(rec)
+		assert.Equal(t, "duplicate", apiError.Code)
+		assert.Equal(t, "The title is already taken", apiError.Message)
+		assert.Empty(t, apiError.Detail)
+		assert.NotContains(t, rec.Body.String(), "UNIQUE")
+
+		rec = send(router, "POST", "/restheadspec/sql_notes", `{"title":"taken"}`)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		var headSpecError map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &headSpecError))
+		assert.Equal(t, "duplicate", headSpecError["_code"])
+		assert.Equal(t, "The title is already taken", headSpecError["_error"])
+	})
+
+	t.Run("production hides unknown server errors", func(t *testing.T) {
+		config := common.HandlerConfig{ErrorDetail: common.ErrorDetailProduction}
+		router := api.serve(config).Router
+		rec := send(router, "POST", "/resolvespec/sql_notes", `{"operation":"create","data":{"title":"negative","stars":-1}}`)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		apiError := resolveError(rec)
+		assert.Equal(t, "create_error", apiError.Code)
+		assert.Empty(t, apiError.Detail)
+		assert.NotEmpty(t, apiError.RequestID)
+		assert.NotContains(t, rec.Body.String(), "CHECK")
+
+		rec = send(router, "POST", "/restheadspec/sql_notes", `{"title":"negative","stars":-1}`)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "CHECK")
+	})
+
+	t.Run("production reports batch items by code and message", func(t *testing.T) {
+		router := api.serve(production).Router
+		for _, path := range []string{"/restheadspec/sql_notes/_batch", "/resolvespec/sql_notes"} {
+			body := `{"atomic":false,"items":[{"action":"create","data":{"title":"batch"}},{"action":"create","data":{"title":"taken"}},{"action":"create","data":{"title":"below","stars":-1}}]}`
+			if path == "/resolvespec/sql_notes" {
+				body = `{"operation":"batch","data":` + body + `}`
+			}
+			rec := send(router, "POST", path, body)
+			require.Equal(t, http.StatusMultiStatus, rec.Code, rec.Body.String())
+			assert.NotContains(t, rec.Body.String(), "UNIQUE")
+			assert.NotContains(t, rec.Body.String(), "CHECK")
+
+			var batch common.BatchResult
+			if path == "/resolvespec/sql_notes" {
+				var response struct {
+					Data common.BatchResult `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				batch = response.Data
+			} else {
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &batch))
+			}
+			require.Len(t, batch.Results, 3)
+			assert.True(t, batch.Results[0].Success)
+			assert.Equal(t, "duplicate", batch.Results[1].Code)
+			assert.Equal(t, "The title is already taken", batch.Results[1].Error)
+			assert.Equal(t, "item_failed", batch.Results[2].Code)
+			assert.Equal(t, "The item failed", batch.Results[2].Error)
+			_, err := api.DB.Exec("DELETE FROM sql_notes WHERE title = 'batch'")
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("production reports transaction operations by code and message", func(t *testing.T) {
+		router := api.serve(production).Router
+		for _, path := range []string{"/restheadspec/_transaction", "/resolvespec/sql_notes"} {
+			body := `{"operations":[{"entity":"sql_notes","action":"create","data":{"title":"tx"}},{"entity":"sql_notes","action":"create","data":{"title":"below","stars":-1}}]}`
+			if path == "/resolvespec/sql_notes" {
+				body = `{"operation":"transaction","data":` + body + `}`
+			}
+			rec := send(router, "POST", path, body)
+			require.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+			assert.NotContains(t, rec.Body.String(), "CHECK")
+
+			var result common.TransactionResult
+			if path == "/resolvespec/sql_notes" {
+				var response struct {
+					Data common.TransactionResult `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				result = response.Data
+			} else {
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+			}
+			require.Len(t, result.Results, 2)
+			assert.Equal(t, "rolled back", result.Results[0].Error)
+			assert.Equal(t, "item_failed", result.Results[1].Code)
+			assert.Equal(t, "The item failed", result.Results[1].Error)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/testmodels"
+)
+
+// TestConditionalRequests covers ETag revalidation of reads and If-Match preconditions on writes
+func TestConditionalRequests(t *testing.T) {
+	db, err := setupStandaloneDB()
+	require.NoError(t, err)
+	defer cleanupStandaloneDB(db)
+
+	resolveSpecHandler, restHeadSpecHandler := setupStandaloneHandlers(db)
+	router := setupStandaloneRouter(resolveSpecHandler, restHeadSpecHandler)
+
+	deptID := fmt.Sprintf("etag_%d", time.Now().UnixNano())
+	require.NoError(t, db.Create(&testmodels.Department{ID: deptID, Name: "Finance", Code: deptID}).Error)
+
+	do := func(method, path string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, err = json.Marshal(body)
+			require.NoError(t, err)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	recordPath := "/restheadspec/departments/" + deptID
+
+	t.Run("read returns a stable ETag", func(t *testing.T) {
+		first := do("GET", recordPath, nil, nil)
+		require.Equal(t, http.StatusOK, first.Code, first.Body.String())
+		etag := first.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+		assert.Equal(t, etag, do("GET", recordPath, nil, nil).Header().Get("ETag"))
+
+		list := do("GET", "/restheadspec/departments", nil, nil)
+		require.Equal(t, http.StatusOK, list.Code)
+		assert.NotEmpty(t, list.Header().Get("ETag"))
+		assert.NotEqual(t, etag, list.Header().Get("ETag"))
+	})
+
+	t.Run("If-None-Match answers 304", func(t *testing.T) {
+		etag := do("GET", recordPath, nil, nil).Header().Get("ETag")
+
+		rec := do("GET", recordPath, nil, map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Body.String())
+		assert.Equal(t, etag, rec.Header().Get("ETag"))
+
+		rec = do("GET", recordPath, nil, map[string]string{"If-None-Match": `"stale"`})
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("If-Match guards updates", func(t *testing.T) {
+		etag := do("GET", recordPath, nil, nil).Header().Get("ETag")
+
+		rec := do("PUT", recordPath, map[string]interface{}{"name": "Accounting"}, map[string]string{"If-Match": etag})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		newETag := rec.Header().Get("ETag")
+		assert.NotEmpty(t, newETag)
+		assert.NotEqual(t, etag, newETag)
+		assert.Equal(t, newETag, do("GET", recordPath, nil, nil).Header().Get("ETag"))
+
+		// The old tag is stale now
+		rec = do("PUT", recordPath, map[string]interface{}{"name": "Treasury"}, map[string]string{"If-Match": etag})
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+		var dept testmodels.Department
+		require.NoError(t, db.First(&dept, "id = ?", deptID).Error)
+		assert.Equal(t, "Accounting", dept.Name)
+	})
+
+	t.Run("If-Match guards deletes", func(t *testing.T) {
+		rec := do("DELETE", recordPath, nil, map[string]string{"If-Match": `"stale"`})
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+		etag := do("GET", recordPath, nil, nil).Header().Get("ETag")
+		rec = do("DELETE", recordPath, nil, map[string]string{"If-Match": etag})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		// A missing record never matches, not even "*"
+		rec = do("DELETE", recordPath, nil, map[string]string{"If-Match": "*"})
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	})
+}
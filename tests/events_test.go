@@ -0,0 +1,223 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/events"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// recordingPublisher passes published messages to a channel
+type recordingPublisher struct {
+	messages chan events.Message
+	topics   chan string
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, topic string, msg events.Message) error {
+	p.topics <- topic
+	p.messages <- msg
+	return nil
+}
+
+func (p *recordingPublisher) Close() error { return nil }
+
+// TestEventPublishing publishes the writes of both APIs with the records before and after
+func TestEventPublishing(t *testing.T) {
+	api := newAPIServer(t, "events",
+		"CREATE TABLE commit_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL UNIQUE)",
+	)
+
+	publisher := &recordingPublisher{messages: make(chan events.Message, 4), topics: make(chan string, 4)}
+	emitter := events.New(events.Config{Publisher: publisher})
+
+	api.register("commit_notes", commitNote{})
+	rs := resolvespec.NewHandler(api.Adapter, api.Registry)
+	rh := restheadspec.NewHandler(api.Adapter, api.Registry)
+	require.NoError(t, rs.Use(emitter))
+	require.NoError(t, rh.Use(emitter))
+	api.serveHandlers(rs, rh)
+
+	send := func(method, path, body string) int {
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec.Code
+	}
+	next := func() events.Message {
+		t.Helper()
+		select {
+		case msg := <-publisher.messages:
+			return msg
+		case <-time.After(time.Second):
+			t.Fatal("no message was published")
+			return events.Message{}
+		}
+	}
+	title := func(record interface{}) interface{} {
+		data, err := json.Marshal(record)
+		require.NoError(t, err)
+		var values map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &values))
+		return values["title"]
+	}
+
+	require.Equal(t, 200, send("POST", "/restheadspec/commit_notes", `{"title":"first"}`))
+	msg := next()
+	assert.Equal(t, events.OpCreate, msg.Op)
+	assert.Equal(t, "commit_notes", msg.Entity)
+	assert.Equal(t, "1", msg.Key())
+	assert.Nil(t, msg.Before)
+	assert.Equal(t, "first", title(msg.After))
+	assert.Equal(t, "resolvespec.commit_notes", <-publisher.topics)
+
+	require.Less(t, send("POST", "/resolvespec/commit_notes/1", `{"operation":"update","data":{"title":"renamed"}}`), 300)
+	msg = next()
+	assert.Equal(t, events.OpUpdate, msg.Op)
+	assert.Equal(t, "first", title(msg.Before))
+	assert.Equal(t, "renamed", title(msg.After))
+
+	require.Less(t, send("DELETE", "/restheadspec/commit_notes/1", ""), 300)
+	msg = next()
+	assert.Equal(t, events.OpDelete, msg.Op)
+	assert.Equal(t, "1", msg.Key())
+	assert.Equal(t, "renamed", title(msg.Before))
+	assert.Nil(t, msg.After)
+
+	// Failed writes publish nothing
+	require.Equal(t, 200, send("POST", "/restheadspec/commit_notes", `{"title":"second"}`))
+	next()
+	require.GreaterOrEqual(t, send("POST", "/restheadspec/commit_notes", `{"title":"second"}`), 400)
+	select {
+	case msg := <-publisher.messages:
+		t.Fatalf("failed write published %+v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestEventOutbox writes the messages of writes in their transaction with WriteTransactions
+func TestEventOutbox(t *testing.T) {
+	api := newAPIServer(t, "events_outbox",
+		"CREATE TABLE commit_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL UNIQUE)",
+	)
+
+	api.register("commit_notes", commitNote{})
+	outbox := events.NewTableOutbox("")
+	require.NoError(t, outbox.Migrate(context.Background(), api.Adapter))
+	rh := restheadspec.NewHandlerWithConfig(api.Adapter, api.Registry, common.HandlerConfig{WriteTransactions: true})
+	require.NoError(t, rh.Use(events.New(events.Config{Outbox: outbox})))
+	// Runs after the outbox write and fails the request, rolling back the record and its event
+	rh.Hooks().Register(restheadspec.AfterCreate, func(ctx *restheadspec.HookContext) error {
+		if strings.Contains(string(mustJSON(t, ctx.Result)), "rejected") {
+			return errors.New("rejected")
+		}
+		return nil
+	})
+	api.serveHandlers(resolvespec.NewHandler(api.Adapter, api.Registry), rh)
+
+	send := func(method, path, body string) int {
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec.Code
+	}
+	count := func(table string) int {
+		var n int
+		require.NoError(t, api.DB.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&n))
+		return n
+	}
+
+	require.Equal(t, 200, send("POST", "/restheadspec/commit_notes", `{"title":"kept"}`))
+	assert.Equal(t, 1, count("commit_notes"))
+	assert.Equal(t, 1, count("event_outbox"))
+
+	var topic, key, payload string
+	require.NoError(t, api.DB.QueryRow("SELECT topic, msg_key, payload FROM event_outbox").Scan(&topic, &key, &payload))
+	assert.Equal(t, "resolvespec.commit_notes", topic)
+	assert.Equal(t, "1", key)
+	var msg events.Message
+	require.NoError(t, json.Unmarshal([]byte(payload), &msg))
+	assert.Equal(t, events.OpCreate, msg.Op)
+
+	assert.Equal(t, 500, send("POST", "/restheadspec/commit_notes", `{"title":"rejected"}`))
+	assert.Equal(t, 1, count("commit_notes"), "the failed request rolled back its record")
+	assert.Equal(t, 1, count("event_outbox"), "the failed request rolled back its event")
+
+	require.Less(t, send("DELETE", "/restheadspec/commit_notes/1", ""), 300)
+	assert.Equal(t, 0, count("commit_notes"))
+	assert.Equal(t, 2, count("event_outbox"))
+}
+
+func mustJSON(t *testing.T, value interface{}) []byte {
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	return data
+}
+
+// TestOutboxRelay publishes the rows of the outbox in order and marks them delivered
+func TestOutboxRelay(t *testing.T) {
+	api := newAPIServer(t, "events_relay",
+		"CREATE TABLE commit_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL UNIQUE)",
+	)
+
+	outbox := events.NewTableOutbox("")
+	require.NoError(t, outbox.Migrate(context.Background(), api.Adapter))
+	require.NoError(t, outbox.Migrate(context.Background(), api.Adapter), "migrations are idempotent")
+
+	api.register("commit_notes", commitNote{})
+	rh := restheadspec.NewHandlerWithConfig(api.Adapter, api.Registry, common.HandlerConfig{WriteTransactions: true})
+	require.NoError(t, rh.Use(events.New(events.Config{Outbox: outbox})))
+	api.serveHandlers(resolvespec.NewHandler(api.Adapter, api.Registry), rh)
+	send := func(method, path, body string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		require.Less(t, rec.Code, 300, rec.Body.String())
+	}
+	send("POST", "/restheadspec/commit_notes", `{"title":"first"}`)
+	send("POST", "/restheadspec/commit_notes", `{"title":"second"}`)
+	send("PUT", "/restheadspec/commit_notes/1", `{"title":"renamed"}`)
+
+	// The broker is down for the first message of record 1
+	var published []string
+	failed := false
+	relay := events.NewRelay(api.Adapter, outbox, events.PublisherFunc(func(ctx context.Context, topic string, msg events.Message) error {
+		if msg.Key() == "1" && !failed {
+			failed = true
+			return errors.New("broker unavailable")
+		}
+		published = append(published, msg.Key()+":"+msg.Op)
+		return nil
+	}), events.RelayConfig{OnError: func(events.Message, error) {}})
+
+	n, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []string{"2:create"}, published, "the update of record 1 waits for its create")
+	var attempts int
+	var lastError string
+	require.NoError(t, api.DB.QueryRow("SELECT attempts, last_error FROM event_outbox WHERE msg_key = '1' AND attempts > 0").Scan(&attempts, &lastError))
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, "broker unavailable", lastError)
+
+	n, err = relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"2:create", "1:create", "1:update"}, published)
+
+	n, err = relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	purged, err := relay.Purge(context.Background(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, purged)
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+type explainRoleKey struct{}
+
+// TestExplain returns query plans in the metadata of reads allowed by AllowExplain
+func TestExplain(t *testing.T) {
+	api := newAPIServer(t, "explain",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+		"INSERT INTO sql_notes (title, stars) VALUES ('first', 3)",
+	)
+
+	api.register("sql_notes", sqlNote{})
+	config := common.HandlerConfig{AllowExplain: func(ctx context.Context) bool {
+		return ctx.Value(explainRoleKey{}) == "admin"
+	}}
+	rh := restheadspec.NewHandlerWithConfig(api.Adapter, api.Registry, config, restheadspec.WithAuth(func(ctx context.Context, r common.Request) (context.Context, error) {
+		return context.WithValue(ctx, explainRoleKey{}, r.Header("X-Role")), nil
+	}))
+	rs := resolvespec.NewHandlerWithConfig(api.Adapter, api.Registry, common.HandlerConfig{AllowExplain: func(context.Context) bool { return true }})
+	api.serveHandlers(rs, rh)
+
+	send := api.send
+
+	rec := send("GET", "/restheadspec/sql_notes", "", map[string]string{"X-Explain": "true", "X-Role": "admin", "X-DetailApi": "true"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var detail struct {
+		Data     []sqlNote       `json:"data"`
+		Metadata common.Metadata `json:"metadata"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+	assert.Len(t, detail.Data, 1, "the read still returns its records")
+	require.NotNil(t, detail.Metadata.Explain)
+	assert.Contains(t, detail.Metadata.Explain.SQL, "sql_notes")
+	assert.NotEmpty(t, detail.Metadata.Explain.Plan)
+
+	rec = send("GET", "/restheadspec/sql_notes", "", map[string]string{"X-Explain": "true", "X-Role": "viewer"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = send("POST", "/resolvespec/sql_notes", `{"operation":"read","options":{"explain":"true","filters":[{"column":"stars","operator":"gt","value":2}]}}`, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response common.Response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.NotNil(t, response.Metadata)
+	require.NotNil(t, response.Metadata.Explain)
+	assert.Equal(t, []interface{}{float64(2)}, response.Metadata.Explain.Params)
+	assert.NotEmpty(t, response.Metadata.Explain.Plan)
+
+	rec = send("POST", "/resolvespec/sql_notes/1", `{"operation":"read"}`, map[string]string{"X-Explain": "true"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Contains(t, response.Metadata.Explain.SQL, "id")
+
+	forbidden := api.serve(common.HandlerConfig{}).Router
+	rec = httptest.NewRecorder()
+	forbidden.ServeHTTP(rec, httptest.NewRequest("POST", "/resolvespec/sql_notes", bytes.NewBufferString(`{"operation":"read","options":{"explain":"analyze"}}`)))
+	assert.Equal(t, http.StatusForbidden, rec.Code, "explain is off without AllowExplain")
+}
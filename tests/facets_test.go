@@ -0,0 +1,117 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// TestFacets counts the values of facet columns over the filters of a read, leaving out the
+// filters of each facet's own column
+func TestFacets(t *testing.T) {
+	for _, name := range []string{"gorm", "sql"} {
+		t.Run(name, func(t *testing.T) {
+			api := newAPIServer(t, "facets_"+name,
+				"CREATE TABLE employees (id INTEGER PRIMARY KEY, first_name TEXT NOT NULL DEFAULT '', last_name TEXT NOT NULL DEFAULT '', status TEXT NOT NULL DEFAULT '', salary REAL NOT NULL DEFAULT 0, department_id INTEGER NOT NULL)",
+				`INSERT INTO employees (id, status, department_id) VALUES
+				(1, 'active', 1), (2, 'active', 1), (3, 'active', 2), (4, 'inactive', 2), (5, 'inactive', 3), (6, 'leave', 3)`,
+			)
+
+			var db common.Database
+			if name == "gorm" {
+				gormDB, err := gorm.Open(sqlite.Dialector{Conn: api.DB}, &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+				require.NoError(t, err)
+				db = database.NewGormAdapter(gormDB)
+			} else {
+				db = database.NewSQLAdapter(api.DB, "sqlite")
+			}
+			api.register("employees", goldenEmployee{})
+			api.serveHandlers(resolvespec.NewHandler(db, api.Registry), restheadspec.NewHandler(db, api.Registry))
+
+			read := func(headers map[string]string) ([]goldenEmployee, common.Metadata) {
+				t.Helper()
+				req := httptest.NewRequest("GET", "/restheadspec/employees", nil)
+				req.Header.Set("X-DetailApi", "true")
+				for key, value := range headers {
+					req.Header.Set(key, value)
+				}
+				rec := httptest.NewRecorder()
+				api.Router.ServeHTTP(rec, req)
+				require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+				var detail struct {
+					Data     []goldenEmployee `json:"data"`
+					Metadata common.Metadata  `json:"metadata"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+				return detail.Data, detail.Metadata
+			}
+
+			employees, metadata := read(map[string]string{"X-Facets": "status,department_id", "X-Searchop-Eq-Status": "active", "X-Limit": "1"})
+			assert.Len(t, employees, 1)
+			assert.Equal(t, map[string]map[string]int64{
+				"status":        {"active": 3, "inactive": 2, "leave": 1},
+				"department_id": {"1": 2, "2": 1},
+			}, metadata.Facets, "a facet leaves out the filters of its own column")
+
+			employees, metadata = read(map[string]string{"X-Facets": "Status, department_id", "X-Searchop-Eq-Status": "active", "X-Searchop-Eq-Department_id": "3"})
+			assert.Empty(t, employees)
+			assert.Equal(t, map[string]map[string]int64{
+				"status":        {"inactive": 1, "leave": 1},
+				"department_id": {"1": 2, "2": 1},
+			}, metadata.Facets)
+
+			req := httptest.NewRequest("GET", "/restheadspec/employees", nil)
+			req.Header.Set("X-Facets", "missing")
+			rec := httptest.NewRecorder()
+			api.Router.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+// TestFacetsOwnerFilter keeps owner filters on the counts of a facet of the owner column
+func TestFacetsOwnerFilter(t *testing.T) {
+	api := newAPIServer(t, "facets_owner",
+		"CREATE TABLE owned_tasks (id INTEGER PRIMARY KEY AUTOINCREMENT, owner_id TEXT NOT NULL, title TEXT NOT NULL)",
+		"INSERT INTO owned_tasks (owner_id, title) VALUES ('alice', 'a'), ('bob', 'b'), ('alice', 'c')",
+	)
+
+	api.register("owned_tasks", ownedTask{})
+	config := common.HandlerConfig{
+		Entities: map[string]common.EntityConfig{"owned_tasks": {OwnerColumn: "owner_id"}},
+		AuditUser: func(ctx context.Context) (string, bool) {
+			user, ok := ctx.Value(auditUserKey{}).(string)
+			return user, ok && user != ""
+		},
+	}
+	api.serve(config)
+
+	req := httptest.NewRequest("GET", "/restheadspec/owned_tasks", nil)
+	req.Header.Set("X-DetailApi", "true")
+	req.Header.Set("X-Only-Mine", "true")
+	req.Header.Set("X-Facets", "owner_id,title")
+	rec := httptest.NewRecorder()
+	api.Router.ServeHTTP(rec, req.WithContext(context.WithValue(req.Context(), auditUserKey{}, "alice")))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var detail struct {
+		Metadata common.Metadata `json:"metadata"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+	assert.Equal(t, map[string]map[string]int64{
+		"owner_id": {"alice": 2},
+		"title":    {"a": 1, "c": 1},
+	}, detail.Metadata.Facets, "records of other users are never counted")
+}
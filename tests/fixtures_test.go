@@ -0,0 +1,130 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/fixtures"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+type fixtureDepartment struct {
+	ID        int64             `json:"id" gorm:"column:id;primaryKey"`
+	Name      string            `json:"name" gorm:"column:name"`
+	Employees []fixtureEmployee `json:"employees,omitempty" gorm:"foreignKey:DepartmentID;references:ID"`
+}
+
+func (fixtureDepartment) TableName() string { return "fixture_departments" }
+
+type fixtureEmployee struct {
+	ID           int64  `json:"id" gorm:"column:id;primaryKey"`
+	Name         string `json:"name" gorm:"column:name"`
+	Email        string `json:"email" gorm:"column:email"`
+	DepartmentID *int64 `json:"department_id" gorm:"column:department_id"`
+	ManagerID    *int64 `json:"manager_id" gorm:"column:manager_id"`
+}
+
+func (fixtureEmployee) TableName() string { return "fixture_employees" }
+
+const departmentFixture = `
+fixture_departments:
+  - _ref: engineering
+    name: Engineering
+    employees:
+      - name: Jane
+        email: jane@example.com
+      - name: John
+        email: john@example.com
+  - _ref: sales
+    name: Sales
+`
+
+const employeeFixture = `{
+  "fixture_employees": [
+    {"_ref": "lead", "name": "Lea", "email": "lea@example.com", "department_id": "$ref:sales"},
+    {"name": "Sam", "email": "$ref:lead.email", "department_id": "$ref:sales", "manager_id": "$ref:lead"}
+  ]
+}`
+
+// TestFixtures loads YAML and JSON fixtures with nested relations and references
+func TestFixtures(t *testing.T) {
+	newLoader := func(t *testing.T, name string) (*fixtures.Loader, *sql.DB) {
+		api := newAPIServer(t, name,
+			"CREATE TABLE fixture_departments (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL)",
+			"CREATE TABLE fixture_employees (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, email TEXT NOT NULL, department_id INTEGER, manager_id INTEGER)",
+		)
+
+		api.register("fixture_departments", fixtureDepartment{})
+		api.register("fixture_employees", fixtureEmployee{})
+		return fixtures.NewLoader(api.Adapter, api.Registry, restheadspec.NewHandler(api.Adapter, api.Registry)), api.DB
+	}
+	ctx := context.Background()
+
+	t.Run("files", func(t *testing.T) {
+		loader, sqldb := newLoader(t, "fixtures_files")
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "departments.yaml"), []byte(departmentFixture), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "employees.json"), []byte(employeeFixture), 0o600))
+
+		records, err := loader.LoadFiles(ctx, filepath.Join(dir, "departments.yaml"), filepath.Join(dir, "employees.json"))
+		require.NoError(t, err)
+		require.NotNil(t, records.ID("engineering"), "generated keys are returned")
+		assert.Equal(t, "Sales", records.Record("sales")["name"])
+		assert.Nil(t, records.ID("missing"))
+
+		// Nested employees get the key of their department
+		var nested int
+		require.NoError(t, sqldb.QueryRow("SELECT COUNT(*) FROM fixture_employees WHERE department_id = ?", records.ID("engineering")).Scan(&nested))
+		assert.Equal(t, 2, nested)
+
+		var email string
+		var departmentID, managerID int64
+		require.NoError(t, sqldb.QueryRow("SELECT email, department_id, manager_id FROM fixture_employees WHERE name = 'Sam'").Scan(&email, &departmentID, &managerID))
+		assert.Equal(t, "lea@example.com", email, "$ref:name.column is a column of the record")
+		assert.EqualValues(t, records.ID("sales"), departmentID)
+		assert.EqualValues(t, records.ID("lead"), managerID)
+	})
+
+	t.Run("fs", func(t *testing.T) {
+		loader, sqldb := newLoader(t, "fixtures_fs")
+		fsys := fstest.MapFS{
+			"fixtures/01_departments.yaml": {Data: []byte(departmentFixture)},
+			"fixtures/02_employees.json":   {Data: []byte(employeeFixture)},
+		}
+		_, err := loader.LoadFS(ctx, fsys, "fixtures/*")
+		require.NoError(t, err)
+
+		var count int
+		require.NoError(t, sqldb.QueryRow("SELECT COUNT(*) FROM fixture_employees").Scan(&count))
+		assert.Equal(t, 4, count)
+
+		_, err = loader.LoadFS(ctx, fsys, "missing/*")
+		assert.Error(t, err)
+	})
+
+	t.Run("errors roll back", func(t *testing.T) {
+		loader, sqldb := newLoader(t, "fixtures_errors")
+		for name, fixture := range map[string]string{
+			"unknown reference": "fixture_departments:\n  - name: A\nfixture_employees:\n  - name: B\n    email: b@example.com\n    department_id: $ref:nowhere\n",
+			"unknown model":     "fixture_departments:\n  - name: A\nfixture_teams:\n  - name: B\n",
+			"nested name":       "fixture_departments:\n  - name: A\n    employees:\n      - _ref: b\n        name: B\n        email: b@example.com\n",
+			"duplicate name":    "fixture_departments:\n  - _ref: a\n    name: A\n  - _ref: a\n    name: B\n",
+			"not a mapping":     "- fixture_departments\n",
+			"not records":       "fixture_departments: 5\n",
+		} {
+			_, err := loader.Load(ctx, []byte(fixture))
+			assert.Error(t, err, name)
+		}
+
+		var count int
+		require.NoError(t, sqldb.QueryRow("SELECT COUNT(*) FROM fixture_departments").Scan(&count))
+		assert.Zero(t, count, "a failing fixture inserts nothing")
+	})
+}
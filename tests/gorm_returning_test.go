@@ -0,0 +1,130 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// TestGormInsertModel is a test model with a generated key and a server-side default
+type TestGormInsertModel struct {
+	ID     int64  `gorm:"column:id;primaryKey;autoIncrement"`
+	Name   string `gorm:"column:name"`
+	Status string `gorm:"column:status;default:active"`
+}
+
+func (TestGormInsertModel) TableName() string {
+	return "gorm_inserts"
+}
+
+func setupGormInsertDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err, "Failed to open SQLite database")
+	require.NoError(t, db.AutoMigrate(&TestGormInsertModel{}))
+	return db
+}
+
+func TestGormInsertReturning_Table(t *testing.T) {
+	adapter := database.NewGormAdapter(setupGormInsertDB(t))
+	ctx := context.Background()
+
+	for expectedID := int64(1); expectedID <= 2; expectedID++ {
+		result, err := adapter.NewInsert().
+			Table("gorm_inserts").
+			Value("name", "Generated").
+			Returning("*").
+			Exec(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.RowsAffected())
+
+		returning, ok := result.(common.ReturningResult)
+		require.True(t, ok, "GORM results should implement ReturningResult")
+		returned := returning.Returned()
+		require.NotNil(t, returned)
+		assert.EqualValues(t, expectedID, returned["id"], "Generated ID should be returned")
+		assert.Equal(t, "active", returned["status"], "Server-side default should be returned")
+
+		id, err := result.LastInsertId()
+		require.NoError(t, err)
+		assert.Equal(t, expectedID, id)
+	}
+}
+
+func TestGormInsertReturning_Model(t *testing.T) {
+	adapter := database.NewGormAdapter(setupGormInsertDB(t))
+
+	record := &TestGormInsertModel{Name: "Model"}
+	result, err := adapter.NewInsert().Model(record).Returning("*").Exec(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.RowsAffected())
+	assert.Equal(t, int64(1), record.ID)
+	assert.Equal(t, "active", record.Status)
+}
+
+func TestGormInsertReturning_WithoutReturning(t *testing.T) {
+	adapter := database.NewGormAdapter(setupGormInsertDB(t))
+
+	result, err := adapter.NewInsert().Table("gorm_inserts").Value("name", "Plain").Exec(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, result.(common.ReturningResult).Returned())
+}
+
+// TestCreateReturnsGeneratedValues checks that create responses of both APIs carry
+// generated keys and server-side defaults
+func TestCreateReturnsGeneratedValues(t *testing.T) {
+	db := setupGormInsertDB(t)
+	adapter := database.NewGormAdapter(db)
+
+	resolveRegistry := modelregistry.NewModelRegistry()
+	require.NoError(t, resolveRegistry.RegisterModel("gorm_inserts", TestGormInsertModel{}))
+	headRegistry := modelregistry.NewModelRegistry()
+	require.NoError(t, headRegistry.RegisterModel("gorm_inserts", TestGormInsertModel{}))
+	router := setupStandaloneRouter(resolvespec.NewHandler(adapter, resolveRegistry), restheadspec.NewHandler(adapter, headRegistry))
+
+	post := func(path, body string) []byte {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("POST", path, bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		return rec.Body.Bytes()
+	}
+
+	t.Run("resolvespec single", func(t *testing.T) {
+		var response struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(post("/resolvespec/gorm_inserts", `{"operation":"create","data":{"name":"one"}}`), &response))
+		assert.NotNil(t, response.Data["id"])
+		assert.Equal(t, "active", response.Data["status"])
+	})
+
+	t.Run("resolvespec list", func(t *testing.T) {
+		var response struct {
+			Data []map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(post("/resolvespec/gorm_inserts", `{"operation":"create","data":[{"name":"two"},{"name":"three"}]}`), &response))
+		require.Len(t, response.Data, 2)
+		assert.NotEqual(t, response.Data[0]["id"], response.Data[1]["id"])
+		assert.Equal(t, "active", response.Data[1]["status"])
+	})
+
+	t.Run("restheadspec batch", func(t *testing.T) {
+		var result common.BatchResult
+		require.NoError(t, json.Unmarshal(post("/restheadspec/gorm_inserts/_batch", `[{"action":"create","data":{"name":"four"}}]`), &result))
+		require.Len(t, result.Results, 1)
+		assert.NotNil(t, result.Results[0].ID, "batch creates report the generated ID")
+	})
+}
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// TestHealthHandler reports ping status, pool statistics and the model count
+func TestHealthHandler(t *testing.T) {
+	db, err := setupStandaloneDB()
+	require.NoError(t, err)
+	defer cleanupStandaloneDB(db)
+	rs, _ := setupStandaloneHandlers(db)
+
+	check := func(handler http.Handler, wantStatus int) common.Health {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+		require.Equal(t, wantStatus, rec.Code, rec.Body.String())
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		var health common.Health
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &health))
+		return health
+	}
+
+	health := check(rs.HealthHandler(), http.StatusOK)
+	assert.Equal(t, "ok", health.Status)
+	assert.Equal(t, "ok", health.Database.Ping)
+	assert.Equal(t, "sqlite", health.Database.Dialect)
+	assert.Equal(t, len(rs.GetRegistry().GetAllModels()), health.Models)
+	assert.Positive(t, health.Models)
+	require.NotNil(t, health.Database.Pool, "GORM exposes its database/sql pool")
+	assert.Positive(t, health.Database.Pool.Open)
+
+	sqldb, err := sql.Open("sqlite", "file:health?mode=memory")
+	require.NoError(t, err)
+	rh := restheadspec.NewHandler(database.NewSQLAdapter(sqldb, "sqlite"), modelregistry.NewModelRegistry())
+	require.NoError(t, sqldb.Close())
+	health = check(rh.HealthHandler(), http.StatusServiceUnavailable)
+	assert.Equal(t, "unavailable", health.Status)
+	assert.NotEqual(t, "ok", health.Database.Ping)
+	assert.Zero(t, health.Models)
+
+	check(resolvespec.NewHandler(database.NewChaosAdapter(database.NewGormAdapter(db), database.ChaosConfig{}), nil).HealthHandler(), http.StatusOK)
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type idTicket struct {
+	ID    string `json:"id" gorm:"column:id;primaryKey"`
+	Title string `json:"title" gorm:"column:title"`
+}
+
+func (idTicket) TableName() string {
+	return "id_tickets"
+}
+
+// TestIDGenerator fills the keys of created records with the generator of the entity
+func TestIDGenerator(t *testing.T) {
+	api := newAPIServer(t, "id_generator",
+		"CREATE TABLE id_tickets (id TEXT PRIMARY KEY, title TEXT NOT NULL)",
+	)
+
+	api.register("id_tickets", idTicket{})
+	config := common.HandlerConfig{
+		Entities: map[string]common.EntityConfig{"id_tickets": {IDGenerator: common.ULID()}},
+	}
+	api.serve(config)
+	send := func(path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, httptest.NewRequest("POST", path, bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		return rec
+	}
+
+	var created idTicket
+	require.NoError(t, json.Unmarshal(send("/restheadspec/id_tickets", `{"title":"first"}`).Body.Bytes(), &created))
+	assert.Len(t, created.ID, 26, "the generated id is returned")
+
+	var response struct {
+		Data []idTicket `json:"data"`
+	}
+	rec := send("/resolvespec/id_tickets", `{"operation":"create","data":[{"title":"second"},{"id":"custom","title":"third"}]}`)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Data, 2)
+	assert.Len(t, response.Data[0].ID, 26)
+	assert.Equal(t, "custom", response.Data[1].ID, "ids sent by the client are kept")
+
+	var ids []string
+	rows, err := api.DB.Query("SELECT id FROM id_tickets")
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		require.NoError(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	assert.ElementsMatch(t, []string{created.ID, response.Data[0].ID, "custom"}, ids)
+}
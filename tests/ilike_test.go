@@ -0,0 +1,60 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/testmodels"
+)
+
+// TestCaseInsensitiveSearchOnSQLite runs ilike filters on SQLite, which has no ILIKE
+func TestCaseInsensitiveSearchOnSQLite(t *testing.T) {
+	db, err := setupStandaloneDB()
+	require.NoError(t, err)
+	defer cleanupStandaloneDB(db)
+
+	resolveSpecHandler, restHeadSpecHandler := setupStandaloneHandlers(db)
+	router := setupStandaloneRouter(resolveSpecHandler, restHeadSpecHandler)
+
+	prefix := fmt.Sprintf("ilike_%d", time.Now().UnixNano())
+	for i, name := range []string{"Marketing Ops", "MARKETING Sales", "Finance"} {
+		code := fmt.Sprintf("%s_%d", prefix, i)
+		require.NoError(t, db.Create(&testmodels.Department{ID: code, Name: prefix + " " + name, Code: code}).Error)
+	}
+
+	t.Run("restheadspec search operator", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/restheadspec/departments", nil)
+		req.Header.Set("X-Searchop-Contains-Name", "marketing")
+		req.Header.Set("X-Searchop-Beginswith-Code", prefix)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var departments []testmodels.Department
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &departments))
+		assert.Len(t, departments, 2)
+	})
+
+	t.Run("resolvespec ilike filter", func(t *testing.T) {
+		body := fmt.Sprintf(`{"operation": "read", "options": {"filters": [
+			{"column": "name", "operator": "ilike", "value": "%%%s marketing%%"}
+		]}}`, prefix)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("POST", "/resolvespec/departments", bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var response struct {
+			Data []testmodels.Department `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Len(t, response.Data, 2)
+	})
+}
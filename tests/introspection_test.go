@@ -0,0 +1,63 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// driftedMember declares tags that drifted from the live table
+type driftedMember struct {
+	ID    int64   `json:"id" gorm:"primaryKey"`
+	Email *string `json:"email" gorm:"size:50"`
+	Level int     `json:"level"`
+}
+
+func (driftedMember) TableName() string { return "drifted_members" }
+
+// TestSchemaIntrospection merges the live columns and indexes into the metadata of HandleGet
+func TestSchemaIntrospection(t *testing.T) {
+	api := newAPIServer(t, "introspection",
+		`CREATE TABLE drifted_members (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		email VARCHAR(120) NOT NULL,
+		level INTEGER NOT NULL DEFAULT 1,
+		legacy TEXT)`,
+		"CREATE UNIQUE INDEX idx_members_email ON drifted_members (email)",
+	)
+
+	api.register("drifted_members", driftedMember{})
+	config := common.HandlerConfig{IntrospectSchema: true}
+	api.serveHandlers(resolvespec.NewHandlerWithConfig(api.Adapter, api.Registry, config), restheadspec.NewHandler(api.Adapter, api.Registry))
+
+	req := httptest.NewRequest("GET", "/resolvespec/drifted_members", nil)
+	rec := httptest.NewRecorder()
+	api.Router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Data common.TableMetadata `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+	columns := make(map[string]common.Column)
+	for _, column := range response.Data.Columns {
+		columns[column.Name] = column
+	}
+	assert.Len(t, columns, 3, "columns the model doesn't map are left out")
+	assert.Equal(t, "varchar(120)", columns["email"].Type)
+	assert.Equal(t, 120, columns["email"].MaxLength)
+	assert.False(t, columns["email"].IsNullable, "the database wins over the pointer type")
+	assert.True(t, columns["email"].IsUnique)
+	assert.True(t, columns["email"].HasIndex)
+	assert.Equal(t, "1", columns["level"].DefaultValue)
+	assert.True(t, columns["id"].IsPrimary)
+	assert.Equal(t, []common.IndexMetadata{{Name: "idx_members_email", Columns: []string{"email"}, Unique: true}}, response.Data.Indexes)
+}
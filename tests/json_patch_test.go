@@ -0,0 +1,91 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// jsonProfile has a JSON document column that is updated partially
+type jsonProfile struct {
+	ID       int64           `json:"id" gorm:"column:id;primaryKey"`
+	Name     string          `json:"name" gorm:"column:name"`
+	Settings common.SqlJSONB `json:"settings" gorm:"column:settings;type:text"`
+}
+
+func (jsonProfile) TableName() string {
+	return "json_profiles"
+}
+
+// TestJSONPatchUpdates merges JSON path keys and json_patch sections into a JSON column
+// instead of replacing the document
+func TestJSONPatchUpdates(t *testing.T) {
+	db, err := setupStandaloneDB()
+	require.NoError(t, err)
+	defer cleanupStandaloneDB(db)
+
+	require.NoError(t, db.Migrator().DropTable(&jsonProfile{}))
+	require.NoError(t, db.AutoMigrate(&jsonProfile{}))
+	require.NoError(t, db.Create(&jsonProfile{
+		ID:       1,
+		Name:     "a",
+		Settings: common.SqlJSONB(`{"theme":"light","font":{"size":10},"legacy":true}`),
+	}).Error)
+
+	registry := modelregistry.NewModelRegistry()
+	require.NoError(t, registry.RegisterModel("json_profiles", jsonProfile{}))
+	adapter := database.NewGormAdapter(db)
+	router := setupStandaloneRouter(resolvespec.NewHandler(adapter, registry), restheadspec.NewHandler(adapter, registry))
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		return rec
+	}
+	settings := func() map[string]interface{} {
+		t.Helper()
+		var profile jsonProfile
+		require.NoError(t, db.First(&profile, 1).Error)
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(profile.Settings, &doc))
+		return doc
+	}
+
+	t.Run("path keys and json_patch merge into the document", func(t *testing.T) {
+		rec := send("PUT", "/restheadspec/json_profiles/1", `{
+			"name": "b",
+			"settings->theme": "dark",
+			"json_patch": {"settings": {"font": {"size": 12}, "legacy": null}}
+		}`)
+
+		assert.Equal(t, map[string]interface{}{
+			"theme": "dark",
+			"font":  map[string]interface{}{"size": float64(12)},
+		}, settings())
+		assert.NotContains(t, rec.Body.String(), "settings->theme")
+		assert.NotContains(t, rec.Body.String(), "json_patch")
+	})
+
+	t.Run("resolvespec updates merge too", func(t *testing.T) {
+		send("POST", "/resolvespec/json_profiles/1", `{
+			"operation": "update",
+			"data": {"settings->font->family": "mono"}
+		}`)
+
+		assert.Equal(t, map[string]interface{}{"size": float64(12), "family": "mono"}, settings()["font"])
+		assert.Equal(t, "dark", settings()["theme"])
+	})
+}
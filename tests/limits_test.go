@@ -0,0 +1,125 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// TestHandlerLimitGuardrails clamps and rejects list reads outside the configured limits
+func TestHandlerLimitGuardrails(t *testing.T) {
+	api := newAPIServer(t, "limit_guardrails",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+	)
+	for i := 0; i < 10; i++ {
+		_, err := api.DB.Exec("INSERT INTO sql_notes (title) VALUES ('note')")
+		require.NoError(t, err)
+	}
+
+	api.register("sql_notes", sqlNote{})
+	api.serveHandlers(
+		resolvespec.NewHandlerWithConfig(api.Adapter, api.Registry, common.HandlerConfig{DefaultLimit: 3, MaxLimit: 5}),
+		restheadspec.NewHandlerWithConfig(api.Adapter, api.Registry, common.HandlerConfig{MaxLimit: 4, MaxOffset: 5, RejectOutOfRange: true}),
+	)
+
+	send := api.send
+	count := func(rec *httptest.ResponseRecorder) int {
+		t.Helper()
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var response struct {
+			Data []sqlNote `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		return len(response.Data)
+	}
+
+	assert.Equal(t, 3, count(send("POST", "/resolvespec/sql_notes", `{"operation":"read"}`, nil)), "default limit")
+	assert.Equal(t, 5, count(send("POST", "/resolvespec/sql_notes", `{"operation":"read","options":{"limit":50}}`, nil)), "clamped limit")
+
+	assert.Equal(t, 4, count(send("GET", "/restheadspec/sql_notes", "", map[string]string{"X-DetailApi": "true"})), "max limit without a limit")
+	assert.Equal(t, http.StatusBadRequest, send("GET", "/restheadspec/sql_notes", "", map[string]string{"X-Limit": "50"}).Code)
+	assert.Equal(t, http.StatusBadRequest, send("GET", "/restheadspec/sql_notes", "", map[string]string{"X-Offset": "6"}).Code)
+	assert.Equal(t, http.StatusOK, send("GET", "/restheadspec/sql_notes/7", "", nil).Code, "single record reads have no limit")
+}
+
+// TestRequestLimits rejects oversized bodies and batches and too complex reads before running them
+func TestRequestLimits(t *testing.T) {
+	api := newAPIServer(t, "request_limits",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+	)
+
+	api.register("sql_notes", sqlNote{})
+	config := common.HandlerConfig{MaxBodyBytes: 200, MaxBatchSize: 2, MaxFilters: 2, MaxPreloadDepth: 1}
+	api.serve(config)
+
+	send := api.send
+	rejected := func(rec *httptest.ResponseRecorder, status int, code string) {
+		t.Helper()
+		assert.Equal(t, status, rec.Code, rec.Body.String())
+		assert.Contains(t, rec.Body.String(), code)
+	}
+	rows := func() int {
+		var n int
+		require.NoError(t, api.DB.QueryRow("SELECT COUNT(*) FROM sql_notes").Scan(&n))
+		return n
+	}
+
+	rejected(send("POST", "/restheadspec/sql_notes", `{"title":"`+string(bytes.Repeat([]byte("x"), 300))+`"}`, nil), http.StatusRequestEntityTooLarge, "body_too_large")
+	rejected(send("POST", "/restheadspec/sql_notes", `[{"title":"a"},{"title":"b"},{"title":"c"}]`, nil), http.StatusRequestEntityTooLarge, "batch_too_large")
+	rejected(send("POST", "/restheadspec/sql_notes/_batch", `{"items":[{"action":"create","data":{"title":"a"}},{"action":"create","data":{"title":"b"}},{"action":"create","data":{"title":"c"}}]}`, nil), http.StatusRequestEntityTooLarge, "batch_too_large")
+	rejected(send("POST", "/resolvespec/sql_notes", `{"operation":"create","data":[{"title":"a"},{"title":"b"},{"title":"c"}]}`, nil), http.StatusRequestEntityTooLarge, "batch_too_large")
+	assert.Zero(t, rows(), "rejected writes insert nothing")
+
+	assert.Equal(t, http.StatusOK, send("POST", "/restheadspec/sql_notes", `[{"title":"a"},{"title":"b"}]`, nil).Code)
+	assert.Equal(t, 2, rows())
+
+	rejected(send("GET", "/restheadspec/sql_notes", "", map[string]string{
+		"X-SearchOp-Eq-Title": "a", "X-SearchOp-Gt-Stars": "0", "X-SearchOp-Lt-Id": "9",
+	}), http.StatusBadRequest, "too_many_filters")
+	rejected(send("POST", "/resolvespec/sql_notes", `{"operation":"read","options":{"preload":[{"relation":"Owner.Team"}]}}`, nil), http.StatusBadRequest, "preload_too_deep")
+	assert.Equal(t, http.StatusOK, send("GET", "/restheadspec/sql_notes", "", map[string]string{"X-SearchOp-Eq-Title": "a"}).Code)
+}
+
+// TestFilterGroupDepthLimit rejects filter groups nesting deeper than the limit instead of
+// dropping their deepest levels
+func TestFilterGroupDepthLimit(t *testing.T) {
+	api := newAPIServer(t, "filter_group_depth",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+		"INSERT INTO sql_notes (title, stars) VALUES ('a', 1), ('b', 2)",
+	)
+	api.register("sql_notes", sqlNote{})
+	api.serve(common.HandlerConfig{})
+
+	nested := func(levels int) string {
+		group := `{"filters":[{"column":"stars","operator":"eq","value":1}]}`
+		for i := 1; i < levels; i++ {
+			group = `{"filters":[{"column":"stars","operator":"gte","value":0}],"groups":[` + group + `]}`
+		}
+		return group
+	}
+
+	rec := api.send("POST", "/resolvespec/sql_notes", `{"operation":"read","options":{"filter_group":`+nested(common.MaxFilterGroupDepth)+`}}`, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Data []sqlNote `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Len(t, response.Data, 1, "the deepest level filters")
+
+	rec = api.send("POST", "/resolvespec/sql_notes", `{"operation":"read","options":{"filter_group":`+nested(common.MaxFilterGroupDepth+1)+`}}`, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "filter_group_too_deep")
+
+	rec = api.send("GET", "/restheadspec/sql_notes", "", map[string]string{"X-Filter-Json": nested(common.MaxFilterGroupDepth + 1)})
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "filter_group_too_deep")
+}
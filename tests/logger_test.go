@@ -0,0 +1,105 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/logger"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// TestHandlerLogger logs the lines of a request through the handler logger with its request id
+func TestHandlerLogger(t *testing.T) {
+	api := newAPIServer(t, "handler_logger",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+	)
+
+	var mu sync.Mutex
+	requestIDs := make(map[string]int)
+	record := logger.NewFunc(func(level logger.Level, msg string, fields map[string]interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		requestIDs[fmt.Sprint(fields["request_id"])]++
+	})
+
+	api.register("sql_notes", sqlNote{})
+	api.serveHandlers(
+		resolvespec.NewHandler(api.Adapter, api.Registry, resolvespec.WithLogger(record)),
+		restheadspec.NewHandler(api.Adapter, api.Registry, restheadspec.WithLogger(record)),
+	)
+
+	send := func(method, path, body, requestID string) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("X-Request-ID", requestID)
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+	send("POST", "/restheadspec/sql_notes", `{"title":"alpha"}`, "req-1")
+	send("POST", "/resolvespec/sql_notes", `{"operation":"read"}`, "req-2")
+
+	assert.Positive(t, requestIDs["req-1"])
+	assert.Positive(t, requestIDs["req-2"])
+	assert.Len(t, requestIDs, 2, "every line of the handler logger carries the request id")
+}
+
+// TestRequestID returns the request id in the response header, error responses and hooks
+func TestRequestID(t *testing.T) {
+	api := newAPIServer(t, "request_id",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+	)
+
+	api.register("sql_notes", sqlNote{})
+	rh := restheadspec.NewHandler(api.Adapter, api.Registry)
+	var hookRequestID string
+	rh.Hooks().Register(restheadspec.BeforeRead, func(hookCtx *restheadspec.HookContext) error {
+		hookRequestID = hookCtx.RequestID
+		return nil
+	})
+	api.serveHandlers(resolvespec.NewHandler(api.Adapter, api.Registry), rh)
+
+	send := func(method, path, body, requestID string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if requestID != "" {
+			req.Header.Set(common.RequestIDHeader, requestID)
+		}
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("GET", "/restheadspec/sql_notes", "", "trace-42")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "trace-42", rec.Header().Get(common.RequestIDHeader))
+	assert.Equal(t, "trace-42", hookRequestID)
+
+	rec = send("GET", "/restheadspec/sql_notes", "", "")
+	generated := rec.Header().Get(common.RequestIDHeader)
+	assert.Len(t, generated, 32)
+	assert.Equal(t, generated, hookRequestID)
+	assert.NotEqual(t, "bad id", send("GET", "/restheadspec/sql_notes", "", "bad id").Header().Get(common.RequestIDHeader))
+
+	rec = send("POST", "/resolvespec/sql_notes", `{"operation":"explode"}`, "trace-43")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	var response common.Response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "trace-43", response.Error.RequestID)
+
+	rec = send("GET", "/restheadspec/missing_notes", "", "trace-44")
+	require.GreaterOrEqual(t, rec.Code, http.StatusBadRequest)
+	var headSpecError map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &headSpecError))
+	assert.Equal(t, "trace-44", headSpecError["_request_id"])
+}
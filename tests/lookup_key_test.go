@@ -0,0 +1,62 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type lookupUser struct {
+	ID    int64  `json:"id" gorm:"column:id;primaryKey"`
+	Email string `json:"email" gorm:"column:email;uniqueIndex"`
+	Name  string `json:"name" gorm:"column:name"`
+}
+
+func (lookupUser) TableName() string { return "lookup_users" }
+
+// TestLookupKey reads records by a unique column named by X-Lookup-Key
+func TestLookupKey(t *testing.T) {
+	api := newAPIServer(t, "lookup_key",
+		"CREATE TABLE lookup_users (id INTEGER PRIMARY KEY, email TEXT NOT NULL UNIQUE, name TEXT NOT NULL)",
+		"INSERT INTO lookup_users (id, email, name) VALUES (1, 'jane@example.com', 'Jane'), (2, 'john@example.com', 'John')",
+	)
+
+	api.register("lookup_users", lookupUser{})
+	api.serve(common.HandlerConfig{})
+
+	send := func(method, path, body, lookupKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if lookupKey != "" {
+			req.Header.Set(common.LookupKeyHeader, lookupKey)
+		}
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("GET", "/restheadspec/lookup_users/john@example.com", "", "email")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"name":"John"`)
+	assert.NotContains(t, rec.Body.String(), `"Jane"`)
+
+	rec = send("GET", "/restheadspec/lookup_users/Jane", "", "name")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "only unique columns can be looked up")
+
+	rec = send("GET", "/restheadspec/lookup_users/1", "", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"name":"Jane"`)
+
+	rec = send("POST", "/resolvespec/lookup_users/jane@example.com", `{"operation":"read"}`, "email")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"name":"Jane"`)
+	assert.NotContains(t, rec.Body.String(), `"John"`)
+
+	rec = send("POST", "/resolvespec/lookup_users/Jane", `{"operation":"read"}`, "name")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
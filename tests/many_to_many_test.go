@@ -0,0 +1,85 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/testmodels"
+)
+
+// TestManyToManyWrites attaches, replaces and detaches many2many associations through
+// nested writes of both handlers
+func TestManyToManyWrites(t *testing.T) {
+	db, err := setupStandaloneDB()
+	require.NoError(t, err)
+	defer cleanupStandaloneDB(db)
+
+	resolveSpecHandler, restHeadSpecHandler := setupStandaloneHandlers(db)
+	router := setupStandaloneRouter(resolveSpecHandler, restHeadSpecHandler)
+
+	prefix := fmt.Sprintf("m2m_%d", time.Now().UnixNano())
+	deptID := prefix + "_dept"
+	require.NoError(t, db.Create(&testmodels.Department{ID: deptID, Name: "R&D", Code: deptID}).Error)
+	for _, code := range []string{"p1", "p2"} {
+		require.NoError(t, db.Create(&testmodels.Project{ID: prefix + "_" + code, Name: code, Code: prefix + "_" + code}).Error)
+	}
+	empID := prefix + "_emp"
+
+	send := func(method, path, body string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+	projectIDs := func() []string {
+		t.Helper()
+		var ids []string
+		require.NoError(t, db.Table("employee_projects").Where("employee_id = ?", empID).Order("project_id").Pluck("project_id", &ids).Error)
+		return ids
+	}
+
+	t.Run("create attaches ids and inserted records", func(t *testing.T) {
+		send("POST", "/restheadspec/employees", fmt.Sprintf(`{
+			"id": %[1]q, "first_name": "Mia", "email": "%[1]s@example.com", "department_id": %[2]q,
+			"projects": [%[3]q, {"id": %[4]q, "name": "p3", "code": %[4]q, "_request": "insert"}]
+		}`, empID, deptID, prefix+"_p1", prefix+"_p3"))
+
+		assert.Equal(t, []string{prefix + "_p1", prefix + "_p3"}, projectIDs())
+		var project testmodels.Project
+		require.NoError(t, db.First(&project, "id = ?", prefix+"_p3").Error)
+		assert.Equal(t, "p3", project.Name)
+	})
+
+	t.Run("update replaces the associations", func(t *testing.T) {
+		send("PUT", "/restheadspec/employees/"+empID, fmt.Sprintf(`{
+			"projects": [{"id": %q}, %q]
+		}`, prefix+"_p3", prefix+"_p2"))
+
+		assert.Equal(t, []string{prefix + "_p2", prefix + "_p3"}, projectIDs())
+	})
+
+	t.Run("single object detaches without deleting", func(t *testing.T) {
+		send("POST", "/resolvespec/employees/"+empID, fmt.Sprintf(`{
+			"operation": "update",
+			"data": {"projects": {"id": %q, "_request": "delete"}}
+		}`, prefix+"_p2"))
+
+		assert.Equal(t, []string{prefix + "_p3"}, projectIDs())
+		var count int64
+		require.NoError(t, db.Model(&testmodels.Project{}).Where("id = ?", prefix+"_p2").Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("empty list detaches all", func(t *testing.T) {
+		send("POST", "/resolvespec/employees/"+empID, `{"operation": "update", "data": {"projects": []}}`)
+
+		assert.Empty(t, projectIDs())
+	})
+}
@@ -0,0 +1,187 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/migrations"
+	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
+)
+
+type migrationTeam struct {
+	ID   int64  `gorm:"primaryKey"`
+	Name string `gorm:"not null"`
+}
+
+func (migrationTeam) TableName() string { return "migration_teams" }
+
+// migrationTeamV2 is the next version of migrationTeam
+type migrationTeamV2 struct {
+	ID     int64  `gorm:"primaryKey"`
+	Name   string `gorm:"not null"`
+	Slug   string `gorm:"uniqueIndex"`
+	Region string `gorm:"index;default:'eu'"`
+}
+
+func (migrationTeamV2) TableName() string { return "migration_teams" }
+
+// TestMigrations generates migrations from the registered models and applies and reverts them
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+	sqldb, err := sql.Open("sqlite", "file:migrations?mode=memory&cache=shared")
+	require.NoError(t, err)
+	defer sqldb.Close()
+	db := database.NewSQLAdapter(sqldb, "sqlite")
+	dir := t.TempDir()
+	migrator := migrations.NewMigrator(db, os.DirFS(dir))
+
+	registry := func(model interface{}) common.ModelRegistry {
+		registry := modelregistry.NewModelRegistry()
+		require.NoError(t, registry.RegisterModel("teams", model))
+		return registry
+	}
+	columns := func() []string {
+		introspected, _, err := common.IntrospectTable(ctx, db, "", "migration_teams")
+		require.NoError(t, err)
+		names := make([]string, len(introspected))
+		for i, column := range introspected {
+			names[i] = column.Name
+		}
+		return names
+	}
+
+	// Create the table
+	initial, err := migrations.Generate(ctx, db, registry(migrationTeam{}), dir, "create teams", migrations.DiffOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, initial)
+	assert.Equal(t, "create_teams", initial.Name)
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, []string{"id", "name"}, columns())
+	_, err = db.Exec(ctx, "INSERT INTO migration_teams (name) VALUES ('core')")
+	require.NoError(t, err, "the integer key is generated")
+
+	again, err := migrations.Generate(ctx, db, registry(migrationTeam{}), dir, "noop", migrations.DiffOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, again, "the database matches the models")
+
+	// Add columns and indexes
+	changes, err := migrations.Diff(ctx, db, registry(migrationTeamV2{}), migrations.DiffOptions{})
+	require.NoError(t, err)
+	kinds := make([]migrations.ChangeKind, len(changes))
+	for i, change := range changes {
+		kinds[i] = change.Kind
+	}
+	assert.Equal(t, []migrations.ChangeKind{migrations.AddColumn, migrations.AddColumn, migrations.CreateIndex, migrations.CreateIndex}, kinds)
+
+	second := migrations.NewMigration(time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC), "team slugs", changes)
+	require.NoError(t, migrations.WriteMigration(dir, second))
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "name", "slug", "region"}, columns())
+	_, err = db.Exec(ctx, "INSERT INTO migration_teams (name, slug) VALUES ('a', 'x'), ('b', 'x')")
+	assert.Error(t, err, "slugs are unique")
+
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status, 2)
+	assert.True(t, status[0].Applied && status[1].Applied)
+	assert.False(t, status[1].AppliedAt.IsZero())
+
+	// Columns of hand-written SQL are only dropped on request
+	_, err = db.Exec(ctx, "ALTER TABLE migration_teams ADD COLUMN legacy text")
+	require.NoError(t, err)
+	changes, err = migrations.Diff(ctx, db, registry(migrationTeamV2{}), migrations.DiffOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	changes, err = migrations.Diff(ctx, db, registry(migrationTeamV2{}), migrations.DiffOptions{Drop: true})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "drop_column migration_teams.legacy", changes[0].String())
+	_, err = db.Exec(ctx, "ALTER TABLE migration_teams DROP COLUMN legacy")
+	require.NoError(t, err)
+
+	// Revert
+	reverted, err := migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, "team_slugs", reverted[0].Name)
+	assert.Equal(t, []string{"id", "name"}, columns())
+
+	reverted, err = migrator.Down(ctx, 5)
+	require.NoError(t, err)
+	assert.Len(t, reverted, 1)
+	assert.Empty(t, columns(), "the table is dropped")
+
+	status, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, status[0].Applied || status[1].Applied)
+
+	// A failing migration is rolled back with its record
+	require.NoError(t, os.WriteFile(dir+"/30000101000000_broken.up.sql", []byte("CREATE TABLE broken (id int); SELECT * FROM missing;"), 0o600))
+	require.NoError(t, os.Remove(dir+"/"+second.Version+"_team_slugs.up.sql"))
+	require.NoError(t, os.Remove(dir+"/"+second.Version+"_team_slugs.down.sql"))
+	applied, err = migrator.Up(ctx)
+	assert.Error(t, err)
+	assert.Len(t, applied, 1)
+	var tables int
+	require.NoError(t, sqldb.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'broken'").Scan(&tables))
+	assert.Zero(t, tables)
+}
+
+type indexedTask struct {
+	ID        int64  `gorm:"primaryKey"`
+	ProjectID int64  `resolvespec:"index:idx_indexed_tasks_project_status"`
+	Status    string `resolvespec:"index:idx_indexed_tasks_project_status"`
+	Code      string
+}
+
+func (indexedTask) TableName() string { return "indexed_tasks" }
+
+func (indexedTask) Indexes() []common.IndexSpec {
+	return []common.IndexSpec{{Name: "idx_indexed_tasks_open_code", Columns: []string{"code"}, Unique: true, Where: "status <> 'done'"}}
+}
+
+// TestEnsureIndexes creates the declared indexes of tables created without them, as by AutoMigrate
+func TestEnsureIndexes(t *testing.T) {
+	ctx := context.Background()
+	sqldb, err := sql.Open("sqlite", "file:ensure_indexes?mode=memory&cache=shared")
+	require.NoError(t, err)
+	defer sqldb.Close()
+	db := database.NewSQLAdapter(sqldb, "sqlite")
+	registry := modelregistry.NewModelRegistry()
+	require.NoError(t, registry.RegisterModel("tasks", indexedTask{}))
+
+	_, err = migrations.EnsureIndexes(ctx, db, registry)
+	assert.Error(t, err, "the table doesn't exist")
+
+	_, err = db.Exec(ctx, "CREATE TABLE indexed_tasks (id integer PRIMARY KEY, project_id integer, status text, code text)")
+	require.NoError(t, err)
+	created, err := migrations.EnsureIndexes(ctx, db, registry)
+	require.NoError(t, err)
+	require.Len(t, created, 2)
+	assert.Equal(t, "idx_indexed_tasks_project_status", created[0].Name)
+	assert.Equal(t, "idx_indexed_tasks_open_code", created[1].Name)
+
+	created, err = migrations.EnsureIndexes(ctx, db, registry)
+	require.NoError(t, err)
+	assert.Empty(t, created, "existing indexes are kept")
+	changes, err := migrations.Diff(ctx, db, registry, migrations.DiffOptions{Drop: true})
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	// The partial unique index only covers open tasks
+	_, err = db.Exec(ctx, "INSERT INTO indexed_tasks (project_id, status, code) VALUES (1, 'done', 'a'), (1, 'done', 'a'), (1, 'open', 'a')")
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, "INSERT INTO indexed_tasks (project_id, status, code) VALUES (1, 'open', 'a')")
+	assert.Error(t, err)
+}
@@ -0,0 +1,55 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// TestMinimalUpdates sets only the changed columns and skips updates changing nothing
+func TestMinimalUpdates(t *testing.T) {
+	api := newAPIServer(t, "minimal_updates",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+		"CREATE TABLE note_updates (id INTEGER PRIMARY KEY AUTOINCREMENT, note_id INTEGER)",
+		"CREATE TRIGGER count_note_updates AFTER UPDATE ON sql_notes BEGIN INSERT INTO note_updates (note_id) VALUES (NEW.id); END",
+		"INSERT INTO sql_notes (title, stars) VALUES ('a', 1)",
+	)
+
+	api.register("sql_notes", sqlNote{})
+	config := common.HandlerConfig{MinimalUpdates: true}
+	api.serve(config)
+
+	send := api.send
+	updates := func() int {
+		var n int
+		require.NoError(t, api.DB.QueryRow("SELECT COUNT(*) FROM note_updates").Scan(&n))
+		return n
+	}
+
+	rec := send("PUT", "/restheadspec/sql_notes/1", `{"title":"a","stars":3}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "stars", rec.Header().Get(common.ChangedColumnsHeader))
+	assert.Equal(t, 1, updates())
+
+	rec = send("PUT", "/restheadspec/sql_notes/1", `{"id":1,"title":"a","stars":3}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Empty(t, rec.Header().Get(common.ChangedColumnsHeader))
+	assert.Contains(t, rec.Body.String(), `"stars":3`)
+	assert.Equal(t, 1, updates(), "an unchanged record is not updated")
+
+	rec = send("POST", "/resolvespec/sql_notes/1", `{"operation":"update","data":{"title":"b","stars":3}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "title", rec.Header().Get(common.ChangedColumnsHeader))
+	assert.Equal(t, 2, updates())
+
+	rec = send("POST", "/resolvespec/sql_notes/1", `{"operation":"update","data":{"title":"b"}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, 2, updates())
+
+	rec = send("POST", "/resolvespec/sql_notes/9", `{"operation":"update","data":{"title":"b"}}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
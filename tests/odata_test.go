@@ -0,0 +1,87 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/testmodels"
+)
+
+// TestODataQueryOptions reads a restheadspec endpoint with OData system query options
+func TestODataQueryOptions(t *testing.T) {
+	db, err := setupStandaloneDB()
+	require.NoError(t, err)
+	defer cleanupStandaloneDB(db)
+
+	resolveSpecHandler, restHeadSpecHandler := setupStandaloneHandlers(db)
+	router := setupStandaloneRouter(resolveSpecHandler, restHeadSpecHandler)
+
+	deptID := fmt.Sprintf("odata_%d", time.Now().UnixNano())
+	require.NoError(t, db.Create(&testmodels.Department{ID: deptID, Name: "Logistics", Code: deptID}).Error)
+	for idx, name := range []string{"Anna", "Bart", "Carla", "Dan"} {
+		require.NoError(t, db.Create(&testmodels.Employee{
+			ID:           fmt.Sprintf("%s_e%d", deptID, idx),
+			FirstName:    name,
+			Email:        fmt.Sprintf("%s_e%d@example.com", deptID, idx),
+			DepartmentID: deptID,
+			Status:       "active",
+		}).Error)
+	}
+
+	get := func(query url.Values) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", "/restheadspec/employees?"+query.Encode(), nil))
+		return rec
+	}
+
+	t.Run("filter, order, page and expand", func(t *testing.T) {
+		rec := get(url.Values{
+			"$filter":  {fmt.Sprintf("department_id eq '%s' and (contains(first_name,'a') or first_name eq 'Dan')", deptID)},
+			"$orderby": {"first_name desc"},
+			"$top":     {"2"},
+			"$skip":    {"1"},
+			"$select":  {"id,first_name,department_id"},
+			"$expand":  {"department($select=id,name)"},
+			"$count":   {"true"},
+		})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var response struct {
+			Count int64 `json:"@odata.count"`
+			Value []struct {
+				FirstName  string `json:"first_name"`
+				Department *struct {
+					Name string `json:"name"`
+				} `json:"department"`
+			} `json:"value"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+		// Anna, Bart and Carla contain an "a", Dan matches by name
+		assert.Equal(t, int64(4), response.Count)
+		require.Len(t, response.Value, 2)
+		assert.Equal(t, "Carla", response.Value[0].FirstName)
+		assert.Equal(t, "Bart", response.Value[1].FirstName)
+		require.NotNil(t, response.Value[0].Department)
+		assert.Equal(t, "Logistics", response.Value[0].Department.Name)
+	})
+
+	t.Run("in and not", func(t *testing.T) {
+		rec := get(url.Values{
+			"$filter": {fmt.Sprintf("department_id eq '%s' and first_name in ('Anna','Bart','Dan') and not (first_name eq 'Anna' or first_name eq 'Bart')", deptID)},
+		})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Contains(t, rec.Body.String(), "Dan")
+		assert.NotContains(t, rec.Body.String(), "Anna")
+		assert.NotContains(t, rec.Body.String(), "Bart")
+		assert.NotContains(t, rec.Body.String(), "Carla")
+	})
+}
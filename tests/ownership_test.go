@@ -0,0 +1,78 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type ownedTask struct {
+	ID      int64  `json:"id" bun:"id,pk,autoincrement"`
+	OwnerID string `json:"owner_id" bun:"owner_id"`
+	Title   string `json:"title" bun:"title"`
+}
+
+func (ownedTask) TableName() string { return "owned_tasks" }
+
+// TestOnlyMine restricts reads with X-Only-Mine to the records of the user of the request
+func TestOnlyMine(t *testing.T) {
+	api := newAPIServer(t, "only_mine",
+		"CREATE TABLE owned_tasks (id INTEGER PRIMARY KEY AUTOINCREMENT, owner_id TEXT NOT NULL, title TEXT NOT NULL)",
+		"INSERT INTO owned_tasks (owner_id, title) VALUES ('alice', 'a'), ('bob', 'b'), ('alice', 'c')",
+	)
+
+	api.register("owned_tasks", ownedTask{})
+	config := common.HandlerConfig{
+		Entities: map[string]common.EntityConfig{"owned_tasks": {OwnerColumn: "owner_id"}},
+		AuditUser: func(ctx context.Context) (string, bool) {
+			user, ok := ctx.Value(auditUserKey{}).(string)
+			return user, ok && user != ""
+		},
+	}
+	withUser := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), auditUserKey{}, r.Header.Get("X-User"))))
+		})
+	}
+	router := withUser(api.serve(config).Router)
+
+	send := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("GET", "/restheadspec/owned_tasks", "", map[string]string{"X-User": "alice", "X-Only-Mine": "true"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var tasks []ownedTask
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tasks))
+	assert.Len(t, tasks, 2)
+
+	rec = send("POST", "/resolvespec/owned_tasks", `{"operation":"read"}`, map[string]string{"X-User": "bob", "X-Only-Mine": "true"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Data []ownedTask `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, "b", response.Data[0].Title)
+
+	rec = send("GET", "/restheadspec/owned_tasks", "", map[string]string{"X-User": "alice"})
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tasks))
+	assert.Len(t, tasks, 3, "without the header every record is read")
+
+	assert.Equal(t, http.StatusUnauthorized, send("GET", "/restheadspec/owned_tasks", "", map[string]string{"X-Only-Mine": "true"}).Code)
+}
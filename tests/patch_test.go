@@ -0,0 +1,65 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPatchDocuments applies JSON Merge Patch and JSON Patch bodies of a PATCH to the current record
+func TestPatchDocuments(t *testing.T) {
+	api := newAPIServer(t, "patch_documents",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+		"INSERT INTO sql_notes (title, stars) VALUES ('draft', 1)",
+	)
+
+	api.register("sql_notes", sqlNote{})
+	api.serve(common.HandlerConfig{})
+
+	send := func(method, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/restheadspec/sql_notes/1", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, req)
+		return rec
+	}
+	note := func() (title string, stars int) {
+		require.NoError(t, api.DB.QueryRow("SELECT title, stars FROM sql_notes WHERE id = 1").Scan(&title, &stars))
+		return title, stars
+	}
+
+	rec := send("PATCH", "application/merge-patch+json", `{"stars":5}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	title, stars := note()
+	assert.Equal(t, "draft", title)
+	assert.Equal(t, 5, stars)
+
+	rec = send("PATCH", "application/json-patch+json", `[{"op":"test","path":"/title","value":"draft"},{"op":"replace","path":"/title","value":"final"}]`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	title, _ = note()
+	assert.Equal(t, "final", title)
+
+	// A failing test operation changes nothing
+	rec = send("PATCH", "application/json-patch+json", `[{"op":"test","path":"/title","value":"draft"},{"op":"replace","path":"/stars","value":0}]`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	_, stars = note()
+	assert.Equal(t, 5, stars)
+
+	rec = send("PATCH", "application/json-patch+json", `[{"op":"replace","path":"/id","value":9}]`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	rec = send("PATCH", "application/json-patch+json", `{"title":"x"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = send("PUT", "application/merge-patch+json", `{"stars":1}`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	req := httptest.NewRequest("PATCH", "/restheadspec/sql_notes/7", strings.NewReader(`{"stars":1}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rec = httptest.NewRecorder()
+	api.Router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/testmodels"
+)
+
+// TestPreloadOptions checks that preload filters, sorting, limits and offsets are
+// enforced per parent record by both handlers
+func TestPreloadOptions(t *testing.T) {
+	db, err := setupStandaloneDB()
+	require.NoError(t, err)
+	defer cleanupStandaloneDB(db)
+
+	resolveSpecHandler, restHeadSpecHandler := setupStandaloneHandlers(db)
+	router := setupStandaloneRouter(resolveSpecHandler, restHeadSpecHandler)
+
+	prefix := fmt.Sprintf("preload_%d", time.Now().UnixNano())
+	for _, dept := range []string{"a", "b"} {
+		deptID := prefix + "_" + dept
+		require.NoError(t, db.Create(&testmodels.Department{ID: deptID, Name: dept, Code: deptID}).Error)
+		for idx, name := range []string{"Anna", "Bart", "Carla", "Dan"} {
+			require.NoError(t, db.Create(&testmodels.Employee{
+				ID:           fmt.Sprintf("%s_e%d", deptID, idx),
+				FirstName:    name,
+				Email:        fmt.Sprintf("%s_e%d@example.com", deptID, idx),
+				DepartmentID: deptID,
+			}).Error)
+		}
+	}
+
+	type department struct {
+		ID        string `json:"id"`
+		Employees []struct {
+			FirstName string `json:"first_name"`
+		} `json:"employees"`
+	}
+	firstNames := func(depts []department) map[string][]string {
+		names := make(map[string][]string, len(depts))
+		for _, dept := range depts {
+			names[dept.ID] = []string{}
+			for _, emp := range dept.Employees {
+				names[dept.ID] = append(names[dept.ID], emp.FirstName)
+			}
+		}
+		return names
+	}
+	// Dan is filtered out, the rest is sorted descending and paged per department
+	want := map[string][]string{
+		prefix + "_a": {"Bart", "Anna"},
+		prefix + "_b": {"Bart", "Anna"},
+	}
+
+	t.Run("restheadspec", func(t *testing.T) {
+		query := url.Values{
+			"$filter": {fmt.Sprintf("startswith(id,'%s')", prefix)},
+			"$expand": {"employees($filter=first_name ne 'Dan';$orderby=first_name desc;$skip=1;$top=2)"},
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", "/restheadspec/departments?"+query.Encode(), nil))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var response struct {
+			Value []department `json:"value"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, want, firstNames(response.Value))
+	})
+
+	t.Run("resolvespec", func(t *testing.T) {
+		body := fmt.Sprintf(`{
+			"operation": "read",
+			"options": {
+				"filters": [{"column": "id", "operator": "like", "value": "%s%%"}],
+				"preload": [{
+					"relation": "employees",
+					"filters": [{"column": "first_name", "operator": "neq", "value": "Dan"}],
+					"sort": [{"column": "first_name", "direction": "desc"}],
+					"offset": 1,
+					"limit": 2
+				}]
+			}
+		}`, prefix)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("POST", "/resolvespec/departments", bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var response struct {
+			Data []department `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, want, firstNames(response.Data))
+	})
+}
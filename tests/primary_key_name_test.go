@@ -0,0 +1,65 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type department struct {
+	RidDepartment int64  `json:"rid_department" gorm:"column:rid_department;primaryKey"`
+	Name          string `json:"name" gorm:"column:name"`
+}
+
+func (department) TableName() string { return "departments" }
+
+// TestPrimaryKeyNotNamedID reads, updates and deletes records by a primary key column not named id
+func TestPrimaryKeyNotNamedID(t *testing.T) {
+	api := newAPIServer(t, "primary_key_name",
+		"CREATE TABLE departments (rid_department INTEGER PRIMARY KEY, name TEXT NOT NULL)",
+		"INSERT INTO departments (rid_department, name) VALUES (10, 'sales'), (20, 'support'), (30, 'finance')",
+	)
+
+	api.register("departments", department{})
+	api.serve(common.HandlerConfig{})
+
+	send := api.send
+	name := func(rid int) string {
+		var n string
+		if err := api.DB.QueryRow("SELECT name FROM departments WHERE rid_department = ?", rid).Scan(&n); err != nil {
+			return ""
+		}
+		return n
+	}
+
+	rec := send("GET", "/restheadspec/departments/20", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"name":"support"`)
+	assert.NotContains(t, rec.Body.String(), `"sales"`)
+
+	rec = send("PUT", "/restheadspec/departments", `{"rid_department":20,"name":"helpdesk"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "helpdesk", name(20))
+	assert.Equal(t, "sales", name(10))
+
+	rec = send("POST", "/resolvespec/departments", `{"operation":"update","data":{"rid_department":30,"name":"accounting"}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "accounting", name(30))
+	assert.Equal(t, "helpdesk", name(20), "only the addressed record is updated")
+
+	rec = send("POST", "/resolvespec/departments", `{"operation":"update","data":[{"rid_department":10,"name":"sales eu"}]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "sales eu", name(10))
+
+	rec = send("POST", "/resolvespec/departments", `{"operation":"delete","data":{"rid_department":30}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Empty(t, name(30))
+
+	rec = send("DELETE", "/restheadspec/departments", `[{"rid_department":20}]`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Empty(t, name(20))
+	assert.Equal(t, "sales eu", name(10))
+}
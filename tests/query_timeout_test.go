@@ -0,0 +1,51 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// TestQueryTimeout answers 504 when the queries of a request exceed the query timeout
+func TestQueryTimeout(t *testing.T) {
+	api := newAPIServer(t, "query_timeout",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+	)
+
+	// Every read takes 200ms
+	slow := database.NewChaosAdapter(database.NewSQLAdapter(api.DB, "sqlite"), database.ChaosConfig{
+		LatencyRate: 1, MinLatency: 200 * time.Millisecond, MaxLatency: 200 * time.Millisecond,
+	})
+	api.register("sql_notes", sqlNote{})
+	api.serveHandlers(
+		resolvespec.NewHandlerWithConfig(slow, api.Registry, common.HandlerConfig{QueryTimeout: 20 * time.Millisecond}),
+		restheadspec.NewHandlerWithConfig(slow, api.Registry, common.HandlerConfig{QueryTimeout: time.Second, MaxQueryTimeout: time.Second}),
+	)
+
+	send := api.send
+
+	rec := send("POST", "/resolvespec/sql_notes", `{"operation":"read"}`, nil)
+	require.Equal(t, http.StatusGatewayTimeout, rec.Code, rec.Body.String())
+	var response common.Response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.NotNil(t, response.Error)
+	assert.Equal(t, "request_timeout", response.Error.Code)
+
+	rec = send("GET", "/restheadspec/sql_notes", "", map[string]string{common.QueryTimeoutHeader: "20ms"})
+	require.Equal(t, http.StatusGatewayTimeout, rec.Code, rec.Body.String())
+	var headSpecError map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &headSpecError))
+	assert.Equal(t, "request_timeout", headSpecError["_code"])
+
+	assert.Equal(t, http.StatusOK, send("GET", "/restheadspec/sql_notes", "", nil).Code, "within the handler timeout")
+	assert.Equal(t, http.StatusBadRequest, send("GET", "/restheadspec/sql_notes", "", map[string]string{common.QueryTimeoutHeader: "soon"}).Code)
+}
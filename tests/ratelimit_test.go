@@ -0,0 +1,53 @@
+package test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/ratelimit"
+)
+
+// TestRateLimiting checks that both handlers answer 429 with Retry-After once the
+// bucket of an entity is empty
+func TestRateLimiting(t *testing.T) {
+	db, err := setupStandaloneDB()
+	require.NoError(t, err)
+	defer cleanupStandaloneDB(db)
+
+	resolveSpecHandler, restHeadSpecHandler := setupStandaloneHandlers(db)
+	limiter := ratelimit.New(ratelimit.Config{},
+		ratelimit.Rule{Entity: "departments", Operations: []string{ratelimit.OperationRead}, Rate: 0.01, Burst: 2},
+	)
+	resolveSpecHandler.SetRateLimiter(limiter)
+	restHeadSpecHandler.SetRateLimiter(limiter)
+	router := setupStandaloneRouter(resolveSpecHandler, restHeadSpecHandler)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		return rec
+	}
+
+	// Both APIs share the bucket of the limiter
+	assert.Equal(t, http.StatusOK, do("GET", "/restheadspec/departments", "").Code)
+	assert.Equal(t, http.StatusOK, do("POST", "/resolvespec/departments", `{"operation":"read"}`).Code)
+
+	rec := do("GET", "/restheadspec/departments", "")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "100", rec.Header().Get("Retry-After"))
+
+	rec = do("POST", "/resolvespec/departments", `{"operation":"read"}`)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+	assert.Contains(t, rec.Body.String(), "rate_limited")
+
+	// Other entities and operations are not limited
+	assert.Equal(t, http.StatusOK, do("GET", "/restheadspec/employees", "").Code)
+	assert.NotEqual(t, http.StatusTooManyRequests, do("POST", "/resolvespec/departments",
+		`{"operation":"create","data":{"id":"rl_1","name":"Rate","code":"rl_1"}}`).Code)
+}
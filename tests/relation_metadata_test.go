@@ -0,0 +1,83 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/router"
+	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+	"github.com/bitechdev/ResolveSpec/pkg/testmodels"
+)
+
+// TestRelationMetadata describes the relations of a model in the metadata of HandleGet
+func TestRelationMetadata(t *testing.T) {
+	registry := modelregistry.NewModelRegistry()
+	testmodels.RegisterTestModels(registry)
+	params := map[string]string{"schema": "", "entity": "employees"}
+
+	relationsByName := func(metadata common.TableMetadata) map[string]common.RelationMetadata {
+		relations := make(map[string]common.RelationMetadata, len(metadata.Relations))
+		for _, relation := range metadata.Relations {
+			relations[relation.Name] = relation
+		}
+		return relations
+	}
+	columnNames := func(metadata common.TableMetadata) []string {
+		names := make([]string, 0, len(metadata.Columns))
+		for _, column := range metadata.Columns {
+			names = append(names, column.Name)
+		}
+		return names
+	}
+
+	rh := restheadspec.NewHandler(nil, registry)
+	rec := httptest.NewRecorder()
+	rh.HandleGet(router.NewHTTPResponseWriter(rec), router.NewHTTPRequest(httptest.NewRequest("GET", "/restheadspec/employees", nil)), params)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var metadata common.TableMetadata
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &metadata))
+
+	relations := relationsByName(metadata)
+	assert.Equal(t, common.RelationMetadata{
+		Name:         "department",
+		Type:         "belongsTo",
+		ForeignKey:   "DepartmentID",
+		References:   "ID",
+		TargetEntity: "departments",
+		Preloadable:  true,
+		Expandable:   true,
+	}, relations["department"])
+	assert.Equal(t, "hasMany", relations["reports"].Type)
+	assert.Equal(t, "employees", relations["reports"].TargetEntity)
+	assert.False(t, relations["reports"].Expandable, "hasMany relations can't be joined")
+	assert.Equal(t, "many2many", relations["projects"].Type)
+	assert.Equal(t, "employee_projects", relations["projects"].JoinTable)
+	assert.Equal(t, "projects", relations["projects"].TargetEntity)
+	assert.Contains(t, columnNames(metadata), "department_id")
+	assert.NotContains(t, columnNames(metadata), "department", "relations are not columns")
+
+	rs := resolvespec.NewHandler(nil, registry)
+	rec = httptest.NewRecorder()
+	rs.HandleGet(router.NewHTTPResponseWriter(rec), router.NewHTTPRequest(httptest.NewRequest("GET", "/resolvespec/employees", nil)), params)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Data common.TableMetadata `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+	relations = relationsByName(response.Data)
+	assert.Equal(t, "belongsTo", relations["department"].Type)
+	assert.Equal(t, "departments", relations["department"].TargetEntity)
+	assert.True(t, relations["department"].Preloadable)
+	assert.False(t, relations["department"].Expandable, "resolvespec has no expand")
+	assert.Equal(t, "many2many", relations["projects"].Type)
+	assert.NotContains(t, columnNames(response.Data), "projects")
+}
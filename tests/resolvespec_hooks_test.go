@@ -0,0 +1,137 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/hooks"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+type hookedNote struct {
+	ID     int64  `json:"id" bun:"id,pk,autoincrement"`
+	Title  string `json:"title" bun:"title"`
+	Source string `json:"source" bun:"source"`
+}
+
+func (hookedNote) TableName() string { return "hooked_notes" }
+
+// TestResolveSpecHooks runs the lifecycle hooks of the body-based API
+func TestResolveSpecHooks(t *testing.T) {
+	api := newAPIServer(t, "resolvespec_hooks",
+		"CREATE TABLE hooked_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, source TEXT NOT NULL DEFAULT '')",
+		"INSERT INTO hooked_notes (title, source) VALUES ('hidden', 'import')",
+	)
+
+	api.register("hooked_notes", hookedNote{})
+	handler := resolvespec.NewHandler(api.Adapter, api.Registry)
+	api.serveHandlers(handler, restheadspec.NewHandler(api.Adapter, api.Registry))
+
+	var events []resolvespec.HookType
+	record := func(hookType resolvespec.HookType) resolvespec.HookFunc {
+		return func(ctx *resolvespec.HookContext) error {
+			events = append(events, hookType)
+			return nil
+		}
+	}
+	handler.Hooks().RegisterMultiple([]resolvespec.HookType{resolvespec.BeforeRead, resolvespec.BeforeScan, resolvespec.AfterScan}, func(ctx *resolvespec.HookContext) error {
+		events = append(events, "read")
+		return nil
+	})
+	handler.Hooks().Register(resolvespec.AfterRead, record(resolvespec.AfterRead))
+	handler.Hooks().Register(resolvespec.BeforeCreate, func(ctx *resolvespec.HookContext) error {
+		ctx.Data.(map[string]interface{})["source"] = "hook"
+		return nil
+	})
+	handler.Hooks().Register(resolvespec.AfterCreate, record(resolvespec.AfterCreate))
+	handler.Hooks().Register(resolvespec.BeforeScan, func(ctx *resolvespec.HookContext) error {
+		if query, ok := ctx.Query.(common.SelectQuery); ok {
+			ctx.Query = query.Where("source <> ?", "import")
+		}
+		return nil
+	})
+	handler.Hooks().Register(resolvespec.BeforeDelete, func(ctx *resolvespec.HookContext) error {
+		return errors.New("notes can't be deleted")
+	})
+
+	send := func(body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/resolvespec/hooked_notes", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send(`{"operation":"create","data":{"title":"first"}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var source string
+	require.NoError(t, api.DB.QueryRow("SELECT source FROM hooked_notes WHERE title = 'first'").Scan(&source))
+	assert.Equal(t, "hook", source)
+	assert.Equal(t, []resolvespec.HookType{resolvespec.AfterCreate}, events)
+
+	events = nil
+	rec = send(`{"operation":"read"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Data []hookedNote `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, "first", response.Data[0].Title)
+	assert.Equal(t, []resolvespec.HookType{"read", "read", "read", resolvespec.AfterRead}, events)
+
+	rec = send(`{"operation":"delete","data":{"id":1}}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "hook_error")
+	var count int
+	require.NoError(t, api.DB.QueryRow("SELECT COUNT(*) FROM hooked_notes").Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+// TestHookResponse answers a request from a hook scoped to its entity
+func TestHookResponse(t *testing.T) {
+	api := newAPIServer(t, "hook_response",
+		"CREATE TABLE hooked_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, source TEXT NOT NULL DEFAULT '')",
+	)
+
+	api.register("hooked_notes", hookedNote{})
+	rs := resolvespec.NewHandler(api.Adapter, api.Registry)
+	rh := restheadspec.NewHandler(api.Adapter, api.Registry)
+	api.serveHandlers(rs, rh)
+
+	cached := []map[string]interface{}{{"id": 7, "title": "cached"}}
+	rh.Hooks().Register(restheadspec.BeforeRead, func(ctx *restheadspec.HookContext) error {
+		return &hooks.Response{Header: map[string]string{"X-Cache": "hit"}, Body: cached}
+	}, hooks.ForEntity("hooked_*"))
+	rh.Hooks().Register(restheadspec.BeforeRead, func(ctx *restheadspec.HookContext) error {
+		return errors.New("runs after the cached response")
+	}, hooks.ForEntity("hooked_notes"), hooks.WithPriority(1))
+	rs.Hooks().Register(resolvespec.BeforeCreate, func(ctx *resolvespec.HookContext) error {
+		return hooks.Respond(http.StatusAccepted, map[string]string{"status": "queued"})
+	}, hooks.ForEntity("*.hooked_notes", "hooked_notes"))
+
+	req := httptest.NewRequest("GET", "/restheadspec/hooked_notes", nil)
+	rec := httptest.NewRecorder()
+	api.Router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "hit", rec.Header().Get("X-Cache"))
+	assert.JSONEq(t, `[{"id":7,"title":"cached"}]`, rec.Body.String())
+
+	req = httptest.NewRequest("POST", "/resolvespec/hooked_notes", strings.NewReader(`{"operation":"create","data":{"title":"later"}}`))
+	rec = httptest.NewRecorder()
+	api.Router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"status":"queued"}`, rec.Body.String())
+	var count int
+	require.NoError(t, api.DB.QueryRow("SELECT COUNT(*) FROM hooked_notes").Scan(&count))
+	assert.Zero(t, count)
+}
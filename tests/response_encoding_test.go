@@ -0,0 +1,190 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/router"
+)
+
+type encodedTag struct {
+	ID   int64  `json:"id" gorm:"column:id;primaryKey"`
+	Name string `json:"name" gorm:"column:name"`
+}
+
+func (encodedTag) TableName() string {
+	return "encoded_tags"
+}
+
+// TestResponseEncodings answers reads in the MessagePack or CBOR encoding of the Accept header
+func TestResponseEncodings(t *testing.T) {
+	api := newAPIServer(t, "response_encoding",
+		"CREATE TABLE encoded_tags (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL)",
+		"INSERT INTO encoded_tags (name) VALUES ('a'), ('b')",
+	)
+
+	api.register("tags", encodedTag{})
+	api.serve(common.HandlerConfig{})
+	read := func(accept string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/restheadspec/tags?x-sort=id", nil)
+		req.Header.Set("Accept", accept)
+		api.Router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		return rec
+	}
+
+	msgpack := read("application/msgpack")
+	assert.Equal(t, "application/msgpack", msgpack.Header().Get("Content-Type"))
+	assert.Equal(t, []byte{
+		0x92,
+		0x82, 0xa2, 'i', 'd', 0x01, 0xa4, 'n', 'a', 'm', 'e', 0xa1, 'a',
+		0x82, 0xa2, 'i', 'd', 0x02, 0xa4, 'n', 'a', 'm', 'e', 0xa1, 'b',
+	}, msgpack.Body.Bytes())
+
+	cbor := read("application/cbor;q=0.9, application/json;q=0.5")
+	assert.Equal(t, "application/cbor", cbor.Header().Get("Content-Type"))
+	assert.Equal(t, []byte{
+		0x82,
+		0xa2, 0x62, 'i', 'd', 0x01, 0x64, 'n', 'a', 'm', 'e', 0x61, 'a',
+		0xa2, 0x62, 'i', 'd', 0x02, 0x64, 'n', 'a', 'm', 'e', 0x61, 'b',
+	}, cbor.Body.Bytes())
+
+	json := read("*/*")
+	assert.Equal(t, "application/json", json.Header().Get("Content-Type"))
+	assert.JSONEq(t, `[{"id":1,"name":"a"},{"id":2,"name":"b"}]`, json.Body.String())
+}
+
+// TestStreamedJSONResponses returns large result sets streamed row by row in full
+func TestStreamedJSONResponses(t *testing.T) {
+	api := newAPIServer(t, "streamed_json",
+		"CREATE TABLE encoded_tags (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL)",
+	)
+	rows := 2*common.StreamJSONMinRows + 1
+	for i := 0; i < rows; i++ {
+		_, err := api.DB.Exec("INSERT INTO encoded_tags (name) VALUES (?)", fmt.Sprintf("tag <%d>", i))
+		require.NoError(t, err)
+	}
+
+	api.register("tags", encodedTag{})
+	api.serve(common.HandlerConfig{})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/restheadspec/tags?x-sort=id", nil)
+	req.Header.Set("X-DetailApi", "true")
+	api.Router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var detail struct {
+		Data     []encodedTag     `json:"data"`
+		Metadata *common.Metadata `json:"metadata"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+	require.Len(t, detail.Data, rows)
+	assert.Equal(t, "tag <0>", detail.Data[0].Name)
+	assert.Equal(t, fmt.Sprintf("tag <%d>", rows-1), detail.Data[rows-1].Name)
+	require.NotNil(t, detail.Metadata)
+	assert.EqualValues(t, rows, detail.Metadata.Total)
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/resolvespec/tags", strings.NewReader(`{"operation": "read"}`))
+	api.Router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Success bool         `json:"success"`
+		Data    []encodedTag `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.True(t, response.Success)
+	assert.Len(t, response.Data, rows)
+}
+
+// encodedName fails to encode the name "bad"
+type encodedName string
+
+func (n encodedName) MarshalJSON() ([]byte, error) {
+	if n == "bad" {
+		return nil, errors.New("name can't be encoded")
+	}
+	return json.Marshal(string(n))
+}
+
+type failingTag struct {
+	ID   int64       `json:"id" gorm:"column:id;primaryKey"`
+	Name encodedName `json:"name" gorm:"column:name"`
+}
+
+func (failingTag) TableName() string {
+	return "encoded_tags"
+}
+
+// TestStreamedJSONRowError aborts the connection when a row of a streamed response fails to
+// encode, so clients can't take the rows before it for the whole response
+func TestStreamedJSONRowError(t *testing.T) {
+	api := newAPIServer(t, "streamed_json_error",
+		"CREATE TABLE encoded_tags (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL)",
+	)
+	rows := 2 * common.StreamJSONMinRows
+	for i := 0; i < rows; i++ {
+		name := fmt.Sprintf("tag %d", i)
+		if i == rows-10 {
+			name = "bad"
+		}
+		_, err := api.DB.Exec("INSERT INTO encoded_tags (name) VALUES (?)", name)
+		require.NoError(t, err)
+	}
+
+	api.register("tags", failingTag{})
+	api.serve(common.HandlerConfig{})
+	server := httptest.NewServer(api.Router)
+	defer server.Close()
+
+	for _, request := range []struct{ method, path, body string }{
+		{"GET", "/restheadspec/tags?x-sort=id", ""},
+		{"POST", "/resolvespec/tags", `{"operation": "read"}`},
+	} {
+		req, err := http.NewRequest(request.method, server.URL+request.path, strings.NewReader(request.body))
+		require.NoError(t, err)
+		resp, err := server.Client().Do(req)
+		if err == nil {
+			_, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+		assert.Error(t, err, "%s %s reads a complete response", request.method, request.path)
+	}
+}
+
+// TestWriteJSONError returns the error of data that fails to encode without writing any of it,
+// and the handlers abort the response
+func TestWriteJSONError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := router.NewHTTPResponseWriter(rec).WriteJSON(common.Response{Success: true, Data: []failingTag{{ID: 1, Name: "bad"}}})
+	assert.Error(t, err)
+	assert.Empty(t, rec.Body.String())
+
+	api := newAPIServer(t, "json_error",
+		"CREATE TABLE encoded_tags (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL)",
+		"INSERT INTO encoded_tags (name) VALUES ('bad')",
+	)
+	api.register("tags", failingTag{})
+	api.serve(common.HandlerConfig{})
+	server := httptest.NewServer(api.Router)
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/restheadspec/tags")
+	if err == nil {
+		_, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	assert.Error(t, err, "the response is aborted")
+}
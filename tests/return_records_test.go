@@ -0,0 +1,81 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReturnRecords answers updates and deletes with the affected records on X-Return-Records
+func TestReturnRecords(t *testing.T) {
+	api := newAPIServer(t, "return_records",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+		"INSERT INTO sql_notes (title, stars) VALUES ('a', 1), ('b', 2), ('c', 3), ('d', 4)",
+	)
+
+	api.register("sql_notes", sqlNote{})
+	api.serve(common.HandlerConfig{})
+
+	type result struct {
+		Updated int       `json:"updated"`
+		Deleted int       `json:"deleted"`
+		Records []sqlNote `json:"records"`
+	}
+	send := func(method, path, body string, headers map[string]string) result {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-Return-Records", "true")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var response result
+		if strings.HasPrefix(path, "/resolvespec") {
+			var wrapped struct {
+				Data result `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &wrapped))
+			return wrapped.Data
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		return response
+	}
+
+	// Updated records are returned with their new values, even when they no longer match
+	response := send("PATCH", "/restheadspec/sql_notes", `{"stars":10}`, map[string]string{
+		"X-Searchop-Lte-Stars": "2",
+		"X-Write-All":          "true",
+	})
+	assert.Equal(t, 2, response.Updated)
+	require.Len(t, response.Records, 2)
+	assert.Equal(t, "a", response.Records[0].Title)
+	assert.Equal(t, 10, response.Records[1].Stars)
+
+	response = send("DELETE", "/restheadspec/sql_notes", `{"filters":[{"column":"stars","operator":"eq","value":10}]}`, map[string]string{"X-Confirm-Bulk": "true"})
+	assert.Equal(t, 2, response.Deleted)
+	require.Len(t, response.Records, 2)
+	assert.Equal(t, "b", response.Records[1].Title)
+
+	response = send("DELETE", "/restheadspec/sql_notes/3", "", nil)
+	assert.Equal(t, 1, response.Deleted)
+	require.Len(t, response.Records, 1)
+	assert.Equal(t, "c", response.Records[0].Title)
+
+	response = send("POST", "/resolvespec/sql_notes", `{"operation":"update_where","data":{"title":"last"},"options":{"filters":[{"column":"stars","operator":"eq","value":4}]},"write_all":true}`, nil)
+	assert.Equal(t, 1, response.Updated)
+	require.Len(t, response.Records, 1)
+	assert.Equal(t, "last", response.Records[0].Title)
+
+	response = send("POST", "/resolvespec/sql_notes/4", `{"operation":"delete"}`, nil)
+	require.Len(t, response.Records, 1)
+	assert.Equal(t, "last", response.Records[0].Title)
+}
@@ -0,0 +1,144 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/router"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// chiLike registers routes like chi.Router on a net/http ServeMux
+type chiLike struct {
+	*http.ServeMux
+}
+
+func (c chiLike) MethodFunc(method, pattern string, handler http.HandlerFunc) {
+	c.HandleFunc(method+" "+pattern, handler)
+}
+
+// colonRouter matches :name patterns like echo and gin
+type colonRouter struct {
+	routes []colonRoute
+}
+
+type colonRoute struct {
+	method  string
+	parts   []string
+	handler http.HandlerFunc
+}
+
+func (c *colonRouter) handle(method, pattern string, handler http.HandlerFunc) {
+	c.routes = append(c.routes, colonRoute{method, strings.Split(strings.Trim(pattern, "/"), "/"), handler})
+}
+
+func (c *colonRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	for _, route := range c.routes {
+		if route.method != r.Method || len(route.parts) != len(parts) {
+			continue
+		}
+		var names, values []string
+		matched := true
+		for i, part := range route.parts {
+			if strings.HasPrefix(part, ":") {
+				names = append(names, part[1:])
+				values = append(values, parts[i])
+			} else if part != parts[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			route.handler(w, router.WithPathParams(r, names, values))
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// TestHTTPRouterAdapters serves both APIs through the chi and echo/gin style adapters
+func TestHTTPRouterAdapters(t *testing.T) {
+	api := newAPIServer(t, "router_adapters",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+	)
+
+	api.register("main.sql_notes", sqlNote{})
+	rs := resolvespec.NewHandler(api.Adapter, api.Registry)
+	rh := restheadspec.NewHandler(api.Adapter, api.Registry)
+
+	chi := chiLike{http.NewServeMux()}
+	resolvespec.SetupHTTPRoutes(router.NewChiAdapter(chi, func(r *http.Request, name string) string {
+		return r.PathValue(name)
+	}), rs)
+	colon := &colonRouter{}
+	restheadspec.SetupHTTPRoutes(router.NewGinAdapter(colon.handle), rh)
+
+	send := func(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		return rec
+	}
+
+	send(chi, "POST", "/main/sql_notes", `{"operation":"create","data":{"title":"alpha","stars":1}}`)
+	send(colon, "POST", "/main/sql_notes", `{"title":"beta","stars":2}`)
+	send(colon, "PUT", "/main/sql_notes/1", `{"stars":3}`)
+
+	var response struct {
+		Data []sqlNote `json:"data"`
+	}
+	rec := send(chi, "POST", "/main/sql_notes", `{"operation":"read","options":{"sort":[{"column":"id","direction":"asc"}]}}`)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Data, 2)
+	assert.Equal(t, 3, response.Data[0].Stars)
+
+	var note sqlNote
+	rec = send(colon, "GET", "/main/sql_notes/2", "")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &note))
+	assert.Equal(t, "beta", note.Title)
+}
+
+// TestMountStdlib serves RestHeadSpec from a net/http ServeMux below a prefix
+func TestMountStdlib(t *testing.T) {
+	api := newAPIServer(t, "mount_stdlib",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+	)
+
+	api.register("main.sql_notes", sqlNote{})
+	mux := http.NewServeMux()
+	restheadspec.MountStdlib(mux, restheadspec.NewHandler(api.Adapter, api.Registry), "/api/")
+	resolvespec.MountStdlib(mux, resolvespec.NewHandler(api.Adapter, api.Registry), "/rpc")
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		return rec
+	}
+
+	require.Equal(t, http.StatusOK, send("POST", "/api/main/sql_notes", `{"title":"alpha","stars":1}`).Code)
+	require.Equal(t, http.StatusOK, send("POST", "/rpc/main/sql_notes", `{"operation":"create","data":{"title":"beta"}}`).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, send("DELETE", "/rpc/main/sql_notes/1", "").Code)
+
+	rec := send("GET", "/api/main/sql_notes/1", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var note sqlNote
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &note))
+	assert.Equal(t, "alpha", note.Title)
+
+	rec = send("GET", "/api/main/sql_notes/metadata", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "stars")
+
+	assert.Equal(t, http.StatusNotFound, send("GET", "/main/sql_notes", "").Code)
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type scopedTicket struct {
+	ID       int64  `json:"id" bun:"id,pk,autoincrement"`
+	Status   string `json:"status" bun:"status"`
+	Priority int    `json:"priority" bun:"priority"`
+}
+
+func (scopedTicket) TableName() string { return "scoped_tickets" }
+
+func (scopedTicket) Scopes() map[string]common.FilterGroup {
+	return map[string]common.FilterGroup{
+		"open":   {Filters: []common.FilterOption{{Column: "status", Operator: "eq", Value: "open"}}},
+		"urgent": {Filters: []common.FilterOption{{Column: "priority", Operator: "gte", Value: 3}}},
+	}
+}
+
+// TestScopes applies the named filters of a model selected with X-Scope or the scopes option
+func TestScopes(t *testing.T) {
+	api := newAPIServer(t, "model_scopes",
+		"CREATE TABLE scoped_tickets (id INTEGER PRIMARY KEY AUTOINCREMENT, status TEXT NOT NULL, priority INTEGER NOT NULL)",
+		"INSERT INTO scoped_tickets (status, priority) VALUES ('open', 1), ('open', 5), ('closed', 4)",
+	)
+
+	api.register("scoped_tickets", scopedTicket{})
+	api.serve(common.HandlerConfig{})
+
+	send := func(method, path, body, scope string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if scope != "" {
+			req.Header.Set("X-Scope", scope)
+		}
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("GET", "/restheadspec/scoped_tickets", "", "open")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var tickets []scopedTicket
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tickets))
+	assert.Len(t, tickets, 2)
+
+	rec = send("GET", "/restheadspec/scoped_tickets", "", "open,urgent")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tickets))
+	require.Len(t, tickets, 1)
+	assert.EqualValues(t, 2, tickets[0].ID)
+
+	rec = send("POST", "/resolvespec/scoped_tickets", `{"operation":"read","options":{"scopes":["urgent"]}}`, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Data []scopedTicket `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Len(t, response.Data, 2)
+
+	assert.Equal(t, http.StatusBadRequest, send("GET", "/restheadspec/scoped_tickets", "", "archived").Code)
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/resolvespec/scoped_tickets", `{"operation":"read"}`, "archived").Code)
+}
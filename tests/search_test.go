@@ -0,0 +1,101 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/modelregistry"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// searchArticle is searched through the FTS5 index articles_fts
+type searchArticle struct {
+	ID    int64  `json:"id" gorm:"column:id;primaryKey"`
+	Title string `json:"title" gorm:"column:title"`
+	Body  string `json:"body" gorm:"column:body"`
+}
+
+func (searchArticle) TableName() string {
+	return "articles"
+}
+
+// TestFullTextSearch searches with X-Search on SQLite FTS5 and returns ranking and snippets in the metadata
+func TestFullTextSearch(t *testing.T) {
+	db, err := setupStandaloneDB()
+	require.NoError(t, err)
+	defer cleanupStandaloneDB(db)
+
+	require.NoError(t, db.Migrator().DropTable(&searchArticle{}))
+	require.NoError(t, db.Exec("DROP TABLE IF EXISTS articles_fts").Error)
+	require.NoError(t, db.AutoMigrate(&searchArticle{}))
+	require.NoError(t, db.Create([]searchArticle{
+		{ID: 1, Title: "Gardening basics", Body: "Water the tomato plants every morning"},
+		{ID: 2, Title: "Tomato soup", Body: "A tomato soup recipe with tomato, basil and cream"},
+		{ID: 3, Title: "Bread", Body: "Flour, water, salt and yeast"},
+	}).Error)
+	require.NoError(t, db.Exec("CREATE VIRTUAL TABLE articles_fts USING fts5(title, body, content='articles', content_rowid='id')").Error)
+	require.NoError(t, db.Exec("INSERT INTO articles_fts(articles_fts) VALUES('rebuild')").Error)
+
+	registry := modelregistry.NewModelRegistry()
+	require.NoError(t, registry.RegisterModel("articles", searchArticle{}))
+	adapter := database.NewGormAdapter(db)
+	router := setupStandaloneRouter(resolvespec.NewHandler(adapter, registry), restheadspec.NewHandler(adapter, registry))
+
+	search := func(headers map[string]string) ([]searchArticle, *common.Metadata) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/restheadspec/articles", nil)
+		req.Header.Set("X-DetailApi", "true")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var response struct {
+			Data     []searchArticle  `json:"data"`
+			Metadata *common.Metadata `json:"metadata"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		return response.Data, response.Metadata
+	}
+
+	t.Run("matches are ranked with snippets", func(t *testing.T) {
+		articles, metadata := search(map[string]string{"X-Search": "tomato"})
+		require.Len(t, articles, 2)
+		assert.Equal(t, int64(2), articles[0].ID, "the best match comes first")
+		assert.Equal(t, int64(2), metadata.Filtered)
+
+		require.NotNil(t, metadata.Search)
+		assert.Equal(t, "tomato", metadata.Search.Query)
+		require.Len(t, metadata.Search.Hits, 2)
+		assert.EqualValues(t, 2, metadata.Search.Hits[0].ID)
+		assert.Greater(t, metadata.Search.Hits[0].Rank, metadata.Search.Hits[1].Rank)
+		assert.Contains(t, metadata.Search.Hits[0].Snippet, "<b>")
+	})
+
+	t.Run("search columns restrict the match", func(t *testing.T) {
+		articles, _ := search(map[string]string{"X-Search": "tomato", "X-Searchcols": "title"})
+		require.Len(t, articles, 1)
+		assert.Equal(t, int64(2), articles[0].ID)
+	})
+
+	t.Run("explicit sort and prefix terms", func(t *testing.T) {
+		articles, _ := search(map[string]string{"X-Search": "wat*", "X-Sort": "-id"})
+		require.Len(t, articles, 2)
+		assert.Equal(t, []int64{3, 1}, []int64{articles[0].ID, articles[1].ID})
+	})
+
+	t.Run("query syntax is treated as text", func(t *testing.T) {
+		articles, _ := search(map[string]string{"X-Search": `"tomato" OR NEAR(`})
+		assert.Empty(t, articles)
+	})
+}
@@ -0,0 +1,66 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type serializedMember struct {
+	ID       int64  `json:"id" gorm:"column:id;primaryKey"`
+	FullName string `json:"full_name" gorm:"column:full_name"`
+	Token    string `json:"token" gorm:"column:token"`
+}
+
+func (serializedMember) TableName() string {
+	return "serialized_members"
+}
+
+// TestSerializer maps the fields of payloads and responses with the serializer of the entity
+func TestSerializer(t *testing.T) {
+	api := newAPIServer(t, "serializer",
+		"CREATE TABLE serialized_members (id INTEGER PRIMARY KEY AUTOINCREMENT, full_name TEXT NOT NULL, token TEXT NOT NULL DEFAULT '')",
+	)
+
+	api.register("members", serializedMember{})
+	config := common.HandlerConfig{
+		Entities: map[string]common.EntityConfig{"members": {Serializer: common.FieldMapping{
+			Rename: map[string]string{"full_name": "name"},
+			Drop:   []string{"token"},
+		}}},
+	}
+	api.serve(config)
+	send := func(method, path, body string) map[string]interface{} {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		return response
+	}
+
+	created := send("POST", "/restheadspec/members", `{"name":"Ada Lovelace","token":"client"}`)
+	assert.Equal(t, "Ada Lovelace", created["name"])
+	assert.NotContains(t, created, "full_name")
+	assert.NotContains(t, created, "token")
+
+	var token string
+	require.NoError(t, api.DB.QueryRow("SELECT token FROM serialized_members").Scan(&token))
+	assert.NotEqual(t, "client", token, "dropped fields are ignored in payloads")
+
+	read := send("POST", "/resolvespec/members", `{"operation":"read"}`)
+	records := read["data"].([]interface{})
+	require.Len(t, records, 1)
+	assert.Equal(t, map[string]interface{}{"id": float64(1), "name": "Ada Lovelace"}, records[0])
+
+	updated := send("POST", "/resolvespec/members/1", `{"operation":"update","data":{"name":"Ada King"}}`)
+	assert.Equal(t, "Ada King", updated["data"].(map[string]interface{})["name"])
+}
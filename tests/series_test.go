@@ -0,0 +1,111 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+type seriesOrder struct {
+	bun.BaseModel `bun:"table:series_orders"`
+	ID            int64      `json:"id" bun:"id,pk" gorm:"column:id;primaryKey"`
+	Status        string     `json:"status" bun:"status" gorm:"column:status"`
+	Amount        float64    `json:"amount" bun:"amount" gorm:"column:amount"`
+	CreatedAt     *time.Time `json:"created_at" bun:"created_at" gorm:"column:created_at"`
+}
+
+func (seriesOrder) TableName() string { return "series_orders" }
+
+// TestSeries counts the records of a read per hour, day, week and month of a timestamp column
+func TestSeries(t *testing.T) {
+	for _, name := range []string{"bun", "gorm", "sql"} {
+		t.Run(name, func(t *testing.T) {
+			api := newAPIServer(t, "series_"+name,
+				"CREATE TABLE series_orders (id INTEGER PRIMARY KEY, status TEXT NOT NULL, amount REAL NOT NULL, created_at TIMESTAMP)",
+			)
+			// 2024-04-29 and 2024-05-06 are Mondays, 2024-05-05 is a Sunday
+			_, err := api.DB.Exec(`INSERT INTO series_orders (id, status, amount, created_at) VALUES
+				(1, 'paid', 10, '2024-04-29 09:10:00'), (2, 'paid', 20, '2024-04-29 09:50:00'),
+				(3, 'open', 5, '2024-05-01 14:00:00'), (4, 'paid', 7, '2024-05-05 23:00:00'),
+				(5, 'paid', 3, '2024-05-06 00:30:00'), (6, 'paid', 100, NULL)`)
+			require.NoError(t, err)
+
+			var db common.Database
+			switch name {
+			case "bun":
+				db = database.NewBunAdapter(bun.NewDB(api.DB, sqlitedialect.New()))
+			case "gorm":
+				gormDB, err := gorm.Open(sqlite.Dialector{Conn: api.DB}, &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+				require.NoError(t, err)
+				db = database.NewGormAdapter(gormDB)
+			default:
+				db = database.NewSQLAdapter(api.DB, "sqlite")
+			}
+			api.register("series_orders", seriesOrder{})
+			api.serveHandlers(resolvespec.NewHandler(db, api.Registry), restheadspec.NewHandler(db, api.Registry))
+
+			send := func(method string, headers map[string]string) *httptest.ResponseRecorder {
+				t.Helper()
+				req := httptest.NewRequest(method, "/restheadspec/series_orders/_series", nil)
+				for key, value := range headers {
+					req.Header.Set(key, value)
+				}
+				rec := httptest.NewRecorder()
+				api.Router.ServeHTTP(rec, req)
+				return rec
+			}
+			points := func(headers map[string]string) map[string]int64 {
+				t.Helper()
+				rec := send("GET", headers)
+				require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+				var series common.Series
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &series))
+				counts := make(map[string]int64, len(series.Points))
+				for _, point := range series.Points {
+					counts[point.Bucket] = point.Count
+				}
+				return counts
+			}
+
+			assert.Equal(t, map[string]int64{"2024-04-29T09:00": 2, "2024-05-01T14:00": 1, "2024-05-05T23:00": 1, "2024-05-06T00:00": 1},
+				points(map[string]string{"X-Bucket": "hour(created_at)"}))
+			assert.Equal(t, map[string]int64{"2024-04-29": 2, "2024-05-01": 1, "2024-05-05": 1, "2024-05-06": 1},
+				points(map[string]string{"X-Bucket": "day(created_at)"}))
+			assert.Equal(t, map[string]int64{"2024-04-29": 4, "2024-05-06": 1},
+				points(map[string]string{"X-Bucket": "week(created_at)"}), "weeks start on Monday")
+			assert.Equal(t, map[string]int64{"2024-04": 2, "2024-05": 3},
+				points(map[string]string{"X-Bucket": "Month(Created_At)"}))
+
+			rec := send("GET", map[string]string{"X-Bucket": "week(created_at)", "X-Aggregate": "sum(amount),max(amount)", "X-Searchop-Eq-Status": "paid"})
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			var series common.Series
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &series))
+			assert.Equal(t, "created_at", series.Column)
+			assert.Equal(t, "week", series.Period)
+			assert.Equal(t, []common.SeriesPoint{
+				{Bucket: "2024-04-29", Count: 3, Aggregates: map[string]map[string]interface{}{"amount": {"sum": float64(37), "max": float64(20)}}},
+				{Bucket: "2024-05-06", Count: 1, Aggregates: map[string]map[string]interface{}{"amount": {"sum": float64(3), "max": float64(3)}}},
+			}, series.Points, "buckets are ordered and follow the filters of the read")
+
+			for _, invalid := range []string{"", "year(created_at)", "day(missing)", "created_at"} {
+				assert.Equal(t, http.StatusBadRequest, send("GET", map[string]string{"X-Bucket": invalid}).Code, invalid)
+			}
+			assert.Equal(t, http.StatusMethodNotAllowed, send("POST", map[string]string{"X-Bucket": "day(created_at)"}).Code)
+		})
+	}
+}
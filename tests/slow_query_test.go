@@ -0,0 +1,59 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// TestSlowQueryReporting reports queries running past the slow query threshold
+func TestSlowQueryReporting(t *testing.T) {
+	api := newAPIServer(t, "slow_query",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+	)
+
+	// Every query takes 30ms
+	slow := database.NewChaosAdapter(database.NewSQLAdapter(api.DB, "sqlite"), database.ChaosConfig{
+		LatencyRate: 1, MinLatency: 30 * time.Millisecond, MaxLatency: 30 * time.Millisecond,
+	})
+	api.register("sql_notes", sqlNote{})
+
+	var mu sync.Mutex
+	var reported []common.SlowQuery
+	config := common.HandlerConfig{SlowQueryThreshold: 20 * time.Millisecond, OnSlowQuery: func(ctx context.Context, query common.SlowQuery) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, query)
+	}}
+	api.serveHandlers(
+		resolvespec.NewHandlerWithConfig(slow, api.Registry, config),
+		restheadspec.NewHandlerWithConfig(slow, api.Registry, config),
+	)
+
+	send := api.send
+
+	rec := send("GET", "/restheadspec/sql_notes", "", map[string]string{"X-Searchop-Gt-Stars": "2", "X-Request-ID": "slow-1"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = send("POST", "/resolvespec/sql_notes", `{"operation":"read","options":{"filters":[{"column":"title","operator":"eq","value":"x"}]}}`, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, reported, 2)
+	assert.Equal(t, "sql_notes", reported[0].Entity)
+	assert.Equal(t, "read", reported[0].Operation)
+	assert.Equal(t, "slow-1", reported[0].RequestID)
+	assert.Contains(t, reported[0].Options, "stars gt ?")
+	assert.GreaterOrEqual(t, reported[0].Duration, 20*time.Millisecond)
+	assert.Contains(t, reported[1].Options, "title eq ?")
+}
@@ -0,0 +1,172 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+// sqlNote is served through the database/sql adapter
+type sqlNote struct {
+	ID    int64  `json:"id" gorm:"column:id;primaryKey"`
+	Title string `json:"title" gorm:"column:title"`
+	Stars int    `json:"stars" gorm:"column:stars"`
+}
+
+func (sqlNote) TableName() string {
+	return "sql_notes"
+}
+
+// TestSQLAdapterHandlers runs both APIs on a plain database/sql connection
+func TestSQLAdapterHandlers(t *testing.T) {
+	api := newAPIServer(t, "sql_adapter",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+	)
+
+	api.register("sql_notes", sqlNote{})
+	api.serve(common.HandlerConfig{})
+
+	send := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		return rec
+	}
+
+	send("POST", "/restheadspec/sql_notes", `[{"title":"alpha","stars":1},{"title":"beta","stars":5},{"title":"gamma","stars":3}]`, nil)
+	send("PUT", "/restheadspec/sql_notes/1", `{"stars":4}`, nil)
+	send("POST", "/resolvespec/sql_notes/3", `{"operation":"delete"}`, nil)
+
+	rec := send("GET", "/restheadspec/sql_notes", "", map[string]string{
+		"X-DetailApi":               "true",
+		"X-Searchop-Gte-Stars":      "2",
+		"X-Searchop-Contains-Title": "A",
+		"X-Sort":                    "-stars",
+	})
+	var response struct {
+		Data     []sqlNote `json:"data"`
+		Metadata struct {
+			Total int64 `json:"total"`
+		} `json:"metadata"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Data, 2)
+	assert.Equal(t, []string{"beta", "alpha"}, []string{response.Data[0].Title, response.Data[1].Title})
+	assert.Equal(t, 4, response.Data[1].Stars)
+	assert.EqualValues(t, 2, response.Metadata.Total)
+}
+
+// bulkAdapter is a database/sql adapter implementing common.BulkInserter, which records the
+// bulk inserted rows instead of inserting them
+type bulkAdapter struct {
+	*database.SQLAdapter
+	copied *[][]interface{}
+}
+
+func (b *bulkAdapter) RunInTransaction(ctx context.Context, fn func(common.Database) error) error {
+	return b.SQLAdapter.RunInTransaction(ctx, func(tx common.Database) error {
+		return fn(&bulkAdapter{SQLAdapter: tx.(*database.SQLAdapter), copied: b.copied})
+	})
+}
+
+func (b *bulkAdapter) BulkInsert(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error) {
+	if table != "sql_notes" || strings.Join(columns, ",") != "stars,title" {
+		return 0, fmt.Errorf("unexpected bulk insert into %s (%v)", table, columns)
+	}
+	*b.copied = append(*b.copied, rows...)
+	return int64(len(rows)), nil
+}
+
+// TestBulkInsertCreate creates arrays above the threshold with one bulk insert of a
+// common.BulkInserter database
+func TestBulkInsertCreate(t *testing.T) {
+	api := newAPIServer(t, "bulk_insert",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+	)
+
+	var copied [][]interface{}
+	api.Adapter = &bulkAdapter{SQLAdapter: database.NewSQLAdapter(api.DB, "sqlite"), copied: &copied}
+
+	api.register("sql_notes", sqlNote{})
+	rs := resolvespec.NewHandler(api.Adapter, api.Registry)
+	rs.SetBulkInsertThreshold(2)
+	rh := restheadspec.NewHandler(api.Adapter, api.Registry)
+	rh.SetBulkInsertThreshold(2)
+	api.serveHandlers(rs, rh)
+
+	send := func(path, body string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, httptest.NewRequest("POST", path, bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	send("/restheadspec/sql_notes", `[{"title":"a","stars":1},{"title":"b","stars":2},{"title":"c","stars":3}]`)
+	require.Len(t, copied, 3)
+	send("/resolvespec/sql_notes", `{"operation":"create","data":[{"title":"d","stars":4},{"title":"e","stars":5},{"title":"f","stars":6}]}`)
+	require.Len(t, copied, 6)
+	assert.Equal(t, []interface{}{float64(6), "f"}, copied[5])
+
+	// Up to the threshold records are inserted with INSERT statements
+	send("/restheadspec/sql_notes", `[{"title":"g","stars":7},{"title":"h","stars":8}]`)
+	assert.Len(t, copied, 6)
+	var count int
+	require.NoError(t, api.DB.QueryRow("SELECT COUNT(*) FROM sql_notes").Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+// TestChunkedCreate creates arrays with multi-row inserts of InsertChunkSize rows
+func TestChunkedCreate(t *testing.T) {
+	api := newAPIServer(t, "chunked_create",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+	)
+
+	api.register("sql_notes", sqlNote{})
+	rh := restheadspec.NewHandlerWithConfig(api.Adapter, api.Registry, common.HandlerConfig{InsertChunkSize: 2})
+	var statements []string
+	rh.Hooks().Register(restheadspec.BeforeScan, func(ctx *restheadspec.HookContext) error {
+		if query, ok := ctx.Query.(common.InsertQuery); ok {
+			statement, _, err := query.ToSQL()
+			statements = append(statements, statement)
+			return err
+		}
+		return nil
+	})
+	api.serveHandlers(resolvespec.NewHandler(api.Adapter, api.Registry), rh)
+
+	rec := httptest.NewRecorder()
+	api.Router.ServeHTTP(rec, httptest.NewRequest("POST", "/restheadspec/sql_notes", bytes.NewBufferString(
+		`[{"title":"a","stars":1},{"title":"b","stars":2},{"title":"c","stars":3},{"title":"d","stars":4},{"title":"e","stars":5}]`)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var created []sqlNote
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.Len(t, created, 5)
+	for i, note := range created {
+		assert.EqualValues(t, i+1, note.ID, "generated keys are returned in order")
+	}
+	require.Len(t, statements, 1, "BeforeScan runs once for the whole array")
+	assert.Equal(t, 2, strings.Count(statements[0], "(?, ?)"), "the first statement inserts a chunk of 2 rows")
+
+	var count int
+	require.NoError(t, api.DB.QueryRow("SELECT COUNT(*) FROM sql_notes").Scan(&count))
+	assert.Equal(t, 5, count)
+}
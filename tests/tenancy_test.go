@@ -0,0 +1,291 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+)
+
+type tenantNote struct {
+	ID       int64  `json:"id" bun:"id,pk,autoincrement"`
+	TenantID int64  `json:"tenant_id" bun:"tenant_id"`
+	Title    string `json:"title" bun:"title"`
+}
+
+func (tenantNote) TableName() string { return "tenant_notes" }
+
+// TestTenantScoping restricts every read, update and delete to the tenant of the request and
+// stamps the tenant on creates
+func TestTenantScoping(t *testing.T) {
+	api := newAPIServer(t, "tenant_scoping",
+		"CREATE TABLE tenant_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, tenant_id INTEGER NOT NULL, title TEXT NOT NULL)",
+		"INSERT INTO tenant_notes (tenant_id, title) VALUES (1, 'one'), (2, 'two'), (2, 'three')",
+	)
+
+	api.register("tenant_notes", tenantNote{})
+	config := common.HandlerConfig{TenantColumn: "tenant_id", TenantResolver: func(r common.Request) (interface{}, error) {
+		tenant, err := strconv.ParseInt(r.Header("X-Tenant"), 10, 64)
+		if err != nil {
+			return nil, errors.New("missing X-Tenant")
+		}
+		return tenant, nil
+	}}
+	api.serve(config)
+
+	send := func(method, path, body, tenant string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if tenant != "" {
+			req.Header.Set("X-Tenant", tenant)
+		}
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, req)
+		return rec
+	}
+	tenantOf := func(id int) int64 {
+		var tenant int64
+		require.NoError(t, api.DB.QueryRow("SELECT tenant_id FROM tenant_notes WHERE id = ?", id).Scan(&tenant))
+		return tenant
+	}
+
+	assert.Equal(t, http.StatusForbidden, send("GET", "/restheadspec/tenant_notes", "", "").Code, "requests without a tenant")
+
+	rec := send("GET", "/restheadspec/tenant_notes", "", "2")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var notes []tenantNote
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &notes))
+	assert.Len(t, notes, 2)
+
+	rec = send("POST", "/resolvespec/tenant_notes", `{"operation":"read","options":{"filters":[{"column":"title","operator":"eq","value":"one"}]}}`, "2")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Data []tenantNote `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Empty(t, response.Data, "rows of other tenants are invisible")
+
+	rec = send("POST", "/resolvespec/tenant_notes/1", `{"operation":"update","data":{"title":"hijacked"}}`, "2")
+	assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+	send("DELETE", "/restheadspec/tenant_notes/1", "", "2")
+	var title string
+	require.NoError(t, api.DB.QueryRow("SELECT title FROM tenant_notes WHERE id = 1").Scan(&title))
+	assert.Equal(t, "one", title, "rows of other tenants can't be changed")
+
+	rec = send("POST", "/restheadspec/tenant_notes", `{"title":"four","tenant_id":1}`, "2")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.EqualValues(t, 2, tenantOf(4), "creates are stamped with the tenant")
+
+	rec = send("POST", "/resolvespec/tenant_notes/2", `{"operation":"update","data":{"title":"moved","tenant_id":1}}`, "2")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.EqualValues(t, 2, tenantOf(2), "updates can't move rows to other tenants")
+
+	t.Run("tenant of the authenticated user", func(t *testing.T) {
+		type tenantKey struct{}
+		config := common.HandlerConfig{TenantColumn: "tenant_id", TenantResolver: func(r common.Request) (interface{}, error) {
+			tenant, _ := r.Context().Value(tenantKey{}).(int64)
+			return tenant, nil
+		}}
+		authenticate := func(ctx context.Context, r common.Request) (context.Context, error) {
+			if r.Header("Authorization") != "Bearer two" {
+				return nil, errors.New("invalid token")
+			}
+			return context.WithValue(ctx, tenantKey{}, int64(2)), nil
+		}
+		api.serveHandlers(
+			resolvespec.NewHandlerWithConfig(api.Adapter, api.Registry, config),
+			restheadspec.NewHandlerWithConfig(api.Adapter, api.Registry, config, restheadspec.WithAuth(authenticate)),
+		)
+
+		assert.Equal(t, http.StatusUnauthorized, api.send("GET", "/restheadspec/tenant_notes", "").Code, "requests are authenticated before their tenant is resolved")
+		rec := api.send("GET", "/restheadspec/tenant_notes", "", map[string]string{"Authorization": "Bearer two"})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var notes []tenantNote
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &notes))
+		require.NotEmpty(t, notes)
+		for _, note := range notes {
+			assert.EqualValues(t, 2, note.TenantID)
+		}
+	})
+}
+
+type tenantProject struct {
+	bun.BaseModel `bun:"table:tenant_projects"`
+	ID            int64         `json:"id" bun:"id,pk" gorm:"column:id;primaryKey"`
+	TenantID      int64         `json:"tenant_id" bun:"tenant_id" gorm:"column:tenant_id"`
+	Name          string        `json:"name" bun:"name" gorm:"column:name"`
+	Tasks         []*tenantTask `json:"tasks,omitempty" bun:"rel:has-many,join:id=project_id" gorm:"foreignKey:ProjectID;references:ID"`
+}
+
+func (tenantProject) TableName() string { return "tenant_projects" }
+
+type tenantTask struct {
+	bun.BaseModel `bun:"table:tenant_tasks"`
+	ID            int64          `json:"id" bun:"id,pk" gorm:"column:id;primaryKey"`
+	TenantID      int64          `json:"tenant_id" bun:"tenant_id" gorm:"column:tenant_id"`
+	ProjectID     int64          `json:"project_id" bun:"project_id" gorm:"column:project_id"`
+	Title         string         `json:"title" bun:"title" gorm:"column:title"`
+	Project       *tenantProject `json:"project,omitempty" bun:"rel:belongs-to,join:project_id=id" gorm:"foreignKey:ProjectID;references:ID"`
+}
+
+func (tenantTask) TableName() string { return "tenant_tasks" }
+
+// newTenantRelationServer serves tenant_projects and tenant_tasks, scoped to the tenant of the
+// X-Tenant header, on the bun or gorm adapter
+func newTenantRelationServer(t *testing.T, adapter string, stmts ...string) *apiServer {
+	t.Helper()
+	api := newAPIServer(t, "tenant_relations_"+adapter, append([]string{
+		"CREATE TABLE tenant_projects (id INTEGER PRIMARY KEY, tenant_id INTEGER NOT NULL, name TEXT NOT NULL)",
+		"CREATE TABLE tenant_tasks (id INTEGER PRIMARY KEY, tenant_id INTEGER NOT NULL, project_id INTEGER NOT NULL, title TEXT NOT NULL)",
+	}, stmts...)...)
+	if adapter == "bun" {
+		api.Adapter = database.NewBunAdapter(bun.NewDB(api.DB, sqlitedialect.New()))
+	} else {
+		gormDB, err := gorm.Open(sqlite.Dialector{Conn: api.DB}, &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+		require.NoError(t, err)
+		api.Adapter = database.NewGormAdapter(gormDB)
+	}
+	api.register("tenant_projects", tenantProject{}).register("tenant_tasks", tenantTask{})
+	api.serve(common.HandlerConfig{TenantColumn: "tenant_id", TenantResolver: func(r common.Request) (interface{}, error) {
+		return strconv.ParseInt(r.Header("X-Tenant"), 10, 64)
+	}})
+	return api
+}
+
+// TestTenantScopingJoins qualifies the tenant column by the table of the read, which joined
+// tables with a tenant column of their own would make ambiguous
+func TestTenantScopingJoins(t *testing.T) {
+	for _, name := range []string{"bun", "gorm"} {
+		t.Run(name, func(t *testing.T) {
+			api := newTenantRelationServer(t, name,
+				"INSERT INTO tenant_projects (id, tenant_id, name) VALUES (1, 1, 'one'), (2, 2, 'two')",
+				"INSERT INTO tenant_tasks (id, tenant_id, project_id, title) VALUES (1, 1, 1, 'a'), (2, 2, 2, 'b'), (3, 2, 2, 'c')",
+			)
+
+			rec := api.send("GET", "/restheadspec/tenant_tasks", "", map[string]string{"X-Tenant": "2", "X-Expand": "Project", "X-Sort": "project.name,title"})
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			var tasks []tenantTask
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tasks))
+			require.Len(t, tasks, 2)
+			for _, task := range tasks {
+				assert.EqualValues(t, 2, task.TenantID)
+				require.NotNil(t, task.Project)
+				assert.Equal(t, "two", task.Project.Name)
+			}
+		})
+	}
+}
+
+// TestTenantScopingRelations keeps the rows of other tenants out of expanded and preloaded
+// relations, which reference them through foreign keys across tenants
+func TestTenantScopingRelations(t *testing.T) {
+	for _, name := range []string{"bun", "gorm"} {
+		t.Run(name, func(t *testing.T) {
+			api := newTenantRelationServer(t, name,
+				"INSERT INTO tenant_projects (id, tenant_id, name) VALUES (1, 1, 'one'), (2, 2, 'two')",
+				"INSERT INTO tenant_tasks (id, tenant_id, project_id, title) VALUES (1, 1, 1, 'a'), (2, 2, 2, 'b'), (3, 2, 1, 'c'), (4, 1, 2, 'd')",
+			)
+			tenant := map[string]string{"X-Tenant": "2"}
+			with := func(headers map[string]string) map[string]string {
+				headers["X-Tenant"] = "2"
+				return headers
+			}
+			assertTasks := func(t *testing.T, tasks []*tenantTask, titles ...string) {
+				t.Helper()
+				found := make([]string, 0, len(tasks))
+				for _, task := range tasks {
+					assert.EqualValues(t, 2, task.TenantID)
+					found = append(found, task.Title)
+				}
+				assert.ElementsMatch(t, titles, found)
+			}
+
+			t.Run("expand", func(t *testing.T) {
+				rec := api.send("GET", "/restheadspec/tenant_tasks", "", with(map[string]string{"X-Expand": "Project"}))
+				require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+				var tasks []tenantTask
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tasks))
+				require.Len(t, tasks, 2)
+				for _, task := range tasks {
+					assert.EqualValues(t, 2, task.TenantID)
+					if task.Title == "b" {
+						require.NotNil(t, task.Project)
+						assert.Equal(t, "two", task.Project.Name)
+					} else if task.Project != nil {
+						assert.Zero(t, task.Project.ID, "the project of another tenant isn't joined")
+						assert.Empty(t, task.Project.Name)
+					}
+				}
+			})
+
+			t.Run("preload belongs-to", func(t *testing.T) {
+				rec := api.send("POST", "/resolvespec/tenant_tasks", `{"operation":"read","options":{"preload":[{"relation":"project"}]}}`, tenant)
+				require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+				var response struct {
+					Data []tenantTask `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				require.Len(t, response.Data, 2)
+				for _, task := range response.Data {
+					assert.EqualValues(t, 2, task.TenantID)
+					if task.Title == "b" {
+						require.NotNil(t, task.Project)
+						assert.Equal(t, "two", task.Project.Name)
+					} else if task.Project != nil {
+						assert.Zero(t, task.Project.ID, "the project of another tenant isn't preloaded")
+					}
+				}
+			})
+
+			t.Run("preload has-many", func(t *testing.T) {
+				rec := api.send("GET", "/restheadspec/tenant_projects", "", with(map[string]string{"X-Preload": "Tasks"}))
+				require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+				var projects []tenantProject
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &projects))
+				require.Len(t, projects, 1)
+				assertTasks(t, projects[0].Tasks, "b")
+			})
+
+			t.Run("nested preload", func(t *testing.T) {
+				rec := api.send("GET", "/restheadspec/tenant_projects", "", with(map[string]string{"X-Preload": "Tasks.Project"}))
+				require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+				var projects []tenantProject
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &projects))
+				require.Len(t, projects, 1)
+				assertTasks(t, projects[0].Tasks, "b")
+				for _, task := range projects[0].Tasks {
+					require.NotNil(t, task.Project)
+					assert.Equal(t, "two", task.Project.Name)
+				}
+			})
+
+			t.Run("row number", func(t *testing.T) {
+				rec := api.send("GET", "/restheadspec/tenant_tasks", "", with(map[string]string{"X-Fetch-RowNumber": "3", "X-DetailApi": "true"}))
+				require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+				var response struct {
+					Metadata common.Metadata `json:"metadata"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				require.NotNil(t, response.Metadata.RowNumber, rec.Body.String())
+				assert.EqualValues(t, 2, *response.Metadata.RowNumber, "rows of other tenants aren't counted")
+			})
+		})
+	}
+}
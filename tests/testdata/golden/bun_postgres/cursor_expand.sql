@@ -0,0 +1,10 @@
+-- restheadspec employees (status 200)
+SELECT count(*) FROM "employees" AS "golden_employee" LEFT JOIN "departments" AS "department" ON ("department"."id" = "golden_employee"."department_id");
+SELECT "golden_employee"."id", "golden_employee"."first_name", "golden_employee"."last_name", "golden_employee"."status", "golden_employee"."salary", "golden_employee"."department_id", "department"."id" AS "department__id", "department"."name" AS "department__name", "department"."code" AS "department__code" FROM "employees" AS "golden_employee" LEFT JOIN "departments" AS "department" ON ("department"."id" = "golden_employee"."department_id") WHERE (EXISTS (
+  SELECT 1
+  FROM employees cursor_select
+  LEFT JOIN departments cursor_select_department ON cursor_select_department.id = cursor_select.department_id
+  WHERE cursor_select.id = 42 AND ((cursor_select_department.name < "department".name)
+  OR (cursor_select_department.name = "department".name AND cursor_select.salary > golden_employee.salary)
+  OR (cursor_select_department.name = "department".name AND cursor_select.salary = golden_employee.salary AND cursor_select.id < golden_employee.id))
+)) ORDER BY "department".name ASC, "golden_employee"."salary" DESC, "golden_employee"."id" ASC LIMIT 10;
@@ -0,0 +1,3 @@
+-- restheadspec employees (status 200)
+WITH _count_wrapper AS (SELECT DISTINCT "golden_employee"."status" FROM "employees" AS "golden_employee") SELECT count(*) FROM _count_wrapper;
+SELECT DISTINCT "golden_employee"."status" FROM "employees" AS "golden_employee" ORDER BY "status" ASC;
@@ -0,0 +1,3 @@
+-- restheadspec employees (status 200)
+SELECT count(*) FROM "employees" AS "golden_employee" LEFT JOIN "departments" AS "department" ON ("department"."id" = "golden_employee"."department_id");
+SELECT "golden_employee"."id", "golden_employee"."first_name", "golden_employee"."last_name", "golden_employee"."status", "golden_employee"."salary", "golden_employee"."department_id", "department"."id" AS "department__id", "department"."name" AS "department__name", "department"."code" AS "department__code" FROM "employees" AS "golden_employee" LEFT JOIN "departments" AS "department" ON ("department"."id" = "golden_employee"."department_id") ORDER BY "golden_employee"."id" ASC;
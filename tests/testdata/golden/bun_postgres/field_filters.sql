@@ -0,0 +1,3 @@
+-- restheadspec employees (status 200)
+SELECT count(*) FROM "employees" AS "golden_employee" WHERE (employees.status = 'active') AND (employees.last_name ILIKE '%smi%') AND (employees.salary > 50000) OR (employees.department_id = 7);
+SELECT "golden_employee"."id", "golden_employee"."first_name", "golden_employee"."last_name", "golden_employee"."status", "golden_employee"."salary", "golden_employee"."department_id" FROM "employees" AS "golden_employee" WHERE (employees.status = 'active') AND (employees.last_name ILIKE '%smi%') AND (employees.salary > 50000) OR (employees.department_id = 7) ORDER BY "id" ASC;
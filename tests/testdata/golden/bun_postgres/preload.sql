@@ -0,0 +1,4 @@
+-- restheadspec departments (status 200)
+SELECT count(*) FROM "departments" AS "golden_department";
+SELECT "golden_department"."id", "golden_department"."name", "golden_department"."code" FROM "departments" AS "golden_department" ORDER BY "id" ASC;
+SELECT "golden_employee"."id", "golden_employee"."first_name", "golden_employee"."last_name", "golden_employee"."status", "golden_employee"."salary", "golden_employee"."department_id" FROM "employees" AS "golden_employee" WHERE ("golden_employee"."department_id" IN (1));
@@ -0,0 +1,2 @@
+-- restheadspec employees (status 200)
+SELECT "golden_employee"."id", "golden_employee"."first_name", "golden_employee"."last_name", "golden_employee"."status", "golden_employee"."salary", "golden_employee"."department_id" FROM "employees" AS "golden_employee" ORDER BY "id" ASC LIMIT 5;
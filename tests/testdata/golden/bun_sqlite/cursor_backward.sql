@@ -0,0 +1,9 @@
+-- restheadspec employees (status 200)
+SELECT count(*) FROM "employees" AS "golden_employee";
+SELECT "golden_employee"."id", "golden_employee"."first_name", "golden_employee"."last_name", "golden_employee"."status", "golden_employee"."salary", "golden_employee"."department_id" FROM "employees" AS "golden_employee" WHERE (EXISTS (
+  SELECT 1
+  FROM employees cursor_select
+  
+  WHERE cursor_select.id = 42 AND ((cursor_select.salary < golden_employee.salary)
+  OR (cursor_select.salary = golden_employee.salary AND cursor_select.id > golden_employee.id))
+)) ORDER BY "salary" DESC, "id" ASC LIMIT 10;
@@ -0,0 +1,3 @@
+-- resolvespec employees (status 200)
+SELECT count(*) FROM "employees" AS "golden_employee" WHERE ((status = 'active' OR (salary > 1000 AND last_name LIKE 'S%')));
+SELECT "golden_employee"."id", "golden_employee"."first_name", "golden_employee"."last_name", "golden_employee"."status", "golden_employee"."salary", "golden_employee"."department_id" FROM "employees" AS "golden_employee" WHERE ((status = 'active' OR (salary > 1000 AND last_name LIKE 'S%')));
@@ -0,0 +1,3 @@
+-- resolvespec departments (status 200)
+SELECT count(*) FROM "departments" AS "golden_department";
+SELECT "golden_department"."id", "golden_department"."name", "golden_department"."code" FROM "departments" AS "golden_department";
@@ -0,0 +1,3 @@
+-- resolvespec employees (status 200)
+SELECT count(*) FROM "employees" AS "golden_employee" WHERE (status = 'active') AND (salary >= 1000);
+SELECT "golden_employee"."id", "golden_employee"."first_name", "golden_employee"."status" FROM "employees" AS "golden_employee" WHERE (status = 'active') AND (salary >= 1000) ORDER BY "last_name" DESC, "id" ASC LIMIT 25 OFFSET 50;
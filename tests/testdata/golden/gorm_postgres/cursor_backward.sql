@@ -0,0 +1,9 @@
+-- restheadspec employees (status 200)
+SELECT count(*) FROM "employees";
+SELECT * FROM "employees" WHERE EXISTS (
+  SELECT 1
+  FROM employees cursor_select
+  
+  WHERE cursor_select.id = 42 AND ((cursor_select.salary < employees.salary)
+  OR (cursor_select.salary = employees.salary AND cursor_select.id > employees.id))
+) ORDER BY salary DESC,id ASC LIMIT 10;
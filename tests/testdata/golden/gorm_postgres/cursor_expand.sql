@@ -0,0 +1,10 @@
+-- restheadspec employees (status 200)
+SELECT count(*) FROM "employees" LEFT JOIN "departments" "Department" ON "employees"."department_id" = "Department"."id";
+SELECT "employees"."id","employees"."first_name","employees"."last_name","employees"."status","employees"."salary","employees"."department_id","Department"."id" AS "Department__id","Department"."name" AS "Department__name","Department"."code" AS "Department__code" FROM "employees" LEFT JOIN "departments" "Department" ON "employees"."department_id" = "Department"."id" WHERE EXISTS (
+  SELECT 1
+  FROM employees cursor_select
+  LEFT JOIN departments cursor_select_Department ON cursor_select_Department.id = cursor_select.department_id
+  WHERE cursor_select.id = 42 AND ((cursor_select_Department.name < "Department".name)
+  OR (cursor_select_Department.name = "Department".name AND cursor_select.salary > employees.salary)
+  OR (cursor_select_Department.name = "Department".name AND cursor_select.salary = employees.salary AND cursor_select.id < employees.id))
+) ORDER BY "Department".name ASC,employees.salary DESC,employees.id ASC LIMIT 10;
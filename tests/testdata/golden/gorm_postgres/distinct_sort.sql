@@ -0,0 +1,3 @@
+-- restheadspec employees (status 200)
+SELECT COUNT(DISTINCT("status")) FROM "employees";
+SELECT DISTINCT "status" FROM "employees" ORDER BY status ASC;
@@ -0,0 +1,3 @@
+-- restheadspec employees (status 200)
+SELECT count(*) FROM "employees";
+SELECT "id","first_name","last_name","department_id" FROM "employees" ORDER BY id ASC;
@@ -0,0 +1,3 @@
+-- restheadspec departments (status 200)
+SELECT count(*) FROM "departments";
+SELECT * FROM "departments" ORDER BY id ASC;
@@ -0,0 +1,3 @@
+-- resolvespec employees (status 200)
+SELECT count(*) FROM "employees" WHERE (status = 'active' OR (salary > 1000 AND last_name LIKE 'S%'));
+SELECT * FROM "employees" WHERE (status = 'active' OR (salary > 1000 AND last_name LIKE 'S%'));
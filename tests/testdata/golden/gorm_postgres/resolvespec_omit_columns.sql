@@ -0,0 +1,3 @@
+-- resolvespec departments (status 200)
+SELECT count(*) FROM "departments";
+SELECT "id","name" FROM "departments";
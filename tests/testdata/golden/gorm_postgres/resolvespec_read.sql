@@ -0,0 +1,3 @@
+-- resolvespec employees (status 200)
+SELECT count(*) FROM "employees" WHERE status = 'active' AND salary >= 1000;
+SELECT "id","first_name","status" FROM "employees" WHERE status = 'active' AND salary >= 1000 ORDER BY last_name DESC,id ASC LIMIT 25 OFFSET 50;
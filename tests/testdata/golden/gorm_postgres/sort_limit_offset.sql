@@ -0,0 +1,3 @@
+-- restheadspec employees (status 200)
+SELECT count(*) FROM "employees";
+SELECT * FROM "employees" ORDER BY last_name DESC,first_name ASC,id ASC LIMIT 10 OFFSET 20;
@@ -0,0 +1,9 @@
+-- restheadspec employees (status 200)
+SELECT count(*) FROM `employees`;
+SELECT * FROM `employees` WHERE EXISTS (
+  SELECT 1
+  FROM employees cursor_select
+  
+  WHERE cursor_select.id = 42 AND ((cursor_select.last_name < employees.last_name)
+  OR (cursor_select.last_name = employees.last_name AND cursor_select.id < employees.id))
+) ORDER BY last_name ASC,id ASC LIMIT 10;
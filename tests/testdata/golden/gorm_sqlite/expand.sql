@@ -0,0 +1,3 @@
+-- restheadspec employees (status 200)
+SELECT count(*) FROM `employees` LEFT JOIN `departments` `Department` ON `employees`.`department_id` = `Department`.`id`;
+SELECT `employees`.`id`,`employees`.`first_name`,`employees`.`last_name`,`employees`.`status`,`employees`.`salary`,`employees`.`department_id`,`Department`.`id` AS `Department__id`,`Department`.`name` AS `Department__name`,`Department`.`code` AS `Department__code` FROM `employees` LEFT JOIN `departments` `Department` ON `employees`.`department_id` = `Department`.`id` ORDER BY employees.id ASC;
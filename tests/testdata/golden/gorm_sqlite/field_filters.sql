@@ -0,0 +1,3 @@
+-- restheadspec employees (status 200)
+SELECT count(*) FROM `employees` WHERE employees.status = "active" AND LOWER(employees.last_name) LIKE LOWER("%smi%") AND employees.salary > 50000 OR employees.department_id = 7;
+SELECT * FROM `employees` WHERE employees.status = "active" AND LOWER(employees.last_name) LIKE LOWER("%smi%") AND employees.salary > 50000 OR employees.department_id = 7 ORDER BY id ASC;
@@ -0,0 +1,3 @@
+-- restheadspec employees (status 200)
+SELECT count(*) FROM `employees` WHERE employees.status = "active" AND ((employees.last_name = "Smith" OR (employees.salary >= 1000 AND CAST(employees.department_id AS TEXT) IN ("1","2"))));
+SELECT * FROM `employees` WHERE employees.status = "active" AND ((employees.last_name = "Smith" OR (employees.salary >= 1000 AND CAST(employees.department_id AS TEXT) IN ("1","2")))) ORDER BY id ASC;
@@ -0,0 +1,3 @@
+-- restheadspec employees (status 200)
+SELECT count(*) FROM `employees`;
+SELECT * FROM `employees` ORDER BY id ASC;
@@ -0,0 +1,3 @@
+-- restheadspec employees (status 200)
+SELECT count(*) FROM `employees` WHERE "id" = "42";
+SELECT * FROM `employees` WHERE "id" = "42" ORDER BY id ASC;
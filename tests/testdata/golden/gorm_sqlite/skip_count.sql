@@ -0,0 +1,2 @@
+-- restheadspec employees (status 200)
+SELECT * FROM `employees` ORDER BY id ASC LIMIT 5;
@@ -0,0 +1,66 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/common/adapters/database"
+)
+
+// TestQueryToSQL renders the statements of every adapter without executing them
+func TestQueryToSQL(t *testing.T) {
+	api := newAPIServer(t, "to_sql",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+		"INSERT INTO sql_notes (title) VALUES ('existing')",
+	)
+
+	gormDB, err := gorm.Open(sqlite.Dialector{Conn: api.DB}, &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	require.NoError(t, err)
+	adapters := map[string]common.Database{
+		"gorm": database.NewGormAdapter(gormDB),
+		"bun":  database.NewBunAdapter(bun.NewDB(api.DB, sqlitedialect.New())),
+		"sql":  database.NewSQLAdapter(api.DB, "sqlite"),
+	}
+
+	for name, db := range adapters {
+		t.Run(name, func(t *testing.T) {
+			query, args, err := db.NewSelect().Model(&[]sqlNote{}).Table("sql_notes").Where("stars > ?", 2).Order("title").Limit(5).ToSQL()
+			require.NoError(t, err)
+			assert.Contains(t, query, "SELECT")
+			assert.Contains(t, query, "stars >")
+			assert.Contains(t, query, "LIMIT 5")
+			if name == "bun" {
+				assert.Contains(t, query, "stars > 2", "bun inlines the parameters")
+			} else {
+				assert.Contains(t, args, 2, "bind parameters are returned separately")
+			}
+
+			query, _, err = db.NewInsert().Model(&sqlNote{Title: "new"}).Table("sql_notes").ToSQL()
+			require.NoError(t, err)
+			assert.Contains(t, query, "INSERT INTO")
+
+			query, _, err = db.NewUpdate().Model(&sqlNote{}).Table("sql_notes").SetMap(map[string]interface{}{"title": "renamed"}).Where("id = ?", 1).ToSQL()
+			require.NoError(t, err)
+			assert.Contains(t, query, "UPDATE")
+
+			query, _, err = db.NewDelete().Model(&sqlNote{}).Table("sql_notes").Where("id = ?", 1).ToSQL()
+			require.NoError(t, err)
+			assert.Contains(t, query, "DELETE FROM")
+
+			var count int
+			require.NoError(t, api.DB.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM sql_notes WHERE title = 'existing'").Scan(&count))
+			assert.Equal(t, 1, count, "ToSQL executes nothing")
+			require.NoError(t, api.DB.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM sql_notes").Scan(&count))
+			assert.Equal(t, 1, count, "ToSQL executes nothing")
+		})
+	}
+}
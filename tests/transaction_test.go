@@ -0,0 +1,150 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+	"github.com/bitechdev/ResolveSpec/pkg/testmodels"
+)
+
+// TestCrossEntityTransaction saves related rows of multiple entities in one transaction
+func TestCrossEntityTransaction(t *testing.T) {
+	db, err := setupStandaloneDB()
+	require.NoError(t, err)
+	defer cleanupStandaloneDB(db)
+
+	resolveSpecHandler, restHeadSpecHandler := setupStandaloneHandlers(db)
+	var hookEntities []string
+	restHeadSpecHandler.Hooks().Register(restheadspec.BeforeCreate, func(hookCtx *restheadspec.HookContext) error {
+		hookEntities = append(hookEntities, hookCtx.Entity)
+		return nil
+	})
+	router := setupStandaloneRouter(resolveSpecHandler, restHeadSpecHandler)
+
+	prefix := fmt.Sprintf("tx_%d", time.Now().UnixNano())
+	id := func(name string) string { return prefix + "_" + name }
+
+	post := func(path string, body interface{}) *httptest.ResponseRecorder {
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("POST", path, bytes.NewReader(payload)))
+		return rec
+	}
+	countEmployees := func(departmentID string) int64 {
+		var count int64
+		require.NoError(t, db.Model(&testmodels.Employee{}).Where("department_id = ?", departmentID).Count(&count).Error)
+		return count
+	}
+
+	t.Run("children reference the parent", func(t *testing.T) {
+		rec := post("/restheadspec/"+restheadspec.TransactionPath, map[string]interface{}{
+			"operations": []interface{}{
+				map[string]interface{}{"entity": "departments", "action": "create", "ref": "dept",
+					"data": map[string]interface{}{"id": id("d1"), "name": "Sales", "code": id("d1")}},
+				map[string]interface{}{"entity": "employees", "action": "create", "ref": "lead",
+					"data": map[string]interface{}{"id": id("e1"), "first_name": "Ada", "email": id("e1") + "@example.com",
+						"department_id": "${dept.id}", "title": "Head of ${dept.name}"}},
+				map[string]interface{}{"entity": "employees", "action": "create",
+					"data": map[string]interface{}{"id": id("e2"), "first_name": "Bob", "email": id("e2") + "@example.com",
+						"department_id": "${0.id}", "manager_id": "${lead.id}"}},
+			},
+		})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var result common.TransactionResult
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.True(t, result.Committed)
+		require.Len(t, result.Results, 3)
+		assert.Equal(t, "dept", result.Results[0].Ref)
+		assert.Equal(t, "employees", result.Results[2].Entity)
+		assert.Equal(t, []string{"departments", "employees", "employees"}, hookEntities)
+
+		var lead, report testmodels.Employee
+		require.NoError(t, db.First(&lead, "id = ?", id("e1")).Error)
+		require.NoError(t, db.First(&report, "id = ?", id("e2")).Error)
+		assert.Equal(t, id("d1"), lead.DepartmentID)
+		assert.Equal(t, "Head of Sales", lead.Title)
+		require.NotNil(t, report.ManagerID)
+		assert.Equal(t, id("e1"), *report.ManagerID)
+	})
+
+	t.Run("failure rolls back every entity", func(t *testing.T) {
+		rec := post("/restheadspec/"+restheadspec.TransactionPath, map[string]interface{}{
+			"operations": []interface{}{
+				map[string]interface{}{"entity": "departments", "action": "create", "ref": "dept",
+					"data": map[string]interface{}{"id": id("d2"), "name": "Support", "code": id("d2")}},
+				map[string]interface{}{"entity": "employees", "action": "create",
+					"data": map[string]interface{}{"id": id("e1"), "department_id": "${dept.id}"}},
+				map[string]interface{}{"entity": "departments", "action": "delete", "id": id("d1")},
+			},
+		})
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		var result common.TransactionResult
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.False(t, result.Committed)
+		assert.Equal(t, "rolled back", result.Results[0].Error)
+		assert.NotEmpty(t, result.Results[1].Error)
+		assert.Equal(t, "not executed", result.Results[2].Error)
+
+		var count int64
+		require.NoError(t, db.Model(&testmodels.Department{}).Where("id = ?", id("d2")).Count(&count).Error)
+		assert.Zero(t, count)
+		assert.Zero(t, countEmployees(id("d2")))
+	})
+
+	t.Run("unknown reference", func(t *testing.T) {
+		rec := post("/restheadspec/"+restheadspec.TransactionPath, map[string]interface{}{
+			"operations": []interface{}{
+				map[string]interface{}{"entity": "employees", "action": "update", "id": "${missing.id}",
+					"data": map[string]interface{}{"title": "x"}},
+			},
+		})
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), `unknown reference \"missing\"`)
+	})
+
+	t.Run("unknown entity", func(t *testing.T) {
+		rec := post("/restheadspec/"+restheadspec.TransactionPath, map[string]interface{}{
+			"operations": []interface{}{
+				map[string]interface{}{"entity": "invoices", "action": "delete", "id": 1},
+			},
+		})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("resolvespec transaction operation", func(t *testing.T) {
+		rec := post("/resolvespec/departments", map[string]interface{}{
+			"operation": "transaction",
+			"data": map[string]interface{}{
+				"operations": []interface{}{
+					map[string]interface{}{"entity": "departments", "action": "create", "ref": "dept",
+						"data": map[string]interface{}{"id": id("d3"), "name": "Research", "code": id("d3")}},
+					map[string]interface{}{"entity": "employees", "action": "create",
+						"data": map[string]interface{}{"id": id("e3"), "email": id("e3") + "@example.com", "department_id": "${dept.id}"}},
+				},
+			},
+		})
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var response struct {
+			Success bool                     `json:"success"`
+			Data    common.TransactionResult `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.True(t, response.Success)
+		assert.True(t, response.Data.Committed)
+		assert.Equal(t, int64(1), countEmployees(id("d3")))
+	})
+}
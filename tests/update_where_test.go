@@ -0,0 +1,79 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUpdateWhere updates the rows matching filters with a row limit or confirmation
+func TestUpdateWhere(t *testing.T) {
+	api := newAPIServer(t, "update_where",
+		"CREATE TABLE sql_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, stars INTEGER NOT NULL DEFAULT 0)",
+		"INSERT INTO sql_notes (title, stars) VALUES ('a', 1), ('b', 2), ('c', 3), ('d', 4)",
+	)
+
+	api.register("sql_notes", sqlNote{})
+	api.serve(common.HandlerConfig{})
+
+	send := api.send
+	titles := func() string {
+		rows, err := api.DB.Query("SELECT title FROM sql_notes ORDER BY id")
+		require.NoError(t, err)
+		defer rows.Close()
+		var list []string
+		for rows.Next() {
+			var title string
+			require.NoError(t, rows.Scan(&title))
+			list = append(list, title)
+		}
+		return strings.Join(list, ",")
+	}
+
+	// resolvespec needs a row limit or write_all
+	rec := send("POST", "/resolvespec/sql_notes", `{"operation":"update_where","data":{"title":"low"},"options":{"filters":[{"column":"stars","operator":"lte","value":2}]}}`, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "unguarded_write")
+
+	rec = send("POST", "/resolvespec/sql_notes", `{"operation":"update_where","data":{"title":"low"},"options":{"filters":[{"column":"stars","operator":"lte","value":2}]},"write_limit":2}`, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Data struct {
+			Updated int `json:"updated"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.Data.Updated)
+	assert.Equal(t, "low,low,c,d", titles())
+
+	// Exceeding the row limit rolls the update back
+	rec = send("POST", "/resolvespec/sql_notes", `{"operation":"update_where","data":{"title":"x"},"options":{"filters":[{"column":"stars","operator":"gt","value":1}]},"write_limit":2}`, nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "row_limit_exceeded")
+	assert.Equal(t, "low,low,c,d", titles())
+
+	// restheadspec updates the rows matching the filter headers
+	rec = send("PATCH", "/restheadspec/sql_notes", `{"title":"high"}`, map[string]string{
+		"X-Searchop-Gte-Stars": "3",
+		"X-Write-All":          "true",
+	})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"updated":2`)
+	assert.Equal(t, "low,low,high,high", titles())
+
+	rec = send("PATCH", "/restheadspec/sql_notes", `{"title":"none"}`, map[string]string{"X-Searchop-Gte-Stars": "3"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "low,low,high,high", titles())
+
+	rec = send("PATCH", "/restheadspec/sql_notes", `{"title":"x"}`, map[string]string{
+		"X-Searchop-Gte-Stars": "1",
+		"X-Write-Limit":        "3",
+	})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "low,low,high,high", titles())
+}
@@ -0,0 +1,107 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+// TestSavedViews saves views of a read per user and shared, and runs them with the view query
+// parameter and X-View
+func TestSavedViews(t *testing.T) {
+	api := newAPIServer(t, "saved_views",
+		"CREATE TABLE owned_tasks (id INTEGER PRIMARY KEY AUTOINCREMENT, owner_id TEXT NOT NULL, title TEXT NOT NULL)",
+		"INSERT INTO owned_tasks (owner_id, title) VALUES ('alice', 'a'), ('bob', 'b'), ('alice', 'c')",
+	)
+
+	api.register("owned_tasks", ownedTask{})
+	views := common.NewTableViewStore(api.Adapter, "")
+	require.NoError(t, views.Migrate(context.Background()))
+	config := common.HandlerConfig{
+		Views: views,
+		AuditUser: func(ctx context.Context) (string, bool) {
+			user, ok := ctx.Value(auditUserKey{}).(string)
+			return user, ok && user != ""
+		},
+	}
+	withUser := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), auditUserKey{}, r.Header.Get("X-User"))))
+		})
+	}
+	router := withUser(api.serve(config).Router)
+
+	send := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	titles := func(rec *httptest.ResponseRecorder) []string {
+		t.Helper()
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var tasks []ownedTask
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tasks))
+		result := make([]string, 0, len(tasks))
+		for _, task := range tasks {
+			result = append(result, task.Title)
+		}
+		return result
+	}
+	alice := map[string]string{"X-User": "alice"}
+
+	rec := send("POST", "/restheadspec/owned_tasks/_views",
+		`{"name":"mine","params":{"X-Searchop-Eq-Owner_id":"alice","X-Sort":"-title"}}`, alice)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var saved common.View
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &saved))
+	assert.Equal(t, "alice", saved.Owner)
+	assert.Equal(t, "-title", saved.Params["x-sort"], "param names are lowercased")
+
+	assert.Equal(t, []string{"c", "a"}, titles(send("GET", "/restheadspec/owned_tasks?view=mine", "", alice)))
+	assert.Equal(t, []string{"c", "a"}, titles(send("GET", "/restheadspec/owned_tasks", "", map[string]string{"X-User": "alice", "X-View": "mine"})))
+	assert.Equal(t, []string{"a", "c"}, titles(send("GET", "/restheadspec/owned_tasks?view=mine", "", map[string]string{"X-User": "alice", "X-Sort": "title"})),
+		"headers of the request take precedence")
+
+	// Views of a user are private unless shared
+	assert.Equal(t, http.StatusNotFound, send("GET", "/restheadspec/owned_tasks?view=mine", "", map[string]string{"X-User": "bob"}).Code)
+	rec = send("POST", "/restheadspec/owned_tasks/_views",
+		`{"name":"mine","shared":true,"params":{"X-Searchop-Eq-Owner_id":"alice","X-Sort":"title"}}`, alice)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, []string{"a", "c"}, titles(send("GET", "/restheadspec/owned_tasks?view=mine", "", map[string]string{"X-User": "bob"})))
+
+	// A view of the user hides a shared view of the same name
+	rec = send("POST", "/restheadspec/owned_tasks/_views", `{"name":"mine","params":{"X-Searchop-Eq-Owner_id":"bob"}}`, map[string]string{"X-User": "bob"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, []string{"b"}, titles(send("GET", "/restheadspec/owned_tasks?view=mine", "", map[string]string{"X-User": "bob"})))
+
+	rec = send("GET", "/restheadspec/owned_tasks/_views", "", map[string]string{"X-User": "bob"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var listed []common.View
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(t, listed, 2)
+	assert.Equal(t, "alice", listed[0].Owner)
+	assert.Equal(t, "bob", listed[1].Owner)
+
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/restheadspec/owned_tasks/_views", `{"name":"../x","params":{"X-Sort":"title"}}`, alice).Code)
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/restheadspec/owned_tasks/_views", `{"name":"x","params":{"Authorization":"secret"}}`, alice).Code)
+	assert.Equal(t, http.StatusNotFound, send("GET", "/restheadspec/owned_tasks?view=missing", "", alice).Code)
+
+	// Only the owner deletes a view
+	assert.Equal(t, http.StatusNotFound, send("DELETE", "/restheadspec/owned_tasks/_views?view=mine", "", map[string]string{"X-User": "carol"}).Code)
+	assert.Equal(t, http.StatusOK, send("DELETE", "/restheadspec/owned_tasks/_views?view=mine", "", alice).Code)
+	assert.Equal(t, []string{"b"}, titles(send("GET", "/restheadspec/owned_tasks?view=mine", "", map[string]string{"X-User": "bob"})))
+	assert.Equal(t, http.StatusNotFound, send("GET", "/restheadspec/owned_tasks?view=mine", "", map[string]string{"X-User": "carol"}).Code)
+}
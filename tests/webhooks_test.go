@@ -0,0 +1,73 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/resolvespec"
+	"github.com/bitechdev/ResolveSpec/pkg/restheadspec"
+	"github.com/bitechdev/ResolveSpec/pkg/webhooks"
+)
+
+// TestWebhooks delivers the committed writes of both APIs to a subscription
+func TestWebhooks(t *testing.T) {
+	api := newAPIServer(t, "webhooks",
+		"CREATE TABLE commit_notes (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL UNIQUE)",
+	)
+
+	received := make(chan webhooks.Event, 4)
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event webhooks.Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received <- event
+	}))
+	defer endpoint.Close()
+
+	dispatcher := webhooks.New(webhooks.Config{Backoff: time.Millisecond})
+	defer dispatcher.Close()
+	_, err := dispatcher.Subscribe(webhooks.Subscription{Entity: "commit_*", URL: endpoint.URL, Secret: "secret", Events: []string{webhooks.EventCreate, webhooks.EventDelete}})
+	require.NoError(t, err)
+
+	api.register("commit_notes", commitNote{})
+	rs := resolvespec.NewHandler(api.Adapter, api.Registry)
+	rh := restheadspec.NewHandler(api.Adapter, api.Registry)
+	require.NoError(t, rs.Use(dispatcher))
+	require.NoError(t, rh.Use(dispatcher))
+	api.serveHandlers(rs, rh)
+
+	send := func(method, path, body string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		api.Router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		require.Less(t, rec.Code, 300, rec.Body.String())
+	}
+	next := func() webhooks.Event {
+		t.Helper()
+		select {
+		case event := <-received:
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("no webhook was delivered")
+			return webhooks.Event{}
+		}
+	}
+
+	send("POST", "/restheadspec/commit_notes", `{"title":"first"}`)
+	event := next()
+	assert.Equal(t, webhooks.EventCreate, event.Type)
+	assert.Equal(t, "commit_notes", event.Entity)
+	assert.Equal(t, "first", event.Data.(map[string]interface{})["title"])
+
+	send("POST", "/resolvespec/commit_notes/1", `{"operation":"update","data":{"title":"renamed"}}`)
+	send("POST", "/resolvespec/commit_notes/1", `{"operation":"delete"}`)
+	event = next()
+	assert.Equal(t, webhooks.EventDelete, event.Type, "updates are not subscribed")
+	assert.Equal(t, "1", event.RecordID)
+}
@@ -0,0 +1,61 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type guardedPost struct {
+	ID        int64  `json:"id" bun:"id,pk,autoincrement"`
+	Title     string `json:"title" bun:"title"`
+	Views     int    `json:"views" bun:"views,scanonly"`
+	CreatedBy string `json:"created_by" bun:"created_by"`
+}
+
+func (guardedPost) TableName() string { return "guarded_posts" }
+
+// TestWriteColumnRules removes read-only columns from writes and rejects protected columns
+// and primary key changes
+func TestWriteColumnRules(t *testing.T) {
+	api := newAPIServer(t, "write_columns",
+		"CREATE TABLE guarded_posts (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, views INTEGER NOT NULL DEFAULT 0, created_by TEXT NOT NULL DEFAULT 'system')",
+		"INSERT INTO guarded_posts (title) VALUES ('first')",
+	)
+
+	api.register("guarded_posts", guardedPost{})
+	config := common.HandlerConfig{ProtectedColumns: []string{"created_by"}}
+	api.serve(config)
+
+	send := api.send
+
+	rec := send("POST", "/restheadspec/guarded_posts", `{"title":"second","views":1000}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "column views is read-only and was ignored", rec.Header().Get(common.WriteWarningsHeader))
+
+	rec = send("POST", "/resolvespec/guarded_posts/1", `{"operation":"update","data":{"title":"renamed","views":5}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Header().Get(common.WriteWarningsHeader), "views")
+
+	rec = send("PUT", "/restheadspec/guarded_posts/1", `{"created_by":"mallory"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	var headError map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &headError))
+	assert.Equal(t, common.ProtectedColumn, headError["_code"])
+	assert.Equal(t, "created_by", headError["_column"])
+
+	rec = send("POST", "/resolvespec/guarded_posts/1", `{"operation":"update","data":{"id":2,"title":"moved"}}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+
+	var views int
+	var title, createdBy string
+	require.NoError(t, api.DB.QueryRow("SELECT title, views, created_by FROM guarded_posts WHERE id = 1").Scan(&title, &views, &createdBy))
+	assert.Equal(t, "renamed", title)
+	assert.Zero(t, views)
+	assert.Equal(t, "system", createdBy)
+}
@@ -0,0 +1,75 @@
+package test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitechdev/ResolveSpec/pkg/common"
+)
+
+type xlsxProduct struct {
+	ID     int64   `json:"id" gorm:"column:id;primaryKey"`
+	Name   string  `json:"name" gorm:"column:name"`
+	Price  float64 `json:"price" gorm:"column:price"`
+	Active bool    `json:"active" gorm:"column:active"`
+}
+
+func (xlsxProduct) TableName() string {
+	return "xlsx_products"
+}
+
+// TestXLSXExport downloads a filtered read as an Excel workbook
+func TestXLSXExport(t *testing.T) {
+	api := newAPIServer(t, "xlsx_export",
+		"CREATE TABLE xlsx_products (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, price REAL NOT NULL, active BOOLEAN NOT NULL)",
+		"INSERT INTO xlsx_products (name, price, active) VALUES ('Lamp', 19.5, 1), ('Desk', 120, 0), ('Chair', 45, 1)",
+	)
+
+	api.register("products", xlsxProduct{})
+	api.serve(common.HandlerConfig{})
+	download := func(headers map[string]string) (*httptest.ResponseRecorder, string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/restheadspec/products", nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		api.Router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+		require.NoError(t, err)
+		file, err := archive.Open("xl/worksheets/sheet1.xml")
+		require.NoError(t, err)
+		defer file.Close()
+		sheet, err := io.ReadAll(file)
+		require.NoError(t, err)
+		return rec, string(sheet)
+	}
+
+	rec, sheet := download(map[string]string{
+		"X-Xlsx":                    "true",
+		"X-Xlsx-Sheet":              "Active products",
+		"X-FieldFilter-Active":      "1",
+		"X-Sort":                    "name",
+		"X-Not-Select-Fields":       "active",
+		"X-Single-Record-As-Object": "true",
+	})
+	assert.Equal(t, common.XLSXContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="Active products.xlsx"`, rec.Header().Get("Content-Disposition"))
+	assert.Contains(t, sheet, `<row r="1"><c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">id</t></is></c><c r="B1" s="1" t="inlineStr"><is><t xml:space="preserve">name</t></is></c><c r="C1" s="1" t="inlineStr"><is><t xml:space="preserve">price</t></is></c></row>`)
+	assert.Contains(t, sheet, `<row r="2"><c r="A2"><v>3</v></c><c r="B2" t="inlineStr"><is><t xml:space="preserve">Chair</t></is></c><c r="C2"><v>45</v></c></row>`)
+	assert.Contains(t, sheet, `<c r="C3"><v>19.5</v></c>`)
+	assert.NotContains(t, sheet, `<row r="4">`)
+
+	rec, sheet = download(map[string]string{"Accept": common.XLSXContentType})
+	assert.Equal(t, `attachment; filename="products.xlsx"`, rec.Header().Get("Content-Disposition"))
+	assert.Contains(t, sheet, `<c r="D3" t="b"><v>0</v></c>`)
+}